@@ -0,0 +1,82 @@
+package analytics
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const dayLayout = "2006-01-02"
+
+// Hashes ip and user agent with a random salt that changes every UTC day.
+// The salt only lives in memory, hashes from different days can't be linked,
+// and a restart starts a new salt (visitors of that day might be counted twice).
+type VisitorHasher struct {
+	mu   sync.Mutex
+	day  string
+	salt []byte
+}
+
+func NewVisitorHasher() *VisitorHasher {
+	return &VisitorHasher{}
+}
+
+// Returns the UTC day of t (YYYY-MM-DD) and the visitor hash for that day
+func (v *VisitorHasher) Hash(t time.Time, ip, userAgent string) (string, string, error) {
+	day := Day(t)
+
+	salt, err := v.saltOf(day)
+	if err != nil {
+		return "", "", fmt.Errorf("Hash: %w", err)
+	}
+
+	hasher := sha256.New()
+	hasher.Write(salt)
+	hasher.Write([]byte(ip))
+	hasher.Write([]byte{0})
+	hasher.Write([]byte(userAgent))
+	return day, hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+func (v *VisitorHasher) saltOf(day string) ([]byte, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.day != day {
+		salt := make([]byte, 32)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, fmt.Errorf("saltOf: generate salt failed: %w", err)
+		}
+		v.day, v.salt = day, salt
+	}
+	return v.salt, nil
+}
+
+// UTC day of t, ex: 2024-06-02
+func Day(t time.Time) string {
+	return t.UTC().Format(dayLayout)
+}
+
+// Parse a day in YYYY-MM-DD
+func ParseDay(day string) (time.Time, error) {
+	return time.Parse(dayLayout, day)
+}
+
+// Host of a http(s) referrer without port and leading "www.",
+// returns empty string for anything else.
+func ReferrerHost(referrer string) string {
+	parsed, err := url.Parse(strings.TrimSpace(referrer))
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return ""
+	}
+	host := strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+	if len(host) > 253 {
+		return ""
+	}
+	return host
+}
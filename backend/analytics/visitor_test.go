@@ -0,0 +1,52 @@
+package analytics_test
+
+import (
+	"blog/analytics"
+	"testing"
+	"time"
+)
+
+func TestVisitorHash(t *testing.T) {
+	hasher := analytics.NewVisitorHasher()
+	morning := time.Date(2024, 6, 2, 1, 0, 0, 0, time.UTC)
+	evening := time.Date(2024, 6, 2, 23, 0, 0, 0, time.UTC)
+	nextDay := time.Date(2024, 6, 3, 1, 0, 0, 0, time.UTC)
+
+	day, hash1, err := hasher.Hash(morning, "1.2.3.4", "firefox")
+	if err != nil {
+		t.Fatalf("TestVisitorHash: hash failed: %s", err)
+	}
+	if day != "2024-06-02" {
+		t.Fatalf("TestVisitorHash: unexpected day %q", day)
+	}
+
+	_, hash2, _ := hasher.Hash(evening, "1.2.3.4", "firefox")
+	if hash1 != hash2 {
+		t.Fatalf("TestVisitorHash: same visitor on the same day should have the same hash")
+	}
+
+	_, hash3, _ := hasher.Hash(evening, "1.2.3.4", "chrome")
+	if hash1 == hash3 {
+		t.Fatalf("TestVisitorHash: different user agents should have different hashes")
+	}
+
+	_, hash4, _ := hasher.Hash(nextDay, "1.2.3.4", "firefox")
+	if hash1 == hash4 {
+		t.Fatalf("TestVisitorHash: hash should change on the next day")
+	}
+}
+
+func TestReferrerHost(t *testing.T) {
+	cases := map[string]string{
+		"https://www.Google.com/search?q=go": "google.com",
+		"http://news.ycombinator.com:80/":    "news.ycombinator.com",
+		"":                                   "",
+		"android-app://com.slack":            "",
+		"javascript:alert(1)":                "",
+	}
+	for referrer, expected := range cases {
+		if host := analytics.ReferrerHost(referrer); host != expected {
+			t.Fatalf("TestReferrerHost: %q should be %q, got %q", referrer, expected, host)
+		}
+	}
+}
@@ -7,6 +7,8 @@ import (
 
 type authHelper interface {
 	Verify(r *http.Request) (bool, error)
+	// Name of the user, for recording who made a change. Only call after Verify.
+	UserName(r *http.Request) (string, error)
 }
 
 type AuthHelper struct {
@@ -47,3 +49,11 @@ func (a *AuthHelper) Verify(r *http.Request) (bool, error) {
 
 	return true, nil
 }
+
+func (a *AuthHelper) UserName(r *http.Request) (string, error) {
+	user, err := a.repo.Get(r.Context())
+	if err != nil {
+		return "", fmt.Errorf("UserName: get user failed: %w", err)
+	}
+	return user.Name, nil
+}
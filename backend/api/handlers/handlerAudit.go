@@ -0,0 +1,345 @@
+package handlers
+
+import (
+	"blog/config"
+	"blog/entities"
+	"blog/util"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrorInvalidAuditTime   = errors.New("from and to should be ISO 8601 times, ex: 2024-06-01T00:00:00Z")
+	ErrorInvalidAuditTarget = errors.New("target should be a type or type:id, ex: blog or blog:1")
+	ErrorInvalidAuditBefore = errors.New("before should be the id of an audit entry")
+)
+
+const (
+	defaultAuditLimit = 50
+	maxAuditLimit     = 200
+)
+
+// values of these fields are never written to the audit log
+var auditRedactedFields = []string{"secret", "jwt", "token", "password"}
+
+// Concrete implementations are at repository/<name>
+type auditRepository interface {
+	Create(ctx context.Context, entry entities.AuditEntry) (*entities.AuditEntry, error)
+	List(ctx context.Context, filter entities.AuditFilter) ([]entities.AuditEntry, error)
+}
+
+// Implemented by JWTHelper
+type auditJWT interface {
+	Subject(token string) (string, error)
+}
+
+// Current state of an audit target, returns sql.ErrNoRows if it doesn't exist
+type AuditSnapshot func(ctx context.Context, id string) (any, error)
+
+type Audit struct {
+	repo   auditRepository
+	jwt    auditJWT
+	auth   authHelper
+	config config.ServerSetting
+}
+
+func NewAudit(repo auditRepository, jwt auditJWT, auth authHelper, config config.ServerSetting) *Audit {
+	return &Audit{
+		repo:   repo,
+		jwt:    jwt,
+		auth:   auth,
+		config: config,
+	}
+}
+
+/*
+Record is a middleware writing an audit entry for authenticated POST, PUT, PATCH and DELETE requests that succeeded.
+
+The target id is read from the idParam path value, or from "id" / "hash" of the response when created.
+snapshot is called before and after the request for the diff, without it the response is used as the state after.
+*/
+func (a *Audit) Record(targetType, idParam string, snapshot AuditSnapshot) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if !slices.Contains([]string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}, r.Method) {
+				next(w, r)
+				return
+			}
+
+			// not authenticated, the handler rejects it
+			authHeader := r.Header.Get("Authorization")
+			if len(authHeader) <= len("Bearer ") {
+				next(w, r)
+				return
+			}
+			actor, err := a.jwt.Subject(readToken(authHeader))
+			if err != nil {
+				next(w, r)
+				return
+			}
+
+			targetID := ""
+			if idParam != "" {
+				targetID = r.PathValue(idParam)
+			}
+
+			var before any
+			if snapshot != nil && targetID != "" {
+				before = a.snapshot(r.Context(), snapshot, targetID)
+			}
+
+			recorder := &responseRecorder{ResponseWriter: w}
+			next(recorder, r)
+			if recorder.status < 200 || recorder.status >= 300 {
+				return
+			}
+
+			// the change is done, record it even if the client went away
+			ctx := context.WithoutCancel(r.Context())
+
+			response := auditResponse{}
+			if err := json.Unmarshal(recorder.body.Bytes(), &response); err != nil {
+				slog.Warn("Record: decode response failed", "error", err)
+			}
+			if targetID == "" {
+				targetID = response.targetID()
+			}
+
+			var after any = response.Msg
+			if snapshot != nil && targetID != "" {
+				after = a.snapshot(ctx, snapshot, targetID)
+			}
+
+			diff, err := auditDiff(before, after)
+			if err != nil {
+				slog.Error("Record: diff failed", "error", err)
+				diff = json.RawMessage("{}")
+			}
+
+			entry := entities.NewAuditEntry(
+				actor,
+				r.Method+" "+r.URL.Path,
+				targetType,
+				targetID,
+				diff,
+				util.ClientIP(r, a.config.ClientIPHeader),
+				util.RequestID(r.Context()),
+			)
+			if _, err := a.repo.Create(ctx, *entry); err != nil {
+				slog.Error("Record: create audit entry failed", "error", err, "route", entry.Route, "request_id", entry.RequestID)
+			}
+		}
+	}
+}
+
+// nil if the target doesn't exist or can't be read
+func (a *Audit) snapshot(ctx context.Context, snapshot AuditSnapshot, id string) any {
+	state, err := snapshot(ctx, id)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			slog.Error("snapshot: get audit target failed", "id", id, "error", err)
+		}
+		return nil
+	}
+	return state
+}
+
+// ListAuditLog
+//
+//	@Summary		List audit log
+//	@Description	changes made through the api, newest first.
+//	@Description	pass the id of the last entry as 'before' to get the next page.
+//	@Tags			audit
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string	true	"jwt token"
+//	@Param			actor			query		string	false	"user name"
+//	@Param			target			query		string	false	"target type, optionally with id. ex: blog or blog:1"
+//	@Param			from			query		string	false	"ISO 8601, inclusive. ex: 2024-06-01T00:00:00Z"
+//	@Param			to				query		string	false	"ISO 8601, inclusive. ex: 2024-06-30T23:59:59Z"
+//	@Param			before			query		int		false	"only entries with a smaller id"
+//	@Param			limit			query		int		false	"at most 200"	default(50)
+//	@Success		200				{object}	entities.RetSuccess[[]entities.AuditEntry]
+//	@Failure		400				{object}	entities.RetFailed
+//	@Failure		403				{object}	entities.RetFailed
+//	@Failure		500				{object}	entities.RetFailed
+//	@Router			/audit [get]
+func (a *Audit) ListAuditLog(w http.ResponseWriter, r *http.Request) error {
+	slog.Debug("ListAuditLog")
+
+	// authorization
+	authorized, err := a.auth.Verify(r)
+	if err != nil || !authorized {
+		slog.Warn("ListAuditLog: authorization failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusForbidden).WriteJSON(w)
+	}
+
+	// parse query params
+	queries := r.URL.Query()
+	slog.Debug("got queries", "queries", queries)
+
+	filter := entities.AuditFilter{
+		Actor: queries.Get("actor"),
+		Limit: defaultAuditLimit,
+	}
+
+	if target := queries.Get("target"); target != "" {
+		targetType, targetID, _ := strings.Cut(target, ":")
+		if targetType == "" {
+			return entities.NewRetFailed(ErrorInvalidAuditTarget, http.StatusBadRequest).WriteJSON(w)
+		}
+		filter.TargetType = targetType
+		filter.TargetID = targetID
+	}
+
+	for _, bound := range []struct {
+		name  string
+		value *string
+	}{{"from", &filter.From}, {"to", &filter.To}} {
+		raw := queries.Get(bound.name)
+		if raw == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			slog.Error("ListAuditLog: parse time failed", "name", bound.name, "error", err)
+			return entities.NewRetFailed(ErrorInvalidAuditTime, http.StatusBadRequest).WriteJSON(w)
+		}
+		*bound.value = parsed.UTC().Format("2006-01-02T15:04:05-07:00")
+	}
+
+	if rawBefore := queries.Get("before"); rawBefore != "" {
+		before, err := strconv.Atoi(rawBefore)
+		if err != nil || before < 1 {
+			return entities.NewRetFailed(ErrorInvalidAuditBefore, http.StatusBadRequest).WriteJSON(w)
+		}
+		filter.Before = before
+	}
+
+	if rawLimit := queries.Get("limit"); rawLimit != "" {
+		limit, err := strconv.Atoi(rawLimit)
+		if err != nil || limit < 1 || limit > maxAuditLimit {
+			return entities.NewRetFailed(ErrorInvalidLimit, http.StatusBadRequest).WriteJSON(w)
+		}
+		filter.Limit = limit
+	}
+
+	entries, err := a.repo.List(r.Context(), filter)
+	if err != nil {
+		slog.Error("ListAuditLog: repo list failed", "error", err)
+
+		if sqliteErr, ok := getSQLiteError(err); ok {
+			slog.Error("got sqlite error", "error code", sqliteErr.Code, "extended error code", sqliteErr.ExtendedCode)
+			return entities.NewRetFailedCustom(err, int(sqliteErr.ExtendedCode), http.StatusInternalServerError).WriteJSON(w)
+		}
+
+		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+	}
+
+	return entities.NewRetSuccess(entries).WriteJSON(w)
+}
+
+// entities.RetSuccess without the type parameter
+type auditResponse struct {
+	Msg json.RawMessage `json:"msg"`
+}
+
+// id of a created target, empty if the response isn't a single target
+func (a auditResponse) targetID() string {
+	target := struct {
+		ID   json.Number `json:"id"`
+		Hash string      `json:"hash"`
+	}{}
+	if err := json.Unmarshal(a.Msg, &target); err != nil {
+		return ""
+	}
+	if target.ID != "" && target.ID != "0" {
+		return target.ID.String()
+	}
+	return target.Hash
+}
+
+/*
+Top level fields that changed between before and after, as {"field": [before, after]}.
+Values that aren't json objects are compared as a whole under "value".
+*/
+func auditDiff(before, after any) (json.RawMessage, error) {
+	beforeFields, beforeValue, err := auditFields(before)
+	if err != nil {
+		return nil, fmt.Errorf("auditDiff: before: %w", err)
+	}
+	afterFields, afterValue, err := auditFields(after)
+	if err != nil {
+		return nil, fmt.Errorf("auditDiff: after: %w", err)
+	}
+
+	diff := map[string][2]any{}
+	if beforeFields == nil && afterFields == nil {
+		if !reflect.DeepEqual(beforeValue, afterValue) {
+			diff["value"] = [2]any{beforeValue, afterValue}
+		}
+	} else {
+		for _, fields := range []map[string]any{beforeFields, afterFields} {
+			for key := range fields {
+				if _, ok := diff[key]; ok || reflect.DeepEqual(beforeFields[key], afterFields[key]) {
+					continue
+				}
+				diff[key] = [2]any{beforeFields[key], afterFields[key]}
+			}
+		}
+	}
+
+	for key, change := range diff {
+		if slices.Contains(auditRedactedFields, strings.ToLower(key)) {
+			for i := range change {
+				if change[i] != nil && change[i] != "" {
+					change[i] = "[redacted]"
+				}
+			}
+			diff[key] = change
+		}
+	}
+
+	raw, err := json.Marshal(diff)
+	if err != nil {
+		return nil, fmt.Errorf("auditDiff: marshal failed: %w", err)
+	}
+	return raw, nil
+}
+
+// Fields if the value is a json object, the decoded value otherwise
+func auditFields(value any) (map[string]any, any, error) {
+	if value == nil {
+		return nil, nil, nil
+	}
+	raw, ok := value.(json.RawMessage)
+	if !ok {
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("auditFields: marshal failed: %w", err)
+		}
+		raw = encoded
+	}
+	if len(raw) == 0 {
+		return nil, nil, nil
+	}
+
+	var decoded any
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return nil, nil, fmt.Errorf("auditFields: unmarshal failed: %w", err)
+	}
+	if fields, ok := decoded.(map[string]any); ok {
+		return fields, nil, nil
+	}
+	return nil, decoded, nil
+}
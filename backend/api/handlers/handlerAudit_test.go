@@ -0,0 +1,155 @@
+package handlers_test
+
+import (
+	"blog/api/handlers"
+	"blog/config"
+	"blog/entities"
+	"blog/util"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type DummyAuditRepo struct {
+	entries []entities.AuditEntry
+	filter  entities.AuditFilter
+}
+
+func (d *DummyAuditRepo) Create(ctx context.Context, entry entities.AuditEntry) (*entities.AuditEntry, error) {
+	d.entries = append(d.entries, entry)
+	return &entry, nil
+}
+func (d *DummyAuditRepo) List(ctx context.Context, filter entities.AuditFilter) ([]entities.AuditEntry, error) {
+	d.filter = filter
+	return []entities.AuditEntry{}, nil
+}
+
+type DummyAuditJWT struct{}
+
+func (d *DummyAuditJWT) Subject(token string) (string, error) {
+	if token != "valid" {
+		return "", errors.New("Subject: invalid token")
+	}
+	return "alex", nil
+}
+
+func initAudit() (*handlers.Audit, *DummyAuditRepo) {
+	repo := &DummyAuditRepo{}
+	return handlers.NewAudit(repo, &DummyAuditJWT{}, &DummyAuthHelper{}, config.NewConfig().Server), repo
+}
+
+func TestHandlerAuditRecord(t *testing.T) {
+	audit, repo := initAudit()
+
+	webhooks := map[string]entities.Webhook{
+		"1": {ID: 1, URL: "http://old", Secret: "old secret"},
+	}
+	snapshot := func(ctx context.Context, id string) (any, error) {
+		webhook, ok := webhooks[id]
+		if !ok {
+			return nil, sql.ErrNoRows
+		}
+		return webhook, nil
+	}
+	update := func(w http.ResponseWriter, r *http.Request) {
+		webhooks["1"] = entities.Webhook{ID: 1, URL: "http://new", Secret: "new secret"}
+		entities.NewRetSuccess(webhooks["1"]).WriteJSON(w)
+	}
+	create := func(w http.ResponseWriter, r *http.Request) {
+		webhooks["2"] = entities.Webhook{ID: 2, URL: "http://created"}
+		entities.NewRetSuccess(webhooks["2"]).WriteJSON(w)
+	}
+	fail := func(w http.ResponseWriter, r *http.Request) {
+		entities.NewRetFailed(errors.New("failed"), http.StatusBadRequest).WriteJSON(w)
+	}
+	record := audit.Record("webhook", "id", snapshot)
+
+	newRequest := func(method, token string) *http.Request {
+		r := httptest.NewRequest(method, "/api/v1/webhooks/1", nil)
+		r.SetPathValue("id", "1")
+		r.RemoteAddr = "10.0.0.1:1234"
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		return r.WithContext(util.WithRequestID(r.Context(), "request-1"))
+	}
+
+	// update with diff
+	record(update)(httptest.NewRecorder(), newRequest(http.MethodPut, "valid"))
+	if len(repo.entries) != 1 {
+		t.Fatalf("TestHandlerAuditRecord: should record the update, got %d entries", len(repo.entries))
+	}
+	entry := repo.entries[0]
+	if entry.Actor != "alex" || entry.Route != "PUT /api/v1/webhooks/1" || entry.TargetType != "webhook" || entry.TargetID != "1" ||
+		entry.ClientIP != "10.0.0.1" || entry.RequestID != "request-1" {
+		t.Fatalf("TestHandlerAuditRecord: unexpected entry %+v", entry)
+	}
+	diff := map[string][2]any{}
+	if err := json.Unmarshal(entry.Diff, &diff); err != nil {
+		t.Fatalf("TestHandlerAuditRecord: decode diff failed: %s", err)
+	}
+	if len(diff) != 2 || diff["url"] != [2]any{"http://old", "http://new"} {
+		t.Fatalf("TestHandlerAuditRecord: diff should only have changed fields, got %s", entry.Diff)
+	}
+	if diff["secret"] != [2]any{"[redacted]", "[redacted]"} {
+		t.Fatalf("TestHandlerAuditRecord: secrets should be redacted, got %s", entry.Diff)
+	}
+
+	// created, id from the response
+	r := newRequest(http.MethodPost, "valid")
+	r.SetPathValue("id", "")
+	record(create)(httptest.NewRecorder(), r)
+	if len(repo.entries) != 2 || repo.entries[1].TargetID != "2" {
+		t.Fatalf("TestHandlerAuditRecord: created target id should come from the response, got %+v", repo.entries)
+	}
+	diff = map[string][2]any{}
+	if err := json.Unmarshal(repo.entries[1].Diff, &diff); err != nil {
+		t.Fatalf("TestHandlerAuditRecord: decode diff failed: %s", err)
+	}
+	if diff["url"] != [2]any{nil, "http://created"} {
+		t.Fatalf("TestHandlerAuditRecord: created fields should have null before, got %s", repo.entries[1].Diff)
+	}
+
+	// not recorded: failed, not authenticated, reads
+	record(fail)(httptest.NewRecorder(), newRequest(http.MethodDelete, "valid"))
+	record(update)(httptest.NewRecorder(), newRequest(http.MethodPut, ""))
+	record(update)(httptest.NewRecorder(), newRequest(http.MethodPut, "forged"))
+	record(update)(httptest.NewRecorder(), newRequest(http.MethodGet, "valid"))
+	if len(repo.entries) != 2 {
+		t.Fatalf("TestHandlerAuditRecord: only successful authenticated changes should be recorded, got %d entries", len(repo.entries))
+	}
+}
+
+func TestHandlerAuditList(t *testing.T) {
+	audit, repo := initAudit()
+
+	r := httptest.NewRequest(http.MethodGet, "/audit?actor=alex&target=blog:3&from=2024-06-01T08:00:00%2B08:00&before=20", nil)
+	r.Header.Set("Authorization", "Bearer valid")
+	w := httptest.NewRecorder()
+	if err := audit.ListAuditLog(w, r); err != nil {
+		t.Fatalf("TestHandlerAuditList: list failed: %s", err)
+	}
+	if w.Code != http.StatusOK {
+		t.Fatalf("TestHandlerAuditList: should succeed, got %d", w.Code)
+	}
+	want := entities.AuditFilter{Actor: "alex", TargetType: "blog", TargetID: "3", From: "2024-06-01T00:00:00+00:00", Before: 20, Limit: 50}
+	if repo.filter != want {
+		t.Fatalf("TestHandlerAuditList: unexpected filter %+v", repo.filter)
+	}
+
+	for _, query := range []string{"from=yesterday", "target=:3", "limit=500", "before=-1"} {
+		r := httptest.NewRequest(http.MethodGet, "/audit?"+query, nil)
+		r.Header.Set("Authorization", "Bearer valid")
+		w := httptest.NewRecorder()
+		if err := audit.ListAuditLog(w, r); err != nil {
+			t.Fatalf("TestHandlerAuditList: list failed: %s", err)
+		}
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("TestHandlerAuditList: %s should be rejected, got %d", query, w.Code)
+		}
+	}
+}
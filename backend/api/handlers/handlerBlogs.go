@@ -2,28 +2,37 @@ package handlers
 
 import (
 	"blog/entities"
+	"blog/markdown"
 	"bytes"
 	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
+	"slices"
 	"strconv"
+	"time"
 
-	"github.com/yuin/goldmark"
-	highlighting "github.com/yuin/goldmark-highlighting/v2"
 	"github.com/yuin/goldmark/parser"
-	"github.com/yuin/goldmark/renderer/html"
+)
+
+var (
+	ErrorInvalidBlogStatus  = errors.New("status should be one of: draft, in_review, scheduled, published, archived, new blogs can't be scheduled")
+	ErrorInvalidScheduledAt = errors.New("scheduledAt should be an ISO 8601 time in the future")
 )
 
 // Concrete implementations are at repository/<name>
 type blogsRepository interface {
 	Create(ctx context.Context, blog entities.InBlog) (*entities.OutBlog, error)
 	CreateWithID(ctx context.Context, blog entities.InBlog, id int) (*entities.OutBlog, error)
-	Update(ctx context.Context, blog entities.InBlog, id int) (*entities.OutBlog, error)
+	// ifMatch is the If-Match header, entities.ErrorPreconditionFailed is returned if it doesn't match
+	Update(ctx context.Context, blog entities.InBlog, id int, ifMatch string) (*entities.OutBlog, error)
+	Patch(ctx context.Context, patch entities.BlogPatch, id int, ifMatch string) (*entities.OutBlog, error)
 
-	// This group of functions will only return rows with 'visible=true' and 'deleted_at=""'
+	// This group of functions will only return rows with 'status=published' and 'deleted_at=""'
 	Get(ctx context.Context, id int) (*entities.OutBlog, error)
 	List(ctx context.Context) ([]entities.OutBlog, error)
 	ListByTopicIDs(ctx context.Context, topicID []int) ([]entities.OutBlog, error)
@@ -36,22 +45,43 @@ type blogsRepository interface {
 	AdminListByTopicIDs(ctx context.Context, topicID []int) ([]entities.OutBlog, error)
 	AdminListByTopicAndTagIDs(ctx context.Context, topicID, tagID []int) ([]entities.OutBlog, error)
 
-	SoftDelete(ctx context.Context, id int) (int, error)
+	SoftDelete(ctx context.Context, id int, deletedBy, ifMatch string) (int, error)
 	// blogs need to be soft deleted first to be deleted
-	Delete(ctx context.Context, id int) (int, error)
-	DeleteNow(ctx context.Context, id int) (int, error)
+	Delete(ctx context.Context, id int, ifMatch string) (int, error)
+	DeleteNow(ctx context.Context, id int, ifMatch string) (int, error)
 	RestoreDeleted(ctx context.Context, id int) (*entities.OutBlog, error)
+	// Blogs in the trash, most recently deleted first
+	ListDeleted(ctx context.Context) ([]entities.OutBlog, error)
+	// Deletes blogs soft deleted at or before the given time, dryRun only lists them
+	PurgeDeleted(ctx context.Context, before time.Time, dryRun bool) ([]entities.Blog, error)
+
+	// Returns entities.ErrorInvalidStatusTransition if not allowed,
+	// sql.ErrNoRows if the blog doesn't exist or is soft deleted
+	Transition(ctx context.Context, id int, status, scheduledAt, actor, note string) (*entities.OutBlog, error)
+	// Returns sql.ErrNoRows if the blog doesn't exist
+	ListStatusEvents(ctx context.Context, id int) ([]entities.BlogStatusEvent, error)
+	// hash -> width of the uploaded images the blog links to
+	MediaWidths(ctx context.Context, id int) (map[string]int, error)
+}
+
+// Concrete implementation is at markdown/
+type markdownConverter interface {
+	Convert(source []byte, writer io.Writer, opts ...parser.ParseOption) error
 }
 
 type Blogs struct {
-	repo blogsRepository
-	auth authHelper
+	repo    blogsRepository
+	auth    authHelper
+	md      markdownConverter
+	preview previewHelper
 }
 
-func NewBlogs(repo blogsRepository, auth authHelper) *Blogs {
+func NewBlogs(repo blogsRepository, auth authHelper, md markdownConverter, preview previewHelper) *Blogs {
 	return &Blogs{
-		repo: repo,
-		auth: auth,
+		repo:    repo,
+		auth:    auth,
+		md:      md,
+		preview: preview,
 	}
 }
 
@@ -64,9 +94,12 @@ func NewBlogs(repo blogsRepository, auth authHelper) *Blogs {
 //	@Produce		json
 //	@Param			blog			body		entities.ReqInBlog	true	"new blog contents"
 //	@Param			Authorization	header		string				true	"jwt token"
+//	@Param			Idempotency-Key	header		string				false	"unique key of the request, retries with the same key and body replay the first response"
 //	@Success		200				{object}	entities.RetSuccess[entities.OutBlog]
 //	@Failure		400				{object}	entities.RetFailed
 //	@Failure		403				{object}	entities.RetFailed
+//	@Failure		409				{object}	entities.RetFailed
+//	@Failure		422				{object}	entities.RetFailed
 //	@Failure		500				{object}	entities.RetFailed
 //	@Router			/blogs [post]
 func (b *Blogs) CreateBlog(w http.ResponseWriter, r *http.Request) error {
@@ -91,11 +124,20 @@ func (b *Blogs) CreateBlog(w http.ResponseWriter, r *http.Request) error {
 		body.Pined,
 		body.Visible,
 	)
+	if err := setInitialStatus(blog, body.Status); err != nil {
+		return entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
+	}
 	inBlog := entities.NewInBlog(
 		*blog,
 		body.Tags,
 		body.Topics,
 	)
+	inBlog.Series, inBlog.Part = body.Series, body.Part
+	inBlog.Actor, err = b.auth.UserName(r)
+	if err != nil {
+		slog.Error("CreateBlog: get user name failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+	}
 
 	outBlog, err := b.repo.Create(r.Context(), *inBlog)
 	if err != nil {
@@ -124,6 +166,7 @@ func (b *Blogs) CreateBlog(w http.ResponseWriter, r *http.Request) error {
 //	@Param			simple			query		bool	false	"output blog with tags and topics as slugs, not as a full struct"																			default(false)
 //	@Param			topic			query		[]int	false	"filter by topic ids, return blogs that have relation with all specified topics. ex: ?topic=1&topic=2"										collectionFormat(multi)
 //	@Param			tag				query		[]int	false	"filter by tag ids, return blogs that have relation with all specified tags, CAN ONLY BE USED IN COMBINATION WITH TOPIC. ex: ?tag=1&tag=2"	collectionFormat(multi)
+//	@Param			status			query		[]string	false	"filter by status, only used with all=true. ex: ?status=draft&status=in_review"	collectionFormat(multi)	Enums(draft, in_review, scheduled, published, archived)
 //	@Success		200				{object}	entities.RetSuccess[[]entities.OutBlog]
 //	@Success		200				{object}	entities.RetSuccess[[]entities.OutBlogSimple]
 //	@Failure		400				{object}	entities.RetFailed
@@ -175,6 +218,15 @@ func (b *Blogs) ListBlogs(w http.ResponseWriter, r *http.Request) error {
 	}
 	tagIDs = removeDuplicate(tagIDs)
 
+	statuses := removeDuplicate(queries["status"])
+	for _, status := range statuses {
+		if !slices.Contains(entities.BlogStatuses, status) {
+			slog.Error("ListBlogs: invalid 'status'", "status", status)
+			return entities.NewRetFailed(ErrorInvalidBlogStatus, http.StatusBadRequest).WriteJSON(w)
+		}
+	}
+	notInStatus := func(blog entities.OutBlog) bool { return !blog.InStatus(statuses) }
+
 	// admin list
 	if all[0] {
 		// authorization
@@ -200,7 +252,7 @@ func (b *Blogs) ListBlogs(w http.ResponseWriter, r *http.Request) error {
 
 				return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
 			}
-			return entities.NewRetSuccess(blogs).WriteJSON(w)
+			return entities.NewRetSuccess(slices.DeleteFunc(blogs, notInStatus)).WriteJSON(w)
 		}
 
 		// admin list by topic ids
@@ -219,7 +271,7 @@ func (b *Blogs) ListBlogs(w http.ResponseWriter, r *http.Request) error {
 
 				return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
 			}
-			return entities.NewRetSuccess(blogs).WriteJSON(w)
+			return entities.NewRetSuccess(slices.DeleteFunc(blogs, notInStatus)).WriteJSON(w)
 		}
 
 		// admin list
@@ -238,7 +290,7 @@ func (b *Blogs) ListBlogs(w http.ResponseWriter, r *http.Request) error {
 
 				return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
 			}
-			return entities.NewRetSuccess(blogs).WriteJSON(w)
+			return entities.NewRetSuccess(slices.DeleteFunc(blogs, func(blog entities.OutBlogSimple) bool { return !blog.InStatus(statuses) })).WriteJSON(w)
 		}
 
 		blogs, err := b.repo.AdminList(r.Context())
@@ -255,7 +307,7 @@ func (b *Blogs) ListBlogs(w http.ResponseWriter, r *http.Request) error {
 
 			return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
 		}
-		return entities.NewRetSuccess(blogs).WriteJSON(w)
+		return entities.NewRetSuccess(slices.DeleteFunc(blogs, notInStatus)).WriteJSON(w)
 	}
 
 	// normal list blogs, only list blogs that are visible and not soft deleted
@@ -320,13 +372,18 @@ func (b *Blogs) ListBlogs(w http.ResponseWriter, r *http.Request) error {
 //	@Accept			json
 //	@Produce		json
 //	@Param			id				path		int		true	"target blog id"
+//	@Param			If-None-Match	header		string	false	"ETag from a previous GET, 304 if the blog hasn't changed"
 //	@Param			Authorization	header		string	false	"jwt token"
 //	@Param			all				query		bool	false	"show all blogs regardless of visibility or soft delete status"	default(false)
 //	@Param			parsed		query		bool  false "parse markdown to html before returning"
+//	@Param			preview			query		string	false	"preview link token, reads the blog regardless of visibility without logging in"
 //	@Success		200				{object}	entities.RetSuccess[entities.OutBlog]
+//	@Success		304				"not modified"
 //	@Failure		400				{object}	entities.RetFailed
 //	@Failure		403				{object}	entities.RetFailed
+//	@Failure		404				{object}	entities.RetFailed
 //	@Failure		500				{object}	entities.RetFailed
+//	@Header			200				{string}	ETag	"version of the blog"
 //	@Router			/blogs/{id} [get]
 func (b *Blogs) GetBlog(w http.ResponseWriter, r *http.Request) error {
 	slog.Debug("GetBlog")
@@ -356,28 +413,29 @@ func (b *Blogs) GetBlog(w http.ResponseWriter, r *http.Request) error {
 		return entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
 	}
 
-	mdParser := goldmark.New(
-		goldmark.WithRendererOptions(
-			html.WithUnsafe(),
-		),
-		goldmark.WithExtensions(
-			highlighting.NewHighlighting(
-				highlighting.WithStyle("gruvbox"),
-			),
-		),
-		goldmark.WithParserOptions(
-			parser.WithAutoHeadingID(),
-		),
-	)
+	// draft preview with a preview link token
+	preview := queries.Get("preview")
+	if preview != "" {
+		valid, err := b.preview.Verify(r.Context(), id, preview)
+		if err != nil || !valid {
+			slog.Warn("GetBlog: preview token verification failed", "error", err)
+			return entities.NewRetFailed(ErrorInvalidPreviewToken, http.StatusForbidden).WriteJSON(w)
+		}
+		// shouldn't be kept by shared caches or indexed
+		w.Header().Set("Cache-Control", "private, no-store")
+		w.Header().Set("X-Robots-Tag", "noindex")
+	}
 
 	// admin get
-	if len(all) > 0 && all[0] {
+	if preview != "" || (len(all) > 0 && all[0]) {
 
 		// authorization
-		authorized, err := b.auth.Verify(r)
-		if err != nil || !authorized {
-			slog.Warn("GetBlog: authorization failed", "error", err.Error())
-			return entities.NewRetFailed(err, http.StatusForbidden).WriteJSON(w)
+		if preview == "" {
+			authorized, err := b.auth.Verify(r)
+			if err != nil || !authorized {
+				slog.Warn("GetBlog: authorization failed", "error", err.Error())
+				return entities.NewRetFailed(err, http.StatusForbidden).WriteJSON(w)
+			}
 		}
 
 		blog, err := b.repo.AdminGet(r.Context(), id)
@@ -394,14 +452,22 @@ func (b *Blogs) GetBlog(w http.ResponseWriter, r *http.Request) error {
 
 			return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
 		}
+		// soft deleted blogs are only for admins
+		if preview != "" && blog.Deleted_at != "" {
+			return entities.NewRetFailed(ErrorTargetNotFound, http.StatusNotFound).WriteJSON(w)
+		}
+
+		if notModified(w, r, blogETag(*blog, parsed)) {
+			return nil
+		}
 
 		// parse markdown to html with highlighing
 		if len(parsed) > 0 && parsed[0] {
-			var buf bytes.Buffer
-			if err := mdParser.Convert([]byte(blog.Content), &buf); err != nil {
+			content, err := b.render(r.Context(), blog.Blog)
+			if err != nil {
 				return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
 			}
-			blog.Content = buf.String()
+			blog.Content = content
 		}
 		return entities.NewRetSuccess(*blog).WriteJSON(w)
 	}
@@ -423,17 +489,42 @@ func (b *Blogs) GetBlog(w http.ResponseWriter, r *http.Request) error {
 
 	}
 
+	if notModified(w, r, blogETag(*blog, parsed)) {
+		return nil
+	}
+
 	// parse markdown to html with highlighing
 	if len(parsed) > 0 && parsed[0] {
-		var buf bytes.Buffer
-		if err := mdParser.Convert([]byte(blog.Content), &buf); err != nil {
+		content, err := b.render(r.Context(), blog.Blog)
+		if err != nil {
 			return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
 		}
-		blog.Content = buf.String()
+		blog.Content = content
 	}
 	return entities.NewRetSuccess(*blog).WriteJSON(w)
 }
 
+// markdown to html, srcset of uploaded images stops at the width of the image
+func (b *Blogs) render(ctx context.Context, blog entities.Blog) (string, error) {
+	widths, err := b.repo.MediaWidths(ctx, blog.ID)
+	if err != nil {
+		return "", fmt.Errorf("render: list media widths failed: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := b.md.Convert([]byte(blog.Content), &buf, markdown.WithMediaWidths(widths)); err != nil {
+		return "", fmt.Errorf("render: convert failed: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// parsed markdown is another representation of the same version
+func blogETag(blog entities.OutBlog, parsed []bool) string {
+	if len(parsed) > 0 && parsed[0] {
+		return blog.ETag("parsed")
+	}
+	return blog.ETag()
+}
+
 // UpdateBlog
 //
 //	@Summary		Update blog
@@ -443,11 +534,16 @@ func (b *Blogs) GetBlog(w http.ResponseWriter, r *http.Request) error {
 //	@Produce		json
 //	@Param			id				path		int					true	"target blog id"
 //	@Param			Authorization	header		string				true	"jwt token"
+//	@Param			If-Match		header		string	false	"ETag from a previous GET, the blog must not have changed since"
 //	@Param			blog			body		entities.ReqInBlog	true	"new blog content"
 //	@Success		200				{object}	entities.RetSuccess[entities.OutBlog]
 //	@Failure		400				{object}	entities.RetFailed
 //	@Failure		403				{object}	entities.RetFailed
+//	@Failure		409				{object}	entities.RetFailed
+//	@Failure		412				{object}	entities.RetFailed
+//	@Failure		428				{object}	entities.RetFailed
 //	@Failure		500				{object}	entities.RetFailed
+//	@Header			200				{string}	ETag	"version of the blog"
 //	@Router			/blogs/{id} [put]
 func (b *Blogs) UpdateBlog(w http.ResponseWriter, r *http.Request) error {
 	slog.Debug("UpdateBlog")
@@ -484,15 +580,29 @@ func (b *Blogs) UpdateBlog(w http.ResponseWriter, r *http.Request) error {
 		blog.Tags,
 		blog.Topics,
 	)
+	inBlog.Series, inBlog.Part = blog.Series, blog.Part
+	inBlog.Actor, err = b.auth.UserName(r)
+	if err != nil {
+		slog.Error("UpdateBlog: get user name failed", "error", err)
+		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+	}
 
 	// update
-	updatedBlog, err := b.repo.Update(r.Context(), *inBlog, id)
+	updatedBlog, err := b.repo.Update(r.Context(), *inBlog, id, r.Header.Get("If-Match"))
 	if err != nil {
 		slog.Error("UpdateBlog: update failed", "error", err)
 		if errors.Is(err, sql.ErrNoRows) {
 			return entities.NewRetFailed(ErrorTargetNotFound, http.StatusBadRequest).WriteJSON(w)
 		}
 
+		if errors.Is(err, entities.ErrorPreconditionFailed) {
+			return entities.NewRetFailed(entities.ErrorPreconditionFailed, http.StatusPreconditionFailed).WriteJSON(w)
+		}
+
+		if errors.Is(err, entities.ErrorConcurrentChange) {
+			return entities.NewRetFailed(entities.ErrorConcurrentChange, http.StatusConflict).WriteJSON(w)
+		}
+
 		if sqliteErr, ok := getSQLiteError(err); ok {
 			slog.Error("got sqlite error", "error code", sqliteErr.Code, "extended error code", sqliteErr.ExtendedCode)
 			return entities.NewRetFailedCustom(err, int(sqliteErr.ExtendedCode), http.StatusInternalServerError).WriteJSON(w)
@@ -500,9 +610,90 @@ func (b *Blogs) UpdateBlog(w http.ResponseWriter, r *http.Request) error {
 
 		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
 	}
+	w.Header().Set("ETag", updatedBlog.ETag())
 	return entities.NewRetSuccess(*updatedBlog).WriteJSON(w)
 }
 
+// PatchBlog
+//
+//	@Summary		Patch blog
+//	@Description	update only the fields present in the body, as a JSON Merge Patch (RFC 7396).
+//	@Description	tags, topics and series are only replaced when present, null clears them.
+//	@Tags			blogs
+//	@Accept			json,application/merge-patch+json
+//	@Produce		json
+//	@Param			id				path		int					true	"target blog id"
+//	@Param			Authorization	header		string				true	"jwt token"
+//	@Param			If-Match		header		string	false	"ETag from a previous GET, the blog must not have changed since"
+//	@Param			blog			body		entities.BlogPatch	true	"fields to change"
+//	@Success		200				{object}	entities.RetSuccess[entities.OutBlog]
+//	@Failure		400				{object}	entities.RetFailed
+//	@Failure		403				{object}	entities.RetFailed
+//	@Failure		404				{object}	entities.RetFailed
+//	@Failure		409				{object}	entities.RetFailed
+//	@Failure		412				{object}	entities.RetFailed
+//	@Failure		428				{object}	entities.RetFailed
+//	@Failure		500				{object}	entities.RetFailed
+//	@Header			200				{string}	ETag	"version of the blog"
+//	@Router			/blogs/{id} [patch]
+func (b *Blogs) PatchBlog(w http.ResponseWriter, r *http.Request) error {
+	slog.Debug("PatchBlog")
+
+	// authorization
+	authorized, err := b.auth.Verify(r)
+	if err != nil || !authorized {
+		slog.Warn("PatchBlog: authorization failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusForbidden).WriteJSON(w)
+	}
+
+	// process path param
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		slog.Error("PatchBlog: id path param to int failed", "error", err)
+		return entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
+	}
+
+	// process body
+	patch := &entities.BlogPatch{}
+	if err := json.NewDecoder(r.Body).Decode(patch); err != nil {
+		slog.Error("PatchBlog: parse body param failed", "error", err)
+		return entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
+	}
+	patch.GenSlug()
+	patch.GenMD5()
+	patch.Actor, err = b.auth.UserName(r)
+	if err != nil {
+		slog.Error("PatchBlog: get user name failed", "error", err)
+		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+	}
+
+	// patch
+	patchedBlog, err := b.repo.Patch(r.Context(), *patch, id, r.Header.Get("If-Match"))
+	if err != nil {
+		slog.Error("PatchBlog: patch failed", "error", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return entities.NewRetFailed(ErrorTargetNotFound, http.StatusNotFound).WriteJSON(w)
+		}
+
+		if errors.Is(err, entities.ErrorPreconditionFailed) {
+			return entities.NewRetFailed(entities.ErrorPreconditionFailed, http.StatusPreconditionFailed).WriteJSON(w)
+		}
+
+		if errors.Is(err, entities.ErrorConcurrentChange) {
+			return entities.NewRetFailed(entities.ErrorConcurrentChange, http.StatusConflict).WriteJSON(w)
+		}
+
+		if sqliteErr, ok := getSQLiteError(err); ok {
+			slog.Error("got sqlite error", "error code", sqliteErr.Code, "extended error code", sqliteErr.ExtendedCode)
+			return entities.NewRetFailedCustom(err, int(sqliteErr.ExtendedCode), http.StatusInternalServerError).WriteJSON(w)
+		}
+
+		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+	}
+	w.Header().Set("ETag", patchedBlog.ETag())
+	return entities.NewRetSuccess(*patchedBlog).WriteJSON(w)
+}
+
 // CreateBlogWithID
 //
 //	@Summary		Create blog with given id
@@ -512,10 +703,13 @@ func (b *Blogs) UpdateBlog(w http.ResponseWriter, r *http.Request) error {
 //	@Produce		json
 //	@Param			id				path		int					true	"blog id"
 //	@Param			Authorization	header		string				true	"jwt token"
+//	@Param			Idempotency-Key	header		string				false	"unique key of the request, retries with the same key and body replay the first response"
 //	@Param			blog			body		entities.ReqInBlog	true	"new blog content"
 //	@Success		200				{object}	entities.RetSuccess[entities.OutBlog]
 //	@Failure		400				{object}	entities.RetFailed
 //	@Failure		403				{object}	entities.RetFailed
+//	@Failure		409				{object}	entities.RetFailed
+//	@Failure		422				{object}	entities.RetFailed
 //	@Failure		500				{object}	entities.RetFailed
 //	@Router			/blogs/{id} [post]
 func (b *Blogs) CreateBlogWithID(w http.ResponseWriter, r *http.Request) error {
@@ -548,11 +742,20 @@ func (b *Blogs) CreateBlogWithID(w http.ResponseWriter, r *http.Request) error {
 		blog.Pined,
 		blog.Visible,
 	)
+	if err := setInitialStatus(newBlog, blog.Status); err != nil {
+		return entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
+	}
 	inBlog := entities.NewInBlog(
 		*newBlog,
 		blog.Tags,
 		blog.Topics,
 	)
+	inBlog.Series, inBlog.Part = blog.Series, blog.Part
+	inBlog.Actor, err = b.auth.UserName(r)
+	if err != nil {
+		slog.Error("CreateBlogWithID: get user name failed", "error", err)
+		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+	}
 
 	// create
 	createdBlog, err := b.repo.CreateWithID(r.Context(), *inBlog, id)
@@ -581,9 +784,12 @@ func (b *Blogs) CreateBlogWithID(w http.ResponseWriter, r *http.Request) error {
 //	@Produce		json
 //	@Param			id				path		int		true	"target blog id"
 //	@Param			Authorization	header		string	true	"jwt token"
+//	@Param			If-Match		header		string	false	"ETag from a previous GET, the blog must not have changed since"
 //	@Success		200				{object}	entities.RetSuccess[entities.RowsAffected]
 //	@Failure		400				{object}	entities.RetFailed
 //	@Failure		403				{object}	entities.RetFailed
+//	@Failure		412				{object}	entities.RetFailed
+//	@Failure		428				{object}	entities.RetFailed
 //	@Failure		500				{object}	entities.RetFailed
 //	@Router			/blogs/{id} [delete]
 func (b *Blogs) SoftDeleteBlog(w http.ResponseWriter, r *http.Request) error {
@@ -603,11 +809,21 @@ func (b *Blogs) SoftDeleteBlog(w http.ResponseWriter, r *http.Request) error {
 		return entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
 	}
 
+	actor, err := b.auth.UserName(r)
+	if err != nil {
+		slog.Error("SoftDeleteBlog: get user name failed", "error", err)
+		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+	}
+
 	// soft delete blog
-	affectedRows, err := b.repo.SoftDelete(r.Context(), id)
+	affectedRows, err := b.repo.SoftDelete(r.Context(), id, actor, r.Header.Get("If-Match"))
 	if err != nil {
 		slog.Error("SoftDeleteBlog: soft delete failed", "error", err)
 
+		if errors.Is(err, entities.ErrorPreconditionFailed) {
+			return entities.NewRetFailed(entities.ErrorPreconditionFailed, http.StatusPreconditionFailed).WriteJSON(w)
+		}
+
 		if sqliteErr, ok := getSQLiteError(err); ok {
 			slog.Error("got sqlite error", "error code", sqliteErr.Code, "extended error code", sqliteErr.ExtendedCode)
 			return entities.NewRetFailedCustom(err, int(sqliteErr.ExtendedCode), http.StatusInternalServerError).WriteJSON(w)
@@ -682,9 +898,12 @@ func (b *Blogs) RestoreDeletedBlog(w http.ResponseWriter, r *http.Request) error
 //	@Produce		json
 //	@Param			id				path		int		true	"target blog id"
 //	@Param			Authorization	header		string	true	"jwt token"
+//	@Param			If-Match		header		string	false	"ETag from a previous GET, the blog must not have changed since"
 //	@Success		200				{object}	entities.RetSuccess[entities.RowsAffected]
 //	@Failure		400				{object}	entities.RetFailed
 //	@Failure		403				{object}	entities.RetFailed
+//	@Failure		412				{object}	entities.RetFailed
+//	@Failure		428				{object}	entities.RetFailed
 //	@Failure		500				{object}	entities.RetFailed
 //	@Router			/blogs/deleted/{id} [delete]
 func (b *Blogs) DeleteBlog(w http.ResponseWriter, r *http.Request) error {
@@ -705,10 +924,14 @@ func (b *Blogs) DeleteBlog(w http.ResponseWriter, r *http.Request) error {
 	}
 
 	// delete blog
-	affectedRows, err := b.repo.Delete(r.Context(), id)
+	affectedRows, err := b.repo.Delete(r.Context(), id, r.Header.Get("If-Match"))
 	if err != nil {
 		slog.Error("DeleteBlog: delete failed", "error", err)
 
+		if errors.Is(err, entities.ErrorPreconditionFailed) {
+			return entities.NewRetFailed(entities.ErrorPreconditionFailed, http.StatusPreconditionFailed).WriteJSON(w)
+		}
+
 		if sqliteErr, ok := getSQLiteError(err); ok {
 			slog.Error("got sqlite error", "error code", sqliteErr.Code, "extended error code", sqliteErr.ExtendedCode)
 			return entities.NewRetFailedCustom(err, int(sqliteErr.ExtendedCode), http.StatusInternalServerError).WriteJSON(w)
@@ -733,9 +956,12 @@ func (b *Blogs) DeleteBlog(w http.ResponseWriter, r *http.Request) error {
 //	@Produce		json
 //	@Param			id				path		int		true	"target blog id"
 //	@Param			Authorization	header		string	true	"jwt token"
+//	@Param			If-Match		header		string	false	"ETag from a previous GET, the blog must not have changed since"
 //	@Success		200				{object}	entities.RetSuccess[entities.RowsAffected]
 //	@Failure		400				{object}	entities.RetFailed
 //	@Failure		403				{object}	entities.RetFailed
+//	@Failure		412				{object}	entities.RetFailed
+//	@Failure		428				{object}	entities.RetFailed
 //	@Failure		500				{object}	entities.RetFailed
 //	@Router			/blogs/delete-now/{id} [delete]
 func (b *Blogs) DeleteBlogNow(w http.ResponseWriter, r *http.Request) error {
@@ -756,10 +982,14 @@ func (b *Blogs) DeleteBlogNow(w http.ResponseWriter, r *http.Request) error {
 	}
 
 	// delete blog
-	affectedRows, err := b.repo.DeleteNow(r.Context(), id)
+	affectedRows, err := b.repo.DeleteNow(r.Context(), id, r.Header.Get("If-Match"))
 	if err != nil {
 		slog.Error("DeleteBlogNow: delete failed", "error", err)
 
+		if errors.Is(err, entities.ErrorPreconditionFailed) {
+			return entities.NewRetFailed(entities.ErrorPreconditionFailed, http.StatusPreconditionFailed).WriteJSON(w)
+		}
+
 		if sqliteErr, ok := getSQLiteError(err); ok {
 			slog.Error("got sqlite error", "error code", sqliteErr.Code, "extended error code", sqliteErr.ExtendedCode)
 			return entities.NewRetFailedCustom(err, int(sqliteErr.ExtendedCode), http.StatusInternalServerError).WriteJSON(w)
@@ -774,3 +1004,244 @@ func (b *Blogs) DeleteBlogNow(w http.ResponseWriter, r *http.Request) error {
 
 	return entities.NewRetSuccess(*entities.NewRowsAffected(affectedRows)).WriteJSON(w)
 }
+
+// ListDeletedBlogs
+//
+//	@Summary		List deleted blogs
+//	@Description	blogs in the trash with when and by whom they were deleted, most recently deleted first.
+//	@Description	they are purged after the retention period ( trash.retention days )
+//	@Tags			blogs
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string	true	"jwt token"
+//	@Success		200				{object}	entities.RetSuccess[[]entities.OutBlog]
+//	@Failure		403				{object}	entities.RetFailed
+//	@Failure		500				{object}	entities.RetFailed
+//	@Router			/blogs/deleted [get]
+func (b *Blogs) ListDeletedBlogs(w http.ResponseWriter, r *http.Request) error {
+	slog.Debug("ListDeletedBlogs")
+
+	// authorization
+	authorized, err := b.auth.Verify(r)
+	if err != nil || !authorized {
+		slog.Warn("ListDeletedBlogs: authorization failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusForbidden).WriteJSON(w)
+	}
+
+	blogs, err := b.repo.ListDeleted(r.Context())
+	if err != nil {
+		slog.Error("ListDeletedBlogs: list deleted failed", "error", err)
+
+		if sqliteErr, ok := getSQLiteError(err); ok {
+			slog.Error("got sqlite error", "error code", sqliteErr.Code, "extended error code", sqliteErr.ExtendedCode)
+			return entities.NewRetFailedCustom(err, int(sqliteErr.ExtendedCode), http.StatusInternalServerError).WriteJSON(w)
+		}
+
+		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+	}
+
+	return entities.NewRetSuccess(blogs).WriteJSON(w)
+}
+
+// EmptyTrash
+//
+//	@Summary		Empty trash
+//	@Description	permanently delete all soft deleted blogs, along with their relations
+//	@Tags			blogs
+//	@Accept			json
+//	@Produce		json
+//	@Param			dryRun			query		bool	false	"only list blogs that would be deleted"
+//	@Param			Authorization	header		string	true	"jwt token"
+//	@Success		200				{object}	entities.RetSuccess[[]entities.Blog]
+//	@Failure		400				{object}	entities.RetFailed
+//	@Failure		403				{object}	entities.RetFailed
+//	@Failure		500				{object}	entities.RetFailed
+//	@Router			/blogs/deleted [delete]
+func (b *Blogs) EmptyTrash(w http.ResponseWriter, r *http.Request) error {
+	slog.Info("EmptyTrash")
+
+	// authorization
+	authorized, err := b.auth.Verify(r)
+	if err != nil || !authorized {
+		slog.Warn("EmptyTrash: authorization failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusForbidden).WriteJSON(w)
+	}
+
+	// parse query params
+	queries := r.URL.Query()
+	slog.Debug("got queries", "queries", queries)
+
+	dryRun, err := strListToBool(queries["dryRun"])
+	if err != nil {
+		slog.Error("EmptyTrash: 'dryRun' string list to bool failed", "error", err)
+		return entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
+	}
+
+	purged, err := b.repo.PurgeDeleted(r.Context(), time.Now(), len(dryRun) > 0 && dryRun[0])
+	if err != nil {
+		slog.Error("EmptyTrash: purge deleted failed", "error", err)
+
+		if sqliteErr, ok := getSQLiteError(err); ok {
+			slog.Error("got sqlite error", "error code", sqliteErr.Code, "extended error code", sqliteErr.ExtendedCode)
+			return entities.NewRetFailedCustom(err, int(sqliteErr.ExtendedCode), http.StatusInternalServerError).WriteJSON(w)
+		}
+
+		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+	}
+
+	return entities.NewRetSuccess(purged).WriteJSON(w)
+}
+
+// TransitionBlog
+//
+//	@Summary		Transition blog status
+//	@Description	move a blog through the editorial workflow, who moved it is recorded.
+//	@Description	draft -> in_review, scheduled, published, archived
+//	@Description	in_review -> draft, scheduled, published
+//	@Description	scheduled -> draft, in_review, published ( published by the server at scheduledAt )
+//	@Description	published -> draft, archived
+//	@Description	archived -> draft, published
+//	@Tags			blogs
+//	@Accept			json
+//	@Produce		json
+//	@Param			id				path		int						true	"target blog id"
+//	@Param			Authorization	header		string					true	"jwt token"
+//	@Param			transition		body		entities.InTransition	true	"new status"
+//	@Success		200				{object}	entities.RetSuccess[entities.OutBlog]
+//	@Failure		400				{object}	entities.RetFailed
+//	@Failure		403				{object}	entities.RetFailed
+//	@Failure		404				{object}	entities.RetFailed
+//	@Failure		409				{object}	entities.RetFailed
+//	@Failure		500				{object}	entities.RetFailed
+//	@Router			/blogs/{id}/transition [post]
+func (b *Blogs) TransitionBlog(w http.ResponseWriter, r *http.Request) error {
+	slog.Debug("TransitionBlog")
+
+	// authorization
+	authorized, err := b.auth.Verify(r)
+	if err != nil || !authorized {
+		slog.Warn("TransitionBlog: authorization failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusForbidden).WriteJSON(w)
+	}
+
+	// parse path param
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		slog.Error("TransitionBlog: id path param to int failed", "error", err)
+		return entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
+	}
+
+	body := &entities.InTransition{}
+	if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+		slog.Error("TransitionBlog: decode failed", "error", err)
+		return entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
+	}
+	if !slices.Contains(entities.BlogStatuses, body.Status) {
+		return entities.NewRetFailed(ErrorInvalidBlogStatus, http.StatusBadRequest).WriteJSON(w)
+	}
+	scheduledAt := ""
+	if body.Status == entities.BlogScheduled {
+		at, err := time.Parse(time.RFC3339, body.ScheduledAt)
+		if err != nil || !at.After(time.Now()) {
+			return entities.NewRetFailed(ErrorInvalidScheduledAt, http.StatusBadRequest).WriteJSON(w)
+		}
+		scheduledAt = at.UTC().Format("2006-01-02T15:04:05-07:00")
+	}
+
+	actor, err := b.auth.UserName(r)
+	if err != nil {
+		slog.Error("TransitionBlog: get user name failed", "error", err)
+		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+	}
+
+	blog, err := b.repo.Transition(r.Context(), id, body.Status, scheduledAt, actor, body.Note)
+	if err != nil {
+		slog.Error("TransitionBlog: transition failed", "error", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return entities.NewRetFailed(ErrorTargetNotFound, http.StatusNotFound).WriteJSON(w)
+		}
+		if errors.Is(err, entities.ErrorInvalidStatusTransition) {
+			return entities.NewRetFailed(err, http.StatusConflict).WriteJSON(w)
+		}
+
+		if sqliteErr, ok := getSQLiteError(err); ok {
+			slog.Error("got sqlite error", "error code", sqliteErr.Code, "extended error code", sqliteErr.ExtendedCode)
+			return entities.NewRetFailedCustom(err, int(sqliteErr.ExtendedCode), http.StatusInternalServerError).WriteJSON(w)
+		}
+
+		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+	}
+
+	return entities.NewRetSuccess(*blog).WriteJSON(w)
+}
+
+// ListBlogStatusEvents
+//
+//	@Summary		List blog status changes
+//	@Description	who moved the blog to which status and when, oldest first
+//	@Tags			blogs
+//	@Accept			json
+//	@Produce		json
+//	@Param			id				path		int		true	"target blog id"
+//	@Param			Authorization	header		string	true	"jwt token"
+//	@Success		200				{object}	entities.RetSuccess[[]entities.BlogStatusEvent]
+//	@Failure		400				{object}	entities.RetFailed
+//	@Failure		403				{object}	entities.RetFailed
+//	@Failure		404				{object}	entities.RetFailed
+//	@Failure		500				{object}	entities.RetFailed
+//	@Router			/blogs/{id}/status-events [get]
+func (b *Blogs) ListBlogStatusEvents(w http.ResponseWriter, r *http.Request) error {
+	slog.Debug("ListBlogStatusEvents")
+
+	// authorization
+	authorized, err := b.auth.Verify(r)
+	if err != nil || !authorized {
+		slog.Warn("ListBlogStatusEvents: authorization failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusForbidden).WriteJSON(w)
+	}
+
+	// parse path param
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		slog.Error("ListBlogStatusEvents: id path param to int failed", "error", err)
+		return entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
+	}
+
+	events, err := b.repo.ListStatusEvents(r.Context(), id)
+	if err != nil {
+		slog.Error("ListBlogStatusEvents: list failed", "error", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return entities.NewRetFailed(ErrorTargetNotFound, http.StatusNotFound).WriteJSON(w)
+		}
+
+		if sqliteErr, ok := getSQLiteError(err); ok {
+			slog.Error("got sqlite error", "error code", sqliteErr.Code, "extended error code", sqliteErr.ExtendedCode)
+			return entities.NewRetFailedCustom(err, int(sqliteErr.ExtendedCode), http.StatusInternalServerError).WriteJSON(w)
+		}
+
+		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+	}
+
+	return entities.NewRetSuccess(events).WriteJSON(w)
+}
+
+// Status of new blogs, from visible if left out. Blogs can only be scheduled through transitions.
+func setInitialStatus(blog *entities.Blog, status string) error {
+	if status == "" {
+		return nil
+	}
+	if status == entities.BlogScheduled || !slices.Contains(entities.BlogStatuses, status) {
+		return ErrorInvalidBlogStatus
+	}
+	blog.SetStatus(status, "")
+	return nil
+}
+
+// AuditSnapshot is the state of a blog recorded in the audit log
+func (b *Blogs) AuditSnapshot(ctx context.Context, id string) (any, error) {
+	blogID, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, fmt.Errorf("AuditSnapshot: id to int failed: %w", err)
+	}
+	return b.repo.AdminGet(ctx, blogID)
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"blog/config"
+	"blog/entities"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+)
+
+var ErrorBulkDataRequired = errors.New("data is required for create and update")
+
+// Concrete implementations are at repository/<name>
+type bulkRepository interface {
+	Apply(ctx context.Context, bulk entities.Bulk) (*entities.OutBulk, error)
+}
+
+type Bulk struct {
+	repo   bulkRepository
+	auth   authHelper
+	config config.BulkSetting
+}
+
+func NewBulk(repo bulkRepository, auth authHelper, config config.BulkSetting) *Bulk {
+	return &Bulk{
+		repo:   repo,
+		auth:   auth,
+		config: config,
+	}
+}
+
+// ApplyBulk
+//
+//	@Summary		Apply bulk operations
+//	@Description	create, update and delete topics, tags and blogs in order, in one transaction.
+//	@Description	created targets can have a ref, later blogs link them with tagRefs and topicRefs.
+//	@Description	with atomic, the first failed operation rolls back all of them, otherwise only failed operations are skipped.
+//	@Description	results of each operation are returned, failed operations don't change the http status.
+//	@Tags			bulk
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string			true	"jwt token"
+//	@Param			Idempotency-Key	header		string			false	"unique key of the request, retries with the same key and body replay the first response"
+//	@Param			bulk			body		entities.Bulk	true	"operations, data is an InTopic, InTag or ReqInBlog"
+//	@Success		200				{object}	entities.RetSuccess[entities.OutBulk]
+//	@Failure		400				{object}	entities.RetFailed
+//	@Failure		403				{object}	entities.RetFailed
+//	@Failure		409				{object}	entities.RetFailed
+//	@Failure		422				{object}	entities.RetFailed
+//	@Failure		500				{object}	entities.RetFailed
+//	@Router			/bulk [post]
+func (b *Bulk) ApplyBulk(w http.ResponseWriter, r *http.Request) error {
+	slog.Debug("ApplyBulk")
+
+	// authorization
+	authorized, err := b.auth.Verify(r)
+	if err != nil || !authorized {
+		slog.Warn("ApplyBulk: authorization failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusForbidden).WriteJSON(w)
+	}
+
+	// process body
+	bulk := &entities.Bulk{}
+	if err := json.NewDecoder(r.Body).Decode(bulk); err != nil {
+		slog.Error("ApplyBulk: decode failed", "error", err)
+		return entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
+	}
+	if err := bulk.Validate(b.config.MaxOperations); err != nil {
+		slog.Error("ApplyBulk: validate failed", "error", err)
+		return entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
+	}
+	for i := range bulk.Operations {
+		if err := decodeBulkData(&bulk.Operations[i]); err != nil {
+			slog.Error("ApplyBulk: decode data failed", "index", i, "error", err)
+			return entities.NewRetFailed(fmt.Errorf("operation %d: %w", i, err), http.StatusBadRequest).WriteJSON(w)
+		}
+	}
+	bulk.Actor, err = b.auth.UserName(r)
+	if err != nil {
+		slog.Error("ApplyBulk: get user name failed", "error", err)
+		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+	}
+
+	out, err := b.repo.Apply(r.Context(), *bulk)
+	if err != nil {
+		slog.Error("ApplyBulk: repo apply failed", "error", err)
+
+		if sqliteErr, ok := getSQLiteError(err); ok {
+			slog.Error("got sqlite error", "error code", sqliteErr.Code, "extended error code", sqliteErr.ExtendedCode)
+			return entities.NewRetFailedCustom(err, int(sqliteErr.ExtendedCode), http.StatusInternalServerError).WriteJSON(w)
+		}
+
+		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+	}
+
+	return entities.NewRetSuccess(*out).WriteJSON(w)
+}
+
+// Decodes data of an operation the same way as the single create and update handlers
+func decodeBulkData(op *entities.BulkOperation) error {
+	if op.Op == entities.BulkDelete {
+		return nil
+	}
+	if len(bytes.TrimSpace(op.Data)) == 0 || bytes.Equal(bytes.TrimSpace(op.Data), []byte("null")) {
+		return ErrorBulkDataRequired
+	}
+
+	switch op.Type {
+	case entities.BulkTopic:
+		in := &entities.InTopic{}
+		if err := json.Unmarshal(op.Data, in); err != nil {
+			return fmt.Errorf("decodeBulkData: decode topic failed: %w", err)
+		}
+		op.Topic = entities.NewTopic(in.Name, in.Description)
+
+	case entities.BulkTag:
+		in := &entities.InTag{}
+		if err := json.Unmarshal(op.Data, in); err != nil {
+			return fmt.Errorf("decodeBulkData: decode tag failed: %w", err)
+		}
+		op.Tag = entities.NewTag(in.Name, in.Description)
+
+	case entities.BulkBlog:
+		in := &entities.ReqInBlog{}
+		if err := json.Unmarshal(op.Data, in); err != nil {
+			return fmt.Errorf("decodeBulkData: decode blog failed: %w", err)
+		}
+		blog := entities.NewBlog(in.Title, in.Content, in.Description, in.Pined, in.Visible)
+		// status is only used on create, like CreateBlog
+		if op.Op == entities.BulkCreate {
+			if err := setInitialStatus(blog, in.Status); err != nil {
+				return err
+			}
+		}
+		op.Blog = entities.NewInBlog(*blog, in.Tags, in.Topics)
+		op.Blog.Series, op.Blog.Part = in.Series, in.Part
+	}
+
+	return nil
+}
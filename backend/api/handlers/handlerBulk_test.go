@@ -0,0 +1,96 @@
+package handlers_test
+
+import (
+	"blog/api/handlers"
+	"blog/config"
+	"blog/entities"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type DummyBulkRepo struct {
+	bulk entities.Bulk
+}
+
+func (d *DummyBulkRepo) Apply(ctx context.Context, bulk entities.Bulk) (*entities.OutBulk, error) {
+	d.bulk = bulk
+	out := entities.NewOutBulk(bulk.Operations)
+	for i := range out.Results {
+		out.Results[i].Status = entities.BulkOK
+	}
+	out.Committed = true
+	return out, nil
+}
+
+func TestHandlerBulk(t *testing.T) {
+	repo := &DummyBulkRepo{}
+	setting := config.NewConfig().Bulk
+	setting.MaxOperations = 3
+	bulk := handlers.NewBulk(repo, &DummyAuthHelper{}, setting)
+
+	request := func(body string, authorized bool) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/bulk", bytes.NewBufferString(body))
+		if authorized {
+			r.Header.Set("Authorization", "Bearer aaa.bbb.ccc")
+		}
+		w := httptest.NewRecorder()
+		if err := bulk.ApplyBulk(w, r); err != nil {
+			t.Fatalf("TestHandlerBulk: apply bulk failed: %s", err)
+		}
+		return w
+	}
+
+	// success
+	w := request(`{"atomic": true, "operations": [
+		{"op": "create", "type": "tag", "ref": "t", "data": {"name": "go tips"}},
+		{"op": "create", "type": "blog", "tagRefs": ["t"], "data": {"title": "hello world", "status": "in_review", "tags": [1]}},
+		{"op": "delete", "type": "topic", "id": 2}
+	]}`, true)
+	if w.Code != http.StatusOK {
+		t.Fatalf("TestHandlerBulk: should succeed, got %d %s", w.Code, w.Body.String())
+	}
+	ret := entities.RetSuccess[entities.OutBulk]{}
+	if err := json.NewDecoder(w.Body).Decode(&ret); err != nil {
+		t.Fatalf("TestHandlerBulk: decode response failed: %s", err)
+	}
+	if !ret.Msg.Committed || len(ret.Msg.Results) != 3 {
+		t.Fatalf("TestHandlerBulk: unexpected response %+v", ret.Msg)
+	}
+	if !repo.bulk.Atomic || repo.bulk.Actor != "dummy" {
+		t.Fatalf("TestHandlerBulk: atomic and actor should be passed, got %+v", repo.bulk)
+	}
+	if tag := repo.bulk.Operations[0].Tag; tag == nil || tag.Slug != "go-tips" {
+		t.Fatalf("TestHandlerBulk: tag data should be decoded, got %+v", tag)
+	}
+	if blog := repo.bulk.Operations[1].Blog; blog == nil || blog.Slug != "hello-world" || blog.Status != entities.BlogInReview || len(blog.Tags) != 1 {
+		t.Fatalf("TestHandlerBulk: blog data should be decoded, got %+v", blog)
+	}
+
+	cases := []struct {
+		name string
+		body string
+		code int
+	}{
+		{"not authorized", `{"operations": [{"op": "delete", "type": "tag", "id": 1}]}`, http.StatusForbidden},
+		{"invalid json", `{"operations": [`, http.StatusBadRequest},
+		{"empty", `{"operations": []}`, http.StatusBadRequest},
+		{"too many", `{"operations": [{"op": "delete", "type": "tag", "id": 1}, {"op": "delete", "type": "tag", "id": 2}, {"op": "delete", "type": "tag", "id": 3}, {"op": "delete", "type": "tag", "id": 4}]}`, http.StatusBadRequest},
+		{"unknown type", `{"operations": [{"op": "create", "type": "series", "data": {}}]}`, http.StatusBadRequest},
+		{"update without id", `{"operations": [{"op": "update", "type": "tag", "data": {"name": "a"}}]}`, http.StatusBadRequest},
+		{"ref before create", `{"operations": [{"op": "create", "type": "blog", "tagRefs": ["t"], "data": {}}, {"op": "create", "type": "tag", "ref": "t", "data": {}}]}`, http.StatusBadRequest},
+		{"ref of wrong type", `{"operations": [{"op": "create", "type": "topic", "ref": "t", "data": {}}, {"op": "create", "type": "blog", "tagRefs": ["t"], "data": {}}]}`, http.StatusBadRequest},
+		{"duplicate ref", `{"operations": [{"op": "create", "type": "tag", "ref": "t", "data": {}}, {"op": "create", "type": "tag", "ref": "t", "data": {}}]}`, http.StatusBadRequest},
+		{"missing data", `{"operations": [{"op": "create", "type": "tag"}]}`, http.StatusBadRequest},
+		{"invalid data", `{"operations": [{"op": "create", "type": "tag", "data": {"name": 1}}]}`, http.StatusBadRequest},
+		{"invalid status", `{"operations": [{"op": "create", "type": "blog", "data": {"title": "a", "status": "scheduled"}}]}`, http.StatusBadRequest},
+	}
+	for _, c := range cases {
+		if w := request(c.body, c.name != "not authorized"); w.Code != c.code {
+			t.Fatalf("TestHandlerBulk: %s: expected %d, got %d %s", c.name, c.code, w.Code, w.Body.String())
+		}
+	}
+}
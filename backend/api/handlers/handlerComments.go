@@ -0,0 +1,376 @@
+package handlers
+
+import (
+	"blog/config"
+	"blog/entities"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	ErrorCommentEmpty         = errors.New("author and content can't be empty")
+	ErrorCommentTooLong       = errors.New("author or content too long")
+	ErrorInvalidCommentStatus = errors.New("status should be one of: pending, approved, rejected")
+)
+
+// Concrete implementations are at repository/<name>
+type commentsRepository interface {
+	// Returns sql.ErrNoRows if the blog is not visible or the parent can't be replied to
+	Create(ctx context.Context, comment entities.Comment) (*entities.Comment, error)
+	// Returns sql.ErrNoRows if the blog is not visible
+	ListApproved(ctx context.Context, blogID int) ([]entities.Comment, error)
+	ListByStatus(ctx context.Context, status string) ([]entities.Comment, error)
+	UpdateStatus(ctx context.Context, id int, status string) (*entities.Comment, error)
+	Delete(ctx context.Context, id int) (int, error)
+}
+
+type Comments struct {
+	repo   commentsRepository
+	auth   authHelper
+	md     markdownConverter // should not allow raw html
+	config config.CommentsSetting
+}
+
+func NewComments(repo commentsRepository, auth authHelper, md markdownConverter, config config.CommentsSetting) *Comments {
+	return &Comments{
+		repo:   repo,
+		auth:   auth,
+		md:     md,
+		config: config,
+	}
+}
+
+// CreateComment
+//
+//	@Summary		Create comment
+//	@Description	comment on a visible blog, comments are only listed after being approved.
+//	@Description	replies can only be made to approved comments of the same blog.
+//	@Tags			comments
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int					true	"target blog id"
+//	@Param			comment	body		entities.InComment	true	"new comment, leave website empty"
+//	@Success		200		{object}	entities.RetSuccess[entities.OutComment]
+//	@Failure		400		{object}	entities.RetFailed
+//	@Failure		404		{object}	entities.RetFailed
+//	@Failure		429		{string}	string	"Too Many Requests"
+//	@Failure		500		{object}	entities.RetFailed
+//	@Router			/blogs/{id}/comments [post]
+func (c *Comments) CreateComment(w http.ResponseWriter, r *http.Request) error {
+	slog.Debug("CreateComment")
+
+	blogID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		slog.Error("CreateComment: id path param to int failed", "error", err)
+		return entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
+	}
+
+	// utf-8 characters are at most 4 bytes, leave some room for the rest of the body
+	r.Body = http.MaxBytesReader(w, r.Body, int64(c.config.MaxLength+c.config.AuthorMaxLength)*4+1024)
+	body := &entities.InComment{}
+	if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+		slog.Error("CreateComment: decode failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
+	}
+	author := strings.TrimSpace(body.Author)
+	content := strings.TrimSpace(body.Content)
+	comment := entities.NewComment(blogID, body.ParentID, author, content)
+
+	// pretend everything went fine, so that bots don't learn anything
+	if body.Website != "" {
+		slog.Warn("CreateComment: honeypot filled, dropping comment", "blogID", blogID)
+		return entities.NewRetSuccess(*entities.NewOutComment(*comment, "")).WriteJSON(w)
+	}
+
+	if author == "" || content == "" {
+		return entities.NewRetFailed(ErrorCommentEmpty, http.StatusBadRequest).WriteJSON(w)
+	}
+	if utf8.RuneCountInString(author) > c.config.AuthorMaxLength ||
+		utf8.RuneCountInString(content) > c.config.MaxLength {
+		return entities.NewRetFailed(ErrorCommentTooLong, http.StatusBadRequest).WriteJSON(w)
+	}
+
+	newComment, err := c.repo.Create(r.Context(), *comment)
+	if err != nil {
+		slog.Error("CreateComment: repo create failed", "error", err.Error())
+		if errors.Is(err, sql.ErrNoRows) {
+			return entities.NewRetFailed(ErrorTargetNotFound, http.StatusNotFound).WriteJSON(w)
+		}
+
+		if sqliteErr, ok := getSQLiteError(err); ok {
+			slog.Error("got sqlite error", "error code", sqliteErr.Code, "extended error code", sqliteErr.ExtendedCode)
+			return entities.NewRetFailedCustom(err, int(sqliteErr.ExtendedCode), http.StatusInternalServerError).WriteJSON(w)
+		}
+
+		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+	}
+
+	outComment, err := c.render(*newComment)
+	if err != nil {
+		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+	}
+
+	return entities.NewRetSuccess(*outComment).WriteJSON(w)
+}
+
+// ListComments
+//
+//	@Summary		List comments
+//	@Description	list approved comments of a blog as threads, oldest first.
+//	@Description	replies to comments that are no longer approved are not included.
+//	@Tags			comments
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		int	true	"target blog id"
+//	@Success		200	{object}	entities.RetSuccess[[]entities.OutComment]
+//	@Failure		400	{object}	entities.RetFailed
+//	@Failure		404	{object}	entities.RetFailed
+//	@Failure		500	{object}	entities.RetFailed
+//	@Router			/blogs/{id}/comments [get]
+func (c *Comments) ListComments(w http.ResponseWriter, r *http.Request) error {
+	slog.Debug("ListComments")
+
+	blogID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		slog.Error("ListComments: id path param to int failed", "error", err)
+		return entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
+	}
+
+	comments, err := c.repo.ListApproved(r.Context(), blogID)
+	if err != nil {
+		slog.Error("ListComments: repo list failed", "error", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return entities.NewRetFailed(ErrorTargetNotFound, http.StatusNotFound).WriteJSON(w)
+		}
+		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+	}
+
+	outComments, err := c.renderAll(comments)
+	if err != nil {
+		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+	}
+
+	return entities.NewRetSuccess(buildCommentTree(outComments)).WriteJSON(w)
+}
+
+// ListCommentsByStatus
+//
+//	@Summary		List comments by status
+//	@Description	moderation queue, list comments across all blogs by status, oldest first
+//	@Tags			comments
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string	true	"jwt token"
+//	@Param			status			query		string	false	"comment status"	Enums(pending, approved, rejected)	default(pending)
+//	@Success		200				{object}	entities.RetSuccess[[]entities.OutComment]
+//	@Failure		400				{object}	entities.RetFailed
+//	@Failure		403				{object}	entities.RetFailed
+//	@Failure		500				{object}	entities.RetFailed
+//	@Router			/comments [get]
+func (c *Comments) ListCommentsByStatus(w http.ResponseWriter, r *http.Request) error {
+	slog.Debug("ListCommentsByStatus")
+
+	// authorization
+	authorized, err := c.auth.Verify(r)
+	if err != nil || !authorized {
+		slog.Warn("ListCommentsByStatus: authorization failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusForbidden).WriteJSON(w)
+	}
+
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "":
+		status = entities.CommentPending
+	case entities.CommentPending, entities.CommentApproved, entities.CommentRejected:
+	default:
+		return entities.NewRetFailed(ErrorInvalidCommentStatus, http.StatusBadRequest).WriteJSON(w)
+	}
+
+	comments, err := c.repo.ListByStatus(r.Context(), status)
+	if err != nil {
+		slog.Error("ListCommentsByStatus: repo list failed", "error", err)
+		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+	}
+
+	outComments, err := c.renderAll(comments)
+	if err != nil {
+		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+	}
+
+	return entities.NewRetSuccess(outComments).WriteJSON(w)
+}
+
+// ApproveComment
+//
+//	@Summary		Approve comment
+//	@Description	approved comments are listed publicly
+//	@Tags			comments
+//	@Accept			json
+//	@Produce		json
+//	@Param			id				path		int		true	"target comment id"
+//	@Param			Authorization	header		string	true	"jwt token"
+//	@Success		200				{object}	entities.RetSuccess[entities.Comment]
+//	@Failure		400				{object}	entities.RetFailed
+//	@Failure		403				{object}	entities.RetFailed
+//	@Failure		404				{object}	entities.RetFailed
+//	@Failure		500				{object}	entities.RetFailed
+//	@Router			/comments/{id}/approve [patch]
+func (c *Comments) ApproveComment(w http.ResponseWriter, r *http.Request) error {
+	slog.Debug("ApproveComment")
+	return c.updateStatus(w, r, "ApproveComment", entities.CommentApproved)
+}
+
+// RejectComment
+//
+//	@Summary		Reject comment
+//	@Description	rejected comments and their replies are hidden, but kept for reference
+//	@Tags			comments
+//	@Accept			json
+//	@Produce		json
+//	@Param			id				path		int		true	"target comment id"
+//	@Param			Authorization	header		string	true	"jwt token"
+//	@Success		200				{object}	entities.RetSuccess[entities.Comment]
+//	@Failure		400				{object}	entities.RetFailed
+//	@Failure		403				{object}	entities.RetFailed
+//	@Failure		404				{object}	entities.RetFailed
+//	@Failure		500				{object}	entities.RetFailed
+//	@Router			/comments/{id}/reject [patch]
+func (c *Comments) RejectComment(w http.ResponseWriter, r *http.Request) error {
+	slog.Debug("RejectComment")
+	return c.updateStatus(w, r, "RejectComment", entities.CommentRejected)
+}
+
+// DeleteComment
+//
+//	@Summary		Delete comment
+//	@Description	delete comment and all of its replies
+//	@Tags			comments
+//	@Accept			json
+//	@Produce		json
+//	@Param			id				path		int		true	"target comment id"
+//	@Param			Authorization	header		string	true	"jwt token"
+//	@Success		200				{object}	entities.RetSuccess[entities.RowsAffected]
+//	@Failure		400				{object}	entities.RetFailed
+//	@Failure		403				{object}	entities.RetFailed
+//	@Failure		404				{object}	entities.RetFailed
+//	@Failure		500				{object}	entities.RetFailed
+//	@Router			/comments/{id} [delete]
+func (c *Comments) DeleteComment(w http.ResponseWriter, r *http.Request) error {
+	slog.Info("DeleteComment")
+
+	// authorization
+	authorized, err := c.auth.Verify(r)
+	if err != nil || !authorized {
+		slog.Warn("DeleteComment: authorization failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusForbidden).WriteJSON(w)
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		slog.Error("DeleteComment: id string to int failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
+	}
+
+	affectedRows, err := c.repo.Delete(r.Context(), id)
+	if err != nil {
+		slog.Error("DeleteComment: repo delete failed", "error", err.Error())
+
+		if sqliteErr, ok := getSQLiteError(err); ok {
+			slog.Error("got sqlite error", "error code", sqliteErr.Code, "extended error code", sqliteErr.ExtendedCode)
+			return entities.NewRetFailedCustom(err, int(sqliteErr.ExtendedCode), http.StatusInternalServerError).WriteJSON(w)
+		}
+
+		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+	}
+
+	if affectedRows == 0 {
+		return entities.NewRetFailed(ErrorTargetNotFound, http.StatusNotFound).WriteJSON(w)
+	}
+	return entities.NewRetSuccess(*entities.NewRowsAffected(affectedRows)).WriteJSON(w)
+}
+
+// Shared by approve and reject
+func (c *Comments) updateStatus(w http.ResponseWriter, r *http.Request, name, status string) error {
+	// authorization
+	authorized, err := c.auth.Verify(r)
+	if err != nil || !authorized {
+		slog.Warn(name+": authorization failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusForbidden).WriteJSON(w)
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		slog.Error(name+": id string to int failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
+	}
+
+	comment, err := c.repo.UpdateStatus(r.Context(), id, status)
+	if err != nil {
+		slog.Error(name+": repo update status failed", "error", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return entities.NewRetFailed(ErrorTargetNotFound, http.StatusNotFound).WriteJSON(w)
+		}
+
+		if sqliteErr, ok := getSQLiteError(err); ok {
+			slog.Error("got sqlite error", "error code", sqliteErr.Code, "extended error code", sqliteErr.ExtendedCode)
+			return entities.NewRetFailedCustom(err, int(sqliteErr.ExtendedCode), http.StatusInternalServerError).WriteJSON(w)
+		}
+
+		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+	}
+
+	return entities.NewRetSuccess(*comment).WriteJSON(w)
+}
+
+func (c *Comments) render(comment entities.Comment) (*entities.OutComment, error) {
+	var buf bytes.Buffer
+	if err := c.md.Convert([]byte(comment.Content), &buf); err != nil {
+		slog.Error("render: convert comment failed", "id", comment.ID, "error", err)
+		return &entities.OutComment{}, err
+	}
+	return entities.NewOutComment(comment, buf.String()), nil
+}
+
+func (c *Comments) renderAll(comments []entities.Comment) ([]entities.OutComment, error) {
+	result := make([]entities.OutComment, 0, len(comments))
+	for _, comment := range comments {
+		outComment, err := c.render(comment)
+		if err != nil {
+			return []entities.OutComment{}, err
+		}
+		result = append(result, *outComment)
+	}
+	return result, nil
+}
+
+// Nest replies under their parents, keeping the original order.
+// Replies whose parent is not in the list are dropped.
+func buildCommentTree(comments []entities.OutComment) []entities.OutComment {
+	children := map[int][]entities.OutComment{}
+	for _, comment := range comments {
+		children[comment.ParentID] = append(children[comment.ParentID], comment)
+	}
+
+	var attach func(parentID int) []entities.OutComment
+	attach = func(parentID int) []entities.OutComment {
+		replies := children[parentID]
+		for i := range replies {
+			replies[i].Replies = attach(replies[i].ID)
+		}
+		return replies
+	}
+
+	roots := attach(0)
+	if roots == nil {
+		return []entities.OutComment{}
+	}
+	return roots
+}
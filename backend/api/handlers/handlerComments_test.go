@@ -0,0 +1,171 @@
+package handlers_test
+
+import (
+	"blog/api/handlers"
+	"blog/config"
+	"blog/entities"
+	"blog/markdown"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type DummyCommentsRepo struct {
+	created int
+}
+
+func (d *DummyCommentsRepo) Create(ctx context.Context, comment entities.Comment) (*entities.Comment, error) {
+	d.created++
+	comment.ID = d.created
+	return &comment, nil
+}
+func (d *DummyCommentsRepo) ListApproved(ctx context.Context, blogID int) ([]entities.Comment, error) {
+	return []entities.Comment{
+		{ID: 1, BlogID: blogID, Content: "root 1"},
+		{ID: 2, BlogID: blogID, Content: "root 2"},
+		{ID: 3, BlogID: blogID, ParentID: 1, Content: "reply to 1"},
+		{ID: 4, BlogID: blogID, ParentID: 3, Content: "reply to 3"},
+		{ID: 5, BlogID: blogID, ParentID: 9, Content: "parent not approved"},
+	}, nil
+}
+func (d *DummyCommentsRepo) ListByStatus(ctx context.Context, status string) ([]entities.Comment, error) {
+	return []entities.Comment{}, nil
+}
+func (d *DummyCommentsRepo) UpdateStatus(ctx context.Context, id int, status string) (*entities.Comment, error) {
+	return &entities.Comment{ID: id, Status: status}, nil
+}
+func (d *DummyCommentsRepo) Delete(ctx context.Context, id int) (int, error) {
+	return 1, nil
+}
+
+func initComments() (*handlers.Comments, *DummyCommentsRepo) {
+	repo := &DummyCommentsRepo{}
+	auth := &DummyAuthHelper{}
+	return handlers.NewComments(repo, auth, markdown.NewSafe(), config.NewConfig().Comments), repo
+}
+
+func TestHandlerCommentsCreate(t *testing.T) {
+	comments, repo := initComments()
+
+	reqBody := bytes.Buffer{}
+	if err := json.NewEncoder(&reqBody).Encode(entities.InComment{Author: " me ", Content: "<b>hi</b> **there**"}); err != nil {
+		t.Fatalf("TestHandlerCommentsCreate: encode request body failed: %s", err)
+	}
+	r := httptest.NewRequest(http.MethodPost, "/blogs/1/comments", &reqBody)
+	r.SetPathValue("id", "1")
+	w := httptest.NewRecorder()
+
+	if err := comments.CreateComment(w, r); err != nil {
+		t.Fatalf("TestHandlerCommentsCreate: create comment failed: %s", err)
+	}
+
+	resData := entities.RetSuccess[entities.OutComment]{}
+	if err := json.NewDecoder(w.Result().Body).Decode(&resData); err != nil {
+		t.Fatalf("TestHandlerCommentsCreate: read response body failed: %s", err)
+	}
+	if resData.Status != http.StatusOK || repo.created != 1 {
+		t.Fatalf("TestHandlerCommentsCreate: comment should be created")
+	}
+	if resData.Msg.Author != "me" || resData.Msg.Status != entities.CommentPending {
+		t.Fatalf("TestHandlerCommentsCreate: unexpected comment %+v", resData.Msg.Comment)
+	}
+	if strings.Contains(resData.Msg.HTML, "<b>") || !strings.Contains(resData.Msg.HTML, "<strong>there</strong>") {
+		t.Fatalf("TestHandlerCommentsCreate: html not sanitized, got %s", resData.Msg.HTML)
+	}
+}
+
+func TestHandlerCommentsCreateHoneypot(t *testing.T) {
+	comments, repo := initComments()
+
+	reqBody := bytes.Buffer{}
+	if err := json.NewEncoder(&reqBody).Encode(entities.InComment{Author: "bot", Content: "spam", Website: "http://spam"}); err != nil {
+		t.Fatalf("TestHandlerCommentsCreateHoneypot: encode request body failed: %s", err)
+	}
+	r := httptest.NewRequest(http.MethodPost, "/blogs/1/comments", &reqBody)
+	r.SetPathValue("id", "1")
+	w := httptest.NewRecorder()
+
+	if err := comments.CreateComment(w, r); err != nil {
+		t.Fatalf("TestHandlerCommentsCreateHoneypot: create comment failed: %s", err)
+	}
+	if w.Result().StatusCode != http.StatusOK {
+		t.Fatalf("TestHandlerCommentsCreateHoneypot: bots should see a success")
+	}
+	if repo.created != 0 {
+		t.Fatalf("TestHandlerCommentsCreateHoneypot: comment should not be stored")
+	}
+}
+
+func TestHandlerCommentsCreateEmpty(t *testing.T) {
+	comments, _ := initComments()
+
+	reqBody := bytes.Buffer{}
+	if err := json.NewEncoder(&reqBody).Encode(entities.InComment{Author: "me", Content: "   "}); err != nil {
+		t.Fatalf("TestHandlerCommentsCreateEmpty: encode request body failed: %s", err)
+	}
+	r := httptest.NewRequest(http.MethodPost, "/blogs/1/comments", &reqBody)
+	r.SetPathValue("id", "1")
+	w := httptest.NewRecorder()
+
+	if err := comments.CreateComment(w, r); err != nil {
+		t.Fatalf("TestHandlerCommentsCreateEmpty: create comment failed: %s", err)
+	}
+
+	resData := entities.RetFailed{}
+	if err := json.NewDecoder(w.Result().Body).Decode(&resData); err != nil {
+		t.Fatalf("TestHandlerCommentsCreateEmpty: read response body failed: %s", err)
+	}
+	if resData.Status != http.StatusBadRequest || resData.Error != handlers.ErrorCommentEmpty.Error() {
+		t.Fatalf("TestHandlerCommentsCreateEmpty: should fail with bad request, got %+v", resData)
+	}
+}
+
+func TestHandlerCommentsList(t *testing.T) {
+	comments, _ := initComments()
+
+	r := httptest.NewRequest(http.MethodGet, "/blogs/1/comments", nil)
+	r.SetPathValue("id", "1")
+	w := httptest.NewRecorder()
+
+	if err := comments.ListComments(w, r); err != nil {
+		t.Fatalf("TestHandlerCommentsList: list comments failed: %s", err)
+	}
+
+	resData := entities.RetSuccess[[]entities.OutComment]{}
+	if err := json.NewDecoder(w.Result().Body).Decode(&resData); err != nil {
+		t.Fatalf("TestHandlerCommentsList: read response body failed: %s", err)
+	}
+
+	tree := resData.Msg
+	if len(tree) != 2 || tree[0].ID != 1 || tree[1].ID != 2 {
+		t.Fatalf("TestHandlerCommentsList: unexpected roots %+v", tree)
+	}
+	if len(tree[0].Replies) != 1 || tree[0].Replies[0].ID != 3 {
+		t.Fatalf("TestHandlerCommentsList: unexpected replies %+v", tree[0].Replies)
+	}
+	if len(tree[0].Replies[0].Replies) != 1 || tree[0].Replies[0].Replies[0].ID != 4 {
+		t.Fatalf("TestHandlerCommentsList: nested reply missing")
+	}
+	if tree[0].HTML != "<p>root 1</p>\n" {
+		t.Fatalf("TestHandlerCommentsList: content not rendered, got %q", tree[0].HTML)
+	}
+}
+
+func TestHandlerCommentsApproveAuthFail(t *testing.T) {
+	comments, _ := initComments()
+
+	r := httptest.NewRequest(http.MethodPatch, "/comments/1/approve", nil)
+	r.SetPathValue("id", "1")
+	w := httptest.NewRecorder()
+
+	if err := comments.ApproveComment(w, r); err != nil {
+		t.Fatalf("TestHandlerCommentsApproveAuthFail: approve failed: %s", err)
+	}
+	if w.Result().StatusCode != http.StatusForbidden {
+		t.Fatalf("TestHandlerCommentsApproveAuthFail: should be forbidden")
+	}
+}
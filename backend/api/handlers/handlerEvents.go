@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"blog/config"
+	"blog/pubsub"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Implemented by pubsub.Broker
+type eventsBroker interface {
+	Subscribe(lastEventID string) (*pubsub.Subscription, []pubsub.Event, bool)
+	Close()
+}
+
+type Events struct {
+	broker eventsBroker
+	config config.EventsSetting
+}
+
+func NewEvents(broker eventsBroker, config config.EventsSetting) *Events {
+	return &Events{
+		broker: broker,
+		config: config,
+	}
+}
+
+// StreamEvents
+//
+//	@Summary		Stream content changes
+//	@Description	server-sent events of blog, tag and topic changes, same event types and data as webhooks,
+//	@Description	blogs that aren't published only have their id.
+//	@Description	reconnecting with Last-Event-ID replays missed events while they are still buffered,
+//	@Description	otherwise a 'reset' event is sent first and cached content should be dropped.
+//	@Tags			events
+//	@Produce		text/event-stream
+//	@Param			Last-Event-ID	header		string	false	"id of the last received event"
+//	@Param			lastEventId		query		string	false	"same as Last-Event-ID, for clients that can't set headers"
+//	@Success		200				{string}	string	"event stream"
+//	@Router			/events [get]
+func (e *Events) StreamEvents(w http.ResponseWriter, r *http.Request) error {
+	slog.Debug("StreamEvents")
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("lastEventId")
+	}
+
+	sub, missed, resumed := e.broker.Subscribe(lastEventID)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	// disable response buffering of nginx
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	rc := http.NewResponseController(w)
+	if !resumed {
+		if _, err := fmt.Fprint(w, "event: reset\ndata: {}\n\n"); err != nil {
+			return nil
+		}
+	}
+	for _, event := range missed {
+		if err := writeEvent(w, event); err != nil {
+			return nil
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		slog.Error("StreamEvents: flush failed", "error", err)
+		return nil
+	}
+
+	heartbeat := time.NewTicker(time.Duration(e.config.Heartbeat) * time.Second)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return nil
+		case event, ok := <-sub.Events():
+			// dropped for being too slow, or shutting down
+			if !ok {
+				return nil
+			}
+			if err := writeEvent(w, event); err != nil {
+				return nil
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return nil
+			}
+		}
+
+		if err := rc.Flush(); err != nil {
+			return nil
+		}
+	}
+}
+
+// Close ends all streams, so that graceful shutdown doesn't wait for them
+func (e *Events) Close() {
+	e.broker.Close()
+}
+
+func writeEvent(w http.ResponseWriter, event pubsub.Event) error {
+	_, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data)
+	return err
+}
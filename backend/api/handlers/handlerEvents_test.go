@@ -0,0 +1,53 @@
+package handlers_test
+
+import (
+	"blog/api/handlers"
+	"blog/config"
+	"blog/pubsub"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestHandlerEventsStream(t *testing.T) {
+	broker := pubsub.NewBroker(10, 10)
+	events := handlers.NewEvents(broker, config.NewConfig().Events)
+
+	sub, _, _ := broker.Subscribe("")
+	broker.Publish("blog.created", map[string]int{"id": 1})
+	broker.Publish("blog.updated", map[string]int{"id": 1})
+	first := <-sub.Events()
+	second := <-sub.Events()
+	sub.Close()
+
+	stream := func(lastEventID string) *httptest.ResponseRecorder {
+		// canceled right away, the handler only sends the missed events
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		r := httptest.NewRequest(http.MethodGet, "/events", nil).WithContext(ctx)
+		r.Header.Set("Last-Event-ID", lastEventID)
+		w := httptest.NewRecorder()
+		if err := events.StreamEvents(w, r); err != nil {
+			t.Fatalf("TestHandlerEventsStream: stream events failed: %s", err)
+		}
+		return w
+	}
+
+	w := stream(strconv.FormatUint(first.ID, 10))
+	if w.Header().Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("TestHandlerEventsStream: unexpected content type %s", w.Header().Get("Content-Type"))
+	}
+	expected := fmt.Sprintf("id: %d\nevent: blog.updated\ndata: {\"id\":1}\n\n", second.ID)
+	if w.Body.String() != expected {
+		t.Fatalf("TestHandlerEventsStream: should replay missed event, got %q", w.Body.String())
+	}
+
+	w = stream("abc")
+	if !strings.HasPrefix(w.Body.String(), "event: reset\n") {
+		t.Fatalf("TestHandlerEventsStream: unknown last event id should reset, got %q", w.Body.String())
+	}
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"blog/config"
+	"blog/entities"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+var ErrorInvalidIdempotencyKey = errors.New("Idempotency-Key should be at most 255 characters")
+
+const maxIdempotencyKeyLength = 255
+
+// Concrete implementations are at repository/<name>
+type idempotencyRepository interface {
+	Begin(ctx context.Context, key entities.IdempotencyKey) (*entities.IdempotencyKey, error)
+	Complete(ctx context.Context, key entities.IdempotencyKey) error
+	Release(ctx context.Context, actor, key string) error
+}
+
+type Idempotency struct {
+	repo   idempotencyRepository
+	auth   authHelper
+	config config.IdempotencySetting
+}
+
+func NewIdempotency(repo idempotencyRepository, auth authHelper, config config.IdempotencySetting) *Idempotency {
+	return &Idempotency{
+		repo:   repo,
+		auth:   auth,
+		config: config,
+	}
+}
+
+/*
+Handle is a middleware making authenticated requests with an Idempotency-Key header safe to retry.
+
+The first request claims the key, its response is stored if it succeeded and replayed for retries
+with the same method, path and body until the key expires. Failed requests release the key.
+*/
+func (i *Idempotency) Handle(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rawKey := r.Header.Get("Idempotency-Key")
+		if rawKey == "" {
+			next(w, r)
+			return
+		}
+		if len(rawKey) > maxIdempotencyKeyLength {
+			entities.NewRetFailed(ErrorInvalidIdempotencyKey, http.StatusBadRequest).WriteJSON(w)
+			return
+		}
+
+		// not authenticated, the handler rejects it
+		authorized, err := i.auth.Verify(r)
+		if err != nil || !authorized {
+			next(w, r)
+			return
+		}
+		actor, err := i.auth.UserName(r)
+		if err != nil {
+			slog.Error("Handle: get user name failed", "error", err)
+			entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			slog.Error("Handle: read body failed", "error", err)
+			entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.New()
+		hash.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+		hash.Write(body)
+
+		lockedUntil := time.Now().Add(time.Duration(i.config.Lock) * time.Second)
+		key := entities.NewIdempotencyKey(actor, rawKey, hex.EncodeToString(hash.Sum(nil)), lockedUntil.UTC().Format("2006-01-02T15:04:05-07:00"))
+
+		stored, err := i.repo.Begin(r.Context(), *key)
+		if err != nil {
+			slog.Warn("Handle: begin idempotent request failed", "error", err, "key", rawKey)
+			switch {
+			case errors.Is(err, entities.ErrorIdempotencyKeyReused):
+				entities.NewRetFailed(entities.ErrorIdempotencyKeyReused, http.StatusUnprocessableEntity).WriteJSON(w)
+			case errors.Is(err, entities.ErrorIdempotencyInProgress):
+				entities.NewRetFailed(entities.ErrorIdempotencyInProgress, http.StatusConflict).WriteJSON(w)
+			default:
+				entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+			}
+			return
+		}
+
+		// retry, replay the stored response
+		if stored != nil {
+			slog.Info("Handle: replay idempotent request", "key", rawKey)
+			if stored.ContentType != "" {
+				w.Header().Set("Content-Type", stored.ContentType)
+			}
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(stored.Status)
+			w.Write(stored.Response)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: w}
+		next(recorder, r)
+
+		// the request is done, keep the result even if the client went away
+		ctx := context.WithoutCancel(r.Context())
+
+		if recorder.status < 200 || recorder.status >= 300 {
+			if err := i.repo.Release(ctx, actor, rawKey); err != nil {
+				slog.Error("Handle: release idempotency key failed", "error", err, "key", rawKey)
+			}
+			return
+		}
+
+		key.Status = recorder.status
+		key.ContentType = w.Header().Get("Content-Type")
+		key.Response = recorder.body.Bytes()
+		key.Expires_at = time.Now().Add(time.Duration(i.config.TTL) * time.Hour).UTC().Format("2006-01-02T15:04:05-07:00")
+		if err := i.repo.Complete(ctx, *key); err != nil {
+			slog.Error("Handle: store idempotent response failed", "error", err, "key", rawKey)
+		}
+	}
+}
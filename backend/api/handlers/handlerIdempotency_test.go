@@ -0,0 +1,113 @@
+package handlers_test
+
+import (
+	"blog/api/handlers"
+	"blog/config"
+	"blog/entities"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type DummyIdempotencyRepo struct {
+	keys     map[string]entities.IdempotencyKey
+	released []string
+}
+
+func (d *DummyIdempotencyRepo) Begin(ctx context.Context, key entities.IdempotencyKey) (*entities.IdempotencyKey, error) {
+	stored, ok := d.keys[key.Actor+"/"+key.Key]
+	if !ok {
+		d.keys[key.Actor+"/"+key.Key] = key
+		return nil, nil
+	}
+	if stored.InProgress() {
+		return nil, fmt.Errorf("Begin: %w", entities.ErrorIdempotencyInProgress)
+	}
+	if stored.RequestHash != key.RequestHash {
+		return nil, fmt.Errorf("Begin: %w", entities.ErrorIdempotencyKeyReused)
+	}
+	return &stored, nil
+}
+func (d *DummyIdempotencyRepo) Complete(ctx context.Context, key entities.IdempotencyKey) error {
+	d.keys[key.Actor+"/"+key.Key] = key
+	return nil
+}
+func (d *DummyIdempotencyRepo) Release(ctx context.Context, actor, key string) error {
+	d.released = append(d.released, actor+"/"+key)
+	delete(d.keys, actor+"/"+key)
+	return nil
+}
+
+func TestHandlerIdempotency(t *testing.T) {
+	repo := &DummyIdempotencyRepo{keys: map[string]entities.IdempotencyKey{}}
+	idempotency := handlers.NewIdempotency(repo, &DummyAuthHelper{}, config.NewConfig().Idempotency)
+
+	calls := 0
+	create := idempotency.Handle(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := io.ReadAll(r.Body)
+		if string(body) == `{"name": "fail"}` {
+			entities.NewRetFailed(handlers.ErrorTargetNotFound, http.StatusBadRequest).WriteJSON(w)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"call": %d}`, calls)
+	})
+	request := func(key, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/tags", bytes.NewBufferString(body))
+		r.Header.Set("Authorization", "Bearer aaa.bbb.ccc")
+		if key != "" {
+			r.Header.Set("Idempotency-Key", key)
+		}
+		w := httptest.NewRecorder()
+		create(w, r)
+		return w
+	}
+
+	// first request
+	w := request("k1", `{"name": "go"}`)
+	if w.Code != http.StatusCreated || w.Body.String() != `{"call": 1}` {
+		t.Fatalf("TestHandlerIdempotency: first request should be handled, got %d %s", w.Code, w.Body.String())
+	}
+
+	// retry is replayed
+	w = request("k1", `{"name": "go"}`)
+	if w.Code != http.StatusCreated || w.Body.String() != `{"call": 1}` || w.Header().Get("Idempotent-Replayed") != "true" || calls != 1 {
+		t.Fatalf("TestHandlerIdempotency: retry should be replayed, got %d %s after %d calls", w.Code, w.Body.String(), calls)
+	}
+
+	// reused key with a different body
+	w = request("k1", `{"name": "rust"}`)
+	if w.Code != http.StatusUnprocessableEntity || calls != 1 {
+		t.Fatalf("TestHandlerIdempotency: reused key should return 422, got %d", w.Code)
+	}
+
+	// in progress
+	repo.keys["dummy/k2"] = entities.IdempotencyKey{Actor: "dummy", Key: "k2"}
+	w = request("k2", "")
+	if w.Code != http.StatusConflict || calls != 1 {
+		t.Fatalf("TestHandlerIdempotency: key in progress should return 409, got %d", w.Code)
+	}
+
+	// failures release the key
+	w = request("k3", `{"name": "fail"}`)
+	if w.Code != http.StatusBadRequest || len(repo.released) != 1 || repo.released[0] != "dummy/k3" {
+		t.Fatalf("TestHandlerIdempotency: failure should release the key, got %d %v", w.Code, repo.released)
+	}
+
+	// without key
+	w = request("", `{"name": "go"}`)
+	if w.Code != http.StatusCreated || calls != 3 {
+		t.Fatalf("TestHandlerIdempotency: request without key should be handled, got %d after %d calls", w.Code, calls)
+	}
+
+	// key too long
+	w = request(string(bytes.Repeat([]byte("k"), 256)), `{"name": "go"}`)
+	if w.Code != http.StatusBadRequest || calls != 3 {
+		t.Fatalf("TestHandlerIdempotency: long key should return 400, got %d", w.Code)
+	}
+}
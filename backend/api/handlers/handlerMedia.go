@@ -0,0 +1,524 @@
+package handlers
+
+import (
+	"blog/config"
+	"blog/entities"
+	"blog/imaging"
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrorMediaInUse      = errors.New("media is referenced by blogs")
+	ErrorMediaFileEmpty  = errors.New("media file empty")
+	ErrorMediaFileAbsent = errors.New("form field 'file' is required")
+	ErrorMediaNotImage   = errors.New("media is not a supported image")
+	ErrorMediaTooLarge   = errors.New("image too large to resize")
+	ErrorInvalidWidth    = errors.New("width must be one of the srcset widths")
+)
+
+// Decoding is done in memory, limit resizing to images under 50 megapixels
+const maxVariantPixels = 50_000_000
+
+// Concrete implementations are at repository/<name>
+type mediaRepository interface {
+	Create(ctx context.Context, media entities.Media) (*entities.Media, error)
+	Get(ctx context.Context, hash string) (*entities.Media, error)
+	List(ctx context.Context) ([]entities.OutMedia, error)
+	ListUnused(ctx context.Context, before string) ([]entities.Media, error)
+	Delete(ctx context.Context, hash string) (int, error)
+	DeleteUnused(ctx context.Context, before string) ([]entities.Media, error)
+}
+
+// Concrete implementations are at storage/<name>
+type mediaStorage interface {
+	Put(r io.Reader) (string, int64, error)
+	Open(hash string) (*os.File, error)
+	Remove(hash string) error
+}
+
+// Concrete implementations are at storage/<name>
+type mediaVariants interface {
+	GetOrCreate(key string, generate func() ([]byte, error)) (*os.File, error)
+	// remove all variants of a media
+	Remove(hash string) error
+}
+
+type Media struct {
+	repo     mediaRepository
+	storage  mediaStorage
+	variants mediaVariants
+	auth     authHelper
+	config   config.MediaSetting
+}
+
+func NewMedia(repo mediaRepository, storage mediaStorage, variants mediaVariants, auth authHelper, config config.MediaSetting) *Media {
+	return &Media{
+		repo:     repo,
+		storage:  storage,
+		variants: variants,
+		auth:     auth,
+		config:   config,
+	}
+}
+
+// UploadMedia
+//
+//	@Summary		Upload media
+//	@Description	upload a file, files are stored by the sha256 of their content, uploading the same file twice returns the existing media
+//	@Tags			media
+//	@Accept			multipart/form-data
+//	@Produce		json
+//	@Param			file			formData	file	true	"file to upload"
+//	@Param			alt				formData	string	false	"alt text"
+//	@Param			Authorization	header		string	true	"jwt token"
+//	@Success		200				{object}	entities.RetSuccess[entities.Media]
+//	@Failure		400				{object}	entities.RetFailed
+//	@Failure		403				{object}	entities.RetFailed
+//	@Failure		413				{object}	entities.RetFailed
+//	@Failure		500				{object}	entities.RetFailed
+//	@Router			/media [post]
+func (m *Media) UploadMedia(w http.ResponseWriter, r *http.Request) error {
+	slog.Debug("UploadMedia")
+
+	// authorization
+	authorized, err := m.auth.Verify(r)
+	if err != nil || !authorized {
+		slog.Warn("UploadMedia: authorization failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusForbidden).WriteJSON(w)
+	}
+
+	// limit upload size, leave some room for the other form fields
+	maxSize := int64(m.config.MaxSize) << 20
+	r.Body = http.MaxBytesReader(w, r.Body, maxSize+(1<<20))
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		slog.Error("UploadMedia: parse multipart form failed", "error", err.Error())
+		maxBytesErr := &http.MaxBytesError{}
+		if errors.As(err, &maxBytesErr) {
+			return entities.NewRetFailed(err, http.StatusRequestEntityTooLarge).WriteJSON(w)
+		}
+		return entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		slog.Error("UploadMedia: get form file failed", "error", err.Error())
+		return entities.NewRetFailed(ErrorMediaFileAbsent, http.StatusBadRequest).WriteJSON(w)
+	}
+	defer file.Close()
+
+	if header.Size == 0 {
+		return entities.NewRetFailed(ErrorMediaFileEmpty, http.StatusBadRequest).WriteJSON(w)
+	}
+	if header.Size > maxSize {
+		err := fmt.Errorf("file size exceeds %d MB", m.config.MaxSize)
+		return entities.NewRetFailed(err, http.StatusRequestEntityTooLarge).WriteJSON(w)
+	}
+
+	// detect content type from content, the client supplied one is not trusted
+	sniff := make([]byte, 512)
+	n, err := io.ReadFull(file, sniff)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		slog.Error("UploadMedia: read file failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
+	}
+	mime := http.DetectContentType(sniff[:n])
+
+	// dimensions, only for image formats with decoders registered by blog/imaging
+	width, height := 0, 0
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		slog.Error("UploadMedia: seek file failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+	}
+	if imgConfig, _, err := image.DecodeConfig(file); err == nil {
+		width, height = imgConfig.Width, imgConfig.Height
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		slog.Error("UploadMedia: seek file failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+	}
+	hash, size, err := m.storage.Put(file)
+	if err != nil {
+		slog.Error("UploadMedia: storage put failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+	}
+
+	inMedia := entities.NewMedia(
+		hash,
+		header.Filename,
+		mime,
+		size,
+		width,
+		height,
+		r.FormValue("alt"),
+	)
+
+	outMedia, err := m.repo.Create(r.Context(), *inMedia)
+	if err != nil {
+		slog.Error("UploadMedia: repo create failed", "error", err.Error())
+
+		if sqliteErr, ok := getSQLiteError(err); ok {
+			slog.Error("got sqlite error", "error code", sqliteErr.Code, "extended error code", sqliteErr.ExtendedCode)
+			return entities.NewRetFailedCustom(err, int(sqliteErr.ExtendedCode), http.StatusInternalServerError).WriteJSON(w)
+		}
+
+		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+	}
+
+	return entities.NewRetSuccess(*outMedia).WriteJSON(w)
+}
+
+// GetMedia
+//
+//	@Summary		Get media
+//	@Description	get media file content by hash, content never changes for a given hash so it can be cached forever.
+//	@Description	images can be resized and converted on the fly, variants are cached on disk.
+//	@Description	files that aren't images are served as attachments
+//	@Tags			media
+//	@Produce		octet-stream
+//	@Param			hash	path		string	true	"sha256 of file content"
+//	@Param			w		query		int		false	"resize image to width (px), one of the srcset widths, never scales up"
+//	@Param			fmt		query		string	false	"convert image to format"	Enums(jpeg, png, gif, bmp, tiff)
+//	@Success		200		{file}		binary
+//	@Failure		400		{object}	entities.RetFailed
+//	@Failure		404		{object}	entities.RetFailed
+//	@Failure		500		{object}	entities.RetFailed
+//	@Router			/media/{hash} [get]
+func (m *Media) GetMedia(w http.ResponseWriter, r *http.Request) error {
+	slog.Debug("GetMedia")
+	var err error
+
+	hash := r.PathValue("hash")
+
+	// parse query params
+	queries := r.URL.Query()
+	slog.Debug("got queries", "queries", queries)
+
+	rawWidth := queries.Get("w")
+	width := 0
+	if rawWidth != "" {
+		// any other width would be resized and cached too, letting anyone fill the cache
+		width, err = strconv.Atoi(rawWidth)
+		if err != nil || !slices.Contains(m.config.SrcsetWidths, width) {
+			slog.Error("GetMedia: invalid 'w'", "w", rawWidth)
+			return entities.NewRetFailed(ErrorInvalidWidth, http.StatusBadRequest).WriteJSON(w)
+		}
+	}
+
+	rawFormat := queries.Get("fmt")
+	format := ""
+	if rawFormat != "" {
+		format, err = imaging.NormalizeFormat(rawFormat)
+		if err != nil {
+			slog.Error("GetMedia: invalid 'fmt'", "error", err)
+			return entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
+		}
+	}
+
+	media, err := m.repo.Get(r.Context(), hash)
+	if err != nil {
+		slog.Error("GetMedia: repo get failed", "error", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return entities.NewRetFailed(ErrorTargetNotFound, http.StatusNotFound).WriteJSON(w)
+		}
+
+		if sqliteErr, ok := getSQLiteError(err); ok {
+			slog.Error("got sqlite error", "error code", sqliteErr.Code, "extended error code", sqliteErr.ExtendedCode)
+			return entities.NewRetFailedCustom(err, int(sqliteErr.ExtendedCode), http.StatusInternalServerError).WriteJSON(w)
+		}
+
+		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+	}
+
+	modTime, _ := time.Parse(time.RFC3339, media.Created_at)
+
+	// original file
+	if rawWidth == "" && rawFormat == "" {
+		file, err := m.storage.Open(media.Hash)
+		if err != nil {
+			slog.Error("GetMedia: storage open failed", "error", err)
+			if errors.Is(err, os.ErrNotExist) {
+				return entities.NewRetFailed(ErrorTargetNotFound, http.StatusNotFound).WriteJSON(w)
+			}
+			return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+		}
+		defer file.Close()
+
+		// only images are shown inline, anything else is downloaded so it can't run as html on this origin
+		contentType := media.Mime
+		if !isImageMime(contentType) {
+			contentType = "application/octet-stream"
+			w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": media.Filename}))
+		}
+		serveImmutable(w, r, file, contentType, media.Hash, media.Filename, modTime)
+		return nil
+	}
+
+	// variant
+	if media.Width == 0 || media.Height == 0 {
+		return entities.NewRetFailed(ErrorMediaNotImage, http.StatusBadRequest).WriteJSON(w)
+	}
+	if media.Width*media.Height > maxVariantPixels {
+		return entities.NewRetFailed(ErrorMediaTooLarge, http.StatusBadRequest).WriteJSON(w)
+	}
+
+	// never scale up
+	if width == 0 || width > media.Width {
+		width = media.Width
+	}
+	// keep original format when possible
+	if format == "" {
+		if original, ok := imaging.FormatFromMime(media.Mime); ok {
+			format = original
+		} else {
+			format = "png"
+		}
+	}
+
+	key := fmt.Sprintf("%s_%d.%s", media.Hash, width, format)
+	file, err := m.variants.GetOrCreate(key, func() ([]byte, error) {
+		return m.genVariant(media.Hash, width, format)
+	})
+	if err != nil {
+		slog.Error("GetMedia: get variant failed", "key", key, "error", err)
+		if errors.Is(err, os.ErrNotExist) {
+			return entities.NewRetFailed(ErrorTargetNotFound, http.StatusNotFound).WriteJSON(w)
+		}
+		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+	}
+	defer file.Close()
+
+	serveImmutable(w, r, file, imaging.ContentType(format), key, media.Filename, modTime)
+	return nil
+}
+
+// Decode original media, resize and encode to the target format
+func (m *Media) genVariant(hash string, width int, format string) ([]byte, error) {
+	original, err := m.storage.Open(hash)
+	if err != nil {
+		return []byte{}, fmt.Errorf("genVariant: open original failed: %w", err)
+	}
+	defer original.Close()
+
+	img, _, err := image.Decode(original)
+	if err != nil {
+		return []byte{}, fmt.Errorf("genVariant: decode original failed: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := imaging.Encode(&buf, imaging.Resize(img, width), format); err != nil {
+		return []byte{}, fmt.Errorf("genVariant: encode variant failed: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Sniffed image types, svg is never sniffed as an image and could contain scripts
+func isImageMime(contentType string) bool {
+	return strings.HasPrefix(contentType, "image/") && contentType != "image/svg+xml"
+}
+
+// Content behind a given etag never changes, so it can be cached forever
+func serveImmutable(w http.ResponseWriter, r *http.Request, content io.ReadSeeker, contentType, etag, name string, modTime time.Time) {
+	w.Header().Set("Content-Type", contentType)
+	// browsers must not guess another type, like html, from the content
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.Header().Set("ETag", fmt.Sprintf("%q", etag))
+	http.ServeContent(w, r, name, modTime, content)
+}
+
+// ListMedia
+//
+//	@Summary		List media
+//	@Description	list all media with the ids of blogs referencing them
+//	@Tags			media
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string	true	"jwt token"
+//	@Success		200				{object}	entities.RetSuccess[[]entities.OutMedia]
+//	@Failure		403				{object}	entities.RetFailed
+//	@Failure		500				{object}	entities.RetFailed
+//	@Router			/media [get]
+func (m *Media) ListMedia(w http.ResponseWriter, r *http.Request) error {
+	slog.Debug("ListMedia")
+
+	// authorization
+	authorized, err := m.auth.Verify(r)
+	if err != nil || !authorized {
+		slog.Warn("ListMedia: authorization failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusForbidden).WriteJSON(w)
+	}
+
+	media, err := m.repo.List(r.Context())
+	if err != nil {
+		slog.Error("ListMedia: repo list failed", "error", err)
+
+		if sqliteErr, ok := getSQLiteError(err); ok {
+			slog.Error("got sqlite error", "error code", sqliteErr.Code, "extended error code", sqliteErr.ExtendedCode)
+			return entities.NewRetFailedCustom(err, int(sqliteErr.ExtendedCode), http.StatusInternalServerError).WriteJSON(w)
+		}
+
+		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+	}
+
+	return entities.NewRetSuccess(media).WriteJSON(w)
+}
+
+// DeleteMedia
+//
+//	@Summary		Delete media
+//	@Description	delete media and its file, media referenced by blogs can't be deleted
+//	@Tags			media
+//	@Accept			json
+//	@Produce		json
+//	@Param			hash			path		string	true	"sha256 of file content"
+//	@Param			Authorization	header		string	true	"jwt token"
+//	@Success		200				{object}	entities.RetSuccess[entities.RowsAffected]
+//	@Failure		403				{object}	entities.RetFailed
+//	@Failure		404				{object}	entities.RetFailed
+//	@Failure		409				{object}	entities.RetFailed
+//	@Failure		500				{object}	entities.RetFailed
+//	@Router			/media/{hash} [delete]
+func (m *Media) DeleteMedia(w http.ResponseWriter, r *http.Request) error {
+	slog.Info("DeleteMedia")
+
+	// authorization
+	authorized, err := m.auth.Verify(r)
+	if err != nil || !authorized {
+		slog.Warn("DeleteMedia: authorization failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusForbidden).WriteJSON(w)
+	}
+
+	hash := r.PathValue("hash")
+
+	affectedRows, err := m.repo.Delete(r.Context(), hash)
+	if err != nil {
+		slog.Error("DeleteMedia: repo delete failed", "error", err.Error())
+
+		if sqliteErr, ok := getSQLiteError(err); ok {
+			slog.Error("got sqlite error", "error code", sqliteErr.Code, "extended error code", sqliteErr.ExtendedCode)
+			return entities.NewRetFailedCustom(err, int(sqliteErr.ExtendedCode), http.StatusInternalServerError).WriteJSON(w)
+		}
+
+		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+	}
+
+	// differentiate if the media dosen't exist or is still in use
+	if affectedRows == 0 {
+		if _, err := m.repo.Get(r.Context(), hash); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return entities.NewRetFailed(ErrorTargetNotFound, http.StatusNotFound).WriteJSON(w)
+			}
+			slog.Error("DeleteMedia: repo get failed", "error", err.Error())
+			return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+		}
+		return entities.NewRetFailed(ErrorMediaInUse, http.StatusConflict).WriteJSON(w)
+	}
+
+	if err := m.storage.Remove(hash); err != nil {
+		// metadata is already gone, the file is only orphaned on disk
+		slog.Error("DeleteMedia: storage remove failed", "hash", hash, "error", err.Error())
+	}
+	if err := m.variants.Remove(hash); err != nil {
+		slog.Error("DeleteMedia: variants remove failed", "hash", hash, "error", err.Error())
+	}
+
+	return entities.NewRetSuccess(*entities.NewRowsAffected(affectedRows)).WriteJSON(w)
+}
+
+// DeleteUnusedMedia
+//
+//	@Summary		Delete unused media
+//	@Description	garbage collect media not referenced by any blog, media uploaded within the grace period are kept
+//	@Tags			media
+//	@Accept			json
+//	@Produce		json
+//	@Param			dryRun			query		bool	false	"only list media that would be deleted"
+//	@Param			Authorization	header		string	true	"jwt token"
+//	@Success		200				{object}	entities.RetSuccess[[]entities.Media]
+//	@Failure		400				{object}	entities.RetFailed
+//	@Failure		403				{object}	entities.RetFailed
+//	@Failure		500				{object}	entities.RetFailed
+//	@Router			/media/unused [delete]
+func (m *Media) DeleteUnusedMedia(w http.ResponseWriter, r *http.Request) error {
+	slog.Info("DeleteUnusedMedia")
+
+	// authorization
+	authorized, err := m.auth.Verify(r)
+	if err != nil || !authorized {
+		slog.Warn("DeleteUnusedMedia: authorization failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusForbidden).WriteJSON(w)
+	}
+
+	// parse query params
+	queries := r.URL.Query()
+	slog.Debug("got queries", "queries", queries)
+
+	dryRun, err := strListToBool(queries["dryRun"])
+	if err != nil {
+		slog.Error("DeleteUnusedMedia: 'dryRun' string list to bool failed", "error", err)
+		return entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
+	}
+
+	before := time.Now().UTC().
+		Add(-time.Duration(m.config.GCGracePeriod) * time.Hour).
+		Format("2006-01-02T15:04:05-07:00")
+
+	if len(dryRun) > 0 && dryRun[0] {
+		media, err := m.repo.ListUnused(r.Context(), before)
+		if err != nil {
+			slog.Error("DeleteUnusedMedia: repo list unused failed", "error", err)
+
+			if sqliteErr, ok := getSQLiteError(err); ok {
+				slog.Error("got sqlite error", "error code", sqliteErr.Code, "extended error code", sqliteErr.ExtendedCode)
+				return entities.NewRetFailedCustom(err, int(sqliteErr.ExtendedCode), http.StatusInternalServerError).WriteJSON(w)
+			}
+
+			return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+		}
+		return entities.NewRetSuccess(media).WriteJSON(w)
+	}
+
+	deleted, err := m.repo.DeleteUnused(r.Context(), before)
+	if err != nil {
+		slog.Error("DeleteUnusedMedia: repo delete unused failed", "error", err)
+
+		if sqliteErr, ok := getSQLiteError(err); ok {
+			slog.Error("got sqlite error", "error code", sqliteErr.Code, "extended error code", sqliteErr.ExtendedCode)
+			return entities.NewRetFailedCustom(err, int(sqliteErr.ExtendedCode), http.StatusInternalServerError).WriteJSON(w)
+		}
+
+		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+	}
+
+	for _, media := range deleted {
+		if err := m.storage.Remove(media.Hash); err != nil {
+			slog.Error("DeleteUnusedMedia: storage remove failed", "hash", media.Hash, "error", err.Error())
+		}
+		if err := m.variants.Remove(media.Hash); err != nil {
+			slog.Error("DeleteUnusedMedia: variants remove failed", "hash", media.Hash, "error", err.Error())
+		}
+	}
+
+	return entities.NewRetSuccess(deleted).WriteJSON(w)
+}
+
+// AuditSnapshot is the state of a media recorded in the audit log
+func (m *Media) AuditSnapshot(ctx context.Context, id string) (any, error) {
+	return m.repo.Get(ctx, id)
+}
@@ -0,0 +1,132 @@
+package handlers_test
+
+import (
+	"blog/api/handlers"
+	"blog/config"
+	"blog/entities"
+	"blog/storage"
+	"bytes"
+	"context"
+	"database/sql"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type DummyMediaRepo struct {
+	media map[string]entities.Media
+}
+
+func (d *DummyMediaRepo) Create(ctx context.Context, media entities.Media) (*entities.Media, error) {
+	d.media[media.Hash] = media
+	return &media, nil
+}
+func (d *DummyMediaRepo) Get(ctx context.Context, hash string) (*entities.Media, error) {
+	media, ok := d.media[hash]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &media, nil
+}
+func (d *DummyMediaRepo) List(ctx context.Context) ([]entities.OutMedia, error) {
+	return []entities.OutMedia{}, nil
+}
+func (d *DummyMediaRepo) ListUnused(ctx context.Context, before string) ([]entities.Media, error) {
+	return []entities.Media{}, nil
+}
+func (d *DummyMediaRepo) Delete(ctx context.Context, hash string) (int, error) {
+	return 0, nil
+}
+func (d *DummyMediaRepo) DeleteUnused(ctx context.Context, before string) ([]entities.Media, error) {
+	return []entities.Media{}, nil
+}
+
+// stores files on temp dirs, returns the handler and a func adding media
+func initMedia(t *testing.T) (*handlers.Media, func(content []byte, mime string, width, height int) string) {
+	repo := &DummyMediaRepo{media: map[string]entities.Media{}}
+	files := storage.NewLocal(t.TempDir())
+	variants := storage.NewVariants(t.TempDir(), 1<<20)
+	if err := variants.Prepare(); err != nil {
+		t.Fatalf("initMedia: prepare variants failed: %s", err)
+	}
+	setting := config.MediaSetting{SrcsetWidths: []int{480, 800}}
+	handler := handlers.NewMedia(repo, files, variants, &DummyAuthHelper{}, setting)
+
+	add := func(content []byte, mime string, width, height int) string {
+		hash, size, err := files.Put(bytes.NewReader(content))
+		if err != nil {
+			t.Fatalf("initMedia: put file failed: %s", err)
+		}
+		repo.media[hash] = *entities.NewMedia(hash, "file", mime, size, width, height, "")
+		return hash
+	}
+	return handler, add
+}
+
+func getMedia(handler *handlers.Media, hash, query string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/media/"+hash+query, nil)
+	req.SetPathValue("hash", hash)
+	w := httptest.NewRecorder()
+	handler.GetMedia(w, req)
+	return w
+}
+
+func TestGetMediaContentType(t *testing.T) {
+	handler, add := initMedia(t)
+
+	buf := bytes.Buffer{}
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatalf("TestGetMediaContentType: encode png failed: %s", err)
+	}
+	imageHash := add(buf.Bytes(), "image/png", 4, 4)
+	htmlHash := add([]byte("<html><script>alert(1)</script></html>"), "text/html; charset=utf-8", 0, 0)
+
+	w := getMedia(handler, imageHash, "")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "image/png" || w.Header().Get("Content-Disposition") != "" {
+		t.Fatalf("TestGetMediaContentType: image should be served inline, got %d %v", w.Code, w.Header())
+	}
+	if w.Header().Get("X-Content-Type-Options") != "nosniff" {
+		t.Fatalf("TestGetMediaContentType: expected nosniff, got %v", w.Header())
+	}
+
+	w = getMedia(handler, htmlHash, "")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/octet-stream" {
+		t.Fatalf("TestGetMediaContentType: html should be served as octet-stream, got %d %v", w.Code, w.Header())
+	}
+	if !strings.HasPrefix(w.Header().Get("Content-Disposition"), "attachment") || w.Header().Get("X-Content-Type-Options") != "nosniff" {
+		t.Fatalf("TestGetMediaContentType: html should be an attachment, got %v", w.Header())
+	}
+}
+
+func TestGetMediaWidth(t *testing.T) {
+	handler, add := initMedia(t)
+
+	buf := bytes.Buffer{}
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 1000, 10))); err != nil {
+		t.Fatalf("TestGetMediaWidth: encode png failed: %s", err)
+	}
+	hash := add(buf.Bytes(), "image/png", 1000, 10)
+
+	testCases := []struct {
+		query    string
+		expected int
+	}{
+		{"?w=480", http.StatusOK},
+		{"?w=800&fmt=jpeg", http.StatusOK},
+		{"?fmt=gif", http.StatusOK},
+		// only srcset widths, other widths would fill the variant cache
+		{"?w=481", http.StatusBadRequest},
+		{"?w=1", http.StatusBadRequest},
+		{"?w=0", http.StatusBadRequest},
+		{"?w=abc", http.StatusBadRequest},
+		{"?w=480&fmt=webp", http.StatusBadRequest},
+	}
+	for _, tc := range testCases {
+		if w := getMedia(handler, hash, tc.query); w.Code != tc.expected {
+			t.Fatalf("TestGetMediaWidth: expected %d for %q, got %d %s", tc.expected, tc.query, w.Code, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"blog/config"
+	"blog/entities"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrorPreviewSecretEmpty   = errors.New("preview secret not configured")
+	ErrorInvalidPreviewExpire = errors.New("expiresIn exceeds the maximum")
+)
+
+// Concrete implementations are at repository/<name>
+type previewLinksRepository interface {
+	// Returns sql.ErrNoRows if the blog doesn't exist
+	Create(ctx context.Context, link entities.PreviewLink) (*entities.PreviewLink, error)
+	Get(ctx context.Context, id int) (*entities.PreviewLink, error)
+	// Returns sql.ErrNoRows if the blog doesn't exist
+	ListByBlogID(ctx context.Context, blogID int) ([]entities.PreviewLink, error)
+	Revoke(ctx context.Context, id, blogID int) (int, error)
+}
+
+type PreviewLinks struct {
+	repo    previewLinksRepository
+	auth    authHelper
+	preview previewHelper
+	config  config.PreviewSetting
+}
+
+func NewPreviewLinks(repo previewLinksRepository, auth authHelper, preview previewHelper, config config.PreviewSetting) *PreviewLinks {
+	return &PreviewLinks{
+		repo:    repo,
+		auth:    auth,
+		preview: preview,
+		config:  config,
+	}
+}
+
+// CreatePreviewLink
+//
+//	@Summary		Create preview link
+//	@Description	mint a token for reading a blog regardless of visibility, with GET /blogs/{id}?preview=<token>.
+//	@Description	expiresIn is in hours, left out for the configured default.
+//	@Tags			blogs
+//	@Accept			json
+//	@Produce		json
+//	@Param			id				path		int						true	"target blog id"
+//	@Param			Authorization	header		string					true	"jwt token"
+//	@Param			link			body		entities.InPreviewLink	false	"note and expiration"
+//	@Success		200				{object}	entities.RetSuccess[entities.PreviewLink]
+//	@Failure		400				{object}	entities.RetFailed
+//	@Failure		403				{object}	entities.RetFailed
+//	@Failure		404				{object}	entities.RetFailed
+//	@Failure		500				{object}	entities.RetFailed
+//	@Router			/blogs/{id}/preview-links [post]
+func (p *PreviewLinks) CreatePreviewLink(w http.ResponseWriter, r *http.Request) error {
+	slog.Debug("CreatePreviewLink")
+
+	// authorization
+	authorized, err := p.auth.Verify(r)
+	if err != nil || !authorized {
+		slog.Warn("CreatePreviewLink: authorization failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusForbidden).WriteJSON(w)
+	}
+
+	if p.config.Secret == "" {
+		slog.Error("CreatePreviewLink: preview secret empty")
+		return entities.NewRetFailed(ErrorPreviewSecretEmpty, http.StatusInternalServerError).WriteJSON(w)
+	}
+
+	blogID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		slog.Error("CreatePreviewLink: id string to int failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
+	}
+
+	// body is optional
+	body := &entities.InPreviewLink{}
+	if err := json.NewDecoder(r.Body).Decode(body); err != nil && !errors.Is(err, io.EOF) {
+		slog.Error("CreatePreviewLink: decode failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
+	}
+	if body.ExpiresIn <= 0 {
+		body.ExpiresIn = p.config.Expire
+	}
+	if body.ExpiresIn > p.config.MaxExpire {
+		return entities.NewRetFailed(ErrorInvalidPreviewExpire, http.StatusBadRequest).WriteJSON(w)
+	}
+
+	expiresAt := time.Now().Add(time.Duration(body.ExpiresIn) * time.Hour).UTC().Format("2006-01-02T15:04:05-07:00")
+	link, err := p.repo.Create(r.Context(), *entities.NewPreviewLink(blogID, body.Note, expiresAt))
+	if err != nil {
+		slog.Error("CreatePreviewLink: repo create failed", "error", err.Error())
+		if errors.Is(err, sql.ErrNoRows) {
+			return entities.NewRetFailed(ErrorTargetNotFound, http.StatusNotFound).WriteJSON(w)
+		}
+
+		if sqliteErr, ok := getSQLiteError(err); ok {
+			slog.Error("got sqlite error", "error code", sqliteErr.Code, "extended error code", sqliteErr.ExtendedCode)
+			return entities.NewRetFailedCustom(err, int(sqliteErr.ExtendedCode), http.StatusInternalServerError).WriteJSON(w)
+		}
+
+		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+	}
+
+	link.Token, err = p.preview.Sign(*link)
+	if err != nil {
+		slog.Error("CreatePreviewLink: sign failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+	}
+
+	return entities.NewRetSuccess(*link).WriteJSON(w)
+}
+
+// ListPreviewLinks
+//
+//	@Summary		List preview links
+//	@Description	list preview links of a blog with their tokens, newest first, including expired ones
+//	@Tags			blogs
+//	@Accept			json
+//	@Produce		json
+//	@Param			id				path		int		true	"target blog id"
+//	@Param			Authorization	header		string	true	"jwt token"
+//	@Success		200				{object}	entities.RetSuccess[[]entities.PreviewLink]
+//	@Failure		400				{object}	entities.RetFailed
+//	@Failure		403				{object}	entities.RetFailed
+//	@Failure		404				{object}	entities.RetFailed
+//	@Failure		500				{object}	entities.RetFailed
+//	@Router			/blogs/{id}/preview-links [get]
+func (p *PreviewLinks) ListPreviewLinks(w http.ResponseWriter, r *http.Request) error {
+	slog.Debug("ListPreviewLinks")
+
+	// authorization
+	authorized, err := p.auth.Verify(r)
+	if err != nil || !authorized {
+		slog.Warn("ListPreviewLinks: authorization failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusForbidden).WriteJSON(w)
+	}
+
+	if p.config.Secret == "" {
+		slog.Error("ListPreviewLinks: preview secret empty")
+		return entities.NewRetFailed(ErrorPreviewSecretEmpty, http.StatusInternalServerError).WriteJSON(w)
+	}
+
+	blogID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		slog.Error("ListPreviewLinks: id string to int failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
+	}
+
+	links, err := p.repo.ListByBlogID(r.Context(), blogID)
+	if err != nil {
+		slog.Error("ListPreviewLinks: repo list failed", "error", err.Error())
+		if errors.Is(err, sql.ErrNoRows) {
+			return entities.NewRetFailed(ErrorTargetNotFound, http.StatusNotFound).WriteJSON(w)
+		}
+
+		if sqliteErr, ok := getSQLiteError(err); ok {
+			slog.Error("got sqlite error", "error code", sqliteErr.Code, "extended error code", sqliteErr.ExtendedCode)
+			return entities.NewRetFailedCustom(err, int(sqliteErr.ExtendedCode), http.StatusInternalServerError).WriteJSON(w)
+		}
+
+		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+	}
+
+	for i := range links {
+		links[i].Token, err = p.preview.Sign(links[i])
+		if err != nil {
+			slog.Error("ListPreviewLinks: sign failed", "error", err.Error())
+			return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+		}
+	}
+
+	return entities.NewRetSuccess(links).WriteJSON(w)
+}
+
+// RevokePreviewLink
+//
+//	@Summary		Revoke preview link
+//	@Description	the token of the link stops working right away
+//	@Tags			blogs
+//	@Accept			json
+//	@Produce		json
+//	@Param			id				path		int		true	"target blog id"
+//	@Param			linkID			path		int		true	"target preview link id"
+//	@Param			Authorization	header		string	true	"jwt token"
+//	@Success		200				{object}	entities.RetSuccess[entities.RowsAffected]
+//	@Failure		400				{object}	entities.RetFailed
+//	@Failure		403				{object}	entities.RetFailed
+//	@Failure		404				{object}	entities.RetFailed
+//	@Failure		500				{object}	entities.RetFailed
+//	@Router			/blogs/{id}/preview-links/{linkID} [delete]
+func (p *PreviewLinks) RevokePreviewLink(w http.ResponseWriter, r *http.Request) error {
+	slog.Debug("RevokePreviewLink")
+
+	// authorization
+	authorized, err := p.auth.Verify(r)
+	if err != nil || !authorized {
+		slog.Warn("RevokePreviewLink: authorization failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusForbidden).WriteJSON(w)
+	}
+
+	blogID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		slog.Error("RevokePreviewLink: id string to int failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
+	}
+	linkID, err := strconv.Atoi(r.PathValue("linkID"))
+	if err != nil {
+		slog.Error("RevokePreviewLink: linkID string to int failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
+	}
+
+	affectedRows, err := p.repo.Revoke(r.Context(), linkID, blogID)
+	if err != nil {
+		slog.Error("RevokePreviewLink: repo revoke failed", "error", err.Error())
+
+		if sqliteErr, ok := getSQLiteError(err); ok {
+			slog.Error("got sqlite error", "error code", sqliteErr.Code, "extended error code", sqliteErr.ExtendedCode)
+			return entities.NewRetFailedCustom(err, int(sqliteErr.ExtendedCode), http.StatusInternalServerError).WriteJSON(w)
+		}
+
+		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+	}
+
+	if affectedRows == 0 {
+		return entities.NewRetFailed(ErrorTargetNotFound, http.StatusNotFound).WriteJSON(w)
+	}
+	return entities.NewRetSuccess(*entities.NewRowsAffected(affectedRows)).WriteJSON(w)
+}
+
+// AuditSnapshot is the state of a preview link recorded in the audit log
+func (p *PreviewLinks) AuditSnapshot(ctx context.Context, id string) (any, error) {
+	linkID, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, fmt.Errorf("AuditSnapshot: id to int failed: %w", err)
+	}
+	return p.repo.Get(ctx, linkID)
+}
@@ -0,0 +1,289 @@
+package handlers
+
+import (
+	"blog/entities"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+// Concrete implementations are at repository/<name>
+type seriesRepository interface {
+	Create(ctx context.Context, series entities.Series) (*entities.Series, error)
+	List(ctx context.Context) ([]entities.Series, error)
+	// Only include visible and not soft deleted parts
+	Get(ctx context.Context, id int) (*entities.OutSeries, error)
+	// Include all parts regardless of visiblility and soft delete status
+	AdminGet(ctx context.Context, id int) (*entities.OutSeries, error)
+	Update(ctx context.Context, series entities.Series, id int) (*entities.Series, error)
+	Delete(ctx context.Context, id int) (int, error)
+}
+
+type Series struct {
+	repo seriesRepository
+	auth authHelper
+}
+
+func NewSeries(repo seriesRepository, auth authHelper) *Series {
+	return &Series{
+		repo: repo,
+		auth: auth,
+	}
+}
+
+// CreateSeries
+//
+//	@Summary		Create series
+//	@Description	series must have unique names
+//	@Tags			series
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string				true	"jwt token"
+//	@Param			series			body		entities.InSeries	true	"new series contents"
+//	@Success		200				{object}	entities.RetSuccess[entities.Series]
+//	@Failure		400				{object}	entities.RetFailed
+//	@Failure		500				{object}	entities.RetFailed
+//	@Router			/series [post]
+func (s *Series) CreateSeries(w http.ResponseWriter, r *http.Request) error {
+	slog.Debug("CreateSeries")
+
+	// authorization
+	authorized, err := s.auth.Verify(r)
+	if err != nil || !authorized {
+		slog.Warn("CreateSeries: authorization failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusForbidden).WriteJSON(w)
+	}
+
+	body := &entities.InSeries{}
+	if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+		slog.Error("CreateSeries: decode failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
+	}
+	inSeries := entities.NewSeries(
+		body.Name,
+		body.Description,
+	)
+
+	outSeries, err := s.repo.Create(r.Context(), *inSeries)
+	if err != nil {
+		slog.Error("CreateSeries: repo create failed", "error", err.Error())
+
+		if sqliteErr, ok := getSQLiteError(err); ok {
+			slog.Error("got sqlite error", "error code", sqliteErr.Code, "extended error code", sqliteErr.ExtendedCode)
+			return entities.NewRetFailedCustom(err, int(sqliteErr.ExtendedCode), http.StatusInternalServerError).WriteJSON(w)
+		}
+
+		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+	}
+
+	return entities.NewRetSuccess(*outSeries).WriteJSON(w)
+}
+
+// ListSeries
+//
+//	@Summary		List series
+//	@Description	list all series, without their parts
+//	@Tags			series
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	entities.RetSuccess[[]entities.Series]
+//	@Failure		500	{object}	entities.RetFailed
+//	@Router			/series [get]
+func (s *Series) ListSeries(w http.ResponseWriter, r *http.Request) error {
+	slog.Debug("ListSeries")
+
+	series, err := s.repo.List(r.Context())
+	if err != nil {
+		slog.Error("ListSeries: repo list failed", "error", err)
+		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+	}
+
+	return entities.NewRetSuccess(series).WriteJSON(w)
+}
+
+// GetSeries
+//
+//	@Summary		Get series
+//	@Description	get series by id with its parts ordered by position
+//	@Tags			series
+//	@Accept			json
+//	@Produce		json
+//	@Param			id				path		int		true	"target series id"
+//	@Param			Authorization	header		string	false	"jwt token, only required if all=true"
+//	@Param			all				query		bool	false	"include parts regardless of visibility or soft delete status"	default(false)
+//	@Success		200				{object}	entities.RetSuccess[entities.OutSeries]
+//	@Failure		400				{object}	entities.RetFailed
+//	@Failure		403				{object}	entities.RetFailed
+//	@Failure		404				{object}	entities.RetFailed
+//	@Failure		500				{object}	entities.RetFailed
+//	@Router			/series/{id} [get]
+func (s *Series) GetSeries(w http.ResponseWriter, r *http.Request) error {
+	slog.Debug("GetSeries")
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		slog.Error("GetSeries: id path param to int failed", "error", err)
+		return entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
+	}
+
+	all, err := strListToBool(r.URL.Query()["all"])
+	if err != nil {
+		slog.Error("GetSeries: 'all' string list to bool failed", "error", err)
+		return entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
+	}
+
+	var series *entities.OutSeries
+	if len(all) > 0 && all[0] {
+		// authorization
+		authorized, err := s.auth.Verify(r)
+		if err != nil || !authorized {
+			slog.Warn("GetSeries: authorization failed", "error", err.Error())
+			return entities.NewRetFailed(err, http.StatusForbidden).WriteJSON(w)
+		}
+
+		series, err = s.repo.AdminGet(r.Context(), id)
+	} else {
+		series, err = s.repo.Get(r.Context(), id)
+	}
+	if err != nil {
+		// differentiate if it's db error or that the user supplied id dosen't exist
+		slog.Error("GetSeries: repo get failed", "error", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return entities.NewRetFailed(ErrorTargetNotFound, http.StatusNotFound).WriteJSON(w)
+		}
+
+		if sqliteErr, ok := getSQLiteError(err); ok {
+			slog.Error("got sqlite error", "error code", sqliteErr.Code, "extended error code", sqliteErr.ExtendedCode)
+			return entities.NewRetFailedCustom(err, int(sqliteErr.ExtendedCode), http.StatusInternalServerError).WriteJSON(w)
+		}
+
+		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+	}
+
+	return entities.NewRetSuccess(*series).WriteJSON(w)
+}
+
+// UpdateSeries
+//
+//	@Summary		Update series
+//	@Description	update series name and description, parts are managed through blogs
+//	@Tags			series
+//	@Accept			json
+//	@Produce		json
+//	@Param			id				path		int					true	"target series id"
+//	@Param			Authorization	header		string				true	"jwt token"
+//	@Param			series			body		entities.InSeries	true	"new series content"
+//	@Success		200				{object}	entities.RetSuccess[entities.Series]
+//	@Failure		400				{object}	entities.RetFailed
+//	@Failure		404				{object}	entities.RetFailed
+//	@Failure		500				{object}	entities.RetFailed
+//	@Router			/series/{id} [put]
+func (s *Series) UpdateSeries(w http.ResponseWriter, r *http.Request) error {
+	slog.Debug("UpdateSeries")
+
+	// authorization
+	authorized, err := s.auth.Verify(r)
+	if err != nil || !authorized {
+		slog.Warn("UpdateSeries: authorization failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusForbidden).WriteJSON(w)
+	}
+
+	// load body
+	body := &entities.InSeries{}
+	if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+		slog.Error("UpdateSeries: decode failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
+	}
+	inSeries := entities.NewSeries(
+		body.Name,
+		body.Description,
+	)
+
+	// get target id
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		slog.Error("UpdateSeries: id string to int failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
+	}
+
+	outSeries, err := s.repo.Update(r.Context(), *inSeries, id)
+	if err != nil {
+		// differentiate if it's db error or that the user supplied id dosen't exist
+		slog.Error("UpdateSeries: repo update failed", "error", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return entities.NewRetFailed(ErrorTargetNotFound, http.StatusNotFound).WriteJSON(w)
+		}
+
+		if sqliteErr, ok := getSQLiteError(err); ok {
+			slog.Error("got sqlite error", "error code", sqliteErr.Code, "extended error code", sqliteErr.ExtendedCode)
+			return entities.NewRetFailedCustom(err, int(sqliteErr.ExtendedCode), http.StatusInternalServerError).WriteJSON(w)
+		}
+
+		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+	}
+
+	return entities.NewRetSuccess(*outSeries).WriteJSON(w)
+}
+
+// DeleteSeries
+//
+//	@Summary		Delete series
+//	@Description	delete series, blogs in it are kept but no longer belong to a series
+//	@Tags			series
+//	@Accept			json
+//	@Produce		json
+//	@Param			id				path		int		true	"target series id"
+//	@Param			Authorization	header		string	true	"jwt token"
+//	@Success		200				{object}	entities.RetSuccess[entities.RowsAffected]
+//	@Failure		400				{object}	entities.RetFailed
+//	@Failure		404				{object}	entities.RetFailed
+//	@Failure		500				{object}	entities.RetFailed
+//	@Router			/series/{id} [delete]
+func (s *Series) DeleteSeries(w http.ResponseWriter, r *http.Request) error {
+	slog.Info("DeleteSeries")
+
+	// authorization
+	authorized, err := s.auth.Verify(r)
+	if err != nil || !authorized {
+		slog.Warn("DeleteSeries: authorization failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusForbidden).WriteJSON(w)
+	}
+
+	// get target id
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		slog.Error("DeleteSeries: id string to int failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
+	}
+
+	affectedRows, err := s.repo.Delete(r.Context(), id)
+	if err != nil {
+		slog.Error("DeleteSeries: repo delete failed", "error", err.Error())
+
+		if sqliteErr, ok := getSQLiteError(err); ok {
+			slog.Error("got sqlite error", "error code", sqliteErr.Code, "extended error code", sqliteErr.ExtendedCode)
+			return entities.NewRetFailedCustom(err, int(sqliteErr.ExtendedCode), http.StatusInternalServerError).WriteJSON(w)
+		}
+
+		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+	}
+
+	if affectedRows == 0 {
+		return entities.NewRetFailed(ErrorTargetNotFound, http.StatusNotFound).WriteJSON(w)
+	}
+	return entities.NewRetSuccess(*entities.NewRowsAffected(affectedRows)).WriteJSON(w)
+}
+
+// AuditSnapshot is the state of a series recorded in the audit log
+func (s *Series) AuditSnapshot(ctx context.Context, id string) (any, error) {
+	seriesID, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, fmt.Errorf("AuditSnapshot: id to int failed: %w", err)
+	}
+	return s.repo.AdminGet(ctx, seriesID)
+}
@@ -0,0 +1,300 @@
+package handlers
+
+import (
+	"blog/analytics"
+	"blog/config"
+	"blog/entities"
+	"blog/util"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrorInvalidDateRange = errors.New("invalid date range, from and to should be YYYY-MM-DD with from <= to, at most 366 days")
+	ErrorInvalidLimit     = errors.New("invalid limit")
+)
+
+const (
+	maxStatsRangeDays = 366
+	maxPopularLimit   = 50
+)
+
+// ex: Googlebot, bingbot, AhrefsBot, Baiduspider, facebookexternalhit
+var botUserAgentKeywords = []string{"bot", "spider", "crawl", "slurp", "facebookexternalhit", "preview"}
+
+// Concrete implementations are at repository/<name>
+type statsRepository interface {
+	// Returns sql.ErrNoRows if the blog is not visible
+	RecordView(ctx context.Context, blogID int, day, referrer, visitorHash string) error
+	ListSummary(ctx context.Context, from, to string) ([]entities.BlogStatsSummary, error)
+	// Returns sql.ErrNoRows if the blog doesn't exist
+	Get(ctx context.Context, blogID int, from, to string) (*entities.BlogStats, error)
+	ListPopular(ctx context.Context, from string, limit int) ([]entities.PopularBlog, error)
+}
+
+type Stats struct {
+	repo         statsRepository
+	auth         authHelper
+	hasher       *analytics.VisitorHasher
+	config       config.AnalyticsSetting
+	serverConfig config.ServerSetting
+}
+
+func NewStats(repo statsRepository, auth authHelper, hasher *analytics.VisitorHasher, config config.AnalyticsSetting, serverConfig config.ServerSetting) *Stats {
+	return &Stats{
+		repo:         repo,
+		auth:         auth,
+		hasher:       hasher,
+		config:       config,
+		serverConfig: serverConfig,
+	}
+}
+
+// RecordView
+//
+//	@Summary		Record blog view
+//	@Description	beacon sent by the frontend when a blog is read.
+//	@Description	views are aggregated per day and referrer host, unique visitors are counted with a daily salted hash, raw ips are never stored.
+//	@Description	requests with DNT or Sec-GPC set, and from known bots, are ignored.
+//	@Tags			stats
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int				true	"target blog id"
+//	@Param			view	body		entities.InView	false	"referrer of the page"
+//	@Success		200		{object}	entities.RetSuccess[string]
+//	@Failure		400		{object}	entities.RetFailed
+//	@Failure		404		{object}	entities.RetFailed
+//	@Failure		429		{string}	string	"Too Many Requests"
+//	@Failure		500		{object}	entities.RetFailed
+//	@Router			/blogs/{id}/view [post]
+func (s *Stats) RecordView(w http.ResponseWriter, r *http.Request) error {
+	slog.Debug("RecordView")
+
+	blogID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		slog.Error("RecordView: id path param to int failed", "error", err)
+		return entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
+	}
+
+	userAgent := r.UserAgent()
+	if r.Header.Get("DNT") == "1" || r.Header.Get("Sec-GPC") == "1" || isBot(userAgent) {
+		return entities.NewRetSuccess("ignored").WriteJSON(w)
+	}
+
+	// body is optional, navigator.sendBeacon sends it as text/plain
+	r.Body = http.MaxBytesReader(w, r.Body, 4096)
+	body := &entities.InView{}
+	if err := json.NewDecoder(r.Body).Decode(body); err != nil && !errors.Is(err, io.EOF) {
+		slog.Error("RecordView: decode failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
+	}
+
+	day, visitorHash, err := s.hasher.Hash(time.Now(), util.ClientIP(r, s.serverConfig.ClientIPHeader), userAgent)
+	if err != nil {
+		slog.Error("RecordView: hash visitor failed", "error", err)
+		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+	}
+
+	if err := s.repo.RecordView(r.Context(), blogID, day, analytics.ReferrerHost(body.Referrer), visitorHash); err != nil {
+		slog.Error("RecordView: repo record view failed", "error", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return entities.NewRetFailed(ErrorTargetNotFound, http.StatusNotFound).WriteJSON(w)
+		}
+
+		if sqliteErr, ok := getSQLiteError(err); ok {
+			slog.Error("got sqlite error", "error code", sqliteErr.Code, "extended error code", sqliteErr.ExtendedCode)
+			return entities.NewRetFailedCustom(err, int(sqliteErr.ExtendedCode), http.StatusInternalServerError).WriteJSON(w)
+		}
+
+		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+	}
+
+	return entities.NewRetSuccess("recorded").WriteJSON(w)
+}
+
+// ListBlogStats
+//
+//	@Summary		List blog stats
+//	@Description	views and visitors of each blog in a date range, most viewed first. blogs without views are omitted.
+//	@Description	defaults to the last 30 days.
+//	@Tags			stats
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string	true	"jwt token"
+//	@Param			from			query		string	false	"first day, YYYY-MM-DD in UTC"
+//	@Param			to				query		string	false	"last day, YYYY-MM-DD in UTC"
+//	@Success		200				{object}	entities.RetSuccess[[]entities.BlogStatsSummary]
+//	@Failure		400				{object}	entities.RetFailed
+//	@Failure		403				{object}	entities.RetFailed
+//	@Failure		500				{object}	entities.RetFailed
+//	@Router			/stats/blogs [get]
+func (s *Stats) ListBlogStats(w http.ResponseWriter, r *http.Request) error {
+	slog.Debug("ListBlogStats")
+
+	// authorization
+	authorized, err := s.auth.Verify(r)
+	if err != nil || !authorized {
+		slog.Warn("ListBlogStats: authorization failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusForbidden).WriteJSON(w)
+	}
+
+	from, to, err := parseDateRange(r, 30)
+	if err != nil {
+		return entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
+	}
+
+	summary, err := s.repo.ListSummary(r.Context(), from, to)
+	if err != nil {
+		slog.Error("ListBlogStats: repo list summary failed", "error", err)
+		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+	}
+
+	return entities.NewRetSuccess(summary).WriteJSON(w)
+}
+
+// GetBlogStats
+//
+//	@Summary		Get blog stats
+//	@Description	daily views, visitors and referrers of a blog in a date range, days without views are omitted.
+//	@Description	defaults to the last 30 days.
+//	@Tags			stats
+//	@Accept			json
+//	@Produce		json
+//	@Param			id				path		int		true	"target blog id"
+//	@Param			Authorization	header		string	true	"jwt token"
+//	@Param			from			query		string	false	"first day, YYYY-MM-DD in UTC"
+//	@Param			to				query		string	false	"last day, YYYY-MM-DD in UTC"
+//	@Success		200				{object}	entities.RetSuccess[entities.BlogStats]
+//	@Failure		400				{object}	entities.RetFailed
+//	@Failure		403				{object}	entities.RetFailed
+//	@Failure		404				{object}	entities.RetFailed
+//	@Failure		500				{object}	entities.RetFailed
+//	@Router			/stats/blogs/{id} [get]
+func (s *Stats) GetBlogStats(w http.ResponseWriter, r *http.Request) error {
+	slog.Debug("GetBlogStats")
+
+	// authorization
+	authorized, err := s.auth.Verify(r)
+	if err != nil || !authorized {
+		slog.Warn("GetBlogStats: authorization failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusForbidden).WriteJSON(w)
+	}
+
+	blogID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		slog.Error("GetBlogStats: id path param to int failed", "error", err)
+		return entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
+	}
+
+	from, to, err := parseDateRange(r, 30)
+	if err != nil {
+		return entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
+	}
+
+	stats, err := s.repo.Get(r.Context(), blogID, from, to)
+	if err != nil {
+		slog.Error("GetBlogStats: repo get failed", "error", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return entities.NewRetFailed(ErrorTargetNotFound, http.StatusNotFound).WriteJSON(w)
+		}
+		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+	}
+
+	return entities.NewRetSuccess(*stats).WriteJSON(w)
+}
+
+// ListPopularBlogs
+//
+//	@Summary		List popular blogs
+//	@Description	most viewed visible blogs in the last days
+//	@Tags			stats
+//	@Accept			json
+//	@Produce		json
+//	@Param			days	query		int	false	"look back this many days, defaults to analytics.popularDays"
+//	@Param			limit	query		int	false	"at most 50, defaults to analytics.popularLimit"
+//	@Success		200		{object}	entities.RetSuccess[[]entities.PopularBlog]
+//	@Failure		400		{object}	entities.RetFailed
+//	@Failure		500		{object}	entities.RetFailed
+//	@Router			/blogs/popular [get]
+func (s *Stats) ListPopularBlogs(w http.ResponseWriter, r *http.Request) error {
+	slog.Debug("ListPopularBlogs")
+
+	queries := r.URL.Query()
+
+	days := s.config.PopularDays
+	if rawDays := queries.Get("days"); rawDays != "" {
+		parsed, err := strconv.Atoi(rawDays)
+		if err != nil || parsed < 1 || parsed > maxStatsRangeDays {
+			return entities.NewRetFailed(ErrorInvalidDateRange, http.StatusBadRequest).WriteJSON(w)
+		}
+		days = parsed
+	}
+
+	limit := s.config.PopularLimit
+	if rawLimit := queries.Get("limit"); rawLimit != "" {
+		parsed, err := strconv.Atoi(rawLimit)
+		if err != nil || parsed < 1 || parsed > maxPopularLimit {
+			return entities.NewRetFailed(ErrorInvalidLimit, http.StatusBadRequest).WriteJSON(w)
+		}
+		limit = parsed
+	}
+
+	from := analytics.Day(time.Now().AddDate(0, 0, -(days - 1)))
+	blogs, err := s.repo.ListPopular(r.Context(), from, limit)
+	if err != nil {
+		slog.Error("ListPopularBlogs: repo list popular failed", "error", err)
+		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+	}
+
+	return entities.NewRetSuccess(blogs).WriteJSON(w)
+}
+
+// Reads 'from' and 'to' queries, defaults to the last defaultDays days ending today
+func parseDateRange(r *http.Request, defaultDays int) (string, string, error) {
+	queries := r.URL.Query()
+	now := time.Now()
+
+	to := analytics.Day(now)
+	if rawTo := queries.Get("to"); rawTo != "" {
+		to = rawTo
+	}
+	toTime, err := analytics.ParseDay(to)
+	if err != nil {
+		return "", "", ErrorInvalidDateRange
+	}
+
+	from := analytics.Day(toTime.AddDate(0, 0, -(defaultDays - 1)))
+	if rawFrom := queries.Get("from"); rawFrom != "" {
+		from = rawFrom
+	}
+	fromTime, err := analytics.ParseDay(from)
+	if err != nil {
+		return "", "", ErrorInvalidDateRange
+	}
+
+	if fromTime.After(toTime) || toTime.Sub(fromTime) >= maxStatsRangeDays*24*time.Hour {
+		return "", "", ErrorInvalidDateRange
+	}
+	return from, to, nil
+}
+
+func isBot(userAgent string) bool {
+	if userAgent == "" {
+		return true
+	}
+	lower := strings.ToLower(userAgent)
+	for _, keyword := range botUserAgentKeywords {
+		if strings.Contains(lower, keyword) {
+			return true
+		}
+	}
+	return false
+}
@@ -6,6 +6,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"strconv"
@@ -17,8 +18,10 @@ type tagsRepository interface {
 	List(ctx context.Context) ([]entities.Tag, error)
 	ListByTopicID(ctx context.Context, topicID int) ([]entities.Tag, error)
 	Get(ctx context.Context, id int) (*entities.Tag, error)
-	Update(ctx context.Context, tag entities.Tag, id int) (*entities.Tag, error)
-	Delete(ctx context.Context, id int) (int, error)
+	// ifMatch is the If-Match header, entities.ErrorPreconditionFailed is returned if it doesn't match
+	Update(ctx context.Context, tag entities.Tag, id int, ifMatch string) (*entities.Tag, error)
+	Patch(ctx context.Context, patch entities.TagPatch, id int, ifMatch string) (*entities.Tag, error)
+	Delete(ctx context.Context, id int, ifMatch string) (int, error)
 }
 
 type Tags struct {
@@ -42,8 +45,11 @@ func NewTags(repo tagsRepository, auth authHelper) *Tags {
 //	@Produce		json
 //	@Param			tag				body		entities.InTag	true	"new tag contents"
 //	@Param			Authorization	header		string			true	"jwt token"
+//	@Param			Idempotency-Key	header		string			false	"unique key of the request, retries with the same key and body replay the first response"
 //	@Success		200				{object}	entities.RetSuccess[entities.Tag]
 //	@Failure		400				{object}	entities.RetFailed
+//	@Failure		409				{object}	entities.RetFailed
+//	@Failure		422				{object}	entities.RetFailed
 //	@Failure		500				{object}	entities.RetFailed
 //	@Router			/tags [post]
 func (t *Tags) CreateTag(w http.ResponseWriter, r *http.Request) error {
@@ -145,11 +151,14 @@ func (t *Tags) ListTags(w http.ResponseWriter, r *http.Request) error {
 //	@Tags			tags
 //	@Accept			json
 //	@Produce		json
-//	@Param			id	path		int	true	"target tag id"
-//	@Success		200	{object}	entities.RetSuccess[entities.Tag]
-//	@Failure		400	{object}	entities.RetFailed
-//	@Failure		404	{object}	entities.RetFailed
-//	@Failure		500	{object}	entities.RetFailed
+//	@Param			id				path		int		true	"target tag id"
+//	@Param			If-None-Match	header		string	false	"ETag from a previous GET, 304 if the tag hasn't changed"
+//	@Success		200				{object}	entities.RetSuccess[entities.Tag]
+//	@Success		304				"not modified"
+//	@Failure		400				{object}	entities.RetFailed
+//	@Failure		404				{object}	entities.RetFailed
+//	@Failure		500				{object}	entities.RetFailed
+//	@Header			200				{string}	ETag	"version of the tag"
 //	@Router			/tags/{id} [get]
 func (t *Tags) GetTag(w http.ResponseWriter, r *http.Request) error {
 	slog.Debug("GetTag")
@@ -177,6 +186,10 @@ func (t *Tags) GetTag(w http.ResponseWriter, r *http.Request) error {
 		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
 	}
 
+	if notModified(w, r, tag.Version.ETag()) {
+		return nil
+	}
+
 	return entities.NewRetSuccess(*tag).WriteJSON(w)
 }
 
@@ -189,11 +202,15 @@ func (t *Tags) GetTag(w http.ResponseWriter, r *http.Request) error {
 //	@Produce		json
 //	@Param			id				path		int				true	"target tag id"
 //	@Param			Authorization	header		string			true	"jwt token"
+//	@Param			If-Match		header		string	false	"ETag from a previous GET, the tag must not have changed since"
 //	@Param			tag				body		entities.InTag	true	"new tag content"
 //	@Success		200				{object}	entities.RetSuccess[entities.Tag]
 //	@Failure		400				{object}	entities.RetFailed
 //	@Failure		404				{object}	entities.RetFailed
+//	@Failure		412				{object}	entities.RetFailed
+//	@Failure		428				{object}	entities.RetFailed
 //	@Failure		500				{object}	entities.RetFailed
+//	@Header			200				{string}	ETag	"version of the tag"
 //	@Router			/tags/{id} [put]
 func (t *Tags) UpdateTag(w http.ResponseWriter, r *http.Request) error {
 	slog.Debug("UpdateTag")
@@ -224,13 +241,86 @@ func (t *Tags) UpdateTag(w http.ResponseWriter, r *http.Request) error {
 		return entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
 	}
 
-	outTag, err := t.repo.Update(r.Context(), *inTag, id)
+	outTag, err := t.repo.Update(r.Context(), *inTag, id, r.Header.Get("If-Match"))
 	if err != nil {
 		slog.Error("UpdateTag: repo update failed", "error", err)
 		if errors.Is(err, sql.ErrNoRows) {
 			return entities.NewRetFailed(ErrorTargetNotFound, http.StatusNotFound).WriteJSON(w)
 		}
 
+		if errors.Is(err, entities.ErrorPreconditionFailed) {
+			return entities.NewRetFailed(entities.ErrorPreconditionFailed, http.StatusPreconditionFailed).WriteJSON(w)
+		}
+
+		if sqliteErr, ok := getSQLiteError(err); ok {
+			slog.Error("got sqlite error", "error code", sqliteErr.Code, "extended error code", sqliteErr.ExtendedCode)
+			return entities.NewRetFailedCustom(err, int(sqliteErr.ExtendedCode), http.StatusInternalServerError).WriteJSON(w)
+		}
+
+		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+	}
+
+	w.Header().Set("ETag", outTag.Version.ETag())
+	return entities.NewRetSuccess(*outTag).WriteJSON(w)
+}
+
+// PatchTag
+//
+//	@Summary		Patch tag
+//	@Description	update only the fields present in the body, as a JSON Merge Patch (RFC 7396).
+//	@Tags			tags
+//	@Accept			json,application/merge-patch+json
+//	@Produce		json
+//	@Param			id				path		int					true	"target tag id"
+//	@Param			Authorization	header		string				true	"jwt token"
+//	@Param			If-Match		header		string	false	"ETag from a previous GET, the tag must not have changed since"
+//	@Param			tag			body		entities.TagPatch	true	"fields to change"
+//	@Success		200				{object}	entities.RetSuccess[entities.Tag]
+//	@Failure		400				{object}	entities.RetFailed
+//	@Failure		403				{object}	entities.RetFailed
+//	@Failure		404				{object}	entities.RetFailed
+//	@Failure		412				{object}	entities.RetFailed
+//	@Failure		428				{object}	entities.RetFailed
+//	@Failure		500				{object}	entities.RetFailed
+//	@Header			200				{string}	ETag	"version of the tag"
+//	@Router			/tags/{id} [patch]
+func (t *Tags) PatchTag(w http.ResponseWriter, r *http.Request) error {
+	slog.Debug("PatchTag")
+
+	// authorization
+	authorized, err := t.auth.Verify(r)
+	if err != nil || !authorized {
+		slog.Warn("PatchTag: authorization failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusForbidden).WriteJSON(w)
+	}
+
+	// load body
+	patch := &entities.TagPatch{}
+	if err := json.NewDecoder(r.Body).Decode(patch); err != nil {
+		slog.Error("PatchTag: decode failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
+	}
+	patch.GenSlug()
+
+	// get target id
+	rawID := r.PathValue("id")
+	id, err := strconv.Atoi(rawID)
+	if err != nil {
+		slog.Error("PatchTag: id string to int failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
+	}
+
+	outTag, err := t.repo.Patch(r.Context(), *patch, id, r.Header.Get("If-Match"))
+	if err != nil {
+		slog.Error("PatchTag: repo patch failed", "error", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return entities.NewRetFailed(ErrorTargetNotFound, http.StatusNotFound).WriteJSON(w)
+		}
+
+		if errors.Is(err, entities.ErrorPreconditionFailed) {
+			return entities.NewRetFailed(entities.ErrorPreconditionFailed, http.StatusPreconditionFailed).WriteJSON(w)
+		}
+
 		if sqliteErr, ok := getSQLiteError(err); ok {
 			slog.Error("got sqlite error", "error code", sqliteErr.Code, "extended error code", sqliteErr.ExtendedCode)
 			return entities.NewRetFailedCustom(err, int(sqliteErr.ExtendedCode), http.StatusInternalServerError).WriteJSON(w)
@@ -239,6 +329,7 @@ func (t *Tags) UpdateTag(w http.ResponseWriter, r *http.Request) error {
 		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
 	}
 
+	w.Header().Set("ETag", outTag.Version.ETag())
 	return entities.NewRetSuccess(*outTag).WriteJSON(w)
 }
 
@@ -251,9 +342,12 @@ func (t *Tags) UpdateTag(w http.ResponseWriter, r *http.Request) error {
 //	@Produce		json
 //	@Param			id				path		int		true	"target tag id"
 //	@Param			Authorization	header		string	true	"jwt token"
+//	@Param			If-Match		header		string	false	"ETag from a previous GET, the tag must not have changed since"
 //	@Success		200				{object}	entities.RetSuccess[entities.RowsAffected]
 //	@Failure		400				{object}	entities.RetFailed
 //	@Failure		404				{object}	entities.RetFailed
+//	@Failure		412				{object}	entities.RetFailed
+//	@Failure		428				{object}	entities.RetFailed
 //	@Failure		500				{object}	entities.RetFailed
 //	@Router			/tags/{id} [delete]
 func (t *Tags) DeleteTag(w http.ResponseWriter, r *http.Request) error {
@@ -274,10 +368,14 @@ func (t *Tags) DeleteTag(w http.ResponseWriter, r *http.Request) error {
 		return entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
 	}
 
-	affectedRows, err := t.repo.Delete(r.Context(), id)
+	affectedRows, err := t.repo.Delete(r.Context(), id, r.Header.Get("If-Match"))
 	if err != nil {
 		slog.Error("DeleteTag: repo delete failed", "error", err.Error())
 
+		if errors.Is(err, entities.ErrorPreconditionFailed) {
+			return entities.NewRetFailed(entities.ErrorPreconditionFailed, http.StatusPreconditionFailed).WriteJSON(w)
+		}
+
 		if sqliteErr, ok := getSQLiteError(err); ok {
 			slog.Error("got sqlite error", "error code", sqliteErr.Code, "extended error code", sqliteErr.ExtendedCode)
 			return entities.NewRetFailedCustom(err, int(sqliteErr.ExtendedCode), http.StatusInternalServerError).WriteJSON(w)
@@ -292,3 +390,12 @@ func (t *Tags) DeleteTag(w http.ResponseWriter, r *http.Request) error {
 
 	return entities.NewRetSuccess(*entities.NewRowsAffected(affectedRows)).WriteJSON(w)
 }
+
+// AuditSnapshot is the state of a tag recorded in the audit log
+func (t *Tags) AuditSnapshot(ctx context.Context, id string) (any, error) {
+	tagID, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, fmt.Errorf("AuditSnapshot: id to int failed: %w", err)
+	}
+	return t.repo.Get(ctx, tagID)
+}
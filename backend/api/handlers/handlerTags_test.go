@@ -22,6 +22,12 @@ func (d *DummyAuthHelper) Verify(r *http.Request) (bool, error) {
 	}
 	return true, nil
 }
+func (d *DummyAuthHelper) UserName(r *http.Request) (string, error) {
+	return "dummy", nil
+}
+
+// If-Match of a tag changed since it was read
+const staleETag = `"stale"`
 
 type DummyTagsRepo struct{}
 
@@ -41,11 +47,27 @@ func (d *DummyTagsRepo) Get(ctx context.Context, id int) (*entities.Tag, error)
 	newTag := &entities.Tag{Name: "get", Description: strconv.Itoa(id)}
 	return newTag, nil
 }
-func (d *DummyTagsRepo) Update(ctx context.Context, tag entities.Tag, id int) (*entities.Tag, error) {
+func (d *DummyTagsRepo) Update(ctx context.Context, tag entities.Tag, id int, ifMatch string) (*entities.Tag, error) {
+	if ifMatch == staleETag {
+		return &entities.Tag{}, entities.ErrorPreconditionFailed
+	}
 	newTag := entities.NewTag(tag.Name, "update"+strconv.Itoa(id))
 	return newTag, nil
 }
-func (d *DummyTagsRepo) Delete(ctx context.Context, id int) (int, error) {
+func (d *DummyTagsRepo) Patch(ctx context.Context, patch entities.TagPatch, id int, ifMatch string) (*entities.Tag, error) {
+	newTag := &entities.Tag{ID: id, Name: "patch", Description: "patch"}
+	if patch.Name != nil {
+		newTag.Name, newTag.Slug = *patch.Name, *patch.Slug
+	}
+	if patch.Description != nil {
+		newTag.Description = *patch.Description
+	}
+	return newTag, nil
+}
+func (d *DummyTagsRepo) Delete(ctx context.Context, id int, ifMatch string) (int, error) {
+	if ifMatch == staleETag {
+		return 0, entities.ErrorPreconditionFailed
+	}
 	return 1, nil
 }
 
@@ -381,6 +403,99 @@ func TestHandlerTagsUpdateNoBody(t *testing.T) {
 	}
 }
 
+func TestHandlerTagsPatch(t *testing.T) {
+	tags := initTags()
+
+	// only the given fields change
+	r := httptest.NewRequest(http.MethodPatch, "/tags/1", bytes.NewBufferString(`{"name": "New Name", "description": null}`))
+	r.SetPathValue("id", "1")
+	r.Header.Set("Authorization", "Bearer aaa.bbb.ccc")
+	r.Header.Set("Content-Type", "application/merge-patch+json")
+	w := httptest.NewRecorder()
+	if err := tags.PatchTag(w, r); err != nil {
+		t.Fatalf("TestHandlerTagsPatch: patch tag failed: %s", err)
+	}
+
+	resData := entities.RetSuccess[entities.Tag]{}
+	if err := json.NewDecoder(w.Result().Body).Decode(&resData); err != nil {
+		t.Fatalf("TestHandlerTagsPatch: read response body failed: %s", err)
+	}
+	if resData.Status != http.StatusOK {
+		t.Fatalf("TestHandlerTagsPatch: status incorrect")
+	}
+	if resData.Msg.Name != "New Name" || resData.Msg.Slug != "new-name" || resData.Msg.Description != "" {
+		t.Fatalf("TestHandlerTagsPatch: patch isn't properly passed, got %+v", resData.Msg)
+	}
+
+	// invalid patches
+	for _, body := range []string{`["name"]`, `null`, `{"name": null}`, `{"slug": "a"}`, `{"name": 1}`} {
+		r := httptest.NewRequest(http.MethodPatch, "/tags/1", bytes.NewBufferString(body))
+		r.SetPathValue("id", "1")
+		r.Header.Set("Authorization", "Bearer aaa.bbb.ccc")
+		w := httptest.NewRecorder()
+		if err := tags.PatchTag(w, r); err != nil {
+			t.Fatalf("TestHandlerTagsPatch: patch tag failed: %s", err)
+		}
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("TestHandlerTagsPatch: %s should be rejected, got %d", body, w.Code)
+		}
+	}
+}
+
+func TestHandlerTagsETag(t *testing.T) {
+	tags := initTags()
+
+	// get returns the etag
+	r := httptest.NewRequest(http.MethodGet, "/tags/1", nil)
+	r.SetPathValue("id", "1")
+	w := httptest.NewRecorder()
+	if err := tags.GetTag(w, r); err != nil {
+		t.Fatalf("TestHandlerTagsETag: get tag failed: %s", err)
+	}
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || etag == "" {
+		t.Fatalf("TestHandlerTagsETag: get should return an etag, got %d %q", w.Code, etag)
+	}
+
+	// revalidate
+	r = httptest.NewRequest(http.MethodGet, "/tags/1", nil)
+	r.SetPathValue("id", "1")
+	r.Header.Set("If-None-Match", `"other", W/`+etag)
+	w = httptest.NewRecorder()
+	if err := tags.GetTag(w, r); err != nil {
+		t.Fatalf("TestHandlerTagsETag: get tag failed: %s", err)
+	}
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Fatalf("TestHandlerTagsETag: matching If-None-Match should return 304, got %d", w.Code)
+	}
+
+	// stale If-Match on update and delete
+	reqBody := bytes.NewBufferString(`{"name": "dummy name"}`)
+	r = httptest.NewRequest(http.MethodPut, "/tags/1", reqBody)
+	r.SetPathValue("id", "1")
+	r.Header.Set("Authorization", "Bearer aaa.bbb.ccc")
+	r.Header.Set("If-Match", staleETag)
+	w = httptest.NewRecorder()
+	if err := tags.UpdateTag(w, r); err != nil {
+		t.Fatalf("TestHandlerTagsETag: update tag failed: %s", err)
+	}
+	if w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("TestHandlerTagsETag: stale If-Match on update should return 412, got %d", w.Code)
+	}
+
+	r = httptest.NewRequest(http.MethodDelete, "/tags/1", nil)
+	r.SetPathValue("id", "1")
+	r.Header.Set("Authorization", "Bearer aaa.bbb.ccc")
+	r.Header.Set("If-Match", staleETag)
+	w = httptest.NewRecorder()
+	if err := tags.DeleteTag(w, r); err != nil {
+		t.Fatalf("TestHandlerTagsETag: delete tag failed: %s", err)
+	}
+	if w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("TestHandlerTagsETag: stale If-Match on delete should return 412, got %d", w.Code)
+	}
+}
+
 /* ============ Delete ============== */
 
 func TestHandlerTagsDelete(t *testing.T) {
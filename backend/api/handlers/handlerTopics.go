@@ -6,6 +6,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"strconv"
@@ -16,8 +17,10 @@ type topicsRepository interface {
 	Create(ctx context.Context, topic entities.Topic) (*entities.Topic, error)
 	List(ctx context.Context) ([]entities.Topic, error)
 	Get(ctx context.Context, id int) (*entities.Topic, error)
-	Update(ctx context.Context, topic entities.Topic, id int) (*entities.Topic, error)
-	Delete(ctx context.Context, id int) (int, error)
+	// ifMatch is the If-Match header, entities.ErrorPreconditionFailed is returned if it doesn't match
+	Update(ctx context.Context, topic entities.Topic, id int, ifMatch string) (*entities.Topic, error)
+	Patch(ctx context.Context, patch entities.TopicPatch, id int, ifMatch string) (*entities.Topic, error)
+	Delete(ctx context.Context, id int, ifMatch string) (int, error)
 }
 
 type Topics struct {
@@ -40,9 +43,12 @@ func NewTopics(repo topicsRepository, auth authHelper) *Topics {
 //	@Accept			json
 //	@Produce		json
 //	@Param			Authorization	header		string				true	"jwt token"
+//	@Param			Idempotency-Key	header		string				false	"unique key of the request, retries with the same key and body replay the first response"
 //	@Param			topic			body		entities.InTopic	true	"new topic contents"
 //	@Success		200				{object}	entities.RetSuccess[entities.Topic]
 //	@Failure		400				{object}	entities.RetFailed
+//	@Failure		409				{object}	entities.RetFailed
+//	@Failure		422				{object}	entities.RetFailed
 //	@Failure		500				{object}	entities.RetFailed
 //	@Router			/topics [post]
 func (t *Topics) CreateTopic(w http.ResponseWriter, r *http.Request) error {
@@ -109,11 +115,14 @@ func (t *Topics) ListTopics(w http.ResponseWriter, r *http.Request) error {
 //	@Tags			topics
 //	@Accept			json
 //	@Produce		json
-//	@Param			id	path		int	true	"target topic id"
-//	@Success		200	{object}	entities.RetSuccess[entities.Topic]
-//	@Failure		400	{object}	entities.RetFailed
-//	@Failure		404	{object}	entities.RetFailed
-//	@Failure		500	{object}	entities.RetFailed
+//	@Param			id				path		int		true	"target topic id"
+//	@Param			If-None-Match	header		string	false	"ETag from a previous GET, 304 if the topic hasn't changed"
+//	@Success		200				{object}	entities.RetSuccess[entities.Topic]
+//	@Success		304				"not modified"
+//	@Failure		400				{object}	entities.RetFailed
+//	@Failure		404				{object}	entities.RetFailed
+//	@Failure		500				{object}	entities.RetFailed
+//	@Header			200				{string}	ETag	"version of the topic"
 //	@Router			/topics/{id} [get]
 func (t *Topics) GetTopic(w http.ResponseWriter, r *http.Request) error {
 	slog.Debug("GetTopic")
@@ -140,6 +149,10 @@ func (t *Topics) GetTopic(w http.ResponseWriter, r *http.Request) error {
 		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
 	}
 
+	if notModified(w, r, topic.Version.ETag()) {
+		return nil
+	}
+
 	return entities.NewRetSuccess(*topic).WriteJSON(w)
 }
 
@@ -152,11 +165,15 @@ func (t *Topics) GetTopic(w http.ResponseWriter, r *http.Request) error {
 //	@Produce		json
 //	@Param			id				path		int					true	"target tag id"
 //	@Param			Authorization	header		string				true	"jwt token"
+//	@Param			If-Match		header		string	false	"ETag from a previous GET, the topic must not have changed since"
 //	@Param			topic			body		entities.InTopic	true	"new topic content"
 //	@Success		200				{object}	entities.RetSuccess[entities.Topic]
 //	@Failure		400				{object}	entities.RetFailed
 //	@Failure		404				{object}	entities.RetFailed
+//	@Failure		412				{object}	entities.RetFailed
+//	@Failure		428				{object}	entities.RetFailed
 //	@Failure		500				{object}	entities.RetFailed
+//	@Header			200				{string}	ETag	"version of the topic"
 //	@Router			/topics/{id} [put]
 func (t *Topics) UpdateTopic(w http.ResponseWriter, r *http.Request) error {
 	slog.Debug("UpdateTopic")
@@ -187,7 +204,7 @@ func (t *Topics) UpdateTopic(w http.ResponseWriter, r *http.Request) error {
 		return entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
 	}
 
-	outTopic, err := t.repo.Update(r.Context(), *inTopic, id)
+	outTopic, err := t.repo.Update(r.Context(), *inTopic, id, r.Header.Get("If-Match"))
 	if err != nil {
 		// differentiate if it's db error or that the user supplied id dosen't exist
 		slog.Error("UpdateTopic: repo update failed", "error", err)
@@ -195,6 +212,79 @@ func (t *Topics) UpdateTopic(w http.ResponseWriter, r *http.Request) error {
 			return entities.NewRetFailed(ErrorTargetNotFound, http.StatusNotFound).WriteJSON(w)
 		}
 
+		if errors.Is(err, entities.ErrorPreconditionFailed) {
+			return entities.NewRetFailed(entities.ErrorPreconditionFailed, http.StatusPreconditionFailed).WriteJSON(w)
+		}
+
+		if sqliteErr, ok := getSQLiteError(err); ok {
+			slog.Error("got sqlite error", "error code", sqliteErr.Code, "extended error code", sqliteErr.ExtendedCode)
+			return entities.NewRetFailedCustom(err, int(sqliteErr.ExtendedCode), http.StatusInternalServerError).WriteJSON(w)
+		}
+
+		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+	}
+
+	w.Header().Set("ETag", outTopic.Version.ETag())
+	return entities.NewRetSuccess(*outTopic).WriteJSON(w)
+}
+
+// PatchTopic
+//
+//	@Summary		Patch topic
+//	@Description	update only the fields present in the body, as a JSON Merge Patch (RFC 7396).
+//	@Tags			topics
+//	@Accept			json,application/merge-patch+json
+//	@Produce		json
+//	@Param			id				path		int					true	"target topic id"
+//	@Param			Authorization	header		string				true	"jwt token"
+//	@Param			If-Match		header		string	false	"ETag from a previous GET, the topic must not have changed since"
+//	@Param			topic			body		entities.TopicPatch	true	"fields to change"
+//	@Success		200				{object}	entities.RetSuccess[entities.Topic]
+//	@Failure		400				{object}	entities.RetFailed
+//	@Failure		403				{object}	entities.RetFailed
+//	@Failure		404				{object}	entities.RetFailed
+//	@Failure		412				{object}	entities.RetFailed
+//	@Failure		428				{object}	entities.RetFailed
+//	@Failure		500				{object}	entities.RetFailed
+//	@Header			200				{string}	ETag	"version of the topic"
+//	@Router			/topics/{id} [patch]
+func (t *Topics) PatchTopic(w http.ResponseWriter, r *http.Request) error {
+	slog.Debug("PatchTopic")
+
+	// authorization
+	authorized, err := t.auth.Verify(r)
+	if err != nil || !authorized {
+		slog.Warn("PatchTopic: authorization failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusForbidden).WriteJSON(w)
+	}
+
+	// load body
+	patch := &entities.TopicPatch{}
+	if err := json.NewDecoder(r.Body).Decode(patch); err != nil {
+		slog.Error("PatchTopic: decode failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
+	}
+	patch.GenSlug()
+
+	// get target id
+	rawID := r.PathValue("id")
+	id, err := strconv.Atoi(rawID)
+	if err != nil {
+		slog.Error("PatchTopic: id string to int failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
+	}
+
+	outTopic, err := t.repo.Patch(r.Context(), *patch, id, r.Header.Get("If-Match"))
+	if err != nil {
+		slog.Error("PatchTopic: repo patch failed", "error", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return entities.NewRetFailed(ErrorTargetNotFound, http.StatusNotFound).WriteJSON(w)
+		}
+
+		if errors.Is(err, entities.ErrorPreconditionFailed) {
+			return entities.NewRetFailed(entities.ErrorPreconditionFailed, http.StatusPreconditionFailed).WriteJSON(w)
+		}
+
 		if sqliteErr, ok := getSQLiteError(err); ok {
 			slog.Error("got sqlite error", "error code", sqliteErr.Code, "extended error code", sqliteErr.ExtendedCode)
 			return entities.NewRetFailedCustom(err, int(sqliteErr.ExtendedCode), http.StatusInternalServerError).WriteJSON(w)
@@ -203,6 +293,7 @@ func (t *Topics) UpdateTopic(w http.ResponseWriter, r *http.Request) error {
 		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
 	}
 
+	w.Header().Set("ETag", outTopic.Version.ETag())
 	return entities.NewRetSuccess(*outTopic).WriteJSON(w)
 }
 
@@ -215,9 +306,12 @@ func (t *Topics) UpdateTopic(w http.ResponseWriter, r *http.Request) error {
 //	@Produce		json
 //	@Param			id				path		int		true	"target topic id"
 //	@Param			Authorization	header		string	true	"jwt token"
+//	@Param			If-Match		header		string	false	"ETag from a previous GET, the topic must not have changed since"
 //	@Success		200				{object}	entities.RetSuccess[entities.RowsAffected]
 //	@Failure		400				{object}	entities.RetFailed
 //	@Failure		404				{object}	entities.RetFailed
+//	@Failure		412				{object}	entities.RetFailed
+//	@Failure		428				{object}	entities.RetFailed
 //	@Failure		500				{object}	entities.RetFailed
 //	@Router			/topics/{id} [delete]
 func (t *Topics) DeleteTopic(w http.ResponseWriter, r *http.Request) error {
@@ -238,10 +332,14 @@ func (t *Topics) DeleteTopic(w http.ResponseWriter, r *http.Request) error {
 		return entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
 	}
 
-	affectedRows, err := t.repo.Delete(r.Context(), id)
+	affectedRows, err := t.repo.Delete(r.Context(), id, r.Header.Get("If-Match"))
 	if err != nil {
 		slog.Error("DeleteTopic: repo delete failed", "error", err.Error())
 
+		if errors.Is(err, entities.ErrorPreconditionFailed) {
+			return entities.NewRetFailed(entities.ErrorPreconditionFailed, http.StatusPreconditionFailed).WriteJSON(w)
+		}
+
 		if sqliteErr, ok := getSQLiteError(err); ok {
 			slog.Error("got sqlite error", "error code", sqliteErr.Code, "extended error code", sqliteErr.ExtendedCode)
 			return entities.NewRetFailedCustom(err, int(sqliteErr.ExtendedCode), http.StatusInternalServerError).WriteJSON(w)
@@ -255,3 +353,12 @@ func (t *Topics) DeleteTopic(w http.ResponseWriter, r *http.Request) error {
 	}
 	return entities.NewRetSuccess(*entities.NewRowsAffected(affectedRows)).WriteJSON(w)
 }
+
+// AuditSnapshot is the state of a topic recorded in the audit log
+func (t *Topics) AuditSnapshot(ctx context.Context, id string) (any, error) {
+	topicID, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, fmt.Errorf("AuditSnapshot: id to int failed: %w", err)
+	}
+	return t.repo.Get(ctx, topicID)
+}
@@ -0,0 +1,435 @@
+package handlers
+
+import (
+	"blog/entities"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+)
+
+var (
+	ErrorInvalidWebhookURL   = errors.New("invalid webhook url, should be an absolute http or https url")
+	ErrorInvalidWebhookEvent = errors.New("invalid webhook event type")
+)
+
+const (
+	defaultDeliveriesLimit = 50
+	maxDeliveriesLimit     = 200
+)
+
+// Concrete implementations are at repository/<name>
+type webhooksRepository interface {
+	Create(ctx context.Context, webhook entities.Webhook) (*entities.Webhook, error)
+	List(ctx context.Context) ([]entities.Webhook, error)
+	Get(ctx context.Context, id int) (*entities.Webhook, error)
+	Update(ctx context.Context, webhook entities.Webhook, id int) (*entities.Webhook, error)
+	Delete(ctx context.Context, id int) (int, error)
+	// Returns sql.ErrNoRows if the webhook doesn't exist
+	ListDeliveries(ctx context.Context, webhookID, limit int) ([]entities.WebhookDelivery, error)
+	// Returns sql.ErrNoRows if the delivery doesn't exist
+	Replay(ctx context.Context, deliveryID int) (*entities.WebhookDelivery, error)
+}
+
+type Webhooks struct {
+	repo webhooksRepository
+	auth authHelper
+}
+
+func NewWebhooks(repo webhooksRepository, auth authHelper) *Webhooks {
+	return &Webhooks{
+		repo: repo,
+		auth: auth,
+	}
+}
+
+// CreateWebhook
+//
+//	@Summary		Create webhook
+//	@Description	subscribe a url to content change events, a secret is generated if left out.
+//	@Description	the secret is only returned here, requests are signed with it in X-Webhook-Signature.
+//	@Tags			webhooks
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string				true	"jwt token"
+//	@Param			webhook			body		entities.InWebhook	true	"url, events and secret"
+//	@Success		200				{object}	entities.RetSuccess[entities.Webhook]
+//	@Failure		400				{object}	entities.RetFailed
+//	@Failure		403				{object}	entities.RetFailed
+//	@Failure		500				{object}	entities.RetFailed
+//	@Router			/webhooks [post]
+func (wh *Webhooks) CreateWebhook(w http.ResponseWriter, r *http.Request) error {
+	slog.Debug("CreateWebhook")
+
+	// authorization
+	authorized, err := wh.auth.Verify(r)
+	if err != nil || !authorized {
+		slog.Warn("CreateWebhook: authorization failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusForbidden).WriteJSON(w)
+	}
+
+	body := &entities.InWebhook{}
+	if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+		slog.Error("CreateWebhook: decode failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
+	}
+	if err := validateWebhook(body); err != nil {
+		return entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
+	}
+
+	if body.Secret == "" {
+		secret, err := generateWebhookSecret()
+		if err != nil {
+			slog.Error("CreateWebhook: generate secret failed", "error", err.Error())
+			return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+		}
+		body.Secret = secret
+	}
+	inWebhook := entities.NewWebhook(body.URL, body.Secret, body.Events, body.Paused)
+
+	outWebhook, err := wh.repo.Create(r.Context(), *inWebhook)
+	if err != nil {
+		slog.Error("CreateWebhook: repo create failed", "error", err.Error())
+
+		if sqliteErr, ok := getSQLiteError(err); ok {
+			slog.Error("got sqlite error", "error code", sqliteErr.Code, "extended error code", sqliteErr.ExtendedCode)
+			return entities.NewRetFailedCustom(err, int(sqliteErr.ExtendedCode), http.StatusInternalServerError).WriteJSON(w)
+		}
+
+		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+	}
+
+	return entities.NewRetSuccess(*outWebhook).WriteJSON(w)
+}
+
+// ListWebhooks
+//
+//	@Summary		List webhooks
+//	@Description	list all webhooks, without secrets
+//	@Tags			webhooks
+//	@Accept			json
+//	@Produce		json
+//	@Param			Authorization	header		string	true	"jwt token"
+//	@Success		200				{object}	entities.RetSuccess[[]entities.Webhook]
+//	@Failure		403				{object}	entities.RetFailed
+//	@Failure		500				{object}	entities.RetFailed
+//	@Router			/webhooks [get]
+func (wh *Webhooks) ListWebhooks(w http.ResponseWriter, r *http.Request) error {
+	slog.Debug("ListWebhooks")
+
+	// authorization
+	authorized, err := wh.auth.Verify(r)
+	if err != nil || !authorized {
+		slog.Warn("ListWebhooks: authorization failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusForbidden).WriteJSON(w)
+	}
+
+	webhooks, err := wh.repo.List(r.Context())
+	if err != nil {
+		slog.Error("ListWebhooks: repo list failed", "error", err)
+		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+	}
+
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+	return entities.NewRetSuccess(webhooks).WriteJSON(w)
+}
+
+// GetWebhook
+//
+//	@Summary		Get webhook
+//	@Description	get webhook by id, without secret
+//	@Tags			webhooks
+//	@Accept			json
+//	@Produce		json
+//	@Param			id				path		int		true	"target webhook id"
+//	@Param			Authorization	header		string	true	"jwt token"
+//	@Success		200				{object}	entities.RetSuccess[entities.Webhook]
+//	@Failure		400				{object}	entities.RetFailed
+//	@Failure		403				{object}	entities.RetFailed
+//	@Failure		404				{object}	entities.RetFailed
+//	@Failure		500				{object}	entities.RetFailed
+//	@Router			/webhooks/{id} [get]
+func (wh *Webhooks) GetWebhook(w http.ResponseWriter, r *http.Request) error {
+	slog.Debug("GetWebhook")
+
+	// authorization
+	authorized, err := wh.auth.Verify(r)
+	if err != nil || !authorized {
+		slog.Warn("GetWebhook: authorization failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusForbidden).WriteJSON(w)
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		slog.Error("GetWebhook: id path param to int failed", "error", err)
+		return entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
+	}
+
+	webhook, err := wh.repo.Get(r.Context(), id)
+	if err != nil {
+		slog.Error("GetWebhook: repo get failed", "error", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return entities.NewRetFailed(ErrorTargetNotFound, http.StatusNotFound).WriteJSON(w)
+		}
+		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+	}
+
+	webhook.Secret = ""
+	return entities.NewRetSuccess(*webhook).WriteJSON(w)
+}
+
+// UpdateWebhook
+//
+//	@Summary		Update webhook
+//	@Description	update url, events and paused, leaving out secret keeps the current one
+//	@Tags			webhooks
+//	@Accept			json
+//	@Produce		json
+//	@Param			id				path		int					true	"target webhook id"
+//	@Param			Authorization	header		string				true	"jwt token"
+//	@Param			webhook			body		entities.InWebhook	true	"new webhook content"
+//	@Success		200				{object}	entities.RetSuccess[entities.Webhook]
+//	@Failure		400				{object}	entities.RetFailed
+//	@Failure		403				{object}	entities.RetFailed
+//	@Failure		404				{object}	entities.RetFailed
+//	@Failure		500				{object}	entities.RetFailed
+//	@Router			/webhooks/{id} [put]
+func (wh *Webhooks) UpdateWebhook(w http.ResponseWriter, r *http.Request) error {
+	slog.Debug("UpdateWebhook")
+
+	// authorization
+	authorized, err := wh.auth.Verify(r)
+	if err != nil || !authorized {
+		slog.Warn("UpdateWebhook: authorization failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusForbidden).WriteJSON(w)
+	}
+
+	// load body
+	body := &entities.InWebhook{}
+	if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+		slog.Error("UpdateWebhook: decode failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
+	}
+	if err := validateWebhook(body); err != nil {
+		return entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
+	}
+	inWebhook := entities.NewWebhook(body.URL, body.Secret, body.Events, body.Paused)
+
+	// get target id
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		slog.Error("UpdateWebhook: id string to int failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
+	}
+
+	outWebhook, err := wh.repo.Update(r.Context(), *inWebhook, id)
+	if err != nil {
+		// differentiate if it's db error or that the user supplied id dosen't exist
+		slog.Error("UpdateWebhook: repo update failed", "error", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return entities.NewRetFailed(ErrorTargetNotFound, http.StatusNotFound).WriteJSON(w)
+		}
+
+		if sqliteErr, ok := getSQLiteError(err); ok {
+			slog.Error("got sqlite error", "error code", sqliteErr.Code, "extended error code", sqliteErr.ExtendedCode)
+			return entities.NewRetFailedCustom(err, int(sqliteErr.ExtendedCode), http.StatusInternalServerError).WriteJSON(w)
+		}
+
+		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+	}
+
+	outWebhook.Secret = ""
+	return entities.NewRetSuccess(*outWebhook).WriteJSON(w)
+}
+
+// DeleteWebhook
+//
+//	@Summary		Delete webhook
+//	@Description	delete webhook together with its delivery log
+//	@Tags			webhooks
+//	@Accept			json
+//	@Produce		json
+//	@Param			id				path		int		true	"target webhook id"
+//	@Param			Authorization	header		string	true	"jwt token"
+//	@Success		200				{object}	entities.RetSuccess[entities.RowsAffected]
+//	@Failure		400				{object}	entities.RetFailed
+//	@Failure		403				{object}	entities.RetFailed
+//	@Failure		404				{object}	entities.RetFailed
+//	@Failure		500				{object}	entities.RetFailed
+//	@Router			/webhooks/{id} [delete]
+func (wh *Webhooks) DeleteWebhook(w http.ResponseWriter, r *http.Request) error {
+	slog.Info("DeleteWebhook")
+
+	// authorization
+	authorized, err := wh.auth.Verify(r)
+	if err != nil || !authorized {
+		slog.Warn("DeleteWebhook: authorization failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusForbidden).WriteJSON(w)
+	}
+
+	// get target id
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		slog.Error("DeleteWebhook: id string to int failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
+	}
+
+	affectedRows, err := wh.repo.Delete(r.Context(), id)
+	if err != nil {
+		slog.Error("DeleteWebhook: repo delete failed", "error", err.Error())
+
+		if sqliteErr, ok := getSQLiteError(err); ok {
+			slog.Error("got sqlite error", "error code", sqliteErr.Code, "extended error code", sqliteErr.ExtendedCode)
+			return entities.NewRetFailedCustom(err, int(sqliteErr.ExtendedCode), http.StatusInternalServerError).WriteJSON(w)
+		}
+
+		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+	}
+
+	if affectedRows == 0 {
+		return entities.NewRetFailed(ErrorTargetNotFound, http.StatusNotFound).WriteJSON(w)
+	}
+	return entities.NewRetSuccess(*entities.NewRowsAffected(affectedRows)).WriteJSON(w)
+}
+
+// ListWebhookDeliveries
+//
+//	@Summary		List webhook deliveries
+//	@Description	delivery log of a webhook with the sent events, newest first
+//	@Tags			webhooks
+//	@Accept			json
+//	@Produce		json
+//	@Param			id				path		int		true	"target webhook id"
+//	@Param			Authorization	header		string	true	"jwt token"
+//	@Param			limit			query		int		false	"at most 200"	default(50)
+//	@Success		200				{object}	entities.RetSuccess[[]entities.WebhookDelivery]
+//	@Failure		400				{object}	entities.RetFailed
+//	@Failure		403				{object}	entities.RetFailed
+//	@Failure		404				{object}	entities.RetFailed
+//	@Failure		500				{object}	entities.RetFailed
+//	@Router			/webhooks/{id}/deliveries [get]
+func (wh *Webhooks) ListWebhookDeliveries(w http.ResponseWriter, r *http.Request) error {
+	slog.Debug("ListWebhookDeliveries")
+
+	// authorization
+	authorized, err := wh.auth.Verify(r)
+	if err != nil || !authorized {
+		slog.Warn("ListWebhookDeliveries: authorization failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusForbidden).WriteJSON(w)
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		slog.Error("ListWebhookDeliveries: id path param to int failed", "error", err)
+		return entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
+	}
+
+	limit := defaultDeliveriesLimit
+	if rawLimit := r.URL.Query().Get("limit"); rawLimit != "" {
+		parsed, err := strconv.Atoi(rawLimit)
+		if err != nil || parsed < 1 || parsed > maxDeliveriesLimit {
+			return entities.NewRetFailed(ErrorInvalidLimit, http.StatusBadRequest).WriteJSON(w)
+		}
+		limit = parsed
+	}
+
+	deliveries, err := wh.repo.ListDeliveries(r.Context(), id, limit)
+	if err != nil {
+		slog.Error("ListWebhookDeliveries: repo list deliveries failed", "error", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return entities.NewRetFailed(ErrorTargetNotFound, http.StatusNotFound).WriteJSON(w)
+		}
+		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+	}
+
+	return entities.NewRetSuccess(deliveries).WriteJSON(w)
+}
+
+// ReplayWebhookDelivery
+//
+//	@Summary		Replay webhook delivery
+//	@Description	send the delivery again as soon as possible, attempts are reset
+//	@Tags			webhooks
+//	@Accept			json
+//	@Produce		json
+//	@Param			id				path		int		true	"target delivery id"
+//	@Param			Authorization	header		string	true	"jwt token"
+//	@Success		200				{object}	entities.RetSuccess[entities.WebhookDelivery]
+//	@Failure		400				{object}	entities.RetFailed
+//	@Failure		403				{object}	entities.RetFailed
+//	@Failure		404				{object}	entities.RetFailed
+//	@Failure		500				{object}	entities.RetFailed
+//	@Router			/webhooks/deliveries/{id}/replay [post]
+func (wh *Webhooks) ReplayWebhookDelivery(w http.ResponseWriter, r *http.Request) error {
+	slog.Info("ReplayWebhookDelivery")
+
+	// authorization
+	authorized, err := wh.auth.Verify(r)
+	if err != nil || !authorized {
+		slog.Warn("ReplayWebhookDelivery: authorization failed", "error", err.Error())
+		return entities.NewRetFailed(err, http.StatusForbidden).WriteJSON(w)
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		slog.Error("ReplayWebhookDelivery: id path param to int failed", "error", err)
+		return entities.NewRetFailed(err, http.StatusBadRequest).WriteJSON(w)
+	}
+
+	delivery, err := wh.repo.Replay(r.Context(), id)
+	if err != nil {
+		slog.Error("ReplayWebhookDelivery: repo replay failed", "error", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return entities.NewRetFailed(ErrorTargetNotFound, http.StatusNotFound).WriteJSON(w)
+		}
+		return entities.NewRetFailed(err, http.StatusInternalServerError).WriteJSON(w)
+	}
+
+	return entities.NewRetSuccess(*delivery).WriteJSON(w)
+}
+
+// Checks url and event types, no events subscribes to all of them
+func validateWebhook(body *entities.InWebhook) error {
+	parsed, err := url.Parse(body.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return ErrorInvalidWebhookURL
+	}
+
+	if len(body.Events) == 0 {
+		body.Events = []string{entities.EventAll}
+	}
+	for _, event := range body.Events {
+		if event != entities.EventAll && !slices.Contains(entities.EventTypes, event) {
+			return fmt.Errorf("%w: %s", ErrorInvalidWebhookEvent, event)
+		}
+	}
+
+	return nil
+}
+
+func generateWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("generateWebhookSecret: read random failed: %w", err)
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+// AuditSnapshot is the state of a webhook recorded in the audit log
+func (wh *Webhooks) AuditSnapshot(ctx context.Context, id string) (any, error) {
+	webhookID, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, fmt.Errorf("AuditSnapshot: id to int failed: %w", err)
+	}
+	return wh.repo.Get(ctx, webhookID)
+}
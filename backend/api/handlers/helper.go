@@ -2,9 +2,11 @@ package handlers
 
 import (
 	"blog/entities"
+	"bytes"
 	"errors"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"strconv"
 
 	"github.com/mattn/go-sqlite3"
@@ -107,3 +109,37 @@ func getSQLiteError(err error) (sqliteErr sqlite3.Error, isSQLiteError bool) {
 	}
 	return sqlite3.Error{}, false
 }
+
+// Sets the ETag header, writes 304 and returns true if If-None-Match matches it
+func notModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	w.Header().Set("ETag", etag)
+	if !entities.IfNoneMatch(r.Header.Get("If-None-Match"), etag) {
+		return false
+	}
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// Keeps the status and body of the response, for middlewares acting on the result of a handler
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rr *responseRecorder) WriteHeader(status int) {
+	rr.status = status
+	rr.ResponseWriter.WriteHeader(status)
+}
+
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	if rr.status == 0 {
+		rr.status = http.StatusOK
+	}
+	rr.body.Write(b)
+	return rr.ResponseWriter.Write(b)
+}
+
+func (rr *responseRecorder) Unwrap() http.ResponseWriter {
+	return rr.ResponseWriter
+}
@@ -51,6 +51,26 @@ func (j *JWTHelper) GenJWT(name string) (string, error) {
 
 // returns nil on success
 func (j *JWTHelper) VerifyJWT(token string) error {
+	if _, err := j.parse(token); err != nil {
+		return fmt.Errorf("verifyJWT: %w", err)
+	}
+	return nil
+}
+
+// Subject (user name) of a valid token, doesn't check if the token was logged out
+func (j *JWTHelper) Subject(token string) (string, error) {
+	parsedToken, err := j.parse(token)
+	if err != nil {
+		return "", fmt.Errorf("Subject: %w", err)
+	}
+	subject, err := parsedToken.Claims.GetSubject()
+	if err != nil {
+		return "", fmt.Errorf("Subject: get subject failed: %w", err)
+	}
+	return subject, nil
+}
+
+func (j *JWTHelper) parse(token string) (*jwt.Token, error) {
 	parseFunc := func(token *jwt.Token) (interface{}, error) {
 		return []byte(j.config.Secret), nil
 	}
@@ -63,11 +83,11 @@ func (j *JWTHelper) VerifyJWT(token string) error {
 	)
 
 	if err != nil {
-		return fmt.Errorf("verifyJWT: validate failed: %w", err)
+		return nil, fmt.Errorf("parse: validate failed: %w", err)
 	}
 
 	if parsedToken.Valid {
-		return nil
+		return parsedToken, nil
 	}
-	return fmt.Errorf("verifyJWT: unexpedted error")
+	return nil, fmt.Errorf("parse: unexpedted error")
 }
@@ -22,6 +22,9 @@ func TestJWTHelper(t *testing.T) {
 	if err := jwtHelper.VerifyJWT(newToken); err != nil {
 		t.Fatalf("TestJWTHelper: verify jwt failed: %s", err)
 	}
+	if subject, err := jwtHelper.Subject(newToken); err != nil || subject != "alex" {
+		t.Fatalf("TestJWTHelper: subject should be alex, got %q %v", subject, err)
+	}
 
 	// fail
 	jwtHelper2 := handlers.NewJWTHelper(
@@ -38,4 +41,7 @@ func TestJWTHelper(t *testing.T) {
 	if err := jwtHelper.VerifyJWT(newToken2); err == nil {
 		t.Fatalf("TestJWTHelper: verify should have failed")
 	}
+	if _, err := jwtHelper.Subject(newToken2); err == nil {
+		t.Fatalf("TestJWTHelper: subject of expired token should fail")
+	}
 }
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"blog/config"
+	"blog/entities"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrorInvalidPreviewToken = errors.New("invalid preview token")
+	ErrorPreviewTokenExpired = errors.New("preview token expired")
+)
+
+type previewHelper interface {
+	Sign(link entities.PreviewLink) (string, error)
+	Verify(ctx context.Context, blogID int, token string) (bool, error)
+}
+
+/*
+Tokens look like <link id>.<expire unix time>.<signature>,
+the signature is a HMAC-SHA256 of blog id, link id and expire time.
+
+Only links still in the database are accepted, so deleting a link revokes its token.
+*/
+type PreviewHelper struct {
+	repo   previewLinksRepository
+	config config.PreviewSetting
+}
+
+func NewPreviewHelper(repo previewLinksRepository, config config.PreviewSetting) *PreviewHelper {
+	return &PreviewHelper{
+		repo:   repo,
+		config: config,
+	}
+}
+
+func (p *PreviewHelper) Sign(link entities.PreviewLink) (string, error) {
+	if p.config.Secret == "" {
+		return "", fmt.Errorf("Sign: must have secret")
+	}
+
+	expiresAt, err := time.Parse(time.RFC3339, link.ExpiresAt)
+	if err != nil {
+		return "", fmt.Errorf("Sign: parse expire time failed: %w", err)
+	}
+
+	payload := strconv.Itoa(link.ID) + "." + strconv.FormatInt(expiresAt.Unix(), 10)
+	return payload + "." + p.signature(link.BlogID, payload), nil
+}
+
+func (p *PreviewHelper) Verify(ctx context.Context, blogID int, token string) (bool, error) {
+	if p.config.Secret == "" {
+		return false, fmt.Errorf("Verify: must have secret")
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return false, fmt.Errorf("Verify: malformed token: %w", ErrorInvalidPreviewToken)
+	}
+	payload := parts[0] + "." + parts[1]
+	if !hmac.Equal([]byte(parts[2]), []byte(p.signature(blogID, payload))) {
+		return false, fmt.Errorf("Verify: signature mismatch: %w", ErrorInvalidPreviewToken)
+	}
+
+	linkID, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return false, fmt.Errorf("Verify: link id to int failed: %w", ErrorInvalidPreviewToken)
+	}
+	expires, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return false, fmt.Errorf("Verify: expire time to int failed: %w", ErrorInvalidPreviewToken)
+	}
+	if time.Now().Unix() >= expires {
+		return false, fmt.Errorf("Verify: %w", ErrorPreviewTokenExpired)
+	}
+
+	// revoked links are deleted
+	link, err := p.repo.Get(ctx, linkID)
+	if err != nil {
+		return false, fmt.Errorf("Verify: get preview link failed: %w", err)
+	}
+	if link.BlogID != blogID {
+		return false, fmt.Errorf("Verify: link of another blog: %w", ErrorInvalidPreviewToken)
+	}
+
+	return true, nil
+}
+
+func (p *PreviewHelper) signature(blogID int, payload string) string {
+	mac := hmac.New(sha256.New, []byte(p.config.Secret))
+	mac.Write([]byte(strconv.Itoa(blogID) + "." + payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
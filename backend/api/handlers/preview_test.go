@@ -0,0 +1,80 @@
+package handlers_test
+
+import (
+	"blog/api/handlers"
+	"blog/config"
+	"blog/entities"
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+)
+
+type dummyPreviewLinksRepo struct {
+	links map[int]entities.PreviewLink
+}
+
+func (d *dummyPreviewLinksRepo) Create(ctx context.Context, link entities.PreviewLink) (*entities.PreviewLink, error) {
+	return &link, nil
+}
+func (d *dummyPreviewLinksRepo) Get(ctx context.Context, id int) (*entities.PreviewLink, error) {
+	link, ok := d.links[id]
+	if !ok {
+		return &entities.PreviewLink{}, sql.ErrNoRows
+	}
+	return &link, nil
+}
+func (d *dummyPreviewLinksRepo) ListByBlogID(ctx context.Context, blogID int) ([]entities.PreviewLink, error) {
+	return []entities.PreviewLink{}, nil
+}
+func (d *dummyPreviewLinksRepo) Revoke(ctx context.Context, id, blogID int) (int, error) {
+	delete(d.links, id)
+	return 1, nil
+}
+
+func TestPreviewHelper(t *testing.T) {
+	ctx := context.Background()
+	future := time.Now().Add(time.Hour).UTC().Format("2006-01-02T15:04:05-07:00")
+	past := time.Now().Add(-time.Hour).UTC().Format("2006-01-02T15:04:05-07:00")
+	repo := &dummyPreviewLinksRepo{links: map[int]entities.PreviewLink{
+		1: {ID: 1, BlogID: 10, ExpiresAt: future},
+		2: {ID: 2, BlogID: 10, ExpiresAt: past},
+	}}
+	preview := handlers.NewPreviewHelper(repo, config.PreviewSetting{Secret: "123123"})
+
+	// pass
+	token, err := preview.Sign(repo.links[1])
+	if err != nil {
+		t.Fatalf("TestPreviewHelper: sign failed: %s", err)
+	}
+	if valid, err := preview.Verify(ctx, 10, token); err != nil || !valid {
+		t.Fatalf("TestPreviewHelper: verify failed: %s", err)
+	}
+
+	// fail
+	if valid, err := preview.Verify(ctx, 11, token); !errors.Is(err, handlers.ErrorInvalidPreviewToken) || valid {
+		t.Fatalf("TestPreviewHelper: token of another blog should be invalid, got %v", err)
+	}
+	other := handlers.NewPreviewHelper(repo, config.PreviewSetting{Secret: "456456"})
+	if valid, err := other.Verify(ctx, 10, token); !errors.Is(err, handlers.ErrorInvalidPreviewToken) || valid {
+		t.Fatalf("TestPreviewHelper: token signed with another secret should be invalid, got %v", err)
+	}
+	if valid, err := preview.Verify(ctx, 10, "1.2"); !errors.Is(err, handlers.ErrorInvalidPreviewToken) || valid {
+		t.Fatalf("TestPreviewHelper: malformed token should be invalid, got %v", err)
+	}
+
+	expired, err := preview.Sign(repo.links[2])
+	if err != nil {
+		t.Fatalf("TestPreviewHelper: sign failed: %s", err)
+	}
+	if valid, err := preview.Verify(ctx, 10, expired); !errors.Is(err, handlers.ErrorPreviewTokenExpired) || valid {
+		t.Fatalf("TestPreviewHelper: expired token should fail, got %v", err)
+	}
+
+	// revoked
+	repo.Revoke(ctx, 1, 10)
+	if valid, err := preview.Verify(ctx, 10, token); !errors.Is(err, sql.ErrNoRows) || valid {
+		t.Fatalf("TestPreviewHelper: revoked token should fail, got %v", err)
+	}
+}
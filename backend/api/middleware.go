@@ -1,8 +1,15 @@
 package api
 
 import (
+	"blog/entities"
+	"blog/util"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"log/slog"
 	"net/http"
+	"sync"
+	"time"
 
 	"golang.org/x/time/rate"
 )
@@ -13,6 +20,7 @@ type apiHandler func(w http.ResponseWriter, r *http.Request) error
 // Default middlewares:
 // - error handling
 // - logging
+// - request id ( outermost, so that other middlewares can use it )
 func WithMiddleware(
 	base apiHandler,
 	handlers ...func(http.HandlerFunc) http.HandlerFunc,
@@ -26,7 +34,7 @@ func WithMiddleware(
 		finalHandler = handler(finalHandler)
 	}
 
-	return finalHandler
+	return requestID(finalHandler)
 }
 
 func WithMiddlewareDebugAccessLog(
@@ -67,6 +75,108 @@ func (rlimit *RateLimit) RateLimit(next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
+// Rate limit per client ip, for public endpoints that write to the database.
+// Limiters idle for longer than ipLimiterTTL are dropped.
+type IPRateLimit struct {
+	average  rate.Limit
+	burst    int
+	ipHeader string
+
+	mu        sync.Mutex
+	limiters  map[string]*ipLimiter
+	lastSweep time.Time
+}
+
+type ipLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+const ipLimiterTTL = 10 * time.Minute
+
+// perMinute requests per minute for each client ip.
+// ipHeader is the header holding the client ip set by the reverse proxy, empty to use the remote address.
+func NewIPRateLimit(perMinute, burst int, ipHeader string) *IPRateLimit {
+	slog.Debug("new ip rate limit", "perMinute", perMinute, "burst", burst, "ipHeader", ipHeader)
+	return &IPRateLimit{
+		average:  rate.Every(time.Minute / time.Duration(max(perMinute, 1))),
+		burst:    burst,
+		ipHeader: ipHeader,
+		limiters: map[string]*ipLimiter{},
+	}
+}
+
+func (rlimit *IPRateLimit) RateLimit(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !rlimit.allow(util.ClientIP(r, rlimit.ipHeader)) {
+			http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+func (rlimit *IPRateLimit) allow(ip string) bool {
+	rlimit.mu.Lock()
+	defer rlimit.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(rlimit.lastSweep) > ipLimiterTTL {
+		for key, entry := range rlimit.limiters {
+			if now.Sub(entry.lastSeen) > ipLimiterTTL {
+				delete(rlimit.limiters, key)
+			}
+		}
+		rlimit.lastSweep = now
+	}
+
+	entry, ok := rlimit.limiters[ip]
+	if !ok {
+		entry = &ipLimiter{limiter: rate.NewLimiter(rlimit.average, rlimit.burst)}
+		rlimit.limiters[ip] = entry
+	}
+	entry.lastSeen = now
+	return entry.limiter.Allow()
+}
+
+// longest X-Request-ID accepted from clients or proxies, a new one is generated otherwise
+const maxRequestIDLength = 64
+
+// Keeps X-Request-ID set by the reverse proxy or generates one,
+// it's sent back in the response and stored in the request context.
+func requestID(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-ID")
+		if id == "" || len(id) > maxRequestIDLength {
+			buf := make([]byte, 16)
+			if _, err := rand.Read(buf); err != nil {
+				slog.Error("requestID: generate request id failed", "error", err)
+			}
+			id = hex.EncodeToString(buf)
+		}
+
+		w.Header().Set("X-Request-ID", id)
+		next(w, r.WithContext(util.WithRequestID(r.Context(), id)))
+	}
+}
+
+var ErrorIfMatchRequired = errors.New("If-Match header is required, send the ETag of the target from a previous GET")
+
+// Rejects writes without If-Match when required, so that clients can't overwrite changes they haven't seen.
+// The handler compares If-Match with the current ETag.
+func requireIfMatch(required bool) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if required && r.Header.Get("If-Match") == "" {
+				entities.NewRetFailed(ErrorIfMatchRequired, http.StatusPreconditionRequired).WriteJSON(w)
+				return
+			}
+			next(w, r)
+		}
+	}
+}
+
 // logging request path
 func logPath(next http.HandlerFunc, level string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
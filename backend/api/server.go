@@ -13,29 +13,59 @@ import (
 )
 
 type Server struct {
-	server *http.Server
-	config config.Config
-	blogs  handlers.Blogs
-	topics handlers.Topics
-	tags   handlers.Tags
-	users  handlers.Users
-	probes handlers.Probes
+	server      *http.Server
+	config      config.Config
+	blogs       handlers.Blogs
+	previews    handlers.PreviewLinks
+	topics      handlers.Topics
+	tags        handlers.Tags
+	users       handlers.Users
+	series      handlers.Series
+	comments    handlers.Comments
+	stats       handlers.Stats
+	webhooks    handlers.Webhooks
+	events      handlers.Events
+	media       handlers.Media
+	probes      handlers.Probes
+	audit       handlers.Audit
+	idempotency handlers.Idempotency
+	bulk        handlers.Bulk
 }
 
 func NewServer(
 	config config.Config,
 	blogs handlers.Blogs,
+	previews handlers.PreviewLinks,
 	tags handlers.Tags,
 	topics handlers.Topics,
 	users handlers.Users,
-	probes handlers.Probes) *Server {
+	series handlers.Series,
+	comments handlers.Comments,
+	stats handlers.Stats,
+	webhooks handlers.Webhooks,
+	events handlers.Events,
+	media handlers.Media,
+	probes handlers.Probes,
+	audit handlers.Audit,
+	idempotency handlers.Idempotency,
+	bulk handlers.Bulk) *Server {
 	return &Server{
-		config: config,
-		blogs:  blogs,
-		tags:   tags,
-		topics: topics,
-		users:  users,
-		probes: probes,
+		config:      config,
+		blogs:       blogs,
+		previews:    previews,
+		tags:        tags,
+		topics:      topics,
+		users:       users,
+		series:      series,
+		comments:    comments,
+		stats:       stats,
+		webhooks:    webhooks,
+		events:      events,
+		media:       media,
+		probes:      probes,
+		audit:       audit,
+		idempotency: idempotency,
+		bulk:        bulk,
 	}
 }
 
@@ -49,34 +79,104 @@ func (s *Server) Start() error {
 	mux.HandleFunc("GET /docs/*", WithMiddleware(apiHandlerWrapper(
 		httpSwagger.Handler(httpSwagger.URL(filepath)))))
 
+	// audit log of authenticated changes
+	auditSession := s.audit.Record("session", "", nil)
+	auditBlog := s.audit.Record("blog", "id", s.blogs.AuditSnapshot)
+	auditTrash := s.audit.Record("blog", "", nil)
+	auditPreviewLink := s.audit.Record("preview_link", "linkID", s.previews.AuditSnapshot)
+	auditTag := s.audit.Record("tag", "id", s.tags.AuditSnapshot)
+	auditTopic := s.audit.Record("topic", "id", s.topics.AuditSnapshot)
+	auditComment := s.audit.Record("comment", "id", nil)
+	auditWebhook := s.audit.Record("webhook", "id", s.webhooks.AuditSnapshot)
+	auditWebhookDelivery := s.audit.Record("webhook_delivery", "id", nil)
+	auditSeries := s.audit.Record("series", "id", s.series.AuditSnapshot)
+	auditMedia := s.audit.Record("media", "hash", s.media.AuditSnapshot)
+	auditBulk := s.audit.Record("bulk", "", nil)
+
+	// optimistic concurrency control
+	ifMatch := requireIfMatch(s.config.Server.RequireIfMatch)
+
 	// authentication
 	loginRateLimit := NewRateLimit(s.config.Login.RateLimit, s.config.Login.RateLimit*2)
 	authCheckRateLimit := NewRateLimit(s.config.Login.RateLimit, s.config.Login.RateLimit*2)
 	mux.HandleFunc(s.post("/login"), WithMiddleware(s.users.Login, loginRateLimit.RateLimit))
-	mux.HandleFunc(s.post("/logout"), WithMiddleware(s.users.Logout))
+	mux.HandleFunc(s.post("/logout"), WithMiddleware(s.users.Logout, auditSession))
 	mux.HandleFunc(s.post("/auth-check"), WithMiddleware(s.users.AuthorizeCheck, authCheckRateLimit.RateLimit))
 
-	mux.HandleFunc(s.post("/blogs"), WithMiddleware(s.blogs.CreateBlog))
+	// replays are answered before the audit log, they aren't new changes
+	mux.HandleFunc(s.post("/blogs"), WithMiddleware(s.blogs.CreateBlog, auditBlog, s.idempotency.Handle))
 	mux.HandleFunc(s.get("/blogs"), WithMiddleware(s.blogs.ListBlogs))
 	mux.HandleFunc(s.get("/blogs/{id}"), WithMiddleware(s.blogs.GetBlog))
-	mux.HandleFunc(s.put("/blogs/{id}"), WithMiddleware(s.blogs.UpdateBlog))
-	mux.HandleFunc(s.post("/blogs/{id}"), WithMiddleware(s.blogs.CreateBlogWithID))
-	mux.HandleFunc(s.delete("/blogs/{id}"), WithMiddleware(s.blogs.SoftDeleteBlog))
-	mux.HandleFunc(s.delete("/blogs/deleted/{id}"), WithMiddleware(s.blogs.DeleteBlog))
-	mux.HandleFunc(s.delete("/blogs/delete-now/{id}"), WithMiddleware(s.blogs.DeleteBlogNow))
-	mux.HandleFunc(s.patch("/blogs/deleted/{id}"), WithMiddleware(s.blogs.RestoreDeletedBlog))
-
-	mux.HandleFunc(s.post("/tags"), WithMiddleware(s.tags.CreateTag))
+	mux.HandleFunc(s.put("/blogs/{id}"), WithMiddleware(s.blogs.UpdateBlog, ifMatch, auditBlog))
+	mux.HandleFunc(s.patch("/blogs/{id}"), WithMiddleware(s.blogs.PatchBlog, ifMatch, auditBlog))
+	mux.HandleFunc(s.post("/blogs/{id}"), WithMiddleware(s.blogs.CreateBlogWithID, auditBlog, s.idempotency.Handle))
+	mux.HandleFunc(s.delete("/blogs/{id}"), WithMiddleware(s.blogs.SoftDeleteBlog, ifMatch, auditBlog))
+	mux.HandleFunc(s.delete("/blogs/deleted/{id}"), WithMiddleware(s.blogs.DeleteBlog, ifMatch, auditBlog))
+	mux.HandleFunc(s.delete("/blogs/delete-now/{id}"), WithMiddleware(s.blogs.DeleteBlogNow, ifMatch, auditBlog))
+	mux.HandleFunc(s.patch("/blogs/deleted/{id}"), WithMiddleware(s.blogs.RestoreDeletedBlog, auditBlog))
+	mux.HandleFunc(s.get("/blogs/deleted"), WithMiddleware(s.blogs.ListDeletedBlogs))
+	mux.HandleFunc(s.delete("/blogs/deleted"), WithMiddleware(s.blogs.EmptyTrash, auditTrash))
+	mux.HandleFunc(s.post("/blogs/{id}/transition"), WithMiddleware(s.blogs.TransitionBlog, auditBlog))
+	mux.HandleFunc(s.get("/blogs/{id}/status-events"), WithMiddleware(s.blogs.ListBlogStatusEvents))
+
+	mux.HandleFunc(s.post("/blogs/{id}/preview-links"), WithMiddleware(s.previews.CreatePreviewLink, auditPreviewLink))
+	mux.HandleFunc(s.get("/blogs/{id}/preview-links"), WithMiddleware(s.previews.ListPreviewLinks))
+	mux.HandleFunc(s.delete("/blogs/{id}/preview-links/{linkID}"), WithMiddleware(s.previews.RevokePreviewLink, auditPreviewLink))
+
+	mux.HandleFunc(s.post("/tags"), WithMiddleware(s.tags.CreateTag, auditTag, s.idempotency.Handle))
 	mux.HandleFunc(s.get("/tags"), WithMiddleware(s.tags.ListTags))
 	mux.HandleFunc(s.get("/tags/{id}"), WithMiddleware(s.tags.GetTag))
-	mux.HandleFunc(s.put("/tags/{id}"), WithMiddleware(s.tags.UpdateTag))
-	mux.HandleFunc(s.delete("/tags/{id}"), WithMiddleware(s.tags.DeleteTag))
+	mux.HandleFunc(s.put("/tags/{id}"), WithMiddleware(s.tags.UpdateTag, ifMatch, auditTag))
+	mux.HandleFunc(s.patch("/tags/{id}"), WithMiddleware(s.tags.PatchTag, ifMatch, auditTag))
+	mux.HandleFunc(s.delete("/tags/{id}"), WithMiddleware(s.tags.DeleteTag, ifMatch, auditTag))
 
-	mux.HandleFunc(s.post("/topics"), WithMiddleware(s.topics.CreateTopic))
+	mux.HandleFunc(s.post("/topics"), WithMiddleware(s.topics.CreateTopic, auditTopic, s.idempotency.Handle))
 	mux.HandleFunc(s.get("/topics"), WithMiddleware(s.topics.ListTopics))
 	mux.HandleFunc(s.get("/topics/{id}"), WithMiddleware(s.topics.GetTopic))
-	mux.HandleFunc(s.put("/topics/{id}"), WithMiddleware(s.topics.UpdateTopic))
-	mux.HandleFunc(s.delete("/topics/{id}"), WithMiddleware(s.topics.DeleteTopic))
+	mux.HandleFunc(s.put("/topics/{id}"), WithMiddleware(s.topics.UpdateTopic, ifMatch, auditTopic))
+	mux.HandleFunc(s.patch("/topics/{id}"), WithMiddleware(s.topics.PatchTopic, ifMatch, auditTopic))
+	mux.HandleFunc(s.delete("/topics/{id}"), WithMiddleware(s.topics.DeleteTopic, ifMatch, auditTopic))
+
+	// topics, tags and blogs in one transaction
+	mux.HandleFunc(s.post("/bulk"), WithMiddleware(s.bulk.ApplyBulk, auditBulk, s.idempotency.Handle))
+
+	commentRateLimit := NewIPRateLimit(s.config.Comments.RateLimit, s.config.Comments.RateLimit, s.config.Server.ClientIPHeader)
+	mux.HandleFunc(s.post("/blogs/{id}/comments"), WithMiddleware(s.comments.CreateComment, commentRateLimit.RateLimit))
+	mux.HandleFunc(s.get("/blogs/{id}/comments"), WithMiddleware(s.comments.ListComments))
+	mux.HandleFunc(s.get("/comments"), WithMiddleware(s.comments.ListCommentsByStatus))
+	mux.HandleFunc(s.patch("/comments/{id}/approve"), WithMiddleware(s.comments.ApproveComment, auditComment))
+	mux.HandleFunc(s.patch("/comments/{id}/reject"), WithMiddleware(s.comments.RejectComment, auditComment))
+	mux.HandleFunc(s.delete("/comments/{id}"), WithMiddleware(s.comments.DeleteComment, auditComment))
+
+	viewRateLimit := NewIPRateLimit(s.config.Analytics.RateLimit, s.config.Analytics.RateLimit, s.config.Server.ClientIPHeader)
+	mux.HandleFunc(s.post("/blogs/{id}/view"), WithMiddleware(s.stats.RecordView, viewRateLimit.RateLimit))
+	mux.HandleFunc(s.get("/blogs/popular"), WithMiddleware(s.stats.ListPopularBlogs))
+	mux.HandleFunc(s.get("/stats/blogs"), WithMiddleware(s.stats.ListBlogStats))
+	mux.HandleFunc(s.get("/stats/blogs/{id}"), WithMiddleware(s.stats.GetBlogStats))
+
+	mux.HandleFunc(s.post("/webhooks"), WithMiddleware(s.webhooks.CreateWebhook, auditWebhook))
+	mux.HandleFunc(s.get("/webhooks"), WithMiddleware(s.webhooks.ListWebhooks))
+	mux.HandleFunc(s.get("/webhooks/{id}"), WithMiddleware(s.webhooks.GetWebhook))
+	mux.HandleFunc(s.put("/webhooks/{id}"), WithMiddleware(s.webhooks.UpdateWebhook, auditWebhook))
+	mux.HandleFunc(s.delete("/webhooks/{id}"), WithMiddleware(s.webhooks.DeleteWebhook, auditWebhook))
+	mux.HandleFunc(s.get("/webhooks/{id}/deliveries"), WithMiddleware(s.webhooks.ListWebhookDeliveries))
+	mux.HandleFunc(s.post("/webhooks/deliveries/{id}/replay"), WithMiddleware(s.webhooks.ReplayWebhookDelivery, auditWebhookDelivery))
+
+	mux.HandleFunc(s.get("/events"), WithMiddleware(s.events.StreamEvents))
+
+	mux.HandleFunc(s.post("/series"), WithMiddleware(s.series.CreateSeries, auditSeries))
+	mux.HandleFunc(s.get("/series"), WithMiddleware(s.series.ListSeries))
+	mux.HandleFunc(s.get("/series/{id}"), WithMiddleware(s.series.GetSeries))
+	mux.HandleFunc(s.put("/series/{id}"), WithMiddleware(s.series.UpdateSeries, auditSeries))
+	mux.HandleFunc(s.delete("/series/{id}"), WithMiddleware(s.series.DeleteSeries, auditSeries))
+
+	mux.HandleFunc(s.post("/media"), WithMiddleware(s.media.UploadMedia, auditMedia))
+	mux.HandleFunc(s.get("/media"), WithMiddleware(s.media.ListMedia))
+	mux.HandleFunc(s.get("/media/{hash}"), WithMiddleware(s.media.GetMedia))
+	mux.HandleFunc(s.delete("/media/{hash}"), WithMiddleware(s.media.DeleteMedia, auditMedia))
+	mux.HandleFunc(s.delete("/media/unused"), WithMiddleware(s.media.DeleteUnusedMedia, auditMedia))
+
+	mux.HandleFunc(s.get("/audit"), WithMiddleware(s.audit.ListAuditLog))
 
 	mux.HandleFunc(s.getRoot("/alive"), WithMiddlewareDebugAccessLog(s.probes.LivenessProbe))
 	mux.HandleFunc(s.getRoot("/ready"), WithMiddlewareDebugAccessLog(s.probes.ReadinessProbe))
@@ -85,6 +185,8 @@ func (s *Server) Start() error {
 		Addr:    fmt.Sprintf(":%d", s.config.Server.Port),
 		Handler: mux,
 	}
+	// event streams never end by themselves
+	s.server.RegisterOnShutdown(s.events.Close)
 	slog.Info("Server is listening on", "port", s.config.Server.Port)
 	return s.server.ListenAndServe()
 }
@@ -0,0 +1,23 @@
+package client
+
+import (
+	"blog/entities"
+	"context"
+	"net/http"
+)
+
+// Newest first, the next page starts before the id of the last entry
+func (c *Client) ListAuditLog(ctx context.Context, filter entities.AuditFilter, options ...RequestOption) ([]entities.AuditEntry, error) {
+	target := filter.TargetType
+	if target != "" && filter.TargetID != "" {
+		target += ":" + filter.TargetID
+	}
+	params := query{}.
+		setString("actor", filter.Actor).
+		setString("target", target).
+		setString("from", filter.From).
+		setString("to", filter.To).
+		setInt("before", filter.Before).
+		setInt("limit", filter.Limit)
+	return do[[]entities.AuditEntry](ctx, c, "ListAuditLog", http.MethodGet, nil, params, options, "audit")
+}
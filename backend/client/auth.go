@@ -0,0 +1,71 @@
+package client
+
+import (
+	"blog/entities"
+	"context"
+	"net/http"
+)
+
+// Adds credentials to requests
+type Auth interface {
+	Authorize(req *http.Request)
+}
+
+// Authorization: Bearer <jwt token>, from Login
+type JWT string
+
+func (j JWT) Authorize(req *http.Request) {
+	req.Header.Set("Authorization", "Bearer "+string(j))
+}
+
+// Authorization: Basic <base64 encoded username:password>, only accepted by Login
+type BasicAuth struct {
+	Username string
+	Password string
+}
+
+func (b BasicAuth) Authorize(req *http.Request) {
+	req.SetBasicAuth(b.Username, b.Password)
+}
+
+// The server itself only takes jwt tokens, this is for reverse proxies in front of it
+type APIKey struct {
+	Header string // X-API-Key if empty
+	Key    string
+}
+
+func (a APIKey) Authorize(req *http.Request) {
+	header := a.Header
+	if header == "" {
+		header = "X-API-Key"
+	}
+	req.Header.Set(header, a.Key)
+}
+
+// Any other way of authorizing requests
+type AuthFunc func(req *http.Request)
+
+func (f AuthFunc) Authorize(req *http.Request) {
+	f(req)
+}
+
+// Login with basic auth, returns a jwt token for JWT
+func (c *Client) Login(ctx context.Context, username, password string, options ...RequestOption) (string, error) {
+	jwt, err := do[entities.JWT](ctx, c.WithAuth(BasicAuth{Username: username, Password: password}), "Login", http.MethodPost, nil, nil, options, "login")
+	if err != nil {
+		return "", err
+	}
+	return jwt.JWT, nil
+}
+
+// Invalidates the jwt token of the client
+func (c *Client) Logout(ctx context.Context, options ...RequestOption) error {
+	_, err := do[string](ctx, c, "Logout", http.MethodPost, nil, nil, options, "logout")
+	return err
+}
+
+// nil if the server accepts the jwt token, errors.Is(err, ErrorForbidden) if not
+func (c *Client) AuthCheck(ctx context.Context, options ...RequestOption) error {
+	_, err := do[string](ctx, c, "AuthCheck", http.MethodPost, nil, nil, options, "auth-check")
+	return err
+}
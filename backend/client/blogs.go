@@ -0,0 +1,124 @@
+package client
+
+import (
+	"blog/entities"
+	"context"
+	"net/http"
+	"strconv"
+)
+
+// Filters of ListBlogs, zero values aren't filtered on
+type BlogFilter struct {
+	// regardless of visibility or soft delete status, needs auth
+	All    bool
+	Topics []int
+	// only used together with topics
+	Tags []int
+	// only used with all, ex: entities.BlogDraft
+	Statuses []string
+}
+
+func (f BlogFilter) query() query {
+	return query{}.
+		setBool("all", f.All).
+		addInts("topic", f.Topics).
+		addInts("tag", f.Tags).
+		addStrings("status", f.Statuses)
+}
+
+type GetBlogQuery struct {
+	// regardless of visibility or soft delete status, needs auth
+	All bool
+	// content is rendered to html
+	Parsed bool
+	// token of a preview link, reads the blog regardless of visibility without auth
+	Preview string
+}
+
+func (c *Client) CreateBlog(ctx context.Context, blog entities.ReqInBlog, options ...RequestOption) (entities.OutBlog, error) {
+	return do[entities.OutBlog](ctx, c, "CreateBlog", http.MethodPost, blog, nil, options, "blogs")
+}
+
+// Create with the given id, for restoring blogs with their original ids
+func (c *Client) CreateBlogWithID(ctx context.Context, id int, blog entities.ReqInBlog, options ...RequestOption) (entities.OutBlog, error) {
+	return do[entities.OutBlog](ctx, c, "CreateBlogWithID", http.MethodPost, blog, nil, options, "blogs", strconv.Itoa(id))
+}
+
+func (c *Client) ListBlogs(ctx context.Context, filter BlogFilter, options ...RequestOption) ([]entities.OutBlog, error) {
+	return do[[]entities.OutBlog](ctx, c, "ListBlogs", http.MethodGet, nil, filter.query(), options, "blogs")
+}
+
+// Tags, topics and series as slugs, only differs from ListBlogs with filter.All
+func (c *Client) ListBlogsSimple(ctx context.Context, filter BlogFilter, options ...RequestOption) ([]entities.OutBlogSimple, error) {
+	return do[[]entities.OutBlogSimple](ctx, c, "ListBlogsSimple", http.MethodGet, nil, filter.query().setBool("simple", true), options, "blogs")
+}
+
+func (c *Client) GetBlog(ctx context.Context, id int, q GetBlogQuery, options ...RequestOption) (entities.OutBlog, error) {
+	params := query{}.
+		setBool("all", q.All).
+		setBool("parsed", q.Parsed).
+		setString("preview", q.Preview)
+	return do[entities.OutBlog](ctx, c, "GetBlog", http.MethodGet, nil, params, options, "blogs", strconv.Itoa(id))
+}
+
+func (c *Client) UpdateBlog(ctx context.Context, id int, blog entities.ReqInBlog, options ...RequestOption) (entities.OutBlog, error) {
+	return do[entities.OutBlog](ctx, c, "UpdateBlog", http.MethodPut, blog, nil, options, "blogs", strconv.Itoa(id))
+}
+
+/*
+JSON Merge Patch of a blog, only the fields in patch change.
+
+Fields are the json names of entities.BlogPatch, nil values are sent as null and clear the field.
+entities.BlogPatch isn't taken as is, its nil fields can't tell unchanged from null.
+*/
+func (c *Client) PatchBlog(ctx context.Context, id int, patch map[string]any, options ...RequestOption) (entities.OutBlog, error) {
+	return do[entities.OutBlog](ctx, c, "PatchBlog", http.MethodPatch, patch, nil, options, "blogs", strconv.Itoa(id))
+}
+
+// Moves the blog to the trash
+func (c *Client) SoftDeleteBlog(ctx context.Context, id int, options ...RequestOption) (entities.RowsAffected, error) {
+	return do[entities.RowsAffected](ctx, c, "SoftDeleteBlog", http.MethodDelete, nil, nil, options, "blogs", strconv.Itoa(id))
+}
+
+// Deletes a blog in the trash
+func (c *Client) DeleteBlog(ctx context.Context, id int, options ...RequestOption) (entities.RowsAffected, error) {
+	return do[entities.RowsAffected](ctx, c, "DeleteBlog", http.MethodDelete, nil, nil, options, "blogs", "deleted", strconv.Itoa(id))
+}
+
+// Deletes a blog without moving it to the trash first
+func (c *Client) DeleteBlogNow(ctx context.Context, id int, options ...RequestOption) (entities.RowsAffected, error) {
+	return do[entities.RowsAffected](ctx, c, "DeleteBlogNow", http.MethodDelete, nil, nil, options, "blogs", "delete-now", strconv.Itoa(id))
+}
+
+func (c *Client) RestoreDeletedBlog(ctx context.Context, id int, options ...RequestOption) (entities.OutBlog, error) {
+	return do[entities.OutBlog](ctx, c, "RestoreDeletedBlog", http.MethodPatch, nil, nil, options, "blogs", "deleted", strconv.Itoa(id))
+}
+
+func (c *Client) ListDeletedBlogs(ctx context.Context, options ...RequestOption) ([]entities.OutBlog, error) {
+	return do[[]entities.OutBlog](ctx, c, "ListDeletedBlogs", http.MethodGet, nil, nil, options, "blogs", "deleted")
+}
+
+// Deletes every blog in the trash, dryRun only lists them
+func (c *Client) EmptyTrash(ctx context.Context, dryRun bool, options ...RequestOption) ([]entities.Blog, error) {
+	return do[[]entities.Blog](ctx, c, "EmptyTrash", http.MethodDelete, nil, query{}.setBool("dryRun", dryRun), options, "blogs", "deleted")
+}
+
+func (c *Client) TransitionBlog(ctx context.Context, id int, transition entities.InTransition, options ...RequestOption) (entities.OutBlog, error) {
+	return do[entities.OutBlog](ctx, c, "TransitionBlog", http.MethodPost, transition, nil, options, "blogs", strconv.Itoa(id), "transition")
+}
+
+func (c *Client) ListBlogStatusEvents(ctx context.Context, id int, options ...RequestOption) ([]entities.BlogStatusEvent, error) {
+	return do[[]entities.BlogStatusEvent](ctx, c, "ListBlogStatusEvents", http.MethodGet, nil, nil, options, "blogs", strconv.Itoa(id), "status-events")
+}
+
+func (c *Client) CreatePreviewLink(ctx context.Context, blogID int, link entities.InPreviewLink, options ...RequestOption) (entities.PreviewLink, error) {
+	return do[entities.PreviewLink](ctx, c, "CreatePreviewLink", http.MethodPost, link, nil, options, "blogs", strconv.Itoa(blogID), "preview-links")
+}
+
+func (c *Client) ListPreviewLinks(ctx context.Context, blogID int, options ...RequestOption) ([]entities.PreviewLink, error) {
+	return do[[]entities.PreviewLink](ctx, c, "ListPreviewLinks", http.MethodGet, nil, nil, options, "blogs", strconv.Itoa(blogID), "preview-links")
+}
+
+func (c *Client) RevokePreviewLink(ctx context.Context, blogID, linkID int, options ...RequestOption) (entities.RowsAffected, error) {
+	return do[entities.RowsAffected](ctx, c, "RevokePreviewLink", http.MethodDelete, nil, nil, options, "blogs", strconv.Itoa(blogID), "preview-links", strconv.Itoa(linkID))
+}
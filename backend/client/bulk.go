@@ -0,0 +1,12 @@
+package client
+
+import (
+	"blog/entities"
+	"context"
+	"net/http"
+)
+
+// Topics, tags and blogs created, updated and deleted in one transaction
+func (c *Client) ApplyBulk(ctx context.Context, bulk entities.Bulk, options ...RequestOption) (entities.OutBulk, error) {
+	return do[entities.OutBulk](ctx, c, "ApplyBulk", http.MethodPost, bulk, nil, options, "bulk")
+}
@@ -0,0 +1,252 @@
+/*
+Package client is a typed client of the blog API.
+
+Every route of api.Server.Start has a method taking a context,
+responses are decoded into entities and failed responses come back as *ResponseError.
+The swagger docs under /docs aren't part of the API and have no method.
+*/
+package client
+
+import (
+	"blog/entities"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// longest response body read, larger bodies are cut off
+const LimitReaderSize int64 = 10 * 1024 * 1024 // 10MB
+
+// Sends requests, *http.Client or anything wrapping it, like a client that retries
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+type Client struct {
+	baseURL string // with the routing prefix, ex: http://localhost:8080/api/v1
+	doer    Doer
+	auth    Auth
+}
+
+// doer defaults to http.DefaultClient, auth can be nil for public routes
+func New(baseURL string, doer Doer, auth Auth) *Client {
+	if doer == nil {
+		doer = http.DefaultClient
+	}
+	return &Client{
+		baseURL: baseURL,
+		doer:    doer,
+		auth:    auth,
+	}
+}
+
+// A copy of the client sending requests with auth, ex: the jwt after logging in
+func (c *Client) WithAuth(auth Auth) *Client {
+	result := *c
+	result.auth = auth
+	return &result
+}
+
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+// Changes a single request, like conditional requests or idempotency keys
+type RequestOption struct {
+	request  func(req *http.Request)
+	response func(res *http.Response)
+}
+
+// The target must not have changed since the GET that returned etag, 412 otherwise
+func IfMatch(etag string) RequestOption {
+	return Header("If-Match", etag)
+}
+
+// Fails with ErrorNotModified if the target still has etag
+func IfNoneMatch(etag string) RequestOption {
+	return Header("If-None-Match", etag)
+}
+
+// Retries with the same key and body replay the first response instead of creating again
+func IdempotencyKey(key string) RequestOption {
+	return Header("Idempotency-Key", key)
+}
+
+func Header(key, value string) RequestOption {
+	return RequestOption{request: func(req *http.Request) {
+		req.Header.Set(key, value)
+	}}
+}
+
+// Stores the ETag of the response in etag, for IfMatch and IfNoneMatch later
+func ETag(etag *string) RequestOption {
+	return RequestOption{response: func(res *http.Response) {
+		*etag = res.Header.Get("ETag")
+	}}
+}
+
+// url of the route under the base url
+func (c *Client) endpoint(query url.Values, elem ...string) (string, error) {
+	apiURL, err := url.JoinPath(c.baseURL, elem...)
+	if err != nil {
+		return "", fmt.Errorf("endpoint: join api url failed: %w", err)
+	}
+	if len(query) > 0 {
+		apiURL += "?" + query.Encode()
+	}
+	return apiURL, nil
+}
+
+// body is encoded as json, nil for none.
+// The body is kept in memory, so it can be sent again by a Doer that retries.
+func (c *Client) newRequest(ctx context.Context, method string, body any, query url.Values, elem ...string) (*http.Request, error) {
+	apiURL, err := c.endpoint(query, elem...)
+	if err != nil {
+		return nil, fmt.Errorf("newRequest: %w", err)
+	}
+
+	if body == nil {
+		req, err := http.NewRequestWithContext(ctx, method, apiURL, nil)
+		if err != nil {
+			return nil, fmt.Errorf("newRequest: create new request failed: %w", err)
+		}
+		return req, nil
+	}
+
+	data := &bytes.Buffer{}
+	if err := json.NewEncoder(data).Encode(body); err != nil {
+		return nil, fmt.Errorf("newRequest: encode body failed: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, apiURL, data)
+	if err != nil {
+		return nil, fmt.Errorf("newRequest: create new request failed: %w", err)
+	}
+	req.Header.Set("content-type", "application/json")
+	return req, nil
+}
+
+/*
+Sends the request with auth and options.
+
+Failed responses are returned as *ResponseError with the body already closed,
+otherwise the caller closes the body.
+*/
+func (c *Client) send(req *http.Request, options []RequestOption) (*http.Response, error) {
+	if c.auth != nil {
+		c.auth.Authorize(req)
+	}
+	for _, option := range options {
+		if option.request != nil {
+			option.request(req)
+		}
+	}
+
+	res, err := c.doer.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("send: %s %s failed: %w", req.Method, req.URL.Path, err)
+	}
+	for _, option := range options {
+		if option.response != nil {
+			option.response(res)
+		}
+	}
+
+	if res.StatusCode < 300 {
+		return res, nil
+	}
+
+	resBody, err := io.ReadAll(io.LimitReader(res.Body, LimitReaderSize))
+	err = errors.Join(err, drainAndClose(res.Body))
+	if err != nil {
+		return nil, fmt.Errorf("send: read body failed: %w", err)
+	}
+	return nil, newResponseError(res, resBody)
+}
+
+// sends the request and decodes the msg of the response
+func call[T entities.MsgType](c *Client, req *http.Request, options []RequestOption) (result T, oErr error) {
+	res, err := c.send(req, options)
+	if err != nil {
+		return result, err
+	}
+	defer func() {
+		oErr = errors.Join(oErr, drainAndClose(res.Body))
+	}()
+
+	data := entities.RetSuccess[T]{}
+	if err := json.NewDecoder(io.LimitReader(res.Body, LimitReaderSize)).Decode(&data); err != nil {
+		return result, fmt.Errorf("call: decode body of %s %s failed: %w", req.Method, req.URL.Path, err)
+	}
+	return data.Msg, nil
+}
+
+// creates the request and calls it, failures are prefixed with name
+func do[T entities.MsgType](ctx context.Context, c *Client, name, method string, body any, params query, options []RequestOption, elem ...string) (T, error) {
+	var zero T
+	req, err := c.newRequest(ctx, method, body, params.values(), elem...)
+	if err != nil {
+		return zero, fmt.Errorf("%s: %w", name, err)
+	}
+	result, err := call[T](c, req, options)
+	if err != nil {
+		return zero, fmt.Errorf("%s: %w", name, err)
+	}
+	return result, nil
+}
+
+func drainAndClose(body io.ReadCloser) error {
+	reader := io.LimitReader(body, LimitReaderSize)
+	_, drainErr := io.Copy(io.Discard, reader)
+	if drainErr != nil {
+		return fmt.Errorf("drainAndClose: drain failed: %w", drainErr)
+	}
+	return body.Close()
+}
+
+// query parameters, zero values are left out
+type query url.Values
+
+func (q query) values() url.Values {
+	return url.Values(q)
+}
+
+func (q query) setBool(key string, value bool) query {
+	if value {
+		url.Values(q).Set(key, "true")
+	}
+	return q
+}
+
+func (q query) setInt(key string, value int) query {
+	if value != 0 {
+		url.Values(q).Set(key, strconv.Itoa(value))
+	}
+	return q
+}
+
+func (q query) setString(key, value string) query {
+	if value != "" {
+		url.Values(q).Set(key, value)
+	}
+	return q
+}
+
+func (q query) addInts(key string, values []int) query {
+	for _, value := range values {
+		url.Values(q).Add(key, strconv.Itoa(value))
+	}
+	return q
+}
+
+func (q query) addStrings(key string, values []string) query {
+	for _, value := range values {
+		url.Values(q).Add(key, value)
+	}
+	return q
+}
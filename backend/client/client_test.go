@@ -0,0 +1,279 @@
+package client
+
+import (
+	"blog/entities"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResponseError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-ID", "request-1")
+		switch r.URL.Path {
+		case "/api/v1/tags/1":
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(entities.NewRetFailed(errors.New("tag not found"), http.StatusNotFound))
+		case "/api/v1/tags/2":
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(entities.NewRetFailed(errors.New("invalid token"), http.StatusForbidden))
+		case "/api/v1/tags/3":
+			w.WriteHeader(http.StatusPreconditionFailed)
+			json.NewEncoder(w).Encode(entities.NewRetFailed(errors.New("etag mismatch"), http.StatusPreconditionFailed))
+		case "/api/v1/tags/4":
+			// sqlite error code instead of the status code
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(entities.NewRetFailed(errors.New("database is locked"), 5))
+		default:
+			// rate limited responses are plain text
+			http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+		}
+	}))
+	defer server.Close()
+	api := New(server.URL+"/api/v1", server.Client(), JWT("token"))
+
+	testCases := []struct {
+		id       int
+		expected error
+		status   int
+		msg      string
+	}{
+		{1, ErrorNotFound, http.StatusNotFound, "tag not found"},
+		{2, ErrorForbidden, http.StatusForbidden, "invalid token"},
+		{3, ErrorPreconditionFailed, http.StatusPreconditionFailed, "etag mismatch"},
+		{4, ErrorServer, 5, "database is locked"},
+		{5, ErrorTooManyRequests, http.StatusTooManyRequests, "Too Many Requests"},
+	}
+	for _, tc := range testCases {
+		_, err := api.GetTag(context.Background(), tc.id)
+		if !errors.Is(err, tc.expected) {
+			t.Fatalf("TestResponseError: expected %q for tag %d, got %v", tc.expected, tc.id, err)
+		}
+		resErr := &ResponseError{}
+		if !errors.As(err, &resErr) {
+			t.Fatalf("TestResponseError: expected a *ResponseError for tag %d, got %T", tc.id, err)
+		}
+		if resErr.Failed.Status != tc.status || resErr.Failed.Error != tc.msg || resErr.RequestID != "request-1" {
+			t.Fatalf("TestResponseError: unexpected error for tag %d: %+v", tc.id, resErr)
+		}
+		if errors.Is(err, ErrorBadRequest) {
+			t.Fatalf("TestResponseError: tag %d shouldn't match other status codes", tc.id)
+		}
+	}
+}
+
+func TestAuth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/login":
+			username, password, ok := r.BasicAuth()
+			if !ok || username != "admin" || password != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				json.NewEncoder(w).Encode(entities.NewRetFailed(errors.New("wrong credentials"), http.StatusUnauthorized))
+				return
+			}
+			json.NewEncoder(w).Encode(entities.NewRetSuccess(*entities.NewJWT("token")))
+		case "/api/v1/auth-check":
+			if r.Header.Get("Authorization") != "Bearer token" {
+				w.WriteHeader(http.StatusForbidden)
+				json.NewEncoder(w).Encode(entities.NewRetFailed(errors.New("invalid token"), http.StatusForbidden))
+				return
+			}
+			json.NewEncoder(w).Encode(entities.NewRetSuccess("ok"))
+		default:
+			if r.Header.Get("X-Proxy-Key") != "key" {
+				w.WriteHeader(http.StatusForbidden)
+				json.NewEncoder(w).Encode(entities.NewRetFailed(errors.New("missing key"), http.StatusForbidden))
+				return
+			}
+			json.NewEncoder(w).Encode(entities.NewRetSuccess([]entities.Topic{}))
+		}
+	}))
+	defer server.Close()
+	api := New(server.URL+"/api/v1", server.Client(), nil)
+	ctx := context.Background()
+
+	if _, err := api.Login(ctx, "admin", "wrong"); err == nil {
+		t.Fatalf("TestAuth: login with a wrong password should fail")
+	}
+	jwt, err := api.Login(ctx, "admin", "secret")
+	if err != nil || jwt != "token" {
+		t.Fatalf("TestAuth: expected token, got %q %v", jwt, err)
+	}
+
+	// login doesn't change the auth of the client
+	if err := api.AuthCheck(ctx); !errors.Is(err, ErrorForbidden) {
+		t.Fatalf("TestAuth: expected forbidden without a token, got %v", err)
+	}
+	if err := api.WithAuth(JWT(jwt)).AuthCheck(ctx); err != nil {
+		t.Fatalf("TestAuth: token should be accepted, got %v", err)
+	}
+
+	if _, err := api.WithAuth(APIKey{Header: "X-Proxy-Key", Key: "key"}).ListTopics(ctx); err != nil {
+		t.Fatalf("TestAuth: api key should be sent, got %v", err)
+	}
+	custom := AuthFunc(func(req *http.Request) {
+		req.Header.Set("X-Proxy-Key", "key")
+	})
+	if _, err := api.WithAuth(custom).ListTopics(ctx); err != nil {
+		t.Fatalf("TestAuth: auth func should be called, got %v", err)
+	}
+}
+
+func TestRequestOptions(t *testing.T) {
+	tag := entities.Tag{ID: 1, Name: "go", Slug: "go"}
+	etag := `"v1"`
+	requests := []*http.Request{}
+	bodies := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, r)
+		bodies = append(bodies, string(body))
+
+		switch r.Method {
+		case http.MethodGet:
+			if r.Header.Get("If-None-Match") == etag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", etag)
+			json.NewEncoder(w).Encode(entities.NewRetSuccess(tag))
+		case http.MethodPut:
+			if r.Header.Get("If-Match") != etag {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				json.NewEncoder(w).Encode(entities.NewRetFailed(errors.New("etag mismatch"), http.StatusPreconditionFailed))
+				return
+			}
+			json.NewEncoder(w).Encode(entities.NewRetSuccess(tag))
+		default:
+			json.NewEncoder(w).Encode(entities.NewRetSuccess(tag))
+		}
+	}))
+	defer server.Close()
+	api := New(server.URL+"/api/v1", server.Client(), JWT("token"))
+	ctx := context.Background()
+
+	// the etag of a read is used for conditional requests
+	received := ""
+	if _, err := api.GetTag(ctx, 1, ETag(&received)); err != nil || received != etag {
+		t.Fatalf("TestRequestOptions: expected etag %s, got %q %v", etag, received, err)
+	}
+	if _, err := api.GetTag(ctx, 1, IfNoneMatch(received)); !errors.Is(err, ErrorNotModified) {
+		t.Fatalf("TestRequestOptions: expected not modified, got %v", err)
+	}
+	if _, err := api.UpdateTag(ctx, 1, *entities.NewInTag("go", ""), IfMatch(`"v0"`)); !errors.Is(err, ErrorPreconditionFailed) {
+		t.Fatalf("TestRequestOptions: expected precondition failed, got %v", err)
+	}
+	if _, err := api.UpdateTag(ctx, 1, *entities.NewInTag("go", ""), IfMatch(received)); err != nil {
+		t.Fatalf("TestRequestOptions: update with the current etag failed: %v", err)
+	}
+
+	created, err := api.CreateTag(ctx, *entities.NewInTag("go", "the language"), IdempotencyKey("key-1"), Header("X-Extra", "1"))
+	if err != nil || created != tag {
+		t.Fatalf("TestRequestOptions: expected %+v, got %+v %v", tag, created, err)
+	}
+	last := requests[len(requests)-1]
+	if last.URL.Path != "/api/v1/tags" || last.Header.Get("Idempotency-Key") != "key-1" || last.Header.Get("X-Extra") != "1" {
+		t.Fatalf("TestRequestOptions: unexpected create request %s %v", last.URL.Path, last.Header)
+	}
+	if last.Header.Get("Authorization") != "Bearer token" || last.Header.Get("content-type") != "application/json" {
+		t.Fatalf("TestRequestOptions: unexpected create headers %v", last.Header)
+	}
+	if strings.TrimSpace(bodies[len(bodies)-1]) != `{"name":"go","description":"the language"}` {
+		t.Fatalf("TestRequestOptions: unexpected create body %q", bodies[len(bodies)-1])
+	}
+}
+
+func TestQuery(t *testing.T) {
+	queries := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Path+"?"+r.URL.RawQuery)
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/api/v1/blogs/"):
+			json.NewEncoder(w).Encode(entities.NewRetSuccess(entities.OutBlog{}))
+		case r.URL.Path == "/api/v1/audit":
+			json.NewEncoder(w).Encode(entities.NewRetSuccess([]entities.AuditEntry{}))
+		default:
+			json.NewEncoder(w).Encode(entities.NewRetSuccess([]entities.OutBlog{}))
+		}
+	}))
+	defer server.Close()
+	api := New(server.URL+"/api/v1", server.Client(), JWT("token"))
+	ctx := context.Background()
+
+	if _, err := api.ListBlogs(ctx, BlogFilter{}); err != nil {
+		t.Fatalf("TestQuery: list blogs failed: %v", err)
+	}
+	filter := BlogFilter{All: true, Topics: []int{1, 2}, Tags: []int{3}, Statuses: []string{entities.BlogDraft}}
+	if _, err := api.ListBlogs(ctx, filter); err != nil {
+		t.Fatalf("TestQuery: list filtered blogs failed: %v", err)
+	}
+	if _, err := api.GetBlog(ctx, 7, GetBlogQuery{Parsed: true, Preview: "abc"}); err != nil {
+		t.Fatalf("TestQuery: get blog failed: %v", err)
+	}
+	if _, err := api.ListAuditLog(ctx, entities.AuditFilter{TargetType: "blog", TargetID: "7", Limit: 10}); err != nil {
+		t.Fatalf("TestQuery: list audit log failed: %v", err)
+	}
+
+	expected := []string{
+		"/api/v1/blogs?",
+		fmt.Sprintf("/api/v1/blogs?all=true&status=%s&tag=3&topic=1&topic=2", entities.BlogDraft),
+		"/api/v1/blogs/7?parsed=true&preview=abc",
+		"/api/v1/audit?limit=10&target=blog%3A7",
+	}
+	if strings.Join(queries, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("TestQuery: expected %v, got %v", expected, queries)
+	}
+}
+
+func TestProbes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/alive":
+			json.NewEncoder(w).Encode(entities.NewRetSuccess("alive"))
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+			json.NewEncoder(w).Encode(entities.NewRetFailed(errors.New("database unavailable"), http.StatusServiceUnavailable))
+		}
+	}))
+	defer server.Close()
+	api := New(server.URL+"/api/v1", server.Client(), nil)
+
+	// probes aren't under the routing prefix
+	if err := api.Alive(context.Background()); err != nil {
+		t.Fatalf("TestProbes: alive failed: %v", err)
+	}
+	if err := api.Ready(context.Background()); !errors.Is(err, ErrorServer) {
+		t.Fatalf("TestProbes: expected server error, got %v", err)
+	}
+}
+
+func TestUploadMedia(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(entities.NewRetFailed(err, http.StatusBadRequest))
+			return
+		}
+		data, _ := io.ReadAll(file)
+		media := entities.NewMedia("hash", header.Filename, "image/png", int64(len(data)), 0, 0, r.FormValue("alt"))
+		json.NewEncoder(w).Encode(entities.NewRetSuccess(*media))
+	}))
+	defer server.Close()
+	api := New(server.URL+"/api/v1", server.Client(), JWT("token"))
+
+	media, err := api.UploadMedia(context.Background(), "cover.png", strings.NewReader("image"), "a cover")
+	if err != nil {
+		t.Fatalf("TestUploadMedia: upload failed: %v", err)
+	}
+	if media.Filename != "cover.png" || media.Size != 5 || media.Alt != "a cover" {
+		t.Fatalf("TestUploadMedia: unexpected media %+v", media)
+	}
+}
@@ -0,0 +1,36 @@
+package client
+
+import (
+	"blog/entities"
+	"context"
+	"net/http"
+	"strconv"
+)
+
+// Public, replies can only be made to approved comments
+func (c *Client) CreateComment(ctx context.Context, blogID int, comment entities.InComment, options ...RequestOption) (entities.OutComment, error) {
+	return do[entities.OutComment](ctx, c, "CreateComment", http.MethodPost, comment, nil, options, "blogs", strconv.Itoa(blogID), "comments")
+}
+
+// Approved comments of a blog as threads
+func (c *Client) ListComments(ctx context.Context, blogID int, options ...RequestOption) ([]entities.OutComment, error) {
+	return do[[]entities.OutComment](ctx, c, "ListComments", http.MethodGet, nil, nil, options, "blogs", strconv.Itoa(blogID), "comments")
+}
+
+// Moderation queue, status is pending when empty
+func (c *Client) ListCommentsByStatus(ctx context.Context, status string, options ...RequestOption) ([]entities.OutComment, error) {
+	return do[[]entities.OutComment](ctx, c, "ListCommentsByStatus", http.MethodGet, nil, query{}.setString("status", status), options, "comments")
+}
+
+func (c *Client) ApproveComment(ctx context.Context, id int, options ...RequestOption) (entities.Comment, error) {
+	return do[entities.Comment](ctx, c, "ApproveComment", http.MethodPatch, nil, nil, options, "comments", strconv.Itoa(id), "approve")
+}
+
+func (c *Client) RejectComment(ctx context.Context, id int, options ...RequestOption) (entities.Comment, error) {
+	return do[entities.Comment](ctx, c, "RejectComment", http.MethodPatch, nil, nil, options, "comments", strconv.Itoa(id), "reject")
+}
+
+// Replies are deleted as well
+func (c *Client) DeleteComment(ctx context.Context, id int, options ...RequestOption) (entities.RowsAffected, error) {
+	return do[entities.RowsAffected](ctx, c, "DeleteComment", http.MethodDelete, nil, nil, options, "comments", strconv.Itoa(id))
+}
@@ -0,0 +1,74 @@
+package client
+
+import (
+	"blog/entities"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Matched with errors.Is against *ResponseError by status code
+var (
+	ErrorNotModified          = errors.New("not modified")
+	ErrorBadRequest           = errors.New("bad request")
+	ErrorForbidden            = errors.New("forbidden, the token might have expired")
+	ErrorNotFound             = errors.New("not found")
+	ErrorConflict             = errors.New("conflict")
+	ErrorPreconditionFailed   = errors.New("precondition failed, the target changed since it was read")
+	ErrorUnprocessable        = errors.New("unprocessable")
+	ErrorPreconditionRequired = errors.New("precondition required, send If-Match")
+	ErrorTooManyRequests      = errors.New("too many requests")
+	ErrorServer               = errors.New("server error")
+)
+
+var statusErrors = map[int]error{
+	http.StatusNotModified:          ErrorNotModified,
+	http.StatusBadRequest:           ErrorBadRequest,
+	http.StatusForbidden:            ErrorForbidden,
+	http.StatusNotFound:             ErrorNotFound,
+	http.StatusConflict:             ErrorConflict,
+	http.StatusPreconditionFailed:   ErrorPreconditionFailed,
+	http.StatusUnprocessableEntity:  ErrorUnprocessable,
+	http.StatusPreconditionRequired: ErrorPreconditionRequired,
+	http.StatusTooManyRequests:      ErrorTooManyRequests,
+}
+
+/*
+A response with a failed status code, the body is decoded into Failed.
+
+Failed.Status is the sqlite error code on database errors and the same as StatusCode otherwise.
+Bodies that aren't a RetFailed, like rate limited responses, are kept in Failed.Error as they are.
+*/
+type ResponseError struct {
+	StatusCode int
+	RequestID  string
+	Failed     entities.RetFailed
+}
+
+func newResponseError(res *http.Response, body []byte) *ResponseError {
+	result := &ResponseError{
+		StatusCode: res.StatusCode,
+		RequestID:  res.Header.Get("X-Request-ID"),
+	}
+	if err := json.Unmarshal(body, &result.Failed); err != nil || result.Failed.Error == "" {
+		result.Failed = entities.RetFailed{
+			Error:  strings.TrimSpace(string(body)),
+			Status: res.StatusCode,
+		}
+	}
+	return result
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("status code %d, msg: %s", e.StatusCode, e.Failed.Error)
+}
+
+// errors.Is(err, ErrorForbidden) on 403, ErrorServer on 5xx
+func (e *ResponseError) Is(target error) bool {
+	if e.StatusCode >= 500 {
+		return target == ErrorServer
+	}
+	return statusErrors[e.StatusCode] == target
+}
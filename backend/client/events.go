@@ -0,0 +1,93 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// sent first when missed events are no longer buffered, cached content should be dropped
+const EventReset = "reset"
+
+// An event of the stream, same types and data as webhooks
+type Event struct {
+	ID   string // empty for reset
+	Type string // ex: blog.created
+	Data json.RawMessage
+}
+
+/*
+Streams content changes to handle, until ctx is done, the server ends the stream or handle fails.
+
+lastEventID resumes after that event, empty for new events only.
+The stream never ends by itself, so the Doer shouldn't have a timeout.
+*/
+func (c *Client) StreamEvents(ctx context.Context, lastEventID string, handle func(Event) error, options ...RequestOption) (oErr error) {
+	req, err := c.newRequest(ctx, http.MethodGet, nil, nil, "events")
+	if err != nil {
+		return fmt.Errorf("StreamEvents: %w", err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	res, err := c.send(req, options)
+	if err != nil {
+		return fmt.Errorf("StreamEvents: %w", err)
+	}
+	defer func() {
+		oErr = errors.Join(oErr, res.Body.Close())
+	}()
+
+	if err := readEvents(bufio.NewScanner(res.Body), handle); err != nil {
+		return fmt.Errorf("StreamEvents: %w", err)
+	}
+	return nil
+}
+
+// text/event-stream, only the fields the server sends
+func readEvents(scanner *bufio.Scanner, handle func(Event) error) error {
+	scanner.Buffer(make([]byte, 0, 64*1024), int(LimitReaderSize))
+
+	event := Event{}
+	data := []string{}
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// a blank line ends the event
+		if line == "" {
+			if len(data) > 0 {
+				event.Data = json.RawMessage(strings.Join(data, "\n"))
+				if err := handle(event); err != nil {
+					return fmt.Errorf("readEvents: handle event %q failed: %w", event.ID, err)
+				}
+			}
+			event, data = Event{}, []string{}
+			continue
+		}
+		// comments, like heartbeats
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "id":
+			event.ID = value
+		case "event":
+			event.Type = value
+		case "data":
+			data = append(data, value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("readEvents: read stream failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStreamEvents(t *testing.T) {
+	lastEventID := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastEventID = r.Header.Get("Last-Event-ID")
+		w.Header().Set("content-type", "text/event-stream")
+		fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+		fmt.Fprint(w, ": heartbeat\n\n")
+		fmt.Fprint(w, "id: 4\nevent: blog.created\ndata: {\"id\":1}\n\n")
+		fmt.Fprint(w, "id: 5\nevent: tag.deleted\ndata: {\"id\":2}\n\n")
+	}))
+	defer server.Close()
+	api := New(server.URL+"/api/v1", server.Client(), JWT("token"))
+
+	events := []Event{}
+	err := api.StreamEvents(context.Background(), "3", func(e Event) error {
+		events = append(events, e)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("TestStreamEvents: stream failed: %v", err)
+	}
+	if lastEventID != "3" {
+		t.Fatalf("TestStreamEvents: expected Last-Event-ID 3, got %q", lastEventID)
+	}
+
+	// heartbeats aren't events
+	expected := []Event{
+		{Type: EventReset, Data: []byte("{}")},
+		{ID: "4", Type: "blog.created", Data: []byte(`{"id":1}`)},
+		{ID: "5", Type: "tag.deleted", Data: []byte(`{"id":2}`)},
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("TestStreamEvents: expected %d events, got %+v", len(expected), events)
+	}
+	for i, e := range expected {
+		if events[i].ID != e.ID || events[i].Type != e.Type || string(events[i].Data) != string(e.Data) {
+			t.Fatalf("TestStreamEvents: expected event %+v, got %+v", e, events[i])
+		}
+	}
+
+	// a failed handler ends the stream
+	stop := errors.New("stop")
+	count := 0
+	err = api.StreamEvents(context.Background(), "", func(e Event) error {
+		count++
+		return stop
+	})
+	if !errors.Is(err, stop) || count != 1 {
+		t.Fatalf("TestStreamEvents: expected the stream to end with the handler error, got %v after %d events", err, count)
+	}
+}
@@ -0,0 +1,82 @@
+package client
+
+import (
+	"blog/entities"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+)
+
+// A variant of an image, zero values keep the original
+type MediaVariant struct {
+	Width  int    // px, one of the srcset widths of the server, never scales up
+	Format string // jpeg, png, gif, bmp or tiff
+}
+
+// The file is read into memory, so it can be sent again by a Doer that retries
+func (c *Client) UploadMedia(ctx context.Context, filename string, file io.Reader, alt string, options ...RequestOption) (entities.Media, error) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", filename)
+	if err != nil {
+		return entities.Media{}, fmt.Errorf("UploadMedia: create form file failed for %q: %w", filename, err)
+	}
+	if _, err := io.Copy(part, file); err != nil {
+		return entities.Media{}, fmt.Errorf("UploadMedia: read file failed for %q: %w", filename, err)
+	}
+	if alt != "" {
+		if err := writer.WriteField("alt", alt); err != nil {
+			return entities.Media{}, fmt.Errorf("UploadMedia: write alt failed for %q: %w", filename, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return entities.Media{}, fmt.Errorf("UploadMedia: close multipart writer failed for %q: %w", filename, err)
+	}
+
+	apiURL, err := c.endpoint(nil, "media")
+	if err != nil {
+		return entities.Media{}, fmt.Errorf("UploadMedia: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL, body)
+	if err != nil {
+		return entities.Media{}, fmt.Errorf("UploadMedia: create new request failed for %q: %w", filename, err)
+	}
+	req.Header.Set("content-type", writer.FormDataContentType())
+
+	media, err := call[entities.Media](c, req, options)
+	if err != nil {
+		return entities.Media{}, fmt.Errorf("UploadMedia: %q: %w", filename, err)
+	}
+	return media, nil
+}
+
+// With the ids of blogs using each one
+func (c *Client) ListMedia(ctx context.Context, options ...RequestOption) ([]entities.OutMedia, error) {
+	return do[[]entities.OutMedia](ctx, c, "ListMedia", http.MethodGet, nil, nil, options, "media")
+}
+
+// Public, returns the file and its content type, the caller closes it
+func (c *Client) GetMedia(ctx context.Context, hash string, variant MediaVariant, options ...RequestOption) (io.ReadCloser, string, error) {
+	params := query{}.setInt("w", variant.Width).setString("fmt", variant.Format)
+	req, err := c.newRequest(ctx, http.MethodGet, nil, params.values(), "media", hash)
+	if err != nil {
+		return nil, "", fmt.Errorf("GetMedia: %w", err)
+	}
+	res, err := c.send(req, options)
+	if err != nil {
+		return nil, "", fmt.Errorf("GetMedia: %w", err)
+	}
+	return res.Body, res.Header.Get("content-type"), nil
+}
+
+func (c *Client) DeleteMedia(ctx context.Context, hash string, options ...RequestOption) (entities.RowsAffected, error) {
+	return do[entities.RowsAffected](ctx, c, "DeleteMedia", http.MethodDelete, nil, nil, options, "media", hash)
+}
+
+// Deletes media no blog links to, dryRun only lists them
+func (c *Client) DeleteUnusedMedia(ctx context.Context, dryRun bool, options ...RequestOption) ([]entities.Media, error) {
+	return do[[]entities.Media](ctx, c, "DeleteUnusedMedia", http.MethodDelete, nil, query{}.setBool("dryRun", dryRun), options, "media", "unused")
+}
@@ -0,0 +1,39 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// Probes aren't under the routing prefix, only the scheme and host of the base url are kept
+func (c *Client) root() (*Client, error) {
+	parsed, err := url.Parse(c.baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("root: parse base url failed: %w", err)
+	}
+	result := *c
+	result.baseURL = (&url.URL{Scheme: parsed.Scheme, Host: parsed.Host}).String()
+	return &result, nil
+}
+
+// nil while the server is running
+func (c *Client) Alive(ctx context.Context, options ...RequestOption) error {
+	root, err := c.root()
+	if err != nil {
+		return fmt.Errorf("Alive: %w", err)
+	}
+	_, err = do[string](ctx, root, "Alive", http.MethodGet, nil, nil, options, "alive")
+	return err
+}
+
+// nil while the server can take requests
+func (c *Client) Ready(ctx context.Context, options ...RequestOption) error {
+	root, err := c.root()
+	if err != nil {
+		return fmt.Errorf("Ready: %w", err)
+	}
+	_, err = do[string](ctx, root, "Ready", http.MethodGet, nil, nil, options, "ready")
+	return err
+}
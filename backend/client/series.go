@@ -0,0 +1,31 @@
+package client
+
+import (
+	"blog/entities"
+	"context"
+	"net/http"
+	"strconv"
+)
+
+func (c *Client) CreateSeries(ctx context.Context, series entities.InSeries, options ...RequestOption) (entities.Series, error) {
+	return do[entities.Series](ctx, c, "CreateSeries", http.MethodPost, series, nil, options, "series")
+}
+
+// Without their parts
+func (c *Client) ListSeries(ctx context.Context, options ...RequestOption) ([]entities.Series, error) {
+	return do[[]entities.Series](ctx, c, "ListSeries", http.MethodGet, nil, nil, options, "series")
+}
+
+// With visible parts in order, all includes every part and needs auth
+func (c *Client) GetSeries(ctx context.Context, id int, all bool, options ...RequestOption) (entities.OutSeries, error) {
+	return do[entities.OutSeries](ctx, c, "GetSeries", http.MethodGet, nil, query{}.setBool("all", all), options, "series", strconv.Itoa(id))
+}
+
+func (c *Client) UpdateSeries(ctx context.Context, id int, series entities.InSeries, options ...RequestOption) (entities.Series, error) {
+	return do[entities.Series](ctx, c, "UpdateSeries", http.MethodPut, series, nil, options, "series", strconv.Itoa(id))
+}
+
+// Blogs in the series are kept
+func (c *Client) DeleteSeries(ctx context.Context, id int, options ...RequestOption) (entities.RowsAffected, error) {
+	return do[entities.RowsAffected](ctx, c, "DeleteSeries", http.MethodDelete, nil, nil, options, "series", strconv.Itoa(id))
+}
@@ -0,0 +1,31 @@
+package client
+
+import (
+	"blog/entities"
+	"context"
+	"net/http"
+	"strconv"
+)
+
+// Public, returns "recorded" or "ignored" ( do not track, bots )
+func (c *Client) RecordView(ctx context.Context, blogID int, view entities.InView, options ...RequestOption) (string, error) {
+	return do[string](ctx, c, "RecordView", http.MethodPost, view, nil, options, "blogs", strconv.Itoa(blogID), "view")
+}
+
+// Zero days and limit use the defaults of the server
+func (c *Client) ListPopularBlogs(ctx context.Context, days, limit int, options ...RequestOption) ([]entities.PopularBlog, error) {
+	params := query{}.setInt("days", days).setInt("limit", limit)
+	return do[[]entities.PopularBlog](ctx, c, "ListPopularBlogs", http.MethodGet, nil, params, options, "blogs", "popular")
+}
+
+// from and to are YYYY-MM-DD in UTC, both inclusive, empty for the defaults of the server
+func (c *Client) ListBlogStats(ctx context.Context, from, to string, options ...RequestOption) ([]entities.BlogStatsSummary, error) {
+	params := query{}.setString("from", from).setString("to", to)
+	return do[[]entities.BlogStatsSummary](ctx, c, "ListBlogStats", http.MethodGet, nil, params, options, "stats", "blogs")
+}
+
+// Daily views, visitors and referrers, from and to like ListBlogStats
+func (c *Client) GetBlogStats(ctx context.Context, blogID int, from, to string, options ...RequestOption) (entities.BlogStats, error) {
+	params := query{}.setString("from", from).setString("to", to)
+	return do[entities.BlogStats](ctx, c, "GetBlogStats", http.MethodGet, nil, params, options, "stats", "blogs", strconv.Itoa(blogID))
+}
@@ -0,0 +1,34 @@
+package client
+
+import (
+	"blog/entities"
+	"context"
+	"net/http"
+	"strconv"
+)
+
+func (c *Client) CreateTag(ctx context.Context, tag entities.InTag, options ...RequestOption) (entities.Tag, error) {
+	return do[entities.Tag](ctx, c, "CreateTag", http.MethodPost, tag, nil, options, "tags")
+}
+
+// Tags related to blogs under all the topics, every tag without topics
+func (c *Client) ListTags(ctx context.Context, topicIDs []int, options ...RequestOption) ([]entities.Tag, error) {
+	return do[[]entities.Tag](ctx, c, "ListTags", http.MethodGet, nil, query{}.addInts("topic", topicIDs), options, "tags")
+}
+
+func (c *Client) GetTag(ctx context.Context, id int, options ...RequestOption) (entities.Tag, error) {
+	return do[entities.Tag](ctx, c, "GetTag", http.MethodGet, nil, nil, options, "tags", strconv.Itoa(id))
+}
+
+func (c *Client) UpdateTag(ctx context.Context, id int, tag entities.InTag, options ...RequestOption) (entities.Tag, error) {
+	return do[entities.Tag](ctx, c, "UpdateTag", http.MethodPut, tag, nil, options, "tags", strconv.Itoa(id))
+}
+
+// JSON Merge Patch of a tag, fields are the json names of entities.TagPatch like PatchBlog
+func (c *Client) PatchTag(ctx context.Context, id int, patch map[string]any, options ...RequestOption) (entities.Tag, error) {
+	return do[entities.Tag](ctx, c, "PatchTag", http.MethodPatch, patch, nil, options, "tags", strconv.Itoa(id))
+}
+
+func (c *Client) DeleteTag(ctx context.Context, id int, options ...RequestOption) (entities.RowsAffected, error) {
+	return do[entities.RowsAffected](ctx, c, "DeleteTag", http.MethodDelete, nil, nil, options, "tags", strconv.Itoa(id))
+}
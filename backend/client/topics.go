@@ -0,0 +1,33 @@
+package client
+
+import (
+	"blog/entities"
+	"context"
+	"net/http"
+	"strconv"
+)
+
+func (c *Client) CreateTopic(ctx context.Context, topic entities.InTopic, options ...RequestOption) (entities.Topic, error) {
+	return do[entities.Topic](ctx, c, "CreateTopic", http.MethodPost, topic, nil, options, "topics")
+}
+
+func (c *Client) ListTopics(ctx context.Context, options ...RequestOption) ([]entities.Topic, error) {
+	return do[[]entities.Topic](ctx, c, "ListTopics", http.MethodGet, nil, nil, options, "topics")
+}
+
+func (c *Client) GetTopic(ctx context.Context, id int, options ...RequestOption) (entities.Topic, error) {
+	return do[entities.Topic](ctx, c, "GetTopic", http.MethodGet, nil, nil, options, "topics", strconv.Itoa(id))
+}
+
+func (c *Client) UpdateTopic(ctx context.Context, id int, topic entities.InTopic, options ...RequestOption) (entities.Topic, error) {
+	return do[entities.Topic](ctx, c, "UpdateTopic", http.MethodPut, topic, nil, options, "topics", strconv.Itoa(id))
+}
+
+// JSON Merge Patch of a topic, fields are the json names of entities.TopicPatch like PatchBlog
+func (c *Client) PatchTopic(ctx context.Context, id int, patch map[string]any, options ...RequestOption) (entities.Topic, error) {
+	return do[entities.Topic](ctx, c, "PatchTopic", http.MethodPatch, patch, nil, options, "topics", strconv.Itoa(id))
+}
+
+func (c *Client) DeleteTopic(ctx context.Context, id int, options ...RequestOption) (entities.RowsAffected, error) {
+	return do[entities.RowsAffected](ctx, c, "DeleteTopic", http.MethodDelete, nil, nil, options, "topics", strconv.Itoa(id))
+}
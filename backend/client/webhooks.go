@@ -0,0 +1,38 @@
+package client
+
+import (
+	"blog/entities"
+	"context"
+	"net/http"
+	"strconv"
+)
+
+// The secret is generated if left out, and only returned here
+func (c *Client) CreateWebhook(ctx context.Context, webhook entities.InWebhook, options ...RequestOption) (entities.Webhook, error) {
+	return do[entities.Webhook](ctx, c, "CreateWebhook", http.MethodPost, webhook, nil, options, "webhooks")
+}
+
+func (c *Client) ListWebhooks(ctx context.Context, options ...RequestOption) ([]entities.Webhook, error) {
+	return do[[]entities.Webhook](ctx, c, "ListWebhooks", http.MethodGet, nil, nil, options, "webhooks")
+}
+
+func (c *Client) GetWebhook(ctx context.Context, id int, options ...RequestOption) (entities.Webhook, error) {
+	return do[entities.Webhook](ctx, c, "GetWebhook", http.MethodGet, nil, nil, options, "webhooks", strconv.Itoa(id))
+}
+
+func (c *Client) UpdateWebhook(ctx context.Context, id int, webhook entities.InWebhook, options ...RequestOption) (entities.Webhook, error) {
+	return do[entities.Webhook](ctx, c, "UpdateWebhook", http.MethodPut, webhook, nil, options, "webhooks", strconv.Itoa(id))
+}
+
+func (c *Client) DeleteWebhook(ctx context.Context, id int, options ...RequestOption) (entities.RowsAffected, error) {
+	return do[entities.RowsAffected](ctx, c, "DeleteWebhook", http.MethodDelete, nil, nil, options, "webhooks", strconv.Itoa(id))
+}
+
+// Newest first, zero limit uses the default of the server
+func (c *Client) ListWebhookDeliveries(ctx context.Context, webhookID, limit int, options ...RequestOption) ([]entities.WebhookDelivery, error) {
+	return do[[]entities.WebhookDelivery](ctx, c, "ListWebhookDeliveries", http.MethodGet, nil, query{}.setInt("limit", limit), options, "webhooks", strconv.Itoa(webhookID), "deliveries")
+}
+
+func (c *Client) ReplayWebhookDelivery(ctx context.Context, deliveryID int, options ...RequestOption) (entities.WebhookDelivery, error) {
+	return do[entities.WebhookDelivery](ctx, c, "ReplayWebhookDelivery", http.MethodPost, nil, nil, options, "webhooks", "deliveries", strconv.Itoa(deliveryID), "replay")
+}
@@ -1,11 +1,16 @@
 package main
 
 import (
+	"blog/analytics"
 	"blog/api"
 	"blog/api/handlers"
 	"blog/config"
 	"blog/db/models/sqlite"
+	"blog/jobs"
+	"blog/markdown"
+	"blog/pubsub"
 	"blog/repositories"
+	"blog/storage"
 	"blog/swagger_docs"
 	_ "blog/swagger_docs"
 	"blog/util"
@@ -66,62 +71,196 @@ func run() error {
 		return fmt.Errorf("run: model prepare failed: %w", err)
 	}
 
+	// content change events, published by repositories after commit
+	eventsBroker := pubsub.NewBroker(config.Events.BufferSize, config.Events.SubscriberBuffer)
+
 	// models
 	blogsModel := sqlite.NewBlogs()
 	blogTagsModel := sqlite.NewBlogTags()
 	blogTopicsModel := sqlite.NewBlogTopics()
+	blogMediaModel := sqlite.NewBlogMedia()
+	blogSeriesModel := sqlite.NewBlogSeries()
 	tagsModel := sqlite.NewTags()
 	topicsModel := sqlite.NewTopics()
+	seriesModel := sqlite.NewSeries()
+	commentsModel := sqlite.NewComments()
+	statsModel := sqlite.NewStats()
+	previewLinksModel := sqlite.NewPreviewLinks()
+	blogStatusEventsModel := sqlite.NewBlogStatusEvents()
+	webhooksModel := sqlite.NewWebhooks()
+	webhookOutboxModel := sqlite.NewWebhookOutbox()
+	webhookDeliveriesModel := sqlite.NewWebhookDeliveries()
 	usersModel := sqlite.NewUsers()
+	mediaModel := sqlite.NewMedia()
+	auditModel := sqlite.NewAudit()
+	idempotencyModel := sqlite.NewIdempotency()
+	savepointsModel := sqlite.NewSavepoints()
 
 	// repositories
 	blogsRepoModels := repositories.NewBlogsRepoModels(
 		blogsModel,
 		blogTagsModel,
 		blogTopicsModel,
+		blogMediaModel,
+		blogSeriesModel,
 		tagsModel,
 		topicsModel,
+		seriesModel,
+		commentsModel,
+		statsModel,
+		previewLinksModel,
+		blogStatusEventsModel,
+		webhookOutboxModel,
 	)
-	blogsRepo := repositories.NewBlogs(db, config.DB, *blogsRepoModels)
+	blogsRepo := repositories.NewBlogs(db, config.DB, *blogsRepoModels, eventsBroker)
 
 	tagsRepoModels := repositories.NewTagsRepoModels(
 		blogTagsModel,
 		tagsModel,
+		webhookOutboxModel,
 	)
-	tagsRepo := repositories.NewTags(db, config.DB, *tagsRepoModels)
+	tagsRepo := repositories.NewTags(db, config.DB, *tagsRepoModels, eventsBroker)
 
 	topicsRepoModels := repositories.NewTopicsRepoModels(
 		blogTopicsModel,
 		topicsModel,
+		webhookOutboxModel,
 	)
-	topicsRepo := repositories.NewTopics(db, config.DB, *topicsRepoModels)
+	topicsRepo := repositories.NewTopics(db, config.DB, *topicsRepoModels, eventsBroker)
 
 	usersRepoModels := repositories.NewUsersRepoModels(
 		usersModel,
 	)
 	usersRepo := repositories.NewUsers(db, config.DB, *usersRepoModels)
 
+	seriesRepoModels := repositories.NewSeriesRepoModels(
+		blogSeriesModel,
+		seriesModel,
+	)
+	seriesRepo := repositories.NewSeries(db, config.DB, *seriesRepoModels)
+
+	commentsRepoModels := repositories.NewCommentsRepoModels(
+		blogsModel,
+		commentsModel,
+	)
+	commentsRepo := repositories.NewComments(db, config.DB, *commentsRepoModels)
+
+	statsRepoModels := repositories.NewStatsRepoModels(
+		blogsModel,
+		statsModel,
+	)
+	statsRepo := repositories.NewStats(db, config.DB, *statsRepoModels)
+
+	previewLinksRepoModels := repositories.NewPreviewLinksRepoModels(
+		blogsModel,
+		previewLinksModel,
+	)
+	previewLinksRepo := repositories.NewPreviewLinks(db, config.DB, *previewLinksRepoModels)
+
+	webhooksRepoModels := repositories.NewWebhooksRepoModels(
+		webhooksModel,
+		webhookOutboxModel,
+		webhookDeliveriesModel,
+	)
+	webhooksRepo := repositories.NewWebhooks(db, config.DB, *webhooksRepoModels)
+
+	mediaRepoModels := repositories.NewMediaRepoModels(
+		mediaModel,
+		blogMediaModel,
+	)
+	mediaRepo := repositories.NewMedia(db, config.DB, *mediaRepoModels)
+
+	auditRepoModels := repositories.NewAuditRepoModels(
+		auditModel,
+	)
+	auditRepo := repositories.NewAudit(db, config.DB, *auditRepoModels)
+
+	idempotencyRepoModels := repositories.NewIdempotencyRepoModels(
+		idempotencyModel,
+	)
+	idempotencyRepo := repositories.NewIdempotency(db, config.DB, *idempotencyRepoModels)
+
+	bulkRepoModels := repositories.NewBulkRepoModels(
+		blogsModel,
+		blogTagsModel,
+		blogTopicsModel,
+		blogMediaModel,
+		blogSeriesModel,
+		blogStatusEventsModel,
+		tagsModel,
+		topicsModel,
+		seriesModel,
+		webhookOutboxModel,
+		savepointsModel,
+	)
+	bulkRepo := repositories.NewBulk(db, config.DB, *bulkRepoModels, eventsBroker)
+
+	// media storage
+	mediaStorage := storage.NewLocal(config.Media.Path)
+	if err := mediaStorage.Prepare(); err != nil {
+		return fmt.Errorf("run: media storage prepare failed: %w", err)
+	}
+	mediaVariants := storage.NewVariants(config.Media.VariantPath, int64(config.Media.VariantCacheSize)<<20)
+	if err := mediaVariants.Prepare(); err != nil {
+		return fmt.Errorf("run: media variants prepare failed: %w", err)
+	}
+
 	// helpers
 	jwtHelper := handlers.NewJWTHelper(config.JWT)
 	authHelper := handlers.NewAuthHelper(usersRepo, jwtHelper)
+	previewHelper := handlers.NewPreviewHelper(previewLinksRepo, config.Preview)
 
 	// handlers
-	blogsHandler := handlers.NewBlogs(blogsRepo, authHelper)
+	blogsHandler := handlers.NewBlogs(blogsRepo, authHelper, markdown.New(config.Media.SrcsetWidths), previewHelper)
 	tagsHandler := handlers.NewTags(tagsRepo, authHelper)
 	topicsHandler := handlers.NewTopics(topicsRepo, authHelper)
 	usersHandler := handlers.NewUsers(usersRepo, jwtHelper, authHelper)
+	seriesHandler := handlers.NewSeries(seriesRepo, authHelper)
+	commentsHandler := handlers.NewComments(commentsRepo, authHelper, markdown.NewSafe(), config.Comments)
+	statsHandler := handlers.NewStats(statsRepo, authHelper, analytics.NewVisitorHasher(), config.Analytics, config.Server)
+	previewLinksHandler := handlers.NewPreviewLinks(previewLinksRepo, authHelper, previewHelper, config.Preview)
+	webhooksHandler := handlers.NewWebhooks(webhooksRepo, authHelper)
+	eventsHandler := handlers.NewEvents(eventsBroker, config.Events)
+	mediaHandler := handlers.NewMedia(mediaRepo, mediaStorage, mediaVariants, authHelper, config.Media)
 	probesHandler := handlers.NewProbes()
+	auditHandler := handlers.NewAudit(auditRepo, jwtHelper, authHelper, config.Server)
+	idempotencyHandler := handlers.NewIdempotency(idempotencyRepo, authHelper, config.Idempotency)
+	bulkHandler := handlers.NewBulk(bulkRepo, authHelper, config.Bulk)
 
 	// setup server
 	server := api.NewServer(
 		*config,
 		*blogsHandler,
+		*previewLinksHandler,
 		*tagsHandler,
 		*topicsHandler,
 		*usersHandler,
+		*seriesHandler,
+		*commentsHandler,
+		*statsHandler,
+		*webhooksHandler,
+		*eventsHandler,
+		*mediaHandler,
 		*probesHandler,
+		*auditHandler,
+		*idempotencyHandler,
+		*bulkHandler,
 	)
 
+	// background jobs
+	jobsCtx, jobsCancel := context.WithCancel(ctx)
+	defer jobsCancel()
+	webhookDispatcher := jobs.NewWebhookDispatcher(webhooksRepo, config.Webhooks)
+	go webhookDispatcher.Run(jobsCtx)
+	blogScheduler := jobs.NewBlogScheduler(blogsRepo, config.Scheduler)
+	go blogScheduler.Run(jobsCtx)
+	trashPurger := jobs.NewTrashPurger(blogsRepo, config.Trash)
+	go trashPurger.Run(jobsCtx)
+	auditPurger := jobs.NewAuditPurger(auditRepo, config.Audit)
+	go auditPurger.Run(jobsCtx)
+	idempotencyPurger := jobs.NewIdempotencyPurger(idempotencyRepo, config.Idempotency)
+	go idempotencyPurger.Run(jobsCtx)
+
 	// start server
 	go func() {
 		if err := server.Start(); !errors.Is(err, http.ErrServerClosed) {
@@ -134,6 +273,7 @@ func run() error {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 	<-sigChan
+	jobsCancel()
 
 	shutdownTimeout, shutdownCancel := context.WithTimeout(
 		ctx,
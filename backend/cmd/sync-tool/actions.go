@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"os"
 	"path"
 	"path/filepath"
 	"slices"
@@ -12,20 +13,26 @@ import (
 
 func syncAll(
 	ctx context.Context,
-	username,
-	password,
+	credentials Credentials,
 	baseURL,
 	sourcePath string,
-	batchSize int) error {
+	batchSize int,
+	options SyncOptions) error {
 	slog.Info("syncAll")
 
+	switch options.Prefer {
+	case "", PreferLocal, PreferRemote:
+	default:
+		return fmt.Errorf("syncAll: %w, got %q", ErrorInvalidPrefer, options.Prefer)
+	}
+
 	loginDone := make(chan bool, 1)
 	processDone := make(chan bool, 1)
 	processErr := make(chan error, 1)
 
 	go func() {
 		// login
-		jwt, err := login(ctx, loginDone, baseURL, username, password)
+		jwt, err := login(ctx, loginDone, baseURL, credentials)
 		fmt.Printf("\n")
 		if err != nil {
 			processErr <- fmt.Errorf("syncAll: login failed: %w", err)
@@ -33,7 +40,7 @@ func syncAll(
 		}
 		slog.Debug("got jwt", "token", jwt)
 
-		syncHelper := NewSyncHelper(baseURL, jwt, batchSize, sourcePath)
+		syncHelper := NewSyncHelper(ctx, baseURL, jwt, batchSize, sourcePath)
 
 		// get data from server
 		tags, err := syncHelper.GetAllTags()
@@ -48,12 +55,24 @@ func syncAll(
 			return
 		}
 
+		series, err := syncHelper.GetAllSeries()
+		if err != nil {
+			processErr <- fmt.Errorf("syncAll: failed to get series from server: %w", err)
+			return
+		}
+
 		blogs, err := syncHelper.GetAllBlogs()
 		if err != nil {
 			processErr <- fmt.Errorf("syncAll: failed to get blogs from server: %w", err)
 			return
 		}
 
+		media, err := syncHelper.GetAllMedia()
+		if err != nil {
+			processErr <- fmt.Errorf("syncAll: failed to get media from server: %w", err)
+			return
+		}
+
 		// load meta file
 		metafile, err := loadMetaFile(filepath.Join(sourcePath, "meta.yaml"))
 		if err != nil {
@@ -73,6 +92,11 @@ func syncAll(
 			processErr <- fmt.Errorf("syncAll: load blogs failed: %w", err)
 			return
 		}
+		localblogs, err = syncHelper.LinkImages(localblogs)
+		if err != nil {
+			processErr <- fmt.Errorf("syncAll: link images failed: %w", err)
+			return
+		}
 
 		// seperate into groups (CRUD + noop)
 		groupedTags, err := groupTags(metafile.Tags, tags)
@@ -87,14 +111,94 @@ func syncAll(
 			return
 		}
 
+		groupedSeries, err := groupSeries(metafile.Series, series)
+		if err != nil {
+			processErr <- fmt.Errorf("syncAll: group series failed: %w", err)
+			return
+		}
+
 		groupedBlogs, err := groupBlogs(localblogs, blogs)
 		if err != nil {
 			processErr <- fmt.Errorf("syncAll: group blogs failed: %w", err)
 			return
 		}
 
+		// three way check against the last sync, conflicting blogs are resolved separately
+		blogDir := filepath.Join(sourcePath, "blogs")
+		stateFile := filepath.Join(sourcePath, "sync-state.json")
+		state, err := loadSyncState(stateFile)
+		if err != nil {
+			processErr <- fmt.Errorf("syncAll: load sync state failed: %w", err)
+			return
+		}
+		journal, err := loadJournal(filepath.Join(sourcePath, "sync-journal.json"))
+		if err != nil {
+			processErr <- fmt.Errorf("syncAll: load journal failed: %w", err)
+			return
+		}
+		journal.MergeState(state)
+		blogsToUpdate, conflicts, err := findConflicts(groupedBlogs.update, blogs, state, blogDir)
+		if err != nil {
+			processErr <- fmt.Errorf("syncAll: find conflicts failed: %w", err)
+			return
+		}
+		groupedBlogs.update = blogsToUpdate
+
+		// show what will change, deletes are confirmed before anything is changed
+		hasDeletes := len(groupedTopics.delete)+len(groupedTags.delete)+len(groupedSeries.delete)+len(groupedBlogs.delete) > 0
+		if options.Plan || options.JSON || (hasDeletes && !options.Yes) {
+			blogEntries, err := planBlogs(groupedBlogs, blogs, syncHelper.ContentDiff)
+			if err != nil {
+				processErr <- fmt.Errorf("syncAll: plan blogs failed: %w", err)
+				return
+			}
+			plan := NewPlan(
+				planTopics(groupedTopics, topics),
+				planTags(groupedTags, tags),
+				planSeries(groupedSeries, series),
+				planMedia(imagesOf(groupedBlogs.create, groupedBlogs.update), media, blogDir),
+				blogEntries,
+				conflictEntries(conflicts),
+			)
+
+			if options.JSON {
+				err = plan.WriteJSON(os.Stdout)
+			} else {
+				err = plan.WriteText(os.Stdout)
+			}
+			if err != nil {
+				processErr <- fmt.Errorf("syncAll: print plan failed: %w", err)
+				return
+			}
+
+			if options.Plan {
+				processDone <- true
+				return
+			}
+			if hasDeletes && !options.Yes {
+				if err := confirmDeletes(plan); err != nil {
+					processErr <- fmt.Errorf("syncAll: %w", err)
+					return
+				}
+			}
+		}
+
+		// from here on an interrupted sync can be resumed
+		if err := journal.Save(); err != nil {
+			processErr <- fmt.Errorf("syncAll: save journal failed: %w", err)
+			return
+		}
+		syncHelper.journal = journal
+
+		resolvedBlogs, unresolved, err := syncHelper.ResolveConflicts(conflicts, options.Prefer, state)
+		if err != nil {
+			processErr <- fmt.Errorf("syncAll: resolve conflicts failed: %w", err)
+			return
+		}
+		groupedBlogs.update = append(groupedBlogs.update, resolvedBlogs...)
+
 		// sync
-		// create tags and topics, also fills in their ids for later use
+		// create tags, topics and series, also fills in their ids for later use
 		newTopics, err := syncHelper.CreateTopics(groupedTopics.create)
 		if err != nil {
 			processErr <- fmt.Errorf("syncAll: create topics failed: %w", err)
@@ -107,7 +211,13 @@ func syncAll(
 			return
 		}
 
-		// update tags and topics
+		newSeries, err := syncHelper.CreateSeries(groupedSeries.create)
+		if err != nil {
+			processErr <- fmt.Errorf("syncAll: create series failed: %w", err)
+			return
+		}
+
+		// update tags, topics and series
 		updatedTopics, err := syncHelper.UpdateTopics(groupedTopics.update)
 		if err != nil {
 			processErr <- fmt.Errorf("syncAll: update topics failed: %w", err)
@@ -118,18 +228,30 @@ func syncAll(
 			processErr <- fmt.Errorf("syncAll: update tags failed: %w", err)
 			return
 		}
+		updatedSeries, err := syncHelper.UpdateSeries(groupedSeries.update)
+		if err != nil {
+			processErr <- fmt.Errorf("syncAll: update series failed: %w", err)
+			return
+		}
 
 		// blogs
 		// prepare blogs for CRUD operations
 		existingTopics := slices.Concat[[]entities.Topic](newTopics, updatedTopics, groupedTopics.noop)
 		existingTags := slices.Concat[[]entities.Tag](newTags, updatedTags, groupedTags.noop)
-		blogMaper := NewBlogMaper(existingTags, existingTopics, sourcePath)
+		existingSeries := slices.Concat[[]entities.Series](newSeries, updatedSeries, groupedSeries.noop)
+		blogMaper := NewBlogMaper(existingTags, existingTopics, existingSeries, sourcePath)
 		updatedBlogs, err := blogMaper.MapIDs(groupedBlogs)
 		if err != nil {
 			processErr <- fmt.Errorf("syncAll: transform blogs failed: %w", err)
 			return
 		}
 
+		// images are uploaded before the blogs linking them
+		if err := syncHelper.UploadImages(imagesOf(updatedBlogs.create, updatedBlogs.update), media); err != nil {
+			processErr <- fmt.Errorf("syncAll: upload images failed: %w", err)
+			return
+		}
+
 		if err := syncHelper.DeleteBlogs(updatedBlogs.delete); err != nil {
 			processErr <- fmt.Errorf("syncAll: delete blogs failed: %w", err)
 			return
@@ -144,7 +266,7 @@ func syncAll(
 			return
 		}
 
-		// delete tags and topics
+		// delete tags, topics and series
 		if err := syncHelper.DeleteTopics(groupedTopics.delete); err != nil {
 			processErr <- fmt.Errorf("syncAll: delete topics failed: %w", err)
 			return
@@ -153,17 +275,51 @@ func syncAll(
 			processErr <- fmt.Errorf("syncAll: delete tags failed: %w", err)
 			return
 		}
+		if err := syncHelper.DeleteSeries(groupedSeries.delete); err != nil {
+			processErr <- fmt.Errorf("syncAll: delete series failed: %w", err)
+			return
+		}
 
 		// update blog id mapping
+		conflictBlogs := make([]BlogInfo, 0, len(conflicts))
+		for _, conflict := range conflicts {
+			conflictBlogs = append(conflictBlogs, conflict.Local)
+		}
 		existingBlogs := slices.Concat[[]BlogInfo](
 			groupedBlogs.update,
 			groupedBlogs.noop,
+			conflictBlogs,
 		)
 		if err := updateIDMapping(existingBlogs, newIDMapping, path.Join(sourcePath, "ids.json")); err != nil {
 			processErr <- fmt.Errorf("syncAll: updated id mapping failed: %w", err)
 			return
 		}
 
+		// record the state after this sync, newIDMapping now holds every blog
+		remoteBlogs, err := syncHelper.GetAllBlogs()
+		if err != nil {
+			processErr <- fmt.Errorf("syncAll: failed to get blogs from server: %w", err)
+			return
+		}
+		state, err = newSyncState(blogDir, newIDMapping, remoteBlogs, unresolved)
+		if err != nil {
+			processErr <- fmt.Errorf("syncAll: record sync state failed: %w", err)
+			return
+		}
+		if err := state.save(stateFile); err != nil {
+			processErr <- fmt.Errorf("syncAll: save sync state failed: %w", err)
+			return
+		}
+		if err := journal.Remove(); err != nil {
+			processErr <- fmt.Errorf("syncAll: %w", err)
+			return
+		}
+
+		if len(unresolved) > 0 {
+			processErr <- fmt.Errorf("syncAll: %w: merge the server versions in %s files into %d blogs and remove them", ErrorConflicts, remoteSuffix, len(unresolved))
+			return
+		}
+
 		processDone <- true
 	}()
 
@@ -1,24 +1,33 @@
 package main
 
 import (
-	"blog/entities"
+	"blog/client"
 	"bufio"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
+	"io/fs"
 	"log/slog"
-	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/term"
 )
 
+var ErrorNoCredentials = errors.New("username and password are required without a terminal prompt")
+
 type Credentials struct {
 	Username string
 	Password string
+	// fail instead of asking for missing username or password
+	NoPrompt bool
+	// the jwt is cached in this file and reused until it expires, empty to always login.
+	// It is only reused for the same url and username
+	TokenFile string
 }
 
 func NewCredentials(username, password string) Credentials {
@@ -28,57 +37,47 @@ func NewCredentials(username, password string) Credentials {
 	}
 }
 
-func getJWT(baseURL, username, password string) (oStr string, oErr error) {
+func getJWT(ctx context.Context, baseURL, username, password string) (string, error) {
 	slog.Debug("getJWT")
 
-	// setup api url
-	// build request
-	req, err := http.NewRequest(http.MethodPost, baseURL+"/login", nil)
+	jwt, err := client.New(baseURL, httpClient, nil).Login(ctx, username, password)
 	if err != nil {
-		return "", fmt.Errorf("getJWT: create new request failed: %w", err)
+		return "", fmt.Errorf("getJWT: %w", err)
 	}
-	req.SetBasicAuth(username, password)
-	req.Header.Set("Content-type", "application/json")
+	return jwt, nil
+}
 
-	// send request
-	res, err := httpClient.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("getJWT: request failed: %w", err)
-	}
+/*
+reads username and password and get jwt token
+
+A cached token is used instead while the server accepts it,
+a new token is cached for the next run.
+*/
+func login(ctx context.Context, done chan<- bool, baseURL string, cred Credentials) (oStr string, oErr error) {
+	slog.Info("login")
 
-	// cleanup
 	defer func() {
-		oErr = errors.Join(oErr, drainAndClose(res.Body))
+		done <- true
 	}()
 
-	// read request
-	resBody, err := io.ReadAll(res.Body)
-	if err != nil {
-		return "", fmt.Errorf("getJWT: read body failed: %w", err)
-	}
-
-	// fail
-	if res.StatusCode >= 400 {
-		return "", fmt.Errorf("getJWT: status code %d, msg: %s", res.StatusCode, string(resBody))
+	if cred.TokenFile != "" {
+		jwt, err := cachedJWT(ctx, baseURL, cred.Username, cred.TokenFile)
+		if err != nil {
+			return "", fmt.Errorf("login: %w", err)
+		}
+		if jwt != "" {
+			slog.Info("using cached token", "filename", cred.TokenFile)
+			return jwt, nil
+		}
 	}
 
-	// success
-	msg := entities.RetSuccess[entities.JWT]{}
-	if err := json.Unmarshal(resBody, &msg); err != nil {
-		return "", fmt.Errorf("getJWT: decode success response failed: %w", err)
+	username, password := cred.Username, cred.Password
+	prompt := username == "" || password == ""
+	if prompt && cred.NoPrompt {
+		return "", fmt.Errorf("login: %w", ErrorNoCredentials)
 	}
-	return msg.Msg.JWT, nil
-}
-
-// reads username and password and get jwt token
-func login(ctx context.Context, done chan<- bool, baseURL, username, password string) (oStr string, oErr error) {
-	slog.Info("login")
 
-	defer func() {
-		done <- true
-	}()
-
-	if username == "" && password == "" {
+	if prompt {
 		// get current terminal state
 		currFd := int(os.Stdin.Fd())
 		currState, err := term.GetState(currFd)
@@ -96,21 +95,28 @@ func login(ctx context.Context, done chan<- bool, baseURL, username, password st
 	result := make(chan string, 1)
 
 	go func() {
-		if username == "" && password == "" {
-			cred, err := stdinCredentials()
+		if prompt {
+			cred, err := stdinCredentials(username)
 			if err != nil {
 				processErr <- fmt.Errorf("stdinCredentials error: %w", err)
+				return
 			}
 			username = cred.Username
 			password = cred.Password
 		}
 
 		// get jwt
-		jwt, err := getJWT(baseURL, username, password)
+		jwt, err := getJWT(ctx, baseURL, username, password)
 		if err != nil {
 			processErr <- fmt.Errorf("Get jwt token error: %w", err)
 			return
 		}
+		if cred.TokenFile != "" {
+			// the token still works for this run
+			if err := saveJWT(cred.TokenFile, baseURL, username, jwt); err != nil {
+				slog.Warn("cache token failed", "error", err)
+			}
+		}
 		result <- jwt
 	}()
 
@@ -125,15 +131,18 @@ func login(ctx context.Context, done chan<- bool, baseURL, username, password st
 	}
 }
 
-func stdinCredentials() (Credentials, error) {
+// username is only asked when it is empty
+func stdinCredentials(username string) (Credentials, error) {
 	// read username
-	reader := bufio.NewReader(os.Stdin)
-	fmt.Print("Username: ")
-	username, err := reader.ReadString('\n')
-	if err != nil {
-		return Credentials{}, fmt.Errorf("Read username error: %w", err)
+	if username == "" {
+		reader := bufio.NewReader(os.Stdin)
+		fmt.Print("Username: ")
+		input, err := reader.ReadString('\n')
+		if err != nil {
+			return Credentials{}, fmt.Errorf("Read username error: %w", err)
+		}
+		username = strings.TrimSuffix(input, "\n")
 	}
-	username = strings.TrimSuffix(username, "\n")
 
 	// read password
 	fmt.Print("Password: ")
@@ -145,3 +154,105 @@ func stdinCredentials() (Credentials, error) {
 
 	return NewCredentials(username, password), nil
 }
+
+// tokens expiring sooner than this aren't reused, so they don't expire in the middle of a sync
+const tokenExpiryMargin = 5 * time.Minute
+
+// cached token of a profile, under the user cache directory
+func tokenFile(profile string) (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("tokenFile: get cache dir failed: %w", err)
+	}
+	return filepath.Join(cacheDir, configDirName, "tokens", profile+".json"), nil
+}
+
+// content of a token file, the token is only sent to the server it was issued by
+type cachedToken struct {
+	URL      string `json:"url"`
+	Username string `json:"username"`
+	Token    string `json:"token"`
+}
+
+/*
+returns the cached token if it hasn't expired and the server accepts it, empty otherwise
+
+Tokens of another url or username are ignored, an empty username never matches.
+*/
+func cachedJWT(ctx context.Context, baseURL, username, tokenFile string) (string, error) {
+	data, err := os.ReadFile(tokenFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("cachedJWT: read token file failed: %w", err)
+	}
+
+	cached := cachedToken{}
+	if err := json.Unmarshal(data, &cached); err != nil {
+		slog.Info("cached token unreadable", "filename", tokenFile, "error", err)
+		return "", nil
+	}
+	if cached.URL != baseURL || cached.Username != username {
+		slog.Info("cached token is for another url or username", "filename", tokenFile)
+		return "", nil
+	}
+
+	token := cached.Token
+	if jwtExpired(token, time.Now().Add(tokenExpiryMargin)) {
+		slog.Info("cached token expired", "filename", tokenFile)
+		return "", nil
+	}
+
+	valid, err := checkJWT(ctx, baseURL, token)
+	if err != nil {
+		return "", fmt.Errorf("cachedJWT: %w", err)
+	}
+	if !valid {
+		slog.Info("cached token rejected by the server", "filename", tokenFile)
+		return "", nil
+	}
+	return token, nil
+}
+
+// the token can only be read by the user
+func saveJWT(tokenFile, baseURL, username, token string) error {
+	if err := os.MkdirAll(filepath.Dir(tokenFile), 0700); err != nil {
+		return fmt.Errorf("saveJWT: create dir failed: %w", err)
+	}
+	data, err := json.Marshal(cachedToken{URL: baseURL, Username: username, Token: token})
+	if err != nil {
+		return fmt.Errorf("saveJWT: marshal failed: %w", err)
+	}
+	if err := writeFileAtomic(tokenFile, data, 0600); err != nil {
+		return fmt.Errorf("saveJWT: write token file failed: %w", err)
+	}
+	return nil
+}
+
+// the signature isn't checked, that is up to the server
+func jwtExpired(token string, at time.Time) bool {
+	claims := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(token, claims); err != nil {
+		return true
+	}
+	expiresAt, err := claims.GetExpirationTime()
+	if err != nil || expiresAt == nil {
+		return true
+	}
+	return !at.Before(expiresAt.Time)
+}
+
+// asks the server if the token is still valid, a logout or a newer login invalidates it
+func checkJWT(ctx context.Context, baseURL, token string) (bool, error) {
+	slog.Debug("checkJWT")
+
+	err := client.New(baseURL, httpClient, client.JWT(token)).AuthCheck(ctx)
+	if errors.Is(err, client.ErrorForbidden) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("checkJWT: %w", err)
+	}
+	return true, nil
+}
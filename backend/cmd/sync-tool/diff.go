@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	diffContext = 3 // unchanged lines around each change
+	// above this many line pairs the whole content is shown as removed and added,
+	// keeps the lcs table of huge blogs out of memory
+	diffMaxCells = 4_000_000
+)
+
+type diffLine struct {
+	kind byte // ' ', '-' or '+'
+	text string
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// line level edit script from a to b, based on the longest common subsequence
+func diffLines(a, b []string) []diffLine {
+	result := make([]diffLine, 0, len(a)+len(b))
+
+	if len(a)*len(b) > diffMaxCells {
+		for _, line := range a {
+			result = append(result, diffLine{'-', line})
+		}
+		for _, line := range b {
+			result = append(result, diffLine{'+', line})
+		}
+		return result
+	}
+
+	// lcs[i][j] is the lcs length of a[i:] and b[j:]
+	lcs := make([][]int32, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			result = append(result, diffLine{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			result = append(result, diffLine{'-', a[i]})
+			i++
+		default:
+			result = append(result, diffLine{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		result = append(result, diffLine{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		result = append(result, diffLine{'+', b[j]})
+	}
+	return result
+}
+
+// unified diff of two texts, empty if they are the same
+func unifiedDiff(from, to, fromName, toName string) string {
+	lines := diffLines(splitLines(from), splitLines(to))
+
+	// line numbers in a and b before each line of the script
+	aLines := make([]int, len(lines)+1)
+	bLines := make([]int, len(lines)+1)
+	changes := []int{}
+	for i, line := range lines {
+		aLines[i+1], bLines[i+1] = aLines[i], bLines[i]
+		if line.kind != '+' {
+			aLines[i+1]++
+		}
+		if line.kind != '-' {
+			bLines[i+1]++
+		}
+		if line.kind != ' ' {
+			changes = append(changes, i)
+		}
+	}
+	if len(changes) == 0 {
+		return ""
+	}
+
+	builder := &strings.Builder{}
+	fmt.Fprintf(builder, "--- %s\n+++ %s\n", fromName, toName)
+
+	for k := 0; k < len(changes); {
+		// changes closer than two contexts share a hunk
+		end := k
+		for end+1 < len(changes) && changes[end+1]-changes[end] <= 2*diffContext {
+			end++
+		}
+		start := max(changes[k]-diffContext, 0)
+		stop := min(changes[end]+diffContext+1, len(lines))
+
+		aStart, aCount := aLines[start]+1, aLines[stop]-aLines[start]
+		bStart, bCount := bLines[start]+1, bLines[stop]-bLines[start]
+		// an empty range points at the line before it
+		if aCount == 0 {
+			aStart--
+		}
+		if bCount == 0 {
+			bStart--
+		}
+		fmt.Fprintf(builder, "@@ -%d,%d +%d,%d @@\n", aStart, aCount, bStart, bCount)
+		for _, line := range lines[start:stop] {
+			builder.WriteByte(line.kind)
+			builder.WriteString(line.text)
+			builder.WriteByte('\n')
+		}
+
+		k = end + 1
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Lines around the frontmatter
+const (
+	yamlDelimiter = "---"
+	tomlDelimiter = "+++"
+)
+
+var (
+	ErrorNoFrontmatter       = errors.New("no frontmatter, the file should start with a '---' or '+++' line")
+	ErrorUnclosedFrontmatter = errors.New("frontmatter isn't closed")
+)
+
+/*
+Splits a blog file into its frontmatter and content.
+
+The frontmatter is YAML between '---' lines or TOML between '+++' lines at the start of the file.
+Only whole lines close it, so rules and separators in the content are kept.
+The content is everything after the closing line, starting with its line break.
+*/
+func parseFrontmatter(data []byte) (BlogFrontmatter, string, error) {
+	delimiter, header, content, err := splitFrontmatter(data)
+	if err != nil {
+		return BlogFrontmatter{}, "", err
+	}
+
+	frontmatter := BlogFrontmatter{}
+	if delimiter == tomlDelimiter {
+		if _, err := toml.Decode(header, &frontmatter); err != nil {
+			return BlogFrontmatter{}, "", fmt.Errorf("parse toml frontmatter failed: %w", err)
+		}
+	} else {
+		if err := yaml.Unmarshal([]byte(header), &frontmatter); err != nil {
+			return BlogFrontmatter{}, "", fmt.Errorf("parse yaml frontmatter failed: %w", err)
+		}
+	}
+
+	return frontmatter, content, nil
+}
+
+// returns the delimiter, the raw frontmatter starting on the second line and the content
+func splitFrontmatter(data []byte) (string, string, string, error) {
+	text := strings.TrimPrefix(string(data), "\ufeff")
+
+	firstLine, rest, _ := strings.Cut(text, "\n")
+	delimiter := strings.TrimRight(firstLine, " \t\r")
+	if delimiter != yamlDelimiter && delimiter != tomlDelimiter {
+		return "", "", "", ErrorNoFrontmatter
+	}
+
+	for offset := 0; offset <= len(rest); {
+		line, _, hasNext := strings.Cut(rest[offset:], "\n")
+		if strings.TrimRight(line, " \t\r") == delimiter {
+			return delimiter, rest[:offset], rest[offset+len(line):], nil
+		}
+		if !hasNext {
+			break
+		}
+		offset += len(line) + 1
+	}
+	return "", "", "", fmt.Errorf("%w, no closing '%s' line", ErrorUnclosedFrontmatter, delimiter)
+}
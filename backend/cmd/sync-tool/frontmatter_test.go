@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseFrontmatter(t *testing.T) {
+	// rules and yaml separators in the content are kept
+	yamlBlog := "---\ntitle: yaml\ntags:\n- go\n---\n\nintro\n\n---\n\nmore --- text\n"
+	frontmatter, content, err := parseFrontmatter([]byte(yamlBlog))
+	if err != nil {
+		t.Fatalf("TestParseFrontmatter: parse yaml failed: %s", err)
+	}
+	if frontmatter.Title != "yaml" || !slices.Equal(frontmatter.Tags, []string{"go"}) {
+		t.Fatalf("TestParseFrontmatter: unexpected yaml frontmatter %+v", frontmatter)
+	}
+	if content != "\n\nintro\n\n---\n\nmore --- text\n" {
+		t.Fatalf("TestParseFrontmatter: unexpected yaml content %q", content)
+	}
+
+	tomlBlog := "+++\r\ntitle = \"toml\"\r\nvisible = true\r\ntopics = [\"backend\"]\r\npart = 2\r\n+++\r\ncontent\r\n"
+	frontmatter, content, err = parseFrontmatter([]byte(tomlBlog))
+	if err != nil {
+		t.Fatalf("TestParseFrontmatter: parse toml failed: %s", err)
+	}
+	if frontmatter.Title != "toml" || !frontmatter.Visible || frontmatter.Part != 2 || !slices.Equal(frontmatter.Topics, []string{"backend"}) {
+		t.Fatalf("TestParseFrontmatter: unexpected toml frontmatter %+v", frontmatter)
+	}
+	if content != "\ncontent\r\n" {
+		t.Fatalf("TestParseFrontmatter: unexpected toml content %q", content)
+	}
+
+	if _, _, err := parseFrontmatter([]byte("# no frontmatter\n")); !errors.Is(err, ErrorNoFrontmatter) {
+		t.Fatalf("TestParseFrontmatter: expected no frontmatter error, got %v", err)
+	}
+	if _, _, err := parseFrontmatter([]byte("")); !errors.Is(err, ErrorNoFrontmatter) {
+		t.Fatalf("TestParseFrontmatter: expected no frontmatter error for an empty file, got %v", err)
+	}
+	if _, _, err := parseFrontmatter([]byte("---\ntitle: open\n+++\n")); !errors.Is(err, ErrorUnclosedFrontmatter) {
+		t.Fatalf("TestParseFrontmatter: expected unclosed frontmatter error, got %v", err)
+	}
+	if _, _, err := parseFrontmatter([]byte("---\ntitle: [\n---\n")); err == nil {
+		t.Fatalf("TestParseFrontmatter: broken yaml should fail")
+	}
+}
+
+func TestLoadBlogsTree(t *testing.T) {
+	blogDir := t.TempDir()
+	files := map[string]string{
+		"flat.md":              "---\ntitle: flat\n---\nflat\n",
+		"go/nested.md":         "+++\ntitle = \"nested\"\n+++\nnested\n",
+		"go/post/index.md":     "---\ntitle: bundle\n---\n![cover](cover.png)\n",
+		"go/post/cover.png":    "png",
+		"go/post/notes.md":     "an asset of the bundle",
+		"broken.md":            "no frontmatter",
+		"go/unclosed.md":       "---\ntitle: unclosed\n",
+		".drafts/hidden.md":    "---\ntitle: hidden\n---\n",
+		"flat.remote.md":       "---\ntitle: flat\n---\nremote\n",
+		"go/readme.txt":        "not markdown",
+		"deep/er/than/deep.md": "---\ntitle: deep\n---\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(blogDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("TestLoadBlogsTree: create dir failed: %s", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("TestLoadBlogsTree: write %q failed: %s", name, err)
+		}
+	}
+
+	blogs, err := loadBlogs(blogDir, map[string]int{"go/post/index.md": 7})
+	if err == nil {
+		t.Fatalf("TestLoadBlogsTree: broken files should be reported")
+	}
+	for _, broken := range []string{"broken.md", "go/unclosed.md"} {
+		if !strings.Contains(err.Error(), broken) {
+			t.Fatalf("TestLoadBlogsTree: error should name %q, got %s", broken, err)
+		}
+	}
+
+	filenames := []string{}
+	for _, blog := range blogs {
+		filenames = append(filenames, blog.Filename)
+		if blog.Filename == "go/post/index.md" && blog.Frontmatter.ID != 7 {
+			t.Fatalf("TestLoadBlogsTree: bundle should get its id, got %d", blog.Frontmatter.ID)
+		}
+	}
+	slices.Sort(filenames)
+	expected := []string{"deep/er/than/deep.md", "flat.md", "go/nested.md", "go/post/index.md"}
+	if !slices.Equal(filenames, expected) {
+		t.Fatalf("TestLoadBlogsTree: expected %v, got %v", expected, filenames)
+	}
+}
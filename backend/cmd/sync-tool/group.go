@@ -8,7 +8,7 @@ import (
 )
 
 type GroupTypes interface {
-	entities.Tag | entities.Topic
+	entities.Tag | entities.Topic | entities.Series
 }
 
 type BlogGroupTypes interface {
@@ -59,7 +59,7 @@ func groupTags(localTags []entities.InTag, tags []entities.Tag) (Groups[entities
 		} else {
 			// the content is differrnt, we should update it
 			newTag := entities.NewTagWithID(remoteTag.ID, localTag.Name, localTag.Description)
-			result.update = append(result.noop, *newTag)
+			result.update = append(result.update, *newTag)
 			delete(tagMap, localTag.Name)
 			continue
 		}
@@ -129,6 +129,55 @@ func groupTopics(localTopics []entities.InTopic, topics []entities.Topic) (Group
 	return result, nil
 }
 
+func groupSeries(localSeries []entities.InSeries, series []entities.Series) (Groups[entities.Series], error) {
+	slog.Info("groupSeries")
+
+	seriesMap := map[string]entities.Series{}
+	for _, s := range series {
+		seriesMap[s.Name] = s
+	}
+
+	result := Groups[entities.Series]{}
+	for _, local := range localSeries {
+		remote, ok := seriesMap[local.Name]
+
+		// the record dosen't exist on remote, we should create it
+		if !ok {
+			newSeries := entities.NewSeries(local.Name, local.Description)
+			result.create = append(result.create, *newSeries)
+			continue
+		}
+
+		// the record is identical, do nothing
+		if local.Description == remote.Description {
+			result.noop = append(result.noop, remote)
+			delete(seriesMap, local.Name)
+			continue
+
+		} else {
+			// the content is differrnt, we should update it
+			newSeries := entities.NewSeriesWithID(remote.ID, local.Name, local.Description)
+			result.update = append(result.update, *newSeries)
+			delete(seriesMap, local.Name)
+			continue
+		}
+	}
+
+	// the remaining remote data should be deleted
+	for _, remote := range seriesMap {
+		result.delete = append(result.delete, remote)
+	}
+
+	slog.Info(
+		"grouped series",
+		"create", len(result.create),
+		"update", len(result.update),
+		"delete", len(result.delete),
+		"noop", len(result.noop),
+	)
+	return result, nil
+}
+
 func groupBlogs(localBlogs []BlogInfo, blogs []entities.OutBlogSimple) (BlogGroup[BlogInfo], error) {
 	slog.Info("groupBlogs")
 
@@ -220,6 +269,15 @@ func blogEqual(localBlog BlogInfo, remoteBlog entities.OutBlogSimple) bool {
 		slog.Debug("Topics not equal", "filename", localBlog.Filename)
 		return false
 	}
+	if localBlog.Frontmatter.Series != remoteBlog.Series {
+		slog.Debug("Series not equal", "filename", localBlog.Filename)
+		return false
+	}
+	// part 0 means keep whatever position the server has
+	if localBlog.Frontmatter.Part != 0 && localBlog.Frontmatter.Part != remoteBlog.Part {
+		slog.Debug("Part not equal", "filename", localBlog.Filename)
+		return false
+	}
 	if localBlog.Content_md5 != remoteBlog.ContentMD5 {
 		slog.Debug("Content_md5 not equal", "filename", localBlog.Filename)
 		return false
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"blog/client"
 	"blog/entities"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -9,6 +11,7 @@ import (
 	"log/slog"
 	"os"
 	"path"
+	"path/filepath"
 )
 
 var (
@@ -25,17 +28,51 @@ func drainAndClose(body io.ReadCloser) error {
 	return body.Close()
 }
 
+// writes to a temp file in the same dir, then renames it over name, an interrupted write never leaves a truncated file
+func writeFileAtomic(name string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("writeFileAtomic: create temp file failed: %w", err)
+	}
+	// fails harmlessly once renamed
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writeFileAtomic: write failed: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writeFileAtomic: sync failed: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writeFileAtomic: close failed: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return fmt.Errorf("writeFileAtomic: chmod failed: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), name); err != nil {
+		return fmt.Errorf("writeFileAtomic: rename failed: %w", err)
+	}
+	return nil
+}
+
 type SyncHelper struct {
+	// requests are canceled with it
+	ctx        context.Context
 	baseURL    string
-	token      string // jwt token
+	api        *client.Client // authorized with the jwt token
 	batchSize  int
 	sourcePath string
+	// progress of syncAll, nil otherwise
+	journal *Journal
 }
 
-func NewSyncHelper(baseURL, token string, batchSize int, sourcePath string) SyncHelper {
+func NewSyncHelper(ctx context.Context, baseURL, token string, batchSize int, sourcePath string) SyncHelper {
 	return SyncHelper{
+		ctx:        ctx,
 		baseURL:    baseURL,
-		token:      token,
+		api:        client.New(baseURL, httpClient, client.JWT(token)),
 		batchSize:  batchSize,
 		sourcePath: sourcePath,
 	}
@@ -55,6 +92,7 @@ type MaperError struct {
 	Ref                BlogInfo `json:"ref"`
 	NoneMatchingTags   []string `json:"noneMatchingTags"`   // slug
 	NoneMatchingTopics []string `json:"noneMatchingTopics"` // slug
+	NoneMatchingSeries string   `json:"noneMatchingSeries"` // slug
 }
 
 func NewMaperError(ref BlogInfo, tags, topics []string) MaperError {
@@ -66,15 +104,16 @@ func NewMaperError(ref BlogInfo, tags, topics []string) MaperError {
 }
 
 // this struct should only be used once
-// used for mapping tag and topic slugs to their ids
+// used for mapping tag, topic and series slugs to their ids
 type BlogMaper struct {
 	tagMap            map[string]int
 	topicMap          map[string]int
+	seriesMap         map[string]int
 	accumulatedErrors []MaperError
 	errorFilePath     string
 }
 
-func NewBlogMaper(tags []entities.Tag, topics []entities.Topic, sourcePath string) BlogMaper {
+func NewBlogMaper(tags []entities.Tag, topics []entities.Topic, series []entities.Series, sourcePath string) BlogMaper {
 	// prepare for lookup
 	topicMap := map[string]int{}
 	for _, topic := range topics {
@@ -84,15 +123,20 @@ func NewBlogMaper(tags []entities.Tag, topics []entities.Topic, sourcePath strin
 	for _, tag := range tags {
 		tagMap[tag.Slug] = tag.ID
 	}
+	seriesMap := map[string]int{}
+	for _, s := range series {
+		seriesMap[s.Slug] = s.ID
+	}
 
 	return BlogMaper{
 		tagMap:        tagMap,
 		topicMap:      topicMap,
+		seriesMap:     seriesMap,
 		errorFilePath: path.Join(sourcePath, "blog-map-error.json"),
 	}
 }
 
-// map topic and tag slugs to their ids
+// map topic, tag and series slugs to their ids
 func (b *BlogMaper) MapIDs(blogs BlogGroup[BlogInfo]) (BlogGroup[BlogInfo], error) {
 	slog.Info("MapIDs")
 
@@ -164,10 +208,21 @@ func (b *BlogMaper) mapIds(blogs []BlogInfo) []BlogInfo {
 			topicIDs = append(topicIDs, id)
 		}
 
+		seriesID := 0
+		if blog.Frontmatter.Series != "" {
+			id, ok := b.seriesMap[blog.Frontmatter.Series]
+			if !ok {
+				slog.Error("blog refereced a none existent series", "series", blog.Frontmatter.Series, "filename", blog.Filename)
+				currErr.NoneMatchingSeries = blog.Frontmatter.Series
+			}
+			seriesID = id
+		}
+
 		// we don't need to finish this after we hit an error,
 		// but we will still loop through all the blogs to get a complete error report.
 		if len(currErr.NoneMatchingTags) > 0 ||
-			len(currErr.NoneMatchingTopics) > 0 {
+			len(currErr.NoneMatchingTopics) > 0 ||
+			currErr.NoneMatchingSeries != "" {
 			b.accumulatedErrors = append(b.accumulatedErrors, currErr)
 			continue
 		} else if len(b.accumulatedErrors) > 0 {
@@ -176,6 +231,7 @@ func (b *BlogMaper) mapIds(blogs []BlogInfo) []BlogInfo {
 
 		blog.Frontmatter.TagIDs = tagIDs
 		blog.Frontmatter.TopicIDs = topicIDs
+		blog.Frontmatter.SeriesID = seriesID
 
 		result = append(result, blog)
 	}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+/*
+Progress of a sync, removed once the sync finishes.
+
+An interrupted sync leaves it behind, the next sync resumes with the same run id.
+Creates are sent with idempotency keys derived from the run id, so a create that reached the server
+before the interruption is replayed instead of done twice,
+and blogs written by the interrupted sync aren't taken as conflicts.
+
+Methods of a nil journal do nothing, for pull and watch.
+*/
+type Journal struct {
+	RunID     string `json:"runID"`
+	StartedAt string `json:"startedAt"`
+	// blogs written so far, as the server returned them
+	Blogs SyncState `json:"blogs"`
+
+	file string
+	lock *sync.Mutex
+}
+
+// loads the journal of an interrupted sync, or starts a new one which isn't saved yet
+func loadJournal(journalFile string) (*Journal, error) {
+	slog.Info("loadJournal", "filename", journalFile)
+
+	journal := &Journal{file: journalFile, lock: &sync.Mutex{}}
+	data, err := os.ReadFile(journalFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		runID := make([]byte, 16)
+		if _, err := rand.Read(runID); err != nil {
+			return nil, fmt.Errorf("loadJournal: generate run id failed: %w", err)
+		}
+		journal.RunID = fmt.Sprintf("%x", runID)
+		journal.StartedAt = time.Now().UTC().Format(time.RFC3339)
+		journal.Blogs = SyncState{}
+		return journal, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("loadJournal: read file failed: %w", err)
+	}
+
+	if err := json.Unmarshal(data, journal); err != nil {
+		return nil, fmt.Errorf("loadJournal: unmarshal failed: %w", err)
+	}
+	if journal.Blogs == nil {
+		journal.Blogs = SyncState{}
+	}
+	slog.Warn("resuming an interrupted sync", "started at", journal.StartedAt, "written blogs", len(journal.Blogs))
+	return journal, nil
+}
+
+func (j *Journal) Save() error {
+	if j == nil {
+		return nil
+	}
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	return j.save()
+}
+
+func (j *Journal) save() error {
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return fmt.Errorf("save: marshal journal failed: %w", err)
+	}
+	if err := writeFileAtomic(j.file, data, 0644); err != nil {
+		return fmt.Errorf("save: write file failed: %w", err)
+	}
+	return nil
+}
+
+// the same operation of the same run always gets the same key, empty without a journal
+func (j *Journal) IdempotencyKey(operation string) string {
+	if j == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s-%x", j.RunID, sha256.Sum256([]byte(operation)))
+}
+
+// records a blog written to the server, saved right away
+func (j *Journal) RecordBlog(filename string, blogState BlogState) error {
+	if j == nil {
+		return nil
+	}
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	j.Blogs[filename] = blogState
+	if err := j.save(); err != nil {
+		return fmt.Errorf("RecordBlog: %w", err)
+	}
+	return nil
+}
+
+// blogs written by the interrupted sync are taken as synced
+func (j *Journal) MergeState(state SyncState) {
+	if j == nil {
+		return
+	}
+	for filename, blogState := range j.Blogs {
+		state[filename] = blogState
+	}
+}
+
+// the sync finished
+func (j *Journal) Remove() error {
+	if j == nil {
+		return nil
+	}
+	if err := os.Remove(j.file); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("Remove: remove journal failed: %w", err)
+	}
+	return nil
+}
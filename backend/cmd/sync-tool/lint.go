@@ -0,0 +1,467 @@
+package main
+
+import (
+	"blog/markdown"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/BurntSushi/toml"
+	"github.com/gosimple/slug"
+	"github.com/yuin/goldmark/text"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	LintError   = "error"
+	LintWarning = "warning"
+)
+
+var ErrorLintFailed = errors.New("lint found problems")
+
+var (
+	// yaml errors look like "yaml: line 3: did not find expected key"
+	yamlLineRegexp = regexp.MustCompile(`line (\d+): (.*)`)
+	// yaml.v3 names the go type of unknown fields, ex: field foo not found in type main.BlogFrontmatter
+	yamlUnknownFieldRegexp = regexp.MustCompile(`field (\S+) not found in type \S+`)
+	// toml errors look like `toml: line 3 (last key "part"): incompatible types: ...`
+	tomlLineRegexp = regexp.MustCompile(`^toml: line (\d+)(?: \(last key "[^"]*"\))?: (.*)`)
+)
+
+// A problem found by lint, printed as file:line: severity: message
+type Diagnostic struct {
+	File     string
+	Line     int
+	Severity string
+	Msg      string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s:%d: %s: %s", d.File, d.Line, d.Severity, d.Msg)
+}
+
+/*
+Checks the source folder without a server, for pre-commit hooks.
+
+Prints every problem as file:line, fails when there are errors, or warnings with strict.
+*/
+func lintAll(sourcePath string, strict bool) error {
+	slog.Info("lintAll", "source", sourcePath, "strict", strict)
+
+	if err := checkSourcePath(sourcePath); err != nil {
+		return fmt.Errorf("lintAll: %w", err)
+	}
+
+	diagnostics, err := lintSource(sourcePath)
+	if err != nil {
+		return fmt.Errorf("lintAll: %w", err)
+	}
+
+	errorCount, warningCount := 0, 0
+	for _, d := range diagnostics {
+		fmt.Println(d)
+		if d.Severity == LintError {
+			errorCount++
+		} else {
+			warningCount++
+		}
+	}
+
+	if errorCount > 0 || (strict && warningCount > 0) {
+		return fmt.Errorf("lintAll: %d errors, %d warnings: %w", errorCount, warningCount, ErrorLintFailed)
+	}
+	slog.Info("lint done", "errors", errorCount, "warnings", warningCount)
+	return nil
+}
+
+// A name in meta.yaml
+type metaEntry struct {
+	name string
+	line int
+	used bool
+}
+
+// A value in the frontmatter of a blog
+type lintValue struct {
+	value string
+	line  int
+}
+
+// A blog that parsed well enough to be checked against the others
+type lintedBlog struct {
+	file        string
+	frontmatter BlogFrontmatter
+	// line of each frontmatter key
+	keyLines map[string]int
+	tags     []lintValue
+	topics   []lintValue
+}
+
+func (b lintedBlog) line(key string) int {
+	if line, ok := b.keyLines[key]; ok {
+		return line
+	}
+	return 1
+}
+
+type linter struct {
+	sourcePath  string
+	diagnostics []Diagnostic
+
+	// slugs of meta.yaml entries, nil when meta.yaml is broken
+	tags   map[string]*metaEntry
+	topics map[string]*metaEntry
+	series map[string]*metaEntry
+}
+
+func (l *linter) report(file string, line int, severity, format string, args ...any) {
+	l.diagnostics = append(l.diagnostics, Diagnostic{
+		File:     file,
+		Line:     line,
+		Severity: severity,
+		Msg:      fmt.Sprintf(format, args...),
+	})
+}
+
+// reports yaml errors with their lines, offset is the line the yaml starts after
+func (l *linter) reportYAML(file string, offset int, err error) {
+	msgs := []string{err.Error()}
+	typeErr := &yaml.TypeError{}
+	if errors.As(err, &typeErr) {
+		msgs = typeErr.Errors
+	}
+
+	for _, msg := range msgs {
+		line := 1
+		if match := yamlLineRegexp.FindStringSubmatch(msg); match != nil {
+			line, _ = strconv.Atoi(match[1])
+			line += offset
+			msg = match[2]
+		}
+		msg = yamlUnknownFieldRegexp.ReplaceAllString(msg, `unknown field "$1"`)
+		l.report(file, line, LintError, "%s", strings.TrimPrefix(msg, "yaml: "))
+	}
+}
+
+// returns the diagnostics of meta.yaml and blogs/, sorted by file and line
+func lintSource(sourcePath string) ([]Diagnostic, error) {
+	l := &linter{sourcePath: sourcePath}
+
+	l.lintMeta()
+
+	blogDir := filepath.Join(sourcePath, "blogs")
+	filenames, errs, err := findBlogFiles(blogDir)
+	if err != nil {
+		return []Diagnostic{}, fmt.Errorf("lintSource: read dir failed: %w", err)
+	}
+	for _, err := range errs {
+		l.report(blogDir, 1, LintError, "%s", err)
+	}
+
+	blogs := []lintedBlog{}
+	for _, filename := range filenames {
+		blog, ok := l.lintBlog(filepath.Join(blogDir, filepath.FromSlash(filename)))
+		if ok {
+			blogs = append(blogs, blog)
+		}
+	}
+
+	l.lintDuplicates(blogs)
+	l.lintUnused()
+
+	slices.SortStableFunc(l.diagnostics, func(a, b Diagnostic) int {
+		if a.File != b.File {
+			return strings.Compare(a.File, b.File)
+		}
+		return a.Line - b.Line
+	})
+	return l.diagnostics, nil
+}
+
+func (l *linter) lintMeta() {
+	metaFile := filepath.Join(l.sourcePath, "meta.yaml")
+	data, err := os.ReadFile(metaFile)
+	if err != nil {
+		l.report(metaFile, 1, LintError, "read file failed: %s", err)
+		return
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&MetaFileContent{}); err != nil && !errors.Is(err, io.EOF) {
+		l.reportYAML(metaFile, 0, err)
+	}
+
+	root := yaml.Node{}
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return
+	}
+	if err := root.Decode(&MetaFileContent{}); err != nil {
+		return
+	}
+
+	l.tags = map[string]*metaEntry{}
+	l.topics = map[string]*metaEntry{}
+	l.series = map[string]*metaEntry{}
+	if len(root.Content) == 0 {
+		return
+	}
+
+	mapping := root.Content[0]
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key, value := mapping.Content[i].Value, mapping.Content[i+1]
+		var entries map[string]*metaEntry
+		switch key {
+		case "tags":
+			entries = l.tags
+		case "topics":
+			entries = l.topics
+		case "series":
+			entries = l.series
+		default:
+			continue
+		}
+
+		for _, item := range value.Content {
+			fields := map[string]string{}
+			for j := 0; j+1 < len(item.Content); j += 2 {
+				fields[item.Content[j].Value] = item.Content[j+1].Value
+			}
+
+			name := fields["name"]
+			if strings.TrimSpace(name) == "" {
+				l.report(metaFile, item.Line, LintError, "%s entry without a name", key)
+				continue
+			}
+			if strings.TrimSpace(fields["description"]) == "" {
+				l.report(metaFile, item.Line, LintWarning, "%s %q has an empty description", key, name)
+			}
+
+			nameSlug := slug.Make(name)
+			if other, ok := entries[nameSlug]; ok {
+				l.report(metaFile, item.Line, LintError, "%s %q has the same slug as %q on line %d", key, name, other.name, other.line)
+				continue
+			}
+			entries[nameSlug] = &metaEntry{name: name, line: item.Line}
+		}
+	}
+}
+
+// checks a single blog, returns false when it can't be checked against the others
+func (l *linter) lintBlog(file string) (lintedBlog, bool) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		l.report(file, 1, LintError, "read file failed: %s", err)
+		return lintedBlog{}, false
+	}
+
+	delimiter, header, content, err := splitFrontmatter(data)
+	if err != nil {
+		l.report(file, 1, LintError, "%s", err)
+		return lintedBlog{}, false
+	}
+	blog := lintedBlog{file: file, keyLines: map[string]int{}}
+
+	// the header starts on the second line
+	var ok bool
+	if delimiter == tomlDelimiter {
+		ok = l.parseTOML(&blog, header)
+	} else {
+		ok = l.parseYAML(&blog, header)
+	}
+	if !ok {
+		return lintedBlog{}, false
+	}
+
+	frontmatter := blog.frontmatter
+	if strings.TrimSpace(frontmatter.Title) == "" {
+		l.report(file, blog.line("title"), LintError, "title is empty")
+	}
+	if strings.TrimSpace(frontmatter.Description) == "" {
+		l.report(file, blog.line("description"), LintWarning, "description is empty")
+	}
+	if frontmatter.Part < 0 {
+		l.report(file, blog.line("part"), LintError, "part should be 0 or more, got %d", frontmatter.Part)
+	}
+	l.lintNames(file, "tag", blog.tags, l.tags)
+	l.lintNames(file, "topic", blog.topics, l.topics)
+	if frontmatter.Series != "" {
+		l.lintNames(file, "series", []lintValue{{frontmatter.Series, blog.line("series")}}, l.series)
+	}
+
+	// content starts with the line break of the closing line
+	closingLine := strings.Count(header, "\n") + 2
+	l.lintContent(file, content, closingLine)
+
+	return blog, true
+}
+
+func (l *linter) parseYAML(blog *lintedBlog, header string) bool {
+	decoder := yaml.NewDecoder(strings.NewReader(header))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&BlogFrontmatter{}); err != nil && !errors.Is(err, io.EOF) {
+		l.reportYAML(blog.file, 1, err)
+	}
+
+	// unknown fields are reported above, the rest of the checks can still be made
+	root := yaml.Node{}
+	if err := yaml.Unmarshal([]byte(header), &root); err != nil {
+		return false
+	}
+	if err := root.Decode(&blog.frontmatter); err != nil {
+		return false
+	}
+	if len(root.Content) == 0 {
+		return true
+	}
+
+	mapping := root.Content[0]
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key, value := mapping.Content[i], mapping.Content[i+1]
+		blog.keyLines[key.Value] = key.Line + 1
+
+		values := []lintValue{}
+		for _, item := range value.Content {
+			values = append(values, lintValue{item.Value, item.Line + 1})
+		}
+		switch key.Value {
+		case "tags":
+			blog.tags = values
+		case "topics":
+			blog.topics = values
+		}
+	}
+	return true
+}
+
+func (l *linter) parseTOML(blog *lintedBlog, header string) bool {
+	metaData, err := toml.Decode(header, &blog.frontmatter)
+	if err != nil {
+		line, msg := 1, err.Error()
+		if match := tomlLineRegexp.FindStringSubmatch(msg); match != nil {
+			line, _ = strconv.Atoi(match[1])
+			line++
+			msg = match[2]
+		}
+		l.report(blog.file, line, LintError, "%s", msg)
+		return false
+	}
+
+	// toml has no line of each value, the key is close enough
+	for i, line := range strings.Split(header, "\n") {
+		key, _, found := strings.Cut(line, "=")
+		if found {
+			blog.keyLines[strings.Trim(key, " \t\"'")] = i + 2
+		}
+	}
+	for _, key := range metaData.Undecoded() {
+		l.report(blog.file, blog.line(key.String()), LintError, "unknown field %q", key.String())
+	}
+	for _, tag := range blog.frontmatter.Tags {
+		blog.tags = append(blog.tags, lintValue{tag, blog.line("tags")})
+	}
+	for _, topic := range blog.frontmatter.Topics {
+		blog.topics = append(blog.topics, lintValue{topic, blog.line("topics")})
+	}
+	return true
+}
+
+// reports names missing from meta.yaml and marks the others as used
+func (l *linter) lintNames(file, kind string, values []lintValue, entries map[string]*metaEntry) {
+	if entries == nil {
+		return
+	}
+	for _, value := range values {
+		entry, ok := entries[slug.Make(value.value)]
+		if !ok {
+			l.report(file, value.line, LintError, "%s %q isn't in meta.yaml", kind, value.value)
+			continue
+		}
+		entry.used = true
+	}
+}
+
+/*
+Checks the markdown renders like on the server, and that relative images exist.
+
+Lines are counted from closingLine, the line the content starts on.
+*/
+func (l *linter) lintContent(file, content string, closingLine int) {
+	lineOf := func(offset int) int {
+		return closingLine + strings.Count(content[:offset], "\n")
+	}
+
+	if !utf8.ValidString(content) {
+		offset := 0
+		for offset < len(content) {
+			r, size := utf8.DecodeRuneInString(content[offset:])
+			if r == utf8.RuneError && size == 1 {
+				break
+			}
+			offset += size
+		}
+		l.report(file, lineOf(offset), LintError, "markdown isn't valid utf-8")
+		return
+	}
+
+	source := []byte(content)
+	md := markdown.New(nil)
+	doc := md.Parser().Parse(text.NewReader(source))
+	if err := md.Renderer().Render(io.Discard, source, doc); err != nil {
+		l.report(file, closingLine, LintError, "render markdown failed: %s", err)
+		return
+	}
+
+	for _, ref := range findImageRefs(content) {
+		if !isRelativeLink(ref.Dest) {
+			continue
+		}
+		if _, err := os.Stat(linkedFile(file, ref.Dest)); err != nil {
+			line := closingLine
+			if ref.Offset >= 0 {
+				line = lineOf(ref.Offset)
+			}
+			l.report(file, line, LintError, "image %q not found", ref.Dest)
+		}
+	}
+}
+
+// reports blogs with the same title or slug, every one of them points to the first
+func (l *linter) lintDuplicates(blogs []lintedBlog) {
+	bySlug := map[string]lintedBlog{}
+	for _, blog := range blogs {
+		title := blog.frontmatter.Title
+		titleSlug := slug.Make(title)
+		if titleSlug == "" {
+			continue
+		}
+		first, ok := bySlug[titleSlug]
+		if !ok {
+			bySlug[titleSlug] = blog
+			continue
+		}
+		if first.frontmatter.Title == title {
+			l.report(blog.file, blog.line("title"), LintError, "duplicate title %q, also used by %s", title, first.file)
+		} else {
+			l.report(blog.file, blog.line("title"), LintError, "slug %q of title %q is also used by %s", titleSlug, title, first.file)
+		}
+	}
+}
+
+func (l *linter) lintUnused() {
+	metaFile := filepath.Join(l.sourcePath, "meta.yaml")
+	for _, entry := range l.tags {
+		if !entry.used {
+			l.report(metaFile, entry.line, LintWarning, "tag %q isn't used by any blog", entry.name)
+		}
+	}
+}
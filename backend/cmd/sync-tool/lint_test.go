@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	sourcePath := t.TempDir()
+	files := map[string]string{
+		"meta.yaml":                  "authors: []\ntags:\n  - name: go\n    description: golang\n  - name: unused\n    description: nobody uses it\ntopics:\n  - name: backend\n    description: \"\"\n",
+		"blogs/a.md":                 "---\ntitle: Hello World\ndescription: first\ntags:\n  - go\n  - rust\ntopics: [backend]\n---\n\n![ok](img/ok.png)\n\n![missing](img/missing.png)\n![remote](https://example.com/a.png)\n",
+		"blogs/b.md":                 "---\ntitle: hello world!\ndescription: same slug\nauthor: me\n---\ncontent\n",
+		"blogs/c.md":                 "+++\ntitle = \"Hello World\"\ntopic = \"backend\"\n+++\n",
+		"blogs/d.md":                 "---\ntitle: d\npart: second\n---\n",
+		"blogs/e.md":                 "---\ntitle: e\ndescription: e\n---\n\n\xff\n",
+		"blogs/f.md":                 "no frontmatter\n",
+		"blogs/g.md":                 "---\n---\n",
+		"blogs/h.md":                 "+++\ntitle = \"h\"\ndescription = \"h\"\npart = \"x\"\n+++\n",
+		"blogs/post/index.md":        "---\ntitle: post\ndescription: bundle\n---\n![cover](cover%20image.png)\n",
+		"blogs/post/cover image.png": "png",
+		"blogs/img/ok.png":           "png",
+	}
+	for name, content := range files {
+		path := filepath.Join(sourcePath, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("TestLint: create dir failed: %s", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("TestLint: write %q failed: %s", name, err)
+		}
+	}
+
+	diagnostics, err := lintSource(sourcePath)
+	if err != nil {
+		t.Fatalf("TestLint: lint failed: %s", err)
+	}
+	got := []string{}
+	for _, d := range diagnostics {
+		rel, err := filepath.Rel(sourcePath, d.File)
+		if err != nil {
+			t.Fatalf("TestLint: unexpected file %q", d.File)
+		}
+		d.File = filepath.ToSlash(rel)
+		got = append(got, d.String())
+	}
+
+	expected := []string{
+		`blogs/a.md:6: error: tag "rust" isn't in meta.yaml`,
+		`blogs/a.md:12: error: image "img/missing.png" not found`,
+		`blogs/b.md:2: error: slug "hello-world" of title "hello world!" is also used by ` + filepath.Join(sourcePath, "blogs", "a.md"),
+		`blogs/b.md:4: error: unknown field "author"`,
+		`blogs/c.md:1: warning: description is empty`,
+		`blogs/c.md:2: error: duplicate title "Hello World", also used by ` + filepath.Join(sourcePath, "blogs", "a.md"),
+		`blogs/c.md:3: error: unknown field "topic"`,
+		"blogs/d.md:3: error: cannot unmarshal !!str `second` into int",
+		`blogs/e.md:6: error: markdown isn't valid utf-8`,
+		`blogs/f.md:1: error: no frontmatter, the file should start with a '---' or '+++' line`,
+		`blogs/g.md:1: error: title is empty`,
+		`blogs/g.md:1: warning: description is empty`,
+		`blogs/h.md:4: error: incompatible types: TOML value has type string; destination has type integer`,
+		`meta.yaml:1: error: unknown field "authors"`,
+		`meta.yaml:5: warning: tag "unused" isn't used by any blog`,
+		`meta.yaml:8: warning: topics "backend" has an empty description`,
+	}
+	if !slices.Equal(got, expected) {
+		t.Fatalf("TestLint: expected\n%s\ngot\n%s", expected, got)
+	}
+}
@@ -4,18 +4,25 @@ import (
 	"blog/entities"
 	"crypto/md5"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"log/slog"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/gosimple/slug"
 	"gopkg.in/yaml.v3"
 )
 
+// a directory with this file is one blog, see loadBlogs
+const bundleIndex = "index.md"
+
 type MetaFileContent struct {
-	Topics []entities.InTopic `yaml:"topics"`
-	Tags   []entities.InTag   `yaml:"tags"`
+	Topics []entities.InTopic  `yaml:"topics"`
+	Tags   []entities.InTag    `yaml:"tags"`
+	Series []entities.InSeries `yaml:"series"`
 }
 
 func loadMetaFile(metaFile string) (MetaFileContent, error) {
@@ -36,19 +43,24 @@ func loadMetaFile(metaFile string) (MetaFileContent, error) {
 }
 
 type BlogFrontmatter struct {
-	ID          int    // this will be loaded separately
-	Title       string `yaml:"title"`
-	Description string `yaml:"description"`
-	Pined       bool   `yaml:"pined"`
-	Visible     bool   `yaml:"visible"`
+	ID          int    `yaml:"-" toml:"-"` // this will be loaded separately
+	Title       string `yaml:"title" toml:"title"`
+	Description string `yaml:"description" toml:"description"`
+	Pined       bool   `yaml:"pined" toml:"pined"`
+	Visible     bool   `yaml:"visible" toml:"visible"`
 
 	// will be transformed into slugs
-	Tags   []string `yaml:"tags"`
-	Topics []string `yaml:"topics"`
+	Tags   []string `yaml:"tags" toml:"tags"`
+	Topics []string `yaml:"topics" toml:"topics"`
+	Series string   `yaml:"series,omitempty" toml:"series"`
+
+	// position in series, 0 keeps the current position or appends to the end
+	Part int `yaml:"part,omitempty" toml:"part"`
 
 	// filled in after transform step
-	TagIDs   []int
-	TopicIDs []int
+	TagIDs   []int `yaml:"-" toml:"-"`
+	TopicIDs []int `yaml:"-" toml:"-"`
+	SeriesID int   `yaml:"-" toml:"-"`
 }
 
 func (b *BlogFrontmatter) slugify() {
@@ -65,12 +77,20 @@ func (b *BlogFrontmatter) slugify() {
 		newTopics = append(newTopics, slug.Make(topic))
 	}
 	b.Topics = newTopics
+
+	// series
+	if b.Series != "" {
+		b.Series = slug.Make(b.Series)
+	}
 }
 
 type BlogInfo struct {
 	Frontmatter BlogFrontmatter
 	Content_md5 string
+	File_md5    string // of the whole file, frontmatter included
 	Filename    string
+	// relative images in the content, filled in by LinkImages
+	Images []LocalImage
 }
 
 func NewBlogInfo(frontmatter BlogFrontmatter, content string, filename string) BlogInfo {
@@ -83,58 +103,115 @@ func NewBlogInfo(frontmatter BlogFrontmatter, content string, filename string) B
 	}
 }
 
-// load all blogs in blogDir, parse their mdx frontmatter
+/*
+load all blogs under blogDir, parse their frontmatter
+
+Blogs are markdown files in blogDir or any directory below it, see findBlogFiles.
+Every broken file is reported in the returned error, along with the blogs that did load.
+*/
 func loadBlogs(blogDir string, idMap map[string]int) ([]BlogInfo, error) {
 	slog.Info("loadBlogs")
 
-	// get all the files
-	files, err := os.ReadDir(blogDir)
+	filenames, errs, err := findBlogFiles(blogDir)
 	if err != nil {
 		return []BlogInfo{}, fmt.Errorf("loadBlogs: read dir failed: %w", err)
 	}
-	slog.Debug("got blogs", "blog count", len(files))
 
 	result := []BlogInfo{}
-
-	// load all of them
-	// split by '---' and use  yaml to unmartial the data
-	for _, file := range files {
-		filepath := fmt.Sprintf("%s/%s", blogDir, file.Name())
-
-		if !strings.HasSuffix(filepath, ".md") {
-			// only process markdown files
-			slog.Info("found a none markdown file, skiping", "filename", file.Name())
+	for _, filename := range filenames {
+		blogInfo, err := loadBlog(blogDir, filename, idMap)
+		if err != nil {
+			errs = append(errs, err)
 			continue
 		}
+		result = append(result, blogInfo)
+	}
+
+	slog.Info("local blogs loaded", "blog count", len(result), "errors", len(errs))
+	if len(errs) > 0 {
+		return result, fmt.Errorf("loadBlogs: %d broken blogs: %w", len(errs), errors.Join(errs...))
+	}
+	return result, nil
+}
+
+/*
+filenames of all blogs under blogDir, as paths from blogDir
+
+Blogs are markdown files in blogDir or any directory below it, hidden directories are skipped.
+A directory with an index.md is a single blog, the other files in it are its assets.
+Unreadable entries below blogDir are returned separately, the walk goes on.
+*/
+func findBlogFiles(blogDir string) ([]string, []error, error) {
+	result := []string{}
+	errs := []error{}
 
-		rawData, err := os.ReadFile(filepath)
+	err := filepath.WalkDir(blogDir, func(path string, entry fs.DirEntry, err error) error {
 		if err != nil {
-			return []BlogInfo{}, fmt.Errorf("loadBlogs: read file %q failed: %w", filepath, err)
+			if path == blogDir {
+				return err
+			}
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			return nil
 		}
 
-		full := string(rawData)
-		splited := strings.Split(full, "---")
-		header := splited[1]
-		content := splited[2]
-
-		parsedHeader := BlogFrontmatter{}
-		if err := yaml.Unmarshal([]byte(header), &parsedHeader); err != nil {
-			return []BlogInfo{}, fmt.Errorf("loadBlogs: parse header from %q failed: %w", filepath, err)
+		filename, err := filepath.Rel(blogDir, path)
+		if err != nil {
+			return err
 		}
-		id, ok := idMap[file.Name()]
-		if ok {
-			slog.Debug("got id for blog", "filename", file.Name(), "id", id)
-			parsedHeader.ID = id
-		} else {
-			slog.Debug("new blog", "filename", file.Name())
+		filename = filepath.ToSlash(filename)
+
+		if entry.IsDir() {
+			if path == blogDir {
+				return nil
+			}
+			if strings.HasPrefix(entry.Name(), ".") {
+				return fs.SkipDir
+			}
+			// a post with its assets
+			if _, err := os.Stat(filepath.Join(path, bundleIndex)); err == nil {
+				result = append(result, filename+"/"+bundleIndex)
+				return fs.SkipDir
+			}
+			return nil
 		}
 
-		blogsInfo := NewBlogInfo(parsedHeader, content, file.Name())
-		result = append(result, blogsInfo)
+		if !isBlogFile(entry.Name()) {
+			// only process markdown files
+			slog.Debug("found a none markdown file, skiping", "filename", filename)
+			return nil
+		}
+		result = append(result, filename)
+		return nil
+	})
+	if err != nil {
+		return []string{}, []error{}, err
 	}
+	return result, errs, nil
+}
 
-	slog.Info("local blogs loaded", "blog count", len(result))
-	return result, nil
+// load a single blog, filename is its path from blogDir
+func loadBlog(blogDir, filename string, idMap map[string]int) (BlogInfo, error) {
+	rawData, err := os.ReadFile(filepath.Join(blogDir, filepath.FromSlash(filename)))
+	if err != nil {
+		return BlogInfo{}, fmt.Errorf("%s: read file failed: %w", filename, err)
+	}
+
+	parsedHeader, content, err := parseFrontmatter(rawData)
+	if err != nil {
+		return BlogInfo{}, fmt.Errorf("%s: %w", filename, err)
+	}
+
+	id, ok := idMap[filename]
+	if ok {
+		slog.Debug("got id for blog", "filename", filename, "id", id)
+		parsedHeader.ID = id
+	} else {
+		slog.Debug("new blog", "filename", filename)
+	}
+
+	blogInfo := NewBlogInfo(parsedHeader, content, filename)
+	blogInfo.File_md5 = fmt.Sprintf("%x", md5.Sum(rawData))
+	return blogInfo, nil
 }
 
 // load ids.json
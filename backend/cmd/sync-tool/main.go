@@ -7,15 +7,17 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
 	"syscall"
 	"time"
 
 	"github.com/urfave/cli/v2"
 )
 
-var httpClient = &http.Client{
+// retries failed requests, each attempt has its own timeout
+var httpClient = NewRetryClient(&http.Client{
 	Timeout: 30 * time.Second,
-}
+}, 4)
 
 func main() {
 	var (
@@ -25,16 +27,20 @@ func main() {
 		batchSize  int
 		username   string
 		password   string
+		options    SyncOptions
+		overwrite  bool
+		debounce   time.Duration
+		strict     bool
+
+		retries int
+
+		profileName string
+		configFile  string
+		credentials Credentials
 	)
 
-	// use custom client to set timeout
-	commonFlags := []cli.Flag{
-		&cli.StringFlag{
-			Name:        "url",
-			Value:       "http://localhost:8080/api/v1",
-			Usage:       "Base `URL` for api",
-			Destination: &url,
-		},
+	// flags of commands that don't talk to the server
+	sourceFlags := []cli.Flag{
 		&cli.StringFlag{
 			Name:        "source",
 			Value:       "./",
@@ -46,12 +52,41 @@ func main() {
 			Usage: "verbose, shows debug log",
 			Count: &verbose,
 		},
+		&cli.StringFlag{
+			Name:        "profile",
+			Usage:       "use url, source, batch size and auth of profile `NAME` in the config file, flags take precedence",
+			Destination: &profileName,
+			EnvVars:     []string{"BLOG_PROFILE"},
+		},
+		&cli.StringFlag{
+			Name:        "config",
+			Value:       defaultConfigFile(),
+			Usage:       "`PATH` to the config file with profiles",
+			Destination: &configFile,
+			EnvVars:     []string{"BLOG_CONFIG"},
+		},
+	}
+
+	// use custom client to set timeout
+	commonFlags := []cli.Flag{
+		&cli.StringFlag{
+			Name:        "url",
+			Value:       "http://localhost:8080/api/v1",
+			Usage:       "Base `URL` for api",
+			Destination: &url,
+		},
 		&cli.IntFlag{
 			Name:        "bs",
 			Value:       5,
 			Usage:       "max `SIZE` of concurrent requests",
 			Destination: &batchSize,
 		},
+		&cli.IntFlag{
+			Name:        "retries",
+			Value:       4,
+			Usage:       "retry requests failed with 429, 5xx or network errors up to `COUNT` times",
+			Destination: &retries,
+		},
 		&cli.StringFlag{
 			Name:        "username",
 			Value:       "",
@@ -68,6 +103,98 @@ func main() {
 		},
 	}
 
+	syncFlags := []cli.Flag{
+		&cli.BoolFlag{
+			Name:        "plan",
+			Usage:       "print what would be created, updated and deleted without changing anything",
+			Destination: &options.Plan,
+		},
+		&cli.BoolFlag{
+			Name:        "json",
+			Usage:       "print the plan as json",
+			Destination: &options.JSON,
+		},
+		&cli.BoolFlag{
+			Name:        "yes",
+			Usage:       "delete without asking for confirmation",
+			Destination: &options.Yes,
+		},
+		&cli.StringFlag{
+			Name:        "prefer",
+			Usage:       "resolve blogs changed locally and on the server with the `SIDE` ( local or remote ), instead of writing .remote.md files",
+			Destination: &options.Prefer,
+		},
+	}
+
+	pullFlags := []cli.Flag{
+		&cli.BoolFlag{
+			Name:        "overwrite",
+			Usage:       "overwrite existing meta.yaml and blog files",
+			Destination: &overwrite,
+		},
+	}
+
+	watchFlags := []cli.Flag{
+		&cli.DurationFlag{
+			Name:        "debounce",
+			Value:       500 * time.Millisecond,
+			Usage:       "wait until files stop changing for `DURATION` before syncing",
+			Destination: &debounce,
+		},
+	}
+
+	lintFlags := []cli.Flag{
+		&cli.BoolFlag{
+			Name:        "strict",
+			Usage:       "fail on warnings too",
+			Destination: &strict,
+		},
+	}
+
+	setupLog := func(ctx *cli.Context) error {
+		log.SetFlags(log.Llongfile | log.Ltime)
+		if verbose > 0 {
+			slog.SetLogLoggerLevel(slog.LevelDebug)
+		}
+		return nil
+	}
+
+	// profile values fill in flags that weren't given, the token is cached per profile
+	setup := func(ctx *cli.Context) error {
+		if err := setupLog(ctx); err != nil {
+			return err
+		}
+		httpClient.retries = max(retries, 0)
+		credentials = NewCredentials(username, password)
+		if profileName == "" {
+			return nil
+		}
+
+		profile, err := loadProfile(configFile, profileName)
+		if err != nil {
+			return err
+		}
+		if profile.URL != "" && !ctx.IsSet("url") {
+			url = profile.URL
+		}
+		if profile.Source != "" && !ctx.IsSet("source") {
+			sourcePath = profile.Source
+		}
+		if profile.BatchSize > 0 && !ctx.IsSet("bs") {
+			batchSize = profile.BatchSize
+		}
+		if profile.Username != "" && !ctx.IsSet("username") {
+			credentials.Username = profile.Username
+		}
+		credentials.NoPrompt = profile.Auth == AuthEnv
+
+		credentials.TokenFile, err = tokenFile(profileName)
+		if err != nil {
+			slog.Warn("token isn't cached", "error", err)
+		}
+		return nil
+	}
+
 	ctxCancel, cancel := context.WithCancel(context.Background())
 	defer cancel()
 	notifyDone := make(chan bool, 1)
@@ -83,21 +210,59 @@ func main() {
 				Action: func(cCtx *cli.Context) error {
 					return syncAll(
 						ctxCancel,
-						username,
-						password,
+						credentials,
 						url,
 						sourcePath,
 						batchSize,
+						options,
 					)
 				},
-				Flags: commonFlags,
-				Before: func(ctx *cli.Context) error {
-					log.SetFlags(log.Llongfile | log.Ltime)
-					if verbose > 0 {
-						slog.SetLogLoggerLevel(slog.LevelDebug)
-					}
-					return nil
+				Flags:  slices.Concat(sourceFlags, commonFlags, syncFlags),
+				Before: setup,
+			},
+			{
+				Name:                   "pull",
+				Usage:                  `Export topics, tags, series and blogs on the server into the source folder. Existing files are kept unless --overwrite is given.`,
+				UseShortOptionHandling: true,
+				Action: func(cCtx *cli.Context) error {
+					return pullAll(
+						ctxCancel,
+						credentials,
+						url,
+						sourcePath,
+						batchSize,
+						overwrite,
+					)
+				},
+				Flags:  slices.Concat(sourceFlags, commonFlags, pullFlags),
+				Before: setup,
+			},
+			{
+				Name:                   "watch",
+				Usage:                  `Watch meta.yaml and blogs/, sync changed files on save. Nothing is deleted, run sync for that.`,
+				UseShortOptionHandling: true,
+				Action: func(cCtx *cli.Context) error {
+					return watchAll(
+						ctxCancel,
+						credentials,
+						url,
+						sourcePath,
+						batchSize,
+						debounce,
+					)
+				},
+				Flags:  slices.Concat(sourceFlags, commonFlags, watchFlags),
+				Before: setup,
+			},
+			{
+				Name:                   "lint",
+				Usage:                  `Check meta.yaml and blogs/ without a server, prints problems as file:line and fails on errors. Can be used as a pre-commit hook.`,
+				UseShortOptionHandling: true,
+				Action: func(cCtx *cli.Context) error {
+					return lintAll(sourcePath, strict)
 				},
+				Flags:  slices.Concat(sourceFlags, lintFlags),
+				Before: setup,
 			},
 		},
 	}
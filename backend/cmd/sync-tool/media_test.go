@@ -0,0 +1,130 @@
+package main
+
+import (
+	"blog/entities"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestLinkImages(t *testing.T) {
+	sourcePath := t.TempDir()
+	blogDir := filepath.Join(sourcePath, "blogs")
+	blogFile := "---\ntitle: post\nvisible: true\n---\n" +
+		"![cover.png](cover.png \"alt same as the link\")\n\n" +
+		"![remote](https://example.com/a.png)\n\n" +
+		"![diagram](../shared/diagram%201.png)\n\n" +
+		"![cover again](cover.png)\n"
+	files := map[string]string{
+		"blogs/post/index.md":        blogFile,
+		"blogs/post/cover.png":       "cover",
+		"blogs/shared/diagram 1.png": "diagram",
+	}
+	for name, content := range files {
+		path := filepath.Join(sourcePath, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("TestLinkImages: create dir failed: %s", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("TestLinkImages: write %q failed: %s", name, err)
+		}
+	}
+	hash := func(content string) string {
+		return fmt.Sprintf("%x", sha256.Sum256([]byte(content)))
+	}
+	coverHash, diagramHash := hash("cover"), hash("diagram")
+
+	// uploads are recorded, the hash is computed like the server does
+	uploaded := []string{}
+	lock := sync.Mutex{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/v1/media" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		data, _ := io.ReadAll(file)
+		lock.Lock()
+		uploaded = append(uploaded, header.Filename)
+		lock.Unlock()
+		media := entities.NewMedia(hash(string(data)), header.Filename, "image/png", int64(len(data)), 0, 0, "")
+		json.NewEncoder(w).Encode(entities.NewRetSuccess(*media))
+	}))
+	defer server.Close()
+	helper := NewSyncHelper(context.Background(), server.URL+"/api/v1", "token", 2, sourcePath)
+
+	blogs, err := loadBlogs(blogDir, map[string]int{})
+	if err != nil {
+		t.Fatalf("TestLinkImages: load blogs failed: %s", err)
+	}
+	blogs, err = helper.LinkImages(blogs)
+	if err != nil {
+		t.Fatalf("TestLinkImages: link images failed: %s", err)
+	}
+	if len(blogs) != 1 || len(blogs[0].Images) != 3 {
+		t.Fatalf("TestLinkImages: expected a blog with 3 images, got %+v", blogs)
+	}
+
+	content, err := helper.loadContent(blogs[0])
+	if err != nil {
+		t.Fatalf("TestLinkImages: load content failed: %s", err)
+	}
+	expected := "\n" +
+		"![cover.png](/api/v1/media/" + coverHash + " \"alt same as the link\")\n\n" +
+		"![remote](https://example.com/a.png)\n\n" +
+		"![diagram](/api/v1/media/" + diagramHash + ")\n\n" +
+		"![cover again](/api/v1/media/" + coverHash + ")\n"
+	if content != expected {
+		t.Fatalf("TestLinkImages: expected content %q, got %q", expected, content)
+	}
+	local, err := os.ReadFile(filepath.Join(blogDir, "post", "index.md"))
+	if err != nil || string(local) != blogFile {
+		t.Fatalf("TestLinkImages: local file shouldn't change, got %q %v", local, err)
+	}
+
+	// the server has the linked content, nothing to update
+	remoteBlog := entities.NewBlog("post", content, "", false, true)
+	remoteBlog.ID = 1
+	blogs[0].Frontmatter.ID = 1
+	grouped, err := groupBlogs(blogs, []entities.OutBlogSimple{entities.NewOutBlogSimple(*remoteBlog, []string{}, []string{})})
+	if err != nil {
+		t.Fatalf("TestLinkImages: group blogs failed: %s", err)
+	}
+	if len(grouped.noop) != 1 || len(grouped.update) != 0 {
+		t.Fatalf("TestLinkImages: unchanged blog should be noop, got %+v", grouped)
+	}
+
+	// only images missing on the server are uploaded, once
+	images := imagesOf(blogs)
+	if len(images) != 2 {
+		t.Fatalf("TestLinkImages: images should be unique, got %+v", images)
+	}
+	remoteMedia := []entities.OutMedia{*entities.NewOutMedia(*entities.NewMedia(coverHash, "cover.png", "image/png", 5, 0, 0, ""), []int{1})}
+	if err := helper.UploadImages(images, remoteMedia); err != nil {
+		t.Fatalf("TestLinkImages: upload images failed: %s", err)
+	}
+	if strings.Join(uploaded, ",") != "diagram 1.png" {
+		t.Fatalf("TestLinkImages: only the diagram should be uploaded, got %v", uploaded)
+	}
+
+	// broken images fail the blog
+	if err := os.Remove(filepath.Join(blogDir, "shared", "diagram 1.png")); err != nil {
+		t.Fatalf("TestLinkImages: remove image failed: %s", err)
+	}
+	if _, err := helper.LinkImages(blogs); err == nil {
+		t.Fatalf("TestLinkImages: missing image should fail")
+	}
+}
@@ -0,0 +1,362 @@
+package main
+
+import (
+	"blog/entities"
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// Actions of a plan entry
+const (
+	PlanCreate = "create"
+	PlanUpdate = "update"
+	PlanDelete = "delete"
+	PlanNoop   = "noop"
+	// changed locally and on the server, left alone
+	PlanConflict = "conflict"
+)
+
+// Kinds of a plan entry, in the order they are printed
+const (
+	PlanTopic  = "topic"
+	PlanTag    = "tag"
+	PlanSeries = "series"
+	PlanMedia  = "media"
+	PlanBlog   = "blog"
+)
+
+var (
+	ErrorDeleteNotConfirmed = errors.New("deletes need confirmation, run in a terminal or pass --yes")
+	ErrorSyncCanceled       = errors.New("sync canceled")
+)
+
+type SyncOptions struct {
+	Plan   bool   // only print the plan, don't change anything
+	JSON   bool   // print the plan as json
+	Yes    bool   // don't ask before deleting
+	Prefer string // how conflicts are resolved, local, remote or empty to resolve them by hand
+}
+
+type PlanEntry struct {
+	Kind   string `json:"kind"`
+	Action string `json:"action"`
+	// zero for created targets
+	ID int `json:"id,omitempty"`
+	// name of topics, tags and series, filename of local blogs, title of deleted blogs
+	Name string `json:"name"`
+	// field level changes of updates
+	Changes []string `json:"changes,omitempty"`
+	// unified diff of the content, from remote to local
+	Diff string `json:"diff,omitempty"`
+}
+
+type PlanSummary struct {
+	Create   int `json:"create"`
+	Update   int `json:"update"`
+	Delete   int `json:"delete"`
+	Noop     int `json:"noop"`
+	Conflict int `json:"conflict"`
+}
+
+type Plan struct {
+	Summary PlanSummary `json:"summary"`
+	Entries []PlanEntry `json:"entries"`
+}
+
+func NewPlan(entries ...[]PlanEntry) Plan {
+	plan := Plan{
+		Entries: slices.Concat(entries...),
+	}
+	for _, entry := range plan.Entries {
+		switch entry.Action {
+		case PlanCreate:
+			plan.Summary.Create++
+		case PlanUpdate:
+			plan.Summary.Update++
+		case PlanDelete:
+			plan.Summary.Delete++
+		case PlanNoop:
+			plan.Summary.Noop++
+		case PlanConflict:
+			plan.Summary.Conflict++
+		}
+	}
+	return plan
+}
+
+func (p Plan) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(p); err != nil {
+		return fmt.Errorf("WriteJSON: encode plan failed: %w", err)
+	}
+	return nil
+}
+
+// terraform style, unchanged targets are only counted
+func (p Plan) WriteText(w io.Writer) error {
+	builder := &strings.Builder{}
+	for _, entry := range p.Entries {
+		symbol := ""
+		switch entry.Action {
+		case PlanCreate:
+			symbol = "+"
+		case PlanUpdate:
+			symbol = "~"
+		case PlanDelete:
+			symbol = "-"
+		case PlanConflict:
+			symbol = "!"
+		default:
+			continue
+		}
+
+		fmt.Fprintf(builder, "  %s %s %q", symbol, entry.Kind, entry.Name)
+		if entry.ID != 0 {
+			fmt.Fprintf(builder, " (id: %d)", entry.ID)
+		}
+		builder.WriteString("\n")
+		for _, change := range entry.Changes {
+			fmt.Fprintf(builder, "      %s\n", change)
+		}
+		for _, line := range splitLines(entry.Diff) {
+			fmt.Fprintf(builder, "        %s\n", line)
+		}
+	}
+
+	if p.Summary.Create+p.Summary.Update+p.Summary.Delete+p.Summary.Conflict == 0 {
+		builder.WriteString("No changes, everything is in sync.\n")
+	} else {
+		fmt.Fprintf(
+			builder,
+			"\nPlan: %d to create, %d to update, %d to delete, %d unchanged, %d conflicts.\n",
+			p.Summary.Create, p.Summary.Update, p.Summary.Delete, p.Summary.Noop, p.Summary.Conflict,
+		)
+	}
+
+	if _, err := io.WriteString(w, builder.String()); err != nil {
+		return fmt.Errorf("WriteText: write plan failed: %w", err)
+	}
+	return nil
+}
+
+// fields shared by topics, tags and series
+type planTarget struct {
+	ID          int
+	Name        string
+	Description string
+}
+
+func planTargets(kind string, create, update, delete, noop []planTarget, remote []planTarget) []PlanEntry {
+	remoteMap := map[string]planTarget{}
+	for _, target := range remote {
+		remoteMap[target.Name] = target
+	}
+
+	result := []PlanEntry{}
+	for _, target := range create {
+		result = append(result, PlanEntry{Kind: kind, Action: PlanCreate, Name: target.Name})
+	}
+	for _, target := range update {
+		entry := PlanEntry{Kind: kind, Action: PlanUpdate, ID: target.ID, Name: target.Name}
+		if remoteMap[target.Name].Description != target.Description {
+			entry.Changes = append(entry.Changes, "description changed")
+		}
+		result = append(result, entry)
+	}
+	for _, target := range delete {
+		result = append(result, PlanEntry{Kind: kind, Action: PlanDelete, ID: target.ID, Name: target.Name})
+	}
+	for _, target := range noop {
+		result = append(result, PlanEntry{Kind: kind, Action: PlanNoop, ID: target.ID, Name: target.Name})
+	}
+
+	sortEntries(result)
+	return result
+}
+
+func planTags(grouped Groups[entities.Tag], remote []entities.Tag) []PlanEntry {
+	convert := func(tags []entities.Tag) []planTarget {
+		result := make([]planTarget, 0, len(tags))
+		for _, tag := range tags {
+			result = append(result, planTarget{tag.ID, tag.Name, tag.Description})
+		}
+		return result
+	}
+	return planTargets(PlanTag, convert(grouped.create), convert(grouped.update), convert(grouped.delete), convert(grouped.noop), convert(remote))
+}
+
+func planTopics(grouped Groups[entities.Topic], remote []entities.Topic) []PlanEntry {
+	convert := func(topics []entities.Topic) []planTarget {
+		result := make([]planTarget, 0, len(topics))
+		for _, topic := range topics {
+			result = append(result, planTarget{topic.ID, topic.Name, topic.Description})
+		}
+		return result
+	}
+	return planTargets(PlanTopic, convert(grouped.create), convert(grouped.update), convert(grouped.delete), convert(grouped.noop), convert(remote))
+}
+
+func planSeries(grouped Groups[entities.Series], remote []entities.Series) []PlanEntry {
+	convert := func(series []entities.Series) []planTarget {
+		result := make([]planTarget, 0, len(series))
+		for _, s := range series {
+			result = append(result, planTarget{s.ID, s.Name, s.Description})
+		}
+		return result
+	}
+	return planTargets(PlanSeries, convert(grouped.create), convert(grouped.update), convert(grouped.delete), convert(grouped.noop), convert(remote))
+}
+
+// contentDiff returns the unified diff of a blog whose content changed
+func planBlogs(
+	grouped BlogGroup[BlogInfo],
+	remote []entities.OutBlogSimple,
+	contentDiff func(local BlogInfo, remote entities.OutBlogSimple) (string, error),
+) ([]PlanEntry, error) {
+	remoteMap := map[int]entities.OutBlogSimple{}
+	for _, blog := range remote {
+		remoteMap[blog.ID] = blog
+	}
+
+	result := []PlanEntry{}
+	for _, blog := range grouped.create {
+		result = append(result, PlanEntry{Kind: PlanBlog, Action: PlanCreate, ID: blog.Frontmatter.ID, Name: blog.Filename})
+	}
+	for _, blog := range grouped.update {
+		remoteBlog := remoteMap[blog.Frontmatter.ID]
+		entry := PlanEntry{
+			Kind:    PlanBlog,
+			Action:  PlanUpdate,
+			ID:      blog.Frontmatter.ID,
+			Name:    blog.Filename,
+			Changes: blogChanges(blog, remoteBlog),
+		}
+		if blog.Content_md5 != remoteBlog.ContentMD5 {
+			diff, err := contentDiff(blog, remoteBlog)
+			if err != nil {
+				return []PlanEntry{}, fmt.Errorf("planBlogs: content diff failed for blog %q: %w", blog.Filename, err)
+			}
+			entry.Diff = diff
+		}
+		result = append(result, entry)
+	}
+	for _, blog := range grouped.delete {
+		result = append(result, PlanEntry{Kind: PlanBlog, Action: PlanDelete, ID: blog.ID, Name: blog.Title})
+	}
+	for _, blog := range grouped.noop {
+		result = append(result, PlanEntry{Kind: PlanBlog, Action: PlanNoop, ID: blog.Frontmatter.ID, Name: blog.Filename})
+	}
+
+	sortEntries(result)
+	return result, nil
+}
+
+// same fields as blogEqual, described
+func blogChanges(localBlog BlogInfo, remoteBlog entities.OutBlogSimple) []string {
+	local := localBlog.Frontmatter
+	changes := []string{}
+
+	if local.Title != remoteBlog.Title {
+		changes = append(changes, fmt.Sprintf("title %q -> %q", remoteBlog.Title, local.Title))
+	}
+	if local.Description != remoteBlog.Description {
+		changes = append(changes, "description changed")
+	}
+	if local.Pined != remoteBlog.Pined {
+		changes = append(changes, fmt.Sprintf("pined %t -> %t", remoteBlog.Pined, local.Pined))
+	}
+	if local.Visible != remoteBlog.Visible {
+		changes = append(changes, fmt.Sprintf("visible %t -> %t", remoteBlog.Visible, local.Visible))
+	}
+	if !slices.Equal(local.Tags, remoteBlog.Tags) {
+		changes = append(changes, "tags"+sliceChanges(remoteBlog.Tags, local.Tags))
+	}
+	if !slices.Equal(local.Topics, remoteBlog.Topics) {
+		changes = append(changes, "topics"+sliceChanges(remoteBlog.Topics, local.Topics))
+	}
+	if local.Series != remoteBlog.Series {
+		changes = append(changes, fmt.Sprintf("series %q -> %q", remoteBlog.Series, local.Series))
+	}
+	if local.Part != 0 && local.Part != remoteBlog.Part {
+		changes = append(changes, fmt.Sprintf("part %d -> %d", remoteBlog.Part, local.Part))
+	}
+	if localBlog.Content_md5 != remoteBlog.ContentMD5 {
+		changes = append(changes, "content changed")
+	}
+
+	return changes
+}
+
+// " +added -removed", or " reordered" when only the order is different
+func sliceChanges(from, to []string) string {
+	builder := &strings.Builder{}
+	for _, s := range to {
+		if !slices.Contains(from, s) {
+			builder.WriteString(" +" + s)
+		}
+	}
+	for _, s := range from {
+		if !slices.Contains(to, s) {
+			builder.WriteString(" -" + s)
+		}
+	}
+	if builder.Len() == 0 {
+		return " reordered"
+	}
+	return builder.String()
+}
+
+// by action then name, so the plan is stable between runs
+func sortEntries(entries []PlanEntry) {
+	order := map[string]int{PlanCreate: 0, PlanUpdate: 1, PlanDelete: 2, PlanConflict: 3, PlanNoop: 4}
+	slices.SortStableFunc(entries, func(a, b PlanEntry) int {
+		if order[a.Action] != order[b.Action] {
+			return order[a.Action] - order[b.Action]
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+}
+
+// unified diff from the content on the server to the local file
+func (s SyncHelper) ContentDiff(local BlogInfo, remote entities.OutBlogSimple) (string, error) {
+	slog.Debug("ContentDiff", "filename", local.Filename)
+
+	localContent, err := s.loadContent(local)
+	if err != nil {
+		return "", fmt.Errorf("ContentDiff: %w", err)
+	}
+	remoteBlog, err := s.GetBlog(remote.ID)
+	if err != nil {
+		return "", fmt.Errorf("ContentDiff: %w", err)
+	}
+
+	return unifiedDiff(remoteBlog.Content, localContent, "remote/"+strconv.Itoa(remote.ID), "local/"+local.Filename), nil
+}
+
+// asks before anything is deleted, only "yes" is accepted
+func confirmDeletes(plan Plan) error {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return ErrorDeleteNotConfirmed
+	}
+
+	fmt.Fprintf(os.Stderr, "\n%d targets will be deleted, only 'yes' will be accepted to continue: ", plan.Summary.Delete)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("confirmDeletes: read answer failed: %w", err)
+	}
+	if strings.TrimSpace(answer) != "yes" {
+		return ErrorSyncCanceled
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"blog/entities"
+	"slices"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	if diff := unifiedDiff("a\nb\n", "a\nb\n", "remote", "local"); diff != "" {
+		t.Fatalf("TestUnifiedDiff: same content should have no diff, got %q", diff)
+	}
+
+	from := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"
+	to := "1\n2\nthree\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n"
+	expected := "--- remote\n+++ local\n" +
+		"@@ -1,6 +1,6 @@\n 1\n 2\n-3\n+three\n 4\n 5\n 6\n" +
+		"@@ -10,3 +10,4 @@\n 10\n 11\n 12\n+13\n"
+	if diff := unifiedDiff(from, to, "remote", "local"); diff != expected {
+		t.Fatalf("TestUnifiedDiff: expected\n%s\ngot\n%s", expected, diff)
+	}
+
+	expected = "--- remote\n+++ local\n@@ -0,0 +1,1 @@\n+new\n"
+	if diff := unifiedDiff("", "new\n", "remote", "local"); diff != expected {
+		t.Fatalf("TestUnifiedDiff: expected\n%s\ngot\n%s", expected, diff)
+	}
+}
+
+func TestPlanBlogs(t *testing.T) {
+	remote := []entities.OutBlogSimple{
+		{Blog: entities.Blog{ID: 1, Title: "one", ContentMD5: "a"}, Tags: []string{"bar", "go"}},
+		{Blog: entities.Blog{ID: 2, Title: "two", ContentMD5: "b"}},
+		{Blog: entities.Blog{ID: 3, Title: "three", ContentMD5: "c"}},
+	}
+	local := []BlogInfo{
+		{Frontmatter: BlogFrontmatter{ID: 1, Title: "one", Description: "new", Tags: []string{"go", "foo"}}, Content_md5: "changed", Filename: "one.md"},
+		{Frontmatter: BlogFrontmatter{ID: 2, Title: "two"}, Content_md5: "b", Filename: "two.md"},
+		{Frontmatter: BlogFrontmatter{Title: "four"}, Content_md5: "d", Filename: "four.md"},
+	}
+	grouped, err := groupBlogs(local, remote)
+	if err != nil {
+		t.Fatalf("TestPlanBlogs: group blogs failed: %s", err)
+	}
+
+	diffed := []string{}
+	entries, err := planBlogs(grouped, remote, func(local BlogInfo, remote entities.OutBlogSimple) (string, error) {
+		diffed = append(diffed, local.Filename)
+		return "diff", nil
+	})
+	if err != nil {
+		t.Fatalf("TestPlanBlogs: plan blogs failed: %s", err)
+	}
+
+	expected := []PlanEntry{
+		{Kind: PlanBlog, Action: PlanCreate, Name: "four.md"},
+		{Kind: PlanBlog, Action: PlanUpdate, ID: 1, Name: "one.md", Changes: []string{"description changed", "tags +foo -bar", "content changed"}, Diff: "diff"},
+		{Kind: PlanBlog, Action: PlanDelete, ID: 3, Name: "three"},
+		{Kind: PlanBlog, Action: PlanNoop, ID: 2, Name: "two.md"},
+	}
+	if len(entries) != len(expected) {
+		t.Fatalf("TestPlanBlogs: expected %d entries, got %+v", len(expected), entries)
+	}
+	for i, entry := range entries {
+		e := expected[i]
+		if entry.Kind != e.Kind || entry.Action != e.Action || entry.ID != e.ID || entry.Name != e.Name ||
+			!slices.Equal(entry.Changes, e.Changes) || entry.Diff != e.Diff {
+			t.Fatalf("TestPlanBlogs: entry %d expected %+v, got %+v", i, e, entry)
+		}
+	}
+	if !slices.Equal(diffed, []string{"one.md"}) {
+		t.Fatalf("TestPlanBlogs: only changed content should be diffed, got %v", diffed)
+	}
+
+	plan := NewPlan(entries)
+	if plan.Summary != (PlanSummary{Create: 1, Update: 1, Delete: 1, Noop: 1}) {
+		t.Fatalf("TestPlanBlogs: unexpected summary %+v", plan.Summary)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Auth methods of a profile
+const (
+	// ask for missing username and password in the terminal
+	AuthPrompt = "prompt"
+	// only use --username and --password or their enviroment variables, for CI
+	AuthEnv = "env"
+)
+
+// directory of the config file and cached tokens, under the user config and cache directories
+const configDirName = "coding-notes-sync"
+
+var (
+	ErrorProfileNotFound = errors.New("profile not found")
+	ErrorInvalidProfile  = errors.New("invalid profile")
+)
+
+// profile names are used as filenames of cached tokens
+var profileNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Settings of a server to sync to, flags given on the command line take precedence
+type Profile struct {
+	URL       string `yaml:"url"`
+	Source    string `yaml:"source"`
+	BatchSize int    `yaml:"batchSize"`
+	// prompt or env, prompt if empty
+	Auth     string `yaml:"auth"`
+	Username string `yaml:"username"`
+}
+
+type ProfileConfig struct {
+	Profiles map[string]Profile `yaml:"profiles"`
+}
+
+// default path of the config file
+func defaultConfigFile() string {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		slog.Debug("get config dir failed", "error", err)
+		return ""
+	}
+	return filepath.Join(configDir, configDirName, "config.yaml")
+}
+
+// loads a profile by name, relative source paths are relative to the config file
+func loadProfile(configFile, name string) (Profile, error) {
+	slog.Info("loadProfile", "filename", configFile, "profile", name)
+
+	if !profileNameRegexp.MatchString(name) {
+		return Profile{}, fmt.Errorf("loadProfile: %w, name %q should only contain letters, numbers, '-' and '_'", ErrorInvalidProfile, name)
+	}
+
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		return Profile{}, fmt.Errorf("loadProfile: read config file failed: %w", err)
+	}
+	config := ProfileConfig{}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return Profile{}, fmt.Errorf("loadProfile: yaml unmarshal failed: %w", err)
+	}
+
+	profile, ok := config.Profiles[name]
+	if !ok {
+		return Profile{}, fmt.Errorf("loadProfile: %w: %q in %s", ErrorProfileNotFound, name, configFile)
+	}
+
+	switch profile.Auth {
+	case "":
+		profile.Auth = AuthPrompt
+	case AuthPrompt, AuthEnv:
+	default:
+		return Profile{}, fmt.Errorf("loadProfile: %w, auth should be %s or %s, got %q", ErrorInvalidProfile, AuthPrompt, AuthEnv, profile.Auth)
+	}
+	if profile.BatchSize < 0 {
+		return Profile{}, fmt.Errorf("loadProfile: %w, batchSize should be positive, got %d", ErrorInvalidProfile, profile.BatchSize)
+	}
+	if profile.Source != "" && !filepath.IsAbs(profile.Source) {
+		profile.Source = filepath.Join(filepath.Dir(configFile), profile.Source)
+	}
+
+	slog.Debug("loaded profile", "profile", profile)
+	return profile, nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"blog/entities"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestLoadProfile(t *testing.T) {
+	configDir := t.TempDir()
+	configFile := filepath.Join(configDir, "config.yaml")
+	config := "profiles:\n" +
+		"  local:\n    url: http://localhost:8080/api/v1\n    source: notes\n" +
+		"  prod:\n    url: https://example.com/api/v1\n    source: /srv/notes\n    batchSize: 10\n    auth: env\n    username: alex\n" +
+		"  broken:\n    auth: password\n"
+	if err := os.WriteFile(configFile, []byte(config), 0644); err != nil {
+		t.Fatalf("TestLoadProfile: write config failed: %s", err)
+	}
+
+	local, err := loadProfile(configFile, "local")
+	if err != nil {
+		t.Fatalf("TestLoadProfile: load local failed: %s", err)
+	}
+	if local.Source != filepath.Join(configDir, "notes") || local.Auth != AuthPrompt || local.BatchSize != 0 {
+		t.Fatalf("TestLoadProfile: unexpected local profile %+v", local)
+	}
+
+	prod, err := loadProfile(configFile, "prod")
+	if err != nil {
+		t.Fatalf("TestLoadProfile: load prod failed: %s", err)
+	}
+	expected := Profile{URL: "https://example.com/api/v1", Source: "/srv/notes", BatchSize: 10, Auth: AuthEnv, Username: "alex"}
+	if prod != expected {
+		t.Fatalf("TestLoadProfile: expected %+v, got %+v", expected, prod)
+	}
+
+	if _, err := loadProfile(configFile, "staging"); !errors.Is(err, ErrorProfileNotFound) {
+		t.Fatalf("TestLoadProfile: expected profile not found, got %v", err)
+	}
+	if _, err := loadProfile(configFile, "broken"); !errors.Is(err, ErrorInvalidProfile) {
+		t.Fatalf("TestLoadProfile: expected invalid auth, got %v", err)
+	}
+	if _, err := loadProfile(configFile, "../local"); !errors.Is(err, ErrorInvalidProfile) {
+		t.Fatalf("TestLoadProfile: expected invalid name, got %v", err)
+	}
+}
+
+func TestLoginTokenCache(t *testing.T) {
+	newToken := func(expiresAt time.Time) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"exp": expiresAt.Unix()}).SignedString([]byte("secret"))
+		if err != nil {
+			t.Fatalf("TestLoginTokenCache: sign token failed: %s", err)
+		}
+		return token
+	}
+	validToken := newToken(time.Now().Add(time.Hour))
+	loginToken := newToken(time.Now().Add(2 * time.Hour))
+
+	logins := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			logins++
+			json.NewEncoder(w).Encode(entities.NewRetSuccess(*entities.NewJWT(loginToken)))
+		case "/auth-check":
+			if r.Header.Get("Authorization") != "Bearer "+validToken {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			json.NewEncoder(w).Encode(entities.NewRetSuccess("pass"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	tokenFile := filepath.Join(t.TempDir(), "tokens", "local.json")
+	cred := Credentials{Username: "user", Password: "password", NoPrompt: true, TokenFile: tokenFile}
+	cachedLogin := func() string {
+		token, err := login(context.Background(), make(chan bool, 1), server.URL, cred)
+		if err != nil {
+			t.Fatalf("TestLoginTokenCache: login failed: %s", err)
+		}
+		return token
+	}
+
+	// nothing cached, the new token is saved for the user only
+	if token := cachedLogin(); token != loginToken || logins != 1 {
+		t.Fatalf("TestLoginTokenCache: expected a login, got %d logins", logins)
+	}
+	info, err := os.Stat(tokenFile)
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("TestLoginTokenCache: token file should be 0600, got %v %v", info, err)
+	}
+
+	// rejected by the server
+	if token := cachedLogin(); token != loginToken || logins != 2 {
+		t.Fatalf("TestLoginTokenCache: rejected token should login again, got %d logins", logins)
+	}
+
+	// accepted
+	if err := saveJWT(tokenFile, server.URL, "user", validToken); err != nil {
+		t.Fatalf("TestLoginTokenCache: save token failed: %s", err)
+	}
+	if token := cachedLogin(); token != validToken || logins != 2 {
+		t.Fatalf("TestLoginTokenCache: cached token should be used, got %d logins", logins)
+	}
+
+	// about to expire
+	if err := saveJWT(tokenFile, server.URL, "user", newToken(time.Now().Add(time.Minute))); err != nil {
+		t.Fatalf("TestLoginTokenCache: save token failed: %s", err)
+	}
+	if token := cachedLogin(); token != loginToken || logins != 3 {
+		t.Fatalf("TestLoginTokenCache: expiring token should login again, got %d logins", logins)
+	}
+
+	// issued by another server or to another user, never sent to this server
+	if err := saveJWT(tokenFile, "http://other.example", "user", validToken); err != nil {
+		t.Fatalf("TestLoginTokenCache: save token failed: %s", err)
+	}
+	if token := cachedLogin(); token != loginToken || logins != 4 {
+		t.Fatalf("TestLoginTokenCache: token of another url should login again, got %d logins", logins)
+	}
+	if err := saveJWT(tokenFile, server.URL, "other", validToken); err != nil {
+		t.Fatalf("TestLoginTokenCache: save token failed: %s", err)
+	}
+	if token := cachedLogin(); token != loginToken || logins != 5 {
+		t.Fatalf("TestLoginTokenCache: token of another username should login again, got %d logins", logins)
+	}
+	// the new token is saved with the url and username it was issued for
+	data, err := os.ReadFile(tokenFile)
+	if err != nil {
+		t.Fatalf("TestLoginTokenCache: read token file failed: %s", err)
+	}
+	cached := cachedToken{}
+	if err := json.Unmarshal(data, &cached); err != nil || cached != (cachedToken{URL: server.URL, Username: "user", Token: loginToken}) {
+		t.Fatalf("TestLoginTokenCache: unexpected token file %s, %v", data, err)
+	}
+
+	// env auth never prompts
+	cred = Credentials{NoPrompt: true}
+	if _, err := login(context.Background(), make(chan bool, 1), server.URL, cred); !errors.Is(err, ErrorNoCredentials) {
+		t.Fatalf("TestLoginTokenCache: expected missing credentials, got %v", err)
+	}
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"blog/entities"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// export the server state into a local source tree, the reverse of syncAll.
+// existing files are kept unless overwrite is set.
+func pullAll(
+	ctx context.Context,
+	credentials Credentials,
+	baseURL,
+	sourcePath string,
+	batchSize int,
+	overwrite bool) error {
+	slog.Info("pullAll")
+
+	loginDone := make(chan bool, 1)
+	processDone := make(chan bool, 1)
+	processErr := make(chan error, 1)
+
+	go func() {
+		// login
+		jwt, err := login(ctx, loginDone, baseURL, credentials)
+		fmt.Printf("\n")
+		if err != nil {
+			processErr <- fmt.Errorf("pullAll: login failed: %w", err)
+			return
+		}
+		slog.Debug("got jwt", "token", jwt)
+
+		syncHelper := NewSyncHelper(ctx, baseURL, jwt, batchSize, sourcePath)
+
+		// get data from server
+		tags, err := syncHelper.GetAllTags()
+		if err != nil {
+			processErr <- fmt.Errorf("pullAll: failed to get tags from server: %w", err)
+			return
+		}
+
+		topics, err := syncHelper.GetAllTopics()
+		if err != nil {
+			processErr <- fmt.Errorf("pullAll: failed to get topics from server: %w", err)
+			return
+		}
+
+		series, err := syncHelper.GetAllSeries()
+		if err != nil {
+			processErr <- fmt.Errorf("pullAll: failed to get series from server: %w", err)
+			return
+		}
+
+		simpleBlogs, err := syncHelper.GetAllBlogs()
+		if err != nil {
+			processErr <- fmt.Errorf("pullAll: failed to get blogs from server: %w", err)
+			return
+		}
+
+		// the list has no content, get blogs one by one.
+		// blogs in the trash are left out, they are deleted on the next sync.
+		blogIDs := []int{}
+		simpleBlogMap := map[int]entities.OutBlogSimple{}
+		for _, blog := range simpleBlogs {
+			if blog.Deleted_at != "" {
+				slog.Info("blog is in the trash, skipping", "id", blog.ID, "title", blog.Title)
+				continue
+			}
+			blogIDs = append(blogIDs, blog.ID)
+			simpleBlogMap[blog.ID] = blog
+		}
+		blogs, err := syncHelper.GetBlogs(blogIDs)
+		if err != nil {
+			processErr <- fmt.Errorf("pullAll: failed to get blog contents from server: %w", err)
+			return
+		}
+
+		// write meta file
+		if err := writeMetaFile(filepath.Join(sourcePath, "meta.yaml"), topics, tags, series, overwrite); err != nil {
+			processErr <- fmt.Errorf("pullAll: write meta file failed: %w", err)
+			return
+		}
+
+		// write blogs
+		idMap, err := loadIDMap(filepath.Join(sourcePath, "ids.json"))
+		if err != nil {
+			processErr <- fmt.Errorf("pullAll: load id map failed: %w", err)
+			return
+		}
+
+		blogDir := filepath.Join(sourcePath, "blogs")
+		if err := os.MkdirAll(blogDir, 0755); err != nil {
+			processErr <- fmt.Errorf("pullAll: create blog dir failed: %w", err)
+			return
+		}
+
+		stateFile := filepath.Join(sourcePath, "sync-state.json")
+		state, err := loadSyncState(stateFile)
+		if err != nil {
+			processErr <- fmt.Errorf("pullAll: load sync state failed: %w", err)
+			return
+		}
+
+		for _, blog := range blogs {
+			filename := blogFilename(blog, idMap)
+			written, err := writeBlogFile(filepath.Join(blogDir, filename), blog, simpleBlogMap[blog.ID], overwrite)
+			if err != nil {
+				processErr <- fmt.Errorf("pullAll: write blog failed: %w", err)
+				return
+			}
+			// a kept file is only mapped if it already was, it might be another blog.
+			// written files are the base of the next three way check
+			if written {
+				idMap[filename] = blog.ID
+				if err := state.record(blogDir, filename, simpleBlogMap[blog.ID]); err != nil {
+					processErr <- fmt.Errorf("pullAll: record sync state failed: %w", err)
+					return
+				}
+			}
+		}
+
+		if err := state.save(stateFile); err != nil {
+			processErr <- fmt.Errorf("pullAll: save sync state failed: %w", err)
+			return
+		}
+
+		// update blog id mapping
+		if err := updateIDMapping([]BlogInfo{}, idMap, filepath.Join(sourcePath, "ids.json")); err != nil {
+			processErr <- fmt.Errorf("pullAll: updated id mapping failed: %w", err)
+			return
+		}
+
+		processDone <- true
+	}()
+
+	select {
+	case <-ctx.Done():
+		slog.Warn("got done")
+		<-loginDone
+		return nil
+	case <-processDone:
+		return nil
+	case err := <-processErr:
+		return err
+	}
+}
+
+// the file ids.json maps to the blog, or one named after its slug.
+// the slug gets an id suffix if the name is mapped to another blog.
+func blogFilename(blog entities.OutBlog, idMap map[string]int) string {
+	for filename, id := range idMap {
+		if id == blog.ID {
+			return filename
+		}
+	}
+
+	filename := blog.Slug + ".md"
+	if _, ok := idMap[filename]; ok {
+		filename = fmt.Sprintf("%s-%d.md", blog.Slug, blog.ID)
+	}
+	return filename
+}
+
+// returns false if the file already exists and is kept
+func writeFile(targetFile string, data []byte, overwrite bool) (bool, error) {
+	if !overwrite {
+		_, err := os.Stat(targetFile)
+		if err == nil {
+			slog.Warn("file exists, keeping it", "file", targetFile)
+			return false, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return false, fmt.Errorf("writeFile: stat %q failed: %w", targetFile, err)
+		}
+	}
+
+	if err := os.WriteFile(targetFile, data, 0644); err != nil {
+		return false, fmt.Errorf("writeFile: write %q failed: %w", targetFile, err)
+	}
+	slog.Debug("wrote file", "file", targetFile)
+	return true, nil
+}
+
+func writeMetaFile(metaFile string, topics []entities.Topic, tags []entities.Tag, series []entities.Series, overwrite bool) error {
+	slog.Info("writeMetaFile")
+
+	data := MetaFileContent{
+		Topics: make([]entities.InTopic, 0, len(topics)),
+		Tags:   make([]entities.InTag, 0, len(tags)),
+		Series: make([]entities.InSeries, 0, len(series)),
+	}
+	for _, topic := range topics {
+		data.Topics = append(data.Topics, entities.NewInTopic(topic.Name, topic.Description))
+	}
+	for _, tag := range tags {
+		data.Tags = append(data.Tags, *entities.NewInTag(tag.Name, tag.Description))
+	}
+	for _, s := range series {
+		data.Series = append(data.Series, entities.NewInSeries(s.Name, s.Description))
+	}
+
+	byteData, err := yaml.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("writeMetaFile: yaml marshal failed: %w", err)
+	}
+	if _, err := writeFile(metaFile, byteData, overwrite); err != nil {
+		return fmt.Errorf("writeMetaFile: %w", err)
+	}
+	return nil
+}
+
+// frontmatter between '---', followed by the content as it is on the server,
+// so loadBlogs reads back the same content.
+// tags and topics follow the order of the simple blog, which blogEqual compares with.
+func writeBlogFile(targetFile string, blog entities.OutBlog, simple entities.OutBlogSimple, overwrite bool) (bool, error) {
+	frontmatter := BlogFrontmatter{
+		Title:       blog.Title,
+		Description: blog.Description,
+		Pined:       blog.Pined,
+		Visible:     blog.Visible,
+		Tags:        make([]string, 0, len(simple.Tags)),
+		Topics:      make([]string, 0, len(simple.Topics)),
+	}
+	tagNames := map[string]string{}
+	for _, tag := range blog.Tags {
+		tagNames[tag.Slug] = tag.Name
+	}
+	for _, tag := range simple.Tags {
+		frontmatter.Tags = append(frontmatter.Tags, tagNames[tag])
+	}
+	topicNames := map[string]string{}
+	for _, topic := range blog.Topics {
+		topicNames[topic.Slug] = topic.Name
+	}
+	for _, topic := range simple.Topics {
+		frontmatter.Topics = append(frontmatter.Topics, topicNames[topic])
+	}
+	if blog.Series != nil {
+		frontmatter.Series = blog.Series.Name
+		frontmatter.Part = blog.Series.Part
+	}
+
+	header, err := yaml.Marshal(frontmatter)
+	if err != nil {
+		return false, fmt.Errorf("writeBlogFile: yaml marshal failed for blog (id: %d): %w", blog.ID, err)
+	}
+
+	data := &bytes.Buffer{}
+	data.WriteString("---\n")
+	data.Write(header)
+	data.WriteString("---")
+	if !strings.HasPrefix(blog.Content, "\n") {
+		data.WriteString("\n")
+	}
+	data.WriteString(blog.Content)
+
+	if err := os.MkdirAll(filepath.Dir(targetFile), 0755); err != nil {
+		return false, fmt.Errorf("writeBlogFile: create dir failed: %w", err)
+	}
+	written, err := writeFile(targetFile, data.Bytes(), overwrite)
+	if err != nil {
+		return false, fmt.Errorf("writeBlogFile: %w", err)
+	}
+	return written, nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"blog/entities"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPullRoundTrip(t *testing.T) {
+	sourcePath := t.TempDir()
+	dir := filepath.Join(sourcePath, "blogs")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatalf("TestPullRoundTrip: create blog dir failed: %s", err)
+	}
+
+	blog := entities.OutBlog{
+		Blog: entities.Blog{
+			ID:          3,
+			Title:       "Hello World",
+			Slug:        "hello-world",
+			Description: "first",
+			Content:     "\n\n## Hello\nworld\n",
+			Visible:     true,
+		},
+		Tags:   []entities.Tag{*entities.NewTag("Go", ""), *entities.NewTag("Tips", "")},
+		Topics: []entities.Topic{*entities.NewTopic("Backend", "")},
+		Series: &entities.BlogSeries{Series: *entities.NewSeries("Basics", ""), Part: 2},
+	}
+	blog.GenMD5()
+	simple := entities.NewOutBlogSimple(blog.Blog, []string{"tips", "go"}, []string{"backend"})
+	simple.Series, simple.Part = "basics", 2
+
+	idMap := map[string]int{"other.md": 1}
+	filename := blogFilename(blog, idMap)
+	if filename != "hello-world.md" {
+		t.Fatalf("TestPullRoundTrip: filename should come from the slug, got %q", filename)
+	}
+	if name := blogFilename(blog, map[string]int{"hello-world.md": 1}); name != "hello-world-3.md" {
+		t.Fatalf("TestPullRoundTrip: filename taken by another blog should get the id, got %q", name)
+	}
+	if name := blogFilename(blog, map[string]int{"old-name.md": 3}); name != "old-name.md" {
+		t.Fatalf("TestPullRoundTrip: mapped filename should be kept, got %q", name)
+	}
+
+	written, err := writeBlogFile(filepath.Join(dir, filename), blog, simple, false)
+	if err != nil || !written {
+		t.Fatalf("TestPullRoundTrip: write blog failed: %t %v", written, err)
+	}
+
+	blogs, err := loadBlogs(dir, map[string]int{filename: blog.ID})
+	if err != nil || len(blogs) != 1 {
+		t.Fatalf("TestPullRoundTrip: load blogs failed: %d %v", len(blogs), err)
+	}
+	if !blogEqual(blogs[0], simple) {
+		t.Fatalf("TestPullRoundTrip: loaded blog should equal the server one, got %+v", blogs[0])
+	}
+	if changes := blogChanges(blogs[0], simple); len(changes) != 0 {
+		t.Fatalf("TestPullRoundTrip: no changes expected, got %v", changes)
+	}
+	content, err := NewSyncHelper(context.Background(), "", "", 1, sourcePath).loadContent(blogs[0])
+	if err != nil || content != blog.Content {
+		t.Fatalf("TestPullRoundTrip: content should be sent back unchanged, got %q %v", content, err)
+	}
+
+	// kept without overwrite
+	if err := os.WriteFile(filepath.Join(dir, filename), []byte("local"), 0644); err != nil {
+		t.Fatalf("TestPullRoundTrip: write local file failed: %s", err)
+	}
+	if written, err := writeBlogFile(filepath.Join(dir, filename), blog, simple, false); err != nil || written {
+		t.Fatalf("TestPullRoundTrip: existing file should be kept: %t %v", written, err)
+	}
+	if written, err := writeBlogFile(filepath.Join(dir, filename), blog, simple, true); err != nil || !written {
+		t.Fatalf("TestPullRoundTrip: existing file should be overwritten: %t %v", written, err)
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Retry-After longer than this is cut short, a sync shouldn't hang for hours
+const maxRetryAfter = 2 * time.Minute
+
+var ErrorBodyNotRewindable = errors.New("request body can't be sent again")
+
+/*
+Sends requests with retries on 429, 5xx and network errors.
+
+Waits with exponential backoff and full jitter between attempts,
+or as long as the Retry-After header of the response says.
+Each attempt has its own timeout from the wrapped client.
+*/
+type RetryClient struct {
+	client *http.Client
+	// retries after the first attempt, 0 disables retrying
+	retries   int
+	baseDelay time.Duration
+	maxDelay  time.Duration
+	// waits between attempts, replaced by tests
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+func NewRetryClient(client *http.Client, retries int) *RetryClient {
+	return &RetryClient{
+		client:    client,
+		retries:   retries,
+		baseDelay: 500 * time.Millisecond,
+		maxDelay:  30 * time.Second,
+		sleep:     sleepContext,
+	}
+}
+
+func (c *RetryClient) Get(url string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Get: create new request failed: %w", err)
+	}
+	return c.Do(req)
+}
+
+// the request body is sent again with req.GetBody, which http.NewRequest sets for in memory bodies
+func (c *RetryClient) Do(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.Body != nil && req.Body != http.NoBody {
+			if req.GetBody == nil {
+				return nil, fmt.Errorf("Do: %w", ErrorBodyNotRewindable)
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("Do: rewind body failed: %w", err)
+			}
+			req.Body = body
+		}
+
+		res, err := c.client.Do(req)
+		if !shouldRetry(req, res, err) || attempt >= c.retries {
+			return res, err
+		}
+
+		delay := c.backoff(attempt)
+		if res != nil {
+			if retryAfter, ok := parseRetryAfter(res.Header.Get("Retry-After"), time.Now()); ok {
+				delay = min(retryAfter, maxRetryAfter)
+			}
+			slog.Warn("request failed, retrying", "method", req.Method, "url", req.URL.String(), "status", res.StatusCode, "attempt", attempt+1, "delay", delay)
+			if err := drainAndClose(res.Body); err != nil {
+				slog.Debug("drain failed response failed", "error", err)
+			}
+		} else {
+			slog.Warn("request failed, retrying", "method", req.Method, "url", req.URL.String(), "error", err, "attempt", attempt+1, "delay", delay)
+		}
+
+		if err := c.sleep(req.Context(), delay); err != nil {
+			return nil, fmt.Errorf("Do: %w", err)
+		}
+	}
+}
+
+// random delay up to baseDelay * 2^attempt, capped by maxDelay
+func (c *RetryClient) backoff(attempt int) time.Duration {
+	ceiling := c.maxDelay
+	if attempt < 32 {
+		ceiling = min(c.baseDelay<<attempt, c.maxDelay)
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return rand.N(ceiling) + 1
+}
+
+func shouldRetry(req *http.Request, res *http.Response, err error) bool {
+	if err != nil {
+		// canceled by the caller, not the network
+		return req.Context().Err() == nil
+	}
+	return res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500
+}
+
+// Retry-After is either seconds or an http date
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
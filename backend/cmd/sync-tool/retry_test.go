@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetryClient(t *testing.T) {
+	attempts := 0
+	bodies := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		switch {
+		case r.URL.Path == "/forbidden":
+			w.WriteHeader(http.StatusForbidden)
+		case attempts == 1:
+			w.Header().Set("Retry-After", "3")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case attempts == 2:
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	delays := []time.Duration{}
+	client := NewRetryClient(server.Client(), 2)
+	client.sleep = func(ctx context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return nil
+	}
+
+	// the body is sent again on each attempt
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/blogs", strings.NewReader("data"))
+	if err != nil {
+		t.Fatalf("TestRetryClient: new request failed: %s", err)
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("TestRetryClient: request failed: %s", err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK || attempts != 3 {
+		t.Fatalf("TestRetryClient: should succeed on the third attempt, got status %d after %d attempts", res.StatusCode, attempts)
+	}
+	for _, body := range bodies {
+		if body != "data" {
+			t.Fatalf("TestRetryClient: body should be sent on each attempt, got %q", bodies)
+		}
+	}
+	if len(delays) != 2 || delays[0] != 3*time.Second {
+		t.Fatalf("TestRetryClient: should wait as long as Retry-After says, got %v", delays)
+	}
+	if delays[1] <= 0 || delays[1] > 2*client.baseDelay {
+		t.Fatalf("TestRetryClient: backoff of the second retry should be within %s, got %s", 2*client.baseDelay, delays[1])
+	}
+
+	// out of retries, the last response is returned
+	attempts = 0
+	client.retries = 1
+	res, err = client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("TestRetryClient: request failed: %s", err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusTooManyRequests || attempts != 2 {
+		t.Fatalf("TestRetryClient: should give up after one retry, got status %d after %d attempts", res.StatusCode, attempts)
+	}
+
+	// client errors aren't retried
+	attempts = 0
+	client.retries = 2
+	res, err = client.Get(server.URL + "/forbidden")
+	if err != nil {
+		t.Fatalf("TestRetryClient: request failed: %s", err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusForbidden || attempts != 1 {
+		t.Fatalf("TestRetryClient: 403 shouldn't be retried, got %d attempts", attempts)
+	}
+
+	// canceled while waiting
+	attempts = 0
+	client.sleep = sleepContext
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatalf("TestRetryClient: new request failed: %s", err)
+	}
+	if _, err := client.Do(req); !errors.Is(err, context.Canceled) {
+		t.Fatalf("TestRetryClient: canceled request should fail with context.Canceled, got %v", err)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		value    string
+		expected time.Duration
+		ok       bool
+	}{
+		{"", 0, false},
+		{"120", 2 * time.Minute, true},
+		{"-1", 0, false},
+		{"soon", 0, false},
+		{now.Add(10 * time.Second).Format(http.TimeFormat), 10 * time.Second, true},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0, true},
+	}
+	for _, c := range cases {
+		got, ok := parseRetryAfter(c.value, now)
+		if got != c.expected || ok != c.ok {
+			t.Fatalf("TestParseRetryAfter: %q should be %s %t, got %s %t", c.value, c.expected, c.ok, got, ok)
+		}
+	}
+}
+
+func TestJournal(t *testing.T) {
+	sourcePath := t.TempDir()
+	journalFile := filepath.Join(sourcePath, "sync-journal.json")
+
+	journal, err := loadJournal(journalFile)
+	if err != nil {
+		t.Fatalf("TestJournal: load new journal failed: %s", err)
+	}
+	if journal.RunID == "" {
+		t.Fatalf("TestJournal: new journal should have a run id")
+	}
+	if _, err := os.Stat(journalFile); err == nil {
+		t.Fatalf("TestJournal: new journal shouldn't be saved before the sync starts")
+	}
+	key := journal.IdempotencyKey("create blog a.md 1")
+	if key == journal.IdempotencyKey("create blog b.md 1") {
+		t.Fatalf("TestJournal: different operations should get different keys")
+	}
+	if len(key) > 255 {
+		t.Fatalf("TestJournal: key should fit the server limit, got %d characters", len(key))
+	}
+
+	if err := journal.Save(); err != nil {
+		t.Fatalf("TestJournal: save failed: %s", err)
+	}
+	written := BlogState{ID: 1, UpdatedAt: "2024-02-01T00:00:00+00:00", ContentMD5: "c", FileMD5: "f"}
+	if err := journal.RecordBlog("a.md", written); err != nil {
+		t.Fatalf("TestJournal: record blog failed: %s", err)
+	}
+
+	// interrupted, the next sync resumes with the same run
+	resumed, err := loadJournal(journalFile)
+	if err != nil {
+		t.Fatalf("TestJournal: load interrupted journal failed: %s", err)
+	}
+	if resumed.RunID != journal.RunID || resumed.IdempotencyKey("create blog a.md 1") != key {
+		t.Fatalf("TestJournal: resumed sync should keep the run id %q, got %q", journal.RunID, resumed.RunID)
+	}
+	state := SyncState{
+		"a.md": {ID: 1, UpdatedAt: "2024-01-01T00:00:00+00:00", FileMD5: "old"},
+		"b.md": {ID: 2, UpdatedAt: "2024-01-01T00:00:00+00:00", FileMD5: "b"},
+	}
+	resumed.MergeState(state)
+	if state["a.md"] != written || state["b.md"].FileMD5 != "b" {
+		t.Fatalf("TestJournal: written blogs should replace their state, got %+v", state)
+	}
+
+	if err := resumed.Remove(); err != nil {
+		t.Fatalf("TestJournal: remove failed: %s", err)
+	}
+	if _, err := os.Stat(journalFile); err == nil {
+		t.Fatalf("TestJournal: journal should be removed")
+	}
+
+	// pull and watch don't keep a journal
+	var none *Journal
+	if none.IdempotencyKey("create tag a") != "" || none.RecordBlog("a.md", written) != nil || none.Remove() != nil {
+		t.Fatalf("TestJournal: nil journal should do nothing")
+	}
+
+	// ids are saved one by one
+	idFile := filepath.Join(sourcePath, "ids.json")
+	if err := saveID(idFile, "a.md", 1); err != nil {
+		t.Fatalf("TestJournal: save id failed: %s", err)
+	}
+	if err := saveID(idFile, "b.md", 2); err != nil {
+		t.Fatalf("TestJournal: save id failed: %s", err)
+	}
+	idMap, err := loadIDMap(idFile)
+	if err != nil {
+		t.Fatalf("TestJournal: load ids failed: %s", err)
+	}
+	if len(idMap) != 2 || idMap["a.md"] != 1 || idMap["b.md"] != 2 {
+		t.Fatalf("TestJournal: both ids should be saved, got %v", idMap)
+	}
+
+	// files are replaced by renaming a temp file, none is left behind
+	entries, err := os.ReadDir(sourcePath)
+	if err != nil {
+		t.Fatalf("TestJournal: read dir failed: %s", err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "ids.json" {
+		t.Fatalf("TestJournal: only ids.json should be left, got %v", entries)
+	}
+	if info, err := os.Stat(idFile); err != nil || info.Mode().Perm() != 0644 {
+		t.Fatalf("TestJournal: ids.json should keep its mode, got %v %v", info, err)
+	}
+}
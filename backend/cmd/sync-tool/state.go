@@ -0,0 +1,253 @@
+package main
+
+import (
+	"blog/entities"
+	"crypto/md5"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	PreferLocal  = "local"
+	PreferRemote = "remote"
+
+	// suffix of the server version of a conflicting blog, written next to the local file
+	remoteSuffix = ".remote.md"
+)
+
+var (
+	ErrorInvalidPrefer = errors.New("prefer should be local or remote")
+	ErrorConflicts     = errors.New("blogs changed locally and on the server since the last sync")
+)
+
+// A blog as it was after the last sync, the base of the three way check
+type BlogState struct {
+	ID         int    `json:"id"`
+	UpdatedAt  string `json:"updatedAt"`  // on the server
+	ContentMD5 string `json:"contentMD5"` // on the server
+	FileMD5    string `json:"fileMD5"`    // of the whole local file
+}
+
+// blog filename to its state, saved in sync-state.json
+type SyncState map[string]BlogState
+
+func loadSyncState(stateFile string) (SyncState, error) {
+	slog.Info("loadSyncState", "filename", stateFile)
+
+	state := SyncState{}
+	file, err := os.ReadFile(stateFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		slog.Warn("no sync state, conflicts are checked after this sync", "filename", stateFile)
+		return state, nil
+	}
+	if err != nil {
+		return SyncState{}, fmt.Errorf("loadSyncState: read file failed: %w", err)
+	}
+	if err := json.Unmarshal(file, &state); err != nil {
+		return SyncState{}, fmt.Errorf("loadSyncState: unmarshal failed: %w", err)
+	}
+	return state, nil
+}
+
+func (s SyncState) save(stateFile string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("save: marshal sync state failed: %w", err)
+	}
+	if err := writeFileAtomic(stateFile, data, 0644); err != nil {
+		return fmt.Errorf("save: write file failed: %w", err)
+	}
+	return nil
+}
+
+// records the blog on the server and the local file as they are now
+func (s SyncState) record(blogDir, filename string, remote entities.OutBlogSimple) error {
+	rawData, err := os.ReadFile(filepath.Join(blogDir, filename))
+	if err != nil {
+		return fmt.Errorf("record: read file failed for blog %q: %w", filename, err)
+	}
+	s[filename] = BlogState{
+		ID:         remote.ID,
+		UpdatedAt:  remote.Updated_at,
+		ContentMD5: remote.ContentMD5,
+		FileMD5:    fmt.Sprintf("%x", md5.Sum(rawData)),
+	}
+	return nil
+}
+
+// records every blog in idMap that exists on the server, except the unresolved ones which keep their state
+func newSyncState(blogDir string, idMap map[string]int, remote []entities.OutBlogSimple, unresolved SyncState) (SyncState, error) {
+	remoteMap := map[int]entities.OutBlogSimple{}
+	for _, blog := range remote {
+		remoteMap[blog.ID] = blog
+	}
+
+	state := SyncState{}
+	for filename, id := range idMap {
+		if blogState, ok := unresolved[filename]; ok {
+			state[filename] = blogState
+			continue
+		}
+		remoteBlog, ok := remoteMap[id]
+		if !ok {
+			continue
+		}
+		if err := state.record(blogDir, filename, remoteBlog); err != nil {
+			return SyncState{}, fmt.Errorf("newSyncState: %w", err)
+		}
+	}
+	return state, nil
+}
+
+func remoteFilename(filename string) string {
+	return strings.TrimSuffix(filename, ".md") + remoteSuffix
+}
+
+// markdown files in blogs/, except the server versions of conflicts
+func isBlogFile(filename string) bool {
+	return strings.HasSuffix(filename, ".md") && !strings.HasSuffix(filename, remoteSuffix)
+}
+
+// A blog changed locally and on the server since the last sync
+type Conflict struct {
+	Local  BlogInfo
+	Remote entities.OutBlogSimple
+	// the remote file from an earlier sync hasn't been removed yet
+	Pending bool
+}
+
+/*
+Three way check of blogs about to be updated.
+
+A blog conflicts if both its local file and the server changed since the last sync,
+or the remote file of an earlier conflict is still there.
+Blogs without a state, like on the first sync, never conflict.
+*/
+func findConflicts(update []BlogInfo, remote []entities.OutBlogSimple, state SyncState, blogDir string) ([]BlogInfo, []Conflict, error) {
+	slog.Info("findConflicts")
+
+	remoteMap := map[int]entities.OutBlogSimple{}
+	for _, blog := range remote {
+		remoteMap[blog.ID] = blog
+	}
+
+	rest := []BlogInfo{}
+	conflicts := []Conflict{}
+	for _, local := range update {
+		remoteBlog := remoteMap[local.Frontmatter.ID]
+
+		_, err := os.Stat(filepath.Join(blogDir, remoteFilename(local.Filename)))
+		if err == nil {
+			conflicts = append(conflicts, Conflict{Local: local, Remote: remoteBlog, Pending: true})
+			continue
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return []BlogInfo{}, []Conflict{}, fmt.Errorf("findConflicts: stat remote file failed for blog %q: %w", local.Filename, err)
+		}
+
+		blogState, ok := state[local.Filename]
+		if ok && blogState.ID == local.Frontmatter.ID &&
+			blogState.UpdatedAt != remoteBlog.Updated_at &&
+			blogState.FileMD5 != local.File_md5 {
+			conflicts = append(conflicts, Conflict{Local: local, Remote: remoteBlog})
+			continue
+		}
+
+		rest = append(rest, local)
+	}
+
+	slog.Info("found conflicts", "count", len(conflicts))
+	return rest, conflicts, nil
+}
+
+/*
+Resolves conflicts by prefer.
+
+  - local: the blogs are updated as usual, the remote file is removed
+  - remote: the local file is replaced by the server version
+  - empty: the server version is written to a remote file for manual resolution,
+    the blog is left alone until it is removed
+
+returns blogs to update and the state of unresolved conflicts
+*/
+func (s SyncHelper) ResolveConflicts(conflicts []Conflict, prefer string, state SyncState) ([]BlogInfo, SyncState, error) {
+	slog.Info("ResolveConflicts", "count", len(conflicts), "prefer", prefer)
+
+	blogDir := filepath.Join(s.sourcePath, "blogs")
+	update := []BlogInfo{}
+	unresolved := SyncState{}
+
+	for _, conflict := range conflicts {
+		filename := conflict.Local.Filename
+		remoteFile := filepath.Join(blogDir, remoteFilename(filename))
+
+		switch prefer {
+		case PreferLocal:
+			slog.Warn("conflict, keeping local", "filename", filename)
+			update = append(update, conflict.Local)
+			if err := os.Remove(remoteFile); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return []BlogInfo{}, SyncState{}, fmt.Errorf("ResolveConflicts: remove remote file failed: %w", err)
+			}
+
+		case PreferRemote:
+			slog.Warn("conflict, keeping remote", "filename", filename)
+			blog, err := s.GetBlog(conflict.Remote.ID)
+			if err != nil {
+				return []BlogInfo{}, SyncState{}, fmt.Errorf("ResolveConflicts: %w", err)
+			}
+			if _, err := writeBlogFile(filepath.Join(blogDir, filename), blog, conflict.Remote, true); err != nil {
+				return []BlogInfo{}, SyncState{}, fmt.Errorf("ResolveConflicts: %w", err)
+			}
+			if err := os.Remove(remoteFile); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return []BlogInfo{}, SyncState{}, fmt.Errorf("ResolveConflicts: remove remote file failed: %w", err)
+			}
+
+		default:
+			// the server version is acknowledged, once the remote file is removed the local file wins
+			blogState := state[filename]
+			blogState.ID = conflict.Remote.ID
+			blogState.UpdatedAt = conflict.Remote.Updated_at
+			blogState.ContentMD5 = conflict.Remote.ContentMD5
+			unresolved[filename] = blogState
+
+			if conflict.Pending {
+				continue
+			}
+			blog, err := s.GetBlog(conflict.Remote.ID)
+			if err != nil {
+				return []BlogInfo{}, SyncState{}, fmt.Errorf("ResolveConflicts: %w", err)
+			}
+			if _, err := writeBlogFile(remoteFile, blog, conflict.Remote, true); err != nil {
+				return []BlogInfo{}, SyncState{}, fmt.Errorf("ResolveConflicts: %w", err)
+			}
+			slog.Warn("conflict, wrote the server version", "filename", filename, "remote file", remoteFile)
+		}
+	}
+
+	return update, unresolved, nil
+}
+
+func conflictEntries(conflicts []Conflict) []PlanEntry {
+	result := []PlanEntry{}
+	for _, conflict := range conflicts {
+		change := "changed locally and on the server since the last sync"
+		if conflict.Pending {
+			change = fmt.Sprintf("unresolved, remove %s once merged", remoteFilename(conflict.Local.Filename))
+		}
+		result = append(result, PlanEntry{
+			Kind:    PlanBlog,
+			Action:  PlanConflict,
+			ID:      conflict.Local.Frontmatter.ID,
+			Name:    conflict.Local.Filename,
+			Changes: []string{change},
+		})
+	}
+	sortEntries(result)
+	return result
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"blog/entities"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConflicts(t *testing.T) {
+	sourcePath := t.TempDir()
+	blogDir := filepath.Join(sourcePath, "blogs")
+	if err := os.Mkdir(blogDir, 0755); err != nil {
+		t.Fatalf("TestConflicts: create blog dir failed: %s", err)
+	}
+
+	// a: changed on both sides, b: only local, c: never synced, d: earlier conflict not merged yet
+	idMap := map[string]int{"a.md": 1, "b.md": 2, "c.md": 3, "d.md": 4}
+	files := map[string]string{
+		"a.md":        "---\ntitle: a\n---\nlocal a\n",
+		"b.md":        "---\ntitle: b\n---\nlocal b\n",
+		"c.md":        "---\ntitle: c\n---\nlocal c\n",
+		"d.md":        "---\ntitle: d\n---\nlocal d\n",
+		"d.remote.md": "---\ntitle: d\n---\nremote d\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(blogDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("TestConflicts: write %q failed: %s", name, err)
+		}
+	}
+
+	blogs, err := loadBlogs(blogDir, idMap)
+	if err != nil {
+		t.Fatalf("TestConflicts: load blogs failed: %s", err)
+	}
+	if len(blogs) != 4 {
+		t.Fatalf("TestConflicts: remote files shouldn't be loaded as blogs, got %d blogs", len(blogs))
+	}
+
+	remote := []entities.OutBlogSimple{}
+	state := SyncState{}
+	for i, name := range []string{"a", "b", "c", "d"} {
+		blog := entities.NewBlog(name, "remote "+name, "", false, true)
+		blog.ID = i + 1
+		blog.Updated_at = "2024-02-01T00:00:00+00:00"
+		remote = append(remote, entities.NewOutBlogSimple(*blog, []string{}, []string{}))
+	}
+	state["a.md"] = BlogState{ID: 1, UpdatedAt: "2024-01-01T00:00:00+00:00", FileMD5: "old"}
+	state["b.md"] = BlogState{ID: 2, UpdatedAt: "2024-02-01T00:00:00+00:00", FileMD5: "old"}
+	state["d.md"] = BlogState{ID: 4, UpdatedAt: "2024-02-01T00:00:00+00:00", FileMD5: "old"}
+
+	rest, conflicts, err := findConflicts(blogs, remote, state, blogDir)
+	if err != nil {
+		t.Fatalf("TestConflicts: find conflicts failed: %s", err)
+	}
+	if len(rest) != 2 || rest[0].Filename != "b.md" || rest[1].Filename != "c.md" {
+		t.Fatalf("TestConflicts: b and c should be updated, got %+v", rest)
+	}
+	if len(conflicts) != 2 || conflicts[0].Local.Filename != "a.md" || conflicts[0].Pending ||
+		conflicts[1].Local.Filename != "d.md" || !conflicts[1].Pending {
+		t.Fatalf("TestConflicts: a and pending d should conflict, got %+v", conflicts)
+	}
+
+	// the server version is written next to the local file
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		blog := entities.NewBlog("a", "\nremote a\n", "", false, true)
+		blog.ID = 1
+		json.NewEncoder(w).Encode(entities.NewRetSuccess(*entities.NewOutBlog(*blog, []entities.Tag{}, []entities.Topic{})))
+	}))
+	defer server.Close()
+	helper := NewSyncHelper(context.Background(), server.URL, "token", 1, sourcePath)
+
+	update, unresolved, err := helper.ResolveConflicts(conflicts, "", state)
+	if err != nil {
+		t.Fatalf("TestConflicts: resolve conflicts failed: %s", err)
+	}
+	if len(update) != 0 || len(unresolved) != 2 {
+		t.Fatalf("TestConflicts: nothing should be resolved, got %+v %+v", update, unresolved)
+	}
+	if unresolved["a.md"].UpdatedAt != remote[0].Updated_at || unresolved["a.md"].FileMD5 != "old" {
+		t.Fatalf("TestConflicts: the server version should be acknowledged, got %+v", unresolved["a.md"])
+	}
+	remoteA, err := os.ReadFile(filepath.Join(blogDir, "a.remote.md"))
+	if err != nil || string(remoteA) != "---\ntitle: a\ndescription: \"\"\npined: false\nvisible: true\ntags: []\ntopics: []\n---\nremote a\n" {
+		t.Fatalf("TestConflicts: server version of a should be written, got %q %v", remoteA, err)
+	}
+
+	// local wins and the remote file is removed
+	update, unresolved, err = helper.ResolveConflicts(conflicts, PreferLocal, state)
+	if err != nil {
+		t.Fatalf("TestConflicts: resolve conflicts failed: %s", err)
+	}
+	if len(update) != 2 || len(unresolved) != 0 {
+		t.Fatalf("TestConflicts: both should be updated, got %+v %+v", update, unresolved)
+	}
+	if _, err := os.Stat(filepath.Join(blogDir, "d.remote.md")); !os.IsNotExist(err) {
+		t.Fatalf("TestConflicts: remote file should be removed, got %v", err)
+	}
+}
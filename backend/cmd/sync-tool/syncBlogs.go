@@ -1,65 +1,115 @@
 package main
 
 import (
+	"blog/client"
 	"blog/entities"
-	"bytes"
 	"encoding/json"
-	"errors"
 	"fmt"
-	"io"
 	"log/slog"
-	"net/http"
-	"net/url"
 	"os"
 	"path"
-	"strconv"
-	"strings"
 )
 
-func (s SyncHelper) GetAllBlogs() (oBlog []entities.OutBlogSimple, oErr error) {
+func (s SyncHelper) GetAllBlogs() ([]entities.OutBlogSimple, error) {
 	slog.Info("GetAllBlogs")
 
-	apiURL, err := url.JoinPath(s.baseURL, "blogs")
+	blogs, err := s.api.ListBlogsSimple(s.ctx, client.BlogFilter{All: true})
 	if err != nil {
-		return []entities.OutBlogSimple{}, fmt.Errorf("GetAllBlogs: join api url failed: %w", err)
+		return []entities.OutBlogSimple{}, fmt.Errorf("GetAllBlogs: %w", err)
 	}
-	slog.Debug("api url", "url", apiURL)
 
-	req, err := http.NewRequest(http.MethodGet, apiURL, nil)
-	if err != nil {
-		return []entities.OutBlogSimple{}, fmt.Errorf("GetAllBlogs: create new request failed: %w", err)
-	}
-	req.Header.Set("Authorization", "Bearer "+s.token)
-	query := req.URL.Query()
-	query.Set("all", "true")
-	query.Set("simple", "true")
-	req.URL.RawQuery = query.Encode()
+	slog.Debug("got blogs", "blogs", blogs)
+	return blogs, nil
+}
+
+// get a single blog with its content, regardless of visibility
+func (s SyncHelper) GetBlog(id int) (entities.OutBlog, error) {
+	slog.Debug("GetBlog", "id", id)
 
-	res, err := httpClient.Do(req)
+	blog, err := s.api.GetBlog(s.ctx, id, client.GetBlogQuery{All: true})
 	if err != nil {
-		return []entities.OutBlogSimple{}, fmt.Errorf("GetAllBlogs: req failed: %w", err)
+		return entities.OutBlog{}, fmt.Errorf("GetBlog: request failed for blog (id: %d): %w", id, err)
 	}
+	return blog, nil
+}
 
-	defer func() {
-		oErr = errors.Join(oErr, drainAndClose(res.Body))
-	}()
+// get blogs with their content by id, in the order of ids
+func (s SyncHelper) GetBlogs(ids []int) ([]entities.OutBlog, error) {
+	slog.Info("GetBlogs", "count", len(ids))
 
-	resBody, err := io.ReadAll(res.Body)
-	if err != nil {
-		return []entities.OutBlogSimple{}, fmt.Errorf("GetAllBlogs: read body failed: %w", err)
+	batchData := make(chan []int, 1)
+	go batch(ids, s.batchSize, batchData)
+
+	blogMap := map[int]entities.OutBlog{}
+
+	// seperate into batches
+	for currentBatch := range batchData {
+		requestErr := make(chan error, 1)
+		response := make(chan entities.OutBlog, 1)
+		responseCount := 0
+
+		for _, id := range currentBatch {
+			go func(id int) {
+				blog, err := s.GetBlog(id)
+				if err != nil {
+					requestErr <- err
+					return
+				}
+				response <- blog
+			}(id)
+		}
+
+		// wait for all requests to finish or if an error occurs
+		for {
+			if responseCount == len(currentBatch) {
+				break
+			}
+			select {
+			case err := <-requestErr:
+				return []entities.OutBlog{}, err
+			case blog := <-response:
+				responseCount++
+				blogMap[blog.ID] = blog
+			}
+		}
 	}
 
-	if res.StatusCode >= 400 {
-		return []entities.OutBlogSimple{}, fmt.Errorf("GetAllBlogs: status code %d, msg: %s", res.StatusCode, string(resBody))
+	result := make([]entities.OutBlog, 0, len(ids))
+	for _, id := range ids {
+		result = append(result, blogMap[id])
 	}
 
-	data := entities.RetSuccess[[]entities.OutBlogSimple]{}
-	if err := json.Unmarshal(resBody, &data); err != nil {
-		return []entities.OutBlogSimple{}, fmt.Errorf("GetAllBlogs: unmarshal failed: %w", err)
+	slog.Info("got blogs", "count", len(result))
+	return result, nil
+}
+
+// content of a local blog without its frontmatter, as it is sent to the server
+func (s SyncHelper) loadContent(inpt BlogInfo) (string, error) {
+	content, _, err := s.loadLinkedContent(inpt)
+	return content, err
+}
+
+// content of a local blog with its relative images linked to media, and the images
+func (s SyncHelper) loadLinkedContent(inpt BlogInfo) (string, []LocalImage, error) {
+	targetFile := path.Join(s.sourcePath, "blogs", inpt.Filename)
+	rawData, err := os.ReadFile(targetFile)
+	if err != nil {
+		return "", []LocalImage{}, fmt.Errorf("loadLinkedContent: read file failed for blog %q: %w", inpt.Filename, err)
+	}
+	_, content, err := parseFrontmatter(rawData)
+	if err != nil {
+		return "", []LocalImage{}, fmt.Errorf("loadLinkedContent: parse blog %q failed: %w", inpt.Filename, err)
 	}
 
-	slog.Debug("got blogs", "blogs", data.Msg)
-	return data.Msg, nil
+	prefix, err := s.mediaPrefix()
+	if err != nil {
+		return "", []LocalImage{}, fmt.Errorf("loadLinkedContent: %w", err)
+	}
+	content, images, err := linkImages(targetFile, content, prefix)
+	if err != nil {
+		return "", []LocalImage{}, fmt.Errorf("loadLinkedContent: blog %q: %w", inpt.Filename, err)
+	}
+	return content, images, nil
 }
 
 type FileIDMap struct {
@@ -74,81 +124,52 @@ func NewFileIDMap(filename string, id int) FileIDMap {
 	}
 }
 
-func (s *SyncHelper) createBlog(inpt BlogInfo) (result FileIDMap, oErr error) {
+// request body of a blog from its frontmatter, the server decides the status
+func newReqInBlog(inpt BlogInfo, content string) entities.ReqInBlog {
+	return entities.ReqInBlog{
+		Title:       inpt.Frontmatter.Title,
+		Content:     content,
+		Description: inpt.Frontmatter.Description,
+		Pined:       inpt.Frontmatter.Pined,
+		Visible:     inpt.Frontmatter.Visible,
+		Tags:        inpt.Frontmatter.TagIDs,
+		Topics:      inpt.Frontmatter.TopicIDs,
+		Series:      inpt.Frontmatter.SeriesID,
+		Part:        inpt.Frontmatter.Part,
+	}
+}
+
+func (s *SyncHelper) createBlog(inpt BlogInfo) (FileIDMap, error) {
 	slog.Debug("createBlog")
 
 	// load content
-	targetFile := path.Join(s.sourcePath, "blogs", inpt.Filename)
-	content, err := os.ReadFile(targetFile)
+	content, err := s.loadContent(inpt)
 	if err != nil {
-		return FileIDMap{}, fmt.Errorf("createBlog: load content failed for blog %q: %w", inpt.Filename, err)
+		return FileIDMap{}, fmt.Errorf("createBlog: %w", err)
 	}
 
-	// prepare request body
-	newBlog := entities.NewBlog(
-		inpt.Frontmatter.Title,
-		strings.Join(strings.Split(string(content), "---")[2:], "---"),
-		inpt.Frontmatter.Description,
-		inpt.Frontmatter.Pined,
-		inpt.Frontmatter.Visible,
-	)
-	newInBlog := entities.NewInBlog(
-		*newBlog,
-		inpt.Frontmatter.TagIDs,
-		inpt.Frontmatter.TopicIDs,
-	)
-
-	body := &bytes.Buffer{}
-	if err := json.NewEncoder(body).Encode(newInBlog); err != nil {
-		return FileIDMap{}, fmt.Errorf("createBlog: encode body failed for blog %q: %w", inpt.Filename, err)
+	options := []client.RequestOption{}
+	// a create of an interrupted sync is replayed, unless the file changed since
+	if key := s.journal.IdempotencyKey("create blog " + inpt.Filename + " " + inpt.File_md5); key != "" {
+		options = append(options, client.IdempotencyKey(key))
 	}
 
-	apiURL := ""
+	var result entities.OutBlog
 	if inpt.Frontmatter.ID == 0 {
 		// normal create, let the database generate id
-		apiURL, err = url.JoinPath(s.baseURL, "blogs")
-		if err != nil {
-			return FileIDMap{}, fmt.Errorf("createBlog: join api url failed for blog %q: %w", inpt.Filename, err)
-		}
+		result, err = s.api.CreateBlog(s.ctx, newReqInBlog(inpt, content), options...)
 	} else {
 		// If we somehow lost our database, we will have to create blogs with their original ids
-		apiURL, err = url.JoinPath(s.baseURL, "blogs", strconv.Itoa(inpt.Frontmatter.ID))
-		if err != nil {
-			return FileIDMap{}, fmt.Errorf("createBlog: join api url failed for blog %q: %w", inpt.Filename, err)
-		}
-	}
-	slog.Debug("api url", "url", apiURL)
-
-	req, err := http.NewRequest(http.MethodPost, apiURL, body)
-	if err != nil {
-		return FileIDMap{}, fmt.Errorf("createBlog: new requset failed for blog %q: %w", inpt.Filename, err)
+		result, err = s.api.CreateBlogWithID(s.ctx, inpt.Frontmatter.ID, newReqInBlog(inpt, content), options...)
 	}
-	req.Header.Set("content-type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+s.token)
-
-	res, err := httpClient.Do(req)
-	if err != nil {
-		return FileIDMap{}, fmt.Errorf("createBlog: requset failed for blog %q: %w", inpt.Filename, err)
-	}
-
-	defer func() {
-		oErr = errors.Join(oErr, drainAndClose(res.Body))
-	}()
-
-	// process response and send it through the channel
-	resBody, err := io.ReadAll(res.Body)
 	if err != nil {
-		return FileIDMap{}, fmt.Errorf("createBlog: read response body failed for blog %q: %w", inpt.Filename, err)
+		return FileIDMap{}, fmt.Errorf("createBlog: request failed for blog %q: %w", inpt.Filename, err)
 	}
-	if res.StatusCode >= 400 {
-		return FileIDMap{}, fmt.Errorf("createBlog: status code %d for blog %q: %s", res.StatusCode, inpt.Filename, string(resBody))
-	}
-	resData := entities.RetSuccess[entities.OutBlog]{}
-	if err := json.Unmarshal(resBody, &resData); err != nil {
-		return FileIDMap{}, fmt.Errorf("createBlog: parse response body failed for blog %q: %w", inpt.Filename, err)
+	if err := s.journal.RecordBlog(inpt.Filename, writtenBlogState(inpt, result)); err != nil {
+		return FileIDMap{}, fmt.Errorf("createBlog: %w", err)
 	}
 
-	return NewFileIDMap(inpt.Filename, resData.Msg.ID), nil
+	return NewFileIDMap(inpt.Filename, result.ID), nil
 }
 
 // return a mapping of blog_filename and id
@@ -189,6 +210,10 @@ func (s SyncHelper) CreateBlogs(blogs []BlogInfo) (map[string]int, error) {
 			case res := <-response:
 				responseCount++
 				result[res.Filename] = res.Id
+				// an interrupted sync keeps the ids of the blogs it created
+				if err := saveID(path.Join(s.sourcePath, "ids.json"), res.Filename, res.Id); err != nil {
+					return map[string]int{}, err
+				}
 			}
 		}
 	}
@@ -197,74 +222,25 @@ func (s SyncHelper) CreateBlogs(blogs []BlogInfo) (map[string]int, error) {
 	return result, nil
 }
 
-func (s SyncHelper) updateBlog(inpt BlogInfo) (oErr error) {
+func (s SyncHelper) updateBlog(inpt BlogInfo) error {
 	slog.Debug("updateBlog")
 
 	// load content
-	targetFile := path.Join(s.sourcePath, "blogs", inpt.Filename)
-	content, err := os.ReadFile(targetFile)
+	content, err := s.loadContent(inpt)
 	if err != nil {
-		return fmt.Errorf("updateBlog: load content failed for blog %q: %w", inpt.Filename, err)
-	}
-
-	// prepare request body
-	newBlog := entities.NewBlog(
-		inpt.Frontmatter.Title,
-		strings.Join(strings.Split(string(content), "---")[2:], "---"),
-		inpt.Frontmatter.Description,
-		inpt.Frontmatter.Pined,
-		inpt.Frontmatter.Visible,
-	)
-	newInBlog := entities.NewInBlog(
-		*newBlog,
-		inpt.Frontmatter.TagIDs,
-		inpt.Frontmatter.TopicIDs,
-	)
-
-	body := &bytes.Buffer{}
-	if err := json.NewEncoder(body).Encode(newInBlog); err != nil {
-		return fmt.Errorf("updateBlog: encode body failed for blog %q: %w", inpt.Filename, err)
+		return fmt.Errorf("updateBlog: %w", err)
 	}
 
 	if inpt.Frontmatter.ID == 0 {
 		return fmt.Errorf("updateBlog: blog id shouldn't be '0', blog %q", inpt.Filename)
 	}
 
-	apiURL, err := url.JoinPath(s.baseURL, "blogs", strconv.Itoa(inpt.Frontmatter.ID))
+	result, err := s.api.UpdateBlog(s.ctx, inpt.Frontmatter.ID, newReqInBlog(inpt, content))
 	if err != nil {
-		return fmt.Errorf("updateBlog: join api url failed for blog %q: %w", inpt.Filename, err)
+		return fmt.Errorf("updateBlog: request failed for blog %q: %w", inpt.Filename, err)
 	}
-	slog.Debug("api url", "url", apiURL)
-
-	req, err := http.NewRequest(http.MethodPut, apiURL, body)
-	if err != nil {
-		return fmt.Errorf("updateBlog: new requset failed for blog %q: %w", inpt.Filename, err)
-	}
-	req.Header.Set("content-type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+s.token)
-
-	res, err := httpClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("updateBlog: requset failed for blog %q: %w", inpt.Filename, err)
-	}
-
-	defer func() {
-		oErr = errors.Join(oErr, drainAndClose(res.Body))
-	}()
-
-	// process response and send it through the channel
-	resBody, err := io.ReadAll(res.Body)
-	if err != nil {
-		return fmt.Errorf("updateBlog: read response body failed for blog %q: %w", inpt.Filename, err)
-	}
-	if res.StatusCode >= 400 {
-		return fmt.Errorf("updateBlog: status code %d for blog %q: %s", res.StatusCode, inpt.Filename, string(resBody))
-	}
-
-	// just to make sure the response is what we expect
-	resData := entities.RetSuccess[entities.OutBlog]{}
-	if err := json.Unmarshal(resBody, &resData); err != nil {
-		return fmt.Errorf("updateBlog: parse response body failed for blog %q: %w", inpt.Filename, err)
+	if err := s.journal.RecordBlog(inpt.Filename, writtenBlogState(inpt, result)); err != nil {
+		return fmt.Errorf("updateBlog: %w", err)
 	}
 
 	return nil
@@ -313,50 +289,18 @@ func (s SyncHelper) UpdateBlogs(blogs []BlogInfo) error {
 	return nil
 }
 
-func (s SyncHelper) deleteBlog(b entities.OutBlogSimple) (oErr error) {
+func (s SyncHelper) deleteBlog(b entities.OutBlogSimple) error {
 	slog.Debug("deleteBlog")
 	if b.ID == 0 {
 		return fmt.Errorf("deleteBlog: blog id shouldn't be '0', blog %q", b.Slug)
 	}
 
-	apiURL, err := url.JoinPath(s.baseURL, "blogs", "delete-now", strconv.Itoa(b.ID))
+	result, err := s.api.DeleteBlogNow(s.ctx, b.ID)
 	if err != nil {
-		return fmt.Errorf("deleteBlog: join api url failed for blog (id: %d) %q: %w", b.ID, b.Slug, err)
+		return fmt.Errorf("deleteBlog: request failed for blog (id: %d) %q: %w", b.ID, b.Slug, err)
 	}
-	slog.Debug("api url", "url", apiURL)
 
-	req, err := http.NewRequest(http.MethodDelete, apiURL, nil)
-	if err != nil {
-		return fmt.Errorf("deleteBlog: new requset failed for blog (id: %d) %q: %w", b.ID, b.Slug, err)
-	}
-	req.Header.Set("content-type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+s.token)
-
-	res, err := httpClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("deleteBlog: requset failed for blog (id: %d) %q: %w", b.ID, b.Slug, err)
-	}
-
-	defer func() {
-		oErr = errors.Join(oErr, drainAndClose(res.Body))
-	}()
-
-	// process response and send it through the channel
-	resBody, err := io.ReadAll(res.Body)
-	if err != nil {
-		return fmt.Errorf("deleteBlog: read response body failed for blog (id: %d) %q: %w", b.ID, b.Slug, err)
-	}
-	if res.StatusCode >= 400 {
-		return fmt.Errorf("deleteBlog: status code %d for blog (id: %d) %q: %s", res.StatusCode, b.ID, b.Slug, string(resBody))
-	}
-
-	// just to make sure the response is what we expect
-	resData := entities.RetSuccess[entities.RowsAffected]{}
-	if err := json.Unmarshal(resBody, &resData); err != nil {
-		return fmt.Errorf("deleteBlog: parse response body failed for blog (id: %d) %q: %w", b.ID, b.Slug, err)
-	}
-
-	if resData.Msg.AffectedRows != 1 {
+	if result.AffectedRows != 1 {
 		return fmt.Errorf("deleteBlog: should only delete one blog (id: %d) %q", b.ID, b.Slug)
 	}
 
@@ -406,6 +350,34 @@ func (s SyncHelper) DeleteBlogs(blogs []entities.OutBlogSimple) error {
 	return nil
 }
 
+// the state of a blog just written to the server
+func writtenBlogState(local BlogInfo, remote entities.OutBlog) BlogState {
+	return BlogState{
+		ID:         remote.ID,
+		UpdatedAt:  remote.Updated_at,
+		ContentMD5: remote.ContentMD5,
+		FileMD5:    local.File_md5,
+	}
+}
+
+// adds a single blog to ids.json
+func saveID(idFile, filename string, id int) error {
+	idMap, err := loadIDMap(idFile)
+	if err != nil {
+		return fmt.Errorf("saveID: %w", err)
+	}
+	idMap[filename] = id
+
+	data, err := json.Marshal(idMap)
+	if err != nil {
+		return fmt.Errorf("saveID: marshal failed: %w", err)
+	}
+	if err := writeFileAtomic(idFile, data, 0644); err != nil {
+		return fmt.Errorf("saveID: write file failed: %w", err)
+	}
+	return nil
+}
+
 // update ids.json (blog filename to id mapping)
 func updateIDMapping(blogs []BlogInfo, newBlogIDs map[string]int, targetFile string) error {
 	slog.Info("updateIDMapping")
@@ -421,7 +393,7 @@ func updateIDMapping(blogs []BlogInfo, newBlogIDs map[string]int, targetFile str
 		return fmt.Errorf("updateIDMapping: marshal failed: %w", err)
 	}
 
-	if err := os.WriteFile(targetFile, data, 0644); err != nil {
+	if err := writeFileAtomic(targetFile, data, 0644); err != nil {
 		return fmt.Errorf("updateIDMapping: write file failed: %w", err)
 	}
 
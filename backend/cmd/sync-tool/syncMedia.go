@@ -0,0 +1,271 @@
+package main
+
+import (
+	"blog/entities"
+	"blog/markdown"
+	"crypto/md5"
+	"crypto/sha256"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// A local image referenced by a blog, uploaded as media and linked by its hash
+type LocalImage struct {
+	Path string // on disk
+	Hash string // sha256 of the file, the same as on the server
+}
+
+// An image in markdown content
+type imageRef struct {
+	Dest string
+	// of the destination in the content, -1 when it can't be found
+	Offset int
+}
+
+// images in markdown content, in order
+func findImageRefs(content string) []imageRef {
+	source := []byte(content)
+	doc := markdown.New(nil).Parser().Parse(text.NewReader(source))
+
+	// images are walked in order, so each one is searched after the previous
+	result := []imageRef{}
+	searchFrom := 0
+	ast.Walk(doc, func(node ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		image, ok := node.(*ast.Image)
+		if !ok {
+			return ast.WalkContinue, nil
+		}
+
+		// the destination comes after the alt text
+		if alt, ok := image.LastChild().(*ast.Text); ok && alt.Segment.Stop > searchFrom {
+			searchFrom = alt.Segment.Stop
+		}
+		ref := imageRef{Dest: string(image.Destination), Offset: -1}
+		if index := strings.Index(content[searchFrom:], ref.Dest); ref.Dest != "" && index >= 0 {
+			ref.Offset = searchFrom + index
+			searchFrom = ref.Offset + len(ref.Dest)
+		}
+		result = append(result, ref)
+		return ast.WalkSkipChildren, nil
+	})
+	return result
+}
+
+// links to files next to the blog, not urls, absolute paths or anchors
+func isRelativeLink(dest string) bool {
+	if dest == "" || strings.HasPrefix(dest, "/") || strings.HasPrefix(dest, "#") {
+		return false
+	}
+	parsed, err := url.Parse(dest)
+	return err == nil && parsed.Scheme == "" && parsed.Host == ""
+}
+
+// file a relative link of a blog points to
+func linkedFile(blogFile, dest string) string {
+	filename, _, _ := strings.Cut(dest, "#")
+	filename, _, _ = strings.Cut(filename, "?")
+	if unescaped, err := url.PathUnescape(filename); err == nil {
+		filename = unescaped
+	}
+	return filepath.Join(filepath.Dir(blogFile), filepath.FromSlash(filename))
+}
+
+/*
+Links relative images in the content of blogFile to media on the server, prefix is the path of media.
+
+The local file isn't changed, only the returned content.
+returns the content and its images
+*/
+func linkImages(blogFile, content, prefix string) (string, []LocalImage, error) {
+	builder := strings.Builder{}
+	images := []LocalImage{}
+	last := 0
+	for _, ref := range findImageRefs(content) {
+		if !isRelativeLink(ref.Dest) {
+			continue
+		}
+		if ref.Offset < 0 {
+			slog.Warn("image link not found in content, kept as is", "filename", blogFile, "image", ref.Dest)
+			continue
+		}
+
+		imageFile := linkedFile(blogFile, ref.Dest)
+		data, err := os.ReadFile(imageFile)
+		if err != nil {
+			return "", []LocalImage{}, fmt.Errorf("linkImages: read image %q failed: %w", ref.Dest, err)
+		}
+		image := LocalImage{Path: imageFile, Hash: fmt.Sprintf("%x", sha256.Sum256(data))}
+		images = append(images, image)
+
+		builder.WriteString(content[last:ref.Offset])
+		builder.WriteString(prefix + image.Hash)
+		last = ref.Offset + len(ref.Dest)
+	}
+	builder.WriteString(content[last:])
+
+	return builder.String(), images, nil
+}
+
+// path of uploaded media, ex: /api/v1/media/
+func (s SyncHelper) mediaPrefix() (string, error) {
+	parsed, err := url.Parse(s.baseURL)
+	if err != nil {
+		return "", fmt.Errorf("mediaPrefix: parse base url failed: %w", err)
+	}
+	return path.Join("/", parsed.Path, "media") + "/", nil
+}
+
+/*
+Fills in the images of blogs, and the md5 of their content with images linked like it is sent to the server.
+So blogs with unchanged images stay the same as on the server.
+*/
+func (s SyncHelper) LinkImages(blogs []BlogInfo) ([]BlogInfo, error) {
+	slog.Info("LinkImages", "count", len(blogs))
+
+	result := make([]BlogInfo, 0, len(blogs))
+	for _, blog := range blogs {
+		content, images, err := s.loadLinkedContent(blog)
+		if err != nil {
+			return []BlogInfo{}, fmt.Errorf("LinkImages: %w", err)
+		}
+		blog.Images = images
+		blog.Content_md5 = fmt.Sprintf("%x", md5.Sum([]byte(content)))
+		result = append(result, blog)
+	}
+	return result, nil
+}
+
+// images of blogs without duplicates
+func imagesOf(blogs ...[]BlogInfo) []LocalImage {
+	record := map[string]bool{}
+	result := []LocalImage{}
+	for _, group := range blogs {
+		for _, blog := range group {
+			for _, image := range blog.Images {
+				if record[image.Hash] {
+					continue
+				}
+				record[image.Hash] = true
+				result = append(result, image)
+			}
+		}
+	}
+	return result
+}
+
+// images that aren't on the server yet
+func missingImages(images []LocalImage, remote []entities.OutMedia) []LocalImage {
+	existing := map[string]bool{}
+	for _, media := range remote {
+		existing[media.Hash] = true
+	}
+
+	result := []LocalImage{}
+	for _, image := range images {
+		if !existing[image.Hash] {
+			result = append(result, image)
+		}
+	}
+	return result
+}
+
+func (s SyncHelper) GetAllMedia() ([]entities.OutMedia, error) {
+	slog.Info("GetAllMedia")
+
+	media, err := s.api.ListMedia(s.ctx)
+	if err != nil {
+		return []entities.OutMedia{}, fmt.Errorf("GetAllMedia: %w", err)
+	}
+
+	slog.Debug("got media", "count", len(media))
+	return media, nil
+}
+
+func (s SyncHelper) uploadImage(image LocalImage) error {
+	slog.Debug("uploadImage", "filename", image.Path)
+
+	file, err := os.Open(image.Path)
+	if err != nil {
+		return fmt.Errorf("uploadImage: open file failed for image %q: %w", image.Path, err)
+	}
+	defer file.Close()
+
+	result, err := s.api.UploadMedia(s.ctx, filepath.Base(image.Path), file, "")
+	if err != nil {
+		return fmt.Errorf("uploadImage: request failed for image %q: %w", image.Path, err)
+	}
+	// the file changed after it was linked
+	if result.Hash != image.Hash {
+		return fmt.Errorf("uploadImage: image %q changed while syncing, got hash %q, expected %q", image.Path, result.Hash, image.Hash)
+	}
+
+	return nil
+}
+
+// uploads images that aren't on the server yet
+func (s SyncHelper) UploadImages(images []LocalImage, remote []entities.OutMedia) error {
+	images = missingImages(images, remote)
+	slog.Info("UploadImages", "count", len(images))
+
+	batchData := make(chan []LocalImage, 1)
+	go batch(images, s.batchSize, batchData)
+	totalCount := 0
+
+	// seperate into batches
+	for currentBatch := range batchData {
+		requestErr := make(chan error, 1)
+		finish := make(chan bool, 1)
+		finishCount := 0
+
+		for _, image := range currentBatch {
+			go func(i LocalImage) {
+				if err := s.uploadImage(i); err != nil {
+					requestErr <- err
+					return
+				}
+				finish <- true
+			}(image)
+		}
+
+		// wait for all requests to finish or if an error occurs
+		for {
+			if finishCount == len(currentBatch) {
+				break
+			}
+			select {
+			case err := <-requestErr:
+				return err
+			case <-finish:
+				finishCount++
+				totalCount++
+			}
+		}
+	}
+
+	slog.Info("uploaded images", "count", totalCount)
+	return nil
+}
+
+func planMedia(images []LocalImage, remote []entities.OutMedia, blogDir string) []PlanEntry {
+	result := []PlanEntry{}
+	for _, image := range missingImages(images, remote) {
+		name := image.Path
+		if rel, err := filepath.Rel(blogDir, image.Path); err == nil {
+			name = filepath.ToSlash(rel)
+		}
+		result = append(result, PlanEntry{Kind: PlanMedia, Action: PlanCreate, Name: name})
+	}
+	sortEntries(result)
+	return result
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"blog/entities"
+	"fmt"
+	"log/slog"
+)
+
+func (s SyncHelper) GetAllSeries() ([]entities.Series, error) {
+	slog.Info("GetAllSeries")
+
+	series, err := s.api.ListSeries(s.ctx)
+	if err != nil {
+		return []entities.Series{}, fmt.Errorf("GetAllSeries: %w", err)
+	}
+
+	slog.Debug("got series", "series", series)
+	return series, nil
+}
+
+func (s SyncHelper) createSeries(t entities.Series) (entities.Series, error) {
+	slog.Debug("createSeries")
+
+	result, err := s.api.CreateSeries(s.ctx, entities.NewInSeries(t.Name, t.Description))
+	if err != nil {
+		return entities.Series{}, fmt.Errorf("createSeries: request failed for series %q: %w", t.Name, err)
+	}
+	return result, nil
+}
+
+func (s SyncHelper) CreateSeries(series []entities.Series) ([]entities.Series, error) {
+	slog.Info("CreateSeries", "count", len(series))
+
+	batchData := make(chan []entities.Series, 1)
+	go batch(series, s.batchSize, batchData)
+
+	result := []entities.Series{}
+
+	// seperate into batches
+	for currentBatch := range batchData {
+		requestErr := make(chan error, 1)
+		response := make(chan entities.Series, 1)
+		responseCount := 0
+
+		// there is no 'bulk api' for now, so we just create series one by one
+		for _, series := range currentBatch {
+			go func(t entities.Series) {
+				res, err := s.createSeries(t)
+				if err != nil {
+					requestErr <- err
+					return
+				}
+				response <- res
+			}(series)
+		}
+
+		// wait for all requests to finish or if an error occurs
+		for {
+			if responseCount == len(currentBatch) {
+				break
+			}
+			select {
+			case newSeries := <-response:
+				result = append(result, newSeries)
+				responseCount++
+			case err := <-requestErr:
+				return []entities.Series{}, err
+			}
+		}
+	}
+
+	slog.Info("created series", "count", len(result))
+	return result, nil
+}
+
+func (s SyncHelper) updateSeries(t entities.Series) (entities.Series, error) {
+	slog.Debug("updateSeries")
+
+	result, err := s.api.UpdateSeries(s.ctx, t.ID, entities.NewInSeries(t.Name, t.Description))
+	if err != nil {
+		return entities.Series{}, fmt.Errorf("updateSeries: request failed for series %q: %w", t.Name, err)
+	}
+	return result, nil
+}
+
+func (s SyncHelper) UpdateSeries(series []entities.Series) ([]entities.Series, error) {
+	slog.Info("UpdateSeries", "count", len(series))
+
+	batchData := make(chan []entities.Series, 1)
+	go batch(series, s.batchSize, batchData)
+
+	result := []entities.Series{}
+
+	// seperate into batches
+	for currentBatch := range batchData {
+		requestErr := make(chan error, 1)
+		response := make(chan entities.Series, 1)
+		responseCount := 0
+
+		// there is no 'bulk api' for now, so we just create series one by one
+		for _, series := range currentBatch {
+			go func(t entities.Series) {
+				res, err := s.updateSeries(t)
+				if err != nil {
+					requestErr <- err
+					return
+				}
+				response <- res
+			}(series)
+		}
+
+		// wait for all requests to finish or if an error occurs
+		for {
+			if responseCount == len(currentBatch) {
+				break
+			}
+			select {
+			case newSeries := <-response:
+				result = append(result, newSeries)
+				responseCount++
+			case err := <-requestErr:
+				return []entities.Series{}, err
+			}
+		}
+	}
+
+	slog.Info("updated series", "count", len(result))
+	return result, nil
+}
+
+func (s SyncHelper) deleteSeries(t entities.Series) error {
+	slog.Debug("deleteSeries")
+
+	result, err := s.api.DeleteSeries(s.ctx, t.ID)
+	if err != nil {
+		return fmt.Errorf("deleteSeries: request failed for series %q: %w", t.Name, err)
+	}
+	if result.AffectedRows != 1 {
+		return fmt.Errorf("deleteSeries: should only delete one series %q", t.Name)
+	}
+	return nil
+}
+
+func (s SyncHelper) DeleteSeries(series []entities.Series) error {
+	slog.Info("DeleteSeries", "count", len(series))
+
+	batchData := make(chan []entities.Series, 1)
+	go batch(series, s.batchSize, batchData)
+
+	totalCount := 0
+
+	// seperate into batches
+	for currentBatch := range batchData {
+		requestErr := make(chan error, 1)
+		finish := make(chan bool, 1)
+		finishCount := 0
+		// there is no 'bulk api' for now, so we just create series one by one
+		for _, series := range currentBatch {
+			go func(t entities.Series) {
+				if err := s.deleteSeries(t); err != nil {
+					requestErr <- err
+					return
+				}
+				finish <- true
+			}(series)
+		}
+
+		// wait for all requests to finish or if an error occurs
+		for {
+			if finishCount == len(currentBatch) {
+				break
+			}
+			select {
+			case err := <-requestErr:
+				return err
+			case <-finish:
+				finishCount++
+				totalCount++
+			}
+		}
+	}
+
+	slog.Info("deleted series", "count", totalCount)
+	return nil
+}
@@ -0,0 +1,457 @@
+package main
+
+import (
+	"blog/client"
+	"blog/entities"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+/*
+Watches meta.yaml and blogs/, changes are synced after no files changed for a while.
+
+Only changed files are synced, and nothing is deleted on the server,
+editors often save by removing and creating files. Run sync to delete.
+*/
+type Watcher struct {
+	ctx         context.Context
+	baseURL     string
+	credentials Credentials
+	sourcePath  string
+	batchSize   int
+	debounce    time.Duration
+	helper      SyncHelper
+
+	// called with the changes of each burst, syncWithLogin unless set by tests
+	syncFunc func(metaChanged bool, blogFiles []string) error
+}
+
+func NewWatcher(ctx context.Context, credentials Credentials, baseURL, sourcePath string, batchSize int, debounce time.Duration) *Watcher {
+	w := &Watcher{
+		ctx:         ctx,
+		baseURL:     baseURL,
+		credentials: credentials,
+		sourcePath:  sourcePath,
+		batchSize:   batchSize,
+		debounce:    debounce,
+	}
+	w.syncFunc = w.syncWithLogin
+	return w
+}
+
+func watchAll(
+	ctx context.Context,
+	credentials Credentials,
+	baseURL,
+	sourcePath string,
+	batchSize int,
+	debounce time.Duration) error {
+	slog.Info("watchAll")
+
+	if err := checkSourcePath(sourcePath); err != nil {
+		return fmt.Errorf("watchAll: %w", err)
+	}
+
+	w := NewWatcher(ctx, credentials, baseURL, sourcePath, batchSize, debounce)
+	if err := w.login(); err != nil {
+		return fmt.Errorf("watchAll: %w", err)
+	}
+	return w.Run()
+}
+
+// the same token is used until the server rejects it
+func (w *Watcher) login() error {
+	loginDone := make(chan bool, 1)
+	jwt, err := login(w.ctx, loginDone, w.baseURL, w.credentials)
+	fmt.Printf("\n")
+	if err != nil {
+		return fmt.Errorf("login failed: %w", err)
+	}
+	slog.Debug("got jwt", "token", jwt)
+
+	w.helper = NewSyncHelper(w.ctx, w.baseURL, jwt, w.batchSize, w.sourcePath)
+	return nil
+}
+
+// blocks until ctx is done
+func (w *Watcher) Run() error {
+	slog.Info("Run")
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("Run: create watcher failed: %w", err)
+	}
+	defer watcher.Close()
+
+	// directories are watched instead of files, files are replaced when editors save
+	metaFile := filepath.Join(w.sourcePath, "meta.yaml")
+	blogDir := filepath.Join(w.sourcePath, "blogs")
+	if err := watcher.Add(w.sourcePath); err != nil {
+		return fmt.Errorf("Run: watch %q failed: %w", w.sourcePath, err)
+	}
+	if _, err := watchTree(watcher, blogDir); err != nil {
+		return fmt.Errorf("Run: %w", err)
+	}
+	fmt.Printf("Watching %s and %s, press Ctrl+C to stop\n", metaFile, blogDir)
+
+	timer := time.NewTimer(w.debounce)
+	timer.Stop()
+
+	metaChanged := false
+	changedBlogs := map[string]bool{}
+
+	for {
+		select {
+		case <-w.ctx.Done():
+			slog.Warn("got done")
+			return nil
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+
+			name := filepath.Clean(event.Name)
+			changed := false
+			if info, err := os.Stat(name); err == nil && info.IsDir() && event.Has(fsnotify.Create) {
+				// files might be written before the directory is watched
+				files, err := watchTree(watcher, name)
+				if err != nil {
+					slog.Error("watch new directory failed", "error", err)
+				}
+				for _, file := range files {
+					if filename, ok := blogOfFile(blogDir, file); ok {
+						changedBlogs[filename] = true
+						changed = true
+					}
+				}
+			}
+			if name == metaFile {
+				metaChanged = true
+				changed = true
+			} else if filename, ok := blogOfFile(blogDir, name); ok {
+				changedBlogs[filename] = true
+				changed = true
+			}
+			if !changed {
+				continue
+			}
+			slog.Debug("file changed", "file", name, "op", event.Op.String())
+
+			// wait for the burst to end
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+			timer.Reset(w.debounce)
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			slog.Error("watch error", "error", err)
+
+		case <-timer.C:
+			// checked again, an index.md might have been added after other markdown files
+			blogFiles := make([]string, 0, len(changedBlogs))
+			for filename := range changedBlogs {
+				if _, ok := blogOfFile(blogDir, filepath.Join(blogDir, filepath.FromSlash(filename))); ok {
+					blogFiles = append(blogFiles, filename)
+				}
+			}
+			slices.Sort(blogFiles)
+			if !metaChanged && len(blogFiles) == 0 {
+				changedBlogs = map[string]bool{}
+				continue
+			}
+
+			// errors are reported, the next save tries again
+			if err := w.syncFunc(metaChanged, blogFiles); err != nil {
+				slog.Error("sync failed", "error", err)
+				fmt.Printf("Sync failed: %s\n", err)
+			} else {
+				fmt.Printf("Synced at %s\n", time.Now().Format(time.TimeOnly))
+			}
+
+			metaChanged = false
+			changedBlogs = map[string]bool{}
+		}
+	}
+}
+
+// watches dir and the directories below it, returns the files in them
+func watchTree(watcher *fsnotify.Watcher, dir string) ([]string, error) {
+	files := []string{}
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() {
+			files = append(files, path)
+			return nil
+		}
+		if path != dir && strings.HasPrefix(entry.Name(), ".") {
+			return fs.SkipDir
+		}
+		if err := watcher.Add(path); err != nil {
+			return fmt.Errorf("watch %q failed: %w", path, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return files, fmt.Errorf("watchTree: %w", err)
+	}
+	return files, nil
+}
+
+// filename of the blog a changed file is, false if it isn't one
+func blogOfFile(blogDir, name string) (string, bool) {
+	filename, err := filepath.Rel(blogDir, name)
+	if err != nil || strings.HasPrefix(filename, "..") || !isBlogFile(filepath.Base(name)) {
+		return "", false
+	}
+
+	// other markdown files of a blog with an index.md are its assets
+	dir := filepath.Dir(name)
+	if dir != blogDir && filepath.Base(name) != bundleIndex {
+		if _, err := os.Stat(filepath.Join(dir, bundleIndex)); err == nil {
+			return "", false
+		}
+	}
+	return filepath.ToSlash(filename), true
+}
+
+// logs in again and retries once if the token was rejected
+func (w *Watcher) syncWithLogin(metaChanged bool, blogFiles []string) error {
+	err := w.syncChanged(metaChanged, blogFiles)
+	if !errors.Is(err, client.ErrorForbidden) {
+		return err
+	}
+
+	slog.Info("token rejected, logging in again")
+	if err := w.login(); err != nil {
+		return fmt.Errorf("syncWithLogin: %w", err)
+	}
+	return w.syncChanged(metaChanged, blogFiles)
+}
+
+// creates and updates the changed targets, like syncAll without deletes
+func (w *Watcher) syncChanged(metaChanged bool, blogFiles []string) error {
+	slog.Info("syncChanged", "meta", metaChanged, "blogs", blogFiles)
+
+	tags, err := w.helper.GetAllTags()
+	if err != nil {
+		return fmt.Errorf("syncChanged: failed to get tags from server: %w", err)
+	}
+	topics, err := w.helper.GetAllTopics()
+	if err != nil {
+		return fmt.Errorf("syncChanged: failed to get topics from server: %w", err)
+	}
+	series, err := w.helper.GetAllSeries()
+	if err != nil {
+		return fmt.Errorf("syncChanged: failed to get series from server: %w", err)
+	}
+
+	if metaChanged {
+		metafile, err := loadMetaFile(filepath.Join(w.sourcePath, "meta.yaml"))
+		if err != nil {
+			return fmt.Errorf("syncChanged: load meta file failed: %w", err)
+		}
+
+		groupedTopics, err := groupTopics(metafile.Topics, topics)
+		if err != nil {
+			return fmt.Errorf("syncChanged: group topics failed: %w", err)
+		}
+		groupedTags, err := groupTags(metafile.Tags, tags)
+		if err != nil {
+			return fmt.Errorf("syncChanged: group tags failed: %w", err)
+		}
+		groupedSeries, err := groupSeries(metafile.Series, series)
+		if err != nil {
+			return fmt.Errorf("syncChanged: group series failed: %w", err)
+		}
+		if len(groupedTopics.delete)+len(groupedTags.delete)+len(groupedSeries.delete) > 0 {
+			slog.Warn("topics, tags or series removed from meta.yaml are kept, run sync to delete them")
+		}
+
+		newTopics, err := w.helper.CreateTopics(groupedTopics.create)
+		if err != nil {
+			return fmt.Errorf("syncChanged: create topics failed: %w", err)
+		}
+		newTags, err := w.helper.CreateTags(groupedTags.create)
+		if err != nil {
+			return fmt.Errorf("syncChanged: create tags failed: %w", err)
+		}
+		newSeries, err := w.helper.CreateSeries(groupedSeries.create)
+		if err != nil {
+			return fmt.Errorf("syncChanged: create series failed: %w", err)
+		}
+		if _, err := w.helper.UpdateTopics(groupedTopics.update); err != nil {
+			return fmt.Errorf("syncChanged: update topics failed: %w", err)
+		}
+		if _, err := w.helper.UpdateTags(groupedTags.update); err != nil {
+			return fmt.Errorf("syncChanged: update tags failed: %w", err)
+		}
+		if _, err := w.helper.UpdateSeries(groupedSeries.update); err != nil {
+			return fmt.Errorf("syncChanged: update series failed: %w", err)
+		}
+
+		// nothing was deleted, the slugs and ids of updated targets didn't change
+		topics = slices.Concat(topics, newTopics)
+		tags = slices.Concat(tags, newTags)
+		series = slices.Concat(series, newSeries)
+	}
+
+	if len(blogFiles) == 0 {
+		return nil
+	}
+
+	idFile := filepath.Join(w.sourcePath, "ids.json")
+	idMap, err := loadIDMap(idFile)
+	if err != nil {
+		return fmt.Errorf("syncChanged: load id map failed: %w", err)
+	}
+
+	// a broken file or conflict doesn't stop the others from syncing
+	blogDir := filepath.Join(w.sourcePath, "blogs")
+	localBlogs := []BlogInfo{}
+	errs := []error{}
+	for _, filename := range blogFiles {
+		blog, err := loadBlog(blogDir, filename, idMap)
+		if errors.Is(err, fs.ErrNotExist) {
+			slog.Warn("blog removed locally, run sync to delete it on the server", "filename", filename)
+			continue
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		linked, err := w.helper.LinkImages([]BlogInfo{blog})
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		localBlogs = append(localBlogs, linked...)
+	}
+
+	if len(localBlogs) > 0 {
+		blogs, err := w.helper.GetAllBlogs()
+		if err != nil {
+			return fmt.Errorf("syncChanged: failed to get blogs from server: %w", err)
+		}
+
+		groupedBlogs, err := groupBlogs(localBlogs, blogs)
+		if err != nil {
+			return fmt.Errorf("syncChanged: group blogs failed: %w", err)
+		}
+		// every blog that didn't change is here
+		groupedBlogs.delete = []entities.OutBlogSimple{}
+
+		// conflicts are never resolved automatically while writing
+		stateFile := filepath.Join(w.sourcePath, "sync-state.json")
+		state, err := loadSyncState(stateFile)
+		if err != nil {
+			return fmt.Errorf("syncChanged: load sync state failed: %w", err)
+		}
+		blogsToUpdate, conflicts, err := findConflicts(groupedBlogs.update, blogs, state, blogDir)
+		if err != nil {
+			return fmt.Errorf("syncChanged: find conflicts failed: %w", err)
+		}
+		_, unresolved, err := w.helper.ResolveConflicts(conflicts, "", state)
+		if err != nil {
+			return fmt.Errorf("syncChanged: resolve conflicts failed: %w", err)
+		}
+		groupedBlogs.update = blogsToUpdate
+
+		blogMaper := NewBlogMaper(tags, topics, series, w.sourcePath)
+		mappedBlogs, err := blogMaper.MapIDs(groupedBlogs)
+		if err != nil {
+			return fmt.Errorf("syncChanged: transform blogs failed: %w", err)
+		}
+
+		media, err := w.helper.GetAllMedia()
+		if err != nil {
+			return fmt.Errorf("syncChanged: failed to get media from server: %w", err)
+		}
+		if err := w.helper.UploadImages(imagesOf(mappedBlogs.create, mappedBlogs.update), media); err != nil {
+			return fmt.Errorf("syncChanged: upload images failed: %w", err)
+		}
+
+		newIDMapping, err := w.helper.CreateBlogs(mappedBlogs.create)
+		if err != nil {
+			return fmt.Errorf("syncChanged: create blogs failed: %w", err)
+		}
+		if err := w.helper.UpdateBlogs(mappedBlogs.update); err != nil {
+			return fmt.Errorf("syncChanged: update blogs failed: %w", err)
+		}
+
+		// keep the ids of blogs that didn't change
+		for filename, id := range newIDMapping {
+			idMap[filename] = id
+		}
+		existingBlogs := slices.Concat(groupedBlogs.update, groupedBlogs.noop)
+		if err := updateIDMapping(existingBlogs, idMap, idFile); err != nil {
+			return fmt.Errorf("syncChanged: updated id mapping failed: %w", err)
+		}
+
+		// record the synced blogs, the rest keep their state
+		remoteBlogs, err := w.helper.GetAllBlogs()
+		if err != nil {
+			return fmt.Errorf("syncChanged: failed to get blogs from server: %w", err)
+		}
+		syncedIDs := map[string]int{}
+		for _, blog := range localBlogs {
+			if id, ok := idMap[blog.Filename]; ok {
+				syncedIDs[blog.Filename] = id
+			}
+		}
+		syncedState, err := newSyncState(blogDir, syncedIDs, remoteBlogs, unresolved)
+		if err != nil {
+			return fmt.Errorf("syncChanged: record sync state failed: %w", err)
+		}
+		for filename, blogState := range syncedState {
+			state[filename] = blogState
+		}
+		if err := state.save(stateFile); err != nil {
+			return fmt.Errorf("syncChanged: save sync state failed: %w", err)
+		}
+
+		if len(unresolved) > 0 {
+			errs = append(errs, fmt.Errorf("%w: merge the server versions in %s files into %d blogs and remove them", ErrorConflicts, remoteSuffix, len(unresolved)))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("syncChanged: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// checked before logging in, so a wrong path fails early
+func checkSourcePath(sourcePath string) error {
+	for _, dir := range []string{sourcePath, filepath.Join(sourcePath, "blogs")} {
+		info, err := os.Stat(dir)
+		if err != nil {
+			return fmt.Errorf("checkSourcePath: %w", err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("checkSourcePath: %q is not a directory", dir)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+type watchCall struct {
+	metaChanged bool
+	blogFiles   []string
+}
+
+func TestWatcherDebounce(t *testing.T) {
+	sourcePath := t.TempDir()
+	blogDir := filepath.Join(sourcePath, "blogs")
+	if err := os.Mkdir(blogDir, 0755); err != nil {
+		t.Fatalf("TestWatcherDebounce: create blog dir failed: %s", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	calls := make(chan watchCall, 10)
+	w := NewWatcher(ctx, Credentials{}, "", sourcePath, 1, 200*time.Millisecond)
+	w.syncFunc = func(metaChanged bool, blogFiles []string) error {
+		calls <- watchCall{metaChanged, blogFiles}
+		return nil
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- w.Run()
+	}()
+	// let the watcher start
+	time.Sleep(100 * time.Millisecond)
+
+	// one burst
+	files := map[string]string{
+		filepath.Join(sourcePath, "meta.yaml"): "tags: []",
+		filepath.Join(blogDir, "b.md"):         "---\ntitle: b\n---\n",
+		filepath.Join(blogDir, "a.md"):         "---\ntitle: a\n---\n",
+		filepath.Join(blogDir, "notes.txt"):    "ignored",
+		filepath.Join(sourcePath, "other.md"):  "ignored",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatalf("TestWatcherDebounce: write %q failed: %s", name, err)
+		}
+	}
+
+	select {
+	case call := <-calls:
+		if !call.metaChanged || !slices.Equal(call.blogFiles, []string{"a.md", "b.md"}) {
+			t.Fatalf("TestWatcherDebounce: unexpected changes %+v", call)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("TestWatcherDebounce: sync wasn't called")
+	}
+
+	select {
+	case call := <-calls:
+		t.Fatalf("TestWatcherDebounce: a burst should sync once, got another %+v", call)
+	case <-time.After(400 * time.Millisecond):
+	}
+
+	// next change is synced on its own
+	if err := os.WriteFile(filepath.Join(blogDir, "a.md"), []byte("---\ntitle: a2\n---\n"), 0644); err != nil {
+		t.Fatalf("TestWatcherDebounce: write a.md failed: %s", err)
+	}
+	select {
+	case call := <-calls:
+		if call.metaChanged || !slices.Equal(call.blogFiles, []string{"a.md"}) {
+			t.Fatalf("TestWatcherDebounce: unexpected changes %+v", call)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("TestWatcherDebounce: sync wasn't called")
+	}
+
+	// new directories are watched, assets of a blog with an index.md aren't blogs
+	postDir := filepath.Join(blogDir, "post")
+	if err := os.Mkdir(postDir, 0755); err != nil {
+		t.Fatalf("TestWatcherDebounce: create post dir failed: %s", err)
+	}
+	for name, content := range map[string]string{"index.md": "---\ntitle: post\n---\n", "notes.md": "asset", "cover.png": "png"} {
+		if err := os.WriteFile(filepath.Join(postDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("TestWatcherDebounce: write %q failed: %s", name, err)
+		}
+	}
+	select {
+	case call := <-calls:
+		if call.metaChanged || !slices.Equal(call.blogFiles, []string{"post/index.md"}) {
+			t.Fatalf("TestWatcherDebounce: unexpected changes %+v", call)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("TestWatcherDebounce: sync wasn't called")
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("TestWatcherDebounce: run failed: %s", err)
+	}
+}
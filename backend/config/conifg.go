@@ -4,6 +4,12 @@ type ServerSetting struct {
 	Port            int    `json:"port"`
 	Prefix          string `json:"prefix"`
 	ShutdownTimeout int    `json:"shutdownTimeout"`
+	// header set by the reverse proxy, ex: X-Forwarded-For. The last address is used.
+	// Leave empty when serving directly, remote address of the connection will be used.
+	ClientIPHeader string `json:"clientIPHeader"`
+	// reject PUT, PATCH and DELETE of blogs, tags and topics without If-Match with 428,
+	// otherwise If-Match is only checked when sent
+	RequireIfMatch bool `json:"requireIfMatch"`
 }
 
 type LoggerSetting struct {
@@ -27,12 +33,116 @@ type LoginSetting struct {
 	RateLimit int `json:"rateLimit"` // request per second
 }
 
+type MediaSetting struct {
+	// directory for uploaded files, files are named by their sha256 hash
+	Path string `json:"path"`
+	// MB
+	MaxSize int `json:"maxSize"`
+	// hour, unused media newer than this will not be garbage collected
+	GCGracePeriod int `json:"gcGracePeriod"`
+	// directory for resized images, can be safely deleted
+	VariantPath string `json:"variantPath"`
+	// MB, least recently used variants are evicted when exceeded
+	VariantCacheSize int `json:"variantCacheSize"`
+	// px, widths offered in srcset of images in rendered blogs
+	SrcsetWidths []int `json:"srcsetWidths"`
+}
+
+type CommentsSetting struct {
+	RateLimit int `json:"rateLimit"` // comments per minute per client ip
+	// characters
+	MaxLength       int `json:"maxLength"`
+	AuthorMaxLength int `json:"authorMaxLength"`
+}
+
+type AnalyticsSetting struct {
+	RateLimit int `json:"rateLimit"` // views per minute per client ip
+	// defaults of the popular blogs list
+	PopularDays  int `json:"popularDays"`
+	PopularLimit int `json:"popularLimit"`
+}
+
+type WebhooksSetting struct {
+	// second, how often new events and due deliveries are checked
+	Interval int `json:"interval"`
+	// second, per request
+	Timeout     int `json:"timeout"`
+	MaxAttempts int `json:"maxAttempts"`
+	// second, delay before the first retry, doubled on every attempt
+	Backoff int `json:"backoff"`
+	// day, finished deliveries are kept for inspection and replay
+	Retention int `json:"retention"`
+}
+
+type EventsSetting struct {
+	// events kept in memory for clients resuming with Last-Event-ID
+	BufferSize int `json:"bufferSize"`
+	// events a client can fall behind before it is disconnected
+	SubscriberBuffer int `json:"subscriberBuffer"`
+	// second, comment sent to keep idle connections open through proxies
+	Heartbeat int `json:"heartbeat"`
+}
+
+type PreviewSetting struct {
+	// signs preview link tokens, links can't be created without it
+	Secret string `json:"secret"`
+	// hour, used when expiresIn is left out
+	Expire int `json:"expire"`
+	// hour
+	MaxExpire int `json:"maxExpire"`
+}
+
+type SchedulerSetting struct {
+	// second, how often scheduled blogs are checked for publishing
+	Interval int `json:"interval"`
+}
+
+type TrashSetting struct {
+	// day, soft deleted blogs are permanently deleted after, 0 keeps them until the trash is emptied
+	Retention int `json:"retention"`
+	// second, how often the trash is checked
+	Interval int `json:"interval"`
+}
+
+type AuditSetting struct {
+	// day, audit entries are kept for, 0 keeps them forever
+	Retention int `json:"retention"`
+	// second, how often old entries are removed
+	Interval int `json:"interval"`
+}
+
+type IdempotencySetting struct {
+	// hour, responses are replayed for retries with the same Idempotency-Key
+	TTL int `json:"ttl"`
+	// second, a key stays claimed while its first request is in progress,
+	// released after in case the server stopped before the request completed
+	Lock int `json:"lock"`
+	// second, how often expired keys are removed
+	Interval int `json:"interval"`
+}
+
+type BulkSetting struct {
+	// operations in one bulk request, all of them run in a single transaction
+	MaxOperations int `json:"maxOperations"`
+}
+
 type Config struct {
-	Server ServerSetting `json:"server"`
-	Logger LoggerSetting `json:"logger"`
-	DB     DBSetting     `json:"db"`
-	JWT    JWTSetting    `json:"jwt"`
-	Login  LoginSetting  `json:"login"`
+	Server      ServerSetting      `json:"server"`
+	Logger      LoggerSetting      `json:"logger"`
+	DB          DBSetting          `json:"db"`
+	JWT         JWTSetting         `json:"jwt"`
+	Login       LoginSetting       `json:"login"`
+	Media       MediaSetting       `json:"media"`
+	Comments    CommentsSetting    `json:"comments"`
+	Analytics   AnalyticsSetting   `json:"analytics"`
+	Webhooks    WebhooksSetting    `json:"webhooks"`
+	Events      EventsSetting      `json:"events"`
+	Preview     PreviewSetting     `json:"preview"`
+	Scheduler   SchedulerSetting   `json:"scheduler"`
+	Trash       TrashSetting       `json:"trash"`
+	Audit       AuditSetting       `json:"audit"`
+	Idempotency IdempotencySetting `json:"idempotency"`
+	Bulk        BulkSetting        `json:"bulk"`
 }
 
 func NewConfig() *Config {
@@ -57,5 +167,59 @@ func NewConfig() *Config {
 		Login: LoginSetting{
 			RateLimit: 1,
 		},
+		Media: MediaSetting{
+			Path:             "./media",
+			MaxSize:          20,
+			GCGracePeriod:    24,
+			VariantPath:      "./media-variants",
+			VariantCacheSize: 500,
+			SrcsetWidths:     []int{480, 800, 1200, 1600},
+		},
+		Comments: CommentsSetting{
+			RateLimit:       3,
+			MaxLength:       5000,
+			AuthorMaxLength: 50,
+		},
+		Analytics: AnalyticsSetting{
+			RateLimit:    30,
+			PopularDays:  30,
+			PopularLimit: 10,
+		},
+		Webhooks: WebhooksSetting{
+			Interval:    5,
+			Timeout:     10,
+			MaxAttempts: 8,
+			Backoff:     30,
+			Retention:   30,
+		},
+		Events: EventsSetting{
+			BufferSize:       1000,
+			SubscriberBuffer: 64,
+			Heartbeat:        25,
+		},
+		Preview: PreviewSetting{
+			Expire:    72,
+			MaxExpire: 720,
+		},
+		Scheduler: SchedulerSetting{
+			Interval: 30,
+		},
+		Trash: TrashSetting{
+			// purging is opt-in
+			Retention: 0,
+			Interval:  3600,
+		},
+		Audit: AuditSetting{
+			Retention: 365,
+			Interval:  3600,
+		},
+		Idempotency: IdempotencySetting{
+			TTL:      24,
+			Lock:     300,
+			Interval: 3600,
+		},
+		Bulk: BulkSetting{
+			MaxOperations: 1000,
+		},
 	}
 }
@@ -0,0 +1,49 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS media(
+  -- sha256 of the file content, also used as the filename on disk
+  hash TEXT NOT NULL UNIQUE PRIMARY KEY,
+
+  -- ISO 8061
+  created_at TEXT NOT NULL DEFAULT (strftime('%FT%T+00:00')),
+  updated_at TEXT NOT NULL DEFAULT (strftime('%FT%T+00:00')),
+
+  -- original filename, only for display
+  filename TEXT DEFAULT "",
+  mime TEXT NOT NULL,
+  size INTEGER NOT NULL DEFAULT 0,
+  -- only filled in for images
+  width INTEGER DEFAULT 0,
+  height INTEGER DEFAULT 0,
+  alt TEXT DEFAULT "",
+
+  CHECK(LENGTH(hash) = 64)
+);
+
+CREATE TRIGGER IF NOT EXISTS media_update_ts
+BEFORE UPDATE ON media
+BEGIN
+  UPDATE media SET updated_at = (strftime('%FT%T+00:00')) WHERE hash = NEW.hash;
+END;
+
+-- media referenced by blog content, maintained by the blogs repository
+CREATE TABLE IF NOT EXISTS blog_media(
+  blog_id INTEGER NOT NULL,
+  media_hash TEXT NOT NULL,
+  FOREIGN KEY(blog_id) REFERENCES blogs(id),
+  FOREIGN KEY(media_hash) REFERENCES media(hash),
+  PRIMARY KEY(blog_id, media_hash)
+);
+CREATE INDEX IF NOT EXISTS blog_media_blog ON blog_media (blog_id);
+CREATE INDEX IF NOT EXISTS blog_media_media ON blog_media (media_hash);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS blog_media;
+DROP INDEX IF EXISTS blog_media_blog;
+DROP INDEX IF EXISTS blog_media_media;
+
+DROP TABLE IF EXISTS media;
+DROP TRIGGER IF EXISTS media_update_ts;
+-- +goose StatementEnd
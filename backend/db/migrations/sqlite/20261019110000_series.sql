@@ -0,0 +1,43 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS series(
+  id INTEGER NOT NULL UNIQUE PRIMARY KEY AUTOINCREMENT,
+
+  -- ISO 8061
+  created_at TEXT NOT NULL DEFAULT (strftime('%FT%T+00:00')),
+  updated_at TEXT NOT NULL DEFAULT (strftime('%FT%T+00:00')),
+
+  name TEXT NOT NULL UNIQUE,
+  description TEXT DEFAULT "",
+  slug TEXT NOT NULL UNIQUE,
+
+  CHECK(LENGTH(name) > 0)
+);
+
+CREATE TRIGGER IF NOT EXISTS series_update_ts
+BEFORE UPDATE ON series
+BEGIN
+  UPDATE series SET updated_at = (strftime('%FT%T+00:00')) WHERE id = NEW.id;
+END;
+
+-- a blog can only be part of one series.
+-- parts are ordered by position, ties are broken by blog id
+CREATE TABLE IF NOT EXISTS blog_series(
+  blog_id INTEGER NOT NULL UNIQUE PRIMARY KEY,
+  series_id INTEGER NOT NULL,
+  position INTEGER NOT NULL,
+  FOREIGN KEY(blog_id) REFERENCES blogs(id),
+  FOREIGN KEY(series_id) REFERENCES series(id),
+  CHECK(position > 0)
+);
+CREATE INDEX IF NOT EXISTS blog_series_series ON blog_series (series_id, position);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS blog_series;
+DROP INDEX IF EXISTS blog_series_series;
+
+DROP TABLE IF EXISTS series;
+DROP TRIGGER IF EXISTS series_update_ts;
+-- +goose StatementEnd
@@ -0,0 +1,41 @@
+-- +goose Up
+-- +goose StatementBegin
+-- replies are removed together with their parent comment
+CREATE TABLE IF NOT EXISTS comments(
+  id INTEGER NOT NULL UNIQUE PRIMARY KEY AUTOINCREMENT,
+
+  -- ISO 8061
+  created_at TEXT NOT NULL DEFAULT (strftime('%FT%T+00:00')),
+  updated_at TEXT NOT NULL DEFAULT (strftime('%FT%T+00:00')),
+
+  blog_id INTEGER NOT NULL,
+  parent_id INTEGER DEFAULT NULL,
+  author TEXT NOT NULL,
+  content TEXT NOT NULL,
+  status TEXT NOT NULL DEFAULT 'pending',
+
+  FOREIGN KEY(blog_id) REFERENCES blogs(id),
+  FOREIGN KEY(parent_id) REFERENCES comments(id),
+  CHECK(LENGTH(author) > 0),
+  CHECK(LENGTH(content) > 0),
+  CHECK(status IN ('pending', 'approved', 'rejected'))
+);
+CREATE INDEX IF NOT EXISTS comments_blog ON comments (blog_id, status);
+CREATE INDEX IF NOT EXISTS comments_parent ON comments (parent_id);
+CREATE INDEX IF NOT EXISTS comments_status ON comments (status, created_at);
+
+CREATE TRIGGER IF NOT EXISTS comments_update_ts
+BEFORE UPDATE ON comments
+BEGIN
+  UPDATE comments SET updated_at = (strftime('%FT%T+00:00')) WHERE id = NEW.id;
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS comments_blog;
+DROP INDEX IF EXISTS comments_parent;
+DROP INDEX IF EXISTS comments_status;
+DROP TABLE IF EXISTS comments;
+DROP TRIGGER IF EXISTS comments_update_ts;
+-- +goose StatementEnd
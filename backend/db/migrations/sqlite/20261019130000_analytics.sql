@@ -0,0 +1,44 @@
+-- +goose Up
+-- +goose StatementBegin
+-- daily aggregates, day is YYYY-MM-DD in UTC.
+-- referrer is the host only, empty for direct visits.
+CREATE TABLE IF NOT EXISTS blog_views_daily(
+  day TEXT NOT NULL,
+  blog_id INTEGER NOT NULL,
+  referrer TEXT NOT NULL DEFAULT '',
+  views INTEGER NOT NULL DEFAULT 0,
+  PRIMARY KEY(day, blog_id, referrer),
+  FOREIGN KEY(blog_id) REFERENCES blogs(id)
+);
+CREATE INDEX IF NOT EXISTS blog_views_daily_blog ON blog_views_daily (blog_id, day);
+
+CREATE TABLE IF NOT EXISTS blog_visitors_daily(
+  day TEXT NOT NULL,
+  blog_id INTEGER NOT NULL,
+  visitors INTEGER NOT NULL DEFAULT 0,
+  PRIMARY KEY(day, blog_id),
+  FOREIGN KEY(blog_id) REFERENCES blogs(id)
+);
+CREATE INDEX IF NOT EXISTS blog_visitors_daily_blog ON blog_visitors_daily (blog_id, day);
+
+-- salted hashes of ip and user agent, only used to count unique visitors of the current day.
+-- the salt changes every day and is never stored, older rows are removed.
+CREATE TABLE IF NOT EXISTS blog_visitor_hashes(
+  day TEXT NOT NULL,
+  blog_id INTEGER NOT NULL,
+  hash TEXT NOT NULL,
+  PRIMARY KEY(day, blog_id, hash),
+  FOREIGN KEY(blog_id) REFERENCES blogs(id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS blog_views_daily_blog;
+DROP TABLE IF EXISTS blog_views_daily;
+
+DROP INDEX IF EXISTS blog_visitors_daily_blog;
+DROP TABLE IF EXISTS blog_visitors_daily;
+
+DROP TABLE IF EXISTS blog_visitor_hashes;
+-- +goose StatementEnd
@@ -0,0 +1,83 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS webhooks(
+  id INTEGER NOT NULL UNIQUE PRIMARY KEY AUTOINCREMENT,
+
+  -- ISO 8061
+  created_at TEXT NOT NULL DEFAULT (strftime('%FT%T+00:00')),
+  updated_at TEXT NOT NULL DEFAULT (strftime('%FT%T+00:00')),
+
+  url TEXT NOT NULL,
+  secret TEXT NOT NULL,
+  -- comma separated event types, '*' for all
+  events TEXT NOT NULL DEFAULT '*',
+  paused INTEGER NOT NULL DEFAULT 0,
+
+  CHECK(LENGTH(url) > 0),
+  CHECK(LENGTH(secret) > 0)
+);
+
+CREATE TRIGGER IF NOT EXISTS webhooks_update_ts
+BEFORE UPDATE ON webhooks
+BEGIN
+  UPDATE webhooks SET updated_at = (strftime('%FT%T+00:00')) WHERE id = NEW.id;
+END;
+
+-- written in the same transaction as the change,
+-- events are fanned out to webhook_deliveries by the dispatcher
+CREATE TABLE IF NOT EXISTS webhook_outbox(
+  id INTEGER NOT NULL UNIQUE PRIMARY KEY AUTOINCREMENT,
+
+  -- ISO 8061
+  created_at TEXT NOT NULL DEFAULT (strftime('%FT%T+00:00')),
+
+  type TEXT NOT NULL,
+  -- json
+  data TEXT NOT NULL,
+  dispatched INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS webhook_outbox_dispatched ON webhook_outbox (dispatched, id);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries(
+  id INTEGER NOT NULL UNIQUE PRIMARY KEY AUTOINCREMENT,
+
+  -- ISO 8061
+  created_at TEXT NOT NULL DEFAULT (strftime('%FT%T+00:00')),
+  updated_at TEXT NOT NULL DEFAULT (strftime('%FT%T+00:00')),
+
+  webhook_id INTEGER NOT NULL,
+  event_id INTEGER NOT NULL,
+  status TEXT NOT NULL DEFAULT 'pending',
+  attempts INTEGER NOT NULL DEFAULT 0,
+  next_attempt_at TEXT NOT NULL DEFAULT (strftime('%FT%T+00:00')),
+  -- of the last attempt
+  response_status INTEGER NOT NULL DEFAULT 0,
+  error TEXT NOT NULL DEFAULT '',
+
+  FOREIGN KEY(webhook_id) REFERENCES webhooks(id),
+  FOREIGN KEY(event_id) REFERENCES webhook_outbox(id),
+  CHECK(status IN ('pending', 'succeeded', 'failed'))
+);
+CREATE INDEX IF NOT EXISTS webhook_deliveries_due ON webhook_deliveries (status, next_attempt_at);
+CREATE INDEX IF NOT EXISTS webhook_deliveries_webhook ON webhook_deliveries (webhook_id, id);
+CREATE INDEX IF NOT EXISTS webhook_deliveries_event ON webhook_deliveries (event_id);
+
+CREATE TRIGGER IF NOT EXISTS webhook_deliveries_update_ts
+BEFORE UPDATE ON webhook_deliveries
+BEGIN
+  UPDATE webhook_deliveries SET updated_at = (strftime('%FT%T+00:00')) WHERE id = NEW.id;
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS webhook_outbox_dispatched;
+DROP INDEX IF EXISTS webhook_deliveries_due;
+DROP INDEX IF EXISTS webhook_deliveries_webhook;
+DROP INDEX IF EXISTS webhook_deliveries_event;
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhook_outbox;
+DROP TABLE IF EXISTS webhooks;
+DROP TRIGGER IF EXISTS webhooks_update_ts;
+DROP TRIGGER IF EXISTS webhook_deliveries_update_ts;
+-- +goose StatementEnd
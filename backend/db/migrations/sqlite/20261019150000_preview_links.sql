@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+-- revoking a link deletes its row, tokens of missing rows are rejected
+CREATE TABLE IF NOT EXISTS preview_links(
+  id INTEGER NOT NULL UNIQUE PRIMARY KEY AUTOINCREMENT,
+
+  -- ISO 8061
+  created_at TEXT NOT NULL DEFAULT (strftime('%FT%T+00:00')),
+  updated_at TEXT NOT NULL DEFAULT (strftime('%FT%T+00:00')),
+  expires_at TEXT NOT NULL,
+
+  blog_id INTEGER NOT NULL,
+  note TEXT NOT NULL DEFAULT '',
+
+  FOREIGN KEY(blog_id) REFERENCES blogs(id)
+);
+CREATE INDEX IF NOT EXISTS preview_links_blog ON preview_links (blog_id);
+
+CREATE TRIGGER IF NOT EXISTS preview_links_update_ts
+BEFORE UPDATE ON preview_links
+BEGIN
+  UPDATE preview_links SET updated_at = (strftime('%FT%T+00:00')) WHERE id = NEW.id;
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS preview_links_blog;
+DROP TABLE IF EXISTS preview_links;
+DROP TRIGGER IF EXISTS preview_links_update_ts;
+-- +goose StatementEnd
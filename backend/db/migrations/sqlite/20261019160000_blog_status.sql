@@ -0,0 +1,45 @@
+-- +goose Up
+-- +goose StatementBegin
+-- visible is kept in sync with status for older clients, only published blogs are visible
+ALTER TABLE blogs ADD COLUMN status TEXT NOT NULL DEFAULT 'draft'
+  CHECK(status IN ('draft', 'in_review', 'scheduled', 'published', 'archived'));
+-- ISO 8061, empty unless scheduled
+ALTER TABLE blogs ADD COLUMN scheduled_at TEXT NOT NULL DEFAULT '';
+
+-- backfill without touching updated_at
+DROP TRIGGER IF EXISTS blogs_update_ts;
+UPDATE blogs SET status = 'published' WHERE visible = 1;
+CREATE TRIGGER IF NOT EXISTS blogs_update_ts
+BEFORE UPDATE ON blogs
+BEGIN
+  UPDATE blogs SET updated_at = (strftime('%FT%T+00:00')) WHERE id = NEW.id;
+END;
+
+CREATE INDEX IF NOT EXISTS blogs_status ON blogs (status, scheduled_at);
+
+CREATE TABLE IF NOT EXISTS blog_status_events(
+  id INTEGER NOT NULL UNIQUE PRIMARY KEY AUTOINCREMENT,
+
+  -- ISO 8061
+  created_at TEXT NOT NULL DEFAULT (strftime('%FT%T+00:00')),
+
+  blog_id INTEGER NOT NULL,
+  -- empty when the blog was created
+  from_status TEXT NOT NULL DEFAULT '',
+  to_status TEXT NOT NULL,
+  actor TEXT NOT NULL DEFAULT '',
+  note TEXT NOT NULL DEFAULT '',
+
+  FOREIGN KEY(blog_id) REFERENCES blogs(id)
+);
+CREATE INDEX IF NOT EXISTS blog_status_events_blog ON blog_status_events (blog_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS blog_status_events_blog;
+DROP TABLE IF EXISTS blog_status_events;
+DROP INDEX IF EXISTS blogs_status;
+ALTER TABLE blogs DROP COLUMN scheduled_at;
+ALTER TABLE blogs DROP COLUMN status;
+-- +goose StatementEnd
@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- user who soft deleted the blog, empty unless soft deleted
+ALTER TABLE blogs ADD COLUMN deleted_by TEXT NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS blogs_deleted_at ON blogs (deleted_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS blogs_deleted_at;
+ALTER TABLE blogs DROP COLUMN deleted_by;
+-- +goose StatementEnd
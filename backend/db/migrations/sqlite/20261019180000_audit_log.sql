@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS audit_log(
+  id INTEGER NOT NULL UNIQUE PRIMARY KEY AUTOINCREMENT,
+
+  -- ISO 8061
+  created_at TEXT NOT NULL DEFAULT (strftime('%FT%T+00:00')),
+
+  -- jwt sub
+  actor TEXT NOT NULL DEFAULT '',
+  -- method and path
+  route TEXT NOT NULL,
+  target_type TEXT NOT NULL,
+  -- empty when unknown, such as bulk deletes
+  target_id TEXT NOT NULL DEFAULT '',
+  -- json object of changed fields, {"field": [before, after]}
+  diff TEXT NOT NULL DEFAULT '{}',
+  client_ip TEXT NOT NULL DEFAULT '',
+  request_id TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS audit_log_created_at ON audit_log (created_at);
+CREATE INDEX IF NOT EXISTS audit_log_actor ON audit_log (actor);
+CREATE INDEX IF NOT EXISTS audit_log_target ON audit_log (target_type, target_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS audit_log_target;
+DROP INDEX IF EXISTS audit_log_actor;
+DROP INDEX IF EXISTS audit_log_created_at;
+DROP TABLE IF EXISTS audit_log;
+-- +goose StatementEnd
@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS idempotency_keys(
+  -- user name of the client, keys are only unique per user
+  actor TEXT NOT NULL,
+  -- Idempotency-Key header
+  key TEXT NOT NULL,
+  -- sha256 of the method, path and body
+  request_hash TEXT NOT NULL,
+  -- 0 while the first request is in progress
+  status INTEGER NOT NULL DEFAULT 0,
+  content_type TEXT NOT NULL DEFAULT '',
+  response BLOB NOT NULL DEFAULT '',
+
+  -- ISO 8061
+  created_at TEXT NOT NULL DEFAULT (strftime('%FT%T+00:00')),
+  -- ISO 8061, the key can be reused after
+  expires_at TEXT NOT NULL,
+
+  PRIMARY KEY (actor, key)
+);
+CREATE INDEX IF NOT EXISTS idempotency_keys_expires_at ON idempotency_keys (expires_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idempotency_keys_expires_at;
+DROP TABLE IF EXISTS idempotency_keys;
+-- +goose StatementEnd
@@ -0,0 +1,57 @@
+-- +goose Up
+-- +goose StatementBegin
+-- bumped on every update of the row, for optimistic concurrency control.
+-- updated_at only has a resolution of one second and can't tell writes within the same second apart
+ALTER TABLE blogs ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE tags ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE topics ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+
+DROP TRIGGER IF EXISTS blogs_update_ts;
+CREATE TRIGGER IF NOT EXISTS blogs_update_ts
+BEFORE UPDATE ON blogs
+BEGIN
+  UPDATE blogs SET updated_at = (strftime('%FT%T+00:00')), version = version + 1 WHERE id = NEW.id;
+END;
+
+DROP TRIGGER IF EXISTS tags_update_ts;
+CREATE TRIGGER IF NOT EXISTS tags_update_ts
+BEFORE UPDATE ON tags
+BEGIN
+  UPDATE tags SET updated_at = (strftime('%FT%T+00:00')), version = version + 1 WHERE id = NEW.id;
+END;
+
+DROP TRIGGER IF EXISTS topics_update_ts;
+CREATE TRIGGER IF NOT EXISTS topics_update_ts
+BEFORE UPDATE ON topics
+BEGIN
+  UPDATE topics SET updated_at = (strftime('%FT%T+00:00')), version = version + 1 WHERE id = NEW.id;
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS blogs_update_ts;
+CREATE TRIGGER IF NOT EXISTS blogs_update_ts
+BEFORE UPDATE ON blogs
+BEGIN
+  UPDATE blogs SET updated_at = (strftime('%FT%T+00:00')) WHERE id = NEW.id;
+END;
+
+DROP TRIGGER IF EXISTS tags_update_ts;
+CREATE TRIGGER IF NOT EXISTS tags_update_ts
+BEFORE UPDATE ON tags
+BEGIN
+  UPDATE tags SET updated_at = (strftime('%FT%T+00:00')) WHERE id = NEW.id;
+END;
+
+DROP TRIGGER IF EXISTS topics_update_ts;
+CREATE TRIGGER IF NOT EXISTS topics_update_ts
+BEFORE UPDATE ON topics
+BEGIN
+  UPDATE topics SET updated_at = (strftime('%FT%T+00:00')) WHERE id = NEW.id;
+END;
+
+ALTER TABLE topics DROP COLUMN version;
+ALTER TABLE tags DROP COLUMN version;
+ALTER TABLE blogs DROP COLUMN version;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+package interfaces
+
+import (
+	"blog/entities"
+	"context"
+	"database/sql"
+)
+
+// Concrete implementations are at db/models/<db name>/
+type AuditModel interface {
+	Create(ctx context.Context, tx *sql.Tx, entry entities.AuditEntry) (*entities.AuditEntry, error)
+	// Newest first
+	List(ctx context.Context, db *sql.DB, filter entities.AuditFilter) ([]entities.AuditEntry, error)
+	// Removes entries created before the given ISO 8601 time
+	DeleteBefore(ctx context.Context, tx *sql.Tx, before string) (int, error)
+}
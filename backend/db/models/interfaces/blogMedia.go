@@ -0,0 +1,16 @@
+package interfaces
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Concrete implementations are at db/models/<db name>/
+type BlogMediaModel interface {
+	// replace all media relations of a blog, hashes that doesn't exist in media are ignored
+	Replace(ctx context.Context, tx *sql.Tx, blogID int, hashes []string) error
+	Delete(ctx context.Context, tx *sql.Tx, blogID int) error
+	ListBlogIDsByHash(ctx context.Context, db *sql.DB, hash string) ([]int, error)
+	// hash -> width in px of the images a blog links to, media without a known width is left out
+	ListWidthsByBlogID(ctx context.Context, db *sql.DB, blogID int) (map[string]int, error)
+}
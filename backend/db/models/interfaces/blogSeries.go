@@ -0,0 +1,21 @@
+package interfaces
+
+import (
+	"blog/entities"
+	"context"
+	"database/sql"
+)
+
+// Concrete implementations are at db/models/<db name>/
+type BlogSeriesModel interface {
+	// position 0 keeps the current position, or appends the blog to the end of the series
+	Upsert(ctx context.Context, tx *sql.Tx, blogID, seriesID, position int) error
+	Delete(ctx context.Context, tx *sql.Tx, blogID int) error
+	DeleteBySeriesID(ctx context.Context, tx *sql.Tx, seriesID int) error
+
+	// only visible and none soft deleted blogs
+	ListParts(ctx context.Context, db *sql.DB, seriesID int) ([]entities.SeriesPart, error)
+	ListPartsInTx(ctx context.Context, tx *sql.Tx, seriesID int) ([]entities.SeriesPart, error)
+	// all blogs regardless of visibility and soft delete status
+	AdminListParts(ctx context.Context, db *sql.DB, seriesID int) ([]entities.SeriesPart, error)
+}
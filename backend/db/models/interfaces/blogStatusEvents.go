@@ -0,0 +1,15 @@
+package interfaces
+
+import (
+	"blog/entities"
+	"context"
+	"database/sql"
+)
+
+// Concrete implementations are at db/models/<db name>/
+type BlogStatusEventsModel interface {
+	Create(ctx context.Context, tx *sql.Tx, event entities.BlogStatusEvent) error
+	// Oldest first
+	ListByBlogID(ctx context.Context, db *sql.DB, blogID int) ([]entities.BlogStatusEvent, error)
+	DeleteByBlogID(ctx context.Context, tx *sql.Tx, blogID int) error
+}
@@ -11,17 +11,25 @@ import (
 type BlogsModel interface {
 	Create(ctx context.Context, tx *sql.Tx, blog entities.InBlog) (*entities.Blog, error)
 	CreateWithID(ctx context.Context, tx *sql.Tx, blog entities.InBlog, id int) (*entities.Blog, error)
-	Update(ctx context.Context, tx *sql.Tx, blog entities.InBlog, id int) (*entities.Blog, error)
+	// version checks are atomic, see entities.Version
+	Update(ctx context.Context, tx *sql.Tx, blog entities.InBlog, id int, version entities.Version) (*entities.Blog, error)
+	// only updates the columns present in the patch
+	Patch(ctx context.Context, tx *sql.Tx, patch entities.BlogPatch, id int, version entities.Version) (*entities.Blog, error)
 	Get(ctx context.Context, db *sql.DB, id int) (*entities.Blog, error)
 	List(ctx context.Context, db *sql.DB) ([]entities.Blog, error)
 	ListByTopicIDs(ctx context.Context, db *sql.DB, topicID []int) ([]entities.Blog, error)
 	ListByTopicAndTagIDs(ctx context.Context, db *sql.DB, topicIDs, tagIDs []int) ([]entities.Blog, error)
 	AdminGet(ctx context.Context, db *sql.DB, id int) (*entities.Blog, error)
+	AdminGetInTx(ctx context.Context, tx *sql.Tx, id int) (*entities.Blog, error)
 	AdminList(ctx context.Context, db *sql.DB) ([]entities.Blog, error)
 	AdminListByTopicIDs(ctx context.Context, db *sql.DB, topicIDs []int) ([]entities.Blog, error)
 	AdminListByTopicAndTagIDs(ctx context.Context, db *sql.DB, topicID, tagID []int) ([]entities.Blog, error)
-	SoftDelete(ctx context.Context, tx *sql.Tx, id int) (int, error)
-	Delete(ctx context.Context, tx *sql.Tx, id int) (int, error)
-	DeleteNow(ctx context.Context, tx *sql.Tx, id int) (int, error)
+	SoftDelete(ctx context.Context, tx *sql.Tx, id int, deletedBy string, version entities.Version) (int, error)
+	Delete(ctx context.Context, tx *sql.Tx, id int, version entities.Version) (int, error)
+	DeleteNow(ctx context.Context, tx *sql.Tx, id int, version entities.Version) (int, error)
 	RestoreDeleted(ctx context.Context, tx *sql.Tx, id int) (*entities.Blog, error)
+	UpdateStatus(ctx context.Context, tx *sql.Tx, id int, from, status, scheduledAt string) (*entities.Blog, error)
+	ListScheduledBefore(ctx context.Context, db *sql.DB, before string) ([]entities.Blog, error)
+	ListDeleted(ctx context.Context, db *sql.DB) ([]entities.Blog, error)
+	ListDeletedBefore(ctx context.Context, db *sql.DB, before string) ([]entities.Blog, error)
 }
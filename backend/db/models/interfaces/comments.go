@@ -0,0 +1,21 @@
+package interfaces
+
+import (
+	"blog/entities"
+	"context"
+	"database/sql"
+)
+
+// Concrete implementations are at db/models/<db name>/
+type CommentsModel interface {
+	Create(ctx context.Context, tx *sql.Tx, comment entities.Comment) (*entities.Comment, error)
+	Get(ctx context.Context, db *sql.DB, id int) (*entities.Comment, error)
+	// Oldest first
+	ListByBlogID(ctx context.Context, db *sql.DB, blogID int, status string) ([]entities.Comment, error)
+	// Oldest first, across all blogs
+	ListByStatus(ctx context.Context, db *sql.DB, status string) ([]entities.Comment, error)
+	UpdateStatus(ctx context.Context, tx *sql.Tx, id int, status string) (*entities.Comment, error)
+	// Deletes the comment and all of its replies
+	Delete(ctx context.Context, tx *sql.Tx, id int) (int, error)
+	DeleteByBlogID(ctx context.Context, tx *sql.Tx, blogID int) error
+}
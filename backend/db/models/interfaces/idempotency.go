@@ -0,0 +1,21 @@
+package interfaces
+
+import (
+	"blog/entities"
+	"context"
+	"database/sql"
+)
+
+// Concrete implementations are at db/models/<db name>/
+type IdempotencyModel interface {
+	// returns false if the actor already has the key
+	Create(ctx context.Context, tx *sql.Tx, key entities.IdempotencyKey) (bool, error)
+	Get(ctx context.Context, db *sql.DB, actor, key string) (*entities.IdempotencyKey, error)
+	// stores the response of a key in progress
+	Complete(ctx context.Context, tx *sql.Tx, key entities.IdempotencyKey) (int, error)
+	// only deletes keys in progress
+	DeleteInProgress(ctx context.Context, tx *sql.Tx, actor, key string) (int, error)
+	// before is ISO 8601, compared with expires_at
+	DeleteExpired(ctx context.Context, tx *sql.Tx, before string) (int, error)
+	DeleteExpiredKey(ctx context.Context, tx *sql.Tx, actor, key, before string) (int, error)
+}
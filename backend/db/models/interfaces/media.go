@@ -0,0 +1,19 @@
+package interfaces
+
+import (
+	"blog/entities"
+	"context"
+	"database/sql"
+)
+
+// Concrete implementations are at db/models/<db name>/
+type MediaModel interface {
+	Create(ctx context.Context, tx *sql.Tx, media entities.Media) (*entities.Media, error)
+	Get(ctx context.Context, db *sql.DB, hash string) (*entities.Media, error)
+	List(ctx context.Context, db *sql.DB) ([]entities.Media, error)
+	// media not referenced by any blog and created before the given timestamp (ISO 8601)
+	ListUnused(ctx context.Context, db *sql.DB, before string) ([]entities.Media, error)
+	// only deletes media that isn't referenced by any blog
+	Delete(ctx context.Context, tx *sql.Tx, hash string) (int, error)
+	DeleteUnused(ctx context.Context, tx *sql.Tx, before string) ([]entities.Media, error)
+}
@@ -0,0 +1,17 @@
+package interfaces
+
+import (
+	"blog/entities"
+	"context"
+	"database/sql"
+)
+
+// Concrete implementations are at db/models/<db name>/
+type PreviewLinksModel interface {
+	Create(ctx context.Context, tx *sql.Tx, link entities.PreviewLink) (*entities.PreviewLink, error)
+	Get(ctx context.Context, db *sql.DB, id int) (*entities.PreviewLink, error)
+	// Newest first, including expired links
+	ListByBlogID(ctx context.Context, db *sql.DB, blogID int) ([]entities.PreviewLink, error)
+	Delete(ctx context.Context, tx *sql.Tx, id, blogID int) (int, error)
+	DeleteByBlogID(ctx context.Context, tx *sql.Tx, blogID int) error
+}
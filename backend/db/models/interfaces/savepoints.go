@@ -0,0 +1,15 @@
+package interfaces
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Concrete implementations are at db/models/<db name>/
+// Undoes part of a transaction, names are chosen by the caller and not user input
+type SavepointsModel interface {
+	Create(ctx context.Context, tx *sql.Tx, name string) error
+	Release(ctx context.Context, tx *sql.Tx, name string) error
+	// also releases the savepoint
+	RollbackTo(ctx context.Context, tx *sql.Tx, name string) error
+}
@@ -0,0 +1,19 @@
+package interfaces
+
+import (
+	"blog/entities"
+	"context"
+	"database/sql"
+)
+
+// Concrete implementations are at db/models/<db name>/
+type SeriesModel interface {
+	Create(ctx context.Context, tx *sql.Tx, series entities.Series) (*entities.Series, error)
+	List(ctx context.Context, db *sql.DB) ([]entities.Series, error)
+	Get(ctx context.Context, db *sql.DB, id int) (*entities.Series, error)
+	// returns sql.ErrNoRows if the blog is not part of a series
+	GetByBlogID(ctx context.Context, db *sql.DB, blogID int) (*entities.BlogSeries, error)
+	GetByBlogIDInTx(ctx context.Context, tx *sql.Tx, blogID int) (*entities.BlogSeries, error)
+	Update(ctx context.Context, tx *sql.Tx, series entities.Series, id int) (*entities.Series, error)
+	Delete(ctx context.Context, tx *sql.Tx, id int) (int, error)
+}
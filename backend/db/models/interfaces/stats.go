@@ -0,0 +1,24 @@
+package interfaces
+
+import (
+	"blog/entities"
+	"context"
+	"database/sql"
+)
+
+// Concrete implementations are at db/models/<db name>/
+// Days are YYYY-MM-DD in UTC, ranges include both ends.
+type StatsModel interface {
+	// Count a view, the visitor is only counted once per blog and day
+	RecordView(ctx context.Context, tx *sql.Tx, day string, blogID int, referrer, visitorHash string) error
+	// Visitor hashes are useless once their day is over
+	DeleteVisitorHashesBefore(ctx context.Context, tx *sql.Tx, day string) error
+	// Blogs with at least one view, most viewed first
+	ListSummary(ctx context.Context, db *sql.DB, from, to string) ([]entities.BlogStatsSummary, error)
+	// Days without views are omitted
+	ListDaily(ctx context.Context, db *sql.DB, blogID int, from, to string) ([]entities.DailyStats, error)
+	ListReferrers(ctx context.Context, db *sql.DB, blogID int, from, to string) ([]entities.ReferrerStats, error)
+	// Visible and not soft deleted blogs only
+	ListPopular(ctx context.Context, db *sql.DB, from string, limit int) ([]entities.PopularBlog, error)
+	DeleteByBlogID(ctx context.Context, tx *sql.Tx, blogID int) error
+}
@@ -10,10 +10,14 @@ import (
 type TagsModel interface {
 	Create(ctx context.Context, tx *sql.Tx, tag entities.Tag) (*entities.Tag, error)
 	ListByBlogID(ctx context.Context, db *sql.DB, blogID int) ([]entities.Tag, error)
+	ListByBlogIDInTx(ctx context.Context, tx *sql.Tx, blogID int) ([]entities.Tag, error)
 	ListSlugByBlogID(ctx context.Context, db *sql.DB, blogID int) ([]string, error)
 	List(ctx context.Context, db *sql.DB) ([]entities.Tag, error)
 	ListByTopicID(ctx context.Context, db *sql.DB, topicID int) ([]entities.Tag, error)
 	Get(ctx context.Context, db *sql.DB, id int) (*entities.Tag, error)
-	Update(ctx context.Context, tx *sql.Tx, tag entities.Tag, id int) (*entities.Tag, error)
-	Delete(ctx context.Context, tx *sql.Tx, id int) (int, error)
+	GetInTx(ctx context.Context, tx *sql.Tx, id int) (*entities.Tag, error)
+	// version checks are atomic, see entities.Version
+	Update(ctx context.Context, tx *sql.Tx, tag entities.Tag, id int, version entities.Version) (*entities.Tag, error)
+	Patch(ctx context.Context, tx *sql.Tx, patch entities.TagPatch, id int, version entities.Version) (*entities.Tag, error)
+	Delete(ctx context.Context, tx *sql.Tx, id int, version entities.Version) (int, error)
 }
@@ -10,9 +10,13 @@ import (
 type TopicsModel interface {
 	Create(ctx context.Context, tx *sql.Tx, topic entities.Topic) (*entities.Topic, error)
 	ListByBlogID(ctx context.Context, db *sql.DB, blog_id int) ([]entities.Topic, error)
+	ListByBlogIDInTx(ctx context.Context, tx *sql.Tx, blog_id int) ([]entities.Topic, error)
 	ListSlugByBlogID(ctx context.Context, db *sql.DB, blogID int) ([]string, error)
 	List(ctx context.Context, db *sql.DB) ([]entities.Topic, error)
 	Get(ctx context.Context, db *sql.DB, id int) (*entities.Topic, error)
-	Update(ctx context.Context, tx *sql.Tx, topic entities.Topic, id int) (*entities.Topic, error)
-	Delete(ctx context.Context, tx *sql.Tx, id int) (int, error)
+	GetInTx(ctx context.Context, tx *sql.Tx, id int) (*entities.Topic, error)
+	// version checks are atomic, see entities.Version
+	Update(ctx context.Context, tx *sql.Tx, topic entities.Topic, id int, version entities.Version) (*entities.Topic, error)
+	Patch(ctx context.Context, tx *sql.Tx, patch entities.TopicPatch, id int, version entities.Version) (*entities.Topic, error)
+	Delete(ctx context.Context, tx *sql.Tx, id int, version entities.Version) (int, error)
 }
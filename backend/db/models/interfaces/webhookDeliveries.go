@@ -0,0 +1,24 @@
+package interfaces
+
+import (
+	"blog/entities"
+	"context"
+	"database/sql"
+)
+
+// Concrete implementations are at db/models/<db name>/
+// Deliveries are returned with their event.
+type WebhookDeliveriesModel interface {
+	Create(ctx context.Context, tx *sql.Tx, webhookID, eventID int) error
+	// Newest first
+	ListByWebhookID(ctx context.Context, db *sql.DB, webhookID, limit int) ([]entities.WebhookDelivery, error)
+	// Pending deliveries of webhooks not paused with next_attempt_at before the given ISO 8601 time, oldest first
+	ListDue(ctx context.Context, db *sql.DB, now string, limit int) ([]entities.WebhookDelivery, error)
+	// Saves status, attempts, next_attempt_at, response_status and error
+	UpdateAttempt(ctx context.Context, tx *sql.Tx, delivery entities.WebhookDelivery) error
+	// Sets the delivery back to pending with attempts reset
+	Replay(ctx context.Context, tx *sql.Tx, id int) (*entities.WebhookDelivery, error)
+	// Removes succeeded and failed deliveries last updated before the given ISO 8601 time
+	DeleteFinishedBefore(ctx context.Context, tx *sql.Tx, before string) (int, error)
+	DeleteByWebhookID(ctx context.Context, tx *sql.Tx, webhookID int) error
+}
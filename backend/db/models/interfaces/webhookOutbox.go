@@ -0,0 +1,18 @@
+package interfaces
+
+import (
+	"blog/entities"
+	"context"
+	"database/sql"
+)
+
+// Concrete implementations are at db/models/<db name>/
+type WebhookOutboxModel interface {
+	// data is json
+	Create(ctx context.Context, tx *sql.Tx, eventType string, data []byte) error
+	// Oldest first
+	ListUndispatched(ctx context.Context, db *sql.DB, limit int) ([]entities.WebhookEvent, error)
+	MarkDispatched(ctx context.Context, tx *sql.Tx, id int) error
+	// Removes dispatched events created before the given ISO 8601 time that have no deliveries left
+	DeleteDispatchedBefore(ctx context.Context, tx *sql.Tx, before string) (int, error)
+}
@@ -0,0 +1,17 @@
+package interfaces
+
+import (
+	"blog/entities"
+	"context"
+	"database/sql"
+)
+
+// Concrete implementations are at db/models/<db name>/
+type WebhooksModel interface {
+	Create(ctx context.Context, tx *sql.Tx, webhook entities.Webhook) (*entities.Webhook, error)
+	Get(ctx context.Context, db *sql.DB, id int) (*entities.Webhook, error)
+	List(ctx context.Context, db *sql.DB) ([]entities.Webhook, error)
+	// Empty secret keeps the current one
+	Update(ctx context.Context, tx *sql.Tx, webhook entities.Webhook, id int) (*entities.Webhook, error)
+	Delete(ctx context.Context, tx *sql.Tx, id int) (int, error)
+}
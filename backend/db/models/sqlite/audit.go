@@ -0,0 +1,170 @@
+package sqlite
+
+import (
+	"blog/entities"
+	"blog/util"
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+type Audit struct{}
+
+func NewAudit() *Audit {
+	return &Audit{}
+}
+
+func (a *Audit) Create(ctx context.Context, tx *sql.Tx, entry entities.AuditEntry) (*entities.AuditEntry, error) {
+	stmt := `
+	INSERT INTO audit_log
+	(
+		actor,
+		route,
+		target_type,
+		target_id,
+		diff,
+		client_ip,
+		request_id
+	)
+	VALUES
+	( ?, ?, ?, ?, ?, ?, ? )
+	RETURNING
+		id,
+		created_at,
+		actor,
+		route,
+		target_type,
+		target_id,
+		diff,
+		client_ip,
+		request_id;
+	`
+	util.LogQuery(ctx, "CreateAuditEntry:", stmt)
+
+	row := tx.QueryRowContext(
+		ctx,
+		stmt,
+		entry.Actor,
+		entry.Route,
+		entry.TargetType,
+		entry.TargetID,
+		string(entry.Diff),
+		entry.ClientIP,
+		entry.RequestID,
+	)
+	if err := row.Err(); err != nil {
+		return &entities.AuditEntry{}, fmt.Errorf("Create: insert audit entry failed: %w", err)
+	}
+
+	newEntry := entities.AuditEntry{}
+	var diff string
+	err := row.Scan(
+		&newEntry.ID,
+		&newEntry.Created_at,
+		&newEntry.Actor,
+		&newEntry.Route,
+		&newEntry.TargetType,
+		&newEntry.TargetID,
+		&diff,
+		&newEntry.ClientIP,
+		&newEntry.RequestID,
+	)
+	if err != nil {
+		return &entities.AuditEntry{}, fmt.Errorf("Create: scan audit entry failed: %w", err)
+	}
+	newEntry.Diff = []byte(diff)
+
+	return &newEntry, nil
+}
+
+// empty filter fields match all
+func (a *Audit) List(ctx context.Context, db *sql.DB, filter entities.AuditFilter) ([]entities.AuditEntry, error) {
+	stmt := `
+	SELECT
+		id,
+		created_at,
+		actor,
+		route,
+		target_type,
+		target_id,
+		diff,
+		client_ip,
+		request_id
+	FROM audit_log
+	WHERE
+		(? = '' OR actor = ?) AND
+		(? = '' OR target_type = ?) AND
+		(? = '' OR target_id = ?) AND
+		(? = '' OR created_at >= ?) AND
+		(? = '' OR created_at <= ?) AND
+		(? = 0 OR id < ?)
+	ORDER BY id DESC
+	LIMIT ?;
+	`
+	util.LogQuery(ctx, "ListAuditEntries:", stmt)
+
+	rows, err := db.QueryContext(
+		ctx,
+		stmt,
+		filter.Actor, filter.Actor,
+		filter.TargetType, filter.TargetType,
+		filter.TargetID, filter.TargetID,
+		filter.From, filter.From,
+		filter.To, filter.To,
+		filter.Before, filter.Before,
+		filter.Limit,
+	)
+	if err != nil {
+		return []entities.AuditEntry{}, fmt.Errorf("List: query failed: %w", err)
+	}
+
+	result := []entities.AuditEntry{}
+	for {
+		if !rows.Next() {
+			break
+		}
+		entry := entities.AuditEntry{}
+		var diff string
+		err := rows.Scan(
+			&entry.ID,
+			&entry.Created_at,
+			&entry.Actor,
+			&entry.Route,
+			&entry.TargetType,
+			&entry.TargetID,
+			&diff,
+			&entry.ClientIP,
+			&entry.RequestID,
+		)
+		if err != nil {
+			if err := rows.Close(); err != nil {
+				return []entities.AuditEntry{}, fmt.Errorf("List: close rows failed: %w", err)
+			}
+			return []entities.AuditEntry{}, fmt.Errorf("List: scan failed: %w", err)
+		}
+		entry.Diff = []byte(diff)
+		result = append(result, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return []entities.AuditEntry{}, fmt.Errorf("List: iterate rows failed: %w", err)
+	}
+
+	return result, nil
+}
+
+func (a *Audit) DeleteBefore(ctx context.Context, tx *sql.Tx, before string) (int, error) {
+	stmt := `DELETE FROM audit_log WHERE created_at < ?;`
+	util.LogQuery(ctx, "DeleteAuditEntriesBefore:", stmt)
+
+	res, err := tx.ExecContext(ctx, stmt, before)
+	if err != nil {
+		return 0, fmt.Errorf("DeleteBefore: delete error: %w", err)
+	}
+
+	affectedRows, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("DeleteBefore: get affected rows error: %w", err)
+	}
+
+	return int(affectedRows), nil
+}
@@ -0,0 +1,140 @@
+package sqlite
+
+import (
+	"blog/util"
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+type BlogMedia struct{}
+
+func NewBlogMedia() *BlogMedia {
+	return &BlogMedia{}
+}
+
+func (b *BlogMedia) Replace(ctx context.Context, tx *sql.Tx, blogID int, hashes []string) error {
+	if err := b.Delete(ctx, tx, blogID); err != nil {
+		return fmt.Errorf("Replace: delete old relations failed: %w", err)
+	}
+
+	if len(hashes) == 0 {
+		return nil
+	}
+
+	valueStrings := make([]string, 0, len(hashes))
+	valueArgs := make([]any, 0, len(hashes)+1)
+	valueArgs = append(valueArgs, blogID)
+
+	for _, hash := range hashes {
+		valueStrings = append(valueStrings, "?")
+		valueArgs = append(valueArgs, hash)
+	}
+
+	// links to media that doesn't exist are simply skipped
+	stmt := fmt.Sprintf(
+		`
+	INSERT OR IGNORE INTO blog_media
+	(
+		blog_id,
+		media_hash
+	)
+	SELECT ?, hash FROM media WHERE hash IN (%s)`,
+		strings.Join(valueStrings, ","),
+	)
+	util.LogQuery(ctx, "ReplaceBlogMedia:", stmt)
+
+	if _, err := tx.ExecContext(ctx, stmt, valueArgs...); err != nil {
+		return fmt.Errorf("Replace: insert blog_media failed: %w", err)
+	}
+
+	return nil
+}
+
+func (b *BlogMedia) Delete(ctx context.Context, tx *sql.Tx, blogID int) error {
+	stmt := `DELETE FROM blog_media WHERE blog_id = ?;`
+
+	util.LogQuery(ctx, "DeleteBlogMedia:", stmt)
+
+	res, err := tx.ExecContext(ctx, stmt, blogID)
+	if err != nil {
+		return fmt.Errorf("Delete: exec context failed: %w", err)
+	}
+	affectedRows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("Delete: aquire affected rows failed: %w", err)
+	}
+	slog.Debug("affected rows", "rows", affectedRows)
+
+	return nil
+}
+
+func (b *BlogMedia) ListBlogIDsByHash(ctx context.Context, db *sql.DB, hash string) ([]int, error) {
+	stmt := `SELECT blog_id FROM blog_media WHERE media_hash = ? ORDER BY blog_id;`
+
+	util.LogQuery(ctx, "ListBlogIDsByHash:", stmt)
+
+	rows, err := db.QueryContext(ctx, stmt, hash)
+	if err != nil {
+		return []int{}, fmt.Errorf("ListBlogIDsByHash: query context failed: %w", err)
+	}
+
+	result := []int{}
+	for {
+		id := 0
+		if !rows.Next() {
+			break
+		}
+		if err := rows.Scan(&id); err != nil {
+			if err := rows.Close(); err != nil {
+				return []int{}, fmt.Errorf("ListBlogIDsByHash: close rows failed: %w", err)
+			}
+			return []int{}, fmt.Errorf("ListBlogIDsByHash: scan failed: %w", err)
+		}
+		result = append(result, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return []int{}, fmt.Errorf("ListBlogIDsByHash: rows iteration error: %w", err)
+	}
+
+	return result, nil
+}
+
+func (b *BlogMedia) ListWidthsByBlogID(ctx context.Context, db *sql.DB, blogID int) (map[string]int, error) {
+	stmt := `
+	SELECT m.hash, m.width FROM blog_media bm
+	JOIN media m ON m.hash = bm.media_hash
+	WHERE bm.blog_id = ? AND m.width > 0;`
+
+	util.LogQuery(ctx, "ListWidthsByBlogID:", stmt)
+
+	rows, err := db.QueryContext(ctx, stmt, blogID)
+	if err != nil {
+		return map[string]int{}, fmt.Errorf("ListWidthsByBlogID: query context failed: %w", err)
+	}
+
+	result := map[string]int{}
+	for {
+		hash := ""
+		width := 0
+		if !rows.Next() {
+			break
+		}
+		if err := rows.Scan(&hash, &width); err != nil {
+			if err := rows.Close(); err != nil {
+				return map[string]int{}, fmt.Errorf("ListWidthsByBlogID: close rows failed: %w", err)
+			}
+			return map[string]int{}, fmt.Errorf("ListWidthsByBlogID: scan failed: %w", err)
+		}
+		result[hash] = width
+	}
+
+	if err := rows.Err(); err != nil {
+		return map[string]int{}, fmt.Errorf("ListWidthsByBlogID: rows iteration error: %w", err)
+	}
+
+	return result, nil
+}
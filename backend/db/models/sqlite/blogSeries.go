@@ -0,0 +1,187 @@
+package sqlite
+
+import (
+	"blog/entities"
+	"blog/util"
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+)
+
+type BlogSeries struct{}
+
+func NewBlogSeries() *BlogSeries {
+	return &BlogSeries{}
+}
+
+// Position 0 keeps the current position if the blog is already in the series,
+// otherwise appends it to the end.
+func (b *BlogSeries) Upsert(ctx context.Context, tx *sql.Tx, blogID, seriesID, position int) error {
+	stmt := `
+	INSERT INTO blog_series
+	(
+		blog_id,
+		series_id,
+		position
+	)
+	VALUES
+	(
+		?,
+		?,
+		CASE WHEN ? > 0 THEN ?
+		ELSE IFNULL(
+			-- already in this series, keep current position
+			(SELECT position FROM blog_series WHERE blog_id = ? AND series_id = ?),
+			-- append to the end
+			(SELECT IFNULL(MAX(position), 0) + 1 FROM blog_series WHERE series_id = ?)
+		)
+		END
+	)
+	ON CONFLICT(blog_id) DO UPDATE SET
+		series_id = excluded.series_id,
+		position = excluded.position;
+	`
+
+	util.LogQuery(ctx, "UpsertBlogSeries:", stmt)
+
+	if _, err := tx.ExecContext(ctx, stmt, blogID, seriesID, position, position, blogID, seriesID, seriesID); err != nil {
+		return fmt.Errorf("Upsert: upsert blog_series failed: %w", err)
+	}
+
+	return nil
+}
+
+func (b *BlogSeries) Delete(ctx context.Context, tx *sql.Tx, blogID int) error {
+	stmt := `DELETE FROM blog_series WHERE blog_id = ?;`
+	util.LogQuery(ctx, "DeleteBlogSeries:", stmt)
+
+	res, err := tx.ExecContext(ctx, stmt, blogID)
+	if err != nil {
+		return fmt.Errorf("Delete: exec context failed: %w", err)
+	}
+	affectedRows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("Delete: aquire affected rows failed: %w", err)
+	}
+	slog.Debug("affected rows", "rows", affectedRows)
+
+	return nil
+}
+
+func (b *BlogSeries) DeleteBySeriesID(ctx context.Context, tx *sql.Tx, seriesID int) error {
+	stmt := `DELETE FROM blog_series WHERE series_id = ?;`
+	util.LogQuery(ctx, "DeleteBlogSeriesBySeriesID:", stmt)
+
+	res, err := tx.ExecContext(ctx, stmt, seriesID)
+	if err != nil {
+		return fmt.Errorf("DeleteBySeriesID: exec context failed: %w", err)
+	}
+	affectedRows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("DeleteBySeriesID: aquire affected rows failed: %w", err)
+	}
+	slog.Debug("affected rows", "rows", affectedRows)
+
+	return nil
+}
+
+// only return visible and none soft deleted blogs
+func (b *BlogSeries) ListParts(ctx context.Context, db *sql.DB, seriesID int) ([]entities.SeriesPart, error) {
+	return b.listParts(ctx, db, seriesID)
+}
+
+// same as ListParts, sees the changes made earlier in tx
+func (b *BlogSeries) ListPartsInTx(ctx context.Context, tx *sql.Tx, seriesID int) ([]entities.SeriesPart, error) {
+	return b.listParts(ctx, tx, seriesID)
+}
+
+func (b *BlogSeries) listParts(ctx context.Context, db rowsQuerier, seriesID int) ([]entities.SeriesPart, error) {
+	stmt := `
+	SELECT
+		blog_series.position,
+		blogs.id,
+		blogs.title,
+		blogs.slug,
+		blogs.description
+	FROM blog_series INNER JOIN blogs
+	ON blog_series.blog_id = blogs.id
+	WHERE
+		blog_series.series_id = ?
+	AND blogs.status = 'published'
+	AND blogs.deleted_at = ""
+	ORDER BY blog_series.position, blogs.id;
+	`
+	util.LogQuery(ctx, "ListSeriesParts:", stmt)
+
+	rows, err := db.QueryContext(ctx, stmt, seriesID)
+	if err != nil {
+		return []entities.SeriesPart{}, fmt.Errorf("ListParts: query failed: %w", err)
+	}
+
+	result, err := scanSeriesPartRows(rows)
+	if err != nil {
+		return []entities.SeriesPart{}, fmt.Errorf("ListParts: scan parts failed: %w", err)
+	}
+
+	return result, nil
+}
+
+func (b *BlogSeries) AdminListParts(ctx context.Context, db *sql.DB, seriesID int) ([]entities.SeriesPart, error) {
+	stmt := `
+	SELECT
+		blog_series.position,
+		blogs.id,
+		blogs.title,
+		blogs.slug,
+		blogs.description
+	FROM blog_series INNER JOIN blogs
+	ON blog_series.blog_id = blogs.id
+	WHERE
+		blog_series.series_id = ?
+	ORDER BY blog_series.position, blogs.id;
+	`
+	util.LogQuery(ctx, "AdminListSeriesParts:", stmt)
+
+	rows, err := db.QueryContext(ctx, stmt, seriesID)
+	if err != nil {
+		return []entities.SeriesPart{}, fmt.Errorf("AdminListParts: query failed: %w", err)
+	}
+
+	result, err := scanSeriesPartRows(rows)
+	if err != nil {
+		return []entities.SeriesPart{}, fmt.Errorf("AdminListParts: scan parts failed: %w", err)
+	}
+
+	return result, nil
+}
+
+func scanSeriesPartRows(rows *sql.Rows) ([]entities.SeriesPart, error) {
+	result := []entities.SeriesPart{}
+	for {
+		if !rows.Next() {
+			break
+		}
+		part := entities.SeriesPart{}
+		err := rows.Scan(
+			&part.Part,
+			&part.ID,
+			&part.Title,
+			&part.Slug,
+			&part.Description,
+		)
+		if err != nil {
+			if err := rows.Close(); err != nil {
+				return []entities.SeriesPart{}, fmt.Errorf("scanSeriesPartRows: close rows failed: %w", err)
+			}
+			return []entities.SeriesPart{}, fmt.Errorf("scanSeriesPartRows: scan failed: %w", err)
+		}
+		result = append(result, part)
+	}
+
+	if err := rows.Err(); err != nil {
+		return []entities.SeriesPart{}, fmt.Errorf("scanSeriesPartRows: rows iteration error: %w", err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,93 @@
+package sqlite
+
+import (
+	"blog/entities"
+	"blog/util"
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+type BlogStatusEvents struct{}
+
+func NewBlogStatusEvents() *BlogStatusEvents {
+	return &BlogStatusEvents{}
+}
+
+func (b *BlogStatusEvents) Create(ctx context.Context, tx *sql.Tx, event entities.BlogStatusEvent) error {
+	stmt := `
+	INSERT INTO blog_status_events
+	(
+		blog_id,
+		from_status,
+		to_status,
+		actor,
+		note
+	)
+	VALUES
+	( ?, ?, ?, ?, ? );
+	`
+	util.LogQuery(ctx, "CreateBlogStatusEvent:", stmt)
+
+	if _, err := tx.ExecContext(ctx, stmt, event.BlogID, event.From, event.To, event.Actor, event.Note); err != nil {
+		return fmt.Errorf("Create: insert blog status event failed: %w", err)
+	}
+
+	return nil
+}
+
+func (b *BlogStatusEvents) ListByBlogID(ctx context.Context, db *sql.DB, blogID int) ([]entities.BlogStatusEvent, error) {
+	stmt := `
+	SELECT * FROM blog_status_events
+	WHERE
+		blog_id = ?
+	ORDER BY id;
+	`
+	util.LogQuery(ctx, "ListBlogStatusEventsByBlogID:", stmt)
+
+	rows, err := db.QueryContext(ctx, stmt, blogID)
+	if err != nil {
+		return []entities.BlogStatusEvent{}, fmt.Errorf("ListByBlogID: query failed: %w", err)
+	}
+
+	result := []entities.BlogStatusEvent{}
+	for {
+		if !rows.Next() {
+			break
+		}
+		event := entities.BlogStatusEvent{}
+		err := rows.Scan(
+			&event.ID,
+			&event.Created_at,
+			&event.BlogID,
+			&event.From,
+			&event.To,
+			&event.Actor,
+			&event.Note,
+		)
+		if err != nil {
+			if err := rows.Close(); err != nil {
+				return []entities.BlogStatusEvent{}, fmt.Errorf("ListByBlogID: close rows failed: %w", err)
+			}
+			return []entities.BlogStatusEvent{}, fmt.Errorf("ListByBlogID: scan failed: %w", err)
+		}
+		result = append(result, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return []entities.BlogStatusEvent{}, fmt.Errorf("ListByBlogID: rows iteration error: %w", err)
+	}
+
+	return result, nil
+}
+
+func (b *BlogStatusEvents) DeleteByBlogID(ctx context.Context, tx *sql.Tx, blogID int) error {
+	stmt := `DELETE FROM blog_status_events WHERE blog_id = ?;`
+	util.LogQuery(ctx, "DeleteBlogStatusEventsByBlogID:", stmt)
+
+	if _, err := tx.ExecContext(ctx, stmt, blogID); err != nil {
+		return fmt.Errorf("DeleteByBlogID: delete error: %w", err)
+	}
+
+	return nil
+}
@@ -28,10 +28,12 @@ func (b *Blogs) Create(ctx context.Context, tx *sql.Tx, blog entities.InBlog) (*
 		description,
 		slug,
 		pined,
-		visible
+		visible,
+		status,
+		scheduled_at
 	)
 	VALUES
-	( ?, ?, ?, ?, ?, ?, ?)
+	( ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	RETURNING *;
 	`
 
@@ -47,6 +49,8 @@ func (b *Blogs) Create(ctx context.Context, tx *sql.Tx, blog entities.InBlog) (*
 		blog.Slug,
 		blog.Pined,
 		blog.Visible,
+		blog.Status,
+		blog.ScheduledAt,
 	)
 	if err := row.Err(); err != nil {
 		return &entities.Blog{}, fmt.Errorf("Create: insert blog failed: %w", err)
@@ -71,10 +75,12 @@ func (b *Blogs) CreateWithID(ctx context.Context, tx *sql.Tx, blog entities.InBl
 		description,
 		slug,
 		pined,
-		visible
+		visible,
+		status,
+		scheduled_at
 	)
 	VALUES
-	( ?, ?, ?, ?, ?, ?, ?, ?)
+	( ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	RETURNING *;
 	`
 
@@ -91,6 +97,8 @@ func (b *Blogs) CreateWithID(ctx context.Context, tx *sql.Tx, blog entities.InBl
 		blog.Slug,
 		blog.Pined,
 		blog.Visible,
+		blog.Status,
+		blog.ScheduledAt,
 	)
 	if err := row.Err(); err != nil {
 		return &entities.Blog{}, fmt.Errorf("CreateWithID: insert blog failed: %w", err)
@@ -104,7 +112,8 @@ func (b *Blogs) CreateWithID(ctx context.Context, tx *sql.Tx, blog entities.InBl
 	return newBlog, nil
 }
 
-func (b *Blogs) Update(ctx context.Context, tx *sql.Tx, blog entities.InBlog, id int) (*entities.Blog, error) {
+// returns sql.ErrNoRows if the blog isn't at the given version
+func (b *Blogs) Update(ctx context.Context, tx *sql.Tx, blog entities.InBlog, id int, version entities.Version) (*entities.Blog, error) {
 	stmt := `
 	UPDATE blogs 
 	SET
@@ -114,9 +123,12 @@ func (b *Blogs) Update(ctx context.Context, tx *sql.Tx, blog entities.InBlog, id
 		description = ?,
 		slug = ?,
 		pined = ?,
-		visible = ?
+		visible = ?,
+		status = ?,
+		scheduled_at = ?
 	WHERE 
 		id = ?
+	AND ` + versionCond + `
 	RETURNING *;
 	`
 	util.LogQuery(ctx, "UpdateBlog:", stmt)
@@ -131,7 +143,11 @@ func (b *Blogs) Update(ctx context.Context, tx *sql.Tx, blog entities.InBlog, id
 		blog.Slug,
 		blog.Pined,
 		blog.Visible,
+		blog.Status,
+		blog.ScheduledAt,
 		id,
+		version,
+		version,
 	)
 	if err := row.Err(); err != nil {
 		return &entities.Blog{}, fmt.Errorf("Update: update blog failed: %w", err)
@@ -145,10 +161,48 @@ func (b *Blogs) Update(ctx context.Context, tx *sql.Tx, blog entities.InBlog, id
 	return newBlog, nil
 }
 
-// only return visible and none soft deleted blogs
+// only updates the columns present in the patch, returns sql.ErrNoRows if the blog isn't at the given version
+func (b *Blogs) Patch(ctx context.Context, tx *sql.Tx, patch entities.BlogPatch, id int, version entities.Version) (*entities.Blog, error) {
+	set := &setClause{}
+	setColumn(set, "title", patch.Title)
+	setColumn(set, "content", patch.Content)
+	setColumn(set, "content_md5", patch.ContentMD5)
+	setColumn(set, "description", patch.Description)
+	setColumn(set, "slug", patch.Slug)
+	setColumn(set, "pined", patch.Pined)
+	setColumn(set, "visible", patch.Visible)
+	setColumn(set, "status", patch.Status)
+	setColumn(set, "scheduled_at", patch.ScheduledAt)
+
+	stmt := fmt.Sprintf(`
+	UPDATE blogs
+	SET
+		%s
+	WHERE
+		id = ?
+	AND %s
+	RETURNING *;
+	`, set, versionCond)
+	util.LogQuery(ctx, "PatchBlog:", stmt)
+
+	args := append(set.args, id, version, version)
+	row := tx.QueryRowContext(ctx, stmt, args...)
+	if err := row.Err(); err != nil {
+		return &entities.Blog{}, fmt.Errorf("Patch: patch blog failed: %w", err)
+	}
+
+	blog, err := scanBlog(row)
+	if err != nil {
+		return &entities.Blog{}, fmt.Errorf("Patch: scan blog failed: %w", err)
+	}
+
+	return blog, nil
+}
+
+// only return published and none soft deleted blogs
 func (b *Blogs) Get(ctx context.Context, db *sql.DB, id int) (*entities.Blog, error) {
 	stmt := `
-	SELECT * FROM blogs WHERE id = ? AND visible = 1 AND deleted_at = "";
+	SELECT * FROM blogs WHERE id = ? AND status = 'published' AND deleted_at = "";
 	`
 	util.LogQuery(ctx, "GetBlog:", stmt)
 
@@ -165,7 +219,7 @@ func (b *Blogs) Get(ctx context.Context, db *sql.DB, id int) (*entities.Blog, er
 	return blog, nil
 }
 
-// only return visible and none soft deleted blogs
+// only return published and none soft deleted blogs
 func (b *Blogs) List(ctx context.Context, db *sql.DB) ([]entities.Blog, error) {
 	stmt := `
 	SELECT
@@ -178,9 +232,13 @@ func (b *Blogs) List(ctx context.Context, db *sql.DB) ([]entities.Blog, error) {
 		description,
 		slug,
 		pined,
-		visible
+		visible,
+		status,
+		scheduled_at,
+		deleted_by,
+		version
 	FROM blogs 
-	WHERE visible = 1 AND deleted_at = ""
+	WHERE status = 'published' AND deleted_at = ""
 	ORDER BY updated_at DESC;
 	`
 	util.LogQuery(ctx, "ListBlogs:", stmt)
@@ -205,7 +263,7 @@ func (b *Blogs) List(ctx context.Context, db *sql.DB) ([]entities.Blog, error) {
 	return result, nil
 }
 
-// only return visible and none soft deleted blogs
+// only return published and none soft deleted blogs
 func (b *Blogs) ListByTopicIDs(ctx context.Context, db *sql.DB, topicIDs []int) ([]entities.Blog, error) {
 	valueStrings := make([]string, 0, len(topicIDs))
 	valueArgs := make([]any, 0, len(topicIDs)+1)
@@ -230,7 +288,11 @@ func (b *Blogs) ListByTopicIDs(ctx context.Context, db *sql.DB, topicIDs []int)
 		description,
 		slug,
 		pined,
-		visible
+		visible,
+		status,
+		scheduled_at,
+		deleted_by,
+		version
 	FROM blogs
 	WHERE id IN (
 		SELECT blog_id FROM (
@@ -239,7 +301,7 @@ func (b *Blogs) ListByTopicIDs(ctx context.Context, db *sql.DB, topicIDs []int)
 			GROUP BY blog_id
 		) WHERE count = ?
 	)
-	AND visible = 1 AND deleted_at = ""
+	AND status = 'published' AND deleted_at = ""
 	ORDER BY updated_at DESC`,
 		strings.Join(valueStrings, ","),
 	)
@@ -266,7 +328,7 @@ func (b *Blogs) ListByTopicIDs(ctx context.Context, db *sql.DB, topicIDs []int)
 	return result, nil
 }
 
-// only return visible and none soft deleted blogs
+// only return published and none soft deleted blogs
 func (b *Blogs) ListByTopicAndTagIDs(ctx context.Context, db *sql.DB, topicIDs, tagIDs []int) ([]entities.Blog, error) {
 	topicValueStrings := make([]string, 0, len(topicIDs))
 	topicValueArgs := make([]any, 0, len(topicIDs))
@@ -297,7 +359,11 @@ func (b *Blogs) ListByTopicAndTagIDs(ctx context.Context, db *sql.DB, topicIDs,
 		description,
 		slug,
 		pined,
-		visible
+		visible,
+		status,
+		scheduled_at,
+		deleted_by,
+		version
 	FROM blogs
 	WHERE id IN (
 		SELECT blog_id FROM (
@@ -308,7 +374,7 @@ func (b *Blogs) ListByTopicAndTagIDs(ctx context.Context, db *sql.DB, topicIDs,
 			GROUP BY blog_id
 		) WHERE count = ?
 	) 
-	AND visible = 1 
+	AND status = 'published'
 	AND deleted_at = ""
 	ORDER BY updated_at DESC;`,
 		strings.Join(topicValueStrings, ","),
@@ -339,6 +405,15 @@ func (b *Blogs) ListByTopicAndTagIDs(ctx context.Context, db *sql.DB, topicIDs,
 
 // return blogs regardless of visiblility and soft delete status
 func (b *Blogs) AdminGet(ctx context.Context, db *sql.DB, id int) (*entities.Blog, error) {
+	return b.adminGet(ctx, db, id)
+}
+
+// same as AdminGet, sees the changes made earlier in tx
+func (b *Blogs) AdminGetInTx(ctx context.Context, tx *sql.Tx, id int) (*entities.Blog, error) {
+	return b.adminGet(ctx, tx, id)
+}
+
+func (b *Blogs) adminGet(ctx context.Context, db rowQuerier, id int) (*entities.Blog, error) {
 	stmt := `
 	SELECT * FROM blogs WHERE id = ?;
 	`
@@ -370,7 +445,11 @@ func (b *Blogs) AdminList(ctx context.Context, db *sql.DB) ([]entities.Blog, err
 		description,
 		slug,
 		pined,
-		visible
+		visible,
+		status,
+		scheduled_at,
+		deleted_by,
+		version
 	FROM blogs ORDER BY updated_at DESC;`
 
 	util.LogQuery(ctx, "AdminListBlogs:", stmt)
@@ -420,7 +499,11 @@ func (b *Blogs) AdminListByTopicIDs(ctx context.Context, db *sql.DB, topicIDs []
 		description,
 		slug,
 		pined,
-		visible
+		visible,
+		status,
+		scheduled_at,
+		deleted_by,
+		version
 	FROM blogs
 	WHERE id IN (
 		SELECT blog_id FROM (
@@ -486,7 +569,11 @@ func (b *Blogs) AdminListByTopicAndTagIDs(ctx context.Context, db *sql.DB, topic
 		description,
 		slug,
 		pined,
-		visible
+		visible,
+		status,
+		scheduled_at,
+		deleted_by,
+		version
 	FROM blogs
 	WHERE id IN (
 		SELECT blog_id FROM (
@@ -523,11 +610,12 @@ func (b *Blogs) AdminListByTopicAndTagIDs(ctx context.Context, db *sql.DB, topic
 	return result, nil
 }
 
-// mark deleted_at with current timestamp (ISO 8061)
-func (b *Blogs) SoftDelete(ctx context.Context, tx *sql.Tx, id int) (int, error) {
+// mark deleted_at with current timestamp (ISO 8061), and who deleted it
+// no rows are affected if the blog isn't at the given version
+func (b *Blogs) SoftDelete(ctx context.Context, tx *sql.Tx, id int, deletedBy string, version entities.Version) (int, error) {
 	ts := time.Now().UTC().Format("2006-01-02T15:04:05-07:00")
 	stmt := `
-	UPDATE blogs SET deleted_at = ? WHERE id = ? AND deleted_at = '';
+	UPDATE blogs SET deleted_at = ?, deleted_by = ? WHERE id = ? AND deleted_at = '' AND ` + versionCond + `;
 	`
 	util.LogQuery(ctx, "SoftDeleteBlog:", stmt)
 
@@ -535,7 +623,10 @@ func (b *Blogs) SoftDelete(ctx context.Context, tx *sql.Tx, id int) (int, error)
 		ctx,
 		stmt,
 		ts,
+		deletedBy,
 		id,
+		version,
+		version,
 	)
 	if err != nil {
 		return 0, fmt.Errorf("SoftDelete: mark timestamp failed: %w", err)
@@ -549,9 +640,10 @@ func (b *Blogs) SoftDelete(ctx context.Context, tx *sql.Tx, id int) (int, error)
 	return int(affectedRows), nil
 }
 
-func (b *Blogs) Delete(ctx context.Context, tx *sql.Tx, id int) (int, error) {
+// no rows are affected if the blog isn't at the given version
+func (b *Blogs) Delete(ctx context.Context, tx *sql.Tx, id int, version entities.Version) (int, error) {
 	stmt := `
-	DELETE FROM blogs WHERE id = ? AND deleted_at <> '';
+	DELETE FROM blogs WHERE id = ? AND deleted_at <> '' AND ` + versionCond + `;
 	`
 	util.LogQuery(ctx, "DeleteBlog:", stmt)
 
@@ -559,6 +651,8 @@ func (b *Blogs) Delete(ctx context.Context, tx *sql.Tx, id int) (int, error) {
 		ctx,
 		stmt,
 		id,
+		version,
+		version,
 	)
 	if err != nil {
 		return 0, fmt.Errorf("DeleteBlog: delete blog failed: %w", err)
@@ -572,9 +666,10 @@ func (b *Blogs) Delete(ctx context.Context, tx *sql.Tx, id int) (int, error) {
 	return int(affectedRows), nil
 }
 
-func (b *Blogs) DeleteNow(ctx context.Context, tx *sql.Tx, id int) (int, error) {
+// no rows are affected if the blog isn't at the given version
+func (b *Blogs) DeleteNow(ctx context.Context, tx *sql.Tx, id int, version entities.Version) (int, error) {
 	stmt := `
-	DELETE FROM blogs WHERE id = ?;
+	DELETE FROM blogs WHERE id = ? AND ` + versionCond + `;
 	`
 	util.LogQuery(ctx, "DeleteBlogNow:", stmt)
 
@@ -582,6 +677,8 @@ func (b *Blogs) DeleteNow(ctx context.Context, tx *sql.Tx, id int) (int, error)
 		ctx,
 		stmt,
 		id,
+		version,
+		version,
 	)
 	if err != nil {
 		return 0, fmt.Errorf("DeleteBlogNow: delete blog failed: %w", err)
@@ -597,7 +694,7 @@ func (b *Blogs) DeleteNow(ctx context.Context, tx *sql.Tx, id int) (int, error)
 
 func (b *Blogs) RestoreDeleted(ctx context.Context, tx *sql.Tx, id int) (*entities.Blog, error) {
 	stmt := `
-	UPDATE blogs SET deleted_at = "" WHERE id = ? RETURNING *;
+	UPDATE blogs SET deleted_at = "", deleted_by = "" WHERE id = ? RETURNING *;
 	`
 	util.LogQuery(ctx, "RestoreDeletedBlog:", stmt)
 
@@ -618,6 +715,174 @@ func (b *Blogs) RestoreDeleted(ctx context.Context, tx *sql.Tx, id int) (*entiti
 	return blog, nil
 }
 
+// soft deleted blogs, most recently deleted first
+func (b *Blogs) ListDeleted(ctx context.Context, db *sql.DB) ([]entities.Blog, error) {
+	stmt := `
+	SELECT
+		id,
+		created_at,
+		updated_at,
+		deleted_at,
+		title,
+		content_md5,
+		description,
+		slug,
+		pined,
+		visible,
+		status,
+		scheduled_at,
+		deleted_by,
+		version
+	FROM blogs
+	WHERE deleted_at <> ""
+	ORDER BY deleted_at DESC, id DESC;
+	`
+	util.LogQuery(ctx, "ListDeletedBlogs:", stmt)
+
+	rows, err := db.QueryContext(ctx, stmt)
+	if err != nil {
+		return []entities.Blog{}, fmt.Errorf("ListDeleted: list blogs failed: %w", err)
+	}
+
+	result := []entities.Blog{}
+	for {
+		if !rows.Next() {
+			break
+		}
+		blog, err := scanBlogRows(rows)
+		if err != nil {
+			rows.Close()
+			return []entities.Blog{}, fmt.Errorf("ListDeleted: scan row failed: %w", err)
+		}
+		result = append(result, *blog)
+	}
+	if err := rows.Err(); err != nil {
+		return []entities.Blog{}, fmt.Errorf("ListDeleted: iterate rows failed: %w", err)
+	}
+
+	return result, nil
+}
+
+// blogs soft deleted at or before the given time (ISO 8061), oldest first
+func (b *Blogs) ListDeletedBefore(ctx context.Context, db *sql.DB, before string) ([]entities.Blog, error) {
+	stmt := `
+	SELECT
+		id,
+		created_at,
+		updated_at,
+		deleted_at,
+		title,
+		content_md5,
+		description,
+		slug,
+		pined,
+		visible,
+		status,
+		scheduled_at,
+		deleted_by,
+		version
+	FROM blogs
+	WHERE deleted_at <> "" AND deleted_at <= ?
+	ORDER BY deleted_at, id;
+	`
+	util.LogQuery(ctx, "ListDeletedBlogsBefore:", stmt)
+
+	rows, err := db.QueryContext(ctx, stmt, before)
+	if err != nil {
+		return []entities.Blog{}, fmt.Errorf("ListDeletedBefore: list blogs failed: %w", err)
+	}
+
+	result := []entities.Blog{}
+	for {
+		if !rows.Next() {
+			break
+		}
+		blog, err := scanBlogRows(rows)
+		if err != nil {
+			rows.Close()
+			return []entities.Blog{}, fmt.Errorf("ListDeletedBefore: scan row failed: %w", err)
+		}
+		result = append(result, *blog)
+	}
+	if err := rows.Err(); err != nil {
+		return []entities.Blog{}, fmt.Errorf("ListDeletedBefore: iterate rows failed: %w", err)
+	}
+
+	return result, nil
+}
+
+// visible is set from status.
+// Only updates blogs still in the from status and not soft deleted, sql.ErrNoRows otherwise.
+func (b *Blogs) UpdateStatus(ctx context.Context, tx *sql.Tx, id int, from, status, scheduledAt string) (*entities.Blog, error) {
+	stmt := `
+	UPDATE blogs
+	SET
+		status = ?,
+		visible = ?,
+		scheduled_at = ?
+	WHERE
+		id = ? AND status = ? AND deleted_at = ''
+	RETURNING *;
+	`
+	util.LogQuery(ctx, "UpdateBlogStatus:", stmt)
+
+	row := tx.QueryRowContext(ctx, stmt, status, status == entities.BlogPublished, scheduledAt, id, from)
+	if err := row.Err(); err != nil {
+		return &entities.Blog{}, fmt.Errorf("UpdateStatus: update blog status failed: %w", err)
+	}
+
+	blog, err := scanBlog(row)
+	if err != nil {
+		return &entities.Blog{}, fmt.Errorf("UpdateStatus: scan blog failed: %w", err)
+	}
+
+	return blog, nil
+}
+
+// scheduled and none soft deleted blogs due at or before the given time (ISO 8061)
+func (b *Blogs) ListScheduledBefore(ctx context.Context, db *sql.DB, before string) ([]entities.Blog, error) {
+	stmt := `
+	SELECT
+		id,
+		created_at,
+		updated_at,
+		deleted_at,
+		title,
+		content_md5,
+		description,
+		slug,
+		pined,
+		visible,
+		status,
+		scheduled_at,
+		deleted_by,
+		version
+	FROM blogs
+	WHERE status = 'scheduled' AND scheduled_at <= ? AND deleted_at = ""
+	ORDER BY scheduled_at, id;
+	`
+	util.LogQuery(ctx, "ListScheduledBlogsBefore:", stmt)
+
+	rows, err := db.QueryContext(ctx, stmt, before)
+	if err != nil {
+		return []entities.Blog{}, fmt.Errorf("ListScheduledBefore: list blogs failed: %w", err)
+	}
+
+	result := []entities.Blog{}
+	for {
+		if !rows.Next() {
+			break
+		}
+		blog, err := scanBlogRows(rows)
+		if err != nil {
+			return []entities.Blog{}, fmt.Errorf("ListScheduledBefore: scan blogs failed: %w", err)
+		}
+		result = append(result, *blog)
+	}
+
+	return result, nil
+}
+
 // Helper for scanning blog
 func scanBlog(row *sql.Row) (*entities.Blog, error) {
 	newBlog := entities.Blog{}
@@ -633,6 +898,10 @@ func scanBlog(row *sql.Row) (*entities.Blog, error) {
 		&newBlog.Slug,
 		&newBlog.Pined,
 		&newBlog.Visible,
+		&newBlog.Status,
+		&newBlog.ScheduledAt,
+		&newBlog.Deleted_by,
+		&newBlog.Version,
 	)
 	if err != nil {
 		return &entities.Blog{}, fmt.Errorf("scanBlog: scan blog failed: %w", err)
@@ -653,6 +922,10 @@ func scanBlogRows(rows *sql.Rows) (*entities.Blog, error) {
 		&newBlog.Slug,
 		&newBlog.Pined,
 		&newBlog.Visible,
+		&newBlog.Status,
+		&newBlog.ScheduledAt,
+		&newBlog.Deleted_by,
+		&newBlog.Version,
 	)
 	if err != nil {
 		return &entities.Blog{}, fmt.Errorf("scanBlog: scan blog failed: %w", err)
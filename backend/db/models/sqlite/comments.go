@@ -0,0 +1,237 @@
+package sqlite
+
+import (
+	"blog/entities"
+	"blog/util"
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+type Comments struct{}
+
+func NewComments() *Comments {
+	return &Comments{}
+}
+
+func (c *Comments) Create(ctx context.Context, tx *sql.Tx, comment entities.Comment) (*entities.Comment, error) {
+	stmt := `
+	INSERT INTO comments
+	(
+		blog_id,
+		parent_id,
+		author,
+		content,
+		status
+	)
+	VALUES
+	( ?, ?, ?, ?, ? )
+	RETURNING *;
+	`
+	util.LogQuery(ctx, "CreateComment:", stmt)
+
+	// top level comments have no parent
+	var parentID sql.NullInt64
+	if comment.ParentID != 0 {
+		parentID = sql.NullInt64{Int64: int64(comment.ParentID), Valid: true}
+	}
+
+	row := tx.QueryRowContext(
+		ctx,
+		stmt,
+		comment.BlogID,
+		parentID,
+		comment.Author,
+		comment.Content,
+		comment.Status,
+	)
+	if err := row.Err(); err != nil {
+		return &entities.Comment{}, fmt.Errorf("Create: insert comment failed: %w", err)
+	}
+
+	newComment, err := scanComment(row)
+	if err != nil {
+		return &entities.Comment{}, fmt.Errorf("Create: scan error: %w", err)
+	}
+
+	return newComment, nil
+}
+
+func (c *Comments) Get(ctx context.Context, db *sql.DB, id int) (*entities.Comment, error) {
+	stmt := `SELECT * FROM comments WHERE id = ?;`
+	util.LogQuery(ctx, "GetComment:", stmt)
+
+	row := db.QueryRowContext(ctx, stmt, id)
+	if err := row.Err(); err != nil {
+		return &entities.Comment{}, fmt.Errorf("Get: query failed: %w", err)
+	}
+
+	comment, err := scanComment(row)
+	if err != nil {
+		return &entities.Comment{}, fmt.Errorf("Get: row scan failed: %w", err)
+	}
+
+	return comment, nil
+}
+
+func (c *Comments) ListByBlogID(ctx context.Context, db *sql.DB, blogID int, status string) ([]entities.Comment, error) {
+	stmt := `
+	SELECT * FROM comments
+	WHERE
+		blog_id = ? AND
+		status = ?
+	ORDER BY created_at, id;
+	`
+	util.LogQuery(ctx, "ListCommentsByBlogID:", stmt)
+
+	rows, err := db.QueryContext(ctx, stmt, blogID, status)
+	if err != nil {
+		return []entities.Comment{}, fmt.Errorf("ListByBlogID: query failed: %w", err)
+	}
+
+	comments, err := scanCommentRows(rows)
+	if err != nil {
+		return []entities.Comment{}, fmt.Errorf("ListByBlogID: %w", err)
+	}
+
+	return comments, nil
+}
+
+func (c *Comments) ListByStatus(ctx context.Context, db *sql.DB, status string) ([]entities.Comment, error) {
+	stmt := `
+	SELECT * FROM comments
+	WHERE
+		status = ?
+	ORDER BY created_at, id;
+	`
+	util.LogQuery(ctx, "ListCommentsByStatus:", stmt)
+
+	rows, err := db.QueryContext(ctx, stmt, status)
+	if err != nil {
+		return []entities.Comment{}, fmt.Errorf("ListByStatus: query failed: %w", err)
+	}
+
+	comments, err := scanCommentRows(rows)
+	if err != nil {
+		return []entities.Comment{}, fmt.Errorf("ListByStatus: %w", err)
+	}
+
+	return comments, nil
+}
+
+func (c *Comments) UpdateStatus(ctx context.Context, tx *sql.Tx, id int, status string) (*entities.Comment, error) {
+	stmt := `
+	UPDATE comments
+	SET
+		status = ?
+	WHERE
+		id = ?
+	RETURNING *;
+	`
+	util.LogQuery(ctx, "UpdateCommentStatus:", stmt)
+
+	row := tx.QueryRowContext(ctx, stmt, status, id)
+	if err := row.Err(); err != nil {
+		return &entities.Comment{}, fmt.Errorf("UpdateStatus: update query failed: %w", err)
+	}
+
+	comment, err := scanComment(row)
+	if err != nil {
+		return &entities.Comment{}, fmt.Errorf("UpdateStatus: scan error: %w", err)
+	}
+
+	return comment, nil
+}
+
+func (c *Comments) Delete(ctx context.Context, tx *sql.Tx, id int) (int, error) {
+	stmt := `
+	WITH RECURSIVE thread(id) AS (
+		SELECT id FROM comments WHERE id = ?
+		UNION ALL
+		SELECT comments.id FROM comments INNER JOIN thread
+		ON comments.parent_id = thread.id
+	)
+	DELETE FROM comments WHERE id IN thread;
+	`
+	util.LogQuery(ctx, "DeleteComment:", stmt)
+
+	res, err := tx.ExecContext(ctx, stmt, id)
+	if err != nil {
+		return 0, fmt.Errorf("Delete: delete error: %w", err)
+	}
+
+	affectedRows, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("Delete: get affected rows failed: %w", err)
+	}
+
+	return int(affectedRows), nil
+}
+
+func (c *Comments) DeleteByBlogID(ctx context.Context, tx *sql.Tx, blogID int) error {
+	stmt := `DELETE FROM comments WHERE blog_id = ?;`
+	util.LogQuery(ctx, "DeleteCommentsByBlogID:", stmt)
+
+	if _, err := tx.ExecContext(ctx, stmt, blogID); err != nil {
+		return fmt.Errorf("DeleteByBlogID: delete error: %w", err)
+	}
+
+	return nil
+}
+
+// Helper for scanning comments
+func scanComment(row *sql.Row) (*entities.Comment, error) {
+	comment := entities.Comment{}
+	parentID := sql.NullInt64{}
+	err := row.Scan(
+		&comment.ID,
+		&comment.Created_at,
+		&comment.Updated_at,
+		&comment.BlogID,
+		&parentID,
+		&comment.Author,
+		&comment.Content,
+		&comment.Status,
+	)
+	if err != nil {
+		return &entities.Comment{}, fmt.Errorf("scanComment: scan comment failed: %w", err)
+	}
+	comment.ParentID = int(parentID.Int64)
+	return &comment, nil
+}
+
+// Helper for scanning comment rows
+func scanCommentRows(rows *sql.Rows) ([]entities.Comment, error) {
+	result := []entities.Comment{}
+	for {
+		if !rows.Next() {
+			break
+		}
+		comment := entities.Comment{}
+		parentID := sql.NullInt64{}
+		err := rows.Scan(
+			&comment.ID,
+			&comment.Created_at,
+			&comment.Updated_at,
+			&comment.BlogID,
+			&parentID,
+			&comment.Author,
+			&comment.Content,
+			&comment.Status,
+		)
+		if err != nil {
+			if err := rows.Close(); err != nil {
+				return []entities.Comment{}, fmt.Errorf("scanCommentRows: close rows failed: %w", err)
+			}
+			return []entities.Comment{}, fmt.Errorf("scanCommentRows: scan failed: %w", err)
+		}
+		comment.ParentID = int(parentID.Int64)
+		result = append(result, comment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return []entities.Comment{}, fmt.Errorf("scanCommentRows: rows iteration error: %w", err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,171 @@
+package sqlite
+
+import (
+	"blog/entities"
+	"blog/util"
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+type Idempotency struct{}
+
+func NewIdempotency() *Idempotency {
+	return &Idempotency{}
+}
+
+// returns false if the actor already has the key
+func (i *Idempotency) Create(ctx context.Context, tx *sql.Tx, key entities.IdempotencyKey) (bool, error) {
+	stmt := `
+	INSERT INTO idempotency_keys
+	(
+		actor,
+		key,
+		request_hash,
+		expires_at
+	)
+	VALUES
+	( ?, ?, ?, ? )
+	ON CONFLICT (actor, key) DO NOTHING;
+	`
+	util.LogQuery(ctx, "CreateIdempotencyKey:", stmt)
+
+	res, err := tx.ExecContext(ctx, stmt, key.Actor, key.Key, key.RequestHash, key.Expires_at)
+	if err != nil {
+		return false, fmt.Errorf("Create: insert idempotency key failed: %w", err)
+	}
+
+	affectedRows, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("Create: get affected rows error: %w", err)
+	}
+
+	return affectedRows > 0, nil
+}
+
+func (i *Idempotency) Get(ctx context.Context, db *sql.DB, actor, key string) (*entities.IdempotencyKey, error) {
+	stmt := `
+	SELECT
+		actor,
+		key,
+		request_hash,
+		status,
+		content_type,
+		response,
+		created_at,
+		expires_at
+	FROM idempotency_keys
+	WHERE actor = ? AND key = ?;
+	`
+	util.LogQuery(ctx, "GetIdempotencyKey:", stmt)
+
+	row := db.QueryRowContext(ctx, stmt, actor, key)
+	if err := row.Err(); err != nil {
+		return &entities.IdempotencyKey{}, fmt.Errorf("Get: get idempotency key failed: %w", err)
+	}
+
+	idempotencyKey := entities.IdempotencyKey{}
+	scanErr := row.Scan(
+		&idempotencyKey.Actor,
+		&idempotencyKey.Key,
+		&idempotencyKey.RequestHash,
+		&idempotencyKey.Status,
+		&idempotencyKey.ContentType,
+		&idempotencyKey.Response,
+		&idempotencyKey.Created_at,
+		&idempotencyKey.Expires_at,
+	)
+	if scanErr != nil {
+		return &entities.IdempotencyKey{}, fmt.Errorf("Get: scan error: %w", scanErr)
+	}
+
+	return &idempotencyKey, nil
+}
+
+// stores the response of a key in progress
+func (i *Idempotency) Complete(ctx context.Context, tx *sql.Tx, key entities.IdempotencyKey) (int, error) {
+	stmt := `
+	UPDATE idempotency_keys
+	SET
+		status = ?,
+		content_type = ?,
+		response = ?,
+		expires_at = ?
+	WHERE
+		actor = ? AND key = ? AND status = 0;
+	`
+	util.LogQuery(ctx, "CompleteIdempotencyKey:", stmt)
+
+	res, err := tx.ExecContext(
+		ctx,
+		stmt,
+		key.Status,
+		key.ContentType,
+		key.Response,
+		key.Expires_at,
+		key.Actor,
+		key.Key,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("Complete: update idempotency key failed: %w", err)
+	}
+
+	affectedRows, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("Complete: get affected rows error: %w", err)
+	}
+
+	return int(affectedRows), nil
+}
+
+// only deletes keys in progress
+func (i *Idempotency) DeleteInProgress(ctx context.Context, tx *sql.Tx, actor, key string) (int, error) {
+	stmt := `DELETE FROM idempotency_keys WHERE actor = ? AND key = ? AND status = 0;`
+	util.LogQuery(ctx, "DeleteIdempotencyKeyInProgress:", stmt)
+
+	res, err := tx.ExecContext(ctx, stmt, actor, key)
+	if err != nil {
+		return 0, fmt.Errorf("DeleteInProgress: delete error: %w", err)
+	}
+
+	affectedRows, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("DeleteInProgress: get affected rows error: %w", err)
+	}
+
+	return int(affectedRows), nil
+}
+
+func (i *Idempotency) DeleteExpired(ctx context.Context, tx *sql.Tx, before string) (int, error) {
+	stmt := `DELETE FROM idempotency_keys WHERE expires_at < ?;`
+	util.LogQuery(ctx, "DeleteExpiredIdempotencyKeys:", stmt)
+
+	res, err := tx.ExecContext(ctx, stmt, before)
+	if err != nil {
+		return 0, fmt.Errorf("DeleteExpired: delete error: %w", err)
+	}
+
+	affectedRows, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("DeleteExpired: get affected rows error: %w", err)
+	}
+
+	return int(affectedRows), nil
+}
+
+func (i *Idempotency) DeleteExpiredKey(ctx context.Context, tx *sql.Tx, actor, key, before string) (int, error) {
+	stmt := `DELETE FROM idempotency_keys WHERE actor = ? AND key = ? AND expires_at < ?;`
+	util.LogQuery(ctx, "DeleteExpiredIdempotencyKey:", stmt)
+
+	res, err := tx.ExecContext(ctx, stmt, actor, key, before)
+	if err != nil {
+		return 0, fmt.Errorf("DeleteExpiredKey: delete error: %w", err)
+	}
+
+	affectedRows, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("DeleteExpiredKey: get affected rows error: %w", err)
+	}
+
+	return int(affectedRows), nil
+}
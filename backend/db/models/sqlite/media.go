@@ -0,0 +1,218 @@
+package sqlite
+
+import (
+	"blog/entities"
+	"blog/util"
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+type Media struct{}
+
+func NewMedia() *Media {
+	return &Media{}
+}
+
+// Uploading the same file twice returns the existing row,
+// alt text and filename are only overwritten when provided.
+func (m *Media) Create(ctx context.Context, tx *sql.Tx, media entities.Media) (*entities.Media, error) {
+	stmt := `
+	INSERT INTO media
+	(
+		hash,
+		filename,
+		mime,
+		size,
+		width,
+		height,
+		alt
+	)
+	VALUES
+	( ?, ?, ?, ?, ?, ?, ? )
+	ON CONFLICT(hash) DO UPDATE SET
+		filename = CASE WHEN excluded.filename <> '' THEN excluded.filename ELSE media.filename END,
+		alt = CASE WHEN excluded.alt <> '' THEN excluded.alt ELSE media.alt END
+	RETURNING *;
+	`
+
+	util.LogQuery(ctx, "CreateMedia:", stmt)
+
+	row := tx.QueryRowContext(
+		ctx,
+		stmt,
+		media.Hash,
+		media.Filename,
+		media.Mime,
+		media.Size,
+		media.Width,
+		media.Height,
+		media.Alt,
+	)
+	if err := row.Err(); err != nil {
+		return &entities.Media{}, fmt.Errorf("Create: insert media failed: %w", err)
+	}
+
+	newMedia, err := scanMedia(row)
+	if err != nil {
+		return &entities.Media{}, fmt.Errorf("Create: scan error: %w", err)
+	}
+
+	return newMedia, nil
+}
+
+func (m *Media) Get(ctx context.Context, db *sql.DB, hash string) (*entities.Media, error) {
+	stmt := `SELECT * FROM media WHERE hash = ?;`
+	util.LogQuery(ctx, "GetMedia:", stmt)
+
+	row := db.QueryRowContext(ctx, stmt, hash)
+	if err := row.Err(); err != nil {
+		return &entities.Media{}, fmt.Errorf("Get: query failed: %w", err)
+	}
+
+	media, err := scanMedia(row)
+	if err != nil {
+		return &entities.Media{}, fmt.Errorf("Get: scan media failed: %w", err)
+	}
+
+	return media, nil
+}
+
+func (m *Media) List(ctx context.Context, db *sql.DB) ([]entities.Media, error) {
+	stmt := `SELECT * FROM media ORDER BY created_at DESC;`
+	util.LogQuery(ctx, "ListMedia:", stmt)
+
+	rows, err := db.QueryContext(ctx, stmt)
+	if err != nil {
+		return []entities.Media{}, fmt.Errorf("List: query failed: %w", err)
+	}
+
+	result, err := scanMediaRows(rows)
+	if err != nil {
+		return []entities.Media{}, fmt.Errorf("List: scan media failed: %w", err)
+	}
+
+	return result, nil
+}
+
+func (m *Media) ListUnused(ctx context.Context, db *sql.DB, before string) ([]entities.Media, error) {
+	stmt := `
+	SELECT * FROM media
+	WHERE
+		hash NOT IN (SELECT media_hash FROM blog_media)
+	AND created_at < ?
+	ORDER BY created_at DESC;
+	`
+	util.LogQuery(ctx, "ListUnusedMedia:", stmt)
+
+	rows, err := db.QueryContext(ctx, stmt, before)
+	if err != nil {
+		return []entities.Media{}, fmt.Errorf("ListUnused: query failed: %w", err)
+	}
+
+	result, err := scanMediaRows(rows)
+	if err != nil {
+		return []entities.Media{}, fmt.Errorf("ListUnused: scan media failed: %w", err)
+	}
+
+	return result, nil
+}
+
+func (m *Media) Delete(ctx context.Context, tx *sql.Tx, hash string) (int, error) {
+	stmt := `
+	DELETE FROM media
+	WHERE
+		hash = ?
+	AND hash NOT IN (SELECT media_hash FROM blog_media);
+	`
+	util.LogQuery(ctx, "DeleteMedia:", stmt)
+
+	res, err := tx.ExecContext(ctx, stmt, hash)
+	if err != nil {
+		return 0, fmt.Errorf("Delete: delete error: %w", err)
+	}
+
+	affectedRows, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("Delete: get affected rows failed: %w", err)
+	}
+
+	return int(affectedRows), nil
+}
+
+func (m *Media) DeleteUnused(ctx context.Context, tx *sql.Tx, before string) ([]entities.Media, error) {
+	stmt := `
+	DELETE FROM media
+	WHERE
+		hash NOT IN (SELECT media_hash FROM blog_media)
+	AND created_at < ?
+	RETURNING *;
+	`
+	util.LogQuery(ctx, "DeleteUnusedMedia:", stmt)
+
+	rows, err := tx.QueryContext(ctx, stmt, before)
+	if err != nil {
+		return []entities.Media{}, fmt.Errorf("DeleteUnused: delete error: %w", err)
+	}
+
+	result, err := scanMediaRows(rows)
+	if err != nil {
+		return []entities.Media{}, fmt.Errorf("DeleteUnused: scan media failed: %w", err)
+	}
+
+	return result, nil
+}
+
+// Helper for scanning media
+func scanMedia(row *sql.Row) (*entities.Media, error) {
+	media := entities.Media{}
+	err := row.Scan(
+		&media.Hash,
+		&media.Created_at,
+		&media.Updated_at,
+		&media.Filename,
+		&media.Mime,
+		&media.Size,
+		&media.Width,
+		&media.Height,
+		&media.Alt,
+	)
+	if err != nil {
+		return &entities.Media{}, fmt.Errorf("scanMedia: scan media failed: %w", err)
+	}
+	return &media, nil
+}
+
+func scanMediaRows(rows *sql.Rows) ([]entities.Media, error) {
+	result := []entities.Media{}
+	for {
+		if !rows.Next() {
+			break
+		}
+		media := entities.Media{}
+		err := rows.Scan(
+			&media.Hash,
+			&media.Created_at,
+			&media.Updated_at,
+			&media.Filename,
+			&media.Mime,
+			&media.Size,
+			&media.Width,
+			&media.Height,
+			&media.Alt,
+		)
+		if err != nil {
+			if err := rows.Close(); err != nil {
+				return []entities.Media{}, fmt.Errorf("scanMediaRows: close rows failed: %w", err)
+			}
+			return []entities.Media{}, fmt.Errorf("scanMediaRows: scan failed: %w", err)
+		}
+		result = append(result, media)
+	}
+
+	if err := rows.Err(); err != nil {
+		return []entities.Media{}, fmt.Errorf("scanMediaRows: rows iteration error: %w", err)
+	}
+
+	return result, nil
+}
@@ -45,3 +45,13 @@ func (m *Models) Prepare(ctx context.Context, migrate bool) error {
 
 	return nil
 }
+
+// *sql.DB or *sql.Tx, for reads needed both outside and inside a transaction
+type rowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// *sql.DB or *sql.Tx, same as rowQuerier for reads returning several rows
+type rowsQuerier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
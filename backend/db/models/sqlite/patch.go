@@ -0,0 +1,25 @@
+package sqlite
+
+import "strings"
+
+// SET clause of a partial update, only columns given a value are changed
+type setClause struct {
+	columns []string
+	args    []any
+}
+
+func setColumn[T any](s *setClause, column string, value *T) {
+	if value == nil {
+		return
+	}
+	s.columns = append(s.columns, column+" = ?")
+	s.args = append(s.args, *value)
+}
+
+// without any column the row is still touched, so that RETURNING gives it back
+func (s *setClause) String() string {
+	if len(s.columns) == 0 {
+		return "id = id"
+	}
+	return strings.Join(s.columns, ",\n\t\t")
+}
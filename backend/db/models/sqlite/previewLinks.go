@@ -0,0 +1,148 @@
+package sqlite
+
+import (
+	"blog/entities"
+	"blog/util"
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+type PreviewLinks struct{}
+
+func NewPreviewLinks() *PreviewLinks {
+	return &PreviewLinks{}
+}
+
+func (p *PreviewLinks) Create(ctx context.Context, tx *sql.Tx, link entities.PreviewLink) (*entities.PreviewLink, error) {
+	stmt := `
+	INSERT INTO preview_links
+	(
+		expires_at,
+		blog_id,
+		note
+	)
+	VALUES
+	( ?, ?, ? )
+	RETURNING *;
+	`
+	util.LogQuery(ctx, "CreatePreviewLink:", stmt)
+
+	row := tx.QueryRowContext(ctx, stmt, link.ExpiresAt, link.BlogID, link.Note)
+	if err := row.Err(); err != nil {
+		return &entities.PreviewLink{}, fmt.Errorf("Create: insert preview link failed: %w", err)
+	}
+
+	newLink, err := scanPreviewLink(row)
+	if err != nil {
+		return &entities.PreviewLink{}, fmt.Errorf("Create: scan error: %w", err)
+	}
+
+	return newLink, nil
+}
+
+func (p *PreviewLinks) Get(ctx context.Context, db *sql.DB, id int) (*entities.PreviewLink, error) {
+	stmt := `SELECT * FROM preview_links WHERE id = ?;`
+	util.LogQuery(ctx, "GetPreviewLink:", stmt)
+
+	row := db.QueryRowContext(ctx, stmt, id)
+	if err := row.Err(); err != nil {
+		return &entities.PreviewLink{}, fmt.Errorf("Get: query failed: %w", err)
+	}
+
+	link, err := scanPreviewLink(row)
+	if err != nil {
+		return &entities.PreviewLink{}, fmt.Errorf("Get: row scan failed: %w", err)
+	}
+
+	return link, nil
+}
+
+func (p *PreviewLinks) ListByBlogID(ctx context.Context, db *sql.DB, blogID int) ([]entities.PreviewLink, error) {
+	stmt := `
+	SELECT * FROM preview_links
+	WHERE
+		blog_id = ?
+	ORDER BY id DESC;
+	`
+	util.LogQuery(ctx, "ListPreviewLinksByBlogID:", stmt)
+
+	rows, err := db.QueryContext(ctx, stmt, blogID)
+	if err != nil {
+		return []entities.PreviewLink{}, fmt.Errorf("ListByBlogID: query failed: %w", err)
+	}
+
+	result := []entities.PreviewLink{}
+	for {
+		if !rows.Next() {
+			break
+		}
+		link := entities.PreviewLink{}
+		err := rows.Scan(
+			&link.ID,
+			&link.Created_at,
+			&link.Updated_at,
+			&link.ExpiresAt,
+			&link.BlogID,
+			&link.Note,
+		)
+		if err != nil {
+			if err := rows.Close(); err != nil {
+				return []entities.PreviewLink{}, fmt.Errorf("ListByBlogID: close rows failed: %w", err)
+			}
+			return []entities.PreviewLink{}, fmt.Errorf("ListByBlogID: scan failed: %w", err)
+		}
+		result = append(result, link)
+	}
+
+	if err := rows.Err(); err != nil {
+		return []entities.PreviewLink{}, fmt.Errorf("ListByBlogID: rows iteration error: %w", err)
+	}
+
+	return result, nil
+}
+
+func (p *PreviewLinks) Delete(ctx context.Context, tx *sql.Tx, id, blogID int) (int, error) {
+	stmt := `DELETE FROM preview_links WHERE id = ? AND blog_id = ?;`
+	util.LogQuery(ctx, "DeletePreviewLink:", stmt)
+
+	res, err := tx.ExecContext(ctx, stmt, id, blogID)
+	if err != nil {
+		return 0, fmt.Errorf("Delete: delete error: %w", err)
+	}
+
+	affectedRows, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("Delete: get affected rows failed: %w", err)
+	}
+
+	return int(affectedRows), nil
+}
+
+func (p *PreviewLinks) DeleteByBlogID(ctx context.Context, tx *sql.Tx, blogID int) error {
+	stmt := `DELETE FROM preview_links WHERE blog_id = ?;`
+	util.LogQuery(ctx, "DeletePreviewLinksByBlogID:", stmt)
+
+	if _, err := tx.ExecContext(ctx, stmt, blogID); err != nil {
+		return fmt.Errorf("DeleteByBlogID: delete error: %w", err)
+	}
+
+	return nil
+}
+
+// Helper for scanning preview links
+func scanPreviewLink(row *sql.Row) (*entities.PreviewLink, error) {
+	link := entities.PreviewLink{}
+	err := row.Scan(
+		&link.ID,
+		&link.Created_at,
+		&link.Updated_at,
+		&link.ExpiresAt,
+		&link.BlogID,
+		&link.Note,
+	)
+	if err != nil {
+		return &entities.PreviewLink{}, fmt.Errorf("scanPreviewLink: scan preview link failed: %w", err)
+	}
+	return &link, nil
+}
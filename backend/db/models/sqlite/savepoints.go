@@ -0,0 +1,54 @@
+package sqlite
+
+import (
+	"blog/util"
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+type Savepoints struct{}
+
+func NewSavepoints() *Savepoints {
+	return &Savepoints{}
+}
+
+// identifiers can't be bound as parameters
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func (s *Savepoints) Create(ctx context.Context, tx *sql.Tx, name string) error {
+	stmt := `SAVEPOINT ` + quoteIdentifier(name) + `;`
+	util.LogQuery(ctx, "CreateSavepoint:", stmt)
+
+	if _, err := tx.ExecContext(ctx, stmt); err != nil {
+		return fmt.Errorf("Create: create savepoint failed: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Savepoints) Release(ctx context.Context, tx *sql.Tx, name string) error {
+	stmt := `RELEASE SAVEPOINT ` + quoteIdentifier(name) + `;`
+	util.LogQuery(ctx, "ReleaseSavepoint:", stmt)
+
+	if _, err := tx.ExecContext(ctx, stmt); err != nil {
+		return fmt.Errorf("Release: release savepoint failed: %w", err)
+	}
+
+	return nil
+}
+
+// sqlite keeps the savepoint after rolling back to it, release it as well
+func (s *Savepoints) RollbackTo(ctx context.Context, tx *sql.Tx, name string) error {
+	stmt := `ROLLBACK TO SAVEPOINT ` + quoteIdentifier(name) + `; RELEASE SAVEPOINT ` + quoteIdentifier(name) + `;`
+	util.LogQuery(ctx, "RollbackToSavepoint:", stmt)
+
+	if _, err := tx.ExecContext(ctx, stmt); err != nil {
+		return fmt.Errorf("RollbackTo: rollback to savepoint failed: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,220 @@
+package sqlite
+
+import (
+	"blog/entities"
+	"blog/util"
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+type Series struct{}
+
+func NewSeries() *Series {
+	return &Series{}
+}
+
+func (s *Series) Create(ctx context.Context, tx *sql.Tx, series entities.Series) (*entities.Series, error) {
+	stmt := `
+	INSERT INTO series
+	(
+		name,
+		description,
+		slug
+	)
+	VALUES
+	( ?, ?, ? )
+	RETURNING *;
+	`
+
+	util.LogQuery(ctx, "CreateSeries:", stmt)
+
+	row := tx.QueryRowContext(
+		ctx,
+		stmt,
+		series.Name,
+		series.Description,
+		series.Slug,
+	)
+	if err := row.Err(); err != nil {
+		return &entities.Series{}, fmt.Errorf("Create: insert series failed: %w", err)
+	}
+
+	newSeries, err := scanSeries(row)
+	if err != nil {
+		return &entities.Series{}, fmt.Errorf("Create: scan error: %w", err)
+	}
+
+	return newSeries, nil
+}
+
+func (s *Series) List(ctx context.Context, db *sql.DB) ([]entities.Series, error) {
+	stmt := `SELECT * FROM series;`
+	util.LogQuery(ctx, "ListSeries:", stmt)
+
+	rows, err := db.QueryContext(ctx, stmt)
+	if err != nil {
+		return []entities.Series{}, fmt.Errorf("List: query failed: %w", err)
+	}
+
+	result := []entities.Series{}
+	for {
+		if !rows.Next() {
+			break
+		}
+		series := entities.Series{}
+		err := rows.Scan(
+			&series.ID,
+			&series.Created_at,
+			&series.Updated_at,
+			&series.Name,
+			&series.Description,
+			&series.Slug,
+		)
+		if err != nil {
+			if err := rows.Close(); err != nil {
+				return []entities.Series{}, fmt.Errorf("List: close rows failed: %w", err)
+			}
+			return []entities.Series{}, fmt.Errorf("List: scan failed: %w", err)
+		}
+		result = append(result, series)
+	}
+
+	if err := rows.Err(); err != nil {
+		return []entities.Series{}, fmt.Errorf("List: rows iteration error: %w", err)
+	}
+
+	return result, nil
+}
+
+func (s *Series) Get(ctx context.Context, db *sql.DB, id int) (*entities.Series, error) {
+	stmt := `SELECT * FROM series WHERE id = ?;`
+	util.LogQuery(ctx, "GetSeries:", stmt)
+
+	row := db.QueryRowContext(ctx, stmt, id)
+	if err := row.Err(); err != nil {
+		return &entities.Series{}, fmt.Errorf("Get: query failed: %w", err)
+	}
+
+	series, err := scanSeries(row)
+	if err != nil {
+		return &entities.Series{}, fmt.Errorf("Get: row scan failed: %w", err)
+	}
+
+	return series, nil
+}
+
+func (s *Series) GetByBlogID(ctx context.Context, db *sql.DB, blogID int) (*entities.BlogSeries, error) {
+	return s.getByBlogID(ctx, db, blogID)
+}
+
+// same as GetByBlogID, sees the changes made earlier in tx
+func (s *Series) GetByBlogIDInTx(ctx context.Context, tx *sql.Tx, blogID int) (*entities.BlogSeries, error) {
+	return s.getByBlogID(ctx, tx, blogID)
+}
+
+func (s *Series) getByBlogID(ctx context.Context, db rowQuerier, blogID int) (*entities.BlogSeries, error) {
+	stmt := `
+	SELECT
+		series.id,
+		series.created_at,
+		series.updated_at,
+		series.name,
+		series.description,
+		series.slug,
+		blog_series.position
+	FROM series INNER JOIN blog_series
+	ON blog_series.series_id = series.id
+	WHERE
+		blog_series.blog_id = ?;
+	`
+	util.LogQuery(ctx, "GetSeriesByBlogID:", stmt)
+
+	row := db.QueryRowContext(ctx, stmt, blogID)
+	if err := row.Err(); err != nil {
+		return &entities.BlogSeries{}, fmt.Errorf("GetByBlogID: query failed: %w", err)
+	}
+
+	blogSeries := entities.BlogSeries{}
+	err := row.Scan(
+		&blogSeries.ID,
+		&blogSeries.Created_at,
+		&blogSeries.Updated_at,
+		&blogSeries.Name,
+		&blogSeries.Description,
+		&blogSeries.Slug,
+		&blogSeries.Part,
+	)
+	if err != nil {
+		return &entities.BlogSeries{}, fmt.Errorf("GetByBlogID: row scan failed: %w", err)
+	}
+
+	return &blogSeries, nil
+}
+
+func (s *Series) Update(ctx context.Context, tx *sql.Tx, series entities.Series, id int) (*entities.Series, error) {
+	stmt := `
+	UPDATE series
+	SET
+		name = ?,
+		description = ?,
+		slug = ?
+	WHERE 
+		id = ?
+	RETURNING *;
+	`
+	util.LogQuery(ctx, "UpdateSeries:", stmt)
+
+	row := tx.QueryRowContext(
+		ctx,
+		stmt,
+		series.Name,
+		series.Description,
+		series.Slug,
+		id,
+	)
+	if err := row.Err(); err != nil {
+		return &entities.Series{}, fmt.Errorf("Update: update query failed: %w", err)
+	}
+
+	newSeries, err := scanSeries(row)
+	if err != nil {
+		return &entities.Series{}, fmt.Errorf("Update: scan error: %w", err)
+	}
+
+	return newSeries, nil
+}
+
+func (s *Series) Delete(ctx context.Context, tx *sql.Tx, id int) (int, error) {
+	stmt := `DELETE FROM series WHERE id = ?;`
+	util.LogQuery(ctx, "DeleteSeries:", stmt)
+
+	res, err := tx.ExecContext(ctx, stmt, id)
+	if err != nil {
+		return 0, fmt.Errorf("Delete: delete error: %w", err)
+	}
+
+	affectedRows, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("Delete: get affected rows failed: %w", err)
+	}
+
+	return int(affectedRows), nil
+}
+
+// Helper for scanning series
+func scanSeries(row *sql.Row) (*entities.Series, error) {
+	series := entities.Series{}
+	err := row.Scan(
+		&series.ID,
+		&series.Created_at,
+		&series.Updated_at,
+		&series.Name,
+		&series.Description,
+		&series.Slug,
+	)
+	if err != nil {
+		return &entities.Series{}, fmt.Errorf("scanSeries: scan series failed: %w", err)
+	}
+	return &series, nil
+}
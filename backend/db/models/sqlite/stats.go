@@ -0,0 +1,295 @@
+package sqlite
+
+import (
+	"blog/entities"
+	"blog/util"
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+type Stats struct{}
+
+func NewStats() *Stats {
+	return &Stats{}
+}
+
+func (s *Stats) RecordView(ctx context.Context, tx *sql.Tx, day string, blogID int, referrer, visitorHash string) error {
+	viewStmt := `
+	INSERT INTO blog_views_daily
+	( day, blog_id, referrer, views )
+	VALUES
+	( ?, ?, ?, 1 )
+	ON CONFLICT(day, blog_id, referrer) DO UPDATE SET views = views + 1;
+	`
+	util.LogQuery(ctx, "RecordView:", viewStmt)
+
+	if _, err := tx.ExecContext(ctx, viewStmt, day, blogID, referrer); err != nil {
+		return fmt.Errorf("RecordView: count view failed: %w", err)
+	}
+
+	hashStmt := `
+	INSERT OR IGNORE INTO blog_visitor_hashes
+	( day, blog_id, hash )
+	VALUES
+	( ?, ?, ? );
+	`
+	util.LogQuery(ctx, "RecordView:", hashStmt)
+
+	res, err := tx.ExecContext(ctx, hashStmt, day, blogID, visitorHash)
+	if err != nil {
+		return fmt.Errorf("RecordView: insert visitor hash failed: %w", err)
+	}
+	affectedRows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("RecordView: get affected rows failed: %w", err)
+	}
+
+	// seen this visitor today
+	if affectedRows == 0 {
+		return nil
+	}
+
+	visitorStmt := `
+	INSERT INTO blog_visitors_daily
+	( day, blog_id, visitors )
+	VALUES
+	( ?, ?, 1 )
+	ON CONFLICT(day, blog_id) DO UPDATE SET visitors = visitors + 1;
+	`
+	util.LogQuery(ctx, "RecordView:", visitorStmt)
+
+	if _, err := tx.ExecContext(ctx, visitorStmt, day, blogID); err != nil {
+		return fmt.Errorf("RecordView: count visitor failed: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Stats) DeleteVisitorHashesBefore(ctx context.Context, tx *sql.Tx, day string) error {
+	stmt := `DELETE FROM blog_visitor_hashes WHERE day < ?;`
+	util.LogQuery(ctx, "DeleteVisitorHashesBefore:", stmt)
+
+	if _, err := tx.ExecContext(ctx, stmt, day); err != nil {
+		return fmt.Errorf("DeleteVisitorHashesBefore: delete error: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Stats) ListSummary(ctx context.Context, db *sql.DB, from, to string) ([]entities.BlogStatsSummary, error) {
+	stmt := `
+	SELECT
+		blogs.id,
+		blogs.title,
+		views.total,
+		COALESCE(visitors.total, 0)
+	FROM (
+		SELECT blog_id, SUM(views) AS total FROM blog_views_daily
+		WHERE day BETWEEN ? AND ?
+		GROUP BY blog_id
+	) AS views
+	INNER JOIN blogs ON blogs.id = views.blog_id
+	LEFT JOIN (
+		SELECT blog_id, SUM(visitors) AS total FROM blog_visitors_daily
+		WHERE day BETWEEN ? AND ?
+		GROUP BY blog_id
+	) AS visitors ON visitors.blog_id = views.blog_id
+	ORDER BY views.total DESC, blogs.id;
+	`
+	util.LogQuery(ctx, "ListStatsSummary:", stmt)
+
+	rows, err := db.QueryContext(ctx, stmt, from, to, from, to)
+	if err != nil {
+		return []entities.BlogStatsSummary{}, fmt.Errorf("ListSummary: query failed: %w", err)
+	}
+
+	result := []entities.BlogStatsSummary{}
+	for {
+		if !rows.Next() {
+			break
+		}
+		summary := entities.BlogStatsSummary{}
+		err := rows.Scan(
+			&summary.BlogID,
+			&summary.Title,
+			&summary.Views,
+			&summary.Visitors,
+		)
+		if err != nil {
+			if err := rows.Close(); err != nil {
+				return []entities.BlogStatsSummary{}, fmt.Errorf("ListSummary: close rows failed: %w", err)
+			}
+			return []entities.BlogStatsSummary{}, fmt.Errorf("ListSummary: scan failed: %w", err)
+		}
+		result = append(result, summary)
+	}
+
+	if err := rows.Err(); err != nil {
+		return []entities.BlogStatsSummary{}, fmt.Errorf("ListSummary: rows iteration error: %w", err)
+	}
+
+	return result, nil
+}
+
+func (s *Stats) ListDaily(ctx context.Context, db *sql.DB, blogID int, from, to string) ([]entities.DailyStats, error) {
+	stmt := `
+	SELECT
+		views.day,
+		views.total,
+		COALESCE(visitors.visitors, 0)
+	FROM (
+		SELECT day, SUM(views) AS total FROM blog_views_daily
+		WHERE blog_id = ? AND day BETWEEN ? AND ?
+		GROUP BY day
+	) AS views
+	LEFT JOIN blog_visitors_daily AS visitors
+	ON visitors.blog_id = ? AND visitors.day = views.day
+	ORDER BY views.day;
+	`
+	util.LogQuery(ctx, "ListDailyStats:", stmt)
+
+	rows, err := db.QueryContext(ctx, stmt, blogID, from, to, blogID)
+	if err != nil {
+		return []entities.DailyStats{}, fmt.Errorf("ListDaily: query failed: %w", err)
+	}
+
+	result := []entities.DailyStats{}
+	for {
+		if !rows.Next() {
+			break
+		}
+		daily := entities.DailyStats{}
+		err := rows.Scan(
+			&daily.Day,
+			&daily.Views,
+			&daily.Visitors,
+		)
+		if err != nil {
+			if err := rows.Close(); err != nil {
+				return []entities.DailyStats{}, fmt.Errorf("ListDaily: close rows failed: %w", err)
+			}
+			return []entities.DailyStats{}, fmt.Errorf("ListDaily: scan failed: %w", err)
+		}
+		result = append(result, daily)
+	}
+
+	if err := rows.Err(); err != nil {
+		return []entities.DailyStats{}, fmt.Errorf("ListDaily: rows iteration error: %w", err)
+	}
+
+	return result, nil
+}
+
+func (s *Stats) ListReferrers(ctx context.Context, db *sql.DB, blogID int, from, to string) ([]entities.ReferrerStats, error) {
+	stmt := `
+	SELECT
+		referrer,
+		SUM(views) AS total
+	FROM blog_views_daily
+	WHERE blog_id = ? AND day BETWEEN ? AND ?
+	GROUP BY referrer
+	ORDER BY total DESC, referrer;
+	`
+	util.LogQuery(ctx, "ListReferrerStats:", stmt)
+
+	rows, err := db.QueryContext(ctx, stmt, blogID, from, to)
+	if err != nil {
+		return []entities.ReferrerStats{}, fmt.Errorf("ListReferrers: query failed: %w", err)
+	}
+
+	result := []entities.ReferrerStats{}
+	for {
+		if !rows.Next() {
+			break
+		}
+		referrer := entities.ReferrerStats{}
+		err := rows.Scan(
+			&referrer.Referrer,
+			&referrer.Views,
+		)
+		if err != nil {
+			if err := rows.Close(); err != nil {
+				return []entities.ReferrerStats{}, fmt.Errorf("ListReferrers: close rows failed: %w", err)
+			}
+			return []entities.ReferrerStats{}, fmt.Errorf("ListReferrers: scan failed: %w", err)
+		}
+		result = append(result, referrer)
+	}
+
+	if err := rows.Err(); err != nil {
+		return []entities.ReferrerStats{}, fmt.Errorf("ListReferrers: rows iteration error: %w", err)
+	}
+
+	return result, nil
+}
+
+func (s *Stats) ListPopular(ctx context.Context, db *sql.DB, from string, limit int) ([]entities.PopularBlog, error) {
+	stmt := `
+	SELECT
+		blogs.id,
+		blogs.title,
+		blogs.slug,
+		blogs.description,
+		SUM(blog_views_daily.views) AS total
+	FROM blog_views_daily INNER JOIN blogs
+	ON blogs.id = blog_views_daily.blog_id
+	WHERE
+		blog_views_daily.day >= ? AND
+		blogs.status = 'published' AND
+		blogs.deleted_at = ''
+	GROUP BY blogs.id
+	ORDER BY total DESC, blogs.id
+	LIMIT ?;
+	`
+	util.LogQuery(ctx, "ListPopularBlogs:", stmt)
+
+	rows, err := db.QueryContext(ctx, stmt, from, limit)
+	if err != nil {
+		return []entities.PopularBlog{}, fmt.Errorf("ListPopular: query failed: %w", err)
+	}
+
+	result := []entities.PopularBlog{}
+	for {
+		if !rows.Next() {
+			break
+		}
+		blog := entities.PopularBlog{}
+		err := rows.Scan(
+			&blog.ID,
+			&blog.Title,
+			&blog.Slug,
+			&blog.Description,
+			&blog.Views,
+		)
+		if err != nil {
+			if err := rows.Close(); err != nil {
+				return []entities.PopularBlog{}, fmt.Errorf("ListPopular: close rows failed: %w", err)
+			}
+			return []entities.PopularBlog{}, fmt.Errorf("ListPopular: scan failed: %w", err)
+		}
+		result = append(result, blog)
+	}
+
+	if err := rows.Err(); err != nil {
+		return []entities.PopularBlog{}, fmt.Errorf("ListPopular: rows iteration error: %w", err)
+	}
+
+	return result, nil
+}
+
+func (s *Stats) DeleteByBlogID(ctx context.Context, tx *sql.Tx, blogID int) error {
+	stmts := []string{
+		`DELETE FROM blog_views_daily WHERE blog_id = ?;`,
+		`DELETE FROM blog_visitors_daily WHERE blog_id = ?;`,
+		`DELETE FROM blog_visitor_hashes WHERE blog_id = ?;`,
+	}
+	for _, stmt := range stmts {
+		util.LogQuery(ctx, "DeleteStatsByBlogID:", stmt)
+		if _, err := tx.ExecContext(ctx, stmt, blogID); err != nil {
+			return fmt.Errorf("DeleteByBlogID: delete error: %w", err)
+		}
+	}
+
+	return nil
+}
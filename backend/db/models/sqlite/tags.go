@@ -49,6 +49,7 @@ func (t *Tags) Create(ctx context.Context, tx *sql.Tx, tag entities.Tag) (*entit
 		&newTag.Name,
 		&newTag.Description,
 		&newTag.Slug,
+		&newTag.Version,
 	)
 	if scanErr != nil {
 		return &entities.Tag{}, fmt.Errorf("Create: scan error: %w", scanErr)
@@ -58,6 +59,15 @@ func (t *Tags) Create(ctx context.Context, tx *sql.Tx, tag entities.Tag) (*entit
 }
 
 func (t *Tags) ListByBlogID(ctx context.Context, db *sql.DB, blogID int) ([]entities.Tag, error) {
+	return t.listByBlogID(ctx, db, blogID)
+}
+
+// same as ListByBlogID, sees the changes made earlier in tx
+func (t *Tags) ListByBlogIDInTx(ctx context.Context, tx *sql.Tx, blogID int) ([]entities.Tag, error) {
+	return t.listByBlogID(ctx, tx, blogID)
+}
+
+func (t *Tags) listByBlogID(ctx context.Context, db rowsQuerier, blogID int) ([]entities.Tag, error) {
 	stmt := `
 	SELECT 
 		tags.id, 
@@ -65,7 +75,8 @@ func (t *Tags) ListByBlogID(ctx context.Context, db *sql.DB, blogID int) ([]enti
 		tags.updated_at, 
 		tags.name, 
 		tags.description, 
-		tags.slug 
+		tags.slug,
+		tags.version
 	FROM tags INNER JOIN blog_tags
 	WHERE 
 		(blog_tags.blog_id = ?) AND (blog_tags.tag_id = tags.id);
@@ -95,6 +106,7 @@ func (t *Tags) ListByBlogID(ctx context.Context, db *sql.DB, blogID int) ([]enti
 			&tag.Name,
 			&tag.Description,
 			&tag.Slug,
+			&tag.Version,
 		)
 		if err != nil {
 			if err := rows.Close(); err != nil {
@@ -180,6 +192,7 @@ func (t *Tags) List(ctx context.Context, db *sql.DB) ([]entities.Tag, error) {
 			&tag.Name,
 			&tag.Description,
 			&tag.Slug,
+			&tag.Version,
 		)
 		if err != nil {
 			if err := rows.Close(); err != nil {
@@ -225,6 +238,7 @@ func (t *Tags) ListByTopicID(ctx context.Context, db *sql.DB, topicID int) ([]en
 			&tag.Name,
 			&tag.Description,
 			&tag.Slug,
+			&tag.Version,
 		)
 		if err != nil {
 			if err := rows.Close(); err != nil {
@@ -242,6 +256,15 @@ func (t *Tags) ListByTopicID(ctx context.Context, db *sql.DB, topicID int) ([]en
 	return result, nil
 }
 func (t *Tags) Get(ctx context.Context, db *sql.DB, id int) (*entities.Tag, error) {
+	return t.get(ctx, db, id)
+}
+
+// same as Get, sees the changes made earlier in tx
+func (t *Tags) GetInTx(ctx context.Context, tx *sql.Tx, id int) (*entities.Tag, error) {
+	return t.get(ctx, tx, id)
+}
+
+func (t *Tags) get(ctx context.Context, db rowQuerier, id int) (*entities.Tag, error) {
 	stmt := `SELECT * FROM tags WHERE id = ?;`
 	util.LogQuery(ctx, "GetTag:", stmt)
 
@@ -258,6 +281,7 @@ func (t *Tags) Get(ctx context.Context, db *sql.DB, id int) (*entities.Tag, erro
 		&tag.Name,
 		&tag.Description,
 		&tag.Slug,
+		&tag.Version,
 	)
 	if err != nil {
 		return &entities.Tag{}, fmt.Errorf("Get: row scan failed: %w", err)
@@ -266,7 +290,8 @@ func (t *Tags) Get(ctx context.Context, db *sql.DB, id int) (*entities.Tag, erro
 	return &tag, nil
 }
 
-func (t *Tags) Update(ctx context.Context, tx *sql.Tx, tag entities.Tag, id int) (*entities.Tag, error) {
+// returns sql.ErrNoRows if the tag isn't at the given version
+func (t *Tags) Update(ctx context.Context, tx *sql.Tx, tag entities.Tag, id int, version entities.Version) (*entities.Tag, error) {
 	stmt := `
 	UPDATE tags
 	SET
@@ -275,6 +300,7 @@ func (t *Tags) Update(ctx context.Context, tx *sql.Tx, tag entities.Tag, id int)
 		slug = ?
 	WHERE 
 		id = ?
+	AND ` + versionCond + `
 	RETURNING *;
 	`
 	util.LogQuery(ctx, "UpdateTag:", stmt)
@@ -286,6 +312,8 @@ func (t *Tags) Update(ctx context.Context, tx *sql.Tx, tag entities.Tag, id int)
 		tag.Description,
 		tag.Slug,
 		id,
+		version,
+		version,
 	)
 	if err := row.Err(); err != nil {
 		return &entities.Tag{}, fmt.Errorf("Update: update query failed: %w", err)
@@ -299,6 +327,7 @@ func (t *Tags) Update(ctx context.Context, tx *sql.Tx, tag entities.Tag, id int)
 		&newTag.Name,
 		&newTag.Description,
 		&newTag.Slug,
+		&newTag.Version,
 	)
 	if scanErr != nil {
 		return &entities.Tag{}, fmt.Errorf("Update: scan error: %w", scanErr)
@@ -307,13 +336,54 @@ func (t *Tags) Update(ctx context.Context, tx *sql.Tx, tag entities.Tag, id int)
 	return &newTag, nil
 }
 
-func (t *Tags) Delete(ctx context.Context, tx *sql.Tx, id int) (int, error) {
+// only updates the columns present in the patch, returns sql.ErrNoRows if the tag isn't at the given version
+func (t *Tags) Patch(ctx context.Context, tx *sql.Tx, patch entities.TagPatch, id int, version entities.Version) (*entities.Tag, error) {
+	set := &setClause{}
+	setColumn(set, "name", patch.Name)
+	setColumn(set, "description", patch.Description)
+	setColumn(set, "slug", patch.Slug)
+
+	stmt := fmt.Sprintf(`
+	UPDATE tags
+	SET
+		%s
+	WHERE
+		id = ?
+	AND %s
+	RETURNING *;
+	`, set, versionCond)
+	util.LogQuery(ctx, "PatchTag:", stmt)
+
+	row := tx.QueryRowContext(ctx, stmt, append(set.args, id, version, version)...)
+	if err := row.Err(); err != nil {
+		return &entities.Tag{}, fmt.Errorf("Patch: patch query failed: %w", err)
+	}
+
+	newTag := entities.Tag{}
+	scanErr := row.Scan(
+		&newTag.ID,
+		&newTag.Created_at,
+		&newTag.Updated_at,
+		&newTag.Name,
+		&newTag.Description,
+		&newTag.Slug,
+		&newTag.Version,
+	)
+	if scanErr != nil {
+		return &entities.Tag{}, fmt.Errorf("Patch: scan error: %w", scanErr)
+	}
+
+	return &newTag, nil
+}
+
+// no rows are affected if the tag isn't at the given version
+func (t *Tags) Delete(ctx context.Context, tx *sql.Tx, id int, version entities.Version) (int, error) {
 	stmt := `
-	DELETE FROM tags WHERE id = ?;
+	DELETE FROM tags WHERE id = ? AND ` + versionCond + `;
 	`
 	util.LogQuery(ctx, "DeleteTags:", stmt)
 
-	res, err := tx.ExecContext(ctx, stmt, id)
+	res, err := tx.ExecContext(ctx, stmt, id, version, version)
 	if err != nil {
 		return 0, fmt.Errorf("Delete: delete error: %w", err)
 	}
@@ -49,6 +49,7 @@ func (t *Topics) Create(ctx context.Context, tx *sql.Tx, topic entities.Topic) (
 		&newTopic.Name,
 		&newTopic.Description,
 		&newTopic.Slug,
+		&newTopic.Version,
 	)
 	if scanErr != nil {
 		return &entities.Topic{}, fmt.Errorf("Create: scan error: %w", scanErr)
@@ -58,6 +59,15 @@ func (t *Topics) Create(ctx context.Context, tx *sql.Tx, topic entities.Topic) (
 }
 
 func (t *Topics) ListByBlogID(ctx context.Context, db *sql.DB, blog_id int) ([]entities.Topic, error) {
+	return t.listByBlogID(ctx, db, blog_id)
+}
+
+// same as ListByBlogID, sees the changes made earlier in tx
+func (t *Topics) ListByBlogIDInTx(ctx context.Context, tx *sql.Tx, blog_id int) ([]entities.Topic, error) {
+	return t.listByBlogID(ctx, tx, blog_id)
+}
+
+func (t *Topics) listByBlogID(ctx context.Context, db rowsQuerier, blog_id int) ([]entities.Topic, error) {
 
 	stmt := `
 	SELECT 
@@ -66,7 +76,8 @@ func (t *Topics) ListByBlogID(ctx context.Context, db *sql.DB, blog_id int) ([]e
 		topics.updated_at, 
 		topics.name, 
 		topics.description, 
-		topics.slug 
+		topics.slug,
+		topics.version
 	FROM topics INNER JOIN blog_topics
 	WHERE 
 		(blog_topics.blog_id = ?) AND (blog_topics.topic_id = topics.id);
@@ -96,6 +107,7 @@ func (t *Topics) ListByBlogID(ctx context.Context, db *sql.DB, blog_id int) ([]e
 			&topic.Name,
 			&topic.Description,
 			&topic.Slug,
+			&topic.Version,
 		)
 		if err != nil {
 			if err := rows.Close(); err != nil {
@@ -181,6 +193,7 @@ func (t *Topics) List(ctx context.Context, db *sql.DB) ([]entities.Topic, error)
 			&topic.Name,
 			&topic.Description,
 			&topic.Slug,
+			&topic.Version,
 		)
 		if err != nil {
 			if err := rows.Close(); err != nil {
@@ -198,6 +211,15 @@ func (t *Topics) List(ctx context.Context, db *sql.DB) ([]entities.Topic, error)
 	return result, nil
 }
 func (t *Topics) Get(ctx context.Context, db *sql.DB, id int) (*entities.Topic, error) {
+	return t.get(ctx, db, id)
+}
+
+// same as Get, sees the changes made earlier in tx
+func (t *Topics) GetInTx(ctx context.Context, tx *sql.Tx, id int) (*entities.Topic, error) {
+	return t.get(ctx, tx, id)
+}
+
+func (t *Topics) get(ctx context.Context, db rowQuerier, id int) (*entities.Topic, error) {
 	stmt := `SELECT * FROM topics WHERE id = ?;`
 	util.LogQuery(ctx, "GetTopic:", stmt)
 
@@ -214,6 +236,7 @@ func (t *Topics) Get(ctx context.Context, db *sql.DB, id int) (*entities.Topic,
 		&topic.Name,
 		&topic.Description,
 		&topic.Slug,
+		&topic.Version,
 	)
 	if err != nil {
 		return &entities.Topic{}, fmt.Errorf("Get: row scan failed: %w", err)
@@ -221,7 +244,9 @@ func (t *Topics) Get(ctx context.Context, db *sql.DB, id int) (*entities.Topic,
 
 	return &topic, nil
 }
-func (t *Topics) Update(ctx context.Context, tx *sql.Tx, topic entities.Topic, id int) (*entities.Topic, error) {
+
+// returns sql.ErrNoRows if the topic isn't at the given version
+func (t *Topics) Update(ctx context.Context, tx *sql.Tx, topic entities.Topic, id int, version entities.Version) (*entities.Topic, error) {
 	stmt := `
 	UPDATE topics
 	SET
@@ -230,6 +255,7 @@ func (t *Topics) Update(ctx context.Context, tx *sql.Tx, topic entities.Topic, i
 		slug = ?
 	WHERE 
 		id = ?
+	AND ` + versionCond + `
 	RETURNING *;
 	`
 	util.LogQuery(ctx, "UpdateTopic:", stmt)
@@ -241,6 +267,8 @@ func (t *Topics) Update(ctx context.Context, tx *sql.Tx, topic entities.Topic, i
 		topic.Description,
 		topic.Slug,
 		id,
+		version,
+		version,
 	)
 	if err := row.Err(); err != nil {
 		return &entities.Topic{}, fmt.Errorf("Update: update query failed: %w", err)
@@ -254,6 +282,7 @@ func (t *Topics) Update(ctx context.Context, tx *sql.Tx, topic entities.Topic, i
 		&newTopic.Name,
 		&newTopic.Description,
 		&newTopic.Slug,
+		&newTopic.Version,
 	)
 	if scanErr != nil {
 		return &entities.Topic{}, fmt.Errorf("Update: scan error: %w", scanErr)
@@ -262,13 +291,54 @@ func (t *Topics) Update(ctx context.Context, tx *sql.Tx, topic entities.Topic, i
 	return &newTopic, nil
 }
 
-func (t *Topics) Delete(ctx context.Context, tx *sql.Tx, id int) (int, error) {
+// only updates the columns present in the patch, returns sql.ErrNoRows if the topic isn't at the given version
+func (t *Topics) Patch(ctx context.Context, tx *sql.Tx, patch entities.TopicPatch, id int, version entities.Version) (*entities.Topic, error) {
+	set := &setClause{}
+	setColumn(set, "name", patch.Name)
+	setColumn(set, "description", patch.Description)
+	setColumn(set, "slug", patch.Slug)
+
+	stmt := fmt.Sprintf(`
+	UPDATE topics
+	SET
+		%s
+	WHERE
+		id = ?
+	AND %s
+	RETURNING *;
+	`, set, versionCond)
+	util.LogQuery(ctx, "PatchTopic:", stmt)
+
+	row := tx.QueryRowContext(ctx, stmt, append(set.args, id, version, version)...)
+	if err := row.Err(); err != nil {
+		return &entities.Topic{}, fmt.Errorf("Patch: patch query failed: %w", err)
+	}
+
+	newTopic := entities.Topic{}
+	scanErr := row.Scan(
+		&newTopic.ID,
+		&newTopic.Created_at,
+		&newTopic.Updated_at,
+		&newTopic.Name,
+		&newTopic.Description,
+		&newTopic.Slug,
+		&newTopic.Version,
+	)
+	if scanErr != nil {
+		return &entities.Topic{}, fmt.Errorf("Patch: scan error: %w", scanErr)
+	}
+
+	return &newTopic, nil
+}
+
+// no rows are affected if the topic isn't at the given version
+func (t *Topics) Delete(ctx context.Context, tx *sql.Tx, id int, version entities.Version) (int, error) {
 	stmt := `
-	DELETE FROM topics WHERE id = ?;
+	DELETE FROM topics WHERE id = ? AND ` + versionCond + `;
 	`
 	util.LogQuery(ctx, "DeleteTags:", stmt)
 
-	res, err := tx.ExecContext(ctx, stmt, id)
+	res, err := tx.ExecContext(ctx, stmt, id, version, version)
 	if err != nil {
 		return 0, fmt.Errorf("Delete: delete error: %w", err)
 	}
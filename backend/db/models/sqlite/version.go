@@ -0,0 +1,5 @@
+package sqlite
+
+// WHERE condition of versioned writes, the zero entities.Version matches any row
+// args: version, version
+const versionCond = `(? = 0 OR version = ?)`
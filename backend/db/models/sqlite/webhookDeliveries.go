@@ -0,0 +1,269 @@
+package sqlite
+
+import (
+	"blog/entities"
+	"blog/util"
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// deliveries are always selected with their event
+const selectWebhookDeliveries = `
+	SELECT
+		webhook_deliveries.id,
+		webhook_deliveries.created_at,
+		webhook_deliveries.updated_at,
+		webhook_deliveries.webhook_id,
+		webhook_deliveries.status,
+		webhook_deliveries.attempts,
+		webhook_deliveries.next_attempt_at,
+		webhook_deliveries.response_status,
+		webhook_deliveries.error,
+		webhook_outbox.id,
+		webhook_outbox.created_at,
+		webhook_outbox.type,
+		webhook_outbox.data
+	FROM webhook_deliveries INNER JOIN webhook_outbox
+	ON webhook_outbox.id = webhook_deliveries.event_id
+`
+
+type WebhookDeliveries struct{}
+
+func NewWebhookDeliveries() *WebhookDeliveries {
+	return &WebhookDeliveries{}
+}
+
+func (w *WebhookDeliveries) Create(ctx context.Context, tx *sql.Tx, webhookID, eventID int) error {
+	stmt := `
+	INSERT INTO webhook_deliveries
+	( webhook_id, event_id )
+	VALUES
+	( ?, ? );
+	`
+	util.LogQuery(ctx, "CreateWebhookDelivery:", stmt)
+
+	if _, err := tx.ExecContext(ctx, stmt, webhookID, eventID); err != nil {
+		return fmt.Errorf("Create: insert delivery failed: %w", err)
+	}
+
+	return nil
+}
+
+func (w *WebhookDeliveries) ListByWebhookID(ctx context.Context, db *sql.DB, webhookID, limit int) ([]entities.WebhookDelivery, error) {
+	stmt := selectWebhookDeliveries + `
+	WHERE webhook_deliveries.webhook_id = ?
+	ORDER BY webhook_deliveries.id DESC
+	LIMIT ?;
+	`
+	util.LogQuery(ctx, "ListWebhookDeliveriesByWebhookID:", stmt)
+
+	rows, err := db.QueryContext(ctx, stmt, webhookID, limit)
+	if err != nil {
+		return []entities.WebhookDelivery{}, fmt.Errorf("ListByWebhookID: query failed: %w", err)
+	}
+
+	deliveries, err := scanWebhookDeliveryRows(rows)
+	if err != nil {
+		return []entities.WebhookDelivery{}, fmt.Errorf("ListByWebhookID: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+func (w *WebhookDeliveries) ListDue(ctx context.Context, db *sql.DB, now string, limit int) ([]entities.WebhookDelivery, error) {
+	stmt := selectWebhookDeliveries + `
+	WHERE
+		webhook_deliveries.status = 'pending' AND
+		webhook_deliveries.next_attempt_at <= ? AND
+		webhook_deliveries.webhook_id IN (SELECT id FROM webhooks WHERE paused = 0)
+	ORDER BY webhook_deliveries.next_attempt_at, webhook_deliveries.id
+	LIMIT ?;
+	`
+	util.LogQuery(ctx, "ListDueWebhookDeliveries:", stmt)
+
+	rows, err := db.QueryContext(ctx, stmt, now, limit)
+	if err != nil {
+		return []entities.WebhookDelivery{}, fmt.Errorf("ListDue: query failed: %w", err)
+	}
+
+	deliveries, err := scanWebhookDeliveryRows(rows)
+	if err != nil {
+		return []entities.WebhookDelivery{}, fmt.Errorf("ListDue: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+func (w *WebhookDeliveries) UpdateAttempt(ctx context.Context, tx *sql.Tx, delivery entities.WebhookDelivery) error {
+	stmt := `
+	UPDATE webhook_deliveries
+	SET
+		status = ?,
+		attempts = ?,
+		next_attempt_at = ?,
+		response_status = ?,
+		error = ?
+	WHERE
+		id = ?;
+	`
+	util.LogQuery(ctx, "UpdateWebhookDeliveryAttempt:", stmt)
+
+	_, err := tx.ExecContext(
+		ctx,
+		stmt,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.NextAttemptAt,
+		delivery.ResponseStatus,
+		delivery.Error,
+		delivery.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("UpdateAttempt: update query failed: %w", err)
+	}
+
+	return nil
+}
+
+func (w *WebhookDeliveries) Replay(ctx context.Context, tx *sql.Tx, id int) (*entities.WebhookDelivery, error) {
+	stmt := `
+	UPDATE webhook_deliveries
+	SET
+		status = 'pending',
+		attempts = 0,
+		next_attempt_at = strftime('%FT%T+00:00'),
+		response_status = 0,
+		error = ''
+	WHERE
+		id = ?;
+	`
+	util.LogQuery(ctx, "ReplayWebhookDelivery:", stmt)
+
+	res, err := tx.ExecContext(ctx, stmt, id)
+	if err != nil {
+		return &entities.WebhookDelivery{}, fmt.Errorf("Replay: update query failed: %w", err)
+	}
+	affectedRows, err := res.RowsAffected()
+	if err != nil {
+		return &entities.WebhookDelivery{}, fmt.Errorf("Replay: get affected rows failed: %w", err)
+	}
+	if affectedRows == 0 {
+		return &entities.WebhookDelivery{}, fmt.Errorf("Replay: delivery not found: %w", sql.ErrNoRows)
+	}
+
+	selectStmt := selectWebhookDeliveries + `WHERE webhook_deliveries.id = ?;`
+	util.LogQuery(ctx, "ReplayWebhookDelivery:", selectStmt)
+
+	row := tx.QueryRowContext(ctx, selectStmt, id)
+	if err := row.Err(); err != nil {
+		return &entities.WebhookDelivery{}, fmt.Errorf("Replay: query failed: %w", err)
+	}
+
+	delivery, err := scanWebhookDelivery(row)
+	if err != nil {
+		return &entities.WebhookDelivery{}, fmt.Errorf("Replay: scan error: %w", err)
+	}
+
+	return delivery, nil
+}
+
+func (w *WebhookDeliveries) DeleteFinishedBefore(ctx context.Context, tx *sql.Tx, before string) (int, error) {
+	stmt := `
+	DELETE FROM webhook_deliveries
+	WHERE
+		status != 'pending' AND
+		updated_at < ?;
+	`
+	util.LogQuery(ctx, "DeleteFinishedWebhookDeliveries:", stmt)
+
+	res, err := tx.ExecContext(ctx, stmt, before)
+	if err != nil {
+		return 0, fmt.Errorf("DeleteFinishedBefore: delete error: %w", err)
+	}
+
+	affectedRows, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("DeleteFinishedBefore: get affected rows error: %w", err)
+	}
+
+	return int(affectedRows), nil
+}
+
+func (w *WebhookDeliveries) DeleteByWebhookID(ctx context.Context, tx *sql.Tx, webhookID int) error {
+	stmt := `DELETE FROM webhook_deliveries WHERE webhook_id = ?;`
+	util.LogQuery(ctx, "DeleteWebhookDeliveriesByWebhookID:", stmt)
+
+	if _, err := tx.ExecContext(ctx, stmt, webhookID); err != nil {
+		return fmt.Errorf("DeleteByWebhookID: delete error: %w", err)
+	}
+
+	return nil
+}
+
+// Helper for scanning a single delivery selected with selectWebhookDeliveries
+func scanWebhookDelivery(row *sql.Row) (*entities.WebhookDelivery, error) {
+	delivery := entities.WebhookDelivery{}
+	var data string
+	err := row.Scan(
+		&delivery.ID,
+		&delivery.Created_at,
+		&delivery.Updated_at,
+		&delivery.WebhookID,
+		&delivery.Status,
+		&delivery.Attempts,
+		&delivery.NextAttemptAt,
+		&delivery.ResponseStatus,
+		&delivery.Error,
+		&delivery.Event.ID,
+		&delivery.Event.Created_at,
+		&delivery.Event.Type,
+		&data,
+	)
+	if err != nil {
+		return &entities.WebhookDelivery{}, fmt.Errorf("scanWebhookDelivery: scan delivery failed: %w", err)
+	}
+	delivery.Event.Data = []byte(data)
+	return &delivery, nil
+}
+
+// Helper for scanning delivery rows selected with selectWebhookDeliveries
+func scanWebhookDeliveryRows(rows *sql.Rows) ([]entities.WebhookDelivery, error) {
+	result := []entities.WebhookDelivery{}
+	for {
+		if !rows.Next() {
+			break
+		}
+		delivery := entities.WebhookDelivery{}
+		var data string
+		err := rows.Scan(
+			&delivery.ID,
+			&delivery.Created_at,
+			&delivery.Updated_at,
+			&delivery.WebhookID,
+			&delivery.Status,
+			&delivery.Attempts,
+			&delivery.NextAttemptAt,
+			&delivery.ResponseStatus,
+			&delivery.Error,
+			&delivery.Event.ID,
+			&delivery.Event.Created_at,
+			&delivery.Event.Type,
+			&data,
+		)
+		if err != nil {
+			if err := rows.Close(); err != nil {
+				return []entities.WebhookDelivery{}, fmt.Errorf("scanWebhookDeliveryRows: close rows failed: %w", err)
+			}
+			return []entities.WebhookDelivery{}, fmt.Errorf("scanWebhookDeliveryRows: scan failed: %w", err)
+		}
+		delivery.Event.Data = []byte(data)
+		result = append(result, delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return []entities.WebhookDelivery{}, fmt.Errorf("scanWebhookDeliveryRows: rows iteration error: %w", err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,114 @@
+package sqlite
+
+import (
+	"blog/entities"
+	"blog/util"
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+type WebhookOutbox struct{}
+
+func NewWebhookOutbox() *WebhookOutbox {
+	return &WebhookOutbox{}
+}
+
+func (w *WebhookOutbox) Create(ctx context.Context, tx *sql.Tx, eventType string, data []byte) error {
+	stmt := `
+	INSERT INTO webhook_outbox
+	( type, data )
+	VALUES
+	( ?, ? );
+	`
+	util.LogQuery(ctx, "CreateWebhookEvent:", stmt)
+
+	if _, err := tx.ExecContext(ctx, stmt, eventType, string(data)); err != nil {
+		return fmt.Errorf("Create: insert event failed: %w", err)
+	}
+
+	return nil
+}
+
+func (w *WebhookOutbox) ListUndispatched(ctx context.Context, db *sql.DB, limit int) ([]entities.WebhookEvent, error) {
+	stmt := `
+	SELECT
+		id,
+		created_at,
+		type,
+		data
+	FROM webhook_outbox
+	WHERE dispatched = 0
+	ORDER BY id
+	LIMIT ?;
+	`
+	util.LogQuery(ctx, "ListUndispatchedWebhookEvents:", stmt)
+
+	rows, err := db.QueryContext(ctx, stmt, limit)
+	if err != nil {
+		return []entities.WebhookEvent{}, fmt.Errorf("ListUndispatched: query failed: %w", err)
+	}
+
+	result := []entities.WebhookEvent{}
+	for {
+		if !rows.Next() {
+			break
+		}
+		event := entities.WebhookEvent{}
+		var data string
+		err := rows.Scan(
+			&event.ID,
+			&event.Created_at,
+			&event.Type,
+			&data,
+		)
+		if err != nil {
+			if err := rows.Close(); err != nil {
+				return []entities.WebhookEvent{}, fmt.Errorf("ListUndispatched: close rows failed: %w", err)
+			}
+			return []entities.WebhookEvent{}, fmt.Errorf("ListUndispatched: scan failed: %w", err)
+		}
+		event.Data = []byte(data)
+		result = append(result, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return []entities.WebhookEvent{}, fmt.Errorf("ListUndispatched: rows iteration error: %w", err)
+	}
+
+	return result, nil
+}
+
+func (w *WebhookOutbox) MarkDispatched(ctx context.Context, tx *sql.Tx, id int) error {
+	stmt := `UPDATE webhook_outbox SET dispatched = 1 WHERE id = ?;`
+	util.LogQuery(ctx, "MarkWebhookEventDispatched:", stmt)
+
+	if _, err := tx.ExecContext(ctx, stmt, id); err != nil {
+		return fmt.Errorf("MarkDispatched: update query failed: %w", err)
+	}
+
+	return nil
+}
+
+func (w *WebhookOutbox) DeleteDispatchedBefore(ctx context.Context, tx *sql.Tx, before string) (int, error) {
+	stmt := `
+	DELETE FROM webhook_outbox
+	WHERE
+		dispatched = 1 AND
+		created_at < ? AND
+		id NOT IN (SELECT event_id FROM webhook_deliveries);
+	`
+	util.LogQuery(ctx, "DeleteDispatchedWebhookEvents:", stmt)
+
+	res, err := tx.ExecContext(ctx, stmt, before)
+	if err != nil {
+		return 0, fmt.Errorf("DeleteDispatchedBefore: delete error: %w", err)
+	}
+
+	affectedRows, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("DeleteDispatchedBefore: get affected rows error: %w", err)
+	}
+
+	return int(affectedRows), nil
+}
@@ -0,0 +1,182 @@
+package sqlite
+
+import (
+	"blog/entities"
+	"blog/util"
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+type Webhooks struct{}
+
+func NewWebhooks() *Webhooks {
+	return &Webhooks{}
+}
+
+func (w *Webhooks) Create(ctx context.Context, tx *sql.Tx, webhook entities.Webhook) (*entities.Webhook, error) {
+	stmt := `
+	INSERT INTO webhooks
+	(
+		url,
+		secret,
+		events,
+		paused
+	)
+	VALUES
+	( ?, ?, ?, ? )
+	RETURNING *;
+	`
+	util.LogQuery(ctx, "CreateWebhook:", stmt)
+
+	row := tx.QueryRowContext(
+		ctx,
+		stmt,
+		webhook.URL,
+		webhook.Secret,
+		strings.Join(webhook.Events, ","),
+		webhook.Paused,
+	)
+	if err := row.Err(); err != nil {
+		return &entities.Webhook{}, fmt.Errorf("Create: insert webhook failed: %w", err)
+	}
+
+	newWebhook, err := scanWebhook(row)
+	if err != nil {
+		return &entities.Webhook{}, fmt.Errorf("Create: scan error: %w", err)
+	}
+
+	return newWebhook, nil
+}
+
+func (w *Webhooks) Get(ctx context.Context, db *sql.DB, id int) (*entities.Webhook, error) {
+	stmt := `SELECT * FROM webhooks WHERE id = ?;`
+	util.LogQuery(ctx, "GetWebhook:", stmt)
+
+	row := db.QueryRowContext(ctx, stmt, id)
+	if err := row.Err(); err != nil {
+		return &entities.Webhook{}, fmt.Errorf("Get: query failed: %w", err)
+	}
+
+	webhook, err := scanWebhook(row)
+	if err != nil {
+		return &entities.Webhook{}, fmt.Errorf("Get: row scan failed: %w", err)
+	}
+
+	return webhook, nil
+}
+
+func (w *Webhooks) List(ctx context.Context, db *sql.DB) ([]entities.Webhook, error) {
+	stmt := `SELECT * FROM webhooks ORDER BY id;`
+	util.LogQuery(ctx, "ListWebhooks:", stmt)
+
+	rows, err := db.QueryContext(ctx, stmt)
+	if err != nil {
+		return []entities.Webhook{}, fmt.Errorf("List: query failed: %w", err)
+	}
+
+	result := []entities.Webhook{}
+	for {
+		if !rows.Next() {
+			break
+		}
+		webhook := entities.Webhook{}
+		var events string
+		err := rows.Scan(
+			&webhook.ID,
+			&webhook.Created_at,
+			&webhook.Updated_at,
+			&webhook.URL,
+			&webhook.Secret,
+			&events,
+			&webhook.Paused,
+		)
+		if err != nil {
+			if err := rows.Close(); err != nil {
+				return []entities.Webhook{}, fmt.Errorf("List: close rows failed: %w", err)
+			}
+			return []entities.Webhook{}, fmt.Errorf("List: scan failed: %w", err)
+		}
+		webhook.Events = strings.Split(events, ",")
+		result = append(result, webhook)
+	}
+
+	if err := rows.Err(); err != nil {
+		return []entities.Webhook{}, fmt.Errorf("List: rows iteration error: %w", err)
+	}
+
+	return result, nil
+}
+
+func (w *Webhooks) Update(ctx context.Context, tx *sql.Tx, webhook entities.Webhook, id int) (*entities.Webhook, error) {
+	stmt := `
+	UPDATE webhooks
+	SET
+		url = ?,
+		secret = COALESCE(NULLIF(?, ''), secret),
+		events = ?,
+		paused = ?
+	WHERE
+		id = ?
+	RETURNING *;
+	`
+	util.LogQuery(ctx, "UpdateWebhook:", stmt)
+
+	row := tx.QueryRowContext(
+		ctx,
+		stmt,
+		webhook.URL,
+		webhook.Secret,
+		strings.Join(webhook.Events, ","),
+		webhook.Paused,
+		id,
+	)
+	if err := row.Err(); err != nil {
+		return &entities.Webhook{}, fmt.Errorf("Update: update query failed: %w", err)
+	}
+
+	newWebhook, err := scanWebhook(row)
+	if err != nil {
+		return &entities.Webhook{}, fmt.Errorf("Update: scan error: %w", err)
+	}
+
+	return newWebhook, nil
+}
+
+func (w *Webhooks) Delete(ctx context.Context, tx *sql.Tx, id int) (int, error) {
+	stmt := `DELETE FROM webhooks WHERE id = ?;`
+	util.LogQuery(ctx, "DeleteWebhook:", stmt)
+
+	res, err := tx.ExecContext(ctx, stmt, id)
+	if err != nil {
+		return 0, fmt.Errorf("Delete: delete error: %w", err)
+	}
+
+	affectedRows, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("Delete: get affected rows error: %w", err)
+	}
+
+	return int(affectedRows), nil
+}
+
+// Helper for scanning a single webhook
+func scanWebhook(row *sql.Row) (*entities.Webhook, error) {
+	webhook := entities.Webhook{}
+	var events string
+	err := row.Scan(
+		&webhook.ID,
+		&webhook.Created_at,
+		&webhook.Updated_at,
+		&webhook.URL,
+		&webhook.Secret,
+		&events,
+		&webhook.Paused,
+	)
+	if err != nil {
+		return &entities.Webhook{}, fmt.Errorf("scanWebhook: scan webhook failed: %w", err)
+	}
+	webhook.Events = strings.Split(events, ",")
+	return &webhook, nil
+}
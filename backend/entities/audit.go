@@ -0,0 +1,42 @@
+package entities
+
+import "encoding/json"
+
+// A change made through the api, only recorded when the request succeeded.
+// created_at is in ISO 8601.
+type AuditEntry struct {
+	ID         int    `json:"id"`
+	Created_at string `json:"created_at"`
+	Actor      string `json:"actor"` // jwt sub
+	Route      string `json:"route"` // method and path, ex: PUT /api/v1/blogs/1
+	TargetType string `json:"targetType"`
+	TargetID   string `json:"targetId"`
+	// changed fields as {"field": [before, after]}, before is null when created and after is null when deleted
+	Diff      json.RawMessage `json:"diff" swaggertype:"object"`
+	ClientIP  string          `json:"clientIp"`
+	RequestID string          `json:"requestId"`
+}
+
+func NewAuditEntry(actor, route, targetType, targetID string, diff json.RawMessage, clientIP, requestID string) *AuditEntry {
+	return &AuditEntry{
+		Actor:      actor,
+		Route:      route,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Diff:       diff,
+		ClientIP:   clientIP,
+		RequestID:  requestID,
+	}
+}
+
+// Empty fields are not filtered on. From and To are ISO 8601, both inclusive.
+// Entries are listed newest first, Before is the id of the last entry of the previous page.
+type AuditFilter struct {
+	Actor      string
+	TargetType string
+	TargetID   string
+	From       string
+	To         string
+	Before     int
+	Limit      int
+}
@@ -0,0 +1,30 @@
+package entities
+
+// A status change of a blog, created_at is in ISO 8601.
+// From is empty when the blog was created.
+type BlogStatusEvent struct {
+	ID         int    `json:"id"`
+	Created_at string `json:"created_at"`
+	BlogID     int    `json:"blogID"`
+	From       string `json:"from"`
+	To         string `json:"to"`
+	Actor      string `json:"actor"` // user name, or scheduler
+	Note       string `json:"note"`
+}
+
+func NewBlogStatusEvent(blogID int, from, to, actor, note string) *BlogStatusEvent {
+	return &BlogStatusEvent{
+		BlogID: blogID,
+		From:   from,
+		To:     to,
+		Actor:  actor,
+		Note:   note,
+	}
+}
+
+// ScheduledAt is required when moving to scheduled, ISO 8601 in the future
+type InTransition struct {
+	Status      string `json:"status" enums:"draft,in_review,scheduled,published,archived"`
+	ScheduledAt string `json:"scheduledAt"`
+	Note        string `json:"note"`
+}
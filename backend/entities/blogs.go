@@ -2,24 +2,58 @@ package entities
 
 import (
 	"crypto/md5"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
 
 	"github.com/gosimple/slug"
 )
 
+// Editorial workflow, only published blogs are public
+const (
+	BlogDraft     = "draft"
+	BlogInReview  = "in_review"
+	BlogScheduled = "scheduled" // published by the scheduler at scheduledAt
+	BlogPublished = "published"
+	BlogArchived  = "archived"
+)
+
+var BlogStatuses = []string{BlogDraft, BlogInReview, BlogScheduled, BlogPublished, BlogArchived}
+
+var ErrorInvalidStatusTransition = errors.New("invalid status transition")
+
+// allowed status changes, from -> to
+var blogTransitions = map[string][]string{
+	BlogDraft:     {BlogInReview, BlogScheduled, BlogPublished, BlogArchived},
+	BlogInReview:  {BlogDraft, BlogScheduled, BlogPublished},
+	BlogScheduled: {BlogDraft, BlogInReview, BlogPublished},
+	BlogPublished: {BlogDraft, BlogArchived},
+	BlogArchived:  {BlogDraft, BlogPublished},
+}
+
+func CanTransition(from, to string) bool {
+	return slices.Contains(blogTransitions[from], to)
+}
+
 // xxx_at are all in ISO 8601.
+// Visible is kept for backward compatibility, it is true only when status is published.
 type Blog struct {
-	ID          int    `json:"id"`
-	Created_at  string `json:"created_at"`
-	Updated_at  string `json:"updated_at"`
-	Deleted_at  string `json:"deleted_at"`
-	Title       string `json:"title"`
-	Content     string `json:"content"`
-	ContentMD5  string `json:"contentMD5"`
-	Description string `json:"description"`
-	Slug        string `json:"slug"`
-	Pined       bool   `json:"pined"`
-	Visible     bool   `json:"visible"`
+	ID          int     `json:"id"`
+	Created_at  string  `json:"created_at"`
+	Updated_at  string  `json:"updated_at"`
+	Deleted_at  string  `json:"deleted_at"`
+	Deleted_by  string  `json:"deleted_by"` // empty unless soft deleted
+	Title       string  `json:"title"`
+	Content     string  `json:"content"`
+	ContentMD5  string  `json:"contentMD5"`
+	Description string  `json:"description"`
+	Slug        string  `json:"slug"`
+	Pined       bool    `json:"pined"`
+	Visible     bool    `json:"visible"`
+	Status      string  `json:"status"`
+	ScheduledAt string  `json:"scheduledAt"` // empty unless scheduled
+	Version     Version `json:"version"`     // bumped on every update
 }
 
 func (b *Blog) GenSlug() {
@@ -29,14 +63,41 @@ func (b *Blog) GenMD5() {
 	b.ContentMD5 = fmt.Sprintf("%x", md5.Sum([]byte(b.Content)))
 }
 
+// Keeps visible in sync with status
+func (b *Blog) SetStatus(status, scheduledAt string) {
+	b.Status = status
+	b.Visible = status == BlogPublished
+	b.ScheduledAt = ""
+	if status == BlogScheduled {
+		b.ScheduledAt = scheduledAt
+	}
+}
+
+// Status of blogs only giving visible, as before the editorial workflow.
+// Hiding a published blog turns it back into a draft, other statuses are kept.
+func StatusFromVisible(current string, visible bool) string {
+	if visible {
+		return BlogPublished
+	}
+	if current == BlogPublished || current == "" {
+		return BlogDraft
+	}
+	return current
+}
+
+// Empty statuses matches all
+func (b *Blog) InStatus(statuses []string) bool {
+	return len(statuses) == 0 || slices.Contains(statuses, b.Status)
+}
+
 func NewBlog(title, content, description string, pined, visible bool) *Blog {
 	blog := &Blog{
 		Title:       title,
 		Content:     content,
 		Description: description,
 		Pined:       pined,
-		Visible:     visible,
 	}
+	blog.SetStatus(StatusFromVisible("", visible), "")
 	blog.GenSlug()
 	blog.GenMD5()
 	return blog
@@ -49,8 +110,8 @@ func NewBlogWithID(id int, title, content, description string, pined, visible bo
 		Content:     content,
 		Description: description,
 		Pined:       pined,
-		Visible:     visible,
 	}
+	blog.SetStatus(StatusFromVisible("", visible), "")
 	blog.GenSlug()
 	blog.GenMD5()
 	return blog
@@ -60,6 +121,12 @@ type InBlog struct {
 	Blog
 	Tags   []int `json:"tags"`
 	Topics []int `json:"topics"`
+	// series id, 0 means not part of a series
+	Series int `json:"series"`
+	// position in series, 0 means append to the end
+	Part int `json:"part"`
+	// who made the change, recorded with status changes
+	Actor string `json:"-"`
 }
 
 func NewInBlog(blog Blog, tags, topics []int) *InBlog {
@@ -82,6 +149,24 @@ type OutBlog struct {
 	Blog
 	Tags   []Tag   `json:"tags"`
 	Topics []Topic `json:"topics"`
+	// only filled in if the blog is part of a series,
+	// prev and next only consider visible blogs
+	Series *BlogSeries `json:"series,omitempty"`
+	Prev   *SeriesPart `json:"prev,omitempty"`
+	Next   *SeriesPart `json:"next,omitempty"`
+}
+
+/*
+Strong ETag of the blog with its tags, topics and series, variant works like Version.ETag.
+
+Renaming a tag, topic or series, or changing a neighbour in the series, doesn't bump the blog version,
+a digest of them is part of the etag so that clients don't keep a stale copy.
+*/
+func (b OutBlog) ETag(variant ...string) string {
+	// plain structs, can't fail
+	related, _ := json.Marshal([]any{b.Tags, b.Topics, b.Series, b.Prev, b.Next})
+	digest := fmt.Sprintf("%x", md5.Sum(related))[:12]
+	return b.Version.ETag(append([]string{digest}, variant...)...)
 }
 
 func NewOutBlog(blog Blog, tags []Tag, topics []Topic) *OutBlog {
@@ -97,6 +182,9 @@ type OutBlogSimple struct {
 	Blog
 	Tags   []string `json:"tags"`
 	Topics []string `json:"topics"`
+	// series slug and position, empty if not part of a series
+	Series string `json:"series,omitempty"`
+	Part   int    `json:"part,omitempty"`
 }
 
 func NewOutBlogSimple(blog Blog, tags []string, topics []string) OutBlogSimple {
@@ -113,6 +201,82 @@ type ReqInBlog struct {
 	Description string `json:"description"`
 	Pined       bool   `json:"pined"`
 	Visible     bool   `json:"visible"`
-	Tags        []int  `json:"tags"`
-	Topics      []int  `json:"topics"`
+	// only used on create, overrides visible. Later changes go through transitions,
+	// except hiding or showing a blog with visible.
+	Status string `json:"status" enums:"draft,in_review,published,archived"`
+	Tags   []int  `json:"tags"`
+	Topics []int  `json:"topics"`
+	Series int    `json:"series"` // series id, 0 means not part of a series
+	Part   int    `json:"part"`   // position in series, 0 means append to the end
+}
+
+var ErrorPatchPartWithoutSeries = errors.New("part can only be patched together with series")
+
+// JSON Merge Patch of a blog, nil fields are left unchanged.
+// Relations are only replaced when tags, topics or series are present.
+type BlogPatch struct {
+	Title       *string `json:"title"`
+	Content     *string `json:"content"`
+	Description *string `json:"description"` // null clears it
+	Pined       *bool   `json:"pined"`
+	Visible     *bool   `json:"visible"`
+	Tags        *[]int  `json:"tags"`   // null clears them
+	Topics      *[]int  `json:"topics"` // null clears them
+	Series      *int    `json:"series"` // null or 0 removes the blog from its series
+	Part        *int    `json:"part"`   // position in series, null or 0 appends to the end
+
+	// derived from the fields above
+	Slug        *string `json:"-"`
+	ContentMD5  *string `json:"-"`
+	Status      *string `json:"-"`
+	ScheduledAt *string `json:"-"`
+	// who made the change, recorded with status changes
+	Actor string `json:"-"`
+}
+
+func (p *BlogPatch) UnmarshalJSON(data []byte) error {
+	err := decodeMergePatch(data, map[string]any{
+		"title":       &p.Title,
+		"content":     &p.Content,
+		"description": &p.Description,
+		"pined":       &p.Pined,
+		"visible":     &p.Visible,
+		"tags":        &p.Tags,
+		"topics":      &p.Topics,
+		"series":      &p.Series,
+		"part":        &p.Part,
+	}, "description", "tags", "topics", "series", "part")
+	if err != nil {
+		return err
+	}
+
+	if p.Part != nil && p.Series == nil {
+		return ErrorPatchPartWithoutSeries
+	}
+	return nil
+}
+
+// Only regenerated when the title changes
+func (p *BlogPatch) GenSlug() {
+	if p.Title != nil {
+		s := slug.Make(*p.Title)
+		p.Slug = &s
+	}
+}
+
+// Only regenerated when the content changes
+func (p *BlogPatch) GenMD5() {
+	if p.Content != nil {
+		sum := fmt.Sprintf("%x", md5.Sum([]byte(*p.Content)))
+		p.ContentMD5 = &sum
+	}
+}
+
+// Keeps visible in sync with status, like Blog.SetStatus
+func (p *BlogPatch) SetStatus(status, scheduledAt string) {
+	visible := status == BlogPublished
+	if status != BlogScheduled {
+		scheduledAt = ""
+	}
+	p.Status, p.Visible, p.ScheduledAt = &status, &visible, &scheduledAt
 }
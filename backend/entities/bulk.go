@@ -0,0 +1,166 @@
+package entities
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Operations and targets of a bulk request
+const (
+	BulkCreate = "create"
+	BulkUpdate = "update"
+	BulkDelete = "delete"
+
+	BulkTopic = "topic"
+	BulkTag   = "tag"
+	BulkBlog  = "blog"
+)
+
+// Result of each operation
+const (
+	BulkOK         = "ok"
+	BulkFailed     = "failed"
+	BulkRolledBack = "rolled_back" // succeeded, undone because another operation failed in atomic mode
+	BulkSkipped    = "skipped"     // not tried because an earlier operation failed in atomic mode
+)
+
+var (
+	ErrorBulkEmpty             = errors.New("bulk request should have at least one operation")
+	ErrorBulkTooManyOperations = errors.New("too many operations in bulk request")
+	ErrorBulkInvalidOperation  = errors.New("invalid bulk operation")
+	ErrorBulkInvalidRef        = errors.New("invalid bulk reference")
+	// the operation creating the referenced target failed
+	ErrorBulkUnresolvedRef = errors.New("referenced target was not created")
+)
+
+/*
+One operation of a bulk request.
+
+Created targets can be given a client side ref, blogs later in the same request
+use it in tagRefs and topicRefs to be linked to tags and topics created with them.
+*/
+type BulkOperation struct {
+	Op   string `json:"op" enums:"create,update,delete"`
+	Type string `json:"type" enums:"topic,tag,blog"`
+	// target of update and delete
+	ID int `json:"id"`
+	// client side id of the created target, unique in the request
+	Ref string `json:"ref"`
+	// optional, same as the If-Match header of single updates and deletes
+	IfMatch string `json:"ifMatch"`
+	// refs of tags and topics created earlier in the request, added to tags and topics of the blog
+	TagRefs   []string `json:"tagRefs"`
+	TopicRefs []string `json:"topicRefs"`
+	// InTopic, InTag or ReqInBlog, empty for delete
+	Data json.RawMessage `json:"data" swaggertype:"object"`
+
+	// decoded from data
+	Topic *Topic  `json:"-"`
+	Tag   *Tag    `json:"-"`
+	Blog  *InBlog `json:"-"`
+}
+
+// Operations are applied in order in one transaction.
+// Failed operations are skipped unless atomic is set, then none are applied.
+type Bulk struct {
+	Atomic     bool            `json:"atomic"`
+	Operations []BulkOperation `json:"operations"`
+	// who made the changes, recorded with status changes and deletes
+	Actor string `json:"-"`
+}
+
+// Checks operations and refs, not the data
+func (b *Bulk) Validate(maxOperations int) error {
+	if len(b.Operations) == 0 {
+		return ErrorBulkEmpty
+	}
+	if len(b.Operations) > maxOperations {
+		return fmt.Errorf("%w: at most %d", ErrorBulkTooManyOperations, maxOperations)
+	}
+
+	// ref -> type of created target
+	refs := map[string]string{}
+	for i, op := range b.Operations {
+		switch op.Type {
+		case BulkTopic, BulkTag, BulkBlog:
+		default:
+			return fmt.Errorf("%w: operation %d: unknown type %q", ErrorBulkInvalidOperation, i, op.Type)
+		}
+
+		switch op.Op {
+		case BulkCreate:
+			if op.ID != 0 {
+				return fmt.Errorf("%w: operation %d: create doesn't take an id", ErrorBulkInvalidOperation, i)
+			}
+		case BulkUpdate, BulkDelete:
+			if op.ID <= 0 {
+				return fmt.Errorf("%w: operation %d: %s needs an id", ErrorBulkInvalidOperation, i, op.Op)
+			}
+			if op.Ref != "" {
+				return fmt.Errorf("%w: operation %d: only created targets have a ref", ErrorBulkInvalidRef, i)
+			}
+		default:
+			return fmt.Errorf("%w: operation %d: unknown op %q", ErrorBulkInvalidOperation, i, op.Op)
+		}
+
+		if (len(op.TagRefs) > 0 || len(op.TopicRefs) > 0) && (op.Type != BulkBlog || op.Op == BulkDelete) {
+			return fmt.Errorf("%w: operation %d: only created or updated blogs take tagRefs and topicRefs", ErrorBulkInvalidRef, i)
+		}
+		for _, ref := range op.TagRefs {
+			if refs[ref] != BulkTag {
+				return fmt.Errorf("%w: operation %d: %q is not a tag created before", ErrorBulkInvalidRef, i, ref)
+			}
+		}
+		for _, ref := range op.TopicRefs {
+			if refs[ref] != BulkTopic {
+				return fmt.Errorf("%w: operation %d: %q is not a topic created before", ErrorBulkInvalidRef, i, ref)
+			}
+		}
+
+		if op.Ref != "" {
+			if _, ok := refs[op.Ref]; ok {
+				return fmt.Errorf("%w: operation %d: duplicate ref %q", ErrorBulkInvalidRef, i, op.Ref)
+			}
+			refs[op.Ref] = op.Type
+		}
+	}
+
+	return nil
+}
+
+type BulkResult struct {
+	Index int    `json:"index"`
+	Op    string `json:"op"`
+	Type  string `json:"type"`
+	Ref   string `json:"ref,omitempty"`
+	// id of the target, assigned by the server for created targets
+	ID     int    `json:"id"`
+	Status string `json:"status" enums:"ok,failed,rolled_back,skipped"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Committed is false when nothing was applied, in atomic mode or when every operation failed
+type OutBulk struct {
+	Committed bool         `json:"committed"`
+	Failed    int          `json:"failed"`
+	Results   []BulkResult `json:"results"`
+}
+
+// Results in order of the operations, all skipped until applied
+func NewOutBulk(operations []BulkOperation) *OutBulk {
+	results := make([]BulkResult, len(operations))
+	for i, op := range operations {
+		results[i] = BulkResult{
+			Index:  i,
+			Op:     op.Op,
+			Type:   op.Type,
+			Ref:    op.Ref,
+			ID:     op.ID,
+			Status: BulkSkipped,
+		}
+	}
+	return &OutBulk{
+		Results: results,
+	}
+}
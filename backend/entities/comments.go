@@ -0,0 +1,54 @@
+package entities
+
+const (
+	CommentPending  = "pending"
+	CommentApproved = "approved"
+	CommentRejected = "rejected"
+)
+
+// xxx_at are all in ISO 8601.
+// Content is markdown as written by the reader.
+type Comment struct {
+	ID         int    `json:"id"`
+	Created_at string `json:"created_at"`
+	Updated_at string `json:"updated_at"`
+	BlogID     int    `json:"blogID"`
+	ParentID   int    `json:"parentID"` // 0 for top level comments
+	Author     string `json:"author"`
+	Content    string `json:"content"`
+	Status     string `json:"status"` // pending, approved or rejected
+}
+
+// New comments always wait for moderation
+func NewComment(blogID, parentID int, author, content string) *Comment {
+	return &Comment{
+		BlogID:   blogID,
+		ParentID: parentID,
+		Author:   author,
+		Content:  content,
+		Status:   CommentPending,
+	}
+}
+
+type InComment struct {
+	ParentID int    `json:"parentID"`
+	Author   string `json:"author"`
+	Content  string `json:"content"`
+	// honeypot, hidden from readers by the frontend.
+	// Anything filled in here is treated as spam.
+	Website string `json:"website"`
+}
+
+// Comment with content rendered as sanitized html
+type OutComment struct {
+	Comment
+	HTML    string       `json:"html"`
+	Replies []OutComment `json:"replies,omitempty"`
+}
+
+func NewOutComment(comment Comment, html string) *OutComment {
+	return &OutComment{
+		Comment: comment,
+		HTML:    html,
+	}
+}
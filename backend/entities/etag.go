@@ -0,0 +1,61 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrorPreconditionFailed = errors.New("target has changed, If-Match doesn't match its current ETag")
+	ErrorConcurrentChange   = errors.New("target was changed by another request while being written, try again")
+)
+
+/*
+Version of a row for optimistic concurrency control, the zero value matches any version.
+
+Rows start at version 1 and the update triggers bump it, updated_at only has a resolution of one second
+and can't tell writes within the same second apart.
+*/
+type Version int
+
+func (v Version) IsZero() bool {
+	return v == 0
+}
+
+// Strong ETag, variant tells apart representations of the same version, ex: parsed markdown
+func (v Version) ETag(variant ...string) string {
+	parts := append([]string{strconv.Itoa(int(v))}, variant...)
+	return fmt.Sprintf(`"%s"`, strings.Join(parts, "-"))
+}
+
+// If-Match uses the strong comparison, "*" matches any etag. An empty header matches too.
+func IfMatch(header, etag string) bool {
+	return header == "" || matchETag(header, etag, false)
+}
+
+// If-None-Match uses the weak comparison, "*" matches any etag. An empty header never matches.
+func IfNoneMatch(header, etag string) bool {
+	return header != "" && matchETag(header, etag, true)
+}
+
+// header is a comma separated list of etags, or "*"
+func matchETag(header, etag string, weak bool) bool {
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if strings.HasPrefix(candidate, "W/") {
+			if !weak {
+				continue
+			}
+			candidate = candidate[2:]
+		}
+		if candidate == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
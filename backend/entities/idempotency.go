@@ -0,0 +1,35 @@
+package entities
+
+import "errors"
+
+var (
+	ErrorIdempotencyKeyReused  = errors.New("Idempotency-Key was already used with a different request")
+	ErrorIdempotencyInProgress = errors.New("a request with the same Idempotency-Key is still in progress")
+)
+
+// Response of a request made with an Idempotency-Key, replayed for retries of the same request.
+// xxx_at are all in ISO 8601.
+type IdempotencyKey struct {
+	Actor       string
+	Key         string
+	RequestHash string
+	// 0 while the first request is in progress
+	Status      int
+	ContentType string
+	Response    []byte
+	Created_at  string
+	Expires_at  string
+}
+
+func NewIdempotencyKey(actor, key, requestHash, expiresAt string) *IdempotencyKey {
+	return &IdempotencyKey{
+		Actor:       actor,
+		Key:         key,
+		RequestHash: requestHash,
+		Expires_at:  expiresAt,
+	}
+}
+
+func (i *IdempotencyKey) InProgress() bool {
+	return i.Status == 0
+}
@@ -0,0 +1,63 @@
+package entities
+
+import (
+	"regexp"
+)
+
+// Matches links to uploaded media, ex: /api/v1/media/<sha256>
+var mediaLinkRegexp = regexp.MustCompile(`/media/([a-f0-9]{64})`)
+
+// xxx_at are all in ISO 8601.
+type Media struct {
+	Hash       string `json:"hash"` // sha256 of file content
+	Created_at string `json:"created_at"`
+	Updated_at string `json:"updated_at"`
+	Filename   string `json:"filename"`
+	Mime       string `json:"mime"`
+	Size       int64  `json:"size"` // bytes
+	Width      int    `json:"width"`
+	Height     int    `json:"height"`
+	Alt        string `json:"alt"`
+}
+
+func NewMedia(hash, filename, mime string, size int64, width, height int, alt string) *Media {
+	return &Media{
+		Hash:     hash,
+		Filename: filename,
+		Mime:     mime,
+		Size:     size,
+		Width:    width,
+		Height:   height,
+		Alt:      alt,
+	}
+}
+
+// media with the ids of blogs referencing it
+type OutMedia struct {
+	Media
+	Blogs []int `json:"blogs"`
+}
+
+func NewOutMedia(media Media, blogs []int) *OutMedia {
+	return &OutMedia{
+		Media: media,
+		Blogs: blogs,
+	}
+}
+
+// Find all media hashes referenced in markdown content, without duplicates
+func ExtractMediaHashes(content string) []string {
+	matches := mediaLinkRegexp.FindAllStringSubmatch(content, -1)
+
+	record := map[string]bool{}
+	result := []string{}
+	for _, match := range matches {
+		hash := match[1]
+		if _, ok := record[hash]; ok {
+			continue
+		}
+		record[hash] = true
+		result = append(result, hash)
+	}
+	return result
+}
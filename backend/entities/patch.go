@@ -0,0 +1,49 @@
+package entities
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+)
+
+var (
+	ErrorPatchNotObject    = errors.New("merge patch should be a json object")
+	ErrorPatchUnknownField = errors.New("unknown field in merge patch")
+	ErrorPatchNullField    = errors.New("field can not be removed, only nullable fields accept null")
+)
+
+/*
+Decodes a JSON Merge Patch (RFC 7396) into fields, pointers to the pointer fields of a patch keyed by json name.
+Absent members leave their field nil. null sets nullable fields to their zero value and is rejected for others.
+*/
+func decodeMergePatch(data []byte, fields map[string]any, nullable ...string) error {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil || members == nil {
+		return ErrorPatchNotObject
+	}
+
+	for name, raw := range members {
+		field, ok := fields[name]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrorPatchUnknownField, name)
+		}
+
+		if string(raw) == "null" {
+			if !slices.Contains(nullable, name) {
+				return fmt.Errorf("%w: %s", ErrorPatchNullField, name)
+			}
+			// field is a **T, point it to a zero T
+			target := reflect.ValueOf(field).Elem()
+			target.Set(reflect.New(target.Type().Elem()))
+			continue
+		}
+
+		if err := json.Unmarshal(raw, field); err != nil {
+			return fmt.Errorf("decodeMergePatch: %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,27 @@
+package entities
+
+// xxx_at are all in ISO 8601.
+// Token is signed from the other fields when returned, it is never stored.
+type PreviewLink struct {
+	ID         int    `json:"id"`
+	Created_at string `json:"created_at"`
+	Updated_at string `json:"updated_at"`
+	ExpiresAt  string `json:"expiresAt"`
+	BlogID     int    `json:"blogID"`
+	Note       string `json:"note"` // who or what the link is for
+	Token      string `json:"token"`
+}
+
+func NewPreviewLink(blogID int, note, expiresAt string) *PreviewLink {
+	return &PreviewLink{
+		BlogID:    blogID,
+		Note:      note,
+		ExpiresAt: expiresAt,
+	}
+}
+
+// Leaving out expiresIn uses the configured default
+type InPreviewLink struct {
+	Note      string `json:"note"`
+	ExpiresIn int    `json:"expiresIn"` // hour
+}
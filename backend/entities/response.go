@@ -28,8 +28,16 @@ func NewRowsAffected(affectedRows int) *RowsAffected {
 }
 
 type MsgType interface {
-	RowsAffected | OutBlog | []OutBlog | []OutBlogSimple |
+	RowsAffected | []Blog | OutBlog | []OutBlog | []OutBlogSimple |
 		Tag | []Tag | Topic | []Topic |
+		Series | []Series | OutSeries |
+		Comment | OutComment | []OutComment |
+		[]BlogStatsSummary | BlogStats | []PopularBlog |
+		Webhook | []Webhook | WebhookDelivery | []WebhookDelivery |
+		PreviewLink | []PreviewLink | []BlogStatusEvent |
+		Media | []Media | []OutMedia |
+		[]AuditEntry |
+		OutBulk |
 		~string | JWT
 }
 
@@ -0,0 +1,76 @@
+package entities
+
+import "github.com/gosimple/slug"
+
+// xxx_at are all in ISO 8601.
+type Series struct {
+	ID          int    `json:"id"`
+	Created_at  string `json:"created_at"`
+	Updated_at  string `json:"updated_at"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Slug        string `json:"slug"`
+}
+
+func (s *Series) GenSlug() {
+	s.Slug = slug.Make(s.Name)
+}
+
+func NewSeries(name, description string) *Series {
+	series := &Series{
+		Name:        name,
+		Description: description,
+	}
+	series.GenSlug()
+	return series
+}
+
+func NewSeriesWithID(id int, name, description string) *Series {
+	series := &Series{
+		ID:          id,
+		Name:        name,
+		Description: description,
+	}
+	series.GenSlug()
+	return series
+}
+
+type InSeries struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description" yaml:"description"`
+}
+
+func NewInSeries(name, description string) InSeries {
+	return InSeries{
+		Name:        name,
+		Description: description,
+	}
+}
+
+// A blog in a series, without content
+type SeriesPart struct {
+	Part        int    `json:"part"` // position in series
+	ID          int    `json:"id"`   // blog id
+	Title       string `json:"title"`
+	Slug        string `json:"slug"`
+	Description string `json:"description"`
+}
+
+// Series with its ordered parts
+type OutSeries struct {
+	Series
+	Parts []SeriesPart `json:"parts"`
+}
+
+func NewOutSeries(series Series, parts []SeriesPart) *OutSeries {
+	return &OutSeries{
+		Series: series,
+		Parts:  parts,
+	}
+}
+
+// The series a blog belongs to, and its position in it
+type BlogSeries struct {
+	Series
+	Part int `json:"part"`
+}
@@ -0,0 +1,64 @@
+package entities
+
+// Sent by the frontend when a blog is viewed
+type InView struct {
+	// document.referrer, only the host is kept
+	Referrer string `json:"referrer"`
+}
+
+// Totals of a blog in a date range.
+// Visitors is the sum of daily unique visitors.
+type BlogStatsSummary struct {
+	BlogID   int    `json:"blogID"`
+	Title    string `json:"title"`
+	Views    int    `json:"views"`
+	Visitors int    `json:"visitors"`
+}
+
+// Day is YYYY-MM-DD in UTC
+type DailyStats struct {
+	Day      string `json:"day"`
+	Views    int    `json:"views"`
+	Visitors int    `json:"visitors"`
+}
+
+// Referrer is a host, empty for direct visits
+type ReferrerStats struct {
+	Referrer string `json:"referrer"`
+	Views    int    `json:"views"`
+}
+
+// Stats of a single blog in a date range, both ends included
+type BlogStats struct {
+	BlogStatsSummary
+	From      string          `json:"from"`
+	To        string          `json:"to"`
+	Days      []DailyStats    `json:"days"`
+	Referrers []ReferrerStats `json:"referrers"`
+}
+
+func NewBlogStats(blogID int, title, from, to string, days []DailyStats, referrers []ReferrerStats) *BlogStats {
+	stats := &BlogStats{
+		BlogStatsSummary: BlogStatsSummary{
+			BlogID: blogID,
+			Title:  title,
+		},
+		From:      from,
+		To:        to,
+		Days:      days,
+		Referrers: referrers,
+	}
+	for _, day := range days {
+		stats.Views += day.Views
+		stats.Visitors += day.Visitors
+	}
+	return stats
+}
+
+type PopularBlog struct {
+	ID          int    `json:"id"`
+	Title       string `json:"title"`
+	Slug        string `json:"slug"`
+	Description string `json:"description"`
+	Views       int    `json:"views"`
+}
@@ -4,12 +4,13 @@ import "github.com/gosimple/slug"
 
 // xxx_at are all in ISO 8601.
 type Tag struct {
-	ID          int    `json:"id"`
-	Created_at  string `json:"created_at"`
-	Updated_at  string `json:"updated_at"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	Slug        string `json:"slug"`
+	ID          int     `json:"id"`
+	Created_at  string  `json:"created_at"`
+	Updated_at  string  `json:"updated_at"`
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Slug        string  `json:"slug"`
+	Version     Version `json:"version"` // bumped on every update
 }
 
 func (t *Tag) GenSlug() {
@@ -46,3 +47,25 @@ func NewInTag(name, description string) *InTag {
 		Description: description,
 	}
 }
+
+// JSON Merge Patch of a tag, nil fields are left unchanged
+type TagPatch struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"` // null clears it
+	Slug        *string `json:"-"`
+}
+
+func (t *TagPatch) UnmarshalJSON(data []byte) error {
+	return decodeMergePatch(data, map[string]any{
+		"name":        &t.Name,
+		"description": &t.Description,
+	}, "description")
+}
+
+// Only regenerated when the name changes
+func (t *TagPatch) GenSlug() {
+	if t.Name != nil {
+		s := slug.Make(*t.Name)
+		t.Slug = &s
+	}
+}
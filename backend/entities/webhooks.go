@@ -0,0 +1,105 @@
+package entities
+
+import (
+	"encoding/json"
+	"slices"
+)
+
+// Event types sent to webhooks
+const (
+	EventBlogCreated  = "blog.created"
+	EventBlogUpdated  = "blog.updated"
+	EventBlogDeleted  = "blog.deleted" // soft deleted, can be restored
+	EventBlogRestored = "blog.restored"
+	EventBlogPurged   = "blog.purged" // permanently deleted
+	EventTagCreated   = "tag.created"
+	EventTagUpdated   = "tag.updated"
+	EventTagDeleted   = "tag.deleted"
+	EventTopicCreated = "topic.created"
+	EventTopicUpdated = "topic.updated"
+	EventTopicDeleted = "topic.deleted"
+
+	// subscribes to all events
+	EventAll = "*"
+)
+
+var EventTypes = []string{
+	EventBlogCreated,
+	EventBlogUpdated,
+	EventBlogDeleted,
+	EventBlogRestored,
+	EventBlogPurged,
+	EventTagCreated,
+	EventTagUpdated,
+	EventTagDeleted,
+	EventTopicCreated,
+	EventTopicUpdated,
+	EventTopicDeleted,
+}
+
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed" // gave up after max attempts
+)
+
+// xxx_at are all in ISO 8601.
+// Secret is only returned when the webhook is created.
+type Webhook struct {
+	ID         int      `json:"id"`
+	Created_at string   `json:"created_at"`
+	Updated_at string   `json:"updated_at"`
+	URL        string   `json:"url"`
+	Secret     string   `json:"secret,omitempty"`
+	Events     []string `json:"events"`
+	Paused     bool     `json:"paused"`
+}
+
+func NewWebhook(url, secret string, events []string, paused bool) *Webhook {
+	return &Webhook{
+		URL:    url,
+		Secret: secret,
+		Events: events,
+		Paused: paused,
+	}
+}
+
+func (w *Webhook) Subscribed(eventType string) bool {
+	return slices.Contains(w.Events, EventAll) || slices.Contains(w.Events, eventType)
+}
+
+// Leaving out secret generates one on create and keeps the old one on update.
+// Leaving out events subscribes to all events.
+type InWebhook struct {
+	URL    string   `json:"url"`
+	Secret string   `json:"secret"`
+	Events []string `json:"events"`
+	Paused bool     `json:"paused"`
+}
+
+// Body of webhook requests.
+// Data is the blog ( without content ), tag or topic after the change, deleted ones only have id.
+type WebhookEvent struct {
+	ID         int             `json:"id"`
+	Created_at string          `json:"created_at"`
+	Type       string          `json:"type"`
+	Data       json.RawMessage `json:"data" swaggertype:"object"`
+}
+
+type DeletedResource struct {
+	ID int `json:"id"`
+}
+
+// A delivery of an event to a webhook, retried until succeeded or failed
+type WebhookDelivery struct {
+	ID             int          `json:"id"`
+	Created_at     string       `json:"created_at"`
+	Updated_at     string       `json:"updated_at"`
+	WebhookID      int          `json:"webhookID"`
+	Status         string       `json:"status"`
+	Attempts       int          `json:"attempts"`
+	NextAttemptAt  string       `json:"nextAttemptAt"`
+	ResponseStatus int          `json:"responseStatus"` // 0 if no response was received
+	Error          string       `json:"error"`
+	Event          WebhookEvent `json:"event"`
+}
@@ -0,0 +1,95 @@
+package imaging
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp"
+)
+
+var ErrorUnsupportedFormat = errors.New("unsupported image format")
+
+// Formats that can be encoded, mapped to their content type
+var formats = map[string]string{
+	"jpeg": "image/jpeg",
+	"png":  "image/png",
+	"gif":  "image/gif",
+	"bmp":  "image/bmp",
+	"tiff": "image/tiff",
+}
+
+// Accepts common aliases, ex: jpg -> jpeg
+func NormalizeFormat(format string) (string, error) {
+	switch format {
+	case "jpg":
+		format = "jpeg"
+	case "tif":
+		format = "tiff"
+	}
+	if _, ok := formats[format]; !ok {
+		return "", fmt.Errorf("NormalizeFormat: %w: %q", ErrorUnsupportedFormat, format)
+	}
+	return format, nil
+}
+
+// Returns the encodable format for a content type, ex: image/png -> png
+func FormatFromMime(mime string) (string, bool) {
+	for format, contentType := range formats {
+		if contentType == mime {
+			return format, true
+		}
+	}
+	return "", false
+}
+
+func ContentType(format string) string {
+	return formats[format]
+}
+
+// Scale image down to the given width, keeping aspect ratio.
+// Images are never scaled up.
+func Resize(src image.Image, width int) image.Image {
+	bounds := src.Bounds()
+	if width <= 0 || width >= bounds.Dx() {
+		return src
+	}
+
+	height := bounds.Dy() * width / bounds.Dx()
+	if height < 1 {
+		height = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Over, nil)
+	return dst
+}
+
+func Encode(w io.Writer, img image.Image, format string) error {
+	var err error
+	switch format {
+	case "jpeg":
+		err = jpeg.Encode(w, img, &jpeg.Options{Quality: 85})
+	case "png":
+		err = png.Encode(w, img)
+	case "gif":
+		err = gif.Encode(w, img, nil)
+	case "bmp":
+		err = bmp.Encode(w, img)
+	case "tiff":
+		err = tiff.Encode(w, img, &tiff.Options{Compression: tiff.Deflate})
+	default:
+		return fmt.Errorf("Encode: %w: %q", ErrorUnsupportedFormat, format)
+	}
+	if err != nil {
+		return fmt.Errorf("Encode: encode %s failed: %w", format, err)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package jobs
+
+import (
+	"blog/config"
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Concrete implementations are at repository/<name>
+type auditRepository interface {
+	Purge(ctx context.Context, before time.Time) (int, error)
+}
+
+// Removes audit entries older than the retention period
+type AuditPurger struct {
+	repo   auditRepository
+	config config.AuditSetting
+}
+
+func NewAuditPurger(repo auditRepository, config config.AuditSetting) *AuditPurger {
+	return &AuditPurger{
+		repo:   repo,
+		config: config,
+	}
+}
+
+// Run purges every interval until ctx is done, does nothing if retention is 0
+func (p *AuditPurger) Run(ctx context.Context) {
+	if p.config.Retention <= 0 {
+		slog.Info("AuditPurger: disabled, retention is 0")
+		return
+	}
+
+	slog.Info("AuditPurger: started", "interval", p.config.Interval, "retention", p.config.Retention)
+	ticker := time.NewTicker(time.Duration(p.config.Interval) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		if err := p.RunOnce(ctx); err != nil {
+			slog.Error("AuditPurger: run failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			slog.Info("AuditPurger: stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce removes entries created before the retention period
+func (p *AuditPurger) RunOnce(ctx context.Context) error {
+	purged, err := p.repo.Purge(ctx, time.Now().AddDate(0, 0, -p.config.Retention))
+	if err != nil {
+		return fmt.Errorf("RunOnce: purge failed: %w", err)
+	}
+	if purged > 0 {
+		slog.Info("AuditPurger: purged audit entries", "count", purged)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package jobs_test
+
+import (
+	"blog/config"
+	"blog/jobs"
+	"context"
+	"testing"
+	"time"
+)
+
+type DummyAuditRepo struct {
+	before []time.Time
+}
+
+func (d *DummyAuditRepo) Purge(ctx context.Context, before time.Time) (int, error) {
+	d.before = append(d.before, before)
+	return 1, nil
+}
+
+func TestAuditPurger(t *testing.T) {
+	repo := &DummyAuditRepo{}
+	setting := config.NewConfig().Audit
+	setting.Retention = 30
+
+	if err := jobs.NewAuditPurger(repo, setting).RunOnce(context.Background()); err != nil {
+		t.Fatalf("TestAuditPurger: run once failed: %s", err)
+	}
+	if len(repo.before) != 1 {
+		t.Fatalf("TestAuditPurger: should purge once, got %d", len(repo.before))
+	}
+	if age := time.Since(repo.before[0]); age < 30*24*time.Hour-time.Minute || age > 30*24*time.Hour+time.Minute {
+		t.Fatalf("TestAuditPurger: should purge entries older than 30 days, got %s", age)
+	}
+
+	// disabled
+	setting.Retention = 0
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	jobs.NewAuditPurger(repo, setting).Run(ctx)
+	if len(repo.before) != 1 {
+		t.Fatalf("TestAuditPurger: should not purge when retention is 0, got %d", len(repo.before))
+	}
+}
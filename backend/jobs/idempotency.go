@@ -0,0 +1,59 @@
+package jobs
+
+import (
+	"blog/config"
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Concrete implementations are at repository/<name>
+type idempotencyRepository interface {
+	Purge(ctx context.Context, before time.Time) (int, error)
+}
+
+// Removes expired idempotency keys, expired keys are ignored anyway and only take space
+type IdempotencyPurger struct {
+	repo   idempotencyRepository
+	config config.IdempotencySetting
+}
+
+func NewIdempotencyPurger(repo idempotencyRepository, config config.IdempotencySetting) *IdempotencyPurger {
+	return &IdempotencyPurger{
+		repo:   repo,
+		config: config,
+	}
+}
+
+// Run purges every interval until ctx is done
+func (p *IdempotencyPurger) Run(ctx context.Context) {
+	slog.Info("IdempotencyPurger: started", "interval", p.config.Interval)
+	ticker := time.NewTicker(time.Duration(p.config.Interval) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		if err := p.RunOnce(ctx); err != nil {
+			slog.Error("IdempotencyPurger: run failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			slog.Info("IdempotencyPurger: stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce removes keys that are already expired
+func (p *IdempotencyPurger) RunOnce(ctx context.Context) error {
+	purged, err := p.repo.Purge(ctx, time.Now())
+	if err != nil {
+		return fmt.Errorf("RunOnce: purge failed: %w", err)
+	}
+	if purged > 0 {
+		slog.Info("IdempotencyPurger: purged idempotency keys", "count", purged)
+	}
+	return nil
+}
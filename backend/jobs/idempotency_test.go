@@ -0,0 +1,32 @@
+package jobs_test
+
+import (
+	"blog/config"
+	"blog/jobs"
+	"context"
+	"testing"
+	"time"
+)
+
+type DummyIdempotencyRepo struct {
+	before []time.Time
+}
+
+func (d *DummyIdempotencyRepo) Purge(ctx context.Context, before time.Time) (int, error) {
+	d.before = append(d.before, before)
+	return 1, nil
+}
+
+func TestIdempotencyPurger(t *testing.T) {
+	repo := &DummyIdempotencyRepo{}
+
+	if err := jobs.NewIdempotencyPurger(repo, config.NewConfig().Idempotency).RunOnce(context.Background()); err != nil {
+		t.Fatalf("TestIdempotencyPurger: run once failed: %s", err)
+	}
+	if len(repo.before) != 1 {
+		t.Fatalf("TestIdempotencyPurger: should purge once, got %d", len(repo.before))
+	}
+	if age := time.Since(repo.before[0]); age < 0 || age > time.Minute {
+		t.Fatalf("TestIdempotencyPurger: should purge keys expired by now, got %s", age)
+	}
+}
@@ -0,0 +1,59 @@
+package jobs
+
+import (
+	"blog/config"
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Concrete implementations are at repository/<name>
+type blogsRepository interface {
+	PublishDue(ctx context.Context, now time.Time) (int, error)
+}
+
+// Publishes scheduled blogs once their time is reached
+type BlogScheduler struct {
+	repo   blogsRepository
+	config config.SchedulerSetting
+}
+
+func NewBlogScheduler(repo blogsRepository, config config.SchedulerSetting) *BlogScheduler {
+	return &BlogScheduler{
+		repo:   repo,
+		config: config,
+	}
+}
+
+// Run publishes due blogs every interval until ctx is done
+func (s *BlogScheduler) Run(ctx context.Context) {
+	slog.Info("BlogScheduler: started", "interval", s.config.Interval)
+	ticker := time.NewTicker(time.Duration(s.config.Interval) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		if err := s.RunOnce(ctx); err != nil {
+			slog.Error("BlogScheduler: run failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			slog.Info("BlogScheduler: stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce publishes blogs scheduled before now
+func (s *BlogScheduler) RunOnce(ctx context.Context) error {
+	published, err := s.repo.PublishDue(ctx, time.Now())
+	if err != nil {
+		return fmt.Errorf("RunOnce: publish due failed: %w", err)
+	}
+	if published > 0 {
+		slog.Info("BlogScheduler: published scheduled blogs", "count", published)
+	}
+	return nil
+}
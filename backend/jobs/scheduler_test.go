@@ -0,0 +1,42 @@
+package jobs_test
+
+import (
+	"blog/config"
+	"blog/jobs"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type DummyBlogsRepo struct {
+	calls int
+	err   error
+}
+
+func (d *DummyBlogsRepo) PublishDue(ctx context.Context, now time.Time) (int, error) {
+	d.calls++
+	return 1, d.err
+}
+
+func TestBlogScheduler(t *testing.T) {
+	repo := &DummyBlogsRepo{}
+	scheduler := jobs.NewBlogScheduler(repo, config.NewConfig().Scheduler)
+
+	if err := scheduler.RunOnce(context.Background()); err != nil {
+		t.Fatalf("TestBlogScheduler: run once failed: %s", err)
+	}
+
+	repo.err = errors.New("db closed")
+	if err := scheduler.RunOnce(context.Background()); !errors.Is(err, repo.err) {
+		t.Fatalf("TestBlogScheduler: should return the repo error, got %v", err)
+	}
+
+	// stops when ctx is done
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	scheduler.Run(ctx)
+	if repo.calls != 3 {
+		t.Fatalf("TestBlogScheduler: should publish once before stopping, got %d calls", repo.calls)
+	}
+}
@@ -0,0 +1,65 @@
+package jobs
+
+import (
+	"blog/config"
+	"blog/entities"
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Concrete implementations are at repository/<name>
+type trashRepository interface {
+	PurgeDeleted(ctx context.Context, before time.Time, dryRun bool) ([]entities.Blog, error)
+}
+
+// Permanently deletes blogs that stayed in the trash longer than the retention period
+type TrashPurger struct {
+	repo   trashRepository
+	config config.TrashSetting
+}
+
+func NewTrashPurger(repo trashRepository, config config.TrashSetting) *TrashPurger {
+	return &TrashPurger{
+		repo:   repo,
+		config: config,
+	}
+}
+
+// Run purges the trash every interval until ctx is done, does nothing if retention is 0
+func (p *TrashPurger) Run(ctx context.Context) {
+	if p.config.Retention <= 0 {
+		slog.Info("TrashPurger: disabled, retention is 0")
+		return
+	}
+
+	slog.Info("TrashPurger: started", "interval", p.config.Interval, "retention", p.config.Retention)
+	ticker := time.NewTicker(time.Duration(p.config.Interval) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		if err := p.RunOnce(ctx); err != nil {
+			slog.Error("TrashPurger: run failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			slog.Info("TrashPurger: stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce purges blogs deleted before the retention period
+func (p *TrashPurger) RunOnce(ctx context.Context) error {
+	purged, err := p.repo.PurgeDeleted(ctx, time.Now().AddDate(0, 0, -p.config.Retention), false)
+	if err != nil {
+		return fmt.Errorf("RunOnce: purge deleted failed: %w", err)
+	}
+	for _, blog := range purged {
+		slog.Info("TrashPurger: purged blog", "id", blog.ID, "title", blog.Title, "deleted_at", blog.Deleted_at)
+	}
+	return nil
+}
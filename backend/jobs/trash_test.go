@@ -0,0 +1,44 @@
+package jobs_test
+
+import (
+	"blog/config"
+	"blog/entities"
+	"blog/jobs"
+	"context"
+	"testing"
+	"time"
+)
+
+type DummyTrashRepo struct {
+	before []time.Time
+}
+
+func (d *DummyTrashRepo) PurgeDeleted(ctx context.Context, before time.Time, dryRun bool) ([]entities.Blog, error) {
+	d.before = append(d.before, before)
+	return []entities.Blog{{ID: 1}}, nil
+}
+
+func TestTrashPurger(t *testing.T) {
+	repo := &DummyTrashRepo{}
+	setting := config.NewConfig().Trash
+	setting.Retention = 7
+
+	if err := jobs.NewTrashPurger(repo, setting).RunOnce(context.Background()); err != nil {
+		t.Fatalf("TestTrashPurger: run once failed: %s", err)
+	}
+	if len(repo.before) != 1 {
+		t.Fatalf("TestTrashPurger: should purge once, got %d", len(repo.before))
+	}
+	if age := time.Since(repo.before[0]); age < 7*24*time.Hour-time.Minute || age > 7*24*time.Hour+time.Minute {
+		t.Fatalf("TestTrashPurger: should purge blogs deleted 7 days ago, got %s", age)
+	}
+
+	// disabled
+	setting.Retention = 0
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	jobs.NewTrashPurger(repo, setting).Run(ctx)
+	if len(repo.before) != 1 {
+		t.Fatalf("TestTrashPurger: should not purge when retention is 0, got %d", len(repo.before))
+	}
+}
@@ -0,0 +1,210 @@
+package jobs
+
+import (
+	"blog/config"
+	"blog/entities"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// events and deliveries handled per round
+	webhookBatchSize = 100
+	// characters of the error kept in the delivery log
+	maxDeliveryErrorLength = 500
+)
+
+// Concrete implementations are at repository/<name>
+type webhooksRepository interface {
+	Get(ctx context.Context, id int) (*entities.Webhook, error)
+	FanOut(ctx context.Context, limit int) (int, error)
+	ListDueDeliveries(ctx context.Context, now time.Time, limit int) ([]entities.WebhookDelivery, error)
+	SaveAttempt(ctx context.Context, delivery entities.WebhookDelivery) error
+	Purge(ctx context.Context, before time.Time) (int, error)
+}
+
+// Delivers events in the outbox to webhooks.
+// Deliveries are at least once, receivers should use X-Webhook-Delivery to drop duplicates.
+type WebhookDispatcher struct {
+	repo   webhooksRepository
+	client *http.Client
+	config config.WebhooksSetting
+}
+
+func NewWebhookDispatcher(repo webhooksRepository, config config.WebhooksSetting) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		repo: repo,
+		client: &http.Client{
+			Timeout: time.Duration(config.Timeout) * time.Second,
+		},
+		config: config,
+	}
+}
+
+// Run dispatches every interval until ctx is done
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	slog.Info("WebhookDispatcher: started", "interval", d.config.Interval)
+	ticker := time.NewTicker(time.Duration(d.config.Interval) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		if err := d.RunOnce(ctx); err != nil {
+			slog.Error("WebhookDispatcher: run failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			slog.Info("WebhookDispatcher: stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce purges old deliveries, fans out new events and sends due deliveries
+func (d *WebhookDispatcher) RunOnce(ctx context.Context) error {
+	now := time.Now()
+
+	if _, err := d.repo.Purge(ctx, now.AddDate(0, 0, -d.config.Retention)); err != nil {
+		return fmt.Errorf("RunOnce: purge failed: %w", err)
+	}
+
+	for {
+		dispatched, err := d.repo.FanOut(ctx, webhookBatchSize)
+		if err != nil {
+			return fmt.Errorf("RunOnce: fan out failed: %w", err)
+		}
+		if dispatched < webhookBatchSize {
+			break
+		}
+	}
+
+	deliveries, err := d.repo.ListDueDeliveries(ctx, now, webhookBatchSize)
+	if err != nil {
+		return fmt.Errorf("RunOnce: list due deliveries failed: %w", err)
+	}
+
+	webhooks := map[int]*entities.Webhook{}
+	for _, delivery := range deliveries {
+		webhook, ok := webhooks[delivery.WebhookID]
+		if !ok {
+			webhook, err = d.repo.Get(ctx, delivery.WebhookID)
+			if err != nil {
+				return fmt.Errorf("RunOnce: get webhook failed: %w", err)
+			}
+			webhooks[delivery.WebhookID] = webhook
+		}
+
+		attempted := d.attempt(ctx, *webhook, delivery)
+
+		// shutting down, the delivery stays pending and is sent again on next start
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		if err := d.repo.SaveAttempt(ctx, attempted); err != nil {
+			return fmt.Errorf("RunOnce: save attempt failed: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Sends the delivery once and returns it with the result of the attempt
+func (d *WebhookDispatcher) attempt(ctx context.Context, webhook entities.Webhook, delivery entities.WebhookDelivery) entities.WebhookDelivery {
+	delivery.Attempts++
+
+	status, err := d.send(ctx, webhook, delivery)
+	delivery.ResponseStatus = status
+	if err == nil {
+		delivery.Status = entities.DeliverySucceeded
+		delivery.Error = ""
+		return delivery
+	}
+
+	slog.Warn("WebhookDispatcher: delivery failed", "delivery", delivery.ID, "attempts", delivery.Attempts, "error", err)
+	delivery.Error = err.Error()
+	if len(delivery.Error) > maxDeliveryErrorLength {
+		delivery.Error = delivery.Error[:maxDeliveryErrorLength]
+	}
+
+	if delivery.Attempts >= d.config.MaxAttempts {
+		delivery.Status = entities.DeliveryFailed
+		return delivery
+	}
+
+	delivery.NextAttemptAt = time.Now().
+		Add(Backoff(time.Duration(d.config.Backoff)*time.Second, delivery.Attempts)).
+		UTC().
+		Format("2006-01-02T15:04:05-07:00")
+	return delivery
+}
+
+// Returns the response status code, 0 if no response was received
+func (d *WebhookDispatcher) send(ctx context.Context, webhook entities.Webhook, delivery entities.WebhookDelivery) (int, error) {
+	body, err := json.Marshal(delivery.Event)
+	if err != nil {
+		return 0, fmt.Errorf("send: marshal event failed: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("send: new request failed: %w", err)
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "blog-webhooks")
+	req.Header.Set("X-Webhook-Event", delivery.Event.Type)
+	req.Header.Set("X-Webhook-Delivery", strconv.Itoa(delivery.ID))
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", Sign(webhook.Secret, timestamp, body))
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("send: request failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	// drain so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("send: unexpected status %d", res.StatusCode)
+	}
+
+	return res.StatusCode, nil
+}
+
+// Sign returns the X-Webhook-Signature header value,
+// hex encoded HMAC-SHA256 of "<timestamp>.<body>" keyed with the webhook secret.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Backoff returns the delay after the given number of failed attempts,
+// base for the first retry and doubled on every following one.
+func Backoff(base time.Duration, attempts int) time.Duration {
+	if attempts < 1 {
+		return base
+	}
+	// keep the shift from overflowing
+	if attempts > 20 {
+		attempts = 20
+	}
+	return base << (attempts - 1)
+}
@@ -0,0 +1,117 @@
+package jobs_test
+
+import (
+	"blog/config"
+	"blog/entities"
+	"blog/jobs"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type DummyWebhooksRepo struct {
+	webhook    entities.Webhook
+	deliveries []entities.WebhookDelivery
+	saved      []entities.WebhookDelivery
+	fannedOut  bool
+}
+
+func (d *DummyWebhooksRepo) Get(ctx context.Context, id int) (*entities.Webhook, error) {
+	return &d.webhook, nil
+}
+func (d *DummyWebhooksRepo) FanOut(ctx context.Context, limit int) (int, error) {
+	d.fannedOut = true
+	return 0, nil
+}
+func (d *DummyWebhooksRepo) ListDueDeliveries(ctx context.Context, now time.Time, limit int) ([]entities.WebhookDelivery, error) {
+	return d.deliveries, nil
+}
+func (d *DummyWebhooksRepo) SaveAttempt(ctx context.Context, delivery entities.WebhookDelivery) error {
+	d.saved = append(d.saved, delivery)
+	return nil
+}
+func (d *DummyWebhooksRepo) Purge(ctx context.Context, before time.Time) (int, error) {
+	return 0, nil
+}
+
+func TestWebhookDispatcher(t *testing.T) {
+	event := entities.WebhookEvent{ID: 1, Type: entities.EventBlogCreated, Data: []byte(`{"id":1}`)}
+
+	fail := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp := r.Header.Get("X-Webhook-Timestamp")
+		if r.Header.Get("X-Webhook-Signature") != jobs.Sign("secret", timestamp, body) {
+			t.Errorf("TestWebhookDispatcher: signature mismatch")
+		}
+		if r.Header.Get("X-Webhook-Event") != entities.EventBlogCreated || r.Header.Get("X-Webhook-Delivery") != "7" {
+			t.Errorf("TestWebhookDispatcher: unexpected headers %v", r.Header)
+		}
+		if fail {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	setting := config.NewConfig().Webhooks
+	setting.MaxAttempts = 2
+	repo := &DummyWebhooksRepo{
+		webhook:    entities.Webhook{ID: 1, URL: server.URL, Secret: "secret"},
+		deliveries: []entities.WebhookDelivery{{ID: 7, WebhookID: 1, Status: entities.DeliveryPending, Event: event}},
+	}
+	dispatcher := jobs.NewWebhookDispatcher(repo, setting)
+
+	// succeeded
+	if err := dispatcher.RunOnce(context.Background()); err != nil {
+		t.Fatalf("TestWebhookDispatcher: run failed: %s", err)
+	}
+	if !repo.fannedOut || len(repo.saved) != 1 {
+		t.Fatalf("TestWebhookDispatcher: should fan out and save the attempt")
+	}
+	if saved := repo.saved[0]; saved.Status != entities.DeliverySucceeded || saved.Attempts != 1 || saved.ResponseStatus != http.StatusOK {
+		t.Fatalf("TestWebhookDispatcher: delivery should succeed, got %+v", saved)
+	}
+
+	// failed, retried later
+	fail = true
+	repo.saved = nil
+	if err := dispatcher.RunOnce(context.Background()); err != nil {
+		t.Fatalf("TestWebhookDispatcher: run failed: %s", err)
+	}
+	retry := repo.saved[0]
+	if retry.Status != entities.DeliveryPending || retry.ResponseStatus != http.StatusInternalServerError || retry.Error == "" {
+		t.Fatalf("TestWebhookDispatcher: delivery should be retried, got %+v", retry)
+	}
+	nextAttempt, err := time.Parse(time.RFC3339, retry.NextAttemptAt)
+	if err != nil || nextAttempt.Before(time.Now().Add(time.Duration(setting.Backoff-1)*time.Second)) {
+		t.Fatalf("TestWebhookDispatcher: next attempt should be backed off, got %s", retry.NextAttemptAt)
+	}
+
+	// gave up after max attempts
+	repo.deliveries = []entities.WebhookDelivery{retry}
+	repo.saved = nil
+	if err := dispatcher.RunOnce(context.Background()); err != nil {
+		t.Fatalf("TestWebhookDispatcher: run failed: %s", err)
+	}
+	if saved := repo.saved[0]; saved.Status != entities.DeliveryFailed || saved.Attempts != 2 {
+		t.Fatalf("TestWebhookDispatcher: delivery should fail after max attempts, got %+v", saved)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	base := 30 * time.Second
+	expected := map[int]time.Duration{
+		1:   30 * time.Second,
+		2:   time.Minute,
+		4:   4 * time.Minute,
+		100: base << 19,
+	}
+	for attempts, delay := range expected {
+		if got := jobs.Backoff(base, attempts); got != delay {
+			t.Fatalf("TestBackoff: attempts %d should wait %s, got %s", attempts, delay, got)
+		}
+	}
+}
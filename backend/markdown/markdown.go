@@ -0,0 +1,152 @@
+package markdown
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	highlighting "github.com/yuin/goldmark-highlighting/v2"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// Links to uploaded media without query params, ex: /api/v1/media/<sha256>
+var mediaLinkRegexp = regexp.MustCompile(`/media/[a-f0-9]{64}$`)
+
+// Parser context key of the widths of uploaded media, hash -> width in px
+var mediaWidthsKey = parser.NewContextKey()
+
+// Passes the widths of uploaded media to Convert, srcset widths larger than the image are left out.
+// Media missing from widths gets all srcset widths.
+func WithMediaWidths(widths map[string]int) parser.ParseOption {
+	pc := parser.NewContext()
+	pc.Set(mediaWidthsKey, widths)
+	return parser.WithContext(pc)
+}
+
+// Markdown to html with syntax highlighting,
+// images pointing to uploaded media will have srcset with the given widths.
+func New(srcsetWidths []int) goldmark.Markdown {
+	return goldmark.New(
+		goldmark.WithRendererOptions(
+			html.WithUnsafe(),
+		),
+		goldmark.WithExtensions(
+			highlighting.NewHighlighting(
+				highlighting.WithStyle("gruvbox"),
+			),
+			NewMediaSrcset(srcsetWidths),
+		),
+		goldmark.WithParserOptions(
+			parser.WithAutoHeadingID(),
+		),
+	)
+}
+
+// Markdown to html for untrusted input such as reader comments.
+// Raw html is omitted and dangerous links are dropped by goldmark itself,
+// links get rel="nofollow ugc" so that spam doesn't gain anything.
+func NewSafe() goldmark.Markdown {
+	return goldmark.New(
+		goldmark.WithRendererOptions(
+			html.WithHardWraps(),
+		),
+		goldmark.WithExtensions(
+			&NofollowLinks{},
+		),
+	)
+}
+
+// Extension adding rel="nofollow ugc" to all links
+type NofollowLinks struct{}
+
+func (n *NofollowLinks) Extend(md goldmark.Markdown) {
+	md.Parser().AddOptions(
+		parser.WithASTTransformers(
+			util.Prioritized(n, 999),
+		),
+	)
+}
+
+func (n *NofollowLinks) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	ast.Walk(doc, func(node ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch node.(type) {
+		case *ast.Link, *ast.AutoLink:
+			node.SetAttributeString("rel", []byte("nofollow ugc"))
+		}
+		return ast.WalkContinue, nil
+	})
+}
+
+// Extension adding srcset, sizes and lazy loading to media images.
+// Variants are served by GET /media/{hash}?w=<width>
+type MediaSrcset struct {
+	widths []int
+}
+
+func NewMediaSrcset(widths []int) *MediaSrcset {
+	sorted := slices.Clone(widths)
+	slices.Sort(sorted)
+	return &MediaSrcset{
+		widths: slices.Compact(sorted),
+	}
+}
+
+func (m *MediaSrcset) Extend(md goldmark.Markdown) {
+	md.Parser().AddOptions(
+		parser.WithASTTransformers(
+			util.Prioritized(m, 999),
+		),
+	)
+}
+
+func (m *MediaSrcset) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	if len(m.widths) == 0 {
+		return
+	}
+	mediaWidths, _ := pc.Get(mediaWidthsKey).(map[string]int)
+
+	ast.Walk(doc, func(node ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		image, ok := node.(*ast.Image)
+		if !ok {
+			return ast.WalkContinue, nil
+		}
+
+		dest := string(image.Destination)
+		if !mediaLinkRegexp.MatchString(dest) {
+			return ast.WalkSkipChildren, nil
+		}
+
+		// variants wider than the image are served at its own width, the image itself stands in for them
+		imageWidth, known := mediaWidths[dest[len(dest)-64:]]
+		candidates := make([]string, 0, len(m.widths)+1)
+		maxWidth := 0
+		for _, width := range m.widths {
+			if known && width > imageWidth {
+				break
+			}
+			candidates = append(candidates, fmt.Sprintf("%s?w=%d %dw", dest, width, width))
+			maxWidth = width
+		}
+		if known && maxWidth < imageWidth && maxWidth < m.widths[len(m.widths)-1] {
+			candidates = append(candidates, fmt.Sprintf("%s %dw", dest, imageWidth))
+			maxWidth = imageWidth
+		}
+
+		image.SetAttributeString("srcset", []byte(strings.Join(candidates, ", ")))
+		image.SetAttributeString("sizes", []byte(fmt.Sprintf("(max-width: %dpx) 100vw, %dpx", maxWidth, maxWidth)))
+		image.SetAttributeString("loading", []byte("lazy"))
+		return ast.WalkSkipChildren, nil
+	})
+}
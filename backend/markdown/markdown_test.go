@@ -0,0 +1,69 @@
+package markdown_test
+
+import (
+	"blog/markdown"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestMediaSrcset(t *testing.T) {
+	hash := strings.Repeat("a", 64)
+	source := "![cat](/api/v1/media/" + hash + ")\n\n![dog](https://example.com/dog.png)"
+
+	var buf bytes.Buffer
+	if err := markdown.New([]int{800, 400}).Convert([]byte(source), &buf); err != nil {
+		t.Fatalf("TestMediaSrcset: convert failed: %s", err)
+	}
+	out := buf.String()
+
+	expected := `srcset="/api/v1/media/` + hash + `?w=400 400w, /api/v1/media/` + hash + `?w=800 800w"`
+	if !strings.Contains(out, expected) {
+		t.Fatalf("TestMediaSrcset: missing srcset, got: %s", out)
+	}
+	if !strings.Contains(out, `sizes="(max-width: 800px) 100vw, 800px"`) {
+		t.Fatalf("TestMediaSrcset: missing sizes, got: %s", out)
+	}
+	if strings.Count(out, "srcset=") != 1 {
+		t.Fatalf("TestMediaSrcset: external images should not have srcset, got: %s", out)
+	}
+
+	// widths larger than the stored image are left out, the image itself is the largest candidate
+	small := strings.Repeat("b", 64)
+	source = "![cat](/api/v1/media/" + hash + ")\n\n![mouse](/api/v1/media/" + small + ")"
+	buf.Reset()
+	widths := map[string]int{hash: 600, small: 200}
+	if err := markdown.New([]int{800, 400}).Convert([]byte(source), &buf, markdown.WithMediaWidths(widths)); err != nil {
+		t.Fatalf("TestMediaSrcset: convert with widths failed: %s", err)
+	}
+	out = buf.String()
+
+	expected = `srcset="/api/v1/media/` + hash + `?w=400 400w, /api/v1/media/` + hash + ` 600w" sizes="(max-width: 600px) 100vw, 600px"`
+	if !strings.Contains(out, expected) {
+		t.Fatalf("TestMediaSrcset: srcset should stop at 600w, got: %s", out)
+	}
+	expected = `srcset="/api/v1/media/` + small + ` 200w" sizes="(max-width: 200px) 100vw, 200px"`
+	if !strings.Contains(out, expected) {
+		t.Fatalf("TestMediaSrcset: image narrower than all widths should only list itself, got: %s", out)
+	}
+}
+
+func TestSafe(t *testing.T) {
+	source := "hi <script>alert(1)</script>\n\n<img src=x onerror=alert(1)>\n\n[click](javascript:alert(1)) [ok](https://example.com)"
+
+	var buf bytes.Buffer
+	if err := markdown.NewSafe().Convert([]byte(source), &buf); err != nil {
+		t.Fatalf("TestSafe: convert failed: %s", err)
+	}
+	out := buf.String()
+
+	if strings.Contains(out, "<script") || strings.Contains(out, "<img") {
+		t.Fatalf("TestSafe: raw html should be omitted, got: %s", out)
+	}
+	if strings.Contains(out, "javascript:") {
+		t.Fatalf("TestSafe: dangerous link should be dropped, got: %s", out)
+	}
+	if !strings.Contains(out, `<a href="https://example.com" rel="nofollow ugc">ok</a>`) {
+		t.Fatalf("TestSafe: link should have rel nofollow, got: %s", out)
+	}
+}
@@ -0,0 +1,158 @@
+package pubsub
+
+import (
+	"encoding/json"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ID goes up by one for every published event
+type Event struct {
+	ID   uint64
+	Type string
+	Data []byte // json
+}
+
+// In memory pub/sub keeping the latest events in a ring buffer for resuming.
+//
+// Publishing never blocks, subscribers that can't keep up are dropped
+// and are expected to reconnect with the last event id they received.
+type Broker struct {
+	mu               sync.Mutex
+	ring             []Event
+	head             int // index of the oldest event
+	size             int
+	nextID           uint64
+	subscriberBuffer int
+	subscribers      map[*Subscription]struct{}
+	closed           bool
+}
+
+// bufferSize events are kept for resuming, each subscriber can fall behind by subscriberBuffer events
+func NewBroker(bufferSize, subscriberBuffer int) *Broker {
+	return &Broker{
+		ring: make([]Event, max(bufferSize, 1)),
+		// ids of a restarted server are larger than the old ones (unless it published over 1000 events per second),
+		// so that old ids are told apart instead of matching new events
+		nextID:           uint64(time.Now().UnixMilli()),
+		subscriberBuffer: subscriberBuffer,
+		subscribers:      map[*Subscription]struct{}{},
+	}
+}
+
+type Subscription struct {
+	broker *Broker
+	c      chan Event
+}
+
+// Closed when the subscriber is dropped or the broker is closed
+func (s *Subscription) Events() <-chan Event {
+	return s.c
+}
+
+func (s *Subscription) Close() {
+	s.broker.unsubscribe(s)
+}
+
+// Publish is safe to call on a nil broker
+func (b *Broker) Publish(eventType string, data any) {
+	if b == nil {
+		return
+	}
+
+	rawData, err := json.Marshal(data)
+	if err != nil {
+		slog.Error("Publish: marshal event data failed", "type", eventType, "error", err)
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+
+	event := Event{ID: b.nextID, Type: eventType, Data: rawData}
+	b.nextID++
+
+	if b.size < len(b.ring) {
+		b.ring[(b.head+b.size)%len(b.ring)] = event
+		b.size++
+	} else {
+		b.ring[b.head] = event
+		b.head = (b.head + 1) % len(b.ring)
+	}
+
+	for sub := range b.subscribers {
+		select {
+		case sub.c <- event:
+		default:
+			slog.Warn("Publish: subscriber too slow, dropped")
+			delete(b.subscribers, sub)
+			close(sub.c)
+		}
+	}
+}
+
+// Subscribe to events after lastEventID, empty for new events only.
+// Returns the missed events, and false if they are no longer buffered or lastEventID is unknown.
+func (b *Broker) Subscribe(lastEventID string) (*Subscription, []Event, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub := &Subscription{broker: b, c: make(chan Event, b.subscriberBuffer)}
+	if b.closed {
+		close(sub.c)
+		return sub, nil, true
+	}
+	b.subscribers[sub] = struct{}{}
+
+	if lastEventID == "" {
+		return sub, nil, true
+	}
+
+	lastID, err := strconv.ParseUint(lastEventID, 10, 64)
+	if err != nil || lastID >= b.nextID {
+		return sub, nil, false
+	}
+	if lastID == b.nextID-1 {
+		return sub, nil, true
+	}
+
+	if b.size == 0 || lastID+1 < b.ring[b.head].ID {
+		return sub, nil, false
+	}
+
+	missed := []Event{}
+	for i := 0; i < b.size; i++ {
+		event := b.ring[(b.head+i)%len(b.ring)]
+		if event.ID > lastID {
+			missed = append(missed, event)
+		}
+	}
+	return sub, missed, true
+}
+
+// Close drops all subscribers, later subscriptions are closed right away
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for sub := range b.subscribers {
+		delete(b.subscribers, sub)
+		close(sub.c)
+	}
+}
+
+func (b *Broker) unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.subscribers[sub]; ok {
+		delete(b.subscribers, sub)
+		close(sub.c)
+	}
+}
@@ -0,0 +1,100 @@
+package pubsub_test
+
+import (
+	"blog/pubsub"
+	"strconv"
+	"testing"
+)
+
+func TestBrokerPublish(t *testing.T) {
+	broker := pubsub.NewBroker(10, 10)
+
+	sub, missed, ok := broker.Subscribe("")
+	if !ok || len(missed) != 0 {
+		t.Fatalf("TestBrokerPublish: new subscription should not replay")
+	}
+	defer sub.Close()
+
+	broker.Publish("blog.created", map[string]int{"id": 1})
+	event := <-sub.Events()
+	if event.Type != "blog.created" || string(event.Data) != `{"id":1}` {
+		t.Fatalf("TestBrokerPublish: unexpected event %+v", event)
+	}
+
+	broker.Publish("blog.updated", map[string]int{"id": 1})
+	next := <-sub.Events()
+	if next.ID != event.ID+1 {
+		t.Fatalf("TestBrokerPublish: ids should go up by one, got %d after %d", next.ID, event.ID)
+	}
+
+	// nil brokers are ignored
+	var nilBroker *pubsub.Broker
+	nilBroker.Publish("blog.created", nil)
+}
+
+func TestBrokerResume(t *testing.T) {
+	broker := pubsub.NewBroker(3, 10)
+
+	sub, _, _ := broker.Subscribe("")
+	for i := 0; i < 5; i++ {
+		broker.Publish("tag.created", i)
+	}
+	ids := []uint64{}
+	for i := 0; i < 5; i++ {
+		ids = append(ids, (<-sub.Events()).ID)
+	}
+	sub.Close()
+
+	// last 3 events are buffered
+	resumed, missed, ok := broker.Subscribe(strconv.FormatUint(ids[2], 10))
+	if !ok || len(missed) != 2 || missed[0].ID != ids[3] || missed[1].ID != ids[4] {
+		t.Fatalf("TestBrokerResume: should replay events after %d, got %+v", ids[2], missed)
+	}
+	resumed.Close()
+
+	upToDate, missed, ok := broker.Subscribe(strconv.FormatUint(ids[4], 10))
+	if !ok || len(missed) != 0 {
+		t.Fatalf("TestBrokerResume: up to date subscriber should not replay, got %+v", missed)
+	}
+	upToDate.Close()
+
+	for _, lastEventID := range []string{strconv.FormatUint(ids[0], 10), "abc", strconv.FormatUint(ids[4]+10, 10)} {
+		tooOld, _, ok := broker.Subscribe(lastEventID)
+		if ok {
+			t.Fatalf("TestBrokerResume: resume from %s should not be possible", lastEventID)
+		}
+		tooOld.Close()
+	}
+}
+
+func TestBrokerSlowSubscriber(t *testing.T) {
+	broker := pubsub.NewBroker(10, 1)
+
+	slow, _, _ := broker.Subscribe("")
+	// does not block on the full subscriber
+	broker.Publish("topic.updated", 1)
+	broker.Publish("topic.updated", 2)
+
+	<-slow.Events()
+	if _, open := <-slow.Events(); open {
+		t.Fatalf("TestBrokerSlowSubscriber: slow subscriber should be dropped")
+	}
+	// closing a dropped subscription is fine
+	slow.Close()
+}
+
+func TestBrokerClose(t *testing.T) {
+	broker := pubsub.NewBroker(10, 10)
+
+	sub, _, _ := broker.Subscribe("")
+	broker.Close()
+	if _, open := <-sub.Events(); open {
+		t.Fatalf("TestBrokerClose: subscription should be closed")
+	}
+
+	late, _, _ := broker.Subscribe("")
+	if _, open := <-late.Events(); open {
+		t.Fatalf("TestBrokerClose: subscriptions after close should be closed")
+	}
+	broker.Publish("blog.created", 1)
+}
@@ -0,0 +1,97 @@
+package repositories
+
+import (
+	"blog/config"
+	"blog/db/models/interfaces"
+	"blog/entities"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type AuditRepoModels struct {
+	audit interfaces.AuditModel
+}
+
+func NewAuditRepoModels(audit interfaces.AuditModel) *AuditRepoModels {
+	return &AuditRepoModels{
+		audit: audit,
+	}
+}
+
+type Audit struct {
+	db     *sql.DB
+	config config.DBSetting
+	models AuditRepoModels
+}
+
+func NewAudit(db *sql.DB, config config.DBSetting, models AuditRepoModels) *Audit {
+	return &Audit{
+		db:     db,
+		config: config,
+		models: models,
+	}
+}
+
+func (a *Audit) Create(ctx context.Context, entry entities.AuditEntry) (*entities.AuditEntry, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(a.config.Timeout)*time.Second)
+	defer cancel()
+
+	tx, err := a.db.BeginTx(ctxTimeout, &sql.TxOptions{})
+	if err != nil {
+		return &entities.AuditEntry{}, fmt.Errorf("Create: begin transaction failed: %w", err)
+	}
+
+	newEntry, err := a.models.audit.Create(ctxTimeout, tx, entry)
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			return &entities.AuditEntry{}, fmt.Errorf("Create: model create audit entry rollback failed: %w", err)
+		}
+		return &entities.AuditEntry{}, fmt.Errorf("Create: model create audit entry failed: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return &entities.AuditEntry{}, fmt.Errorf("Create: commit failed: %w", err)
+	}
+
+	return newEntry, nil
+}
+
+// Newest first
+func (a *Audit) List(ctx context.Context, filter entities.AuditFilter) ([]entities.AuditEntry, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(a.config.Timeout)*time.Second)
+	defer cancel()
+
+	entries, err := a.models.audit.List(ctxTimeout, a.db, filter)
+	if err != nil {
+		return []entities.AuditEntry{}, fmt.Errorf("List: model list audit entries failed: %w", err)
+	}
+
+	return entries, nil
+}
+
+// Removes entries created before the given time, returns the number removed
+func (a *Audit) Purge(ctx context.Context, before time.Time) (int, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(a.config.Timeout)*time.Second)
+	defer cancel()
+
+	tx, err := a.db.BeginTx(ctxTimeout, &sql.TxOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("Purge: begin transaction failed: %w", err)
+	}
+
+	affectedRows, err := a.models.audit.DeleteBefore(ctxTimeout, tx, before.UTC().Format("2006-01-02T15:04:05-07:00"))
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			return 0, fmt.Errorf("Purge: model delete audit entries rollback failed: %w", err)
+		}
+		return 0, fmt.Errorf("Purge: model delete audit entries failed: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("Purge: commit failed: %w", err)
+	}
+
+	return affectedRows, nil
+}
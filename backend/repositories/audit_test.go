@@ -0,0 +1,100 @@
+package repositories_test
+
+import (
+	"blog/config"
+	"blog/db"
+	"blog/db/models/sqlite"
+	"blog/entities"
+	"blog/repositories"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestAuditSqlite(t *testing.T) {
+	// connect
+	dbConn, err := sql.Open("sqlite3", "file:test.db?mode=memory&_foreign_keys=on")
+	if err != nil {
+		t.Fatalf("TestAuditSqlite: open db connection failed: %s", err)
+	}
+	defer dbConn.Close()
+
+	// migrate db
+	if err := db.Up(dbConn, db.EmbedMigrationsSQLite, "sqlite3", "migrations/sqlite"); err != nil {
+		t.Fatalf("TestAuditSqlite: migrate up failed: %s", err)
+	}
+
+	auditRepo := repositories.NewAudit(dbConn, config.NewConfig().DB, *repositories.NewAuditRepoModels(sqlite.NewAudit()))
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	entries := []*entities.AuditEntry{
+		entities.NewAuditEntry("alex", "POST /api/v1/blogs", "blog", "1", json.RawMessage(`{"title":[null,"a"]}`), "10.0.0.1", "r1"),
+		entities.NewAuditEntry("alex", "PUT /api/v1/blogs/1", "blog", "1", json.RawMessage(`{"title":["a","b"]}`), "10.0.0.1", "r2"),
+		entities.NewAuditEntry("alex", "POST /api/v1/tags", "tag", "1", json.RawMessage(`{"name":[null,"go"]}`), "10.0.0.1", "r3"),
+		entities.NewAuditEntry("bob", "DELETE /api/v1/blogs/2", "blog", "2", json.RawMessage(`{}`), "10.0.0.2", "r4"),
+	}
+	for _, entry := range entries {
+		created, err := auditRepo.Create(ctxTimeout, *entry)
+		if err != nil {
+			t.Fatalf("TestAuditSqlite: create failed: %s", err)
+		}
+		if created.ID == 0 || created.Created_at == "" || string(created.Diff) != string(entry.Diff) {
+			t.Fatalf("TestAuditSqlite: unexpected created entry %+v", created)
+		}
+	}
+
+	cases := []struct {
+		name   string
+		filter entities.AuditFilter
+		ids    []int
+	}{
+		{"all", entities.AuditFilter{Limit: 50}, []int{4, 3, 2, 1}},
+		{"actor", entities.AuditFilter{Actor: "alex", Limit: 50}, []int{3, 2, 1}},
+		{"target type", entities.AuditFilter{TargetType: "blog", Limit: 50}, []int{4, 2, 1}},
+		{"target", entities.AuditFilter{TargetType: "blog", TargetID: "1", Limit: 50}, []int{2, 1}},
+		{"first page", entities.AuditFilter{Limit: 2}, []int{4, 3}},
+		{"next page", entities.AuditFilter{Before: 3, Limit: 2}, []int{2, 1}},
+		{"future", entities.AuditFilter{From: time.Now().Add(time.Hour).UTC().Format("2006-01-02T15:04:05-07:00"), Limit: 50}, []int{}},
+		{"past", entities.AuditFilter{To: "2000-01-01T00:00:00+00:00", Limit: 50}, []int{}},
+	}
+	for _, c := range cases {
+		listed, err := auditRepo.List(ctxTimeout, c.filter)
+		if err != nil {
+			t.Fatalf("TestAuditSqlite: %s: list failed: %s", c.name, err)
+		}
+		ids := []int{}
+		for _, entry := range listed {
+			ids = append(ids, entry.ID)
+		}
+		if len(ids) != len(c.ids) {
+			t.Fatalf("TestAuditSqlite: %s: expected %v, got %v", c.name, c.ids, ids)
+		}
+		for i := range ids {
+			if ids[i] != c.ids[i] {
+				t.Fatalf("TestAuditSqlite: %s: expected %v, got %v", c.name, c.ids, ids)
+			}
+		}
+	}
+
+	// nothing older than an hour ago
+	purged, err := auditRepo.Purge(ctxTimeout, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("TestAuditSqlite: purge failed: %s", err)
+	}
+	if purged != 0 {
+		t.Fatalf("TestAuditSqlite: recent entries should be kept, purged %d", purged)
+	}
+	purged, err = auditRepo.Purge(ctxTimeout, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("TestAuditSqlite: purge failed: %s", err)
+	}
+	if purged != 4 {
+		t.Fatalf("TestAuditSqlite: all entries should be purged, purged %d", purged)
+	}
+}
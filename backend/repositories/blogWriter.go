@@ -0,0 +1,294 @@
+package repositories
+
+import (
+	"blog/db/models/interfaces"
+	"blog/entities"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// Writes of blogs with their relations inside a transaction, shared by Blogs and Bulk.
+// Outbox events are written in the transaction, publishing after commit is left to the caller.
+type blogWriter struct {
+	blog         interfaces.BlogsModel
+	blogTags     interfaces.BlogTagsModel
+	blogTopics   interfaces.BlogTopicsModel
+	blogMedia    interfaces.BlogMediaModel
+	blogSeries   interfaces.BlogSeriesModel
+	statusEvents interfaces.BlogStatusEventsModel
+	tags         interfaces.TagsModel
+	topics       interfaces.TopicsModel
+	series       interfaces.SeriesModel
+	outbox       interfaces.WebhookOutboxModel
+}
+
+func (w blogWriter) reader(tx *sql.Tx) outBlogReader {
+	return outBlogReader{
+		tags:       w.tags,
+		topics:     w.topics,
+		series:     w.series,
+		blogSeries: w.blogSeries,
+		tx:         tx,
+	}
+}
+
+// Creates the blog with its relations, id 0 lets the db pick one
+func (w blogWriter) create(ctx context.Context, tx *sql.Tx, blog entities.InBlog, id int) (*entities.Blog, error) {
+	var newBlog *entities.Blog
+	var err error
+	if id > 0 {
+		newBlog, err = w.blog.CreateWithID(ctx, tx, blog, id)
+	} else {
+		newBlog, err = w.blog.Create(ctx, tx, blog)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("create: model create blog failed: %w", err)
+	}
+
+	statusEvent := entities.NewBlogStatusEvent(newBlog.ID, "", newBlog.Status, blog.Actor, "created")
+	if err := w.statusEvents.Create(ctx, tx, *statusEvent); err != nil {
+		return nil, fmt.Errorf("create: model create status event failed: %w", err)
+	}
+
+	if err := w.blogTags.Upsert(ctx, tx, newBlog.ID, blog.Tags); err != nil {
+		return nil, fmt.Errorf("create: model create blog_tags failed: %w", err)
+	}
+
+	if err := w.blogTopics.Upsert(ctx, tx, newBlog.ID, blog.Topics); err != nil {
+		return nil, fmt.Errorf("create: model create blog_topics failed: %w", err)
+	}
+
+	if err := w.blogMedia.Replace(ctx, tx, newBlog.ID, entities.ExtractMediaHashes(blog.Content)); err != nil {
+		return nil, fmt.Errorf("create: model replace blog_media failed: %w", err)
+	}
+
+	if blog.Series > 0 {
+		if err := w.blogSeries.Upsert(ctx, tx, newBlog.ID, blog.Series, blog.Part); err != nil {
+			return nil, fmt.Errorf("create: model create blog_series failed: %w", err)
+		}
+	}
+
+	if err := emitEvent(ctx, tx, w.outbox, entities.EventBlogCreated, blogEventData(*newBlog)); err != nil {
+		return nil, fmt.Errorf("create: emit event failed: %w", err)
+	}
+
+	return newBlog, nil
+}
+
+// Reads the blog a write is made against in tx and compares it with ifMatch
+func (w blogWriter) current(ctx context.Context, tx *sql.Tx, id int, ifMatch string) (*entities.Blog, error) {
+	current, err := w.blog.AdminGetInTx(ctx, tx, id)
+	if err != nil {
+		return nil, fmt.Errorf("current: model admin get blog failed: %w", err)
+	}
+	if err := w.reader(tx).checkIfMatch(ctx, ifMatch, *current); err != nil {
+		return nil, fmt.Errorf("current: %w", err)
+	}
+	return current, nil
+}
+
+/*
+Replaces the blog and its relations. The status is derived from the blog read in tx,
+the write only goes through while the blog is still at the version read.
+*/
+func (w blogWriter) update(ctx context.Context, tx *sql.Tx, blog entities.InBlog, id int, ifMatch string) (*entities.Blog, error) {
+	current, err := w.current(ctx, tx, id, ifMatch)
+	if err != nil {
+		return nil, fmt.Errorf("update: %w", err)
+	}
+	if status := entities.StatusFromVisible(current.Status, blog.Visible); status != current.Status {
+		blog.SetStatus(status, "")
+	} else {
+		blog.SetStatus(current.Status, current.ScheduledAt)
+	}
+
+	newBlog, err := w.blog.Update(ctx, tx, blog, id, current.Version)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("update: %w", changedSinceRead(ifMatch))
+		}
+		return nil, fmt.Errorf("update: model update blog failed: %w", err)
+	}
+
+	if newBlog.Status != current.Status {
+		statusEvent := entities.NewBlogStatusEvent(id, current.Status, newBlog.Status, blog.Actor, "visible changed")
+		if err := w.statusEvents.Create(ctx, tx, *statusEvent); err != nil {
+			return nil, fmt.Errorf("update: model create status event failed: %w", err)
+		}
+	}
+
+	// Update many-to-many table
+	// Uses upsert + inverse delete
+	if err := w.blogTags.Upsert(ctx, tx, newBlog.ID, blog.Tags); err != nil {
+		return nil, fmt.Errorf("update: model update blog_tags failed: %w", err)
+	}
+
+	if err := w.blogTags.InverseDelete(ctx, tx, newBlog.ID, blog.Tags); err != nil {
+		return nil, fmt.Errorf("update: model inverse delete blog_tags failed: %w", err)
+	}
+
+	if err := w.blogTopics.Upsert(ctx, tx, newBlog.ID, blog.Topics); err != nil {
+		return nil, fmt.Errorf("update: model update blog_topics failed: %w", err)
+	}
+
+	if err := w.blogTopics.InverseDelete(ctx, tx, newBlog.ID, blog.Topics); err != nil {
+		return nil, fmt.Errorf("update: model inverse delete blog_topics failed: %w", err)
+	}
+
+	if err := w.blogMedia.Replace(ctx, tx, newBlog.ID, entities.ExtractMediaHashes(blog.Content)); err != nil {
+		return nil, fmt.Errorf("update: model replace blog_media failed: %w", err)
+	}
+
+	if blog.Series > 0 {
+		if err := w.blogSeries.Upsert(ctx, tx, newBlog.ID, blog.Series, blog.Part); err != nil {
+			return nil, fmt.Errorf("update: model update blog_series failed: %w", err)
+		}
+	} else {
+		if err := w.blogSeries.Delete(ctx, tx, newBlog.ID); err != nil {
+			return nil, fmt.Errorf("update: model delete blog_series failed: %w", err)
+		}
+	}
+
+	if err := emitEvent(ctx, tx, w.outbox, entities.EventBlogUpdated, blogEventData(*newBlog)); err != nil {
+		return nil, fmt.Errorf("update: emit event failed: %w", err)
+	}
+
+	return newBlog, nil
+}
+
+/*
+Moves the blog to the trash, returns 0 affected rows if it doesn't exist or is already in the trash.
+With ifMatch the blog is read in tx and compared with it first.
+*/
+func (w blogWriter) softDelete(ctx context.Context, tx *sql.Tx, id int, deletedBy, ifMatch string) (int, error) {
+	version, err := w.deleteVersion(ctx, tx, id, ifMatch)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("softDelete: %w", err)
+	}
+
+	affectedRows, err := w.blog.SoftDelete(ctx, tx, id, deletedBy, version)
+	if err != nil {
+		return 0, fmt.Errorf("softDelete: model soft delete blog failed: %w", err)
+	}
+
+	if affectedRows > 0 {
+		if err := emitEvent(ctx, tx, w.outbox, entities.EventBlogDeleted, entities.DeletedResource{ID: id}); err != nil {
+			return 0, fmt.Errorf("softDelete: emit event failed: %w", err)
+		}
+	}
+
+	return affectedRows, nil
+}
+
+/*
+Version a delete has to be made against, the blog is read in tx and compared with ifMatch.
+Without ifMatch the zero version is returned and the delete always goes through.
+*/
+func (w blogWriter) deleteVersion(ctx context.Context, tx *sql.Tx, id int, ifMatch string) (entities.Version, error) {
+	if ifMatch == "" {
+		return 0, nil
+	}
+	current, err := w.current(ctx, tx, id, ifMatch)
+	if err != nil {
+		return 0, fmt.Errorf("deleteVersion: %w", err)
+	}
+	return current.Version, nil
+}
+
+// Reads the tags, topics and series of blogs, inside tx if set
+type outBlogReader struct {
+	tags       interfaces.TagsModel
+	topics     interfaces.TopicsModel
+	series     interfaces.SeriesModel
+	blogSeries interfaces.BlogSeriesModel
+	db         *sql.DB
+	tx         *sql.Tx
+}
+
+func (r outBlogReader) fill(ctx context.Context, blog entities.Blog) (*entities.OutBlog, error) {
+	var tags []entities.Tag
+	var err error
+	if r.tx != nil {
+		tags, err = r.tags.ListByBlogIDInTx(ctx, r.tx, blog.ID)
+	} else {
+		tags, err = r.tags.ListByBlogID(ctx, r.db, blog.ID)
+	}
+	if err != nil {
+		return &entities.OutBlog{}, fmt.Errorf("fill: model get tags failed: %w", err)
+	}
+
+	var topics []entities.Topic
+	if r.tx != nil {
+		topics, err = r.topics.ListByBlogIDInTx(ctx, r.tx, blog.ID)
+	} else {
+		topics, err = r.topics.ListByBlogID(ctx, r.db, blog.ID)
+	}
+	if err != nil {
+		return &entities.OutBlog{}, fmt.Errorf("fill: model get topics failed: %w", err)
+	}
+
+	outBlog := entities.NewOutBlog(blog, tags, topics)
+
+	var series *entities.BlogSeries
+	if r.tx != nil {
+		series, err = r.series.GetByBlogIDInTx(ctx, r.tx, blog.ID)
+	} else {
+		series, err = r.series.GetByBlogID(ctx, r.db, blog.ID)
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return outBlog, nil
+		}
+		return &entities.OutBlog{}, fmt.Errorf("fill: model get series failed: %w", err)
+	}
+	outBlog.Series = series
+
+	// neighbours are looked up by position, so that hidden blogs still get them
+	var parts []entities.SeriesPart
+	if r.tx != nil {
+		parts, err = r.blogSeries.ListPartsInTx(ctx, r.tx, series.ID)
+	} else {
+		parts, err = r.blogSeries.ListParts(ctx, r.db, series.ID)
+	}
+	if err != nil {
+		return &entities.OutBlog{}, fmt.Errorf("fill: model list series parts failed: %w", err)
+	}
+	for _, part := range parts {
+		if part.ID == blog.ID {
+			continue
+		}
+		if part.Part < series.Part || (part.Part == series.Part && part.ID < blog.ID) {
+			prev := part
+			outBlog.Prev = &prev
+			continue
+		}
+		next := part
+		outBlog.Next = &next
+		break
+	}
+
+	return outBlog, nil
+}
+
+/*
+If-Match of a blog is compared with the ETag of the blog with its tags, topics and series, the same one GET returns.
+The related rows are read in tx, an empty ifMatch always matches.
+*/
+func (r outBlogReader) checkIfMatch(ctx context.Context, ifMatch string, current entities.Blog) error {
+	if ifMatch == "" {
+		return nil
+	}
+	outBlog, err := r.fill(ctx, current)
+	if err != nil {
+		return fmt.Errorf("checkIfMatch: %w", err)
+	}
+	if !entities.IfMatch(ifMatch, outBlog.ETag()) {
+		return entities.ErrorPreconditionFailed
+	}
+	return nil
+}
@@ -6,46 +6,74 @@ import (
 	"blog/entities"
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"log/slog"
 	"time"
 )
 
 type BlogRepoModels struct {
-	blog       interfaces.BlogsModel
-	blogTags   interfaces.BlogTagsModel
-	blogTopics interfaces.BlogTopicsModel
-	tags       interfaces.TagsModel
-	topics     interfaces.TopicsModel
+	blog         interfaces.BlogsModel
+	blogTags     interfaces.BlogTagsModel
+	blogTopics   interfaces.BlogTopicsModel
+	blogMedia    interfaces.BlogMediaModel
+	blogSeries   interfaces.BlogSeriesModel
+	tags         interfaces.TagsModel
+	topics       interfaces.TopicsModel
+	series       interfaces.SeriesModel
+	comments     interfaces.CommentsModel
+	stats        interfaces.StatsModel
+	previewLinks interfaces.PreviewLinksModel
+	statusEvents interfaces.BlogStatusEventsModel
+	outbox       interfaces.WebhookOutboxModel
 }
 
 func NewBlogsRepoModels(
 	blog interfaces.BlogsModel,
 	blogTags interfaces.BlogTagsModel,
 	blogTopics interfaces.BlogTopicsModel,
+	blogMedia interfaces.BlogMediaModel,
+	blogSeries interfaces.BlogSeriesModel,
 	tags interfaces.TagsModel,
 	topics interfaces.TopicsModel,
+	series interfaces.SeriesModel,
+	comments interfaces.CommentsModel,
+	stats interfaces.StatsModel,
+	previewLinks interfaces.PreviewLinksModel,
+	statusEvents interfaces.BlogStatusEventsModel,
+	outbox interfaces.WebhookOutboxModel,
 ) *BlogRepoModels {
 
 	return &BlogRepoModels{
-		blog:       blog,
-		blogTags:   blogTags,
-		blogTopics: blogTopics,
-		tags:       tags,
-		topics:     topics,
+		blog:         blog,
+		blogTags:     blogTags,
+		blogTopics:   blogTopics,
+		blogMedia:    blogMedia,
+		blogSeries:   blogSeries,
+		tags:         tags,
+		topics:       topics,
+		series:       series,
+		comments:     comments,
+		stats:        stats,
+		previewLinks: previewLinks,
+		statusEvents: statusEvents,
+		outbox:       outbox,
 	}
 }
 
 type Blogs struct {
-	db     *sql.DB
-	config config.DBSetting
-	models BlogRepoModels
+	db        *sql.DB
+	config    config.DBSetting
+	models    BlogRepoModels
+	publisher Publisher
 }
 
-func NewBlogs(db *sql.DB, config config.DBSetting, models BlogRepoModels) *Blogs {
+func NewBlogs(db *sql.DB, config config.DBSetting, models BlogRepoModels, publisher Publisher) *Blogs {
 	return &Blogs{
-		db:     db,
-		config: config,
-		models: models,
+		db:        db,
+		config:    config,
+		models:    models,
+		publisher: publisher,
 	}
 }
 
@@ -58,32 +86,20 @@ func (b *Blogs) Create(ctx context.Context, blog entities.InBlog) (*entities.Out
 		return &entities.OutBlog{}, fmt.Errorf("Create: begin transaction error: %w", err)
 	}
 
-	newBlog, err := b.models.blog.Create(ctxTimeout, tx, blog)
+	newBlog, err := b.writer().create(ctxTimeout, tx, blog, 0)
 	if err != nil {
 		if err := tx.Rollback(); err != nil {
-			return &entities.OutBlog{}, fmt.Errorf("Create: query rollback error: %w", err)
+			return &entities.OutBlog{}, fmt.Errorf("Create: create blog rollback error: %w", err)
 		}
-		return &entities.OutBlog{}, fmt.Errorf("Create: models create blog failed: %w", err)
-	}
-
-	if err := b.models.blogTags.Upsert(ctxTimeout, tx, newBlog.ID, blog.Tags); err != nil {
-		if err := tx.Rollback(); err != nil {
-			return &entities.OutBlog{}, fmt.Errorf("Create: model create blog_tags rollback error: %w", err)
-		}
-		return &entities.OutBlog{}, fmt.Errorf("Create: model create blog_tags error: %w", err)
-	}
-
-	if err := b.models.blogTopics.Upsert(ctxTimeout, tx, newBlog.ID, blog.Topics); err != nil {
-		if err := tx.Rollback(); err != nil {
-			return &entities.OutBlog{}, fmt.Errorf("Create: model create blog_topics rollback error: %w", err)
-		}
-		return &entities.OutBlog{}, fmt.Errorf("Create: model create blog_topics error: %w", err)
+		return &entities.OutBlog{}, fmt.Errorf("Create: %w", err)
 	}
 
 	if err := tx.Commit(); err != nil {
 		return &entities.OutBlog{}, fmt.Errorf("Create: commit error: %w", err)
 	}
 
+	publish(b.publisher, entities.EventBlogCreated, blogStreamData(*newBlog))
+
 	outBlog, err := b.fillOutBlog(ctxTimeout, *newBlog)
 	if err != nil {
 		return &entities.OutBlog{}, fmt.Errorf("Create: fill OutBlog failed: %w", err)
@@ -101,104 +117,221 @@ func (b *Blogs) CreateWithID(ctx context.Context, blog entities.InBlog, id int)
 		return &entities.OutBlog{}, fmt.Errorf("CreateWithID: begin transaction error: %w", err)
 	}
 
-	newBlog, err := b.models.blog.CreateWithID(ctxTimeout, tx, blog, id)
+	newBlog, err := b.writer().create(ctxTimeout, tx, blog, id)
 	if err != nil {
 		if err := tx.Rollback(); err != nil {
-			return &entities.OutBlog{}, fmt.Errorf("CreateWithID: query rollback error: %w", err)
+			return &entities.OutBlog{}, fmt.Errorf("CreateWithID: create blog rollback error: %w", err)
 		}
-		return &entities.OutBlog{}, fmt.Errorf("CreateWithID: models create blog failed: %w", err)
+		return &entities.OutBlog{}, fmt.Errorf("CreateWithID: %w", err)
 	}
 
-	if err := b.models.blogTags.Upsert(ctxTimeout, tx, newBlog.ID, blog.Tags); err != nil {
-		if err := tx.Rollback(); err != nil {
-			return &entities.OutBlog{}, fmt.Errorf("CreateWithID: model create blog_tags rollback error: %w", err)
-		}
-		return &entities.OutBlog{}, fmt.Errorf("CreateWithID: model create blog_tags error: %w", err)
+	if err := tx.Commit(); err != nil {
+		return &entities.OutBlog{}, fmt.Errorf("CreateWithID: commit error: %w", err)
+	}
+
+	publish(b.publisher, entities.EventBlogCreated, blogStreamData(*newBlog))
+
+	outBlog, err := b.fillOutBlog(ctxTimeout, *newBlog)
+	if err != nil {
+		return &entities.OutBlog{}, fmt.Errorf("CreateWithID: fill OutBlog failed: %w", err)
 	}
 
-	if err := b.models.blogTopics.Upsert(ctxTimeout, tx, newBlog.ID, blog.Topics); err != nil {
+	return outBlog, nil
+}
+
+/*
+Status only changes through Transition, except for the visible field of older clients:
+showing a blog publishes it, hiding a published blog turns it back into a draft.
+*/
+func (b *Blogs) Update(ctx context.Context, blog entities.InBlog, id int, ifMatch string) (*entities.OutBlog, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(b.config.Timeout)*time.Second)
+	defer cancel()
+
+	tx, err := b.db.BeginTx(ctxTimeout, &sql.TxOptions{})
+	if err != nil {
+		return &entities.OutBlog{}, fmt.Errorf("Update: begin transaction error: %w", err)
+	}
+
+	newBlog, err := b.writer().update(ctxTimeout, tx, blog, id, ifMatch)
+	if err != nil {
 		if err := tx.Rollback(); err != nil {
-			return &entities.OutBlog{}, fmt.Errorf("CreateWithID: model create blog_topics rollback error: %w", err)
+			return &entities.OutBlog{}, fmt.Errorf("Update: update blog rollback error: %w", err)
 		}
-		return &entities.OutBlog{}, fmt.Errorf("CreateWithID: model create blog_topics error: %w", err)
+		return &entities.OutBlog{}, fmt.Errorf("Update: %w", err)
 	}
 
 	if err := tx.Commit(); err != nil {
-		return &entities.OutBlog{}, fmt.Errorf("CreateWithID: commit error: %w", err)
+		return &entities.OutBlog{}, fmt.Errorf("Update: commit error: %w", err)
 	}
 
+	publish(b.publisher, entities.EventBlogUpdated, blogStreamData(*newBlog))
+
 	outBlog, err := b.fillOutBlog(ctxTimeout, *newBlog)
 	if err != nil {
-		return &entities.OutBlog{}, fmt.Errorf("CreateWithID: fill OutBlog failed: %w", err)
+		return &entities.OutBlog{}, fmt.Errorf("Update: fill OutBlog failed: %w", err)
 	}
 
 	return outBlog, nil
 }
 
-func (b *Blogs) Update(ctx context.Context, blog entities.InBlog, id int) (*entities.OutBlog, error) {
+/*
+Patch only changes the fields present in the patch, relations are left alone unless given.
+Visible goes through the status like in Update.
+*/
+func (b *Blogs) Patch(ctx context.Context, patch entities.BlogPatch, id int, ifMatch string) (*entities.OutBlog, error) {
 	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(b.config.Timeout)*time.Second)
 	defer cancel()
 
 	tx, err := b.db.BeginTx(ctxTimeout, &sql.TxOptions{})
 	if err != nil {
-		return &entities.OutBlog{}, fmt.Errorf("Update: begin transaction error: %w", err)
+		return &entities.OutBlog{}, fmt.Errorf("Patch: begin transaction error: %w", err)
 	}
 
-	// Update blog
-	newBlog, err := b.models.blog.Update(ctxTimeout, tx, blog, id)
+	// read in tx, the status is derived from it and the write only goes through while the blog is at its version
+	current, err := b.writer().current(ctxTimeout, tx, id, ifMatch)
 	if err != nil {
 		if err := tx.Rollback(); err != nil {
-			return &entities.OutBlog{}, fmt.Errorf("Update: query rollback error: %w", err)
+			return &entities.OutBlog{}, fmt.Errorf("Patch: current blog rollback error: %w", err)
+		}
+		return &entities.OutBlog{}, fmt.Errorf("Patch: %w", err)
+	}
+	if patch.Visible != nil {
+		if status := entities.StatusFromVisible(current.Status, *patch.Visible); status != current.Status {
+			patch.SetStatus(status, "")
+		} else {
+			patch.Visible = nil
 		}
-		return &entities.OutBlog{}, fmt.Errorf("Update: models update blog failed: %w", err)
 	}
 
-	// Update many-to-many table
-	// Uses upsert + inverse delete
-	if err := b.models.blogTags.Upsert(ctxTimeout, tx, newBlog.ID, blog.Tags); err != nil {
+	newBlog, err := b.models.blog.Patch(ctxTimeout, tx, patch, id, current.Version)
+	if err != nil {
 		if err := tx.Rollback(); err != nil {
-			return &entities.OutBlog{}, fmt.Errorf("Update: model update blog_tags rollback error: %w", err)
+			return &entities.OutBlog{}, fmt.Errorf("Patch: query rollback error: %w", err)
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			return &entities.OutBlog{}, fmt.Errorf("Patch: %w", changedSinceRead(ifMatch))
 		}
-		return &entities.OutBlog{}, fmt.Errorf("Update: model update blog_tags error: %w", err)
+		return &entities.OutBlog{}, fmt.Errorf("Patch: models patch blog failed: %w", err)
 	}
 
-	if err := b.models.blogTags.InverseDelete(ctxTimeout, tx, newBlog.ID, blog.Tags); err != nil {
-		if err := tx.Rollback(); err != nil {
-			return &entities.OutBlog{}, fmt.Errorf("Update: model inverse delete blog_tags rollback error: %w", err)
+	if newBlog.Status != current.Status {
+		statusEvent := entities.NewBlogStatusEvent(id, current.Status, newBlog.Status, patch.Actor, "visible changed")
+		if err := b.models.statusEvents.Create(ctxTimeout, tx, *statusEvent); err != nil {
+			if err := tx.Rollback(); err != nil {
+				return &entities.OutBlog{}, fmt.Errorf("Patch: model create status event rollback error: %w", err)
+			}
+			return &entities.OutBlog{}, fmt.Errorf("Patch: model create status event error: %w", err)
 		}
-		return &entities.OutBlog{}, fmt.Errorf("Update: model inverse delete blog_tags error: %w", err)
 	}
 
-	if err := b.models.blogTopics.Upsert(ctxTimeout, tx, newBlog.ID, blog.Topics); err != nil {
-		if err := tx.Rollback(); err != nil {
-			return &entities.OutBlog{}, fmt.Errorf("Update: model update blog_topics rollback error: %w", err)
+	if patch.Tags != nil {
+		if err := b.replaceTags(ctxTimeout, tx, id, *patch.Tags); err != nil {
+			if err := tx.Rollback(); err != nil {
+				return &entities.OutBlog{}, fmt.Errorf("Patch: replace blog_tags rollback error: %w", err)
+			}
+			return &entities.OutBlog{}, fmt.Errorf("Patch: replace blog_tags error: %w", err)
 		}
-		return &entities.OutBlog{}, fmt.Errorf("Update: model update blog_topics error: %w", err)
 	}
 
-	if err := b.models.blogTopics.InverseDelete(ctxTimeout, tx, newBlog.ID, blog.Topics); err != nil {
+	if patch.Topics != nil {
+		if err := b.replaceTopics(ctxTimeout, tx, id, *patch.Topics); err != nil {
+			if err := tx.Rollback(); err != nil {
+				return &entities.OutBlog{}, fmt.Errorf("Patch: replace blog_topics rollback error: %w", err)
+			}
+			return &entities.OutBlog{}, fmt.Errorf("Patch: replace blog_topics error: %w", err)
+		}
+	}
+
+	if patch.Content != nil {
+		if err := b.models.blogMedia.Replace(ctxTimeout, tx, id, entities.ExtractMediaHashes(*patch.Content)); err != nil {
+			if err := tx.Rollback(); err != nil {
+				return &entities.OutBlog{}, fmt.Errorf("Patch: model replace blog_media rollback error: %w", err)
+			}
+			return &entities.OutBlog{}, fmt.Errorf("Patch: model replace blog_media error: %w", err)
+		}
+	}
+
+	if patch.Series != nil {
+		if *patch.Series > 0 {
+			part := 0
+			if patch.Part != nil {
+				part = *patch.Part
+			}
+			if err := b.models.blogSeries.Upsert(ctxTimeout, tx, id, *patch.Series, part); err != nil {
+				if err := tx.Rollback(); err != nil {
+					return &entities.OutBlog{}, fmt.Errorf("Patch: model update blog_series rollback error: %w", err)
+				}
+				return &entities.OutBlog{}, fmt.Errorf("Patch: model update blog_series error: %w", err)
+			}
+		} else {
+			if err := b.models.blogSeries.Delete(ctxTimeout, tx, id); err != nil {
+				if err := tx.Rollback(); err != nil {
+					return &entities.OutBlog{}, fmt.Errorf("Patch: model delete blog_series rollback error: %w", err)
+				}
+				return &entities.OutBlog{}, fmt.Errorf("Patch: model delete blog_series error: %w", err)
+			}
+		}
+	}
+
+	if err := emitEvent(ctxTimeout, tx, b.models.outbox, entities.EventBlogUpdated, blogEventData(*newBlog)); err != nil {
 		if err := tx.Rollback(); err != nil {
-			return &entities.OutBlog{}, fmt.Errorf("Update: model inverse delete blog_topics rollback error: %w", err)
+			return &entities.OutBlog{}, fmt.Errorf("Patch: emit event rollback failed: %w", err)
 		}
-		return &entities.OutBlog{}, fmt.Errorf("Update: model inverse delete blog_topics error: %w", err)
+		return &entities.OutBlog{}, fmt.Errorf("Patch: emit event failed: %w", err)
 	}
 
 	if err := tx.Commit(); err != nil {
-		return &entities.OutBlog{}, fmt.Errorf("Update: commit error: %w", err)
+		return &entities.OutBlog{}, fmt.Errorf("Patch: commit error: %w", err)
 	}
 
+	publish(b.publisher, entities.EventBlogUpdated, blogStreamData(*newBlog))
+
 	outBlog, err := b.fillOutBlog(ctxTimeout, *newBlog)
 	if err != nil {
-		return &entities.OutBlog{}, fmt.Errorf("Update: fill OutBlog failed: %w", err)
+		return &entities.OutBlog{}, fmt.Errorf("Patch: fill OutBlog failed: %w", err)
 	}
 
 	return outBlog, nil
 }
 
+// an empty list removes all tags of the blog
+func (b *Blogs) replaceTags(ctx context.Context, tx *sql.Tx, blogID int, tagIDs []int) error {
+	if len(tagIDs) == 0 {
+		if err := b.models.blogTags.Delete(ctx, tx, blogID); err != nil {
+			return fmt.Errorf("replaceTags: model delete blog_tags failed: %w", err)
+		}
+		return nil
+	}
+	if err := b.models.blogTags.Upsert(ctx, tx, blogID, tagIDs); err != nil {
+		return fmt.Errorf("replaceTags: model upsert blog_tags failed: %w", err)
+	}
+	if err := b.models.blogTags.InverseDelete(ctx, tx, blogID, tagIDs); err != nil {
+		return fmt.Errorf("replaceTags: model inverse delete blog_tags failed: %w", err)
+	}
+	return nil
+}
+
+// an empty list removes all topics of the blog
+func (b *Blogs) replaceTopics(ctx context.Context, tx *sql.Tx, blogID int, topicIDs []int) error {
+	if len(topicIDs) == 0 {
+		if err := b.models.blogTopics.Delete(ctx, tx, blogID); err != nil {
+			return fmt.Errorf("replaceTopics: model delete blog_topics failed: %w", err)
+		}
+		return nil
+	}
+	if err := b.models.blogTopics.Upsert(ctx, tx, blogID, topicIDs); err != nil {
+		return fmt.Errorf("replaceTopics: model upsert blog_topics failed: %w", err)
+	}
+	if err := b.models.blogTopics.InverseDelete(ctx, tx, blogID, topicIDs); err != nil {
+		return fmt.Errorf("replaceTopics: model inverse delete blog_topics failed: %w", err)
+	}
+	return nil
+}
+
 /*
 Only return blog with field values:
 
-- visible: true
+- status: published
 
 - deleted_at: ""
 */
@@ -222,7 +355,7 @@ func (b *Blogs) Get(ctx context.Context, id int) (*entities.OutBlog, error) {
 /*
 Only return blogs with field values:
 
-- visible: true
+- status: published
 
 - deleted_at: ""
 */
@@ -251,7 +384,7 @@ func (b *Blogs) List(ctx context.Context) ([]entities.OutBlog, error) {
 /*
 Only return blogs with field values:
 
-- visible: true
+- status: published
 
 - deleted_at: ""
 */
@@ -280,7 +413,7 @@ func (b *Blogs) ListByTopicIDs(ctx context.Context, topicID []int) ([]entities.O
 /*
 Only return blogs with field values:
 
-- visible: true
+- status: published
 
 - deleted_at: ""
 */
@@ -416,7 +549,8 @@ func (b *Blogs) AdminListByTopicAndTagIDs(ctx context.Context, topicID, tagID []
 	return result, nil
 }
 
-func (b *Blogs) SoftDelete(ctx context.Context, id int) (int, error) {
+// Moves a blog to the trash, deletedBy is kept to show in the trash
+func (b *Blogs) SoftDelete(ctx context.Context, id int, deletedBy, ifMatch string) (int, error) {
 	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(b.config.Timeout)*time.Second)
 	defer cancel()
 
@@ -425,19 +559,26 @@ func (b *Blogs) SoftDelete(ctx context.Context, id int) (int, error) {
 		return 0, fmt.Errorf("SoftDelete: begin transaction failed: %w", err)
 	}
 
-	affectedRows, err := b.models.blog.SoftDelete(ctxTimeout, tx, id)
+	affectedRows, err := b.writer().softDelete(ctxTimeout, tx, id, deletedBy, ifMatch)
 	if err != nil {
-		return 0, fmt.Errorf("SoftDelete: blog soft delete failed: %w", err)
+		if err := tx.Rollback(); err != nil {
+			return 0, fmt.Errorf("SoftDelete: blog soft delete rollback failed: %w", err)
+		}
+		return 0, fmt.Errorf("SoftDelete: %w", err)
 	}
 
 	if err := tx.Commit(); err != nil {
 		return 0, fmt.Errorf("SoftDelete: commit failed: %w", err)
 	}
 
+	if affectedRows > 0 {
+		publish(b.publisher, entities.EventBlogDeleted, entities.DeletedResource{ID: id})
+	}
+
 	return affectedRows, nil
 }
 
-func (b *Blogs) Delete(ctx context.Context, id int) (int, error) {
+func (b *Blogs) Delete(ctx context.Context, id int, ifMatch string) (int, error) {
 	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(b.config.Timeout)*time.Second)
 	defer cancel()
 
@@ -446,6 +587,17 @@ func (b *Blogs) Delete(ctx context.Context, id int) (int, error) {
 		return 0, fmt.Errorf("Delete: begin transaction failed: %w", err)
 	}
 
+	version, err := b.writer().deleteVersion(ctxTimeout, tx, id, ifMatch)
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			return 0, fmt.Errorf("Delete: delete version rollback failed: %w", err)
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("Delete: %w", err)
+	}
+
 	// delete relations
 	if err := b.models.blogTags.Delete(ctxTimeout, tx, id); err != nil {
 		if err := tx.Rollback(); err != nil {
@@ -461,8 +613,50 @@ func (b *Blogs) Delete(ctx context.Context, id int) (int, error) {
 		return 0, fmt.Errorf("Delete: model delete blog_topics error: %w", err)
 	}
 
+	if err := b.models.blogMedia.Delete(ctxTimeout, tx, id); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return 0, fmt.Errorf("Delete: model delete blog_media rollback error: %w", err)
+		}
+		return 0, fmt.Errorf("Delete: model delete blog_media error: %w", err)
+	}
+
+	if err := b.models.blogSeries.Delete(ctxTimeout, tx, id); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return 0, fmt.Errorf("Delete: model delete blog_series rollback error: %w", err)
+		}
+		return 0, fmt.Errorf("Delete: model delete blog_series error: %w", err)
+	}
+
+	if err := b.models.comments.DeleteByBlogID(ctxTimeout, tx, id); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return 0, fmt.Errorf("Delete: model delete comments rollback error: %w", err)
+		}
+		return 0, fmt.Errorf("Delete: model delete comments error: %w", err)
+	}
+
+	if err := b.models.stats.DeleteByBlogID(ctxTimeout, tx, id); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return 0, fmt.Errorf("Delete: model delete stats rollback error: %w", err)
+		}
+		return 0, fmt.Errorf("Delete: model delete stats error: %w", err)
+	}
+
+	if err := b.models.previewLinks.DeleteByBlogID(ctxTimeout, tx, id); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return 0, fmt.Errorf("Delete: model delete preview links rollback error: %w", err)
+		}
+		return 0, fmt.Errorf("Delete: model delete preview links error: %w", err)
+	}
+
+	if err := b.models.statusEvents.DeleteByBlogID(ctxTimeout, tx, id); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return 0, fmt.Errorf("Delete: model delete status events rollback error: %w", err)
+		}
+		return 0, fmt.Errorf("Delete: model delete status events error: %w", err)
+	}
+
 	// delete blog
-	affectedRows, err := b.models.blog.Delete(ctxTimeout, tx, id)
+	affectedRows, err := b.models.blog.Delete(ctxTimeout, tx, id, version)
 	if err != nil {
 		if err := tx.Rollback(); err != nil {
 			return 0, fmt.Errorf("Delete: model delete blog rollback failed: %w", err)
@@ -470,14 +664,31 @@ func (b *Blogs) Delete(ctx context.Context, id int) (int, error) {
 		return 0, fmt.Errorf("Delete: model delete blog failed: %w", err)
 	}
 
+	// the blog isn't soft deleted, keep its relations and comments
+	if affectedRows == 0 {
+		if err := tx.Rollback(); err != nil {
+			return 0, fmt.Errorf("Delete: rollback failed: %w", err)
+		}
+		return 0, nil
+	}
+
+	if err := emitEvent(ctxTimeout, tx, b.models.outbox, entities.EventBlogPurged, entities.DeletedResource{ID: id}); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return 0, fmt.Errorf("Delete: emit event rollback failed: %w", err)
+		}
+		return 0, fmt.Errorf("Delete: emit event failed: %w", err)
+	}
+
 	if err := tx.Commit(); err != nil {
 		return 0, fmt.Errorf("Delete: commit failed: %w", err)
 	}
 
+	publish(b.publisher, entities.EventBlogPurged, entities.DeletedResource{ID: id})
+
 	return affectedRows, nil
 }
 
-func (b *Blogs) DeleteNow(ctx context.Context, id int) (int, error) {
+func (b *Blogs) DeleteNow(ctx context.Context, id int, ifMatch string) (int, error) {
 	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(b.config.Timeout)*time.Second)
 	defer cancel()
 
@@ -486,6 +697,17 @@ func (b *Blogs) DeleteNow(ctx context.Context, id int) (int, error) {
 		return 0, fmt.Errorf("DeleteNow: begin transaction failed: %w", err)
 	}
 
+	version, err := b.writer().deleteVersion(ctxTimeout, tx, id, ifMatch)
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			return 0, fmt.Errorf("DeleteNow: delete version rollback failed: %w", err)
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("DeleteNow: %w", err)
+	}
+
 	// delete relations
 	if err := b.models.blogTags.Delete(ctxTimeout, tx, id); err != nil {
 		if err := tx.Rollback(); err != nil {
@@ -501,8 +723,50 @@ func (b *Blogs) DeleteNow(ctx context.Context, id int) (int, error) {
 		return 0, fmt.Errorf("DeleteNow: model delete blog_topics error: %w", err)
 	}
 
+	if err := b.models.blogMedia.Delete(ctxTimeout, tx, id); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return 0, fmt.Errorf("DeleteNow: model delete blog_media rollback error: %w", err)
+		}
+		return 0, fmt.Errorf("DeleteNow: model delete blog_media error: %w", err)
+	}
+
+	if err := b.models.blogSeries.Delete(ctxTimeout, tx, id); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return 0, fmt.Errorf("DeleteNow: model delete blog_series rollback error: %w", err)
+		}
+		return 0, fmt.Errorf("DeleteNow: model delete blog_series error: %w", err)
+	}
+
+	if err := b.models.comments.DeleteByBlogID(ctxTimeout, tx, id); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return 0, fmt.Errorf("DeleteNow: model delete comments rollback error: %w", err)
+		}
+		return 0, fmt.Errorf("DeleteNow: model delete comments error: %w", err)
+	}
+
+	if err := b.models.stats.DeleteByBlogID(ctxTimeout, tx, id); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return 0, fmt.Errorf("DeleteNow: model delete stats rollback error: %w", err)
+		}
+		return 0, fmt.Errorf("DeleteNow: model delete stats error: %w", err)
+	}
+
+	if err := b.models.previewLinks.DeleteByBlogID(ctxTimeout, tx, id); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return 0, fmt.Errorf("DeleteNow: model delete preview links rollback error: %w", err)
+		}
+		return 0, fmt.Errorf("DeleteNow: model delete preview links error: %w", err)
+	}
+
+	if err := b.models.statusEvents.DeleteByBlogID(ctxTimeout, tx, id); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return 0, fmt.Errorf("DeleteNow: model delete status events rollback error: %w", err)
+		}
+		return 0, fmt.Errorf("DeleteNow: model delete status events error: %w", err)
+	}
+
 	// delete blog
-	affectedRows, err := b.models.blog.DeleteNow(ctxTimeout, tx, id)
+	affectedRows, err := b.models.blog.DeleteNow(ctxTimeout, tx, id, version)
 	if err != nil {
 		if err := tx.Rollback(); err != nil {
 			return 0, fmt.Errorf("DeleteNow: model delete blog rollback failed: %w", err)
@@ -510,10 +774,23 @@ func (b *Blogs) DeleteNow(ctx context.Context, id int) (int, error) {
 		return 0, fmt.Errorf("DeleteNow: model delete blog failed: %w", err)
 	}
 
+	if affectedRows > 0 {
+		if err := emitEvent(ctxTimeout, tx, b.models.outbox, entities.EventBlogPurged, entities.DeletedResource{ID: id}); err != nil {
+			if err := tx.Rollback(); err != nil {
+				return 0, fmt.Errorf("DeleteNow: emit event rollback failed: %w", err)
+			}
+			return 0, fmt.Errorf("DeleteNow: emit event failed: %w", err)
+		}
+	}
+
 	if err := tx.Commit(); err != nil {
 		return 0, fmt.Errorf("DeleteNow: commit failed: %w", err)
 	}
 
+	if affectedRows > 0 {
+		publish(b.publisher, entities.EventBlogPurged, entities.DeletedResource{ID: id})
+	}
+
 	return affectedRows, nil
 }
 
@@ -534,10 +811,19 @@ func (b *Blogs) RestoreDeleted(ctx context.Context, id int) (*entities.OutBlog,
 		return &entities.OutBlog{}, fmt.Errorf("RestoreDeleted: model restore deleted blog failed: %w", err)
 	}
 
+	if err := emitEvent(ctxTimeout, tx, b.models.outbox, entities.EventBlogRestored, blogEventData(*blog)); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return &entities.OutBlog{}, fmt.Errorf("RestoreDeleted: emit event rollback failed: %w", err)
+		}
+		return &entities.OutBlog{}, fmt.Errorf("RestoreDeleted: emit event failed: %w", err)
+	}
+
 	if err := tx.Commit(); err != nil {
 		return &entities.OutBlog{}, fmt.Errorf("RestoreDeleted: commit failed: %w", err)
 	}
 
+	publish(b.publisher, entities.EventBlogRestored, blogStreamData(*blog))
+
 	outBlog, err := b.fillOutBlog(ctxTimeout, *blog)
 	if err != nil {
 		return &entities.OutBlog{}, fmt.Errorf("RestoreDeleted: fill OutBlog failed: %w", err)
@@ -546,23 +832,221 @@ func (b *Blogs) RestoreDeleted(ctx context.Context, id int) (*entities.OutBlog,
 	return outBlog, nil
 }
 
-// Helper function to fill out OutBlog with tags and topics
-func (b *Blogs) fillOutBlog(ctx context.Context, blog entities.Blog) (*entities.OutBlog, error) {
-	tags, err := b.models.tags.ListByBlogID(ctx, b.db, blog.ID)
+/*
+Moves a blog to another status, scheduledAt is only kept for scheduled.
+
+Returns entities.ErrorInvalidStatusTransition if the change is not allowed,
+and sql.ErrNoRows if the blog doesn't exist or is soft deleted.
+*/
+func (b *Blogs) Transition(ctx context.Context, id int, status, scheduledAt, actor, note string) (*entities.OutBlog, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(b.config.Timeout)*time.Second)
+	defer cancel()
+
+	current, err := b.models.blog.AdminGet(ctxTimeout, b.db, id)
 	if err != nil {
-		return &entities.OutBlog{}, fmt.Errorf("fillOutBlog: model get tags failed: %w", err)
+		return &entities.OutBlog{}, fmt.Errorf("Transition: model admin get blog failed: %w", err)
+	}
+	if current.Deleted_at != "" {
+		return &entities.OutBlog{}, fmt.Errorf("Transition: blog soft deleted: %w", sql.ErrNoRows)
+	}
+	if !entities.CanTransition(current.Status, status) {
+		return &entities.OutBlog{}, fmt.Errorf("Transition: %s to %s: %w", current.Status, status, entities.ErrorInvalidStatusTransition)
+	}
+	if status != entities.BlogScheduled {
+		scheduledAt = ""
 	}
 
-	topics, err := b.models.topics.ListByBlogID(ctx, b.db, blog.ID)
+	tx, err := b.db.BeginTx(ctxTimeout, &sql.TxOptions{})
 	if err != nil {
-		return &entities.OutBlog{}, fmt.Errorf("fillOutBlog: model get topics failed: %w", err)
+		return &entities.OutBlog{}, fmt.Errorf("Transition: begin transaction failed: %w", err)
+	}
+
+	// the status checked above is only updated if no one changed it since
+	blog, err := b.models.blog.UpdateStatus(ctxTimeout, tx, id, current.Status, status, scheduledAt)
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			return &entities.OutBlog{}, fmt.Errorf("Transition: model update blog status rollback failed: %w", err)
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			return &entities.OutBlog{}, fmt.Errorf("Transition: status changed from %s since it was read: %w", current.Status, entities.ErrorInvalidStatusTransition)
+		}
+		return &entities.OutBlog{}, fmt.Errorf("Transition: model update blog status failed: %w", err)
+	}
+
+	statusEvent := entities.NewBlogStatusEvent(id, current.Status, status, actor, note)
+	if err := b.models.statusEvents.Create(ctxTimeout, tx, *statusEvent); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return &entities.OutBlog{}, fmt.Errorf("Transition: model create status event rollback failed: %w", err)
+		}
+		return &entities.OutBlog{}, fmt.Errorf("Transition: model create status event failed: %w", err)
+	}
+
+	if err := emitEvent(ctxTimeout, tx, b.models.outbox, entities.EventBlogUpdated, blogEventData(*blog)); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return &entities.OutBlog{}, fmt.Errorf("Transition: emit event rollback failed: %w", err)
+		}
+		return &entities.OutBlog{}, fmt.Errorf("Transition: emit event failed: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return &entities.OutBlog{}, fmt.Errorf("Transition: commit failed: %w", err)
+	}
+
+	publish(b.publisher, entities.EventBlogUpdated, blogStreamData(*blog))
+
+	outBlog, err := b.fillOutBlog(ctxTimeout, *blog)
+	if err != nil {
+		return &entities.OutBlog{}, fmt.Errorf("Transition: fill OutBlog failed: %w", err)
 	}
 
-	outBlog := entities.NewOutBlog(blog, tags, topics)
 	return outBlog, nil
 }
 
-// Helper function to fill out OutBlog with tags and topics
+// Status changes of a blog, oldest first. Returns sql.ErrNoRows if the blog doesn't exist
+func (b *Blogs) ListStatusEvents(ctx context.Context, id int) ([]entities.BlogStatusEvent, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(b.config.Timeout)*time.Second)
+	defer cancel()
+
+	if _, err := b.models.blog.AdminGet(ctxTimeout, b.db, id); err != nil {
+		return []entities.BlogStatusEvent{}, fmt.Errorf("ListStatusEvents: model admin get blog failed: %w", err)
+	}
+
+	events, err := b.models.statusEvents.ListByBlogID(ctxTimeout, b.db, id)
+	if err != nil {
+		return []entities.BlogStatusEvent{}, fmt.Errorf("ListStatusEvents: model list status events failed: %w", err)
+	}
+
+	return events, nil
+}
+
+// Widths of the uploaded images a blog links to by hash, used to leave out srcset widths larger than the image
+func (b *Blogs) MediaWidths(ctx context.Context, id int) (map[string]int, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(b.config.Timeout)*time.Second)
+	defer cancel()
+
+	widths, err := b.models.blogMedia.ListWidthsByBlogID(ctxTimeout, b.db, id)
+	if err != nil {
+		return map[string]int{}, fmt.Errorf("MediaWidths: model list media widths failed: %w", err)
+	}
+
+	return widths, nil
+}
+
+// Publishes scheduled blogs due at now, returns the number published
+func (b *Blogs) PublishDue(ctx context.Context, now time.Time) (int, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(b.config.Timeout)*time.Second)
+	defer cancel()
+
+	due, err := b.models.blog.ListScheduledBefore(ctxTimeout, b.db, now.UTC().Format("2006-01-02T15:04:05-07:00"))
+	if err != nil {
+		return 0, fmt.Errorf("PublishDue: model list scheduled blogs failed: %w", err)
+	}
+
+	// one failed blog doesn't hold back the others
+	published := 0
+	errs := []error{}
+	for _, blog := range due {
+		if _, err := b.Transition(ctx, blog.ID, entities.BlogPublished, "", "scheduler", "scheduled time reached"); err != nil {
+			slog.Error("PublishDue: transition blog failed", "id", blog.ID, "error", err)
+			errs = append(errs, fmt.Errorf("transition blog %d failed: %w", blog.ID, err))
+			continue
+		}
+		published++
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return published, fmt.Errorf("PublishDue: %w", err)
+	}
+	return published, nil
+}
+
+// Blogs in the trash, most recently deleted first
+func (b *Blogs) ListDeleted(ctx context.Context) ([]entities.OutBlog, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(b.config.Timeout)*time.Second)
+	defer cancel()
+
+	blogs, err := b.models.blog.ListDeleted(ctxTimeout, b.db)
+	if err != nil {
+		return []entities.OutBlog{}, fmt.Errorf("ListDeleted: model list deleted blogs failed: %w", err)
+	}
+
+	result := []entities.OutBlog{}
+	for _, blog := range blogs {
+		outBlog, err := b.fillOutBlog(ctxTimeout, blog)
+		if err != nil {
+			return []entities.OutBlog{}, fmt.Errorf("ListDeleted: fill OutBlog failed: %w", err)
+		}
+		result = append(result, *outBlog)
+	}
+
+	return result, nil
+}
+
+/*
+Permanently deletes blogs soft deleted at or before the given time, along with their relations.
+
+Returns the purged blogs, with dryRun nothing is deleted and the blogs that would be purged are returned.
+*/
+func (b *Blogs) PurgeDeleted(ctx context.Context, before time.Time, dryRun bool) ([]entities.Blog, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(b.config.Timeout)*time.Second)
+	defer cancel()
+
+	blogs, err := b.models.blog.ListDeletedBefore(ctxTimeout, b.db, before.UTC().Format("2006-01-02T15:04:05-07:00"))
+	if err != nil {
+		return []entities.Blog{}, fmt.Errorf("PurgeDeleted: model list deleted blogs failed: %w", err)
+	}
+	if dryRun {
+		return blogs, nil
+	}
+
+	purged := []entities.Blog{}
+	for _, blog := range blogs {
+		affectedRows, err := b.Delete(ctx, blog.ID, "")
+		if err != nil {
+			return purged, fmt.Errorf("PurgeDeleted: delete blog %d failed: %w", blog.ID, err)
+		}
+		// restored in the meantime
+		if affectedRows == 0 {
+			continue
+		}
+		purged = append(purged, blog)
+	}
+
+	return purged, nil
+}
+
+// Helper function to fill out OutBlog with tags, topics and series
+func (b *Blogs) fillOutBlog(ctx context.Context, blog entities.Blog) (*entities.OutBlog, error) {
+	reader := outBlogReader{
+		tags:       b.models.tags,
+		topics:     b.models.topics,
+		series:     b.models.series,
+		blogSeries: b.models.blogSeries,
+		db:         b.db,
+	}
+	outBlog, err := reader.fill(ctx, blog)
+	if err != nil {
+		return &entities.OutBlog{}, fmt.Errorf("fillOutBlog: %w", err)
+	}
+	return outBlog, nil
+}
+
+func (b *Blogs) writer() blogWriter {
+	return blogWriter{
+		blog:         b.models.blog,
+		blogTags:     b.models.blogTags,
+		blogTopics:   b.models.blogTopics,
+		blogMedia:    b.models.blogMedia,
+		blogSeries:   b.models.blogSeries,
+		statusEvents: b.models.statusEvents,
+		tags:         b.models.tags,
+		topics:       b.models.topics,
+		series:       b.models.series,
+		outbox:       b.models.outbox,
+	}
+}
+
+// Helper function to fill out OutBlogSimple with tags, topics and series as slugs
 func (b *Blogs) fillOutBlogSimple(ctx context.Context, blog entities.Blog) (entities.OutBlogSimple, error) {
 	tags, err := b.models.tags.ListSlugByBlogID(ctx, b.db, blog.ID)
 	if err != nil {
@@ -575,5 +1059,16 @@ func (b *Blogs) fillOutBlogSimple(ctx context.Context, blog entities.Blog) (enti
 	}
 
 	outBlog := entities.NewOutBlogSimple(blog, tags, topics)
+
+	series, err := b.models.series.GetByBlogID(ctx, b.db, blog.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return outBlog, nil
+		}
+		return entities.OutBlogSimple{}, fmt.Errorf("fillOutBlogSimple: model get series failed: %w", err)
+	}
+	outBlog.Series = series.Slug
+	outBlog.Part = series.Part
+
 	return outBlog, nil
 }
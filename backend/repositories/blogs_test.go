@@ -8,7 +8,10 @@ import (
 	"blog/repositories"
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -19,26 +22,47 @@ import (
 
 // Prepres blogs, tags, and topics repo
 func prepareRepos(dbConn *sql.DB) (repositories.Blogs, repositories.Tags, repositories.Topics) {
+	return preparePublishingRepos(dbConn, nil)
+}
+
+// same as prepareRepos, committed changes are published to publisher
+func preparePublishingRepos(dbConn *sql.DB, publisher repositories.Publisher) (repositories.Blogs, repositories.Tags, repositories.Topics) {
 	blogsModel := sqlite.NewBlogs()
 	blogTagsModel := sqlite.NewBlogTags()
 	blogTopicsModel := sqlite.NewBlogTopics()
+	blogMediaModel := sqlite.NewBlogMedia()
+	blogSeriesModel := sqlite.NewBlogSeries()
 	tagsModel := sqlite.NewTags()
 	topicsModel := sqlite.NewTopics()
+	seriesModel := sqlite.NewSeries()
+	commentsModel := sqlite.NewComments()
+	statsModel := sqlite.NewStats()
+	previewLinksModel := sqlite.NewPreviewLinks()
+	blogStatusEventsModel := sqlite.NewBlogStatusEvents()
+	outboxModel := sqlite.NewWebhookOutbox()
 
-	topicsRepoModels := repositories.NewTopicsRepoModels(blogTopicsModel, topicsModel)
-	topicsRepo := repositories.NewTopics(dbConn, config.NewConfig().DB, *topicsRepoModels)
+	topicsRepoModels := repositories.NewTopicsRepoModels(blogTopicsModel, topicsModel, outboxModel)
+	topicsRepo := repositories.NewTopics(dbConn, config.NewConfig().DB, *topicsRepoModels, publisher)
 
-	tagsRepoModels := repositories.NewTagsRepoModels(blogTagsModel, tagsModel)
-	tagsRepo := repositories.NewTags(dbConn, config.NewConfig().DB, *tagsRepoModels)
+	tagsRepoModels := repositories.NewTagsRepoModels(blogTagsModel, tagsModel, outboxModel)
+	tagsRepo := repositories.NewTags(dbConn, config.NewConfig().DB, *tagsRepoModels, publisher)
 
 	blogsRepoModels := repositories.NewBlogsRepoModels(
 		blogsModel,
 		blogTagsModel,
 		blogTopicsModel,
+		blogMediaModel,
+		blogSeriesModel,
 		tagsModel,
 		topicsModel,
+		seriesModel,
+		commentsModel,
+		statsModel,
+		previewLinksModel,
+		blogStatusEventsModel,
+		outboxModel,
 	)
-	blogsRepo := repositories.NewBlogs(dbConn, config.NewConfig().DB, *blogsRepoModels)
+	blogsRepo := repositories.NewBlogs(dbConn, config.NewConfig().DB, *blogsRepoModels, publisher)
 
 	return *blogsRepo, *tagsRepo, *topicsRepo
 }
@@ -84,7 +108,7 @@ func TestBlogsCreateSqlite(t *testing.T) {
 	if err != nil {
 		t.Fatalf("TestBlogsCreateSqlite: create failed: %s", err)
 	}
-	if !cmp.Equal(newBlog, &createResult1.Blog, cmpopts.IgnoreFields(entities.Blog{}, "ID", "Created_at", "Updated_at")) {
+	if !cmp.Equal(newBlog, &createResult1.Blog, cmpopts.IgnoreFields(entities.Blog{}, "ID", "Created_at", "Updated_at", "Version")) {
 		t.Fatalf("TestBlogsCreateSqlite: create cmp blog failed")
 	}
 	if !cmp.Equal(topic1, &createResult1.Topics[0]) {
@@ -177,11 +201,11 @@ func TestBlogsUpdateSqlite(t *testing.T) {
 		[]int{1},
 		[]int{1},
 	)
-	updatedBlog, err := blogsRepo.Update(ctxTimeout, *newInBlog2, 1)
+	updatedBlog, err := blogsRepo.Update(ctxTimeout, *newInBlog2, 1, "")
 	if err != nil {
 		t.Fatalf("TestBlogsUpdateSqlite: update failed: %s", err)
 	}
-	if !cmp.Equal(newBlog2, &updatedBlog.Blog, cmpopts.IgnoreFields(entities.Blog{}, "ID", "Created_at", "Updated_at")) {
+	if !cmp.Equal(newBlog2, &updatedBlog.Blog, cmpopts.IgnoreFields(entities.Blog{}, "ID", "Created_at", "Updated_at", "Version")) {
 		t.Fatalf("TestBlogsUpdateSqlite: update cmp blog failed")
 	}
 	if len(updatedBlog.Topics) != 1 {
@@ -198,6 +222,196 @@ func TestBlogsUpdateSqlite(t *testing.T) {
 	}
 }
 
+func TestBlogsPatchSqlite(t *testing.T) {
+	// connect
+	dbConn, err := sql.Open("sqlite3", "file:test.db?mode=memory&_foreign_keys=on")
+	if err != nil {
+		t.Fatalf("TestBlogsPatchSqlite: open db connection failed: %s", err)
+	}
+	defer dbConn.Close()
+
+	// migrate db
+	if err := db.Up(dbConn, db.EmbedMigrationsSQLite, "sqlite3", "migrations/sqlite"); err != nil {
+		t.Fatalf("TestBlogsPatchSqlite migrate up failed: %s", err)
+	}
+
+	// setup repo
+	blogsRepo, tagsRepo, topicsRepo := prepareRepos(dbConn)
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	// prepare topic, tags and blog
+	topicsRepo.Create(ctxTimeout, *entities.NewTopic("topic1", "topic1"))
+	topicsRepo.Create(ctxTimeout, *entities.NewTopic("topic2", "topic2"))
+	tagsRepo.Create(ctxTimeout, *entities.NewTag("tag1", "tag1"))
+	tagsRepo.Create(ctxTimeout, *entities.NewTag("tag2", "tag2"))
+	newBlog := entities.NewBlog("title1", "content1", "description1", false, false)
+	blogsRepo.Create(ctxTimeout, *entities.NewInBlog(*newBlog, []int{1, 2}, []int{1, 2}))
+
+	// toggle pined and visible, everything else is kept
+	patch := entities.BlogPatch{}
+	if err := json.Unmarshal([]byte(`{"pined": true, "visible": true}`), &patch); err != nil {
+		t.Fatalf("TestBlogsPatchSqlite: decode patch failed: %s", err)
+	}
+	patched, err := blogsRepo.Patch(ctxTimeout, patch, 1, "")
+	if err != nil {
+		t.Fatalf("TestBlogsPatchSqlite: patch failed: %s", err)
+	}
+	if !patched.Pined || !patched.Visible || patched.Status != entities.BlogPublished {
+		t.Fatalf("TestBlogsPatchSqlite: pined and visible should be set, got %+v", patched.Blog)
+	}
+	if patched.Title != newBlog.Title || patched.Content != newBlog.Content || patched.ContentMD5 != newBlog.ContentMD5 || patched.Slug != newBlog.Slug {
+		t.Fatalf("TestBlogsPatchSqlite: fields not in the patch should be kept, got %+v", patched.Blog)
+	}
+	if len(patched.Tags) != 2 || len(patched.Topics) != 2 {
+		t.Fatalf("TestBlogsPatchSqlite: relations should be kept without tags or topics")
+	}
+	events, err := blogsRepo.ListStatusEvents(ctxTimeout, 1)
+	if err != nil || len(events) != 2 {
+		t.Fatalf("TestBlogsPatchSqlite: showing the blog should record a status event, got %v %s", events, err)
+	}
+
+	// title and content regenerate slug and md5, tags are replaced, topics cleared
+	patch = entities.BlogPatch{}
+	if err := json.Unmarshal([]byte(`{"title": "new title", "content": "new content", "tags": [2], "topics": null}`), &patch); err != nil {
+		t.Fatalf("TestBlogsPatchSqlite: decode patch failed: %s", err)
+	}
+	patch.GenSlug()
+	patch.GenMD5()
+	patched, err = blogsRepo.Patch(ctxTimeout, patch, 1, "")
+	if err != nil {
+		t.Fatalf("TestBlogsPatchSqlite: patch failed: %s", err)
+	}
+	want := entities.NewBlog("new title", "new content", "description1", true, true)
+	if patched.Slug != want.Slug || patched.ContentMD5 != want.ContentMD5 || patched.Description != want.Description || !patched.Pined {
+		t.Fatalf("TestBlogsPatchSqlite: slug and md5 should be regenerated, got %+v", patched.Blog)
+	}
+	if len(patched.Tags) != 1 || patched.Tags[0].ID != 2 || len(patched.Topics) != 0 {
+		t.Fatalf("TestBlogsPatchSqlite: relations should be replaced, got tags %v topics %v", patched.Tags, patched.Topics)
+	}
+
+	// not found
+	if _, err := blogsRepo.Patch(ctxTimeout, entities.BlogPatch{}, 10, ""); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("TestBlogsPatchSqlite: patching a missing blog should fail with no rows, got %v", err)
+	}
+}
+
+func TestBlogsIfMatchSqlite(t *testing.T) {
+	// connect
+	dbConn, err := sql.Open("sqlite3", "file:test.db?mode=memory&_foreign_keys=on")
+	if err != nil {
+		t.Fatalf("TestBlogsIfMatchSqlite: open db connection failed: %s", err)
+	}
+	defer dbConn.Close()
+
+	// migrate db
+	if err := db.Up(dbConn, db.EmbedMigrationsSQLite, "sqlite3", "migrations/sqlite"); err != nil {
+		t.Fatalf("TestBlogsIfMatchSqlite migrate up failed: %s", err)
+	}
+
+	// setup repo
+	blogsRepo, tagsRepo, _ := prepareRepos(dbConn)
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	created, err := blogsRepo.Create(ctxTimeout, *entities.NewInBlog(*entities.NewBlog("title1", "content1", "description1", false, false), nil, nil))
+	if err != nil {
+		t.Fatalf("TestBlogsIfMatchSqlite: create failed: %s", err)
+	}
+	etag := created.ETag()
+
+	// matching etag
+	updated, err := blogsRepo.Update(ctxTimeout, *entities.NewInBlog(*entities.NewBlog("title2", "content2", "description2", false, false), nil, nil), created.ID, etag)
+	if err != nil {
+		t.Fatalf("TestBlogsIfMatchSqlite: update with the current etag failed: %s", err)
+	}
+	if updated.ETag() == etag {
+		t.Fatalf("TestBlogsIfMatchSqlite: etag should change with the content")
+	}
+
+	// stale etag, the blog is kept
+	title := "title3"
+	if _, err := blogsRepo.Patch(ctxTimeout, entities.BlogPatch{Title: &title}, created.ID, etag); !errors.Is(err, entities.ErrorPreconditionFailed) {
+		t.Fatalf("TestBlogsIfMatchSqlite: patch with a stale etag should fail, got %v", err)
+	}
+	if _, err := blogsRepo.SoftDelete(ctxTimeout, created.ID, "admin", etag); !errors.Is(err, entities.ErrorPreconditionFailed) {
+		t.Fatalf("TestBlogsIfMatchSqlite: soft delete with a stale etag should fail, got %v", err)
+	}
+	current, err := blogsRepo.AdminGet(ctxTimeout, created.ID)
+	if err != nil || current.Title != "title2" || current.Deleted_at != "" {
+		t.Fatalf("TestBlogsIfMatchSqlite: blog shouldn't change with a stale etag, got %+v %v", current, err)
+	}
+
+	// any version
+	if _, err := blogsRepo.Patch(ctxTimeout, entities.BlogPatch{Title: &title}, created.ID, "*"); err != nil {
+		t.Fatalf("TestBlogsIfMatchSqlite: patch with If-Match * failed: %s", err)
+	}
+
+	// writes within the same second that leave the content as is still change the etag
+	current, err = blogsRepo.AdminGet(ctxTimeout, created.ID)
+	if err != nil {
+		t.Fatalf("TestBlogsIfMatchSqlite: get failed: %s", err)
+	}
+	etag = current.ETag()
+	pined := true
+	if _, err := blogsRepo.Patch(ctxTimeout, entities.BlogPatch{Pined: &pined}, created.ID, etag); err != nil {
+		t.Fatalf("TestBlogsIfMatchSqlite: patch with the current etag failed: %s", err)
+	}
+	pined = false
+	if _, err := blogsRepo.Patch(ctxTimeout, entities.BlogPatch{Pined: &pined}, created.ID, etag); !errors.Is(err, entities.ErrorPreconditionFailed) {
+		t.Fatalf("TestBlogsIfMatchSqlite: second patch with the same etag should fail, got %v", err)
+	}
+
+	// renaming a tag changes the etag of its blogs, not their version
+	tag, err := tagsRepo.Create(ctxTimeout, *entities.NewTag("go", "go"))
+	if err != nil {
+		t.Fatalf("TestBlogsIfMatchSqlite: create tag failed: %s", err)
+	}
+	tagged, err := blogsRepo.Create(ctxTimeout, *entities.NewInBlog(*entities.NewBlog("title4", "content4", "description4", false, false), []int{tag.ID}, nil))
+	if err != nil {
+		t.Fatalf("TestBlogsIfMatchSqlite: create tagged blog failed: %s", err)
+	}
+	if _, err := tagsRepo.Update(ctxTimeout, *entities.NewTag("golang", "go"), tag.ID, ""); err != nil {
+		t.Fatalf("TestBlogsIfMatchSqlite: update tag failed: %s", err)
+	}
+	renamed, err := blogsRepo.AdminGet(ctxTimeout, tagged.ID)
+	if err != nil {
+		t.Fatalf("TestBlogsIfMatchSqlite: get tagged blog failed: %s", err)
+	}
+	if renamed.Version != tagged.Version || renamed.ETag() == tagged.ETag() {
+		t.Fatalf("TestBlogsIfMatchSqlite: tag rename should only change the etag, got %s %s", tagged.ETag(), renamed.ETag())
+	}
+
+	// deletes from the trash check If-Match too
+	if _, err := blogsRepo.DeleteNow(ctxTimeout, tagged.ID, tagged.ETag()); !errors.Is(err, entities.ErrorPreconditionFailed) {
+		t.Fatalf("TestBlogsIfMatchSqlite: delete now with a stale etag should fail, got %v", err)
+	}
+	if _, err := blogsRepo.SoftDelete(ctxTimeout, tagged.ID, "admin", renamed.ETag()); err != nil {
+		t.Fatalf("TestBlogsIfMatchSqlite: soft delete with the current etag failed: %s", err)
+	}
+	trashed, err := blogsRepo.AdminGet(ctxTimeout, tagged.ID)
+	if err != nil {
+		t.Fatalf("TestBlogsIfMatchSqlite: get trashed blog failed: %s", err)
+	}
+	if _, err := blogsRepo.Delete(ctxTimeout, tagged.ID, renamed.ETag()); !errors.Is(err, entities.ErrorPreconditionFailed) {
+		t.Fatalf("TestBlogsIfMatchSqlite: delete with the etag before soft delete should fail, got %v", err)
+	}
+	if affectedRows, err := blogsRepo.Delete(ctxTimeout, tagged.ID, trashed.ETag()); err != nil || affectedRows != 1 {
+		t.Fatalf("TestBlogsIfMatchSqlite: delete with the current etag should delete the blog, got %d %v", affectedRows, err)
+	}
+
+	// the version check is made by the update itself
+	blogsModel := sqlite.NewBlogs()
+	tx, err := dbConn.BeginTx(ctxTimeout, nil)
+	if err != nil {
+		t.Fatalf("TestBlogsIfMatchSqlite: begin transaction failed: %s", err)
+	}
+	defer tx.Rollback()
+	if _, err := blogsModel.Patch(ctxTimeout, tx, entities.BlogPatch{Title: &title}, created.ID, created.Version); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("TestBlogsIfMatchSqlite: model patch with an old version should change no rows, got %v", err)
+	}
+}
+
 func TestBlogsGetSqlite(t *testing.T) {
 	// connect
 	dbConn, err := sql.Open("sqlite3", "file:test.db?mode=memory&_foreign_keys=on")
@@ -255,7 +469,7 @@ func TestBlogsGetSqlite(t *testing.T) {
 	if err != nil {
 		t.Fatalf("TestBlogsGetSqlite: get failed: %s", err)
 	}
-	if !cmp.Equal(visibleBlog, &blog1.Blog, cmpopts.IgnoreFields(entities.Blog{}, "ID", "Created_at", "Updated_at")) {
+	if !cmp.Equal(visibleBlog, &blog1.Blog, cmpopts.IgnoreFields(entities.Blog{}, "ID", "Created_at", "Updated_at", "Version")) {
 		t.Fatalf("TestBlogsGetSqlite: get cmp blog failed")
 	}
 	if !cmp.Equal(topic1, &blog1.Topics[0]) {
@@ -333,7 +547,7 @@ func TestBlogsListSqlite(t *testing.T) {
 		t.Fatalf("TestBlogsListSqlite: list should only return one")
 	}
 	// List will not return content (too large)
-	if !cmp.Equal(visibleBlog, &blogs[0].Blog, cmpopts.IgnoreFields(entities.Blog{}, "ID", "Created_at", "Updated_at", "Content")) {
+	if !cmp.Equal(visibleBlog, &blogs[0].Blog, cmpopts.IgnoreFields(entities.Blog{}, "ID", "Created_at", "Updated_at", "Version", "Content")) {
 		t.Fatalf("TestBlogsListSqlite: list cmp blog failed")
 	}
 	if !cmp.Equal(topic1, &blogs[0].Topics[0]) {
@@ -405,7 +619,7 @@ func TestBlogsListByTopicIDsSqlite(t *testing.T) {
 		t.Fatalf("TestBlogsListByTopicIDsSqlite: list should only return one")
 	}
 	// List will not return content (too large)
-	if !cmp.Equal(visibleBlog, &blogs[0].Blog, cmpopts.IgnoreFields(entities.Blog{}, "ID", "Created_at", "Updated_at", "Content")) {
+	if !cmp.Equal(visibleBlog, &blogs[0].Blog, cmpopts.IgnoreFields(entities.Blog{}, "ID", "Created_at", "Updated_at", "Version", "Content")) {
 		t.Fatalf("TestBlogsListByTopicIDsSqlite: list cmp blog failed")
 	}
 	if !cmp.Equal(topic1, &blogs[0].Topics[0]) {
@@ -486,7 +700,7 @@ func TestBlogsListByTopicAndTagIDsSqlite(t *testing.T) {
 		t.Fatalf("TestBlogsListByTopicAndTagIDsSqlite: list should only return one")
 	}
 	// List will not return content (too large)
-	if !cmp.Equal(visibleBlog, &blogs[0].Blog, cmpopts.IgnoreFields(entities.Blog{}, "ID", "Created_at", "Updated_at", "Content")) {
+	if !cmp.Equal(visibleBlog, &blogs[0].Blog, cmpopts.IgnoreFields(entities.Blog{}, "ID", "Created_at", "Updated_at", "Version", "Content")) {
 		t.Fatalf("TestBlogsListByTopicAndTagIDsSqlite: list cmp blog failed")
 	}
 	if !cmp.Equal(topic1, &blogs[0].Topics[0]) {
@@ -563,7 +777,7 @@ func TestBlogsAdminGetSqlite(t *testing.T) {
 	if err != nil {
 		t.Fatalf("TestBlogsAdminGetSqlite: get failed: %s", err)
 	}
-	if !cmp.Equal(visibleBlog, &blog1.Blog, cmpopts.IgnoreFields(entities.Blog{}, "ID", "Created_at", "Updated_at")) {
+	if !cmp.Equal(visibleBlog, &blog1.Blog, cmpopts.IgnoreFields(entities.Blog{}, "ID", "Created_at", "Updated_at", "Version")) {
 		t.Fatalf("TestBlogsAdminGetSqlite: get cmp blog failed")
 	}
 	if !cmp.Equal(topic1, &blog1.Topics[0]) {
@@ -578,7 +792,7 @@ func TestBlogsAdminGetSqlite(t *testing.T) {
 	if err != nil {
 		t.Fatalf("TestBlogsAdminGetSqlite: get failed: %s", err)
 	}
-	if !cmp.Equal(notVisibleBlog, &blog2.Blog, cmpopts.IgnoreFields(entities.Blog{}, "ID", "Created_at", "Updated_at")) {
+	if !cmp.Equal(notVisibleBlog, &blog2.Blog, cmpopts.IgnoreFields(entities.Blog{}, "ID", "Created_at", "Updated_at", "Version")) {
 		t.Fatalf("TestBlogsAdminGetSqlite: get cmp blog failed")
 	}
 	if !cmp.Equal(topic1, &blog2.Topics[0]) {
@@ -902,7 +1116,7 @@ func compareListBlog(
 	tag1 entities.Tag,
 ) error {
 	// first blog
-	if !cmp.Equal(notVisibleBlog, blogs[0].Blog, cmpopts.IgnoreFields(entities.Blog{}, "ID", "Created_at", "Updated_at", "Content")) {
+	if !cmp.Equal(notVisibleBlog, blogs[0].Blog, cmpopts.IgnoreFields(entities.Blog{}, "ID", "Created_at", "Updated_at", "Version", "Content")) {
 		return fmt.Errorf("compareListBlog: list cmp blogs[0].Blog failed")
 	}
 	if !cmp.Equal(topic1, blogs[0].Topics[0]) {
@@ -913,7 +1127,7 @@ func compareListBlog(
 	}
 
 	// second blog
-	if !cmp.Equal(visibleBlog, blogs[1].Blog, cmpopts.IgnoreFields(entities.Blog{}, "ID", "Created_at", "Updated_at", "Content")) {
+	if !cmp.Equal(visibleBlog, blogs[1].Blog, cmpopts.IgnoreFields(entities.Blog{}, "ID", "Created_at", "Updated_at", "Version", "Content")) {
 		return fmt.Errorf("compareListBlog: list cmp blogs[1].Blog failed")
 	}
 	if !cmp.Equal(topic1, blogs[1].Topics[0]) {
@@ -934,7 +1148,7 @@ func compareListBlogSimple(
 	tag1 string,
 ) error {
 	// first blog
-	if !cmp.Equal(notVisibleBlog, blogs[0].Blog, cmpopts.IgnoreFields(entities.Blog{}, "ID", "Created_at", "Updated_at", "Content")) {
+	if !cmp.Equal(notVisibleBlog, blogs[0].Blog, cmpopts.IgnoreFields(entities.Blog{}, "ID", "Created_at", "Updated_at", "Version", "Content")) {
 		return fmt.Errorf("compareListBlogSimple: list cmp blogs[0].Blog failed")
 	}
 	if !cmp.Equal(topic1, blogs[0].Topics[0]) {
@@ -945,7 +1159,7 @@ func compareListBlogSimple(
 	}
 
 	// second blog
-	if !cmp.Equal(visibleBlog, blogs[1].Blog, cmpopts.IgnoreFields(entities.Blog{}, "ID", "Created_at", "Updated_at", "Content")) {
+	if !cmp.Equal(visibleBlog, blogs[1].Blog, cmpopts.IgnoreFields(entities.Blog{}, "ID", "Created_at", "Updated_at", "Version", "Content")) {
 		return fmt.Errorf("compareListBlogSimple: list cmp blogs[1].Blog failed")
 	}
 	if !cmp.Equal(topic1, blogs[1].Topics[0]) {
@@ -996,7 +1210,7 @@ func TestBlogsSoftDeleteSqlite(t *testing.T) {
 	)
 	blogsRepo.Create(ctxTimeout, *newInBlog1)
 
-	affectedRows, err := blogsRepo.SoftDelete(ctxTimeout, 1)
+	affectedRows, err := blogsRepo.SoftDelete(ctxTimeout, 1, "admin", "")
 	if err != nil {
 		t.Fatalf("TestBlogsSoftDeleteSqlite: soft delete failed: %s", err)
 	}
@@ -1065,7 +1279,7 @@ func TestBlogsDeleteSqlite(t *testing.T) {
 	blogsRepo.Create(ctxTimeout, *newInBlog1)
 
 	// delete (needs to be soft deleted first)
-	affectedRows, err := blogsRepo.Delete(ctxTimeout, 1)
+	affectedRows, err := blogsRepo.Delete(ctxTimeout, 1, "")
 	if err != nil {
 		t.Fatalf("TestBlogsDeleteSqlite: delete failed: %s", err)
 	}
@@ -1074,7 +1288,7 @@ func TestBlogsDeleteSqlite(t *testing.T) {
 	}
 
 	// soft delete
-	affectedRows2, err2 := blogsRepo.SoftDelete(ctxTimeout, 1)
+	affectedRows2, err2 := blogsRepo.SoftDelete(ctxTimeout, 1, "admin", "")
 	if err2 != nil {
 		t.Fatalf("TestBlogsDeleteSqlite: soft delete failed: %s", err)
 	}
@@ -1083,7 +1297,7 @@ func TestBlogsDeleteSqlite(t *testing.T) {
 	}
 
 	// this time it will be deleted
-	affectedRows3, err3 := blogsRepo.Delete(ctxTimeout, 1)
+	affectedRows3, err3 := blogsRepo.Delete(ctxTimeout, 1, "")
 	if err3 != nil {
 		t.Fatalf("TestBlogsDeleteSqlite: delete failed: %s", err)
 	}
@@ -1137,7 +1351,7 @@ func TestBlogsRestoreDeletedSqlite(t *testing.T) {
 	blogsRepo.Create(ctxTimeout, *newInBlog1)
 
 	// soft delete
-	affectedRows, err := blogsRepo.SoftDelete(ctxTimeout, 1)
+	affectedRows, err := blogsRepo.SoftDelete(ctxTimeout, 1, "admin", "")
 	if err != nil {
 		t.Fatalf("TestBlogsRestoreDeletedSqlite: soft delete failed: %s", err)
 	}
@@ -1150,7 +1364,351 @@ func TestBlogsRestoreDeletedSqlite(t *testing.T) {
 	if err != nil {
 		t.Fatalf("TestBlogsRestoreDeletedSqlite: restore delete failed: %s", err)
 	}
-	if !cmp.Equal(visibleBlog, &blog.Blog, cmpopts.IgnoreFields(entities.Blog{}, "ID", "Created_at", "Updated_at", "Deleted_at")) {
+	if !cmp.Equal(visibleBlog, &blog.Blog, cmpopts.IgnoreFields(entities.Blog{}, "ID", "Created_at", "Updated_at", "Version", "Deleted_at")) {
 		t.Fatalf("TestBlogsRestoreDeletedSqlite: restored blog cmp failed")
 	}
 }
+
+func TestBlogsWorkflowSqlite(t *testing.T) {
+	// connect
+	dbConn, err := sql.Open("sqlite3", "file:test.db?mode=memory&_foreign_keys=on")
+	if err != nil {
+		t.Fatalf("TestBlogsWorkflowSqlite: open db connection failed: %s", err)
+	}
+	defer dbConn.Close()
+
+	// migrate db
+	if err := db.Up(dbConn, db.EmbedMigrationsSQLite, "sqlite3", "migrations/sqlite"); err != nil {
+		t.Fatalf("TestBlogsWorkflowSqlite: migrate up failed: %s", err)
+	}
+
+	// setup repo
+	blogsRepo, _, _ := prepareRepos(dbConn)
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	draft := entities.NewInBlog(*entities.NewBlog("title1", "content1", "description1", false, false), []int{}, []int{})
+	draft.Actor = "writer"
+	blog, err := blogsRepo.Create(ctxTimeout, *draft)
+	if err != nil {
+		t.Fatalf("TestBlogsWorkflowSqlite: create failed: %s", err)
+	}
+	if blog.Status != entities.BlogDraft {
+		t.Fatalf("TestBlogsWorkflowSqlite: blogs that are not visible should be drafts, got %s", blog.Status)
+	}
+
+	// not allowed
+	if _, err := blogsRepo.Transition(ctxTimeout, blog.ID, entities.BlogDraft, "", "editor", ""); !errors.Is(err, entities.ErrorInvalidStatusTransition) {
+		t.Fatalf("TestBlogsWorkflowSqlite: draft to draft should not be allowed, got %v", err)
+	}
+	if _, err := blogsRepo.Transition(ctxTimeout, 999, entities.BlogPublished, "", "editor", ""); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("TestBlogsWorkflowSqlite: transition none existent blog should return sql.ErrNoRows, got %v", err)
+	}
+
+	if _, err := blogsRepo.Transition(ctxTimeout, blog.ID, entities.BlogInReview, "", "writer", "please review"); err != nil {
+		t.Fatalf("TestBlogsWorkflowSqlite: transition to in_review failed: %s", err)
+	}
+
+	// a transition checked against the status before in_review doesn't apply
+	tx, err := dbConn.BeginTx(ctxTimeout, &sql.TxOptions{})
+	if err != nil {
+		t.Fatalf("TestBlogsWorkflowSqlite: begin transaction failed: %s", err)
+	}
+	if _, err := sqlite.NewBlogs().UpdateStatus(ctxTimeout, tx, blog.ID, entities.BlogDraft, entities.BlogArchived, ""); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("TestBlogsWorkflowSqlite: update from a stale status should return sql.ErrNoRows, got %v", err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("TestBlogsWorkflowSqlite: rollback failed: %s", err)
+	}
+	scheduledAt := time.Now().Add(time.Hour).UTC().Format("2006-01-02T15:04:05-07:00")
+	scheduled, err := blogsRepo.Transition(ctxTimeout, blog.ID, entities.BlogScheduled, scheduledAt, "editor", "")
+	if err != nil {
+		t.Fatalf("TestBlogsWorkflowSqlite: transition to scheduled failed: %s", err)
+	}
+	if scheduled.Status != entities.BlogScheduled || scheduled.ScheduledAt != scheduledAt || scheduled.Visible {
+		t.Fatalf("TestBlogsWorkflowSqlite: unexpected scheduled blog %+v", scheduled.Blog)
+	}
+
+	// public getters only return published blogs
+	if _, err := blogsRepo.Get(ctxTimeout, blog.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("TestBlogsWorkflowSqlite: scheduled blog should not be public, got %v", err)
+	}
+
+	// not due yet
+	published, err := blogsRepo.PublishDue(ctxTimeout, time.Now())
+	if err != nil {
+		t.Fatalf("TestBlogsWorkflowSqlite: publish due failed: %s", err)
+	}
+	if published != 0 {
+		t.Fatalf("TestBlogsWorkflowSqlite: should publish nothing before scheduled time, got %d", published)
+	}
+	published, err = blogsRepo.PublishDue(ctxTimeout, time.Now().Add(2*time.Hour))
+	if err != nil {
+		t.Fatalf("TestBlogsWorkflowSqlite: publish due failed: %s", err)
+	}
+	if published != 1 {
+		t.Fatalf("TestBlogsWorkflowSqlite: should publish the scheduled blog, got %d", published)
+	}
+
+	public, err := blogsRepo.Get(ctxTimeout, blog.ID)
+	if err != nil {
+		t.Fatalf("TestBlogsWorkflowSqlite: get published blog failed: %s", err)
+	}
+	if public.Status != entities.BlogPublished || !public.Visible || public.ScheduledAt != "" {
+		t.Fatalf("TestBlogsWorkflowSqlite: unexpected published blog %+v", public.Blog)
+	}
+
+	// toggling visible still works
+	hidden := entities.NewInBlog(*entities.NewBlog("title1", "content1", "description1", false, false), []int{}, []int{})
+	hidden.Actor = "writer"
+	updated, err := blogsRepo.Update(ctxTimeout, *hidden, blog.ID, "")
+	if err != nil {
+		t.Fatalf("TestBlogsWorkflowSqlite: update failed: %s", err)
+	}
+	if updated.Status != entities.BlogDraft {
+		t.Fatalf("TestBlogsWorkflowSqlite: hiding a published blog should move it to draft, got %s", updated.Status)
+	}
+
+	events, err := blogsRepo.ListStatusEvents(ctxTimeout, blog.ID)
+	if err != nil {
+		t.Fatalf("TestBlogsWorkflowSqlite: list status events failed: %s", err)
+	}
+	want := [][]string{
+		{"", entities.BlogDraft, "writer"},
+		{entities.BlogDraft, entities.BlogInReview, "writer"},
+		{entities.BlogInReview, entities.BlogScheduled, "editor"},
+		{entities.BlogScheduled, entities.BlogPublished, "scheduler"},
+		{entities.BlogPublished, entities.BlogDraft, "writer"},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("TestBlogsWorkflowSqlite: should have %d status events, got %+v", len(want), events)
+	}
+	for i, event := range events {
+		if event.From != want[i][0] || event.To != want[i][1] || event.Actor != want[i][2] {
+			t.Fatalf("TestBlogsWorkflowSqlite: unexpected status event %d %+v", i, event)
+		}
+	}
+
+	if _, err := blogsRepo.ListStatusEvents(ctxTimeout, 999); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("TestBlogsWorkflowSqlite: list status events of none existent blog should return sql.ErrNoRows, got %v", err)
+	}
+}
+
+func TestBlogsPublishDueSqlite(t *testing.T) {
+	// connect
+	dbConn, err := sql.Open("sqlite3", "file:test.db?mode=memory&_foreign_keys=on")
+	if err != nil {
+		t.Fatalf("TestBlogsPublishDueSqlite: open db connection failed: %s", err)
+	}
+	defer dbConn.Close()
+
+	// migrate db
+	if err := db.Up(dbConn, db.EmbedMigrationsSQLite, "sqlite3", "migrations/sqlite"); err != nil {
+		t.Fatalf("TestBlogsPublishDueSqlite: migrate up failed: %s", err)
+	}
+
+	// setup repo
+	blogsRepo, _, _ := prepareRepos(dbConn)
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	scheduledAt := time.Now().Add(time.Hour).UTC().Format("2006-01-02T15:04:05-07:00")
+	ids := []int{}
+	for _, title := range []string{"title1", "title2"} {
+		draft := entities.NewInBlog(*entities.NewBlog(title, "content", "description", false, false), []int{}, []int{})
+		blog, err := blogsRepo.Create(ctxTimeout, *draft)
+		if err != nil {
+			t.Fatalf("TestBlogsPublishDueSqlite: create failed: %s", err)
+		}
+		if _, err := blogsRepo.Transition(ctxTimeout, blog.ID, entities.BlogScheduled, scheduledAt, "editor", ""); err != nil {
+			t.Fatalf("TestBlogsPublishDueSqlite: transition to scheduled failed: %s", err)
+		}
+		ids = append(ids, blog.ID)
+	}
+
+	// the first due blog can't be published
+	failFirst := fmt.Sprintf(`
+	CREATE TRIGGER fail_publish BEFORE UPDATE OF status ON blogs
+	WHEN NEW.id = %d
+	BEGIN
+		SELECT RAISE(ABORT, 'publish failed');
+	END;`, ids[0])
+	if _, err := dbConn.ExecContext(ctxTimeout, failFirst); err != nil {
+		t.Fatalf("TestBlogsPublishDueSqlite: create trigger failed: %s", err)
+	}
+
+	published, err := blogsRepo.PublishDue(ctxTimeout, time.Now().Add(2*time.Hour))
+	if err == nil || !strings.Contains(err.Error(), fmt.Sprintf("blog %d", ids[0])) {
+		t.Fatalf("TestBlogsPublishDueSqlite: should return the error of the first blog, got %v", err)
+	}
+	if published != 1 {
+		t.Fatalf("TestBlogsPublishDueSqlite: should still publish the second blog, got %d", published)
+	}
+	if blog, err := blogsRepo.AdminGet(ctxTimeout, ids[1]); err != nil || blog.Status != entities.BlogPublished {
+		t.Fatalf("TestBlogsPublishDueSqlite: second blog should be published, got %+v %v", blog.Blog, err)
+	}
+	if blog, err := blogsRepo.AdminGet(ctxTimeout, ids[0]); err != nil || blog.Status != entities.BlogScheduled {
+		t.Fatalf("TestBlogsPublishDueSqlite: first blog should stay scheduled, got %+v %v", blog.Blog, err)
+	}
+}
+
+func TestBlogsTrashSqlite(t *testing.T) {
+	// connect
+	dbConn, err := sql.Open("sqlite3", "file:test.db?mode=memory&_foreign_keys=on")
+	if err != nil {
+		t.Fatalf("TestBlogsTrashSqlite: open db connection failed: %s", err)
+	}
+	defer dbConn.Close()
+
+	// migrate db
+	if err := db.Up(dbConn, db.EmbedMigrationsSQLite, "sqlite3", "migrations/sqlite"); err != nil {
+		t.Fatalf("TestBlogsTrashSqlite: migrate up failed: %s", err)
+	}
+
+	// setup repo
+	blogsRepo, tagsRepo, topicsRepo := prepareRepos(dbConn)
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	topicsRepo.Create(ctxTimeout, *entities.NewTopic("topic1", "topic1"))
+	tagsRepo.Create(ctxTimeout, *entities.NewTag("tag1", "tag1"))
+	for i := 1; i <= 2; i++ {
+		inBlog := entities.NewInBlog(*entities.NewBlog(fmt.Sprintf("title%d", i), "content", "description", false, true), []int{1}, []int{1})
+		if _, err := blogsRepo.Create(ctxTimeout, *inBlog); err != nil {
+			t.Fatalf("TestBlogsTrashSqlite: create failed: %s", err)
+		}
+	}
+
+	if _, err := blogsRepo.SoftDelete(ctxTimeout, 1, "admin", ""); err != nil {
+		t.Fatalf("TestBlogsTrashSqlite: soft delete failed: %s", err)
+	}
+
+	trash, err := blogsRepo.ListDeleted(ctxTimeout)
+	if err != nil {
+		t.Fatalf("TestBlogsTrashSqlite: list deleted failed: %s", err)
+	}
+	if len(trash) != 1 || trash[0].ID != 1 || trash[0].Deleted_by != "admin" || trash[0].Deleted_at == "" || len(trash[0].Tags) != 1 {
+		t.Fatalf("TestBlogsTrashSqlite: unexpected trash %+v", trash)
+	}
+
+	// not old enough
+	purged, err := blogsRepo.PurgeDeleted(ctxTimeout, time.Now().Add(-time.Hour), false)
+	if err != nil {
+		t.Fatalf("TestBlogsTrashSqlite: purge deleted failed: %s", err)
+	}
+	if len(purged) != 0 {
+		t.Fatalf("TestBlogsTrashSqlite: blogs deleted within retention should be kept, got %+v", purged)
+	}
+
+	// dry run
+	purged, err = blogsRepo.PurgeDeleted(ctxTimeout, time.Now().Add(time.Minute), true)
+	if err != nil {
+		t.Fatalf("TestBlogsTrashSqlite: purge deleted dry run failed: %s", err)
+	}
+	if len(purged) != 1 || purged[0].ID != 1 {
+		t.Fatalf("TestBlogsTrashSqlite: dry run should list the deleted blog, got %+v", purged)
+	}
+	if _, err := blogsRepo.AdminGet(ctxTimeout, 1); err != nil {
+		t.Fatalf("TestBlogsTrashSqlite: dry run should not delete, got %s", err)
+	}
+
+	purged, err = blogsRepo.PurgeDeleted(ctxTimeout, time.Now().Add(time.Minute), false)
+	if err != nil {
+		t.Fatalf("TestBlogsTrashSqlite: purge deleted failed: %s", err)
+	}
+	if len(purged) != 1 || purged[0].ID != 1 {
+		t.Fatalf("TestBlogsTrashSqlite: should purge the deleted blog, got %+v", purged)
+	}
+	if _, err := blogsRepo.AdminGet(ctxTimeout, 1); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("TestBlogsTrashSqlite: purged blog should be gone, got %v", err)
+	}
+
+	// relations of the purged blog are removed, the other blog is untouched
+	var relations int
+	if err := dbConn.QueryRow("SELECT (SELECT COUNT(*) FROM blog_tags WHERE blog_id = 1) + (SELECT COUNT(*) FROM blog_topics WHERE blog_id = 1)").Scan(&relations); err != nil {
+		t.Fatalf("TestBlogsTrashSqlite: count relations failed: %s", err)
+	}
+	if relations != 0 {
+		t.Fatalf("TestBlogsTrashSqlite: relations of purged blog should be removed, got %d", relations)
+	}
+	if blog, err := blogsRepo.Get(ctxTimeout, 2); err != nil || len(blog.Tags) != 1 {
+		t.Fatalf("TestBlogsTrashSqlite: other blog should be kept, got %+v %v", blog, err)
+	}
+
+	// restoring clears who deleted it
+	if _, err := blogsRepo.SoftDelete(ctxTimeout, 2, "admin", ""); err != nil {
+		t.Fatalf("TestBlogsTrashSqlite: soft delete failed: %s", err)
+	}
+	restored, err := blogsRepo.RestoreDeleted(ctxTimeout, 2)
+	if err != nil {
+		t.Fatalf("TestBlogsTrashSqlite: restore failed: %s", err)
+	}
+	if restored.Deleted_at != "" || restored.Deleted_by != "" {
+		t.Fatalf("TestBlogsTrashSqlite: restored blog should not keep deletion info, got %+v", restored.Blog)
+	}
+}
+
+// records published events as json, like the events stream sends them
+type recordingPublisher struct {
+	events []string
+}
+
+func (r *recordingPublisher) Publish(eventType string, data any) {
+	raw, _ := json.Marshal(data)
+	r.events = append(r.events, eventType+" "+string(raw))
+}
+
+func TestBlogsEventStreamSqlite(t *testing.T) {
+	// connect
+	dbConn, err := sql.Open("sqlite3", "file:test.db?mode=memory&_foreign_keys=on")
+	if err != nil {
+		t.Fatalf("TestBlogsEventStreamSqlite: open db connection failed: %s", err)
+	}
+	defer dbConn.Close()
+
+	// migrate db
+	if err := db.Up(dbConn, db.EmbedMigrationsSQLite, "sqlite3", "migrations/sqlite"); err != nil {
+		t.Fatalf("TestBlogsEventStreamSqlite: migrate up failed: %s", err)
+	}
+
+	// setup repo
+	publisher := &recordingPublisher{}
+	blogsRepo, _, _ := preparePublishingRepos(dbConn, publisher)
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	// a draft through the workflow and the trash, its title is never public
+	draft, err := blogsRepo.Create(ctxTimeout, *entities.NewInBlog(*entities.NewBlog("secret draft", "content", "secret description", false, false), nil, nil))
+	if err != nil {
+		t.Fatalf("TestBlogsEventStreamSqlite: create draft failed: %s", err)
+	}
+	title := "secret draft renamed"
+	if _, err := blogsRepo.Patch(ctxTimeout, entities.BlogPatch{Title: &title}, draft.ID, ""); err != nil {
+		t.Fatalf("TestBlogsEventStreamSqlite: patch draft failed: %s", err)
+	}
+	if _, err := blogsRepo.Transition(ctxTimeout, draft.ID, entities.BlogInReview, "", "alex", ""); err != nil {
+		t.Fatalf("TestBlogsEventStreamSqlite: transition draft failed: %s", err)
+	}
+	if _, err := blogsRepo.SoftDelete(ctxTimeout, draft.ID, "alex", ""); err != nil {
+		t.Fatalf("TestBlogsEventStreamSqlite: soft delete draft failed: %s", err)
+	}
+	if _, err := blogsRepo.RestoreDeleted(ctxTimeout, draft.ID); err != nil {
+		t.Fatalf("TestBlogsEventStreamSqlite: restore draft failed: %s", err)
+	}
+	if len(publisher.events) != 5 {
+		t.Fatalf("TestBlogsEventStreamSqlite: expected 5 events, got %v", publisher.events)
+	}
+	for _, event := range publisher.events {
+		if strings.Contains(event, "secret") {
+			t.Fatalf("TestBlogsEventStreamSqlite: unpublished blog leaked to the stream: %s", event)
+		}
+	}
+
+	// published blogs are sent as they are
+	if _, err := blogsRepo.Create(ctxTimeout, *entities.NewInBlog(*entities.NewBlog("public", "content", "public description", false, true), nil, nil)); err != nil {
+		t.Fatalf("TestBlogsEventStreamSqlite: create published failed: %s", err)
+	}
+	if last := publisher.events[len(publisher.events)-1]; !strings.Contains(last, `"title":"public"`) {
+		t.Fatalf("TestBlogsEventStreamSqlite: published blog should be sent with its fields, got %s", last)
+	}
+}
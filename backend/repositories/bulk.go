@@ -0,0 +1,362 @@
+package repositories
+
+import (
+	"blog/config"
+	"blog/db/models/interfaces"
+	"blog/entities"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type BulkRepoModels struct {
+	blog         interfaces.BlogsModel
+	blogTags     interfaces.BlogTagsModel
+	blogTopics   interfaces.BlogTopicsModel
+	blogMedia    interfaces.BlogMediaModel
+	blogSeries   interfaces.BlogSeriesModel
+	statusEvents interfaces.BlogStatusEventsModel
+	tags         interfaces.TagsModel
+	topics       interfaces.TopicsModel
+	series       interfaces.SeriesModel
+	outbox       interfaces.WebhookOutboxModel
+	savepoints   interfaces.SavepointsModel
+}
+
+func NewBulkRepoModels(
+	blog interfaces.BlogsModel,
+	blogTags interfaces.BlogTagsModel,
+	blogTopics interfaces.BlogTopicsModel,
+	blogMedia interfaces.BlogMediaModel,
+	blogSeries interfaces.BlogSeriesModel,
+	statusEvents interfaces.BlogStatusEventsModel,
+	tags interfaces.TagsModel,
+	topics interfaces.TopicsModel,
+	series interfaces.SeriesModel,
+	outbox interfaces.WebhookOutboxModel,
+	savepoints interfaces.SavepointsModel,
+) *BulkRepoModels {
+
+	return &BulkRepoModels{
+		blog:         blog,
+		blogTags:     blogTags,
+		blogTopics:   blogTopics,
+		blogMedia:    blogMedia,
+		blogSeries:   blogSeries,
+		statusEvents: statusEvents,
+		tags:         tags,
+		topics:       topics,
+		series:       series,
+		outbox:       outbox,
+		savepoints:   savepoints,
+	}
+}
+
+type Bulk struct {
+	db        *sql.DB
+	config    config.DBSetting
+	models    BulkRepoModels
+	publisher Publisher
+}
+
+func NewBulk(db *sql.DB, config config.DBSetting, models BulkRepoModels, publisher Publisher) *Bulk {
+	return &Bulk{
+		db:        db,
+		config:    config,
+		models:    models,
+		publisher: publisher,
+	}
+}
+
+// savepoint around each operation when failed operations are skipped
+const bulkSavepoint = "bulk_operation"
+
+// state read in the transaction just before the operation, see Bulk.prepare
+type bulkPrepared struct {
+	version entities.Version
+	err     error
+}
+
+// event of an applied operation, published after commit
+type bulkEvent struct {
+	eventType string
+	data      any
+}
+
+/*
+Apply runs the operations in order in one transaction, the bulk should be validated first.
+
+In atomic mode the first failed operation rolls back all of them, otherwise failed operations
+are rolled back alone and the others are committed. Failures of operations are reported in the
+results, the error is only returned when the transaction itself failed.
+*/
+func (b *Bulk) Apply(ctx context.Context, bulk entities.Bulk) (*entities.OutBulk, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(b.config.Timeout)*time.Second)
+	defer cancel()
+
+	out := entities.NewOutBulk(bulk.Operations)
+
+	tx, err := b.db.BeginTx(ctxTimeout, &sql.TxOptions{})
+	if err != nil {
+		return &entities.OutBulk{}, fmt.Errorf("Apply: begin transaction failed: %w", err)
+	}
+
+	// ref -> id of created targets
+	refs := map[string]int{}
+	events := []bulkEvent{}
+	for i, op := range bulk.Operations {
+		if !bulk.Atomic {
+			if err := b.models.savepoints.Create(ctxTimeout, tx, bulkSavepoint); err != nil {
+				if err := tx.Rollback(); err != nil {
+					return &entities.OutBulk{}, fmt.Errorf("Apply: model create savepoint rollback failed: %w", err)
+				}
+				return &entities.OutBulk{}, fmt.Errorf("Apply: model create savepoint failed: %w", err)
+			}
+		}
+
+		// read after the savepoint, earlier operations may have changed the target
+		prepared := b.prepare(ctxTimeout, tx, op)
+		id, opEvents, err := b.apply(ctxTimeout, tx, op, prepared, refs, bulk.Actor)
+		if err != nil {
+			out.Results[i].Status = entities.BulkFailed
+			out.Results[i].Error = err.Error()
+			out.Failed++
+
+			if bulk.Atomic {
+				if err := tx.Rollback(); err != nil {
+					return &entities.OutBulk{}, fmt.Errorf("Apply: operation %d failed, rollback failed: %w", i, err)
+				}
+				// ids of rolled back creates don't exist
+				for j := range i {
+					out.Results[j].Status = entities.BulkRolledBack
+					out.Results[j].ID = bulk.Operations[j].ID
+				}
+				return out, nil
+			}
+
+			if err := b.models.savepoints.RollbackTo(ctxTimeout, tx, bulkSavepoint); err != nil {
+				if err := tx.Rollback(); err != nil {
+					return &entities.OutBulk{}, fmt.Errorf("Apply: model rollback to savepoint rollback failed: %w", err)
+				}
+				return &entities.OutBulk{}, fmt.Errorf("Apply: model rollback to savepoint failed: %w", err)
+			}
+			continue
+		}
+
+		if !bulk.Atomic {
+			if err := b.models.savepoints.Release(ctxTimeout, tx, bulkSavepoint); err != nil {
+				if err := tx.Rollback(); err != nil {
+					return &entities.OutBulk{}, fmt.Errorf("Apply: model release savepoint rollback failed: %w", err)
+				}
+				return &entities.OutBulk{}, fmt.Errorf("Apply: model release savepoint failed: %w", err)
+			}
+		}
+
+		out.Results[i].ID = id
+		out.Results[i].Status = entities.BulkOK
+		if op.Ref != "" {
+			refs[op.Ref] = id
+		}
+		events = append(events, opEvents...)
+	}
+
+	// nothing to commit
+	if out.Failed == len(bulk.Operations) {
+		if err := tx.Rollback(); err != nil {
+			return &entities.OutBulk{}, fmt.Errorf("Apply: rollback failed: %w", err)
+		}
+		return out, nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return &entities.OutBulk{}, fmt.Errorf("Apply: commit failed: %w", err)
+	}
+	out.Committed = true
+
+	for _, event := range events {
+		publish(b.publisher, event.eventType, event.data)
+	}
+
+	return out, nil
+}
+
+// Reads the version of tags and topics to write against in tx, so changes of earlier operations are seen.
+// Blogs are read by blogWriter.
+func (b *Bulk) prepare(ctx context.Context, tx *sql.Tx, op entities.BulkOperation) bulkPrepared {
+	switch {
+	case op.Type == entities.BulkTag && op.IfMatch != "":
+		current, err := b.models.tags.GetInTx(ctx, tx, op.ID)
+		if err != nil {
+			return bulkPrepared{err: fmt.Errorf("prepare: model get tag failed: %w", err)}
+		}
+		version, err := expectedVersion(op.IfMatch, current.Version)
+		if err != nil {
+			return bulkPrepared{err: fmt.Errorf("prepare: %w", err)}
+		}
+		return bulkPrepared{version: version}
+
+	case op.Type == entities.BulkTopic && op.IfMatch != "":
+		current, err := b.models.topics.GetInTx(ctx, tx, op.ID)
+		if err != nil {
+			return bulkPrepared{err: fmt.Errorf("prepare: model get topic failed: %w", err)}
+		}
+		version, err := expectedVersion(op.IfMatch, current.Version)
+		if err != nil {
+			return bulkPrepared{err: fmt.Errorf("prepare: %w", err)}
+		}
+		return bulkPrepared{version: version}
+	}
+
+	return bulkPrepared{}
+}
+
+// Applies one operation in tx, returns the id of the target and its events
+func (b *Bulk) apply(ctx context.Context, tx *sql.Tx, op entities.BulkOperation, prepared bulkPrepared, refs map[string]int, actor string) (int, []bulkEvent, error) {
+	if prepared.err != nil {
+		return 0, nil, prepared.err
+	}
+
+	switch op.Type + " " + op.Op {
+	case entities.BulkTopic + " " + entities.BulkCreate:
+		newTopic, err := b.models.topics.Create(ctx, tx, *op.Topic)
+		if err != nil {
+			return 0, nil, fmt.Errorf("apply: model create topic failed: %w", err)
+		}
+		return newTopic.ID, []bulkEvent{{entities.EventTopicCreated, *newTopic}}, nil
+
+	case entities.BulkTopic + " " + entities.BulkUpdate:
+		newTopic, err := b.models.topics.Update(ctx, tx, *op.Topic, op.ID, prepared.version)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) && !prepared.version.IsZero() {
+				return 0, nil, fmt.Errorf("apply: %w", entities.ErrorPreconditionFailed)
+			}
+			return 0, nil, fmt.Errorf("apply: model update topic failed: %w", err)
+		}
+		return newTopic.ID, []bulkEvent{{entities.EventTopicUpdated, *newTopic}}, nil
+
+	case entities.BulkTopic + " " + entities.BulkDelete:
+		affectedRows, err := b.models.topics.Delete(ctx, tx, op.ID, prepared.version)
+		if err != nil {
+			return 0, nil, fmt.Errorf("apply: model delete topic failed: %w", err)
+		}
+		if err := bulkDeleted(affectedRows, prepared.version); err != nil {
+			return 0, nil, fmt.Errorf("apply: delete topic: %w", err)
+		}
+		return op.ID, []bulkEvent{{entities.EventTopicDeleted, entities.DeletedResource{ID: op.ID}}}, nil
+
+	case entities.BulkTag + " " + entities.BulkCreate:
+		newTag, err := b.models.tags.Create(ctx, tx, *op.Tag)
+		if err != nil {
+			return 0, nil, fmt.Errorf("apply: model create tag failed: %w", err)
+		}
+		return newTag.ID, []bulkEvent{{entities.EventTagCreated, *newTag}}, nil
+
+	case entities.BulkTag + " " + entities.BulkUpdate:
+		newTag, err := b.models.tags.Update(ctx, tx, *op.Tag, op.ID, prepared.version)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) && !prepared.version.IsZero() {
+				return 0, nil, fmt.Errorf("apply: %w", entities.ErrorPreconditionFailed)
+			}
+			return 0, nil, fmt.Errorf("apply: model update tag failed: %w", err)
+		}
+		return newTag.ID, []bulkEvent{{entities.EventTagUpdated, *newTag}}, nil
+
+	case entities.BulkTag + " " + entities.BulkDelete:
+		affectedRows, err := b.models.tags.Delete(ctx, tx, op.ID, prepared.version)
+		if err != nil {
+			return 0, nil, fmt.Errorf("apply: model delete tag failed: %w", err)
+		}
+		if err := bulkDeleted(affectedRows, prepared.version); err != nil {
+			return 0, nil, fmt.Errorf("apply: delete tag: %w", err)
+		}
+		return op.ID, []bulkEvent{{entities.EventTagDeleted, entities.DeletedResource{ID: op.ID}}}, nil
+
+	case entities.BulkBlog + " " + entities.BulkCreate:
+		blog, err := resolveBulkRefs(*op.Blog, op, refs)
+		if err != nil {
+			return 0, nil, fmt.Errorf("apply: %w", err)
+		}
+		blog.Actor = actor
+		newBlog, err := b.writer().create(ctx, tx, blog, 0)
+		if err != nil {
+			return 0, nil, fmt.Errorf("apply: %w", err)
+		}
+		return newBlog.ID, []bulkEvent{{entities.EventBlogCreated, blogStreamData(*newBlog)}}, nil
+
+	case entities.BulkBlog + " " + entities.BulkUpdate:
+		blog, err := resolveBulkRefs(*op.Blog, op, refs)
+		if err != nil {
+			return 0, nil, fmt.Errorf("apply: %w", err)
+		}
+		blog.Actor = actor
+		newBlog, err := b.writer().update(ctx, tx, blog, op.ID, op.IfMatch)
+		if err != nil {
+			return 0, nil, fmt.Errorf("apply: %w", err)
+		}
+		return newBlog.ID, []bulkEvent{{entities.EventBlogUpdated, blogStreamData(*newBlog)}}, nil
+
+	case entities.BulkBlog + " " + entities.BulkDelete:
+		affectedRows, err := b.writer().softDelete(ctx, tx, op.ID, actor, op.IfMatch)
+		if err != nil {
+			return 0, nil, fmt.Errorf("apply: %w", err)
+		}
+		if err := bulkDeleted(affectedRows, 0); err != nil {
+			return 0, nil, fmt.Errorf("apply: soft delete blog: %w", err)
+		}
+		return op.ID, []bulkEvent{{entities.EventBlogDeleted, entities.DeletedResource{ID: op.ID}}}, nil
+	}
+
+	return 0, nil, fmt.Errorf("apply: %w: %s %s", entities.ErrorBulkInvalidOperation, op.Op, op.Type)
+}
+
+func (b *Bulk) writer() blogWriter {
+	return blogWriter{
+		blog:         b.models.blog,
+		blogTags:     b.models.blogTags,
+		blogTopics:   b.models.blogTopics,
+		blogMedia:    b.models.blogMedia,
+		blogSeries:   b.models.blogSeries,
+		statusEvents: b.models.statusEvents,
+		tags:         b.models.tags,
+		topics:       b.models.topics,
+		series:       b.models.series,
+		outbox:       b.models.outbox,
+	}
+}
+
+// Adds ids of tags and topics created earlier in the request
+func resolveBulkRefs(blog entities.InBlog, op entities.BulkOperation, refs map[string]int) (entities.InBlog, error) {
+	tags := append([]int{}, blog.Tags...)
+	for _, ref := range op.TagRefs {
+		id, ok := refs[ref]
+		if !ok {
+			return blog, fmt.Errorf("resolveBulkRefs: tag %q: %w", ref, entities.ErrorBulkUnresolvedRef)
+		}
+		tags = append(tags, id)
+	}
+
+	topics := append([]int{}, blog.Topics...)
+	for _, ref := range op.TopicRefs {
+		id, ok := refs[ref]
+		if !ok {
+			return blog, fmt.Errorf("resolveBulkRefs: topic %q: %w", ref, entities.ErrorBulkUnresolvedRef)
+		}
+		topics = append(topics, id)
+	}
+
+	blog.Tags, blog.Topics = tags, topics
+	return blog, nil
+}
+
+// Deletes of missing targets fail, unlike single deletes reporting 0 affected rows
+func bulkDeleted(affectedRows int, version entities.Version) error {
+	if affectedRows > 0 {
+		return nil
+	}
+	if !version.IsZero() {
+		return entities.ErrorPreconditionFailed
+	}
+	return sql.ErrNoRows
+}
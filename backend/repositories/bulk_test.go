@@ -0,0 +1,222 @@
+package repositories_test
+
+import (
+	"blog/config"
+	"blog/db"
+	"blog/db/models/sqlite"
+	"blog/entities"
+	"blog/repositories"
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func prepareBulkRepo(dbConn *sql.DB) *repositories.Bulk {
+	bulkRepoModels := repositories.NewBulkRepoModels(
+		sqlite.NewBlogs(),
+		sqlite.NewBlogTags(),
+		sqlite.NewBlogTopics(),
+		sqlite.NewBlogMedia(),
+		sqlite.NewBlogSeries(),
+		sqlite.NewBlogStatusEvents(),
+		sqlite.NewTags(),
+		sqlite.NewTopics(),
+		sqlite.NewSeries(),
+		sqlite.NewWebhookOutbox(),
+		sqlite.NewSavepoints(),
+	)
+	return repositories.NewBulk(dbConn, config.NewConfig().DB, *bulkRepoModels, nil)
+}
+
+func TestBulkSqlite(t *testing.T) {
+	// connect
+	dbConn, err := sql.Open("sqlite3", "file:test.db?mode=memory&_foreign_keys=on")
+	if err != nil {
+		t.Fatalf("TestBulkSqlite: open db connection failed: %s", err)
+	}
+	defer dbConn.Close()
+
+	// migrate db
+	if err := db.Up(dbConn, db.EmbedMigrationsSQLite, "sqlite3", "migrations/sqlite"); err != nil {
+		t.Fatalf("TestBulkSqlite: migrate up failed: %s", err)
+	}
+
+	// setup repo
+	blogsRepo, tagsRepo, topicsRepo := prepareRepos(dbConn)
+	bulkRepo := prepareBulkRepo(dbConn)
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	oldTag, _ := tagsRepo.Create(ctxTimeout, *entities.NewTag("old", "old"))
+
+	blog := func(title string, tags, topics []int) *entities.InBlog {
+		return entities.NewInBlog(*entities.NewBlog(title, "content of "+title, title, false, true), tags, topics)
+	}
+
+	// new tag and topic referenced by a new blog
+	bulk := entities.Bulk{
+		Atomic: true,
+		Operations: []entities.BulkOperation{
+			{Op: entities.BulkCreate, Type: entities.BulkTopic, Ref: "go", Topic: entities.NewTopic("go", "go")},
+			{Op: entities.BulkCreate, Type: entities.BulkTag, Ref: "tips", Tag: entities.NewTag("tips", "tips")},
+			{Op: entities.BulkUpdate, Type: entities.BulkTag, ID: oldTag.ID, Tag: entities.NewTag("older", "older")},
+			{Op: entities.BulkCreate, Type: entities.BulkBlog, Ref: "b1", TagRefs: []string{"tips"}, TopicRefs: []string{"go"}, Blog: blog("blog1", []int{oldTag.ID}, nil)},
+		},
+		Actor: "alex",
+	}
+	out, err := bulkRepo.Apply(ctxTimeout, bulk)
+	if err != nil {
+		t.Fatalf("TestBulkSqlite: apply failed: %s", err)
+	}
+	if !out.Committed || out.Failed != 0 {
+		t.Fatalf("TestBulkSqlite: should be committed, got %+v", out)
+	}
+	for _, result := range out.Results {
+		if result.Status != entities.BulkOK || result.ID == 0 {
+			t.Fatalf("TestBulkSqlite: unexpected result %+v", result)
+		}
+	}
+	created, err := blogsRepo.AdminGet(ctxTimeout, out.Results[3].ID)
+	if err != nil {
+		t.Fatalf("TestBulkSqlite: get created blog failed: %s", err)
+	}
+	if len(created.Tags) != 2 || len(created.Topics) != 1 || created.Topics[0].ID != out.Results[0].ID {
+		t.Fatalf("TestBulkSqlite: created blog should link tags and topics by ref, got %+v %+v", created.Tags, created.Topics)
+	}
+	if updated, _ := tagsRepo.Get(ctxTimeout, oldTag.ID); updated.Name != "older" {
+		t.Fatalf("TestBulkSqlite: tag should be updated, got %s", updated.Name)
+	}
+
+	// atomic, nothing is applied when one fails
+	bulk = entities.Bulk{
+		Atomic: true,
+		Operations: []entities.BulkOperation{
+			{Op: entities.BulkCreate, Type: entities.BulkTag, Tag: entities.NewTag("new", "new")},
+			{Op: entities.BulkCreate, Type: entities.BulkTag, Tag: entities.NewTag("tips", "duplicate")},
+			{Op: entities.BulkDelete, Type: entities.BulkTag, ID: oldTag.ID},
+		},
+	}
+	out, err = bulkRepo.Apply(ctxTimeout, bulk)
+	if err != nil {
+		t.Fatalf("TestBulkSqlite: apply atomic failed: %s", err)
+	}
+	if out.Committed || out.Failed != 1 {
+		t.Fatalf("TestBulkSqlite: atomic should not be committed, got %+v", out)
+	}
+	statuses := []string{entities.BulkRolledBack, entities.BulkFailed, entities.BulkSkipped}
+	for i, result := range out.Results {
+		if result.Status != statuses[i] {
+			t.Fatalf("TestBulkSqlite: atomic result %d should be %s, got %+v", i, statuses[i], result)
+		}
+	}
+	if out.Results[0].ID != 0 {
+		t.Fatalf("TestBulkSqlite: rolled back create should not have an id, got %d", out.Results[0].ID)
+	}
+	if tags, _ := tagsRepo.List(ctxTimeout); len(tags) != 2 {
+		t.Fatalf("TestBulkSqlite: atomic should not create or delete tags, got %d tags", len(tags))
+	}
+
+	// not atomic, failed operations are skipped
+	bulk = entities.Bulk{
+		Operations: []entities.BulkOperation{
+			{Op: entities.BulkCreate, Type: entities.BulkTag, Ref: "dup", Tag: entities.NewTag("tips", "duplicate")},
+			{Op: entities.BulkCreate, Type: entities.BulkBlog, TagRefs: []string{"dup"}, Blog: blog("blog2", nil, nil)},
+			{Op: entities.BulkCreate, Type: entities.BulkBlog, Blog: blog("blog3", nil, nil)},
+			{Op: entities.BulkUpdate, Type: entities.BulkTopic, ID: 1, IfMatch: `"stale"`, Topic: entities.NewTopic("golang", "")},
+			{Op: entities.BulkDelete, Type: entities.BulkBlog, ID: created.ID},
+			{Op: entities.BulkDelete, Type: entities.BulkBlog, ID: 999},
+		},
+		Actor: "alex",
+	}
+	out, err = bulkRepo.Apply(ctxTimeout, bulk)
+	if err != nil {
+		t.Fatalf("TestBulkSqlite: apply not atomic failed: %s", err)
+	}
+	if !out.Committed || out.Failed != 4 {
+		t.Fatalf("TestBulkSqlite: should be committed with 4 failures, got %+v", out)
+	}
+	statuses = []string{entities.BulkFailed, entities.BulkFailed, entities.BulkOK, entities.BulkFailed, entities.BulkOK, entities.BulkFailed}
+	for i, result := range out.Results {
+		if result.Status != statuses[i] {
+			t.Fatalf("TestBulkSqlite: result %d should be %s, got %+v", i, statuses[i], result)
+		}
+	}
+	if _, err := blogsRepo.AdminGet(ctxTimeout, out.Results[2].ID); err != nil {
+		t.Fatalf("TestBulkSqlite: blog3 should be created: %s", err)
+	}
+	if deleted, _ := blogsRepo.AdminGet(ctxTimeout, created.ID); deleted.Deleted_at == "" || deleted.Deleted_by != "alex" {
+		t.Fatalf("TestBulkSqlite: blog1 should be soft deleted by alex, got %+v", deleted.Blog)
+	}
+	if topic, _ := topicsRepo.Get(ctxTimeout, 1); topic.Name != "go" {
+		t.Fatalf("TestBulkSqlite: stale If-Match should not update the topic, got %s", topic.Name)
+	}
+
+	// operations see the changes of earlier operations on the same target
+	blog3ID := out.Results[2].ID
+	hidden := entities.NewInBlog(*entities.NewBlog("blog3", "content of blog3", "blog3", false, false), nil, nil)
+	bulk = entities.Bulk{
+		Operations: []entities.BulkOperation{
+			{Op: entities.BulkUpdate, Type: entities.BulkBlog, ID: blog3ID, Blog: hidden},
+			{Op: entities.BulkUpdate, Type: entities.BulkBlog, ID: blog3ID, Blog: blog("blog3", nil, nil)},
+		},
+		Actor: "alex",
+	}
+	out, err = bulkRepo.Apply(ctxTimeout, bulk)
+	if err != nil {
+		t.Fatalf("TestBulkSqlite: apply updates of the same blog failed: %s", err)
+	}
+	if !out.Committed || out.Failed != 0 {
+		t.Fatalf("TestBulkSqlite: updates of the same blog should be committed, got %+v", out)
+	}
+	events, err := sqlite.NewBlogStatusEvents().ListByBlogID(ctxTimeout, dbConn, blog3ID)
+	if err != nil {
+		t.Fatalf("TestBulkSqlite: list status events failed: %s", err)
+	}
+	if len(events) != 3 || events[2].From != entities.BlogDraft || events[2].To != entities.BlogPublished {
+		t.Fatalf("TestBulkSqlite: second update should be made against the draft, got %+v", events)
+	}
+
+	// ifMatch is compared with the same etag as single updates
+	current, err := blogsRepo.AdminGet(ctxTimeout, blog3ID)
+	if err != nil {
+		t.Fatalf("TestBulkSqlite: get blog3 failed: %s", err)
+	}
+	bulk = entities.Bulk{
+		Operations: []entities.BulkOperation{
+			{Op: entities.BulkUpdate, Type: entities.BulkBlog, ID: blog3ID, IfMatch: current.Version.ETag(), Blog: blog("blog3", nil, nil)},
+			{Op: entities.BulkUpdate, Type: entities.BulkBlog, ID: blog3ID, IfMatch: current.ETag(), Blog: blog("blog3 updated", nil, nil)},
+			{Op: entities.BulkDelete, Type: entities.BulkBlog, ID: blog3ID, IfMatch: current.ETag()},
+		},
+		Actor: "alex",
+	}
+	out, err = bulkRepo.Apply(ctxTimeout, bulk)
+	if err != nil {
+		t.Fatalf("TestBulkSqlite: apply with ifMatch failed: %s", err)
+	}
+	statuses = []string{entities.BulkFailed, entities.BulkOK, entities.BulkFailed}
+	for i, result := range out.Results {
+		if result.Status != statuses[i] {
+			t.Fatalf("TestBulkSqlite: ifMatch result %d should be %s, got %+v", i, statuses[i], result)
+		}
+	}
+	if updated, _ := blogsRepo.AdminGet(ctxTimeout, blog3ID); updated.Title != "blog3 updated" || updated.Deleted_at != "" {
+		t.Fatalf("TestBulkSqlite: only the update with the current etag should apply, got %+v", updated.Blog)
+	}
+
+	// every operation failed
+	bulk = entities.Bulk{
+		Operations: []entities.BulkOperation{
+			{Op: entities.BulkDelete, Type: entities.BulkTopic, ID: 999},
+		},
+	}
+	out, err = bulkRepo.Apply(ctxTimeout, bulk)
+	if err != nil {
+		t.Fatalf("TestBulkSqlite: apply failed: %s", err)
+	}
+	if out.Committed || out.Failed != 1 || out.Results[0].Error == "" {
+		t.Fatalf("TestBulkSqlite: nothing should be committed, got %+v", out)
+	}
+}
@@ -0,0 +1,163 @@
+package repositories
+
+import (
+	"blog/config"
+	"blog/db/models/interfaces"
+	"blog/entities"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type CommentsRepoModels struct {
+	blogs    interfaces.BlogsModel
+	comments interfaces.CommentsModel
+}
+
+func NewCommentsRepoModels(
+	blogs interfaces.BlogsModel,
+	comments interfaces.CommentsModel,
+) *CommentsRepoModels {
+
+	return &CommentsRepoModels{
+		blogs:    blogs,
+		comments: comments,
+	}
+}
+
+type Comments struct {
+	db     *sql.DB
+	config config.DBSetting
+	models CommentsRepoModels
+}
+
+func NewComments(db *sql.DB, config config.DBSetting, models CommentsRepoModels) *Comments {
+	return &Comments{
+		db:     db,
+		config: config,
+		models: models,
+	}
+}
+
+/*
+Readers can only comment on visible blogs that are not soft deleted.
+Replies must belong to an approved comment of the same blog.
+
+Both cases return sql.ErrNoRows when not satisfied.
+*/
+func (c *Comments) Create(ctx context.Context, comment entities.Comment) (*entities.Comment, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(c.config.Timeout)*time.Second)
+	defer cancel()
+
+	if _, err := c.models.blogs.Get(ctxTimeout, c.db, comment.BlogID); err != nil {
+		return &entities.Comment{}, fmt.Errorf("Create: model get blog failed: %w", err)
+	}
+
+	if comment.ParentID != 0 {
+		parent, err := c.models.comments.Get(ctxTimeout, c.db, comment.ParentID)
+		if err != nil {
+			return &entities.Comment{}, fmt.Errorf("Create: model get parent comment failed: %w", err)
+		}
+		if parent.BlogID != comment.BlogID || parent.Status != entities.CommentApproved {
+			return &entities.Comment{}, fmt.Errorf("Create: parent comment not approved or on another blog: %w", sql.ErrNoRows)
+		}
+	}
+
+	tx, err := c.db.BeginTx(ctxTimeout, &sql.TxOptions{})
+	if err != nil {
+		return &entities.Comment{}, fmt.Errorf("Create: begin transaction failed: %w", err)
+	}
+
+	newComment, err := c.models.comments.Create(ctxTimeout, tx, comment)
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			return &entities.Comment{}, fmt.Errorf("Create: model create comment rollback failed: %w", err)
+		}
+		return &entities.Comment{}, fmt.Errorf("Create: model create comment failed: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return &entities.Comment{}, fmt.Errorf("Create: commit failed: %w", err)
+	}
+
+	return newComment, nil
+}
+
+// Approved comments of a visible blog, returns sql.ErrNoRows if the blog is not visible
+func (c *Comments) ListApproved(ctx context.Context, blogID int) ([]entities.Comment, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(c.config.Timeout)*time.Second)
+	defer cancel()
+
+	if _, err := c.models.blogs.Get(ctxTimeout, c.db, blogID); err != nil {
+		return []entities.Comment{}, fmt.Errorf("ListApproved: model get blog failed: %w", err)
+	}
+
+	comments, err := c.models.comments.ListByBlogID(ctxTimeout, c.db, blogID, entities.CommentApproved)
+	if err != nil {
+		return []entities.Comment{}, fmt.Errorf("ListApproved: model list comments failed: %w", err)
+	}
+
+	return comments, nil
+}
+
+func (c *Comments) ListByStatus(ctx context.Context, status string) ([]entities.Comment, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(c.config.Timeout)*time.Second)
+	defer cancel()
+
+	comments, err := c.models.comments.ListByStatus(ctxTimeout, c.db, status)
+	if err != nil {
+		return []entities.Comment{}, fmt.Errorf("ListByStatus: model list comments failed: %w", err)
+	}
+
+	return comments, nil
+}
+
+func (c *Comments) UpdateStatus(ctx context.Context, id int, status string) (*entities.Comment, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(c.config.Timeout)*time.Second)
+	defer cancel()
+
+	tx, err := c.db.BeginTx(ctxTimeout, &sql.TxOptions{})
+	if err != nil {
+		return &entities.Comment{}, fmt.Errorf("UpdateStatus: begin transaction failed: %w", err)
+	}
+
+	comment, err := c.models.comments.UpdateStatus(ctxTimeout, tx, id, status)
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			return &entities.Comment{}, fmt.Errorf("UpdateStatus: model update comment rollback failed: %w", err)
+		}
+		return &entities.Comment{}, fmt.Errorf("UpdateStatus: model update comment failed: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return &entities.Comment{}, fmt.Errorf("UpdateStatus: commit failed: %w", err)
+	}
+
+	return comment, nil
+}
+
+// Replies are deleted as well, affected rows includes them
+func (c *Comments) Delete(ctx context.Context, id int) (int, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(c.config.Timeout)*time.Second)
+	defer cancel()
+
+	tx, err := c.db.BeginTx(ctxTimeout, &sql.TxOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("Delete: begin transaction failed: %w", err)
+	}
+
+	affectedRows, err := c.models.comments.Delete(ctxTimeout, tx, id)
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			return 0, fmt.Errorf("Delete: model delete comment rollback failed: %w", err)
+		}
+		return 0, fmt.Errorf("Delete: model delete comment failed: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("Delete: commit failed: %w", err)
+	}
+
+	return affectedRows, nil
+}
@@ -0,0 +1,132 @@
+package repositories_test
+
+import (
+	"blog/config"
+	"blog/db"
+	"blog/db/models/sqlite"
+	"blog/entities"
+	"blog/repositories"
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestCommentsSqlite(t *testing.T) {
+	// connect
+	dbConn, err := sql.Open("sqlite3", "file:test.db?mode=memory&_foreign_keys=on")
+	if err != nil {
+		t.Fatalf("TestCommentsSqlite: open db connection failed: %s", err)
+	}
+	defer dbConn.Close()
+
+	// migrate db
+	if err := db.Up(dbConn, db.EmbedMigrationsSQLite, "sqlite3", "migrations/sqlite"); err != nil {
+		t.Fatalf("TestCommentsSqlite: migrate up failed: %s", err)
+	}
+
+	blogsRepo, _, _ := prepareRepos(dbConn)
+	commentsRepoModels := repositories.NewCommentsRepoModels(sqlite.NewBlogs(), sqlite.NewComments())
+	commentsRepo := repositories.NewComments(dbConn, config.NewConfig().DB, *commentsRepoModels)
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	newBlog := func(title string, visible bool) *entities.OutBlog {
+		inBlog := entities.NewInBlog(*entities.NewBlog(title, "content", "desc", false, visible), []int{}, []int{})
+		blog, err := blogsRepo.Create(ctxTimeout, *inBlog)
+		if err != nil {
+			t.Fatalf("TestCommentsSqlite: create blog failed: %s", err)
+		}
+		return blog
+	}
+	blog1 := newBlog("blog 1", true)
+	blog2 := newBlog("blog 2", true)
+	hidden := newBlog("hidden", false)
+
+	// hidden blogs can't be commented on
+	if _, err := commentsRepo.Create(ctxTimeout, *entities.NewComment(hidden.ID, 0, "a", "hi")); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("TestCommentsSqlite: comment on hidden blog should return sql.ErrNoRows, got %v", err)
+	}
+
+	root, err := commentsRepo.Create(ctxTimeout, *entities.NewComment(blog1.ID, 0, "a", "root"))
+	if err != nil {
+		t.Fatalf("TestCommentsSqlite: create comment failed: %s", err)
+	}
+	if root.Status != entities.CommentPending || root.ParentID != 0 {
+		t.Fatalf("TestCommentsSqlite: new comment should be pending without parent, got %+v", root)
+	}
+
+	// can't reply to pending comments
+	if _, err := commentsRepo.Create(ctxTimeout, *entities.NewComment(blog1.ID, root.ID, "b", "reply")); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("TestCommentsSqlite: reply to pending comment should return sql.ErrNoRows, got %v", err)
+	}
+
+	if _, err := commentsRepo.UpdateStatus(ctxTimeout, root.ID, entities.CommentApproved); err != nil {
+		t.Fatalf("TestCommentsSqlite: approve failed: %s", err)
+	}
+
+	// can't reply across blogs
+	if _, err := commentsRepo.Create(ctxTimeout, *entities.NewComment(blog2.ID, root.ID, "b", "reply")); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("TestCommentsSqlite: reply on another blog should return sql.ErrNoRows, got %v", err)
+	}
+
+	reply, err := commentsRepo.Create(ctxTimeout, *entities.NewComment(blog1.ID, root.ID, "b", "reply"))
+	if err != nil {
+		t.Fatalf("TestCommentsSqlite: create reply failed: %s", err)
+	}
+	if reply.ParentID != root.ID {
+		t.Fatalf("TestCommentsSqlite: reply parent incorrect, got %d", reply.ParentID)
+	}
+
+	// only approved comments are listed
+	approved, err := commentsRepo.ListApproved(ctxTimeout, blog1.ID)
+	if err != nil {
+		t.Fatalf("TestCommentsSqlite: list approved failed: %s", err)
+	}
+	if len(approved) != 1 || approved[0].ID != root.ID {
+		t.Fatalf("TestCommentsSqlite: should only list the approved comment, got %+v", approved)
+	}
+	if _, err := commentsRepo.ListApproved(ctxTimeout, hidden.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("TestCommentsSqlite: list on hidden blog should return sql.ErrNoRows, got %v", err)
+	}
+
+	pending, err := commentsRepo.ListByStatus(ctxTimeout, entities.CommentPending)
+	if err != nil {
+		t.Fatalf("TestCommentsSqlite: list pending failed: %s", err)
+	}
+	if len(pending) != 1 || pending[0].ID != reply.ID {
+		t.Fatalf("TestCommentsSqlite: pending queue should only have the reply, got %+v", pending)
+	}
+
+	if _, err := commentsRepo.UpdateStatus(ctxTimeout, 999, entities.CommentRejected); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("TestCommentsSqlite: update none existent comment should return sql.ErrNoRows, got %v", err)
+	}
+
+	// deleting a comment removes its replies
+	affectedRows, err := commentsRepo.Delete(ctxTimeout, root.ID)
+	if err != nil {
+		t.Fatalf("TestCommentsSqlite: delete failed: %s", err)
+	}
+	if affectedRows != 2 {
+		t.Fatalf("TestCommentsSqlite: delete should remove comment and reply, got %d", affectedRows)
+	}
+
+	// deleting the blog removes its comments
+	if _, err := commentsRepo.Create(ctxTimeout, *entities.NewComment(blog2.ID, 0, "c", "bye")); err != nil {
+		t.Fatalf("TestCommentsSqlite: create comment failed: %s", err)
+	}
+	if _, err := blogsRepo.DeleteNow(ctxTimeout, blog2.ID, ""); err != nil {
+		t.Fatalf("TestCommentsSqlite: delete blog failed: %s", err)
+	}
+	pending, err = commentsRepo.ListByStatus(ctxTimeout, entities.CommentPending)
+	if err != nil {
+		t.Fatalf("TestCommentsSqlite: list pending failed: %s", err)
+	}
+	if len(pending) != 0 {
+		t.Fatalf("TestCommentsSqlite: comments of deleted blog should be removed, got %+v", pending)
+	}
+}
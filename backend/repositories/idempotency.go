@@ -0,0 +1,168 @@
+package repositories
+
+import (
+	"blog/config"
+	"blog/db/models/interfaces"
+	"blog/entities"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type IdempotencyRepoModels struct {
+	idempotency interfaces.IdempotencyModel
+}
+
+func NewIdempotencyRepoModels(idempotency interfaces.IdempotencyModel) *IdempotencyRepoModels {
+	return &IdempotencyRepoModels{
+		idempotency: idempotency,
+	}
+}
+
+type Idempotency struct {
+	db     *sql.DB
+	config config.DBSetting
+	models IdempotencyRepoModels
+}
+
+func NewIdempotency(db *sql.DB, config config.DBSetting, models IdempotencyRepoModels) *Idempotency {
+	return &Idempotency{
+		db:     db,
+		config: config,
+		models: models,
+	}
+}
+
+/*
+Begin claims the key for a new request and returns nil, expired keys can be claimed again.
+
+If the key is taken, the stored response is returned for the same request.
+A different request gets entities.ErrorIdempotencyKeyReused,
+and entities.ErrorIdempotencyInProgress is returned until the first request completes.
+*/
+func (i *Idempotency) Begin(ctx context.Context, key entities.IdempotencyKey) (*entities.IdempotencyKey, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(i.config.Timeout)*time.Second)
+	defer cancel()
+
+	tx, err := i.db.BeginTx(ctxTimeout, &sql.TxOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("Begin: begin transaction failed: %w", err)
+	}
+
+	now := time.Now().UTC().Format("2006-01-02T15:04:05-07:00")
+	if _, err := i.models.idempotency.DeleteExpiredKey(ctxTimeout, tx, key.Actor, key.Key, now); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return nil, fmt.Errorf("Begin: model delete expired key rollback failed: %w", err)
+		}
+		return nil, fmt.Errorf("Begin: model delete expired key failed: %w", err)
+	}
+
+	created, err := i.models.idempotency.Create(ctxTimeout, tx, key)
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			return nil, fmt.Errorf("Begin: model create key rollback failed: %w", err)
+		}
+		return nil, fmt.Errorf("Begin: model create key failed: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("Begin: commit failed: %w", err)
+	}
+
+	if created {
+		return nil, nil
+	}
+
+	stored, err := i.models.idempotency.Get(ctxTimeout, i.db, key.Actor, key.Key)
+	if err != nil {
+		// released by the first request in the meantime
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("Begin: %w", entities.ErrorIdempotencyInProgress)
+		}
+		return nil, fmt.Errorf("Begin: model get key failed: %w", err)
+	}
+
+	if stored.RequestHash != key.RequestHash {
+		return nil, fmt.Errorf("Begin: %w", entities.ErrorIdempotencyKeyReused)
+	}
+	if stored.InProgress() {
+		return nil, fmt.Errorf("Begin: %w", entities.ErrorIdempotencyInProgress)
+	}
+
+	return stored, nil
+}
+
+// Complete stores the response of a claimed key, to be replayed until it expires
+func (i *Idempotency) Complete(ctx context.Context, key entities.IdempotencyKey) error {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(i.config.Timeout)*time.Second)
+	defer cancel()
+
+	tx, err := i.db.BeginTx(ctxTimeout, &sql.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("Complete: begin transaction failed: %w", err)
+	}
+
+	if _, err := i.models.idempotency.Complete(ctxTimeout, tx, key); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return fmt.Errorf("Complete: model complete key rollback failed: %w", err)
+		}
+		return fmt.Errorf("Complete: model complete key failed: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("Complete: commit failed: %w", err)
+	}
+
+	return nil
+}
+
+// Release frees a claimed key without storing a response, so that the request can be retried
+func (i *Idempotency) Release(ctx context.Context, actor, key string) error {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(i.config.Timeout)*time.Second)
+	defer cancel()
+
+	tx, err := i.db.BeginTx(ctxTimeout, &sql.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("Release: begin transaction failed: %w", err)
+	}
+
+	if _, err := i.models.idempotency.DeleteInProgress(ctxTimeout, tx, actor, key); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return fmt.Errorf("Release: model delete key rollback failed: %w", err)
+		}
+		return fmt.Errorf("Release: model delete key failed: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("Release: commit failed: %w", err)
+	}
+
+	return nil
+}
+
+// Removes keys expired before the given time, returns the number removed
+func (i *Idempotency) Purge(ctx context.Context, before time.Time) (int, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(i.config.Timeout)*time.Second)
+	defer cancel()
+
+	tx, err := i.db.BeginTx(ctxTimeout, &sql.TxOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("Purge: begin transaction failed: %w", err)
+	}
+
+	affectedRows, err := i.models.idempotency.DeleteExpired(ctxTimeout, tx, before.UTC().Format("2006-01-02T15:04:05-07:00"))
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			return 0, fmt.Errorf("Purge: model delete expired keys rollback failed: %w", err)
+		}
+		return 0, fmt.Errorf("Purge: model delete expired keys failed: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("Purge: commit failed: %w", err)
+	}
+
+	return affectedRows, nil
+}
@@ -0,0 +1,114 @@
+package repositories_test
+
+import (
+	"blog/config"
+	"blog/db"
+	"blog/db/models/sqlite"
+	"blog/entities"
+	"blog/repositories"
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestIdempotencySqlite(t *testing.T) {
+	// connect
+	dbConn, err := sql.Open("sqlite3", "file:test.db?mode=memory&_foreign_keys=on")
+	if err != nil {
+		t.Fatalf("TestIdempotencySqlite: open db connection failed: %s", err)
+	}
+	defer dbConn.Close()
+
+	// migrate db
+	if err := db.Up(dbConn, db.EmbedMigrationsSQLite, "sqlite3", "migrations/sqlite"); err != nil {
+		t.Fatalf("TestIdempotencySqlite: migrate up failed: %s", err)
+	}
+
+	idempotencyRepo := repositories.NewIdempotency(dbConn, config.NewConfig().DB, *repositories.NewIdempotencyRepoModels(sqlite.NewIdempotency()))
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	format := func(t time.Time) string { return t.UTC().Format("2006-01-02T15:04:05-07:00") }
+	locked := format(time.Now().Add(time.Minute))
+
+	// claim
+	key := entities.NewIdempotencyKey("alex", "k1", "hash1", locked)
+	stored, err := idempotencyRepo.Begin(ctxTimeout, *key)
+	if err != nil || stored != nil {
+		t.Fatalf("TestIdempotencySqlite: first begin should claim the key, got %+v, %v", stored, err)
+	}
+
+	// same request while in progress
+	if _, err := idempotencyRepo.Begin(ctxTimeout, *key); !errors.Is(err, entities.ErrorIdempotencyInProgress) {
+		t.Fatalf("TestIdempotencySqlite: should be in progress, got %v", err)
+	}
+
+	// different request
+	other := entities.NewIdempotencyKey("alex", "k1", "hash2", locked)
+	if _, err := idempotencyRepo.Begin(ctxTimeout, *other); !errors.Is(err, entities.ErrorIdempotencyKeyReused) {
+		t.Fatalf("TestIdempotencySqlite: should be reused, got %v", err)
+	}
+
+	// same key of another actor
+	if stored, err := idempotencyRepo.Begin(ctxTimeout, *entities.NewIdempotencyKey("bob", "k1", "hash2", locked)); err != nil || stored != nil {
+		t.Fatalf("TestIdempotencySqlite: keys should be scoped by actor, got %+v, %v", stored, err)
+	}
+
+	// complete and replay
+	key.Status = 201
+	key.ContentType = "application/json"
+	key.Response = []byte(`{"status":"success"}`)
+	key.Expires_at = format(time.Now().Add(time.Hour))
+	if err := idempotencyRepo.Complete(ctxTimeout, *key); err != nil {
+		t.Fatalf("TestIdempotencySqlite: complete failed: %s", err)
+	}
+	stored, err = idempotencyRepo.Begin(ctxTimeout, *entities.NewIdempotencyKey("alex", "k1", "hash1", locked))
+	if err != nil || stored == nil {
+		t.Fatalf("TestIdempotencySqlite: should replay, got %+v, %v", stored, err)
+	}
+	if stored.Status != 201 || stored.ContentType != "application/json" || string(stored.Response) != `{"status":"success"}` {
+		t.Fatalf("TestIdempotencySqlite: unexpected stored response %+v", stored)
+	}
+
+	// completed keys are not released
+	if err := idempotencyRepo.Release(ctxTimeout, "alex", "k1"); err != nil {
+		t.Fatalf("TestIdempotencySqlite: release failed: %s", err)
+	}
+	if stored, err := idempotencyRepo.Begin(ctxTimeout, *key); err != nil || stored == nil {
+		t.Fatalf("TestIdempotencySqlite: completed key should not be released, got %+v, %v", stored, err)
+	}
+
+	// released keys can be claimed again
+	if err := idempotencyRepo.Release(ctxTimeout, "bob", "k1"); err != nil {
+		t.Fatalf("TestIdempotencySqlite: release failed: %s", err)
+	}
+	if stored, err := idempotencyRepo.Begin(ctxTimeout, *entities.NewIdempotencyKey("bob", "k1", "hash3", locked)); err != nil || stored != nil {
+		t.Fatalf("TestIdempotencySqlite: released key should be claimed again, got %+v, %v", stored, err)
+	}
+
+	// expired keys can be claimed again
+	expired := entities.NewIdempotencyKey("alex", "k2", "hash1", format(time.Now().Add(-time.Minute)))
+	if stored, err := idempotencyRepo.Begin(ctxTimeout, *expired); err != nil || stored != nil {
+		t.Fatalf("TestIdempotencySqlite: begin failed, got %+v, %v", stored, err)
+	}
+	if stored, err := idempotencyRepo.Begin(ctxTimeout, *entities.NewIdempotencyKey("alex", "k2", "hash2", locked)); err != nil || stored != nil {
+		t.Fatalf("TestIdempotencySqlite: expired key should be claimed again, got %+v, %v", stored, err)
+	}
+
+	// purge
+	if _, err := idempotencyRepo.Begin(ctxTimeout, *entities.NewIdempotencyKey("alex", "k3", "hash1", format(time.Now().Add(-time.Minute)))); err != nil {
+		t.Fatalf("TestIdempotencySqlite: begin failed: %s", err)
+	}
+	purged, err := idempotencyRepo.Purge(ctxTimeout, time.Now())
+	if err != nil {
+		t.Fatalf("TestIdempotencySqlite: purge failed: %s", err)
+	}
+	if purged != 1 {
+		t.Fatalf("TestIdempotencySqlite: should purge 1 key, got %d", purged)
+	}
+}
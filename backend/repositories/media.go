@@ -0,0 +1,163 @@
+package repositories
+
+import (
+	"blog/config"
+	"blog/db/models/interfaces"
+	"blog/entities"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type MediaRepoModels struct {
+	media     interfaces.MediaModel
+	blogMedia interfaces.BlogMediaModel
+}
+
+func NewMediaRepoModels(
+	media interfaces.MediaModel,
+	blogMedia interfaces.BlogMediaModel,
+) *MediaRepoModels {
+
+	return &MediaRepoModels{
+		media:     media,
+		blogMedia: blogMedia,
+	}
+}
+
+type Media struct {
+	db     *sql.DB
+	config config.DBSetting
+	models MediaRepoModels
+}
+
+func NewMedia(db *sql.DB, config config.DBSetting, models MediaRepoModels) *Media {
+	return &Media{
+		db:     db,
+		config: config,
+		models: models,
+	}
+}
+
+func (m *Media) Create(ctx context.Context, media entities.Media) (*entities.Media, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(m.config.Timeout)*time.Second)
+	defer cancel()
+
+	tx, err := m.db.BeginTx(ctxTimeout, &sql.TxOptions{})
+	if err != nil {
+		return &entities.Media{}, fmt.Errorf("Create: begin transaction failed: %w", err)
+	}
+
+	newMedia, err := m.models.media.Create(ctxTimeout, tx, media)
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			return &entities.Media{}, fmt.Errorf("Create: model create media rollback failed: %w", err)
+		}
+		return &entities.Media{}, fmt.Errorf("Create: model create media failed: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return &entities.Media{}, fmt.Errorf("Create: commit failed: %w", err)
+	}
+
+	return newMedia, nil
+}
+
+func (m *Media) Get(ctx context.Context, hash string) (*entities.Media, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(m.config.Timeout)*time.Second)
+	defer cancel()
+
+	media, err := m.models.media.Get(ctxTimeout, m.db, hash)
+	if err != nil {
+		return &entities.Media{}, fmt.Errorf("Get: model get media failed: %w", err)
+	}
+
+	return media, nil
+}
+
+// Returns all media, with the ids of blogs referencing them
+func (m *Media) List(ctx context.Context) ([]entities.OutMedia, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(m.config.Timeout)*time.Second)
+	defer cancel()
+
+	media, err := m.models.media.List(ctxTimeout, m.db)
+	if err != nil {
+		return []entities.OutMedia{}, fmt.Errorf("List: model list media failed: %w", err)
+	}
+
+	result := []entities.OutMedia{}
+	for _, item := range media {
+		blogIDs, err := m.models.blogMedia.ListBlogIDsByHash(ctxTimeout, m.db, item.Hash)
+		if err != nil {
+			return []entities.OutMedia{}, fmt.Errorf("List: model list blog ids failed: %w", err)
+		}
+		result = append(result, *entities.NewOutMedia(item, blogIDs))
+	}
+
+	return result, nil
+}
+
+// Returns media not referenced by any blog and created before the given ISO 8601 timestamp
+func (m *Media) ListUnused(ctx context.Context, before string) ([]entities.Media, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(m.config.Timeout)*time.Second)
+	defer cancel()
+
+	media, err := m.models.media.ListUnused(ctxTimeout, m.db, before)
+	if err != nil {
+		return []entities.Media{}, fmt.Errorf("ListUnused: model list unused media failed: %w", err)
+	}
+
+	return media, nil
+}
+
+// Media still referenced by blogs will not be deleted, affected rows will be 0
+func (m *Media) Delete(ctx context.Context, hash string) (int, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(m.config.Timeout)*time.Second)
+	defer cancel()
+
+	tx, err := m.db.BeginTx(ctxTimeout, &sql.TxOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("Delete: begin transaction failed: %w", err)
+	}
+
+	affectedRows, err := m.models.media.Delete(ctxTimeout, tx, hash)
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			return 0, fmt.Errorf("Delete: model delete media rollback failed: %w", err)
+		}
+		return 0, fmt.Errorf("Delete: model delete media failed: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("Delete: commit failed: %w", err)
+	}
+
+	return affectedRows, nil
+}
+
+// Deletes media not referenced by any blog and created before the given ISO 8601 timestamp,
+// returns the deleted media so their files can be removed.
+func (m *Media) DeleteUnused(ctx context.Context, before string) ([]entities.Media, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(m.config.Timeout)*time.Second)
+	defer cancel()
+
+	tx, err := m.db.BeginTx(ctxTimeout, &sql.TxOptions{})
+	if err != nil {
+		return []entities.Media{}, fmt.Errorf("DeleteUnused: begin transaction failed: %w", err)
+	}
+
+	deleted, err := m.models.media.DeleteUnused(ctxTimeout, tx, before)
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			return []entities.Media{}, fmt.Errorf("DeleteUnused: model delete unused media rollback failed: %w", err)
+		}
+		return []entities.Media{}, fmt.Errorf("DeleteUnused: model delete unused media failed: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return []entities.Media{}, fmt.Errorf("DeleteUnused: commit failed: %w", err)
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,141 @@
+package repositories_test
+
+import (
+	"blog/config"
+	"blog/db"
+	"blog/db/models/sqlite"
+	"blog/entities"
+	"blog/repositories"
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestMediaSqlite(t *testing.T) {
+	// connect
+	dbConn, err := sql.Open("sqlite3", "file:test.db?mode=memory&_foreign_keys=on")
+	if err != nil {
+		t.Fatalf("TestMediaSqlite: open db connection failed: %s", err)
+	}
+	defer dbConn.Close()
+
+	// migrate db
+	if err := db.Up(dbConn, db.EmbedMigrationsSQLite, "sqlite3", "migrations/sqlite"); err != nil {
+		t.Fatalf("TestMediaSqlite: migrate up failed: %s", err)
+	}
+
+	// setup repo
+	blogsRepo, _, _ := prepareRepos(dbConn)
+	mediaRepoModels := repositories.NewMediaRepoModels(sqlite.NewMedia(), sqlite.NewBlogMedia())
+	mediaRepo := repositories.NewMedia(dbConn, config.NewConfig().DB, *mediaRepoModels)
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	// test create
+	media1 := entities.NewMedia(strings.Repeat("a", 64), "cat.png", "image/png", 100, 10, 20, "a cat")
+	newMedia1, err := mediaRepo.Create(ctxTimeout, *media1)
+	if err != nil {
+		t.Fatalf("TestMediaSqlite: create failed: %s", err)
+	}
+	if !cmp.Equal(media1, newMedia1, cmpopts.IgnoreFields(entities.Media{}, "Created_at", "Updated_at")) {
+		t.Fatalf("TestMediaSqlite: create cmp failed")
+	}
+
+	// uploading the same content again keeps the existing alt text
+	again := entities.NewMedia(media1.Hash, "", "image/png", 100, 10, 20, "")
+	newAgain, err := mediaRepo.Create(ctxTimeout, *again)
+	if err != nil {
+		t.Fatalf("TestMediaSqlite: create again failed: %s", err)
+	}
+	if newAgain.Alt != media1.Alt || newAgain.Filename != media1.Filename {
+		t.Fatalf("TestMediaSqlite: create again should keep alt and filename, got: %+v", newAgain)
+	}
+
+	media2 := entities.NewMedia(strings.Repeat("b", 64), "notes.pdf", "application/pdf", 200, 0, 0, "")
+	if _, err := mediaRepo.Create(ctxTimeout, *media2); err != nil {
+		t.Fatalf("TestMediaSqlite: create media2 failed: %s", err)
+	}
+
+	// blogs referencing media, unknown hashes are ignored
+	content := fmt.Sprintf("![cat](/api/v1/media/%s)\n![dog](/api/v1/media/%s)", media1.Hash, strings.Repeat("c", 64))
+	blog := entities.NewInBlog(*entities.NewBlog("title1", content, "description1", false, true), []int{}, []int{})
+	newBlog, err := blogsRepo.Create(ctxTimeout, *blog)
+	if err != nil {
+		t.Fatalf("TestMediaSqlite: create blog failed: %s", err)
+	}
+
+	list, err := mediaRepo.List(ctxTimeout)
+	if err != nil {
+		t.Fatalf("TestMediaSqlite: list failed: %s", err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("TestMediaSqlite: list should have 2 media, got %d", len(list))
+	}
+	for _, item := range list {
+		switch item.Hash {
+		case media1.Hash:
+			if !cmp.Equal(item.Blogs, []int{newBlog.ID}) {
+				t.Fatalf("TestMediaSqlite: media1 should be referenced by blog %d, got %v", newBlog.ID, item.Blogs)
+			}
+		case media2.Hash:
+			if len(item.Blogs) != 0 {
+				t.Fatalf("TestMediaSqlite: media2 should not be referenced, got %v", item.Blogs)
+			}
+		}
+	}
+
+	// widths of the images the blog links to, for the srcset
+	widths, err := blogsRepo.MediaWidths(ctxTimeout, newBlog.ID)
+	if err != nil {
+		t.Fatalf("TestMediaSqlite: media widths failed: %s", err)
+	}
+	if !cmp.Equal(widths, map[string]int{media1.Hash: media1.Width}) {
+		t.Fatalf("TestMediaSqlite: media widths should only have media1, got %v", widths)
+	}
+
+	// referenced media can't be deleted
+	affectedRows, err := mediaRepo.Delete(ctxTimeout, media1.Hash)
+	if err != nil {
+		t.Fatalf("TestMediaSqlite: delete failed: %s", err)
+	}
+	if affectedRows != 0 {
+		t.Fatalf("TestMediaSqlite: referenced media should not be deleted")
+	}
+
+	// grace period keeps recent uploads
+	past := time.Now().UTC().Add(-time.Hour).Format("2006-01-02T15:04:05-07:00")
+	unused, err := mediaRepo.ListUnused(ctxTimeout, past)
+	if err != nil {
+		t.Fatalf("TestMediaSqlite: list unused failed: %s", err)
+	}
+	if len(unused) != 0 {
+		t.Fatalf("TestMediaSqlite: recent media should not be listed as unused, got %d", len(unused))
+	}
+
+	// removing the reference makes media1 unused
+	blog.Content = "no images"
+	if _, err := blogsRepo.Update(ctxTimeout, *blog, newBlog.ID, ""); err != nil {
+		t.Fatalf("TestMediaSqlite: update blog failed: %s", err)
+	}
+
+	future := time.Now().UTC().Add(time.Hour).Format("2006-01-02T15:04:05-07:00")
+	deleted, err := mediaRepo.DeleteUnused(ctxTimeout, future)
+	if err != nil {
+		t.Fatalf("TestMediaSqlite: delete unused failed: %s", err)
+	}
+	if len(deleted) != 2 {
+		t.Fatalf("TestMediaSqlite: should delete 2 unused media, got %d", len(deleted))
+	}
+
+	if _, err := mediaRepo.Get(ctxTimeout, media1.Hash); err == nil {
+		t.Fatalf("TestMediaSqlite: get deleted media should have failed")
+	}
+}
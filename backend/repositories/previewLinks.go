@@ -0,0 +1,124 @@
+package repositories
+
+import (
+	"blog/config"
+	"blog/db/models/interfaces"
+	"blog/entities"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type PreviewLinksRepoModels struct {
+	blogs        interfaces.BlogsModel
+	previewLinks interfaces.PreviewLinksModel
+}
+
+func NewPreviewLinksRepoModels(
+	blogs interfaces.BlogsModel,
+	previewLinks interfaces.PreviewLinksModel,
+) *PreviewLinksRepoModels {
+
+	return &PreviewLinksRepoModels{
+		blogs:        blogs,
+		previewLinks: previewLinks,
+	}
+}
+
+type PreviewLinks struct {
+	db     *sql.DB
+	config config.DBSetting
+	models PreviewLinksRepoModels
+}
+
+func NewPreviewLinks(db *sql.DB, config config.DBSetting, models PreviewLinksRepoModels) *PreviewLinks {
+	return &PreviewLinks{
+		db:     db,
+		config: config,
+		models: models,
+	}
+}
+
+// Links can be made for any blog, returns sql.ErrNoRows if the blog doesn't exist
+func (p *PreviewLinks) Create(ctx context.Context, link entities.PreviewLink) (*entities.PreviewLink, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(p.config.Timeout)*time.Second)
+	defer cancel()
+
+	if _, err := p.models.blogs.AdminGet(ctxTimeout, p.db, link.BlogID); err != nil {
+		return &entities.PreviewLink{}, fmt.Errorf("Create: model admin get blog failed: %w", err)
+	}
+
+	tx, err := p.db.BeginTx(ctxTimeout, &sql.TxOptions{})
+	if err != nil {
+		return &entities.PreviewLink{}, fmt.Errorf("Create: begin transaction failed: %w", err)
+	}
+
+	newLink, err := p.models.previewLinks.Create(ctxTimeout, tx, link)
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			return &entities.PreviewLink{}, fmt.Errorf("Create: model create preview link rollback failed: %w", err)
+		}
+		return &entities.PreviewLink{}, fmt.Errorf("Create: model create preview link failed: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return &entities.PreviewLink{}, fmt.Errorf("Create: commit failed: %w", err)
+	}
+
+	return newLink, nil
+}
+
+func (p *PreviewLinks) Get(ctx context.Context, id int) (*entities.PreviewLink, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(p.config.Timeout)*time.Second)
+	defer cancel()
+
+	link, err := p.models.previewLinks.Get(ctxTimeout, p.db, id)
+	if err != nil {
+		return &entities.PreviewLink{}, fmt.Errorf("Get: model get preview link failed: %w", err)
+	}
+
+	return link, nil
+}
+
+// Returns sql.ErrNoRows if the blog doesn't exist
+func (p *PreviewLinks) ListByBlogID(ctx context.Context, blogID int) ([]entities.PreviewLink, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(p.config.Timeout)*time.Second)
+	defer cancel()
+
+	if _, err := p.models.blogs.AdminGet(ctxTimeout, p.db, blogID); err != nil {
+		return []entities.PreviewLink{}, fmt.Errorf("ListByBlogID: model admin get blog failed: %w", err)
+	}
+
+	links, err := p.models.previewLinks.ListByBlogID(ctxTimeout, p.db, blogID)
+	if err != nil {
+		return []entities.PreviewLink{}, fmt.Errorf("ListByBlogID: model list preview links failed: %w", err)
+	}
+
+	return links, nil
+}
+
+// Tokens of revoked links stop working right away
+func (p *PreviewLinks) Revoke(ctx context.Context, id, blogID int) (int, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(p.config.Timeout)*time.Second)
+	defer cancel()
+
+	tx, err := p.db.BeginTx(ctxTimeout, &sql.TxOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("Revoke: begin transaction failed: %w", err)
+	}
+
+	affectedRows, err := p.models.previewLinks.Delete(ctxTimeout, tx, id, blogID)
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			return 0, fmt.Errorf("Revoke: model delete preview link rollback failed: %w", err)
+		}
+		return 0, fmt.Errorf("Revoke: model delete preview link failed: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("Revoke: commit failed: %w", err)
+	}
+
+	return affectedRows, nil
+}
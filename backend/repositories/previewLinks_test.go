@@ -0,0 +1,89 @@
+package repositories_test
+
+import (
+	"blog/config"
+	"blog/db"
+	"blog/db/models/sqlite"
+	"blog/entities"
+	"blog/repositories"
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestPreviewLinksSqlite(t *testing.T) {
+	// connect
+	dbConn, err := sql.Open("sqlite3", "file:test.db?mode=memory&_foreign_keys=on")
+	if err != nil {
+		t.Fatalf("TestPreviewLinksSqlite: open db connection failed: %s", err)
+	}
+	defer dbConn.Close()
+
+	// migrate db
+	if err := db.Up(dbConn, db.EmbedMigrationsSQLite, "sqlite3", "migrations/sqlite"); err != nil {
+		t.Fatalf("TestPreviewLinksSqlite: migrate up failed: %s", err)
+	}
+
+	blogsRepo, _, _ := prepareRepos(dbConn)
+	previewLinksRepoModels := repositories.NewPreviewLinksRepoModels(sqlite.NewBlogs(), sqlite.NewPreviewLinks())
+	previewLinksRepo := repositories.NewPreviewLinks(dbConn, config.NewConfig().DB, *previewLinksRepoModels)
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	inBlog := entities.NewInBlog(*entities.NewBlog("draft", "content", "desc", false, false), []int{}, []int{})
+	draft, err := blogsRepo.Create(ctxTimeout, *inBlog)
+	if err != nil {
+		t.Fatalf("TestPreviewLinksSqlite: create blog failed: %s", err)
+	}
+
+	expiresAt := time.Now().Add(time.Hour).UTC().Format("2006-01-02T15:04:05-07:00")
+	first, err := previewLinksRepo.Create(ctxTimeout, *entities.NewPreviewLink(draft.ID, "reviewer", expiresAt))
+	if err != nil {
+		t.Fatalf("TestPreviewLinksSqlite: create preview link failed: %s", err)
+	}
+	if first.BlogID != draft.ID || first.Note != "reviewer" || first.ExpiresAt != expiresAt {
+		t.Fatalf("TestPreviewLinksSqlite: unexpected preview link %+v", first)
+	}
+	second, err := previewLinksRepo.Create(ctxTimeout, *entities.NewPreviewLink(draft.ID, "", expiresAt))
+	if err != nil {
+		t.Fatalf("TestPreviewLinksSqlite: create preview link failed: %s", err)
+	}
+	if _, err := previewLinksRepo.Create(ctxTimeout, *entities.NewPreviewLink(999, "", expiresAt)); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("TestPreviewLinksSqlite: link of none existent blog should return sql.ErrNoRows, got %v", err)
+	}
+
+	links, err := previewLinksRepo.ListByBlogID(ctxTimeout, draft.ID)
+	if err != nil {
+		t.Fatalf("TestPreviewLinksSqlite: list preview links failed: %s", err)
+	}
+	if len(links) != 2 || links[0].ID != second.ID {
+		t.Fatalf("TestPreviewLinksSqlite: should list newest first, got %+v", links)
+	}
+	if _, err := previewLinksRepo.ListByBlogID(ctxTimeout, 999); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("TestPreviewLinksSqlite: list of none existent blog should return sql.ErrNoRows, got %v", err)
+	}
+
+	// revoke only within the same blog
+	if affected, _ := previewLinksRepo.Revoke(ctxTimeout, first.ID, 999); affected != 0 {
+		t.Fatalf("TestPreviewLinksSqlite: revoke with another blog id should not delete, got %d", affected)
+	}
+	if affected, err := previewLinksRepo.Revoke(ctxTimeout, first.ID, draft.ID); err != nil || affected != 1 {
+		t.Fatalf("TestPreviewLinksSqlite: revoke failed: %d %v", affected, err)
+	}
+	if _, err := previewLinksRepo.Get(ctxTimeout, first.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("TestPreviewLinksSqlite: revoked link should be gone, got %v", err)
+	}
+
+	// deleting the blog removes its links
+	if _, err := blogsRepo.DeleteNow(ctxTimeout, draft.ID, ""); err != nil {
+		t.Fatalf("TestPreviewLinksSqlite: delete blog failed: %s", err)
+	}
+	if _, err := previewLinksRepo.Get(ctxTimeout, second.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("TestPreviewLinksSqlite: links of deleted blog should be removed, got %v", err)
+	}
+}
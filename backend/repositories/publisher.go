@@ -0,0 +1,24 @@
+package repositories
+
+import "blog/entities"
+
+// Notified of committed changes, implemented by pubsub.Broker.
+// nil when nobody listens.
+type Publisher interface {
+	Publish(eventType string, data any)
+}
+
+func publish(publisher Publisher, eventType string, data any) {
+	if publisher == nil {
+		return
+	}
+	publisher.Publish(eventType, data)
+}
+
+// The events stream is public, blogs that aren't published are only sent with their id
+func blogStreamData(blog entities.Blog) any {
+	if blog.Status != entities.BlogPublished || blog.Deleted_at != "" {
+		return entities.DeletedResource{ID: blog.ID}
+	}
+	return blogEventData(blog)
+}
@@ -0,0 +1,176 @@
+package repositories
+
+import (
+	"blog/config"
+	"blog/db/models/interfaces"
+	"blog/entities"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type SeriesRepoModels struct {
+	blogSeries interfaces.BlogSeriesModel
+	series     interfaces.SeriesModel
+}
+
+func NewSeriesRepoModels(
+	blogSeries interfaces.BlogSeriesModel,
+	series interfaces.SeriesModel,
+) *SeriesRepoModels {
+
+	return &SeriesRepoModels{
+		blogSeries: blogSeries,
+		series:     series,
+	}
+}
+
+type Series struct {
+	db     *sql.DB
+	config config.DBSetting
+	models SeriesRepoModels
+}
+
+func NewSeries(db *sql.DB, config config.DBSetting, models SeriesRepoModels) *Series {
+	return &Series{
+		db:     db,
+		config: config,
+		models: models,
+	}
+}
+
+func (s *Series) Create(ctx context.Context, series entities.Series) (*entities.Series, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(s.config.Timeout)*time.Second)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctxTimeout, &sql.TxOptions{})
+	if err != nil {
+		return &entities.Series{}, fmt.Errorf("Create: begin transaction failed: %w", err)
+	}
+
+	newSeries, err := s.models.series.Create(ctxTimeout, tx, series)
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			return &entities.Series{}, fmt.Errorf("Create: model create series rollback failed: %w", err)
+		}
+		return &entities.Series{}, fmt.Errorf("Create: model create series failed: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return &entities.Series{}, fmt.Errorf("Create: commit failed: %w", err)
+	}
+
+	return newSeries, nil
+}
+
+func (s *Series) List(ctx context.Context) ([]entities.Series, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(s.config.Timeout)*time.Second)
+	defer cancel()
+
+	series, err := s.models.series.List(ctxTimeout, s.db)
+	if err != nil {
+		return []entities.Series{}, fmt.Errorf("List: model list series failed: %w", err)
+	}
+
+	return series, nil
+}
+
+/*
+Only include parts with field values:
+
+- status: published
+
+- deleted_at: ""
+*/
+func (s *Series) Get(ctx context.Context, id int) (*entities.OutSeries, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(s.config.Timeout)*time.Second)
+	defer cancel()
+
+	series, err := s.models.series.Get(ctxTimeout, s.db, id)
+	if err != nil {
+		return &entities.OutSeries{}, fmt.Errorf("Get: model get series failed: %w", err)
+	}
+
+	parts, err := s.models.blogSeries.ListParts(ctxTimeout, s.db, id)
+	if err != nil {
+		return &entities.OutSeries{}, fmt.Errorf("Get: model list parts failed: %w", err)
+	}
+
+	return entities.NewOutSeries(*series, parts), nil
+}
+
+// Include all parts regardless of visiblity and delete timestamp
+func (s *Series) AdminGet(ctx context.Context, id int) (*entities.OutSeries, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(s.config.Timeout)*time.Second)
+	defer cancel()
+
+	series, err := s.models.series.Get(ctxTimeout, s.db, id)
+	if err != nil {
+		return &entities.OutSeries{}, fmt.Errorf("AdminGet: model get series failed: %w", err)
+	}
+
+	parts, err := s.models.blogSeries.AdminListParts(ctxTimeout, s.db, id)
+	if err != nil {
+		return &entities.OutSeries{}, fmt.Errorf("AdminGet: model list parts failed: %w", err)
+	}
+
+	return entities.NewOutSeries(*series, parts), nil
+}
+
+func (s *Series) Update(ctx context.Context, series entities.Series, id int) (*entities.Series, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(s.config.Timeout)*time.Second)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctxTimeout, &sql.TxOptions{})
+	if err != nil {
+		return &entities.Series{}, fmt.Errorf("Update: begin transaction failed: %w", err)
+	}
+
+	newSeries, err := s.models.series.Update(ctxTimeout, tx, series, id)
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			return &entities.Series{}, fmt.Errorf("Update: model update series rollback failed: %w", err)
+		}
+		return &entities.Series{}, fmt.Errorf("Update: model update series failed: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return &entities.Series{}, fmt.Errorf("Update: commit failed: %w", err)
+	}
+
+	return newSeries, nil
+}
+
+// Blogs in the series are kept, they are just no longer part of a series
+func (s *Series) Delete(ctx context.Context, id int) (int, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(s.config.Timeout)*time.Second)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctxTimeout, &sql.TxOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("Delete: begin transaction failed: %w", err)
+	}
+
+	// delete relations
+	if err := s.models.blogSeries.DeleteBySeriesID(ctxTimeout, tx, id); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return 0, fmt.Errorf("Delete: model delete blog_series rollback failed: %w", err)
+		}
+		return 0, fmt.Errorf("Delete: model delete blog_series failed: %w", err)
+	}
+
+	affectedRows, err := s.models.series.Delete(ctxTimeout, tx, id)
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			return 0, fmt.Errorf("Delete: model delete series rollback failed: %w", err)
+		}
+		return 0, fmt.Errorf("Delete: model delete series failed: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("Delete: commit failed: %w", err)
+	}
+
+	return affectedRows, nil
+}
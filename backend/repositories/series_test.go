@@ -0,0 +1,225 @@
+package repositories_test
+
+import (
+	"blog/config"
+	"blog/db"
+	"blog/db/models/sqlite"
+	"blog/entities"
+	"blog/repositories"
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func prepareSeriesRepo(dbConn *sql.DB) repositories.Series {
+	seriesRepoModels := repositories.NewSeriesRepoModels(sqlite.NewBlogSeries(), sqlite.NewSeries())
+	return *repositories.NewSeries(dbConn, config.NewConfig().DB, *seriesRepoModels)
+}
+
+func TestSeriesCRUDSqlite(t *testing.T) {
+	// connect
+	dbConn, err := sql.Open("sqlite3", "file:test.db?mode=memory&_foreign_keys=on")
+	if err != nil {
+		t.Fatalf("TestSeriesCRUDSqlite: open db connection failed: %s", err)
+	}
+	defer dbConn.Close()
+
+	// migrate db
+	if err := db.Up(dbConn, db.EmbedMigrationsSQLite, "sqlite3", "migrations/sqlite"); err != nil {
+		t.Fatalf("TestSeriesCRUDSqlite: migrate up failed: %s", err)
+	}
+
+	seriesRepo := prepareSeriesRepo(dbConn)
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	// create
+	entry := entities.NewSeries("Go Concurrency", "desc 1")
+	newEntry, err := seriesRepo.Create(ctxTimeout, *entry)
+	if err != nil {
+		t.Fatalf("TestSeriesCRUDSqlite: create failed: %s", err)
+	}
+	if !cmp.Equal(entry, newEntry, cmpopts.IgnoreFields(entities.Series{}, "ID", "Created_at", "Updated_at")) {
+		t.Fatalf("TestSeriesCRUDSqlite: create cmp failed")
+	}
+	if newEntry.Slug != "go-concurrency" {
+		t.Fatalf("TestSeriesCRUDSqlite: unexpected slug %q", newEntry.Slug)
+	}
+
+	// duplicate name
+	if _, err := seriesRepo.Create(ctxTimeout, *entry); err == nil {
+		t.Fatalf("TestSeriesCRUDSqlite: create duplicate should have failed")
+	}
+
+	// update
+	updated := entities.NewSeries("Go Concurrency Patterns", "desc 2")
+	updatedEntry, err := seriesRepo.Update(ctxTimeout, *updated, newEntry.ID)
+	if err != nil {
+		t.Fatalf("TestSeriesCRUDSqlite: update failed: %s", err)
+	}
+	if updatedEntry.Name != updated.Name || updatedEntry.Slug != "go-concurrency-patterns" {
+		t.Fatalf("TestSeriesCRUDSqlite: update cmp failed")
+	}
+
+	// list
+	list, err := seriesRepo.List(ctxTimeout)
+	if err != nil {
+		t.Fatalf("TestSeriesCRUDSqlite: list failed: %s", err)
+	}
+	if len(list) != 1 {
+		t.Fatalf("TestSeriesCRUDSqlite: list should have 1 series, got %d", len(list))
+	}
+
+	// get
+	outSeries, err := seriesRepo.Get(ctxTimeout, newEntry.ID)
+	if err != nil {
+		t.Fatalf("TestSeriesCRUDSqlite: get failed: %s", err)
+	}
+	if len(outSeries.Parts) != 0 {
+		t.Fatalf("TestSeriesCRUDSqlite: new series should have no parts")
+	}
+
+	// delete
+	affectedRows, err := seriesRepo.Delete(ctxTimeout, newEntry.ID)
+	if err != nil {
+		t.Fatalf("TestSeriesCRUDSqlite: delete failed: %s", err)
+	}
+	if affectedRows != 1 {
+		t.Fatalf("TestSeriesCRUDSqlite: delete should affect 1 row, got %d", affectedRows)
+	}
+	if _, err := seriesRepo.Get(ctxTimeout, newEntry.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("TestSeriesCRUDSqlite: get after delete should return sql.ErrNoRows, got %v", err)
+	}
+}
+
+func TestSeriesPartsSqlite(t *testing.T) {
+	// connect
+	dbConn, err := sql.Open("sqlite3", "file:test.db?mode=memory&_foreign_keys=on")
+	if err != nil {
+		t.Fatalf("TestSeriesPartsSqlite: open db connection failed: %s", err)
+	}
+	defer dbConn.Close()
+
+	// migrate db
+	if err := db.Up(dbConn, db.EmbedMigrationsSQLite, "sqlite3", "migrations/sqlite"); err != nil {
+		t.Fatalf("TestSeriesPartsSqlite: migrate up failed: %s", err)
+	}
+
+	blogsRepo, _, _ := prepareRepos(dbConn)
+	seriesRepo := prepareSeriesRepo(dbConn)
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	series, err := seriesRepo.Create(ctxTimeout, *entities.NewSeries("series 1", "desc"))
+	if err != nil {
+		t.Fatalf("TestSeriesPartsSqlite: create series failed: %s", err)
+	}
+
+	// part 2 is hidden, part 3 is appended to the end
+	newInBlog := func(title string, visible bool, part int) entities.InBlog {
+		inBlog := entities.NewInBlog(*entities.NewBlog(title, "content", "desc", false, visible), []int{}, []int{})
+		inBlog.Series, inBlog.Part = series.ID, part
+		return *inBlog
+	}
+	blog2, err := blogsRepo.Create(ctxTimeout, newInBlog("blog 2", false, 2))
+	if err != nil {
+		t.Fatalf("TestSeriesPartsSqlite: create blog 2 failed: %s", err)
+	}
+	blog1, err := blogsRepo.Create(ctxTimeout, newInBlog("blog 1", true, 1))
+	if err != nil {
+		t.Fatalf("TestSeriesPartsSqlite: create blog 1 failed: %s", err)
+	}
+	blog3, err := blogsRepo.Create(ctxTimeout, newInBlog("blog 3", true, 0))
+	if err != nil {
+		t.Fatalf("TestSeriesPartsSqlite: create blog 3 failed: %s", err)
+	}
+	if blog3.Series == nil || blog3.Series.Part != 3 {
+		t.Fatalf("TestSeriesPartsSqlite: blog 3 should be appended as part 3, got %+v", blog3.Series)
+	}
+
+	// public parts skip hidden blogs
+	outSeries, err := seriesRepo.Get(ctxTimeout, series.ID)
+	if err != nil {
+		t.Fatalf("TestSeriesPartsSqlite: get series failed: %s", err)
+	}
+	publicIDs := []int{}
+	for _, part := range outSeries.Parts {
+		publicIDs = append(publicIDs, part.ID)
+	}
+	if !cmp.Equal(publicIDs, []int{blog1.ID, blog3.ID}) {
+		t.Fatalf("TestSeriesPartsSqlite: unexpected public parts %v", publicIDs)
+	}
+
+	adminSeries, err := seriesRepo.AdminGet(ctxTimeout, series.ID)
+	if err != nil {
+		t.Fatalf("TestSeriesPartsSqlite: admin get series failed: %s", err)
+	}
+	adminIDs := []int{}
+	for _, part := range adminSeries.Parts {
+		adminIDs = append(adminIDs, part.ID)
+	}
+	if !cmp.Equal(adminIDs, []int{blog1.ID, blog2.ID, blog3.ID}) {
+		t.Fatalf("TestSeriesPartsSqlite: unexpected admin parts %v", adminIDs)
+	}
+
+	// prev and next skip the hidden blog
+	outBlog1, err := blogsRepo.Get(ctxTimeout, blog1.ID)
+	if err != nil {
+		t.Fatalf("TestSeriesPartsSqlite: get blog 1 failed: %s", err)
+	}
+	if outBlog1.Prev != nil || outBlog1.Next == nil || outBlog1.Next.ID != blog3.ID {
+		t.Fatalf("TestSeriesPartsSqlite: unexpected prev/next for blog 1: %+v %+v", outBlog1.Prev, outBlog1.Next)
+	}
+	outBlog3, err := blogsRepo.Get(ctxTimeout, blog3.ID)
+	if err != nil {
+		t.Fatalf("TestSeriesPartsSqlite: get blog 3 failed: %s", err)
+	}
+	if outBlog3.Next != nil || outBlog3.Prev == nil || outBlog3.Prev.ID != blog1.ID {
+		t.Fatalf("TestSeriesPartsSqlite: unexpected prev/next for blog 3: %+v %+v", outBlog3.Prev, outBlog3.Next)
+	}
+
+	// update without part keeps the position, series 0 removes the blog from the series
+	if _, err := blogsRepo.Update(ctxTimeout, newInBlog("blog 1", true, 0), blog1.ID, ""); err != nil {
+		t.Fatalf("TestSeriesPartsSqlite: update blog 1 failed: %s", err)
+	}
+	outBlog1, err = blogsRepo.Get(ctxTimeout, blog1.ID)
+	if err != nil {
+		t.Fatalf("TestSeriesPartsSqlite: get blog 1 failed: %s", err)
+	}
+	if outBlog1.Series == nil || outBlog1.Series.Part != 1 {
+		t.Fatalf("TestSeriesPartsSqlite: blog 1 should keep part 1, got %+v", outBlog1.Series)
+	}
+
+	inBlog3 := newInBlog("blog 3", true, 0)
+	inBlog3.Series = 0
+	if _, err := blogsRepo.Update(ctxTimeout, inBlog3, blog3.ID, ""); err != nil {
+		t.Fatalf("TestSeriesPartsSqlite: update blog 3 failed: %s", err)
+	}
+	outBlog3, err = blogsRepo.Get(ctxTimeout, blog3.ID)
+	if err != nil {
+		t.Fatalf("TestSeriesPartsSqlite: get blog 3 failed: %s", err)
+	}
+	if outBlog3.Series != nil || outBlog3.Prev != nil {
+		t.Fatalf("TestSeriesPartsSqlite: blog 3 should no longer be in a series")
+	}
+
+	// deleting the series keeps its blogs
+	if _, err := seriesRepo.Delete(ctxTimeout, series.ID); err != nil {
+		t.Fatalf("TestSeriesPartsSqlite: delete series failed: %s", err)
+	}
+	outBlog1, err = blogsRepo.Get(ctxTimeout, blog1.ID)
+	if err != nil {
+		t.Fatalf("TestSeriesPartsSqlite: get blog 1 after series delete failed: %s", err)
+	}
+	if outBlog1.Series != nil || outBlog1.Next != nil {
+		t.Fatalf("TestSeriesPartsSqlite: blog 1 should no longer be in a series")
+	}
+}
@@ -0,0 +1,124 @@
+package repositories
+
+import (
+	"blog/config"
+	"blog/db/models/interfaces"
+	"blog/entities"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type StatsRepoModels struct {
+	blogs interfaces.BlogsModel
+	stats interfaces.StatsModel
+}
+
+func NewStatsRepoModels(
+	blogs interfaces.BlogsModel,
+	stats interfaces.StatsModel,
+) *StatsRepoModels {
+
+	return &StatsRepoModels{
+		blogs: blogs,
+		stats: stats,
+	}
+}
+
+type Stats struct {
+	db     *sql.DB
+	config config.DBSetting
+	models StatsRepoModels
+}
+
+func NewStats(db *sql.DB, config config.DBSetting, models StatsRepoModels) *Stats {
+	return &Stats{
+		db:     db,
+		config: config,
+		models: models,
+	}
+}
+
+// Only views of visible blogs are counted, returns sql.ErrNoRows otherwise.
+// Visitor hashes of previous days are removed along the way.
+func (s *Stats) RecordView(ctx context.Context, blogID int, day, referrer, visitorHash string) error {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(s.config.Timeout)*time.Second)
+	defer cancel()
+
+	if _, err := s.models.blogs.Get(ctxTimeout, s.db, blogID); err != nil {
+		return fmt.Errorf("RecordView: model get blog failed: %w", err)
+	}
+
+	tx, err := s.db.BeginTx(ctxTimeout, &sql.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("RecordView: begin transaction failed: %w", err)
+	}
+
+	if err := s.models.stats.RecordView(ctxTimeout, tx, day, blogID, referrer, visitorHash); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return fmt.Errorf("RecordView: model record view rollback failed: %w", err)
+		}
+		return fmt.Errorf("RecordView: model record view failed: %w", err)
+	}
+
+	if err := s.models.stats.DeleteVisitorHashesBefore(ctxTimeout, tx, day); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return fmt.Errorf("RecordView: model delete visitor hashes rollback failed: %w", err)
+		}
+		return fmt.Errorf("RecordView: model delete visitor hashes failed: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("RecordView: commit failed: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Stats) ListSummary(ctx context.Context, from, to string) ([]entities.BlogStatsSummary, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(s.config.Timeout)*time.Second)
+	defer cancel()
+
+	summary, err := s.models.stats.ListSummary(ctxTimeout, s.db, from, to)
+	if err != nil {
+		return []entities.BlogStatsSummary{}, fmt.Errorf("ListSummary: model list summary failed: %w", err)
+	}
+
+	return summary, nil
+}
+
+// Stats of any blog regardless of visibility, returns sql.ErrNoRows if the blog doesn't exist
+func (s *Stats) Get(ctx context.Context, blogID int, from, to string) (*entities.BlogStats, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(s.config.Timeout)*time.Second)
+	defer cancel()
+
+	blog, err := s.models.blogs.AdminGet(ctxTimeout, s.db, blogID)
+	if err != nil {
+		return &entities.BlogStats{}, fmt.Errorf("Get: model get blog failed: %w", err)
+	}
+
+	days, err := s.models.stats.ListDaily(ctxTimeout, s.db, blogID, from, to)
+	if err != nil {
+		return &entities.BlogStats{}, fmt.Errorf("Get: model list daily stats failed: %w", err)
+	}
+
+	referrers, err := s.models.stats.ListReferrers(ctxTimeout, s.db, blogID, from, to)
+	if err != nil {
+		return &entities.BlogStats{}, fmt.Errorf("Get: model list referrer stats failed: %w", err)
+	}
+
+	return entities.NewBlogStats(blog.ID, blog.Title, from, to, days, referrers), nil
+}
+
+func (s *Stats) ListPopular(ctx context.Context, from string, limit int) ([]entities.PopularBlog, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(s.config.Timeout)*time.Second)
+	defer cancel()
+
+	blogs, err := s.models.stats.ListPopular(ctxTimeout, s.db, from, limit)
+	if err != nil {
+		return []entities.PopularBlog{}, fmt.Errorf("ListPopular: model list popular blogs failed: %w", err)
+	}
+
+	return blogs, nil
+}
@@ -0,0 +1,125 @@
+package repositories_test
+
+import (
+	"blog/config"
+	"blog/db"
+	"blog/db/models/sqlite"
+	"blog/entities"
+	"blog/repositories"
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestStatsSqlite(t *testing.T) {
+	// connect
+	dbConn, err := sql.Open("sqlite3", "file:test.db?mode=memory&_foreign_keys=on")
+	if err != nil {
+		t.Fatalf("TestStatsSqlite: open db connection failed: %s", err)
+	}
+	defer dbConn.Close()
+
+	// migrate db
+	if err := db.Up(dbConn, db.EmbedMigrationsSQLite, "sqlite3", "migrations/sqlite"); err != nil {
+		t.Fatalf("TestStatsSqlite: migrate up failed: %s", err)
+	}
+
+	blogsRepo, _, _ := prepareRepos(dbConn)
+	statsRepoModels := repositories.NewStatsRepoModels(sqlite.NewBlogs(), sqlite.NewStats())
+	statsRepo := repositories.NewStats(dbConn, config.NewConfig().DB, *statsRepoModels)
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	newBlog := func(title string, visible bool) *entities.OutBlog {
+		inBlog := entities.NewInBlog(*entities.NewBlog(title, "content", "desc", false, visible), []int{}, []int{})
+		blog, err := blogsRepo.Create(ctxTimeout, *inBlog)
+		if err != nil {
+			t.Fatalf("TestStatsSqlite: create blog failed: %s", err)
+		}
+		return blog
+	}
+	blog1 := newBlog("blog 1", true)
+	blog2 := newBlog("blog 2", true)
+	hidden := newBlog("hidden", false)
+
+	if err := statsRepo.RecordView(ctxTimeout, hidden.ID, "2026-10-01", "", "a"); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("TestStatsSqlite: view of hidden blog should return sql.ErrNoRows, got %v", err)
+	}
+
+	views := []struct {
+		blogID   int
+		day      string
+		referrer string
+		hash     string
+	}{
+		{blog1.ID, "2026-10-01", "", "a"},
+		{blog1.ID, "2026-10-01", "example.com", "a"},
+		{blog1.ID, "2026-10-01", "example.com", "b"},
+		{blog2.ID, "2026-10-01", "", "a"},
+		{blog1.ID, "2026-10-02", "", "a"},
+	}
+	for _, view := range views {
+		if err := statsRepo.RecordView(ctxTimeout, view.blogID, view.day, view.referrer, view.hash); err != nil {
+			t.Fatalf("TestStatsSqlite: record view failed: %s", err)
+		}
+	}
+
+	// hashes of previous days are removed
+	var hashes int
+	if err := dbConn.QueryRow(`SELECT COUNT(*) FROM blog_visitor_hashes WHERE day < '2026-10-02';`).Scan(&hashes); err != nil {
+		t.Fatalf("TestStatsSqlite: count visitor hashes failed: %s", err)
+	}
+	if hashes != 0 {
+		t.Fatalf("TestStatsSqlite: old visitor hashes should be removed, got %d", hashes)
+	}
+
+	summary, err := statsRepo.ListSummary(ctxTimeout, "2026-10-01", "2026-10-02")
+	if err != nil {
+		t.Fatalf("TestStatsSqlite: list summary failed: %s", err)
+	}
+	if len(summary) != 2 || summary[0].BlogID != blog1.ID || summary[0].Views != 4 || summary[0].Visitors != 3 {
+		t.Fatalf("TestStatsSqlite: unexpected summary %+v", summary)
+	}
+	if summary[1].BlogID != blog2.ID || summary[1].Views != 1 || summary[1].Visitors != 1 {
+		t.Fatalf("TestStatsSqlite: unexpected summary %+v", summary)
+	}
+
+	stats, err := statsRepo.Get(ctxTimeout, blog1.ID, "2026-10-01", "2026-10-01")
+	if err != nil {
+		t.Fatalf("TestStatsSqlite: get stats failed: %s", err)
+	}
+	if stats.Views != 3 || stats.Visitors != 2 || len(stats.Days) != 1 {
+		t.Fatalf("TestStatsSqlite: unexpected stats %+v", stats)
+	}
+	if len(stats.Referrers) != 2 || stats.Referrers[0].Referrer != "example.com" || stats.Referrers[0].Views != 2 {
+		t.Fatalf("TestStatsSqlite: unexpected referrers %+v", stats.Referrers)
+	}
+	if _, err := statsRepo.Get(ctxTimeout, 999, "2026-10-01", "2026-10-01"); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("TestStatsSqlite: stats of none existent blog should return sql.ErrNoRows, got %v", err)
+	}
+
+	popular, err := statsRepo.ListPopular(ctxTimeout, "2026-10-01", 1)
+	if err != nil {
+		t.Fatalf("TestStatsSqlite: list popular failed: %s", err)
+	}
+	if len(popular) != 1 || popular[0].ID != blog1.ID || popular[0].Views != 4 {
+		t.Fatalf("TestStatsSqlite: unexpected popular blogs %+v", popular)
+	}
+
+	// deleting the blog removes its stats
+	if _, err := blogsRepo.DeleteNow(ctxTimeout, blog1.ID, ""); err != nil {
+		t.Fatalf("TestStatsSqlite: delete blog failed: %s", err)
+	}
+	summary, err = statsRepo.ListSummary(ctxTimeout, "2026-10-01", "2026-10-02")
+	if err != nil {
+		t.Fatalf("TestStatsSqlite: list summary failed: %s", err)
+	}
+	if len(summary) != 1 || summary[0].BlogID != blog2.ID {
+		t.Fatalf("TestStatsSqlite: stats of deleted blog should be removed, got %+v", summary)
+	}
+}
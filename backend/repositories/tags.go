@@ -6,6 +6,7 @@ import (
 	"blog/entities"
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 )
@@ -13,30 +14,35 @@ import (
 type TagsRepoModels struct {
 	blogTags interfaces.BlogTagsModel
 	tags     interfaces.TagsModel
+	outbox   interfaces.WebhookOutboxModel
 }
 
 func NewTagsRepoModels(
 	blogTags interfaces.BlogTagsModel,
 	tags interfaces.TagsModel,
+	outbox interfaces.WebhookOutboxModel,
 ) *TagsRepoModels {
 
 	return &TagsRepoModels{
 		blogTags: blogTags,
 		tags:     tags,
+		outbox:   outbox,
 	}
 }
 
 type Tags struct {
-	db     *sql.DB
-	config config.DBSetting
-	models TagsRepoModels
+	db        *sql.DB
+	config    config.DBSetting
+	models    TagsRepoModels
+	publisher Publisher
 }
 
-func NewTags(db *sql.DB, config config.DBSetting, models TagsRepoModels) *Tags {
+func NewTags(db *sql.DB, config config.DBSetting, models TagsRepoModels, publisher Publisher) *Tags {
 	return &Tags{
-		db:     db,
-		config: config,
-		models: models,
+		db:        db,
+		config:    config,
+		models:    models,
+		publisher: publisher,
 	}
 }
 
@@ -57,10 +63,19 @@ func (t *Tags) Create(ctx context.Context, tag entities.Tag) (*entities.Tag, err
 		return &entities.Tag{}, fmt.Errorf("Create: model create tag failed: %w", err)
 	}
 
+	if err := emitEvent(ctxTimeout, tx, t.models.outbox, entities.EventTagCreated, *newTag); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return &entities.Tag{}, fmt.Errorf("Create: emit event rollback failed: %w", err)
+		}
+		return &entities.Tag{}, fmt.Errorf("Create: emit event failed: %w", err)
+	}
+
 	if err := tx.Commit(); err != nil {
 		return &entities.Tag{}, fmt.Errorf("Create: commit failed: %w", err)
 	}
 
+	publish(t.publisher, entities.EventTagCreated, *newTag)
+
 	return newTag, nil
 }
 
@@ -100,50 +115,150 @@ func (t *Tags) Get(ctx context.Context, id int) (*entities.Tag, error) {
 	return tag, nil
 }
 
-func (t *Tags) Update(ctx context.Context, tag entities.Tag, id int) (*entities.Tag, error) {
+func (t *Tags) Update(ctx context.Context, tag entities.Tag, id int, ifMatch string) (*entities.Tag, error) {
 	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(t.config.Timeout)*time.Second)
 	defer cancel()
 
+	version, err := t.version(ctxTimeout, id, ifMatch)
+	if err != nil {
+		return &entities.Tag{}, fmt.Errorf("Update: %w", err)
+	}
+
 	tx, err := t.db.BeginTx(ctxTimeout, &sql.TxOptions{})
 	if err != nil {
 		return &entities.Tag{}, fmt.Errorf("Update: begin transaction failed: %w", err)
 	}
 
-	newTag, err := t.models.tags.Update(ctxTimeout, tx, tag, id)
+	newTag, err := t.models.tags.Update(ctxTimeout, tx, tag, id, version)
 	if err != nil {
 		if err := tx.Rollback(); err != nil {
 			return &entities.Tag{}, fmt.Errorf("Update: model update tag rollback failed: %w", err)
 		}
+		if errors.Is(err, sql.ErrNoRows) && !version.IsZero() {
+			return &entities.Tag{}, fmt.Errorf("Update: %w", entities.ErrorPreconditionFailed)
+		}
 		return &entities.Tag{}, fmt.Errorf("Update: model update tag failed: %w", err)
 	}
 
+	if err := emitEvent(ctxTimeout, tx, t.models.outbox, entities.EventTagUpdated, *newTag); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return &entities.Tag{}, fmt.Errorf("Update: emit event rollback failed: %w", err)
+		}
+		return &entities.Tag{}, fmt.Errorf("Update: emit event failed: %w", err)
+	}
+
 	if err := tx.Commit(); err != nil {
 		return &entities.Tag{}, fmt.Errorf("Update: commit failed: %w", err)
 	}
 
+	publish(t.publisher, entities.EventTagUpdated, *newTag)
+
+	return newTag, nil
+}
+
+// only changes the fields present in the patch
+func (t *Tags) Patch(ctx context.Context, patch entities.TagPatch, id int, ifMatch string) (*entities.Tag, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(t.config.Timeout)*time.Second)
+	defer cancel()
+
+	version, err := t.version(ctxTimeout, id, ifMatch)
+	if err != nil {
+		return &entities.Tag{}, fmt.Errorf("Patch: %w", err)
+	}
+
+	tx, err := t.db.BeginTx(ctxTimeout, &sql.TxOptions{})
+	if err != nil {
+		return &entities.Tag{}, fmt.Errorf("Patch: begin transaction failed: %w", err)
+	}
+
+	newTag, err := t.models.tags.Patch(ctxTimeout, tx, patch, id, version)
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			return &entities.Tag{}, fmt.Errorf("Patch: model patch tag rollback failed: %w", err)
+		}
+		if errors.Is(err, sql.ErrNoRows) && !version.IsZero() {
+			return &entities.Tag{}, fmt.Errorf("Patch: %w", entities.ErrorPreconditionFailed)
+		}
+		return &entities.Tag{}, fmt.Errorf("Patch: model patch tag failed: %w", err)
+	}
+
+	if err := emitEvent(ctxTimeout, tx, t.models.outbox, entities.EventTagUpdated, *newTag); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return &entities.Tag{}, fmt.Errorf("Patch: emit event rollback failed: %w", err)
+		}
+		return &entities.Tag{}, fmt.Errorf("Patch: emit event failed: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return &entities.Tag{}, fmt.Errorf("Patch: commit failed: %w", err)
+	}
+
+	publish(t.publisher, entities.EventTagUpdated, *newTag)
+
 	return newTag, nil
 }
 
-func (t *Tags) Delete(ctx context.Context, id int) (int, error) {
+func (t *Tags) Delete(ctx context.Context, id int, ifMatch string) (int, error) {
 	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(t.config.Timeout)*time.Second)
 	defer cancel()
 
+	version, err := t.version(ctxTimeout, id, ifMatch)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("Delete: %w", err)
+	}
+
 	tx, err := t.db.BeginTx(ctxTimeout, &sql.TxOptions{})
 	if err != nil {
 		return 0, fmt.Errorf("Delete: begin transaction failed: %w", err)
 	}
 
-	affectedRows, err := t.models.tags.Delete(ctxTimeout, tx, id)
+	affectedRows, err := t.models.tags.Delete(ctxTimeout, tx, id, version)
 	if err != nil {
 		if err := tx.Rollback(); err != nil {
 			return 0, fmt.Errorf("Delete: model delete tag rollback failed: %w", err)
 		}
 		return 0, fmt.Errorf("Delete: model delete tag failed: %w", err)
 	}
+	if affectedRows == 0 && !version.IsZero() {
+		if err := tx.Rollback(); err != nil {
+			return 0, fmt.Errorf("Delete: version check rollback failed: %w", err)
+		}
+		return 0, fmt.Errorf("Delete: %w", entities.ErrorPreconditionFailed)
+	}
+
+	if affectedRows > 0 {
+		if err := emitEvent(ctxTimeout, tx, t.models.outbox, entities.EventTagDeleted, entities.DeletedResource{ID: id}); err != nil {
+			if err := tx.Rollback(); err != nil {
+				return 0, fmt.Errorf("Delete: emit event rollback failed: %w", err)
+			}
+			return 0, fmt.Errorf("Delete: emit event failed: %w", err)
+		}
+	}
 
 	if err := tx.Commit(); err != nil {
 		return 0, fmt.Errorf("Delete: commit failed: %w", err)
 	}
 
+	if affectedRows > 0 {
+		publish(t.publisher, entities.EventTagDeleted, entities.DeletedResource{ID: id})
+	}
+
 	return affectedRows, nil
 }
+
+// version to write against, read first to compare it with If-Match. See expectedVersion
+func (t *Tags) version(ctx context.Context, id int, ifMatch string) (entities.Version, error) {
+	if ifMatch == "" {
+		return 0, nil
+	}
+
+	current, err := t.models.tags.Get(ctx, t.db, id)
+	if err != nil {
+		return 0, fmt.Errorf("version: model get tag failed: %w", err)
+	}
+
+	return expectedVersion(ifMatch, current.Version)
+}
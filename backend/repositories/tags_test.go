@@ -8,6 +8,7 @@ import (
 	"blog/repositories"
 	"context"
 	"database/sql"
+	"errors"
 	"testing"
 	"time"
 
@@ -32,8 +33,8 @@ func TestTagsCreateSqlite(t *testing.T) {
 	// setup repo
 	tagsModel := sqlite.NewTags()
 	blogTagsModel := sqlite.NewBlogTags()
-	tagsRepoModels := repositories.NewTagsRepoModels(blogTagsModel, tagsModel)
-	tagsRepo := repositories.NewTags(dbConn, config.NewConfig().DB, *tagsRepoModels)
+	tagsRepoModels := repositories.NewTagsRepoModels(blogTagsModel, tagsModel, sqlite.NewWebhookOutbox())
+	tagsRepo := repositories.NewTags(dbConn, config.NewConfig().DB, *tagsRepoModels, nil)
 
 	ctxTimeout, cancel := context.WithTimeout(context.Background(), time.Second*10)
 	defer cancel()
@@ -44,7 +45,7 @@ func TestTagsCreateSqlite(t *testing.T) {
 	if err != nil {
 		t.Fatalf("TestTagsCreateSqlite: create failed: %s", err)
 	}
-	if !cmp.Equal(tag1, newTag1, cmpopts.IgnoreFields(entities.Tag{}, "ID", "Created_at", "Updated_at")) {
+	if !cmp.Equal(tag1, newTag1, cmpopts.IgnoreFields(entities.Tag{}, "ID", "Created_at", "Updated_at", "Version")) {
 		t.Fatalf("TestTagsCreateSqlite: create cmp failed")
 	}
 
@@ -73,8 +74,8 @@ func TestTagsUpdateSqlite(t *testing.T) {
 	// setup repo
 	tagsModel := sqlite.NewTags()
 	blogTagsModel := sqlite.NewBlogTags()
-	tagsRepoModels := repositories.NewTagsRepoModels(blogTagsModel, tagsModel)
-	tagsRepo := repositories.NewTags(dbConn, config.NewConfig().DB, *tagsRepoModels)
+	tagsRepoModels := repositories.NewTagsRepoModels(blogTagsModel, tagsModel, sqlite.NewWebhookOutbox())
+	tagsRepo := repositories.NewTags(dbConn, config.NewConfig().DB, *tagsRepoModels, nil)
 
 	ctxTimeout, cancel := context.WithTimeout(context.Background(), time.Second*10)
 	defer cancel()
@@ -88,17 +89,17 @@ func TestTagsUpdateSqlite(t *testing.T) {
 
 	// test update
 	tag3 := entities.NewTag("updated name 3", "updated desc 3")
-	newTag3, err := tagsRepo.Update(ctxTimeout, *tag3, 1)
+	newTag3, err := tagsRepo.Update(ctxTimeout, *tag3, 1, "")
 	if err != nil {
 		t.Fatalf("TestTagsUpdateSqlite: update failed: %s", err)
 	}
-	if !cmp.Equal(tag3, newTag3, cmpopts.IgnoreFields(entities.Tag{}, "ID", "Created_at", "Updated_at")) {
+	if !cmp.Equal(tag3, newTag3, cmpopts.IgnoreFields(entities.Tag{}, "ID", "Created_at", "Updated_at", "Version")) {
 		t.Fatalf("TestTagsUpdateSqlite: update cmp failed")
 	}
 
 	// test update failed becuse of unique constraint
 	tag4 := tag3
-	_, err4 := tagsRepo.Update(ctxTimeout, *tag4, 2)
+	_, err4 := tagsRepo.Update(ctxTimeout, *tag4, 2, "")
 	if err4 == nil {
 		t.Fatalf("TestTagsUpdateSqlite: update sould have failed failed")
 	}
@@ -120,8 +121,8 @@ func TestTagsDeleteSqlite(t *testing.T) {
 	// setup repo
 	tagsModel := sqlite.NewTags()
 	blogTagsModel := sqlite.NewBlogTags()
-	tagsRepoModels := repositories.NewTagsRepoModels(blogTagsModel, tagsModel)
-	tagsRepo := repositories.NewTags(dbConn, config.NewConfig().DB, *tagsRepoModels)
+	tagsRepoModels := repositories.NewTagsRepoModels(blogTagsModel, tagsModel, sqlite.NewWebhookOutbox())
+	tagsRepo := repositories.NewTags(dbConn, config.NewConfig().DB, *tagsRepoModels, nil)
 
 	ctxTimeout, cancel := context.WithTimeout(context.Background(), time.Second*10)
 	defer cancel()
@@ -131,7 +132,7 @@ func TestTagsDeleteSqlite(t *testing.T) {
 	tagsRepo.Create(ctxTimeout, *tag1)
 
 	// test delete
-	affectedRows, err := tagsRepo.Delete(ctxTimeout, 1)
+	affectedRows, err := tagsRepo.Delete(ctxTimeout, 1, "")
 	if err != nil {
 		t.Fatalf("TestTagsDeleteSqlite: delete failed: %s", err)
 	}
@@ -140,7 +141,7 @@ func TestTagsDeleteSqlite(t *testing.T) {
 	}
 
 	// test delete no affected rows
-	affectedRows2, err2 := tagsRepo.Delete(ctxTimeout, 1)
+	affectedRows2, err2 := tagsRepo.Delete(ctxTimeout, 1, "")
 	if err2 != nil {
 		t.Fatalf("TestTagsDeleteSqlite: delete failed: %s", err)
 	}
@@ -150,3 +151,55 @@ func TestTagsDeleteSqlite(t *testing.T) {
 }
 
 // TODO: should fail with foreign key constraint
+
+func TestTagsPatchSqlite(t *testing.T) {
+	// connect
+	dbConn, err := sql.Open("sqlite3", "file:test.db?mode=memory&_foreign_keys=on")
+	if err != nil {
+		t.Fatalf("TestTagsPatchSqlite: open db connection failed: %s", err)
+	}
+	defer dbConn.Close()
+
+	// migrate db
+	if err := db.Up(dbConn, db.EmbedMigrationsSQLite, "sqlite3", "migrations/sqlite"); err != nil {
+		t.Fatalf("TestTagsPatchSqlite: migrate up failed: %s", err)
+	}
+
+	// setup repo
+	tagsModel := sqlite.NewTags()
+	blogTagsModel := sqlite.NewBlogTags()
+	tagsRepoModels := repositories.NewTagsRepoModels(blogTagsModel, tagsModel, sqlite.NewWebhookOutbox())
+	tagsRepo := repositories.NewTags(dbConn, config.NewConfig().DB, *tagsRepoModels, nil)
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	tagsRepo.Create(ctxTimeout, *entities.NewTag("name 1", "desc 1"))
+
+	// description only
+	description := "patched desc"
+	patched, err := tagsRepo.Patch(ctxTimeout, entities.TagPatch{Description: &description}, 1, "")
+	if err != nil {
+		t.Fatalf("TestTagsPatchSqlite: patch failed: %s", err)
+	}
+	if patched.Name != "name 1" || patched.Slug != "name-1" || patched.Description != description {
+		t.Fatalf("TestTagsPatchSqlite: only description should change, got %+v", patched)
+	}
+
+	// name regenerates the slug
+	name := "Patched Name"
+	patch := entities.TagPatch{Name: &name}
+	patch.GenSlug()
+	patched, err = tagsRepo.Patch(ctxTimeout, patch, 1, "")
+	if err != nil {
+		t.Fatalf("TestTagsPatchSqlite: patch failed: %s", err)
+	}
+	if patched.Name != name || patched.Slug != "patched-name" || patched.Description != description {
+		t.Fatalf("TestTagsPatchSqlite: name and slug should change, got %+v", patched)
+	}
+
+	// not found
+	if _, err := tagsRepo.Patch(ctxTimeout, patch, 10, ""); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("TestTagsPatchSqlite: patching a missing tag should fail with no rows, got %v", err)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"blog/entities"
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 )
@@ -13,30 +14,35 @@ import (
 type TopicsRepoModels struct {
 	blogTopics interfaces.BlogTopicsModel
 	topics     interfaces.TopicsModel
+	outbox     interfaces.WebhookOutboxModel
 }
 
 func NewTopicsRepoModels(
 	blogTopics interfaces.BlogTopicsModel,
 	topics interfaces.TopicsModel,
+	outbox interfaces.WebhookOutboxModel,
 ) *TopicsRepoModels {
 
 	return &TopicsRepoModels{
 		blogTopics: blogTopics,
 		topics:     topics,
+		outbox:     outbox,
 	}
 }
 
 type Topics struct {
-	db     *sql.DB
-	config config.DBSetting
-	models TopicsRepoModels
+	db        *sql.DB
+	config    config.DBSetting
+	models    TopicsRepoModels
+	publisher Publisher
 }
 
-func NewTopics(db *sql.DB, config config.DBSetting, models TopicsRepoModels) *Topics {
+func NewTopics(db *sql.DB, config config.DBSetting, models TopicsRepoModels, publisher Publisher) *Topics {
 	return &Topics{
-		db:     db,
-		config: config,
-		models: models,
+		db:        db,
+		config:    config,
+		models:    models,
+		publisher: publisher,
 	}
 }
 
@@ -57,10 +63,19 @@ func (t *Topics) Create(ctx context.Context, topic entities.Topic) (*entities.To
 		return &entities.Topic{}, fmt.Errorf("Create: model create topic failed: %w", err)
 	}
 
+	if err := emitEvent(ctxTimeout, tx, t.models.outbox, entities.EventTopicCreated, *newTopic); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return &entities.Topic{}, fmt.Errorf("Create: emit event rollback failed: %w", err)
+		}
+		return &entities.Topic{}, fmt.Errorf("Create: emit event failed: %w", err)
+	}
+
 	if err := tx.Commit(); err != nil {
 		return &entities.Topic{}, fmt.Errorf("Create: commit failed: %w", err)
 	}
 
+	publish(t.publisher, entities.EventTopicCreated, *newTopic)
+
 	return newTopic, nil
 }
 
@@ -88,50 +103,150 @@ func (t *Topics) Get(ctx context.Context, id int) (*entities.Topic, error) {
 	return topic, nil
 }
 
-func (t *Topics) Update(ctx context.Context, topic entities.Topic, id int) (*entities.Topic, error) {
+func (t *Topics) Update(ctx context.Context, topic entities.Topic, id int, ifMatch string) (*entities.Topic, error) {
 	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(t.config.Timeout)*time.Second)
 	defer cancel()
 
+	version, err := t.version(ctxTimeout, id, ifMatch)
+	if err != nil {
+		return &entities.Topic{}, fmt.Errorf("Update: %w", err)
+	}
+
 	tx, err := t.db.BeginTx(ctxTimeout, &sql.TxOptions{})
 	if err != nil {
 		return &entities.Topic{}, fmt.Errorf("Update: begin transaction failed: %w", err)
 	}
 
-	newTopic, err := t.models.topics.Update(ctxTimeout, tx, topic, id)
+	newTopic, err := t.models.topics.Update(ctxTimeout, tx, topic, id, version)
 	if err != nil {
 		if err := tx.Rollback(); err != nil {
 			return &entities.Topic{}, fmt.Errorf("Update: model update topic rollback failed: %w", err)
 		}
+		if errors.Is(err, sql.ErrNoRows) && !version.IsZero() {
+			return &entities.Topic{}, fmt.Errorf("Update: %w", entities.ErrorPreconditionFailed)
+		}
 		return &entities.Topic{}, fmt.Errorf("Update: model update topic failed: %w", err)
 	}
 
+	if err := emitEvent(ctxTimeout, tx, t.models.outbox, entities.EventTopicUpdated, *newTopic); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return &entities.Topic{}, fmt.Errorf("Update: emit event rollback failed: %w", err)
+		}
+		return &entities.Topic{}, fmt.Errorf("Update: emit event failed: %w", err)
+	}
+
 	if err := tx.Commit(); err != nil {
 		return &entities.Topic{}, fmt.Errorf("Update: commit failed: %w", err)
 	}
 
+	publish(t.publisher, entities.EventTopicUpdated, *newTopic)
+
+	return newTopic, nil
+}
+
+// only changes the fields present in the patch
+func (t *Topics) Patch(ctx context.Context, patch entities.TopicPatch, id int, ifMatch string) (*entities.Topic, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(t.config.Timeout)*time.Second)
+	defer cancel()
+
+	version, err := t.version(ctxTimeout, id, ifMatch)
+	if err != nil {
+		return &entities.Topic{}, fmt.Errorf("Patch: %w", err)
+	}
+
+	tx, err := t.db.BeginTx(ctxTimeout, &sql.TxOptions{})
+	if err != nil {
+		return &entities.Topic{}, fmt.Errorf("Patch: begin transaction failed: %w", err)
+	}
+
+	newTopic, err := t.models.topics.Patch(ctxTimeout, tx, patch, id, version)
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			return &entities.Topic{}, fmt.Errorf("Patch: model patch topic rollback failed: %w", err)
+		}
+		if errors.Is(err, sql.ErrNoRows) && !version.IsZero() {
+			return &entities.Topic{}, fmt.Errorf("Patch: %w", entities.ErrorPreconditionFailed)
+		}
+		return &entities.Topic{}, fmt.Errorf("Patch: model patch topic failed: %w", err)
+	}
+
+	if err := emitEvent(ctxTimeout, tx, t.models.outbox, entities.EventTopicUpdated, *newTopic); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return &entities.Topic{}, fmt.Errorf("Patch: emit event rollback failed: %w", err)
+		}
+		return &entities.Topic{}, fmt.Errorf("Patch: emit event failed: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return &entities.Topic{}, fmt.Errorf("Patch: commit failed: %w", err)
+	}
+
+	publish(t.publisher, entities.EventTopicUpdated, *newTopic)
+
 	return newTopic, nil
 }
 
-func (t *Topics) Delete(ctx context.Context, id int) (int, error) {
+func (t *Topics) Delete(ctx context.Context, id int, ifMatch string) (int, error) {
 	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(t.config.Timeout)*time.Second)
 	defer cancel()
 
+	version, err := t.version(ctxTimeout, id, ifMatch)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("Delete: %w", err)
+	}
+
 	tx, err := t.db.BeginTx(ctxTimeout, &sql.TxOptions{})
 	if err != nil {
 		return 0, fmt.Errorf("Delete: begin transaction failed: %w", err)
 	}
 
-	affectedRows, err := t.models.topics.Delete(ctxTimeout, tx, id)
+	affectedRows, err := t.models.topics.Delete(ctxTimeout, tx, id, version)
 	if err != nil {
 		if err := tx.Rollback(); err != nil {
 			return 0, fmt.Errorf("Delete: model delete topic rollback failed: %w", err)
 		}
 		return 0, fmt.Errorf("Delete: model delete topic failed: %w", err)
 	}
+	if affectedRows == 0 && !version.IsZero() {
+		if err := tx.Rollback(); err != nil {
+			return 0, fmt.Errorf("Delete: version check rollback failed: %w", err)
+		}
+		return 0, fmt.Errorf("Delete: %w", entities.ErrorPreconditionFailed)
+	}
+
+	if affectedRows > 0 {
+		if err := emitEvent(ctxTimeout, tx, t.models.outbox, entities.EventTopicDeleted, entities.DeletedResource{ID: id}); err != nil {
+			if err := tx.Rollback(); err != nil {
+				return 0, fmt.Errorf("Delete: emit event rollback failed: %w", err)
+			}
+			return 0, fmt.Errorf("Delete: emit event failed: %w", err)
+		}
+	}
 
 	if err := tx.Commit(); err != nil {
 		return 0, fmt.Errorf("Delete: commit failed: %w", err)
 	}
 
+	if affectedRows > 0 {
+		publish(t.publisher, entities.EventTopicDeleted, entities.DeletedResource{ID: id})
+	}
+
 	return affectedRows, nil
 }
+
+// version to write against, read first to compare it with If-Match. See expectedVersion
+func (t *Topics) version(ctx context.Context, id int, ifMatch string) (entities.Version, error) {
+	if ifMatch == "" {
+		return 0, nil
+	}
+
+	current, err := t.models.topics.Get(ctx, t.db, id)
+	if err != nil {
+		return 0, fmt.Errorf("version: model get topic failed: %w", err)
+	}
+
+	return expectedVersion(ifMatch, current.Version)
+}
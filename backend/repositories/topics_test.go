@@ -32,8 +32,8 @@ func TestTopicsCreateSqlite(t *testing.T) {
 	// setup repo
 	topicsModel := sqlite.NewTopics()
 	blogTopicsModel := sqlite.NewBlogTopics()
-	topicsRepoModels := repositories.NewTopicsRepoModels(blogTopicsModel, topicsModel)
-	topicsRepo := repositories.NewTopics(dbConn, config.NewConfig().DB, *topicsRepoModels)
+	topicsRepoModels := repositories.NewTopicsRepoModels(blogTopicsModel, topicsModel, sqlite.NewWebhookOutbox())
+	topicsRepo := repositories.NewTopics(dbConn, config.NewConfig().DB, *topicsRepoModels, nil)
 
 	ctxTimeout, cancel := context.WithTimeout(context.Background(), time.Second*10)
 	defer cancel()
@@ -44,7 +44,7 @@ func TestTopicsCreateSqlite(t *testing.T) {
 	if err != nil {
 		t.Fatalf("TestTopicsCreateSqlite: create failed: %s", err)
 	}
-	if !cmp.Equal(entry1, newEntry1, cmpopts.IgnoreFields(entities.Topic{}, "ID", "Created_at", "Updated_at")) {
+	if !cmp.Equal(entry1, newEntry1, cmpopts.IgnoreFields(entities.Topic{}, "ID", "Created_at", "Updated_at", "Version")) {
 		t.Fatalf("TestTopicsCreateSqlite: create cmp failed")
 	}
 
@@ -73,8 +73,8 @@ func TestTopicsUpdateSqlite(t *testing.T) {
 	// setup repo
 	topicsModel := sqlite.NewTopics()
 	blogTopicsModel := sqlite.NewBlogTopics()
-	topicsRepoModels := repositories.NewTopicsRepoModels(blogTopicsModel, topicsModel)
-	topicsRepo := repositories.NewTopics(dbConn, config.NewConfig().DB, *topicsRepoModels)
+	topicsRepoModels := repositories.NewTopicsRepoModels(blogTopicsModel, topicsModel, sqlite.NewWebhookOutbox())
+	topicsRepo := repositories.NewTopics(dbConn, config.NewConfig().DB, *topicsRepoModels, nil)
 
 	ctxTimeout, cancel := context.WithTimeout(context.Background(), time.Second*10)
 	defer cancel()
@@ -88,17 +88,17 @@ func TestTopicsUpdateSqlite(t *testing.T) {
 
 	// test update
 	topic3 := entities.NewTopic("updated name 3", "updated desc 3")
-	newTopic3, err := topicsRepo.Update(ctxTimeout, *topic3, 1)
+	newTopic3, err := topicsRepo.Update(ctxTimeout, *topic3, 1, "")
 	if err != nil {
 		t.Fatalf("TestTopicsUpdateSqlite: update failed: %s", err)
 	}
-	if !cmp.Equal(topic3, newTopic3, cmpopts.IgnoreFields(entities.Topic{}, "ID", "Created_at", "Updated_at")) {
+	if !cmp.Equal(topic3, newTopic3, cmpopts.IgnoreFields(entities.Topic{}, "ID", "Created_at", "Updated_at", "Version")) {
 		t.Fatalf("TestTopicsUpdateSqlite: update cmp failed")
 	}
 
 	// test update failed becuse of unique constraint
 	topic4 := topic3
-	_, err4 := topicsRepo.Update(ctxTimeout, *topic4, 2)
+	_, err4 := topicsRepo.Update(ctxTimeout, *topic4, 2, "")
 	if err4 == nil {
 		t.Fatalf("TestTopicsUpdateSqlite: update sould have failed failed")
 	}
@@ -120,8 +120,8 @@ func TestTopicsDeleteSqlite(t *testing.T) {
 	// setup repo
 	topicsModel := sqlite.NewTopics()
 	blogTopicsModel := sqlite.NewBlogTopics()
-	topicsRepoModels := repositories.NewTopicsRepoModels(blogTopicsModel, topicsModel)
-	topicsRepo := repositories.NewTopics(dbConn, config.NewConfig().DB, *topicsRepoModels)
+	topicsRepoModels := repositories.NewTopicsRepoModels(blogTopicsModel, topicsModel, sqlite.NewWebhookOutbox())
+	topicsRepo := repositories.NewTopics(dbConn, config.NewConfig().DB, *topicsRepoModels, nil)
 
 	ctxTimeout, cancel := context.WithTimeout(context.Background(), time.Second*10)
 	defer cancel()
@@ -131,7 +131,7 @@ func TestTopicsDeleteSqlite(t *testing.T) {
 	topicsRepo.Create(ctxTimeout, *topic1)
 
 	// test delete
-	affectedRows, err := topicsRepo.Delete(ctxTimeout, 1)
+	affectedRows, err := topicsRepo.Delete(ctxTimeout, 1, "")
 	if err != nil {
 		t.Fatalf("TestTopicsDeleteSqlite: delete failed: %s", err)
 	}
@@ -140,7 +140,7 @@ func TestTopicsDeleteSqlite(t *testing.T) {
 	}
 
 	// test delete no affected rows
-	affectedRows2, err2 := topicsRepo.Delete(ctxTimeout, 1)
+	affectedRows2, err2 := topicsRepo.Delete(ctxTimeout, 1, "")
 	if err2 != nil {
 		t.Fatalf("TestTopicsDeleteSqlite: delete failed: %s", err)
 	}
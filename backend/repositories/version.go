@@ -0,0 +1,27 @@
+package repositories
+
+import (
+	"blog/entities"
+)
+
+/*
+Version a write has to be made against for optimistic concurrency control.
+Without If-Match the zero version is returned and the write always goes through.
+*/
+func expectedVersion(ifMatch string, current entities.Version) (entities.Version, error) {
+	if ifMatch == "" {
+		return 0, nil
+	}
+	if !entities.IfMatch(ifMatch, current.ETag()) {
+		return 0, entities.ErrorPreconditionFailed
+	}
+	return current, nil
+}
+
+// error of a write made against the version read in its transaction, when the row changed in between
+func changedSinceRead(ifMatch string) error {
+	if ifMatch != "" {
+		return entities.ErrorPreconditionFailed
+	}
+	return entities.ErrorConcurrentChange
+}
@@ -0,0 +1,337 @@
+package repositories
+
+import (
+	"blog/config"
+	"blog/db/models/interfaces"
+	"blog/entities"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+type WebhooksRepoModels struct {
+	webhooks   interfaces.WebhooksModel
+	outbox     interfaces.WebhookOutboxModel
+	deliveries interfaces.WebhookDeliveriesModel
+}
+
+func NewWebhooksRepoModels(
+	webhooks interfaces.WebhooksModel,
+	outbox interfaces.WebhookOutboxModel,
+	deliveries interfaces.WebhookDeliveriesModel,
+) *WebhooksRepoModels {
+
+	return &WebhooksRepoModels{
+		webhooks:   webhooks,
+		outbox:     outbox,
+		deliveries: deliveries,
+	}
+}
+
+type Webhooks struct {
+	db     *sql.DB
+	config config.DBSetting
+	models WebhooksRepoModels
+}
+
+func NewWebhooks(db *sql.DB, config config.DBSetting, models WebhooksRepoModels) *Webhooks {
+	return &Webhooks{
+		db:     db,
+		config: config,
+		models: models,
+	}
+}
+
+func (w *Webhooks) Create(ctx context.Context, webhook entities.Webhook) (*entities.Webhook, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(w.config.Timeout)*time.Second)
+	defer cancel()
+
+	tx, err := w.db.BeginTx(ctxTimeout, &sql.TxOptions{})
+	if err != nil {
+		return &entities.Webhook{}, fmt.Errorf("Create: begin transaction failed: %w", err)
+	}
+
+	newWebhook, err := w.models.webhooks.Create(ctxTimeout, tx, webhook)
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			return &entities.Webhook{}, fmt.Errorf("Create: model create webhook rollback failed: %w", err)
+		}
+		return &entities.Webhook{}, fmt.Errorf("Create: model create webhook failed: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return &entities.Webhook{}, fmt.Errorf("Create: commit failed: %w", err)
+	}
+
+	return newWebhook, nil
+}
+
+func (w *Webhooks) List(ctx context.Context) ([]entities.Webhook, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(w.config.Timeout)*time.Second)
+	defer cancel()
+
+	webhooks, err := w.models.webhooks.List(ctxTimeout, w.db)
+	if err != nil {
+		return []entities.Webhook{}, fmt.Errorf("List: model list webhooks failed: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+func (w *Webhooks) Get(ctx context.Context, id int) (*entities.Webhook, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(w.config.Timeout)*time.Second)
+	defer cancel()
+
+	webhook, err := w.models.webhooks.Get(ctxTimeout, w.db, id)
+	if err != nil {
+		return &entities.Webhook{}, fmt.Errorf("Get: model get webhook failed: %w", err)
+	}
+
+	return webhook, nil
+}
+
+func (w *Webhooks) Update(ctx context.Context, webhook entities.Webhook, id int) (*entities.Webhook, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(w.config.Timeout)*time.Second)
+	defer cancel()
+
+	tx, err := w.db.BeginTx(ctxTimeout, &sql.TxOptions{})
+	if err != nil {
+		return &entities.Webhook{}, fmt.Errorf("Update: begin transaction failed: %w", err)
+	}
+
+	newWebhook, err := w.models.webhooks.Update(ctxTimeout, tx, webhook, id)
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			return &entities.Webhook{}, fmt.Errorf("Update: model update webhook rollback failed: %w", err)
+		}
+		return &entities.Webhook{}, fmt.Errorf("Update: model update webhook failed: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return &entities.Webhook{}, fmt.Errorf("Update: commit failed: %w", err)
+	}
+
+	return newWebhook, nil
+}
+
+// Deletes the webhook together with its delivery log
+func (w *Webhooks) Delete(ctx context.Context, id int) (int, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(w.config.Timeout)*time.Second)
+	defer cancel()
+
+	tx, err := w.db.BeginTx(ctxTimeout, &sql.TxOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("Delete: begin transaction failed: %w", err)
+	}
+
+	if err := w.models.deliveries.DeleteByWebhookID(ctxTimeout, tx, id); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return 0, fmt.Errorf("Delete: model delete deliveries rollback failed: %w", err)
+		}
+		return 0, fmt.Errorf("Delete: model delete deliveries failed: %w", err)
+	}
+
+	affectedRows, err := w.models.webhooks.Delete(ctxTimeout, tx, id)
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			return 0, fmt.Errorf("Delete: model delete webhook rollback failed: %w", err)
+		}
+		return 0, fmt.Errorf("Delete: model delete webhook failed: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("Delete: commit failed: %w", err)
+	}
+
+	return affectedRows, nil
+}
+
+// Newest first, returns sql.ErrNoRows if the webhook doesn't exist
+func (w *Webhooks) ListDeliveries(ctx context.Context, webhookID, limit int) ([]entities.WebhookDelivery, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(w.config.Timeout)*time.Second)
+	defer cancel()
+
+	if _, err := w.models.webhooks.Get(ctxTimeout, w.db, webhookID); err != nil {
+		return []entities.WebhookDelivery{}, fmt.Errorf("ListDeliveries: model get webhook failed: %w", err)
+	}
+
+	deliveries, err := w.models.deliveries.ListByWebhookID(ctxTimeout, w.db, webhookID, limit)
+	if err != nil {
+		return []entities.WebhookDelivery{}, fmt.Errorf("ListDeliveries: model list deliveries failed: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// Queues the delivery to be sent again as soon as possible, returns sql.ErrNoRows if it doesn't exist
+func (w *Webhooks) Replay(ctx context.Context, deliveryID int) (*entities.WebhookDelivery, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(w.config.Timeout)*time.Second)
+	defer cancel()
+
+	tx, err := w.db.BeginTx(ctxTimeout, &sql.TxOptions{})
+	if err != nil {
+		return &entities.WebhookDelivery{}, fmt.Errorf("Replay: begin transaction failed: %w", err)
+	}
+
+	delivery, err := w.models.deliveries.Replay(ctxTimeout, tx, deliveryID)
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			return &entities.WebhookDelivery{}, fmt.Errorf("Replay: model replay delivery rollback failed: %w", err)
+		}
+		return &entities.WebhookDelivery{}, fmt.Errorf("Replay: model replay delivery failed: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return &entities.WebhookDelivery{}, fmt.Errorf("Replay: commit failed: %w", err)
+	}
+
+	return delivery, nil
+}
+
+// Turns events in the outbox into deliveries of subscribed webhooks.
+// Paused webhooks don't receive events that happened while paused.
+// Returns the number of events dispatched.
+func (w *Webhooks) FanOut(ctx context.Context, limit int) (int, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(w.config.Timeout)*time.Second)
+	defer cancel()
+
+	events, err := w.models.outbox.ListUndispatched(ctxTimeout, w.db, limit)
+	if err != nil {
+		return 0, fmt.Errorf("FanOut: model list undispatched events failed: %w", err)
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	webhooks, err := w.models.webhooks.List(ctxTimeout, w.db)
+	if err != nil {
+		return 0, fmt.Errorf("FanOut: model list webhooks failed: %w", err)
+	}
+
+	tx, err := w.db.BeginTx(ctxTimeout, &sql.TxOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("FanOut: begin transaction failed: %w", err)
+	}
+
+	for _, event := range events {
+		for _, webhook := range webhooks {
+			if webhook.Paused || !webhook.Subscribed(event.Type) {
+				continue
+			}
+			if err := w.models.deliveries.Create(ctxTimeout, tx, webhook.ID, event.ID); err != nil {
+				if err := tx.Rollback(); err != nil {
+					return 0, fmt.Errorf("FanOut: model create delivery rollback failed: %w", err)
+				}
+				return 0, fmt.Errorf("FanOut: model create delivery failed: %w", err)
+			}
+		}
+
+		if err := w.models.outbox.MarkDispatched(ctxTimeout, tx, event.ID); err != nil {
+			if err := tx.Rollback(); err != nil {
+				return 0, fmt.Errorf("FanOut: model mark dispatched rollback failed: %w", err)
+			}
+			return 0, fmt.Errorf("FanOut: model mark dispatched failed: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("FanOut: commit failed: %w", err)
+	}
+
+	return len(events), nil
+}
+
+// Pending deliveries due at the given time, oldest first
+func (w *Webhooks) ListDueDeliveries(ctx context.Context, now time.Time, limit int) ([]entities.WebhookDelivery, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(w.config.Timeout)*time.Second)
+	defer cancel()
+
+	deliveries, err := w.models.deliveries.ListDue(ctxTimeout, w.db, now.UTC().Format("2006-01-02T15:04:05-07:00"), limit)
+	if err != nil {
+		return []entities.WebhookDelivery{}, fmt.Errorf("ListDueDeliveries: model list due deliveries failed: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// Saves the result of a delivery attempt
+func (w *Webhooks) SaveAttempt(ctx context.Context, delivery entities.WebhookDelivery) error {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(w.config.Timeout)*time.Second)
+	defer cancel()
+
+	tx, err := w.db.BeginTx(ctxTimeout, &sql.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("SaveAttempt: begin transaction failed: %w", err)
+	}
+
+	if err := w.models.deliveries.UpdateAttempt(ctxTimeout, tx, delivery); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return fmt.Errorf("SaveAttempt: model update attempt rollback failed: %w", err)
+		}
+		return fmt.Errorf("SaveAttempt: model update attempt failed: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("SaveAttempt: commit failed: %w", err)
+	}
+
+	return nil
+}
+
+// Removes finished deliveries and dispatched events older than the given time.
+// Returns the number of deliveries removed.
+func (w *Webhooks) Purge(ctx context.Context, before time.Time) (int, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Duration(w.config.Timeout)*time.Second)
+	defer cancel()
+
+	ts := before.UTC().Format("2006-01-02T15:04:05-07:00")
+
+	tx, err := w.db.BeginTx(ctxTimeout, &sql.TxOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("Purge: begin transaction failed: %w", err)
+	}
+
+	affectedRows, err := w.models.deliveries.DeleteFinishedBefore(ctxTimeout, tx, ts)
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			return 0, fmt.Errorf("Purge: model delete deliveries rollback failed: %w", err)
+		}
+		return 0, fmt.Errorf("Purge: model delete deliveries failed: %w", err)
+	}
+
+	if _, err := w.models.outbox.DeleteDispatchedBefore(ctxTimeout, tx, ts); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return 0, fmt.Errorf("Purge: model delete events rollback failed: %w", err)
+		}
+		return 0, fmt.Errorf("Purge: model delete events failed: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("Purge: commit failed: %w", err)
+	}
+
+	return affectedRows, nil
+}
+
+// Writes an event to the outbox in the transaction of the change,
+// so that webhooks are only notified of committed changes.
+func emitEvent(ctx context.Context, tx *sql.Tx, outbox interfaces.WebhookOutboxModel, eventType string, data any) error {
+	rawData, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("emitEvent: marshal %s data failed: %w", eventType, err)
+	}
+
+	if err := outbox.Create(ctx, tx, eventType, rawData); err != nil {
+		return fmt.Errorf("emitEvent: model create %s event failed: %w", eventType, err)
+	}
+
+	return nil
+}
+
+// Blogs are sent without content to keep requests small
+func blogEventData(blog entities.Blog) entities.Blog {
+	blog.Content = ""
+	return blog
+}
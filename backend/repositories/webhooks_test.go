@@ -0,0 +1,188 @@
+package repositories_test
+
+import (
+	"blog/config"
+	"blog/db"
+	"blog/db/models/sqlite"
+	"blog/entities"
+	"blog/repositories"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestWebhooksSqlite(t *testing.T) {
+	// connect
+	dbConn, err := sql.Open("sqlite3", "file:test.db?mode=memory&_foreign_keys=on")
+	if err != nil {
+		t.Fatalf("TestWebhooksSqlite: open db connection failed: %s", err)
+	}
+	defer dbConn.Close()
+
+	// migrate db
+	if err := db.Up(dbConn, db.EmbedMigrationsSQLite, "sqlite3", "migrations/sqlite"); err != nil {
+		t.Fatalf("TestWebhooksSqlite: migrate up failed: %s", err)
+	}
+
+	blogsRepo, tagsRepo, _ := prepareRepos(dbConn)
+	webhooksRepoModels := repositories.NewWebhooksRepoModels(sqlite.NewWebhooks(), sqlite.NewWebhookOutbox(), sqlite.NewWebhookDeliveries())
+	webhooksRepo := repositories.NewWebhooks(dbConn, config.NewConfig().DB, *webhooksRepoModels)
+
+	ctxTimeout, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	all, err := webhooksRepo.Create(ctxTimeout, *entities.NewWebhook("http://all", "secret", []string{entities.EventAll}, false))
+	if err != nil {
+		t.Fatalf("TestWebhooksSqlite: create webhook failed: %s", err)
+	}
+	tagsOnly, err := webhooksRepo.Create(ctxTimeout, *entities.NewWebhook("http://tags", "secret", []string{entities.EventTagCreated, entities.EventTagDeleted}, false))
+	if err != nil {
+		t.Fatalf("TestWebhooksSqlite: create webhook failed: %s", err)
+	}
+	paused, err := webhooksRepo.Create(ctxTimeout, *entities.NewWebhook("http://paused", "secret", []string{entities.EventAll}, true))
+	if err != nil {
+		t.Fatalf("TestWebhooksSqlite: create webhook failed: %s", err)
+	}
+
+	// empty secret keeps the current one
+	updated, err := webhooksRepo.Update(ctxTimeout, *entities.NewWebhook("http://tags", "", tagsOnly.Events, false), tagsOnly.ID)
+	if err != nil {
+		t.Fatalf("TestWebhooksSqlite: update webhook failed: %s", err)
+	}
+	if updated.Secret != "secret" || len(updated.Events) != 2 {
+		t.Fatalf("TestWebhooksSqlite: unexpected updated webhook %+v", updated)
+	}
+
+	// changes write events to the outbox
+	inBlog := entities.NewInBlog(*entities.NewBlog("blog", "content", "desc", false, true), []int{}, []int{})
+	blog, err := blogsRepo.Create(ctxTimeout, *inBlog)
+	if err != nil {
+		t.Fatalf("TestWebhooksSqlite: create blog failed: %s", err)
+	}
+	if _, err := tagsRepo.Create(ctxTimeout, *entities.NewTag("tag", "desc")); err != nil {
+		t.Fatalf("TestWebhooksSqlite: create tag failed: %s", err)
+	}
+	if _, err := blogsRepo.SoftDelete(ctxTimeout, blog.ID, "admin", ""); err != nil {
+		t.Fatalf("TestWebhooksSqlite: soft delete blog failed: %s", err)
+	}
+	// nothing deleted, no event
+	if _, err := blogsRepo.SoftDelete(ctxTimeout, 999, "admin", ""); err != nil {
+		t.Fatalf("TestWebhooksSqlite: soft delete blog failed: %s", err)
+	}
+
+	dispatched, err := webhooksRepo.FanOut(ctxTimeout, 100)
+	if err != nil {
+		t.Fatalf("TestWebhooksSqlite: fan out failed: %s", err)
+	}
+	if dispatched != 3 {
+		t.Fatalf("TestWebhooksSqlite: should dispatch 3 events, got %d", dispatched)
+	}
+	if dispatched, _ := webhooksRepo.FanOut(ctxTimeout, 100); dispatched != 0 {
+		t.Fatalf("TestWebhooksSqlite: events should only be dispatched once, got %d", dispatched)
+	}
+
+	allDeliveries, err := webhooksRepo.ListDeliveries(ctxTimeout, all.ID, 50)
+	if err != nil {
+		t.Fatalf("TestWebhooksSqlite: list deliveries failed: %s", err)
+	}
+	if len(allDeliveries) != 3 || allDeliveries[2].Event.Type != entities.EventBlogCreated {
+		t.Fatalf("TestWebhooksSqlite: unexpected deliveries %+v", allDeliveries)
+	}
+	sentBlog := entities.Blog{}
+	if err := json.Unmarshal(allDeliveries[2].Event.Data, &sentBlog); err != nil {
+		t.Fatalf("TestWebhooksSqlite: unmarshal event data failed: %s", err)
+	}
+	if sentBlog.ID != blog.ID || sentBlog.Title != "blog" || sentBlog.Content != "" {
+		t.Fatalf("TestWebhooksSqlite: blog should be sent without content, got %+v", sentBlog)
+	}
+
+	tagDeliveries, err := webhooksRepo.ListDeliveries(ctxTimeout, tagsOnly.ID, 50)
+	if err != nil {
+		t.Fatalf("TestWebhooksSqlite: list deliveries failed: %s", err)
+	}
+	if len(tagDeliveries) != 1 || tagDeliveries[0].Event.Type != entities.EventTagCreated {
+		t.Fatalf("TestWebhooksSqlite: should only deliver subscribed events, got %+v", tagDeliveries)
+	}
+
+	pausedDeliveries, err := webhooksRepo.ListDeliveries(ctxTimeout, paused.ID, 50)
+	if err != nil {
+		t.Fatalf("TestWebhooksSqlite: list deliveries failed: %s", err)
+	}
+	if len(pausedDeliveries) != 0 {
+		t.Fatalf("TestWebhooksSqlite: paused webhooks should not get deliveries, got %+v", pausedDeliveries)
+	}
+
+	if _, err := webhooksRepo.ListDeliveries(ctxTimeout, 999, 50); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("TestWebhooksSqlite: list deliveries of none existent webhook should return sql.ErrNoRows, got %v", err)
+	}
+
+	due, err := webhooksRepo.ListDueDeliveries(ctxTimeout, time.Now().Add(time.Minute), 100)
+	if err != nil {
+		t.Fatalf("TestWebhooksSqlite: list due deliveries failed: %s", err)
+	}
+	if len(due) != 4 {
+		t.Fatalf("TestWebhooksSqlite: should have 4 due deliveries, got %d", len(due))
+	}
+
+	// retry later
+	retry := due[0]
+	retry.Attempts = 1
+	retry.ResponseStatus = 500
+	retry.Error = "unexpected status 500"
+	retry.NextAttemptAt = time.Now().Add(time.Hour).UTC().Format("2006-01-02T15:04:05-07:00")
+	if err := webhooksRepo.SaveAttempt(ctxTimeout, retry); err != nil {
+		t.Fatalf("TestWebhooksSqlite: save attempt failed: %s", err)
+	}
+	succeeded := due[1]
+	succeeded.Attempts = 1
+	succeeded.Status = entities.DeliverySucceeded
+	if err := webhooksRepo.SaveAttempt(ctxTimeout, succeeded); err != nil {
+		t.Fatalf("TestWebhooksSqlite: save attempt failed: %s", err)
+	}
+
+	due, err = webhooksRepo.ListDueDeliveries(ctxTimeout, time.Now().Add(time.Minute), 100)
+	if err != nil {
+		t.Fatalf("TestWebhooksSqlite: list due deliveries failed: %s", err)
+	}
+	if len(due) != 2 {
+		t.Fatalf("TestWebhooksSqlite: retried and succeeded deliveries should not be due, got %d", len(due))
+	}
+
+	// replay sends the delivery again right away
+	replayed, err := webhooksRepo.Replay(ctxTimeout, retry.ID)
+	if err != nil {
+		t.Fatalf("TestWebhooksSqlite: replay failed: %s", err)
+	}
+	if replayed.Status != entities.DeliveryPending || replayed.Attempts != 0 || replayed.Error != "" {
+		t.Fatalf("TestWebhooksSqlite: unexpected replayed delivery %+v", replayed)
+	}
+	if _, err := webhooksRepo.Replay(ctxTimeout, 999); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("TestWebhooksSqlite: replay none existent delivery should return sql.ErrNoRows, got %v", err)
+	}
+
+	// only finished deliveries are purged
+	purged, err := webhooksRepo.Purge(ctxTimeout, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("TestWebhooksSqlite: purge failed: %s", err)
+	}
+	if purged != 1 {
+		t.Fatalf("TestWebhooksSqlite: should purge the succeeded delivery, got %d", purged)
+	}
+
+	// deleting a webhook removes its deliveries
+	if _, err := webhooksRepo.Delete(ctxTimeout, all.ID); err != nil {
+		t.Fatalf("TestWebhooksSqlite: delete webhook failed: %s", err)
+	}
+	due, err = webhooksRepo.ListDueDeliveries(ctxTimeout, time.Now().Add(time.Minute), 100)
+	if err != nil {
+		t.Fatalf("TestWebhooksSqlite: list due deliveries failed: %s", err)
+	}
+	if len(due) != 1 || due[0].WebhookID != tagsOnly.ID {
+		t.Fatalf("TestWebhooksSqlite: deliveries of deleted webhook should be removed, got %+v", due)
+	}
+}
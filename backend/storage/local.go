@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+var ErrorInvalidHash = errors.New("invalid hash")
+
+var hashRegexp = regexp.MustCompile(`^[a-f0-9]{64}$`)
+
+// Content-addressed file storage on local disk.
+// Files are named by the sha256 of their content and sharded by the first two characters,
+// ex: <root>/ab/abcdef...
+type Local struct {
+	root string
+}
+
+func NewLocal(root string) *Local {
+	return &Local{
+		root: root,
+	}
+}
+
+// Creates the root directory if it doesn't exist
+func (l *Local) Prepare() error {
+	if err := os.MkdirAll(l.root, 0o755); err != nil {
+		return fmt.Errorf("Prepare: create root directory failed: %w", err)
+	}
+	return nil
+}
+
+// Write content to storage, returns the sha256 hash and size.
+// Storing the same content twice is a no-op.
+func (l *Local) Put(r io.Reader) (string, int64, error) {
+	tmp, err := os.CreateTemp(l.root, ".upload-*")
+	if err != nil {
+		return "", 0, fmt.Errorf("Put: create temp file failed: %w", err)
+	}
+	// no-op after successful rename
+	defer os.Remove(tmp.Name())
+
+	hasher := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hasher), r)
+	if err != nil {
+		tmp.Close()
+		return "", 0, fmt.Errorf("Put: write temp file failed: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", 0, fmt.Errorf("Put: close temp file failed: %w", err)
+	}
+
+	hash := fmt.Sprintf("%x", hasher.Sum(nil))
+	target := l.path(hash)
+
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return "", 0, fmt.Errorf("Put: create directory failed: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return "", 0, fmt.Errorf("Put: rename temp file failed: %w", err)
+	}
+
+	return hash, size, nil
+}
+
+// Caller should close the file
+func (l *Local) Open(hash string) (*os.File, error) {
+	if !hashRegexp.MatchString(hash) {
+		return nil, ErrorInvalidHash
+	}
+
+	file, err := os.Open(l.path(hash))
+	if err != nil {
+		return nil, fmt.Errorf("Open: open file failed: %w", err)
+	}
+	return file, nil
+}
+
+// Removing a file that doesn't exist is not an error
+func (l *Local) Remove(hash string) error {
+	if !hashRegexp.MatchString(hash) {
+		return ErrorInvalidHash
+	}
+
+	if err := os.Remove(l.path(hash)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("Remove: remove file failed: %w", err)
+	}
+	return nil
+}
+
+func (l *Local) path(hash string) string {
+	return filepath.Join(l.root, hash[:2], hash)
+}
@@ -0,0 +1,229 @@
+package storage
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/sync/singleflight"
+)
+
+var ErrorInvalidVariantKey = errors.New("invalid variant key")
+
+// ex: <sha256>_800.jpeg
+var variantKeyRegexp = regexp.MustCompile(`^[a-f0-9]{64}_[0-9]+\.[a-z]+$`)
+
+type variantEntry struct {
+	key  string
+	size int64
+}
+
+// Disk cache for generated media variants (resized, converted...etc).
+// Total size is capped, least recently used variants are evicted first.
+// Everything in here can be regenerated from the original media.
+type Variants struct {
+	root    string
+	maxSize int64
+
+	mu      sync.Mutex
+	size    int64
+	lru     *list.List // front is most recently used
+	entries map[string]*list.Element
+
+	group singleflight.Group
+}
+
+func NewVariants(root string, maxSize int64) *Variants {
+	return &Variants{
+		root:    root,
+		maxSize: maxSize,
+		lru:     list.New(),
+		entries: map[string]*list.Element{},
+	}
+}
+
+// Creates the root directory and loads existing variants,
+// using modification time as the initial usage order.
+func (v *Variants) Prepare() error {
+	if err := os.MkdirAll(v.root, 0o755); err != nil {
+		return fmt.Errorf("Prepare: create root directory failed: %w", err)
+	}
+
+	dirEntries, err := os.ReadDir(v.root)
+	if err != nil {
+		return fmt.Errorf("Prepare: read root directory failed: %w", err)
+	}
+
+	infos := []os.FileInfo{}
+	for _, entry := range dirEntries {
+		if entry.IsDir() || !variantKeyRegexp.MatchString(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return fmt.Errorf("Prepare: stat variant failed: %w", err)
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ModTime().After(infos[j].ModTime())
+	})
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for _, info := range infos {
+		element := v.lru.PushBack(&variantEntry{key: info.Name(), size: info.Size()})
+		v.entries[info.Name()] = element
+		v.size += info.Size()
+	}
+	v.evict()
+
+	slog.Info("variants loaded", "count", v.lru.Len(), "bytes", v.size)
+	return nil
+}
+
+// Open a cached variant, or generate and cache it if absent.
+// Concurrent requests for the same key only generate once.
+// Caller should close the file.
+func (v *Variants) GetOrCreate(key string, generate func() ([]byte, error)) (*os.File, error) {
+	if !variantKeyRegexp.MatchString(key) {
+		return nil, ErrorInvalidVariantKey
+	}
+
+	if file, err := v.open(key); err == nil {
+		return file, nil
+	}
+
+	if _, err, _ := v.group.Do(key, func() (any, error) {
+		// might have been created while waiting
+		if v.has(key) {
+			return nil, nil
+		}
+		data, err := generate()
+		if err != nil {
+			return nil, err
+		}
+		return nil, v.put(key, data)
+	}); err != nil {
+		return nil, fmt.Errorf("GetOrCreate: create variant failed: %w", err)
+	}
+
+	file, err := v.open(key)
+	if err != nil {
+		return nil, fmt.Errorf("GetOrCreate: open variant failed: %w", err)
+	}
+	return file, nil
+}
+
+// Remove all variants of a media
+func (v *Variants) Remove(hash string) error {
+	if !hashRegexp.MatchString(hash) {
+		return ErrorInvalidHash
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	for key, element := range v.entries {
+		if !strings.HasPrefix(key, hash+"_") {
+			continue
+		}
+		if err := v.remove(element); err != nil {
+			return fmt.Errorf("Remove: %w", err)
+		}
+	}
+	return nil
+}
+
+func (v *Variants) has(key string) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	_, ok := v.entries[key]
+	return ok
+}
+
+func (v *Variants) open(key string) (*os.File, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	element, ok := v.entries[key]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+
+	file, err := os.Open(filepath.Join(v.root, key))
+	if err != nil {
+		// removed from disk by someone else, forget about it
+		v.lru.Remove(element)
+		delete(v.entries, key)
+		v.size -= element.Value.(*variantEntry).size
+		return nil, fmt.Errorf("open: open variant failed: %w", err)
+	}
+
+	v.lru.MoveToFront(element)
+	return file, nil
+}
+
+func (v *Variants) put(key string, data []byte) error {
+	tmp, err := os.CreateTemp(v.root, ".variant-*")
+	if err != nil {
+		return fmt.Errorf("put: create temp file failed: %w", err)
+	}
+	// no-op after successful rename
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("put: write temp file failed: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("put: close temp file failed: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(v.root, key)); err != nil {
+		return fmt.Errorf("put: rename temp file failed: %w", err)
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if element, ok := v.entries[key]; ok {
+		v.size -= element.Value.(*variantEntry).size
+		v.lru.Remove(element)
+	}
+	v.entries[key] = v.lru.PushFront(&variantEntry{key: key, size: int64(len(data))})
+	v.size += int64(len(data))
+	v.evict()
+
+	return nil
+}
+
+// Caller should hold the lock.
+// The most recently used variant is always kept, even if it alone exceeds the limit,
+// so that it can be served right after being created.
+func (v *Variants) evict() {
+	for v.size > v.maxSize && v.lru.Len() > 1 {
+		if err := v.remove(v.lru.Back()); err != nil {
+			slog.Error("evict: remove variant failed", "error", err.Error())
+			return
+		}
+	}
+}
+
+// Caller should hold the lock
+func (v *Variants) remove(element *list.Element) error {
+	entry := element.Value.(*variantEntry)
+	if err := os.Remove(filepath.Join(v.root, entry.key)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("remove: remove variant failed: %w", err)
+	}
+	v.lru.Remove(element)
+	delete(v.entries, entry.key)
+	v.size -= entry.size
+	return nil
+}
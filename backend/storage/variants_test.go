@@ -0,0 +1,118 @@
+package storage_test
+
+import (
+	"blog/storage"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func variantKey(char string, width int) string {
+	return fmt.Sprintf("%s_%d.png", strings.Repeat(char, 64), width)
+}
+
+func TestVariantsGetOrCreate(t *testing.T) {
+	root := t.TempDir()
+	variants := storage.NewVariants(root, 1<<20)
+	if err := variants.Prepare(); err != nil {
+		t.Fatalf("TestVariantsGetOrCreate: prepare failed: %s", err)
+	}
+
+	calls := 0
+	generate := func() ([]byte, error) {
+		calls++
+		return []byte("variant"), nil
+	}
+
+	for i := 0; i < 2; i++ {
+		file, err := variants.GetOrCreate(variantKey("a", 100), generate)
+		if err != nil {
+			t.Fatalf("TestVariantsGetOrCreate: get or create failed: %s", err)
+		}
+		content, _ := io.ReadAll(file)
+		file.Close()
+		if !bytes.Equal(content, []byte("variant")) {
+			t.Fatalf("TestVariantsGetOrCreate: unexpected content: %q", content)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("TestVariantsGetOrCreate: variant should only be generated once, got %d", calls)
+	}
+
+	// generate errors are returned and nothing is cached
+	_, err := variants.GetOrCreate(variantKey("b", 100), func() ([]byte, error) {
+		return nil, errors.New("boom")
+	})
+	if err == nil {
+		t.Fatalf("TestVariantsGetOrCreate: get or create should have failed")
+	}
+
+	// invalid keys are rejected
+	if _, err := variants.GetOrCreate("../../etc/passwd", generate); !errors.Is(err, storage.ErrorInvalidVariantKey) {
+		t.Fatalf("TestVariantsGetOrCreate: invalid key should be rejected, got: %v", err)
+	}
+
+	// remove all variants of a media
+	if err := variants.Remove(strings.Repeat("a", 64)); err != nil {
+		t.Fatalf("TestVariantsGetOrCreate: remove failed: %s", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, variantKey("a", 100))); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("TestVariantsGetOrCreate: variant should be removed from disk")
+	}
+}
+
+func TestVariantsEviction(t *testing.T) {
+	root := t.TempDir()
+	// room for two 10 byte variants
+	variants := storage.NewVariants(root, 25)
+	if err := variants.Prepare(); err != nil {
+		t.Fatalf("TestVariantsEviction: prepare failed: %s", err)
+	}
+
+	generate := func() ([]byte, error) {
+		return bytes.Repeat([]byte("x"), 10), nil
+	}
+	get := func(key string) {
+		file, err := variants.GetOrCreate(key, generate)
+		if err != nil {
+			t.Fatalf("TestVariantsEviction: get or create %s failed: %s", key, err)
+		}
+		file.Close()
+	}
+
+	get(variantKey("a", 1))
+	get(variantKey("b", 1))
+	// 'a' becomes most recently used
+	get(variantKey("a", 1))
+	// evicts 'b'
+	get(variantKey("c", 1))
+
+	for key, exists := range map[string]bool{
+		variantKey("a", 1): true,
+		variantKey("b", 1): false,
+		variantKey("c", 1): true,
+	} {
+		_, err := os.Stat(filepath.Join(root, key))
+		if exists && err != nil {
+			t.Fatalf("TestVariantsEviction: %s should exist: %s", key, err)
+		}
+		if !exists && !errors.Is(err, os.ErrNotExist) {
+			t.Fatalf("TestVariantsEviction: %s should be evicted", key)
+		}
+	}
+
+	// existing variants are picked up on restart and still evicted
+	reloaded := storage.NewVariants(root, 15)
+	if err := reloaded.Prepare(); err != nil {
+		t.Fatalf("TestVariantsEviction: reload prepare failed: %s", err)
+	}
+	entries, _ := os.ReadDir(root)
+	if len(entries) != 1 {
+		t.Fatalf("TestVariantsEviction: should only keep 1 variant after reload, got %d", len(entries))
+	}
+}
@@ -35,6 +35,93 @@ const docTemplate = `{
                 }
             }
         },
+        "/audit": {
+            "get": {
+                "description": "changes made through the api, newest first.\npass the id of the last entry as 'before' to get the next page.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "audit"
+                ],
+                "summary": "List audit log",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "jwt token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "user name",
+                        "name": "actor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "target type, optionally with id. ex: blog or blog:1",
+                        "name": "target",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ISO 8601, inclusive. ex: 2024-06-01T00:00:00Z",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ISO 8601, inclusive. ex: 2024-06-30T23:59:59Z",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "only entries with a smaller id",
+                        "name": "before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "at most 200",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/blog_entities.RetSuccess-array_entities_AuditEntry"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    }
+                }
+            }
+        },
         "/auth-check": {
             "post": {
                 "description": "Checks if jwt is valid",
@@ -126,6 +213,23 @@ const docTemplate = `{
                         "description": "filter by tag ids, return blogs that have relation with all specified tags, CAN ONLY BE USED IN COMBINATION WITH TOPIC. ex: ?tag=1\u0026tag=2",
                         "name": "tag",
                         "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "enum": [
+                                "draft",
+                                "in_review",
+                                "scheduled",
+                                "published",
+                                "archived"
+                            ],
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "filter by status, only used with all=true. ex: ?status=draft\u0026status=in_review",
+                        "name": "status",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -183,6 +287,12 @@ const docTemplate = `{
                         "name": "Authorization",
                         "in": "header",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "unique key of the request, retries with the same key and body replay the first response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -204,6 +314,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/entities.RetFailed"
                         }
                     },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -240,6 +362,12 @@ const docTemplate = `{
                         "name": "Authorization",
                         "in": "header",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from a previous GET, the blog must not have changed since",
+                        "name": "If-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -261,6 +389,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/entities.RetFailed"
                         }
                     },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -270,9 +410,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/blogs/deleted/{id}": {
-            "delete": {
-                "description": "delete blog",
+        "/blogs/deleted": {
+            "get": {
+                "description": "blogs in the trash with when and by whom they were deleted, most recently deleted first.\nthey are purged after the retention period ( trash.retention days )",
                 "consumes": [
                     "application/json"
                 ],
@@ -282,15 +422,8 @@ const docTemplate = `{
                 "tags": [
                     "blogs"
                 ],
-                "summary": "Delete blog",
+                "summary": "List deleted blogs",
                 "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "target blog id",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
                     {
                         "type": "string",
                         "description": "jwt token",
@@ -303,13 +436,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/blog_entities.RetSuccess-entities_RowsAffected"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "$ref": "#/definitions/entities.RetFailed"
+                            "$ref": "#/definitions/blog_entities.RetSuccess-array_entities_OutBlog"
                         }
                     },
                     "403": {
@@ -326,8 +453,8 @@ const docTemplate = `{
                     }
                 }
             },
-            "patch": {
-                "description": "restore delete blog",
+            "delete": {
+                "description": "permanently delete all soft deleted blogs, along with their relations",
                 "consumes": [
                     "application/json"
                 ],
@@ -337,14 +464,13 @@ const docTemplate = `{
                 "tags": [
                     "blogs"
                 ],
-                "summary": "Restore delete blog",
+                "summary": "Empty trash",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "target blog id",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "type": "boolean",
+                        "description": "only list blogs that would be deleted",
+                        "name": "dryRun",
+                        "in": "query"
                     },
                     {
                         "type": "string",
@@ -358,7 +484,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/blog_entities.RetSuccess-entities_OutBlog"
+                            "$ref": "#/definitions/blog_entities.RetSuccess-array_entities_Blog"
                         }
                     },
                     "400": {
@@ -382,9 +508,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/blogs/{id}": {
-            "get": {
-                "description": "get blog",
+        "/blogs/deleted/{id}": {
+            "delete": {
+                "description": "delete blog",
                 "consumes": [
                     "application/json"
                 ],
@@ -394,7 +520,7 @@ const docTemplate = `{
                 "tags": [
                     "blogs"
                 ],
-                "summary": "Get blog",
+                "summary": "Delete blog",
                 "parameters": [
                     {
                         "type": "integer",
@@ -407,27 +533,21 @@ const docTemplate = `{
                         "type": "string",
                         "description": "jwt token",
                         "name": "Authorization",
-                        "in": "header"
-                    },
-                    {
-                        "type": "boolean",
-                        "default": false,
-                        "description": "show all blogs regardless of visibility or soft delete status",
-                        "name": "all",
-                        "in": "query"
+                        "in": "header",
+                        "required": true
                     },
                     {
-                        "type": "boolean",
-                        "description": "parse markdown to html before returning",
-                        "name": "parsed",
-                        "in": "query"
+                        "type": "string",
+                        "description": "ETag from a previous GET, the blog must not have changed since",
+                        "name": "If-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/blog_entities.RetSuccess-entities_OutBlog"
+                            "$ref": "#/definitions/blog_entities.RetSuccess-entities_RowsAffected"
                         }
                     },
                     "400": {
@@ -442,6 +562,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/entities.RetFailed"
                         }
                     },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -450,8 +582,8 @@ const docTemplate = `{
                     }
                 }
             },
-            "put": {
-                "description": "update blog",
+            "patch": {
+                "description": "restore delete blog",
                 "consumes": [
                     "application/json"
                 ],
@@ -461,7 +593,7 @@ const docTemplate = `{
                 "tags": [
                     "blogs"
                 ],
-                "summary": "Update blog",
+                "summary": "Restore delete blog",
                 "parameters": [
                     {
                         "type": "integer",
@@ -476,15 +608,6 @@ const docTemplate = `{
                         "name": "Authorization",
                         "in": "header",
                         "required": true
-                    },
-                    {
-                        "description": "new blog content",
-                        "name": "blog",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/entities.ReqInBlog"
-                        }
                     }
                 ],
                 "responses": {
@@ -513,9 +636,11 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "post": {
-                "description": "create blog with given id",
+            }
+        },
+        "/blogs/popular": {
+            "get": {
+                "description": "most viewed visible blogs in the last days",
                 "consumes": [
                     "application/json"
                 ],
@@ -523,39 +648,28 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "blogs"
+                    "stats"
                 ],
-                "summary": "Create blog with given id",
+                "summary": "List popular blogs",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "blog id",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "jwt token",
-                        "name": "Authorization",
-                        "in": "header",
-                        "required": true
+                        "description": "look back this many days, defaults to analytics.popularDays",
+                        "name": "days",
+                        "in": "query"
                     },
                     {
-                        "description": "new blog content",
-                        "name": "blog",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/entities.ReqInBlog"
-                        }
+                        "type": "integer",
+                        "description": "at most 50, defaults to analytics.popularLimit",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/blog_entities.RetSuccess-entities_OutBlog"
+                            "$ref": "#/definitions/blog_entities.RetSuccess-array_entities_PopularBlog"
                         }
                     },
                     "400": {
@@ -564,12 +678,6 @@ const docTemplate = `{
                             "$ref": "#/definitions/entities.RetFailed"
                         }
                     },
-                    "403": {
-                        "description": "Forbidden",
-                        "schema": {
-                            "$ref": "#/definitions/entities.RetFailed"
-                        }
-                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -577,9 +685,11 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "update blog",
+            }
+        },
+        "/blogs/{id}": {
+            "get": {
+                "description": "get blog",
                 "consumes": [
                     "application/json"
                 ],
@@ -589,7 +699,7 @@ const docTemplate = `{
                 "tags": [
                     "blogs"
                 ],
-                "summary": "Soft delete blog",
+                "summary": "Get blog",
                 "parameters": [
                     {
                         "type": "integer",
@@ -598,21 +708,54 @@ const docTemplate = `{
                         "in": "path",
                         "required": true
                     },
+                    {
+                        "type": "string",
+                        "description": "ETag from a previous GET, 304 if the blog hasn't changed",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    },
                     {
                         "type": "string",
                         "description": "jwt token",
                         "name": "Authorization",
-                        "in": "header",
-                        "required": true
+                        "in": "header"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "show all blogs regardless of visibility or soft delete status",
+                        "name": "all",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "parse markdown to html before returning",
+                        "name": "parsed",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "preview link token, reads the blog regardless of visibility without logging in",
+                        "name": "preview",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/blog_entities.RetSuccess-entities_RowsAffected"
+                            "$ref": "#/definitions/blog_entities.RetSuccess-entities_OutBlog"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "version of the blog"
+                            }
                         }
                     },
+                    "304": {
+                        "description": "not modified"
+                    },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
@@ -625,6 +768,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/entities.RetFailed"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -632,11 +781,9 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/login": {
-            "post": {
-                "description": "login to get jwt token",
+            },
+            "put": {
+                "description": "update blog",
                 "consumes": [
                     "application/json"
                 ],
@@ -644,23 +791,51 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "blogs"
                 ],
-                "summary": "Login",
+                "summary": "Update blog",
                 "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "target blog id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
                     {
                         "type": "string",
-                        "description": "user credentials",
+                        "description": "jwt token",
                         "name": "Authorization",
                         "in": "header",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from a previous GET, the blog must not have changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "new blog content",
+                        "name": "blog",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entities.ReqInBlog"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/blog_entities.RetSuccess-entities_JWT"
+                            "$ref": "#/definitions/blog_entities.RetSuccess-entities_OutBlog"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "version of the blog"
+                            }
                         }
                     },
                     "400": {
@@ -669,14 +844,26 @@ const docTemplate = `{
                             "$ref": "#/definitions/entities.RetFailed"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
                     "412": {
                         "description": "Precondition Failed",
                         "schema": {
                             "$ref": "#/definitions/entities.RetFailed"
                         }
                     },
-                    "422": {
-                        "description": "Unprocessable Entity",
+                    "428": {
+                        "description": "Precondition Required",
                         "schema": {
                             "$ref": "#/definitions/entities.RetFailed"
                         }
@@ -688,11 +875,9 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/logout": {
+            },
             "post": {
-                "description": "logout, deletes jwt token, needs to have valid token in the first place",
+                "description": "create blog with given id",
                 "consumes": [
                     "application/json"
                 ],
@@ -700,23 +885,51 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "blogs"
                 ],
-                "summary": "Logout",
+                "summary": "Create blog with given id",
                 "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "blog id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
                     {
                         "type": "string",
                         "description": "jwt token",
                         "name": "Authorization",
                         "in": "header",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "unique key of the request, retries with the same key and body replay the first response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "new blog content",
+                        "name": "blog",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entities.ReqInBlog"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/blog_entities.RetSuccess-string"
+                            "$ref": "#/definitions/blog_entities.RetSuccess-entities_OutBlog"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
                         }
                     },
                     "403": {
@@ -725,44 +938,28 @@ const docTemplate = `{
                             "$ref": "#/definitions/entities.RetFailed"
                         }
                     },
-                    "412": {
-                        "description": "Precondition Failed",
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
                             "$ref": "#/definitions/entities.RetFailed"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "422": {
+                        "description": "Unprocessable Entity",
                         "schema": {
                             "$ref": "#/definitions/entities.RetFailed"
                         }
-                    }
-                }
-            }
-        },
-        "/ready": {
-            "get": {
-                "description": "Readiness probe for health check",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "healthCheck"
-                ],
-                "summary": "Readiness probe",
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/blog_entities.RetSuccess-string"
+                            "$ref": "#/definitions/entities.RetFailed"
                         }
                     }
                 }
-            }
-        },
-        "/tags": {
-            "get": {
-                "description": "list all tags",
+            },
+            "delete": {
+                "description": "update blog",
                 "consumes": [
                     "application/json"
                 ],
@@ -770,22 +967,60 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "tags"
+                    "blogs"
                 ],
-                "summary": "List tags",
+                "summary": "Soft delete blog",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "topic id",
-                        "name": "topic",
-                        "in": "query"
+                        "description": "target blog id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "jwt token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from a previous GET, the blog must not have changed since",
+                        "name": "If-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/blog_entities.RetSuccess-array_entities_Tag"
+                            "$ref": "#/definitions/blog_entities.RetSuccess-entities_RowsAffected"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
                         }
                     },
                     "500": {
@@ -796,27 +1031,26 @@ const docTemplate = `{
                     }
                 }
             },
-            "post": {
-                "description": "tags must have unique names",
+            "patch": {
+                "description": "update only the fields present in the body, as a JSON Merge Patch (RFC 7396).\ntags, topics and series are only replaced when present, null clears them.",
                 "consumes": [
-                    "application/json"
+                    "application/json",
+                    "application/merge-patch+json"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "tags"
+                    "blogs"
                 ],
-                "summary": "Create tag",
+                "summary": "Patch blog",
                 "parameters": [
                     {
-                        "description": "new tag contents",
-                        "name": "tag",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/entities.InTag"
-                        }
+                        "type": "integer",
+                        "description": "target blog id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     },
                     {
                         "type": "string",
@@ -824,13 +1058,34 @@ const docTemplate = `{
                         "name": "Authorization",
                         "in": "header",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from a previous GET, the blog must not have changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "fields to change",
+                        "name": "blog",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entities.BlogPatch"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/blog_entities.RetSuccess-entities_Tag"
+                            "$ref": "#/definitions/blog_entities.RetSuccess-entities_OutBlog"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "version of the blog"
+                            }
                         }
                     },
                     "400": {
@@ -839,6 +1094,36 @@ const docTemplate = `{
                             "$ref": "#/definitions/entities.RetFailed"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -848,9 +1133,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/tags/{id}": {
+        "/blogs/{id}/comments": {
             "get": {
-                "description": "get tag by id",
+                "description": "list approved comments of a blog as threads, oldest first.\nreplies to comments that are no longer approved are not included.",
                 "consumes": [
                     "application/json"
                 ],
@@ -858,13 +1143,13 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "tags"
+                    "comments"
                 ],
-                "summary": "Get tags",
+                "summary": "List comments",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "target tag id",
+                        "description": "target blog id",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -874,7 +1159,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/blog_entities.RetSuccess-entities_Tag"
+                            "$ref": "#/definitions/blog_entities.RetSuccess-array_entities_OutComment"
                         }
                     },
                     "400": {
@@ -897,8 +1182,8 @@ const docTemplate = `{
                     }
                 }
             },
-            "put": {
-                "description": "update tag",
+            "post": {
+                "description": "comment on a visible blog, comments are only listed after being approved.\nreplies can only be made to approved comments of the same blog.",
                 "consumes": [
                     "application/json"
                 ],
@@ -906,31 +1191,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "tags"
+                    "comments"
                 ],
-                "summary": "Update tag",
+                "summary": "Create comment",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "target tag id",
+                        "description": "target blog id",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "jwt token",
-                        "name": "Authorization",
-                        "in": "header",
-                        "required": true
-                    },
-                    {
-                        "description": "new tag content",
-                        "name": "tag",
+                        "description": "new comment, leave website empty",
+                        "name": "comment",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/entities.InTag"
+                            "$ref": "#/definitions/entities.InComment"
                         }
                     }
                 ],
@@ -938,7 +1216,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/blog_entities.RetSuccess-entities_Tag"
+                            "$ref": "#/definitions/blog_entities.RetSuccess-entities_OutComment"
                         }
                     },
                     "400": {
@@ -953,6 +1231,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/entities.RetFailed"
                         }
                     },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -960,9 +1244,11 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "delete tag",
+            }
+        },
+        "/blogs/{id}/preview-links": {
+            "get": {
+                "description": "list preview links of a blog with their tokens, newest first, including expired ones",
                 "consumes": [
                     "application/json"
                 ],
@@ -970,13 +1256,13 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "tags"
+                    "blogs"
                 ],
-                "summary": "Delete tag",
+                "summary": "List preview links",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "target tag id",
+                        "description": "target blog id",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -993,7 +1279,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/blog_entities.RetSuccess-entities_RowsAffected"
+                            "$ref": "#/definitions/blog_entities.RetSuccess-array_entities_PreviewLink"
                         }
                     },
                     "400": {
@@ -1002,6 +1288,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/entities.RetFailed"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -1015,11 +1307,9 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/topics": {
-            "get": {
-                "description": "list all topics",
+            },
+            "post": {
+                "description": "mint a token for reading a blog regardless of visibility, with GET /blogs/{id}?preview=\u003ctoken\u003e.\nexpiresIn is in hours, left out for the configured default.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1027,14 +1317,56 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "topics"
+                    "blogs"
+                ],
+                "summary": "Create preview link",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "target blog id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "jwt token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "note and expiration",
+                        "name": "link",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/entities.InPreviewLink"
+                        }
+                    }
                 ],
-                "summary": "List topics",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/blog_entities.RetSuccess-array_entities_Topic"
+                            "$ref": "#/definitions/blog_entities.RetSuccess-entities_PreviewLink"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
                         }
                     },
                     "500": {
@@ -1044,9 +1376,11 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "post": {
-                "description": "topics must have unique names",
+            }
+        },
+        "/blogs/{id}/preview-links/{linkID}": {
+            "delete": {
+                "description": "the token of the link stops working right away",
                 "consumes": [
                     "application/json"
                 ],
@@ -1054,32 +1388,37 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "topics"
+                    "blogs"
                 ],
-                "summary": "Create topic",
+                "summary": "Revoke preview link",
                 "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "target blog id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "target preview link id",
+                        "name": "linkID",
+                        "in": "path",
+                        "required": true
+                    },
                     {
                         "type": "string",
                         "description": "jwt token",
                         "name": "Authorization",
                         "in": "header",
                         "required": true
-                    },
-                    {
-                        "description": "new topic contents",
-                        "name": "topic",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/entities.InTopic"
-                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/blog_entities.RetSuccess-entities_Topic"
+                            "$ref": "#/definitions/blog_entities.RetSuccess-entities_RowsAffected"
                         }
                     },
                     "400": {
@@ -1088,6 +1427,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/entities.RetFailed"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -1097,9 +1448,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/topics/{id}": {
+        "/blogs/{id}/status-events": {
             "get": {
-                "description": "get topic by id",
+                "description": "who moved the blog to which status and when, oldest first",
                 "consumes": [
                     "application/json"
                 ],
@@ -1107,23 +1458,30 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "topics"
+                    "blogs"
                 ],
-                "summary": "Get topic",
+                "summary": "List blog status changes",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "target topic id",
+                        "description": "target blog id",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "jwt token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/blog_entities.RetSuccess-entities_Topic"
+                            "$ref": "#/definitions/blog_entities.RetSuccess-array_entities_BlogStatusEvent"
                         }
                     },
                     "400": {
@@ -1132,6 +1490,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/entities.RetFailed"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -1145,9 +1509,11 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "put": {
-                "description": "update topic",
+            }
+        },
+        "/blogs/{id}/transition": {
+            "post": {
+                "description": "move a blog through the editorial workflow, who moved it is recorded.\ndraft -\u003e in_review, scheduled, published, archived\nin_review -\u003e draft, scheduled, published\nscheduled -\u003e draft, in_review, published ( published by the server at scheduledAt )\npublished -\u003e draft, archived\narchived -\u003e draft, published",
                 "consumes": [
                     "application/json"
                 ],
@@ -1155,13 +1521,13 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "topics"
+                    "blogs"
                 ],
-                "summary": "Update topic",
+                "summary": "Transition blog status",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "target tag id",
+                        "description": "target blog id",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -1174,12 +1540,12 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "new topic content",
-                        "name": "topic",
+                        "description": "new status",
+                        "name": "transition",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/entities.InTopic"
+                            "$ref": "#/definitions/entities.InTransition"
                         }
                     }
                 ],
@@ -1187,7 +1553,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/blog_entities.RetSuccess-entities_Topic"
+                            "$ref": "#/definitions/blog_entities.RetSuccess-entities_OutBlog"
                         }
                     },
                     "400": {
@@ -1196,12 +1562,24 @@ const docTemplate = `{
                             "$ref": "#/definitions/entities.RetFailed"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/entities.RetFailed"
                         }
                     },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -1209,9 +1587,11 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "delete topic",
+            }
+        },
+        "/blogs/{id}/view": {
+            "post": {
+                "description": "beacon sent by the frontend when a blog is read.\nviews are aggregated per day and referrer host, unique visitors are counted with a daily salted hash, raw ips are never stored.\nrequests with DNT or Sec-GPC set, and from known bots, are ignored.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1219,30 +1599,102 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "topics"
+                    "stats"
                 ],
-                "summary": "Delete topic",
+                "summary": "Record blog view",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "target topic id",
+                        "description": "target blog id",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
+                    {
+                        "description": "referrer of the page",
+                        "name": "view",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/entities.InView"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/blog_entities.RetSuccess-string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    }
+                }
+            }
+        },
+        "/bulk": {
+            "post": {
+                "description": "create, update and delete topics, tags and blogs in order, in one transaction.\ncreated targets can have a ref, later blogs link them with tagRefs and topicRefs.\nwith atomic, the first failed operation rolls back all of them, otherwise only failed operations are skipped.\nresults of each operation are returned, failed operations don't change the http status.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "bulk"
+                ],
+                "summary": "Apply bulk operations",
+                "parameters": [
                     {
                         "type": "string",
                         "description": "jwt token",
                         "name": "Authorization",
                         "in": "header",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "unique key of the request, retries with the same key and body replay the first response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "operations, data is an InTopic, InTag or ReqInBlog",
+                        "name": "bulk",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entities.Bulk"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/blog_entities.RetSuccess-entities_RowsAffected"
+                            "$ref": "#/definitions/blog_entities.RetSuccess-entities_OutBulk"
                         }
                     },
                     "400": {
@@ -1251,8 +1703,20 @@ const docTemplate = `{
                             "$ref": "#/definitions/entities.RetFailed"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
                         "schema": {
                             "$ref": "#/definitions/entities.RetFailed"
                         }
@@ -1265,387 +1729,4030 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "blog_entities.RetSuccess-array_entities_OutBlog": {
-            "type": "object",
+        },
+        "/comments": {
+            "get": {
+                "description": "moderation queue, list comments across all blogs by status, oldest first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "List comments by status",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "jwt token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "pending",
+                            "approved",
+                            "rejected"
+                        ],
+                        "type": "string",
+                        "default": "pending",
+                        "description": "comment status",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/blog_entities.RetSuccess-array_entities_OutComment"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    }
+                }
+            }
+        },
+        "/comments/{id}": {
+            "delete": {
+                "description": "delete comment and all of its replies",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Delete comment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "target comment id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "jwt token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/blog_entities.RetSuccess-entities_RowsAffected"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    }
+                }
+            }
+        },
+        "/comments/{id}/approve": {
+            "patch": {
+                "description": "approved comments are listed publicly",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Approve comment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "target comment id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "jwt token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/blog_entities.RetSuccess-entities_Comment"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    }
+                }
+            }
+        },
+        "/comments/{id}/reject": {
+            "patch": {
+                "description": "rejected comments and their replies are hidden, but kept for reference",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Reject comment",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "target comment id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "jwt token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/blog_entities.RetSuccess-entities_Comment"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    }
+                }
+            }
+        },
+        "/events": {
+            "get": {
+                "description": "server-sent events of blog, tag and topic changes, same event types and data as webhooks,\nblogs that aren't published only have their id.\nreconnecting with Last-Event-ID replays missed events while they are still buffered,\notherwise a 'reset' event is sent first and cached content should be dropped.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "events"
+                ],
+                "summary": "Stream content changes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "id of the last received event",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "same as Last-Event-ID, for clients that can't set headers",
+                        "name": "lastEventId",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "event stream",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/login": {
+            "post": {
+                "description": "login to get jwt token",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Login",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "user credentials",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/blog_entities.RetSuccess-entities_JWT"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    }
+                }
+            }
+        },
+        "/logout": {
+            "post": {
+                "description": "logout, deletes jwt token, needs to have valid token in the first place",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Logout",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "jwt token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/blog_entities.RetSuccess-string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    }
+                }
+            }
+        },
+        "/media": {
+            "get": {
+                "description": "list all media with the ids of blogs referencing them",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "media"
+                ],
+                "summary": "List media",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "jwt token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/blog_entities.RetSuccess-array_entities_OutMedia"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "upload a file, files are stored by the sha256 of their content, uploading the same file twice returns the existing media",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "media"
+                ],
+                "summary": "Upload media",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "file to upload",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "alt text",
+                        "name": "alt",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "jwt token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/blog_entities.RetSuccess-entities_Media"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    }
+                }
+            }
+        },
+        "/media/unused": {
+            "delete": {
+                "description": "garbage collect media not referenced by any blog, media uploaded within the grace period are kept",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "media"
+                ],
+                "summary": "Delete unused media",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "only list media that would be deleted",
+                        "name": "dryRun",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "jwt token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/blog_entities.RetSuccess-array_entities_Media"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    }
+                }
+            }
+        },
+        "/media/{hash}": {
+            "get": {
+                "description": "get media file content by hash, content never changes for a given hash so it can be cached forever.\nimages can be resized and converted on the fly, variants are cached on disk.\nfiles that aren't images are served as attachments",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "media"
+                ],
+                "summary": "Get media",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "sha256 of file content",
+                        "name": "hash",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "resize image to width (px), one of the srcset widths, never scales up",
+                        "name": "w",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "jpeg",
+                            "png",
+                            "gif",
+                            "bmp",
+                            "tiff"
+                        ],
+                        "type": "string",
+                        "description": "convert image to format",
+                        "name": "fmt",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "delete media and its file, media referenced by blogs can't be deleted",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "media"
+                ],
+                "summary": "Delete media",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "sha256 of file content",
+                        "name": "hash",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "jwt token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/blog_entities.RetSuccess-entities_RowsAffected"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    }
+                }
+            }
+        },
+        "/ready": {
+            "get": {
+                "description": "Readiness probe for health check",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "healthCheck"
+                ],
+                "summary": "Readiness probe",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/blog_entities.RetSuccess-string"
+                        }
+                    }
+                }
+            }
+        },
+        "/series": {
+            "get": {
+                "description": "list all series, without their parts",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "series"
+                ],
+                "summary": "List series",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/blog_entities.RetSuccess-array_entities_Series"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "series must have unique names",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "series"
+                ],
+                "summary": "Create series",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "jwt token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "new series contents",
+                        "name": "series",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entities.InSeries"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/blog_entities.RetSuccess-entities_Series"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    }
+                }
+            }
+        },
+        "/series/{id}": {
+            "get": {
+                "description": "get series by id with its parts ordered by position",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "series"
+                ],
+                "summary": "Get series",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "target series id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "jwt token, only required if all=true",
+                        "name": "Authorization",
+                        "in": "header"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "include parts regardless of visibility or soft delete status",
+                        "name": "all",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/blog_entities.RetSuccess-entities_OutSeries"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "update series name and description, parts are managed through blogs",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "series"
+                ],
+                "summary": "Update series",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "target series id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "jwt token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "new series content",
+                        "name": "series",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entities.InSeries"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/blog_entities.RetSuccess-entities_Series"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "delete series, blogs in it are kept but no longer belong to a series",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "series"
+                ],
+                "summary": "Delete series",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "target series id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "jwt token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/blog_entities.RetSuccess-entities_RowsAffected"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    }
+                }
+            }
+        },
+        "/stats/blogs": {
+            "get": {
+                "description": "views and visitors of each blog in a date range, most viewed first. blogs without views are omitted.\ndefaults to the last 30 days.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stats"
+                ],
+                "summary": "List blog stats",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "jwt token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "first day, YYYY-MM-DD in UTC",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "last day, YYYY-MM-DD in UTC",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/blog_entities.RetSuccess-array_entities_BlogStatsSummary"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    }
+                }
+            }
+        },
+        "/stats/blogs/{id}": {
+            "get": {
+                "description": "daily views, visitors and referrers of a blog in a date range, days without views are omitted.\ndefaults to the last 30 days.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stats"
+                ],
+                "summary": "Get blog stats",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "target blog id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "jwt token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "first day, YYYY-MM-DD in UTC",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "last day, YYYY-MM-DD in UTC",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/blog_entities.RetSuccess-entities_BlogStats"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    }
+                }
+            }
+        },
+        "/tags": {
+            "get": {
+                "description": "list all tags",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tags"
+                ],
+                "summary": "List tags",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "topic id",
+                        "name": "topic",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/blog_entities.RetSuccess-array_entities_Tag"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "tags must have unique names",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tags"
+                ],
+                "summary": "Create tag",
+                "parameters": [
+                    {
+                        "description": "new tag contents",
+                        "name": "tag",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entities.InTag"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "jwt token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "unique key of the request, retries with the same key and body replay the first response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/blog_entities.RetSuccess-entities_Tag"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    }
+                }
+            }
+        },
+        "/tags/{id}": {
+            "get": {
+                "description": "get tag by id",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tags"
+                ],
+                "summary": "Get tags",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "target tag id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from a previous GET, 304 if the tag hasn't changed",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/blog_entities.RetSuccess-entities_Tag"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "version of the tag"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "not modified"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "update tag",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tags"
+                ],
+                "summary": "Update tag",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "target tag id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "jwt token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from a previous GET, the tag must not have changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "new tag content",
+                        "name": "tag",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entities.InTag"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/blog_entities.RetSuccess-entities_Tag"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "version of the tag"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "delete tag",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tags"
+                ],
+                "summary": "Delete tag",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "target tag id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "jwt token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from a previous GET, the tag must not have changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/blog_entities.RetSuccess-entities_RowsAffected"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "update only the fields present in the body, as a JSON Merge Patch (RFC 7396).",
+                "consumes": [
+                    "application/json",
+                    "application/merge-patch+json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tags"
+                ],
+                "summary": "Patch tag",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "target tag id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "jwt token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from a previous GET, the tag must not have changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "fields to change",
+                        "name": "tag",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entities.TagPatch"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/blog_entities.RetSuccess-entities_Tag"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "version of the tag"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    }
+                }
+            }
+        },
+        "/topics": {
+            "get": {
+                "description": "list all topics",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "topics"
+                ],
+                "summary": "List topics",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/blog_entities.RetSuccess-array_entities_Topic"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "topics must have unique names",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "topics"
+                ],
+                "summary": "Create topic",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "jwt token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "unique key of the request, retries with the same key and body replay the first response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "new topic contents",
+                        "name": "topic",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entities.InTopic"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/blog_entities.RetSuccess-entities_Topic"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    }
+                }
+            }
+        },
+        "/topics/{id}": {
+            "get": {
+                "description": "get topic by id",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "topics"
+                ],
+                "summary": "Get topic",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "target topic id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from a previous GET, 304 if the topic hasn't changed",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/blog_entities.RetSuccess-entities_Topic"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "version of the topic"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "not modified"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "update topic",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "topics"
+                ],
+                "summary": "Update topic",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "target tag id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "jwt token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from a previous GET, the topic must not have changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "new topic content",
+                        "name": "topic",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entities.InTopic"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/blog_entities.RetSuccess-entities_Topic"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "version of the topic"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "delete topic",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "topics"
+                ],
+                "summary": "Delete topic",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "target topic id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "jwt token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from a previous GET, the topic must not have changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/blog_entities.RetSuccess-entities_RowsAffected"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "update only the fields present in the body, as a JSON Merge Patch (RFC 7396).",
+                "consumes": [
+                    "application/json",
+                    "application/merge-patch+json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "topics"
+                ],
+                "summary": "Patch topic",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "target topic id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "jwt token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from a previous GET, the topic must not have changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "fields to change",
+                        "name": "topic",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entities.TopicPatch"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/blog_entities.RetSuccess-entities_Topic"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "version of the topic"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks": {
+            "get": {
+                "description": "list all webhooks, without secrets",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "List webhooks",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "jwt token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/blog_entities.RetSuccess-array_entities_Webhook"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "subscribe a url to content change events, a secret is generated if left out.\nthe secret is only returned here, requests are signed with it in X-Webhook-Signature.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Create webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "jwt token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "url, events and secret",
+                        "name": "webhook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entities.InWebhook"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/blog_entities.RetSuccess-entities_Webhook"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/deliveries/{id}/replay": {
+            "post": {
+                "description": "send the delivery again as soon as possible, attempts are reset",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Replay webhook delivery",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "target delivery id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "jwt token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/blog_entities.RetSuccess-entities_WebhookDelivery"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}": {
+            "get": {
+                "description": "get webhook by id, without secret",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Get webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "target webhook id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "jwt token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/blog_entities.RetSuccess-entities_Webhook"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "update url, events and paused, leaving out secret keeps the current one",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Update webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "target webhook id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "jwt token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "new webhook content",
+                        "name": "webhook",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entities.InWebhook"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/blog_entities.RetSuccess-entities_Webhook"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "delete webhook together with its delivery log",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Delete webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "target webhook id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "jwt token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/blog_entities.RetSuccess-entities_RowsAffected"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhooks/{id}/deliveries": {
+            "get": {
+                "description": "delivery log of a webhook with the sent events, newest first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "List webhook deliveries",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "target webhook id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "jwt token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "at most 200",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/blog_entities.RetSuccess-array_entities_WebhookDelivery"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/entities.RetFailed"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "blog_entities.RetSuccess-array_entities_AuditEntry": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "msg": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.AuditEntry"
+                    }
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "blog_entities.RetSuccess-array_entities_Blog": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "msg": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.Blog"
+                    }
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "blog_entities.RetSuccess-array_entities_BlogStatsSummary": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "msg": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.BlogStatsSummary"
+                    }
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "blog_entities.RetSuccess-array_entities_BlogStatusEvent": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "msg": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.BlogStatusEvent"
+                    }
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "blog_entities.RetSuccess-array_entities_Media": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "msg": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.Media"
+                    }
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "blog_entities.RetSuccess-array_entities_OutBlog": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "msg": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.OutBlog"
+                    }
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "blog_entities.RetSuccess-array_entities_OutBlogSimple": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "msg": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.OutBlogSimple"
+                    }
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "blog_entities.RetSuccess-array_entities_OutComment": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "msg": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.OutComment"
+                    }
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "blog_entities.RetSuccess-array_entities_OutMedia": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "msg": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.OutMedia"
+                    }
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "blog_entities.RetSuccess-array_entities_PopularBlog": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "msg": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.PopularBlog"
+                    }
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "blog_entities.RetSuccess-array_entities_PreviewLink": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "msg": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.PreviewLink"
+                    }
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "blog_entities.RetSuccess-array_entities_Series": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "msg": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.Series"
+                    }
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "blog_entities.RetSuccess-array_entities_Tag": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "msg": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.Tag"
+                    }
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "blog_entities.RetSuccess-array_entities_Topic": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "msg": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.Topic"
+                    }
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "blog_entities.RetSuccess-array_entities_Webhook": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "msg": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.Webhook"
+                    }
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "blog_entities.RetSuccess-array_entities_WebhookDelivery": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "msg": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.WebhookDelivery"
+                    }
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "blog_entities.RetSuccess-entities_BlogStats": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "msg": {
+                    "$ref": "#/definitions/entities.BlogStats"
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "blog_entities.RetSuccess-entities_Comment": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "msg": {
+                    "$ref": "#/definitions/entities.Comment"
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "blog_entities.RetSuccess-entities_JWT": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "msg": {
+                    "$ref": "#/definitions/entities.JWT"
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "blog_entities.RetSuccess-entities_Media": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "msg": {
+                    "$ref": "#/definitions/entities.Media"
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "blog_entities.RetSuccess-entities_OutBlog": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "msg": {
+                    "$ref": "#/definitions/entities.OutBlog"
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "blog_entities.RetSuccess-entities_OutBulk": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "msg": {
+                    "$ref": "#/definitions/entities.OutBulk"
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "blog_entities.RetSuccess-entities_OutComment": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "msg": {
+                    "$ref": "#/definitions/entities.OutComment"
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "blog_entities.RetSuccess-entities_OutSeries": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "msg": {
+                    "$ref": "#/definitions/entities.OutSeries"
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "blog_entities.RetSuccess-entities_PreviewLink": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "msg": {
+                    "$ref": "#/definitions/entities.PreviewLink"
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "blog_entities.RetSuccess-entities_RowsAffected": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "msg": {
+                    "$ref": "#/definitions/entities.RowsAffected"
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "blog_entities.RetSuccess-entities_Series": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "msg": {
+                    "$ref": "#/definitions/entities.Series"
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "blog_entities.RetSuccess-entities_Tag": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "msg": {
+                    "$ref": "#/definitions/entities.Tag"
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "blog_entities.RetSuccess-entities_Topic": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "msg": {
+                    "$ref": "#/definitions/entities.Topic"
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "blog_entities.RetSuccess-entities_Webhook": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "msg": {
+                    "$ref": "#/definitions/entities.Webhook"
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "blog_entities.RetSuccess-entities_WebhookDelivery": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "msg": {
+                    "$ref": "#/definitions/entities.WebhookDelivery"
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "blog_entities.RetSuccess-string": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "msg": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entities.AuditEntry": {
+            "type": "object",
+            "properties": {
+                "actor": {
+                    "description": "jwt sub",
+                    "type": "string"
+                },
+                "clientIp": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "diff": {
+                    "description": "changed fields as {\"field\": [before, after]}, before is null when created and after is null when deleted",
+                    "type": "object"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "requestId": {
+                    "type": "string"
+                },
+                "route": {
+                    "description": "method and path, ex: PUT /api/v1/blogs/1",
+                    "type": "string"
+                },
+                "targetId": {
+                    "type": "string"
+                },
+                "targetType": {
+                    "type": "string"
+                }
+            }
+        },
+        "entities.Blog": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "string"
+                },
+                "contentMD5": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "deleted_by": {
+                    "description": "empty unless soft deleted",
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "pined": {
+                    "type": "boolean"
+                },
+                "scheduledAt": {
+                    "description": "empty unless scheduled",
+                    "type": "string"
+                },
+                "slug": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "version": {
+                    "description": "bumped on every update",
+                    "type": "integer"
+                },
+                "visible": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "entities.BlogPatch": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "string"
+                },
+                "description": {
+                    "description": "null clears it",
+                    "type": "string"
+                },
+                "part": {
+                    "description": "position in series, null or 0 appends to the end",
+                    "type": "integer"
+                },
+                "pined": {
+                    "type": "boolean"
+                },
+                "series": {
+                    "description": "null or 0 removes the blog from its series",
+                    "type": "integer"
+                },
+                "tags": {
+                    "description": "null clears them",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "title": {
+                    "type": "string"
+                },
+                "topics": {
+                    "description": "null clears them",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "visible": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "entities.BlogSeries": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "part": {
+                    "type": "integer"
+                },
+                "slug": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "entities.BlogStats": {
+            "type": "object",
+            "properties": {
+                "blogID": {
+                    "type": "integer"
+                },
+                "days": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.DailyStats"
+                    }
+                },
+                "from": {
+                    "type": "string"
+                },
+                "referrers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.ReferrerStats"
+                    }
+                },
+                "title": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                },
+                "views": {
+                    "type": "integer"
+                },
+                "visitors": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entities.BlogStatsSummary": {
+            "type": "object",
+            "properties": {
+                "blogID": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "views": {
+                    "type": "integer"
+                },
+                "visitors": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entities.BlogStatusEvent": {
+            "type": "object",
+            "properties": {
+                "actor": {
+                    "description": "user name, or scheduler",
+                    "type": "string"
+                },
+                "blogID": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "note": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "entities.Bulk": {
+            "type": "object",
+            "properties": {
+                "atomic": {
+                    "type": "boolean"
+                },
+                "operations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.BulkOperation"
+                    }
+                }
+            }
+        },
+        "entities.BulkOperation": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "description": "InTopic, InTag or ReqInBlog, empty for delete",
+                    "type": "object"
+                },
+                "id": {
+                    "description": "target of update and delete",
+                    "type": "integer"
+                },
+                "ifMatch": {
+                    "description": "optional, same as the If-Match header of single updates and deletes",
+                    "type": "string"
+                },
+                "op": {
+                    "type": "string",
+                    "enum": [
+                        "create",
+                        "update",
+                        "delete"
+                    ]
+                },
+                "ref": {
+                    "description": "client side id of the created target, unique in the request",
+                    "type": "string"
+                },
+                "tagRefs": {
+                    "description": "refs of tags and topics created earlier in the request, added to tags and topics of the blog",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "topicRefs": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "topic",
+                        "tag",
+                        "blog"
+                    ]
+                }
+            }
+        },
+        "entities.BulkResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "description": "id of the target, assigned by the server for created targets",
+                    "type": "integer"
+                },
+                "index": {
+                    "type": "integer"
+                },
+                "op": {
+                    "type": "string"
+                },
+                "ref": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "ok",
+                        "failed",
+                        "rolled_back",
+                        "skipped"
+                    ]
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "entities.Comment": {
+            "type": "object",
+            "properties": {
+                "author": {
+                    "type": "string"
+                },
+                "blogID": {
+                    "type": "integer"
+                },
+                "content": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "parentID": {
+                    "description": "0 for top level comments",
+                    "type": "integer"
+                },
+                "status": {
+                    "description": "pending, approved or rejected",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "entities.DailyStats": {
+            "type": "object",
+            "properties": {
+                "day": {
+                    "type": "string"
+                },
+                "views": {
+                    "type": "integer"
+                },
+                "visitors": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entities.InComment": {
+            "type": "object",
+            "properties": {
+                "author": {
+                    "type": "string"
+                },
+                "content": {
+                    "type": "string"
+                },
+                "parentID": {
+                    "type": "integer"
+                },
+                "website": {
+                    "description": "honeypot, hidden from readers by the frontend.\nAnything filled in here is treated as spam.",
+                    "type": "string"
+                }
+            }
+        },
+        "entities.InPreviewLink": {
+            "type": "object",
+            "properties": {
+                "expiresIn": {
+                    "description": "hour",
+                    "type": "integer"
+                },
+                "note": {
+                    "type": "string"
+                }
+            }
+        },
+        "entities.InSeries": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "entities.InTag": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "entities.InTopic": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "entities.InTransition": {
+            "type": "object",
             "properties": {
-                "error": {
+                "note": {
                     "type": "string"
                 },
-                "msg": {
+                "scheduledAt": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "draft",
+                        "in_review",
+                        "scheduled",
+                        "published",
+                        "archived"
+                    ]
+                }
+            }
+        },
+        "entities.InView": {
+            "type": "object",
+            "properties": {
+                "referrer": {
+                    "description": "document.referrer, only the host is kept",
+                    "type": "string"
+                }
+            }
+        },
+        "entities.InWebhook": {
+            "type": "object",
+            "properties": {
+                "events": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/entities.OutBlog"
+                        "type": "string"
                     }
                 },
-                "status": {
+                "paused": {
+                    "type": "boolean"
+                },
+                "secret": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "entities.JWT": {
+            "type": "object",
+            "properties": {
+                "jwt": {
+                    "type": "string"
+                }
+            }
+        },
+        "entities.Media": {
+            "type": "object",
+            "properties": {
+                "alt": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "filename": {
+                    "type": "string"
+                },
+                "hash": {
+                    "description": "sha256 of file content",
+                    "type": "string"
+                },
+                "height": {
+                    "type": "integer"
+                },
+                "mime": {
+                    "type": "string"
+                },
+                "size": {
+                    "description": "bytes",
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "width": {
                     "type": "integer"
                 }
             }
         },
-        "blog_entities.RetSuccess-array_entities_OutBlogSimple": {
+        "entities.OutBlog": {
             "type": "object",
             "properties": {
-                "error": {
+                "content": {
                     "type": "string"
                 },
-                "msg": {
+                "contentMD5": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "deleted_by": {
+                    "description": "empty unless soft deleted",
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "next": {
+                    "$ref": "#/definitions/entities.SeriesPart"
+                },
+                "pined": {
+                    "type": "boolean"
+                },
+                "prev": {
+                    "$ref": "#/definitions/entities.SeriesPart"
+                },
+                "scheduledAt": {
+                    "description": "empty unless scheduled",
+                    "type": "string"
+                },
+                "series": {
+                    "description": "only filled in if the blog is part of a series,\nprev and next only consider visible blogs",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/entities.BlogSeries"
+                        }
+                    ]
+                },
+                "slug": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "tags": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/entities.OutBlogSimple"
+                        "$ref": "#/definitions/entities.Tag"
                     }
                 },
-                "status": {
+                "title": {
+                    "type": "string"
+                },
+                "topics": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.Topic"
+                    }
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "version": {
+                    "description": "bumped on every update",
                     "type": "integer"
+                },
+                "visible": {
+                    "type": "boolean"
                 }
             }
         },
-        "blog_entities.RetSuccess-array_entities_Tag": {
+        "entities.OutBlogSimple": {
             "type": "object",
             "properties": {
-                "error": {
+                "content": {
                     "type": "string"
                 },
-                "msg": {
+                "contentMD5": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "deleted_by": {
+                    "description": "empty unless soft deleted",
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "part": {
+                    "type": "integer"
+                },
+                "pined": {
+                    "type": "boolean"
+                },
+                "scheduledAt": {
+                    "description": "empty unless scheduled",
+                    "type": "string"
+                },
+                "series": {
+                    "description": "series slug and position, empty if not part of a series",
+                    "type": "string"
+                },
+                "slug": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "tags": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/entities.Tag"
+                        "type": "string"
                     }
                 },
-                "status": {
+                "title": {
+                    "type": "string"
+                },
+                "topics": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "version": {
+                    "description": "bumped on every update",
                     "type": "integer"
+                },
+                "visible": {
+                    "type": "boolean"
                 }
             }
         },
-        "blog_entities.RetSuccess-array_entities_Topic": {
+        "entities.OutBulk": {
             "type": "object",
             "properties": {
-                "error": {
+                "committed": {
+                    "type": "boolean"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.BulkResult"
+                    }
+                }
+            }
+        },
+        "entities.OutComment": {
+            "type": "object",
+            "properties": {
+                "author": {
                     "type": "string"
                 },
-                "msg": {
+                "blogID": {
+                    "type": "integer"
+                },
+                "content": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "html": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "parentID": {
+                    "description": "0 for top level comments",
+                    "type": "integer"
+                },
+                "replies": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/entities.Topic"
+                        "$ref": "#/definitions/entities.OutComment"
                     }
                 },
                 "status": {
+                    "description": "pending, approved or rejected",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "entities.OutMedia": {
+            "type": "object",
+            "properties": {
+                "alt": {
+                    "type": "string"
+                },
+                "blogs": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "filename": {
+                    "type": "string"
+                },
+                "hash": {
+                    "description": "sha256 of file content",
+                    "type": "string"
+                },
+                "height": {
+                    "type": "integer"
+                },
+                "mime": {
+                    "type": "string"
+                },
+                "size": {
+                    "description": "bytes",
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "width": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entities.OutSeries": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
                     "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "parts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.SeriesPart"
+                    }
+                },
+                "slug": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
                 }
             }
         },
-        "blog_entities.RetSuccess-entities_JWT": {
+        "entities.PopularBlog": {
             "type": "object",
             "properties": {
-                "error": {
+                "description": {
                     "type": "string"
                 },
-                "msg": {
-                    "$ref": "#/definitions/entities.JWT"
+                "id": {
+                    "type": "integer"
                 },
-                "status": {
+                "slug": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "views": {
                     "type": "integer"
                 }
             }
         },
-        "blog_entities.RetSuccess-entities_OutBlog": {
+        "entities.PreviewLink": {
             "type": "object",
             "properties": {
-                "error": {
+                "blogID": {
+                    "type": "integer"
+                },
+                "created_at": {
                     "type": "string"
                 },
-                "msg": {
-                    "$ref": "#/definitions/entities.OutBlog"
+                "expiresAt": {
+                    "type": "string"
                 },
-                "status": {
+                "id": {
                     "type": "integer"
+                },
+                "note": {
+                    "description": "who or what the link is for",
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
                 }
             }
         },
-        "blog_entities.RetSuccess-entities_RowsAffected": {
+        "entities.ReferrerStats": {
             "type": "object",
             "properties": {
-                "error": {
+                "referrer": {
                     "type": "string"
                 },
-                "msg": {
-                    "$ref": "#/definitions/entities.RowsAffected"
-                },
-                "status": {
+                "views": {
                     "type": "integer"
                 }
             }
         },
-        "blog_entities.RetSuccess-entities_Tag": {
+        "entities.ReqInBlog": {
             "type": "object",
             "properties": {
-                "error": {
+                "content": {
                     "type": "string"
                 },
-                "msg": {
-                    "$ref": "#/definitions/entities.Tag"
+                "description": {
+                    "type": "string"
                 },
-                "status": {
+                "part": {
+                    "description": "position in series, 0 means append to the end",
+                    "type": "integer"
+                },
+                "pined": {
+                    "type": "boolean"
+                },
+                "series": {
+                    "description": "series id, 0 means not part of a series",
                     "type": "integer"
+                },
+                "status": {
+                    "description": "only used on create, overrides visible. Later changes go through transitions,\nexcept hiding or showing a blog with visible.",
+                    "type": "string",
+                    "enum": [
+                        "draft",
+                        "in_review",
+                        "published",
+                        "archived"
+                    ]
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "title": {
+                    "type": "string"
+                },
+                "topics": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "visible": {
+                    "type": "boolean"
                 }
             }
         },
-        "blog_entities.RetSuccess-entities_Topic": {
+        "entities.RetFailed": {
             "type": "object",
             "properties": {
                 "error": {
                     "type": "string"
                 },
                 "msg": {
-                    "$ref": "#/definitions/entities.Topic"
+                    "type": "string"
                 },
                 "status": {
                     "type": "integer"
                 }
             }
         },
-        "blog_entities.RetSuccess-string": {
+        "entities.RowsAffected": {
             "type": "object",
             "properties": {
-                "error": {
-                    "type": "string"
-                },
-                "msg": {
-                    "type": "string"
-                },
-                "status": {
+                "affectedRows": {
                     "type": "integer"
                 }
             }
         },
-        "entities.InTag": {
+        "entities.Series": {
             "type": "object",
             "properties": {
+                "created_at": {
+                    "type": "string"
+                },
                 "description": {
                     "type": "string"
                 },
+                "id": {
+                    "type": "integer"
+                },
                 "name": {
                     "type": "string"
+                },
+                "slug": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
                 }
             }
         },
-        "entities.InTopic": {
+        "entities.SeriesPart": {
             "type": "object",
             "properties": {
                 "description": {
                     "type": "string"
                 },
-                "name": {
+                "id": {
+                    "description": "blog id",
+                    "type": "integer"
+                },
+                "part": {
+                    "description": "position in series",
+                    "type": "integer"
+                },
+                "slug": {
                     "type": "string"
-                }
-            }
-        },
-        "entities.JWT": {
-            "type": "object",
-            "properties": {
-                "jwt": {
+                },
+                "title": {
                     "type": "string"
                 }
             }
         },
-        "entities.OutBlog": {
+        "entities.Tag": {
             "type": "object",
             "properties": {
-                "content": {
-                    "type": "string"
-                },
-                "contentMD5": {
-                    "type": "string"
-                },
                 "created_at": {
                     "type": "string"
                 },
-                "deleted_at": {
-                    "type": "string"
-                },
                 "description": {
                     "type": "string"
                 },
                 "id": {
                     "type": "integer"
                 },
-                "pined": {
-                    "type": "boolean"
-                },
-                "slug": {
+                "name": {
                     "type": "string"
                 },
-                "tags": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/entities.Tag"
-                    }
-                },
-                "title": {
+                "slug": {
                     "type": "string"
                 },
-                "topics": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/entities.Topic"
-                    }
-                },
                 "updated_at": {
                     "type": "string"
                 },
-                "visible": {
-                    "type": "boolean"
+                "version": {
+                    "description": "bumped on every update",
+                    "type": "integer"
                 }
             }
         },
-        "entities.OutBlogSimple": {
+        "entities.TagPatch": {
             "type": "object",
             "properties": {
-                "content": {
+                "description": {
+                    "description": "null clears it",
                     "type": "string"
                 },
-                "contentMD5": {
+                "name": {
                     "type": "string"
-                },
+                }
+            }
+        },
+        "entities.Topic": {
+            "type": "object",
+            "properties": {
                 "created_at": {
                     "type": "string"
                 },
-                "deleted_at": {
-                    "type": "string"
-                },
                 "description": {
                     "type": "string"
                 },
                 "id": {
                     "type": "integer"
                 },
-                "pined": {
-                    "type": "boolean"
-                },
-                "slug": {
+                "name": {
                     "type": "string"
                 },
-                "tags": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
-                },
-                "title": {
+                "slug": {
                     "type": "string"
                 },
-                "topics": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
-                },
                 "updated_at": {
                     "type": "string"
                 },
-                "visible": {
-                    "type": "boolean"
+                "version": {
+                    "description": "bumped on every update",
+                    "type": "integer"
                 }
             }
         },
-        "entities.ReqInBlog": {
+        "entities.TopicPatch": {
             "type": "object",
             "properties": {
-                "content": {
-                    "type": "string"
-                },
                 "description": {
+                    "description": "null clears it",
                     "type": "string"
                 },
-                "pined": {
-                    "type": "boolean"
-                },
-                "tags": {
-                    "type": "array",
-                    "items": {
-                        "type": "integer"
-                    }
-                },
-                "title": {
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "entities.Webhook": {
+            "type": "object",
+            "properties": {
+                "created_at": {
                     "type": "string"
                 },
-                "topics": {
+                "events": {
                     "type": "array",
                     "items": {
-                        "type": "integer"
+                        "type": "string"
                     }
                 },
-                "visible": {
+                "id": {
+                    "type": "integer"
+                },
+                "paused": {
                     "type": "boolean"
-                }
-            }
-        },
-        "entities.RetFailed": {
-            "type": "object",
-            "properties": {
-                "error": {
+                },
+                "secret": {
                     "type": "string"
                 },
-                "msg": {
+                "updated_at": {
                     "type": "string"
                 },
-                "status": {
-                    "type": "integer"
+                "url": {
+                    "type": "string"
                 }
             }
         },
-        "entities.RowsAffected": {
+        "entities.WebhookDelivery": {
             "type": "object",
             "properties": {
-                "affectedRows": {
+                "attempts": {
                     "type": "integer"
-                }
-            }
-        },
-        "entities.Tag": {
-            "type": "object",
-            "properties": {
+                },
                 "created_at": {
                     "type": "string"
                 },
-                "description": {
+                "error": {
                     "type": "string"
                 },
+                "event": {
+                    "$ref": "#/definitions/entities.WebhookEvent"
+                },
                 "id": {
                     "type": "integer"
                 },
-                "name": {
+                "nextAttemptAt": {
                     "type": "string"
                 },
-                "slug": {
+                "responseStatus": {
+                    "description": "0 if no response was received",
+                    "type": "integer"
+                },
+                "status": {
                     "type": "string"
                 },
                 "updated_at": {
                     "type": "string"
+                },
+                "webhookID": {
+                    "type": "integer"
                 }
             }
         },
-        "entities.Topic": {
+        "entities.WebhookEvent": {
             "type": "object",
             "properties": {
                 "created_at": {
                     "type": "string"
                 },
-                "description": {
-                    "type": "string"
+                "data": {
+                    "type": "object"
                 },
                 "id": {
                     "type": "integer"
                 },
-                "name": {
-                    "type": "string"
-                },
-                "slug": {
-                    "type": "string"
-                },
-                "updated_at": {
+                "type": {
                     "type": "string"
                 }
             }
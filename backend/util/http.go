@@ -0,0 +1,40 @@
+package util
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Client ip from the header set by the reverse proxy,
+// falls back to the remote address if the header is not configured or empty.
+// Only the last address of the header is trusted, the rest can be forged by the client.
+func ClientIP(r *http.Request, ipHeader string) string {
+	if ipHeader != "" {
+		if values := r.Header.Values(ipHeader); len(values) > 0 {
+			addresses := strings.Split(values[len(values)-1], ",")
+			if ip := strings.TrimSpace(addresses[len(addresses)-1]); ip != "" {
+				return ip
+			}
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+type requestIDKey struct{}
+
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// Empty if the request didn't go through the request id middleware
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}